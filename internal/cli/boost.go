@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lazypower/continuity/internal/hooks"
+	"github.com/lazypower/continuity/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var boostClear bool
+
+var boostCmd = &cobra.Command{
+	Use:   "boost <uri> <value>",
+	Short: "Hand-set a memory's relevance",
+	Long: `Set a memory's relevance by hand, from 0 (buried) to 1 (top). Values outside
+that range are clamped.
+
+Relevance normally decays over time and jumps back to 1 whenever a memory is
+retrieved. A hand-set value overrides both: a memory you demoted stays demoted
+when it's next retrieved, and one you boosted doesn't fade. tree and
+profile --verbose mark hand-set memories. --clear hands the memory back to
+decay.
+
+Examples:
+  continuity boost mem://user/preferences/old-editor 0.1   # demote
+  continuity boost mem://agent/patterns/retry-jitter 1     # keep at the top
+  continuity boost mem://user/preferences/old-editor --clear`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runBoost,
+}
+
+func init() {
+	boostCmd.Flags().BoolVar(&boostClear, "clear", false, "Drop the override and let decay manage relevance again")
+}
+
+func runBoost(cmd *cobra.Command, args []string) error {
+	uri := strings.TrimSpace(args[0])
+	if !strings.HasPrefix(uri, "mem://") {
+		return fmt.Errorf("invalid URI %q: must start with mem://", uri)
+	}
+	req := map[string]any{"uri": uri}
+	switch {
+	case boostClear && len(args) == 2:
+		return fmt.Errorf("give a value or --clear, not both")
+	case boostClear:
+		req["clear"] = true
+	case len(args) == 2:
+		v, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return fmt.Errorf("invalid relevance %q: want a number from 0 to 1", args[1])
+		}
+		req["relevance"] = v
+	default:
+		return fmt.Errorf("a relevance value (0-1) or --clear is required")
+	}
+
+	client := hooks.NewClient()
+	if !client.Healthy() {
+		return fmt.Errorf("continuity server is not running — start it with: continuity serve")
+	}
+	warnIfSkewed()
+
+	body, _ := json.Marshal(req)
+	data, err := client.Post("/api/memories/relevance", body)
+	var resp struct {
+		Status    string  `json:"status"`
+		URI       string  `json:"uri"`
+		Relevance float64 `json:"relevance"`
+		Error     string  `json:"error"`
+	}
+	if jerr := json.Unmarshal(data, &resp); jerr != nil {
+		if err != nil {
+			return fmt.Errorf("boost: %w", err)
+		}
+		return fmt.Errorf("parse response: %w", jerr)
+	}
+	if resp.Error != "" {
+		fmt.Fprintf(os.Stderr, "error: %s\n", resp.Error)
+		os.Exit(1)
+	}
+	if resp.Status == "set" {
+		fmt.Printf("set: %s relevance %.2f (hand-set; decay won't change it)\n", resp.URI, resp.Relevance)
+		return nil
+	}
+	fmt.Printf("%s: %s\n", resp.Status, resp.URI)
+	return nil
+}
+
+// relevanceMark is the suffix tree and profile print after a memory whose
+// relevance was hand-set, and "" otherwise.
+func relevanceMark(n store.MemNode) string {
+	if !n.IsRelevanceSet() {
+		return ""
+	}
+	return fmt.Sprintf(" [relevance %.2f, hand-set]", n.Relevance)
+}
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lazypower/continuity/internal/engine"
+	"github.com/lazypower/continuity/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	calibratePairs          string
+	calibrateSamples        int
+	calibrateEmbedderChoice string
+)
+
+var calibrateCmd = &cobra.Command{
+	Use:   "calibrate",
+	Short: "Suggest a similarity threshold for the current embedder",
+	Long: `Embed pairs of memories known to be duplicates and pairs known to be
+distinct, print how the embedder scores each kind, and suggest the cosine
+threshold that best separates them — the number to pass to dedup --threshold
+or clusters --threshold.
+
+--pairs reads labelled pairs from a jsonl file (or - for stdin), one per line:
+
+  {"a": "Prefers tabs", "b": "Likes tabs over spaces", "duplicate": true}
+
+Either side may be a mem:// URI, which stands for that memory's L0. Without
+--pairs, pairs are generated from existing memories: each L0 against a light
+rewording of itself (duplicate) and against another memory's L0 (distinct).
+Generated pairs are a rough guide; labelled ones are better.
+
+Reads the database directly and writes nothing.`,
+	Args: cobra.NoArgs,
+	RunE: runCalibrate,
+}
+
+func init() {
+	calibrateCmd.Flags().StringVar(&calibratePairs, "pairs", "", "jsonl file of labelled pairs (- for stdin); default generates pairs from existing memories")
+	calibrateCmd.Flags().IntVar(&calibrateSamples, "samples", 50, "Memories to generate pairs from when --pairs is not set (0: all)")
+	calibrateCmd.Flags().StringVar(&calibrateEmbedderChoice, "embedder", "auto", "Embedder to use: ollama, tfidf, or auto (probe Ollama, fall back to tfidf)")
+}
+
+func runCalibrate(cmd *cobra.Command, args []string) error {
+	db, err := openDB()
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer db.Close()
+
+	lc, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	emb, desc, err := dedupEmbedder(calibrateEmbedderChoice, lc.LLM)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Embedder: %s\n", desc)
+
+	var pairs []engine.CalibrationPair
+	if calibratePairs != "" {
+		in := io.Reader(os.Stdin)
+		if calibratePairs != "-" {
+			f, err := os.Open(calibratePairs)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+		if pairs, err = readCalibrationPairs(db, in); err != nil {
+			return err
+		}
+		fmt.Printf("Pairs: %d from %s\n", len(pairs), calibratePairs)
+	} else {
+		leaves, err := db.ListLeaves()
+		if err != nil {
+			return fmt.Errorf("list leaves: %w", err)
+		}
+		pairs = engine.PerturbPairs(leaves, calibrateSamples)
+		if len(pairs) == 0 {
+			return fmt.Errorf("not enough memories to generate pairs from — pass --pairs")
+		}
+		fmt.Printf("Pairs: %d generated from %d memories\n", len(pairs), len(pairs)/2)
+	}
+
+	report, err := engine.Calibrate(context.Background(), emb, pairs)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	printSimilarityStats("Duplicates", report.Duplicates)
+	printSimilarityStats("Distinct", report.Distinct)
+	fmt.Println()
+	fmt.Printf("Suggested threshold: %.2f (%.0f%% of pairs classified correctly)\n", report.Suggested, report.Accuracy*100)
+	fmt.Printf("Current threshold:   %.2f (%.0f%%)\n", report.Current, report.CurrentAccuracy*100)
+	if !report.Separable {
+		fmt.Println("\nThe two kinds overlap: no threshold gets every pair right. Check the pairs, or expect some misses either way.")
+	}
+	return nil
+}
+
+// readCalibrationPairs parses labelled pairs, one JSON object per line,
+// resolving mem:// sides to the memory's L0.
+func readCalibrationPairs(db *store.DB, r io.Reader) ([]engine.CalibrationPair, error) {
+	var pairs []engine.CalibrationPair
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		var p engine.CalibrationPair
+		if err := json.Unmarshal([]byte(text), &p); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		for _, side := range []*string{&p.A, &p.B} {
+			if !strings.HasPrefix(*side, "mem://") {
+				continue
+			}
+			node, err := db.GetNodeByURI(*side)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: look up %s: %w", line, *side, err)
+			}
+			if node == nil || node.L0Abstract == "" {
+				return nil, fmt.Errorf("line %d: no memory with an L0 at %s", line, *side)
+			}
+			*side = node.L0Abstract
+		}
+		if p.A == "" || p.B == "" {
+			return nil, fmt.Errorf("line %d: both a and b are required", line)
+		}
+		pairs = append(pairs, p)
+	}
+	return pairs, sc.Err()
+}
+
+func printSimilarityStats(label string, s engine.SimilarityStats) {
+	fmt.Printf("%-11s %4d pairs   min %.2f  mean %.2f  max %.2f\n", label+":", s.Count, s.Min, s.Mean, s.Max)
+	var bins [10]int
+	for _, v := range s.Scores {
+		b := int(v * 10)
+		if b < 0 {
+			b = 0
+		}
+		if b > 9 {
+			b = 9
+		}
+		bins[b]++
+	}
+	for i, n := range bins {
+		if n > 0 {
+			fmt.Printf("  %.1f–%.1f  %4d  %s\n", float64(i)/10, float64(i+1)/10, n, decayBar(n, s.Count))
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/lazypower/continuity/internal/engine"
+	"github.com/spf13/cobra"
+)
+
+var clustersThreshold float64
+
+var clustersCmd = &cobra.Command{
+	Use:   "clusters",
+	Short: "Show groups of similar memories (read-only)",
+	Long: `Group leaf memories by vector similarity, per category, and print each
+cluster's members with a representative L0. Uses the same clustering as
+` + "`dedup`" + `, so at the same threshold it shows exactly what dedup would merge —
+but it never deletes, embeds, or touches anything. Memories without a vector in
+the active embedder's space are left out.`,
+	Args: cobra.NoArgs,
+	RunE: runClusters,
+}
+
+func init() {
+	clustersCmd.Flags().Float64Var(&clustersThreshold, "threshold", 0.65, "Cosine similarity threshold (0.0-1.0); default is embedder-aware when unset")
+}
+
+func runClusters(cmd *cobra.Command, args []string) error {
+	db, err := openDB()
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer db.Close()
+
+	lc, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	emb, err := resolveActiveEmbedder(db, lc.Config)
+	if err != nil {
+		return fmt.Errorf("resolve embedder: %w", err)
+	}
+	if emb == nil {
+		return fmt.Errorf("no embedder (CONTINUITY_EMBEDDER=none) — clustering needs vectors")
+	}
+
+	threshold := clustersThreshold
+	if !cmd.Flags().Changed("threshold") {
+		threshold = engine.MatchThreshold(emb)
+	}
+
+	eng := engine.New(db, nil)
+	eng.SetEmbedder(emb)
+	clusters, err := eng.Clusters(threshold)
+	if err != nil {
+		return fmt.Errorf("clusters: %w", err)
+	}
+
+	fmt.Printf("Embedder: %s  Threshold: %.2f\n", engine.EmbedderIdentity(emb), threshold)
+	if len(clusters) == 0 {
+		fmt.Println("No clusters — every memory stands alone at this threshold.")
+		return nil
+	}
+	for _, c := range clusters {
+		fmt.Printf("\n[%s] %d memories — %s\n", c.Category, len(c.Members), c.Representative.L0Abstract)
+		for _, m := range c.Members {
+			marker := " "
+			if m.ID == c.Representative.ID {
+				marker = "*"
+			}
+			fmt.Printf("  %s %s\n", marker, m.URI)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lazypower/continuity/internal/config"
+	"github.com/lazypower/continuity/internal/hooks"
+	"github.com/lazypower/continuity/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// envConfigPath points at an alternate config.toml (default
+// ~/.continuity/config.toml).
+const envConfigPath = "CONTINUITY_CONFIG"
+
+// loadedConfig is the effective configuration plus where it came from.
+type loadedConfig struct {
+	config.Config
+	Path  string   // config.toml consulted
+	Found bool     // whether Path existed
+	Env   []string // env vars that overrode file/default values
+
+	// KeySource is where the Anthropic key came from (config.KeySource*), or
+	// "" when there is none or resolveKey hasn't run.
+	KeySource string
+}
+
+// loadConfig resolves configuration the way `serve` does: defaults, then
+// config.toml, then environment overrides (ANTHROPIC_API_KEY and the
+// CONTINUITY_* server variables). The Anthropic key is left unresolved;
+// commands that use the LLM call resolveKey.
+func loadConfig() (loadedConfig, error) {
+	path := strings.TrimSpace(os.Getenv(envConfigPath))
+	if path == "" {
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			return loadedConfig{}, err
+		}
+	}
+	cfg, found, err := config.Load(path)
+	if err != nil {
+		return loadedConfig{}, fmt.Errorf("load config: %w", err)
+	}
+	lc := loadedConfig{Config: cfg, Path: path, Found: found}
+
+	if os.Getenv("ANTHROPIC_API_KEY") != "" {
+		// The env var still selects the anthropic provider, as it always has,
+		// but its key is the lowest-precedence source.
+		lc.LLM.Provider = "anthropic"
+		lc.Env = append(lc.Env, "ANTHROPIC_API_KEY")
+	}
+	if err := applyServeEnvOverrides(&lc.Config); err != nil {
+		return loadedConfig{}, err
+	}
+	for _, name := range []string{envServeDB, envServeBind, envServePort} {
+		if strings.TrimSpace(os.Getenv(name)) != "" {
+			lc.Env = append(lc.Env, name)
+		}
+	}
+	return lc, nil
+}
+
+// resolveKey sets lc.LLM.AnthropicKey by config.LLMConfig.ResolveAnthropicKey's
+// precedence, where ANTHROPIC_API_KEY is last, and records the source. Only
+// commands that talk to the LLM call it, so a database command never reads a
+// key file or the keychain.
+func (lc *loadedConfig) resolveKey() error {
+	source, err := lc.LLM.ResolveAnthropicKey(os.Getenv("ANTHROPIC_API_KEY"))
+	if err != nil {
+		return fmt.Errorf("resolve anthropic key: %w", err)
+	}
+	lc.KeySource = source
+	return nil
+}
+
+// resolveDBPath returns cfg's database path, or the default when unset.
+func resolveDBPath(cfg config.Config) (string, error) {
+	if cfg.Database.Path != "" {
+		return cfg.Database.Path, nil
+	}
+	return store.DefaultDBPath()
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show the effective configuration",
+	Long: `Show the configuration continuity actually runs with: defaults, overlaid
+with config.toml, overlaid with environment variables — the same resolution
+` + "`serve`" + ` performs. API keys are redacted. The output is valid config.toml.`,
+	Args: cobra.NoArgs,
+	RunE: runConfig,
+}
+
+func runConfig(cmd *cobra.Command, args []string) error {
+	lc, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if err := lc.resolveKey(); err != nil {
+		return err
+	}
+	dbPath, err := resolveDBPath(lc.Config)
+	if err != nil {
+		return fmt.Errorf("resolve db path: %w", err)
+	}
+
+	status := "loaded"
+	if !lc.Found {
+		status = "not found, using defaults"
+	}
+	fmt.Printf("# config file: %s (%s)\n", lc.Path, status)
+	if len(lc.Env) > 0 {
+		fmt.Printf("# env overrides: %s\n", strings.Join(lc.Env, ", "))
+	}
+	if lc.KeySource != "" {
+		fmt.Printf("# api key:     anthropic, from %s\n", lc.KeySource)
+	}
+	fmt.Printf("# database:    %s\n", dbPath)
+	fmt.Printf("# server URL:  %s\n", hooks.ResolveServerURL())
+	fmt.Printf("# embedder:    %s (%s)\n", resolveEmbedderChoice(lc.LLM.OllamaURL, lc.LLM.EmbeddingModel), envServeEmbedder)
+	fmt.Println()
+	return config.Write(os.Stdout, lc.Redacted())
+}
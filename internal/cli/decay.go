@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lazypower/continuity/internal/config"
+	"github.com/lazypower/continuity/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	decayRun     bool
+	decayVerbose bool
+)
+
+var decayCmd = &cobra.Command{
+	Use:   "decay",
+	Short: "Show when relevance decay last ran, or run it now",
+	Long: `Without flags, print when the scheduled relevance decay last ran.
+
+--run applies a decay pass immediately against the local database, using the
+[engine] decay settings from the config, and records it as the last run so the
+server's schedule counts from now. It prints how many memories were lowered,
+how many fell below the context threshold, and how many reached the floor;
+--verbose adds the distribution of their new relevances.`,
+	Args: cobra.NoArgs,
+	RunE: runDecay,
+}
+
+func init() {
+	decayCmd.Flags().BoolVar(&decayRun, "run", false, "Run a decay pass now")
+	decayCmd.Flags().BoolVarP(&decayVerbose, "verbose", "v", false, "With --run, print the distribution of new relevances")
+}
+
+// decayParams converts the [engine] decay settings into store.DecayParams.
+func decayParams(ec config.EngineConfig) store.DecayParams {
+	return store.DecayParams{
+		HalfLife:       time.Duration(ec.DecayHalfLifeDays) * 24 * time.Hour,
+		AccessBoost:    ec.AccessBoost,
+		AccessHalfLife: time.Duration(ec.AccessBoostHalfLifeDays) * 24 * time.Hour,
+	}
+}
+
+func runDecay(cmd *cobra.Command, args []string) error {
+	db, err := openDB()
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer db.Close()
+
+	if !decayRun {
+		last, ok, err := db.GetMeta(store.MetaLastDecay)
+		if err != nil {
+			return fmt.Errorf("read last decay: %w", err)
+		}
+		ms, perr := strconv.ParseInt(last, 10, 64)
+		if !ok || perr != nil {
+			fmt.Println("Decay has not run yet. Use --run to run it now.")
+			return nil
+		}
+		t := time.UnixMilli(ms)
+		fmt.Printf("Last decay: %s (%s ago)\n", t.Format(time.RFC3339), time.Since(t).Round(time.Minute))
+		return nil
+	}
+
+	lc, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if err := db.SetDecayParams(decayParams(lc.Engine)); err != nil {
+		return fmt.Errorf("config [engine]: %w", err)
+	}
+
+	report, err := db.DecayAllNodesReport()
+	if err != nil {
+		return fmt.Errorf("decay: %w", err)
+	}
+	if err := db.SetMeta(store.MetaLastDecay, strconv.FormatInt(time.Now().UnixMilli(), 10)); err != nil {
+		return fmt.Errorf("record last decay: %w", err)
+	}
+
+	fmt.Printf("Decayed %d of %d memories\n", report.Updated, report.Considered)
+	fmt.Printf("  below context threshold (%.1f): %d\n", store.ContextMinRelevance, report.BelowContext)
+	fmt.Printf("  at the floor (%.1f):            %d\n", store.DecayFloor, report.AtFloor)
+	if decayVerbose && report.Updated > 0 {
+		fmt.Println("\nNew relevance:")
+		for _, bin := range report.Distribution {
+			fmt.Printf("  %.1f–%.1f  %4d  %s\n", bin.Lo, bin.Hi, bin.Count, decayBar(bin.Count, report.Updated))
+		}
+	}
+	return nil
+}
+
+// decayBar scales count against total into a 20-cell bar.
+func decayBar(count, total int) string {
+	cells := 0
+	if total > 0 {
+		cells = (count*20 + total - 1) / total
+	}
+	return strings.Repeat("█", cells)
+}
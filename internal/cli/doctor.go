@@ -3,6 +3,7 @@ package cli
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"sort"
@@ -32,6 +33,7 @@ Checks:
   - active embedder + expected vector dimension
   - stored vector model/dimension distribution
   - missing vectors (leaves with no embedding)
+  - corrupt vectors (BLOB length doesn't match the stored dimension)
   - mixed-dimension vectors
   - stale vectors from an older embedder
   - a read-only retrieval smoke test (do nodes retrieve themselves?)`,
@@ -81,6 +83,7 @@ type doctorReport struct {
 	MixedDims      bool          `json:"mixed_dimensions"`
 	StaleVectors   int           `json:"stale_vectors"`
 	DimMismatch    int           `json:"dim_mismatch_vectors"`
+	CorruptVectors int           `json:"corrupt_vectors"`
 	Smoke          smokeResult   `json:"retrieval_smoke_test"`
 	Findings       []string      `json:"findings"`
 	Healthy        bool          `json:"healthy"`
@@ -118,7 +121,11 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	}
 	defer db.Close()
 
-	emb, err := resolveActiveEmbedder(db, config.Default())
+	lc, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	emb, err := resolveActiveEmbedder(db, lc.Config)
 	if err != nil {
 		return fmt.Errorf("resolve embedder: %w", err)
 	}
@@ -136,8 +143,27 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("all vectors: %w", err)
 	}
 	declared, _, _ := db.VectorIdentity()
+	corrupt, err := db.CorruptVectors()
+	if err != nil {
+		return err
+	}
 
 	rep := buildDoctorReport(emb, leaves, vectors, declared, fetchServerIdentity())
+	if len(corrupt) > 0 {
+		// AllVectors skipped these, so live leaves holding one were counted
+		// as missing above; report them as corrupt instead.
+		rep.CorruptVectors = len(corrupt)
+		live := make(map[int64]bool, len(leaves))
+		for _, n := range leaves {
+			live[n.ID] = true
+		}
+		for _, id := range corrupt {
+			if live[id] {
+				rep.MissingVectors--
+			}
+		}
+		rep.Findings, rep.Healthy = diagnose(rep)
+	}
 
 	if doctorJSON {
 		enc := json.NewEncoder(os.Stdout)
@@ -168,14 +194,18 @@ func runDoctorRepair(db *store.DB, emb engine.Embedder, apply bool, srv serverId
 		return fmt.Errorf("list leaves: %w", err)
 	}
 
-	// A leaf needs (re-)embedding if it has no vector, or a vector under a
-	// different model/dimension than the active embedder.
+	// A leaf needs (re-)embedding if it has no vector, a corrupt one, or one
+	// under a different model/dimension than the active embedder.
 	var todo []store.MemNode
 	for _, n := range leaves {
 		if n.L0Abstract == "" {
 			continue
 		}
 		v, err := db.GetVector(n.ID)
+		if errors.Is(err, store.ErrCorruptVector) {
+			todo = append(todo, n)
+			continue
+		}
 		if err != nil {
 			return fmt.Errorf("get vector %s: %w", n.URI, err)
 		}
@@ -218,8 +248,9 @@ func runDoctorRepair(db *store.DB, emb engine.Embedder, apply bool, srv serverId
 	// half-migrated.
 	ctx := context.Background()
 	type pendingWrite struct {
-		id  int64
-		vec []float64
+		id   int64
+		vec  []float64
+		text string
 	}
 	writes := make([]pendingWrite, 0, len(todo))
 	for i := range todo {
@@ -227,7 +258,7 @@ func runDoctorRepair(db *store.DB, emb engine.Embedder, apply bool, srv serverId
 		if err != nil {
 			return fmt.Errorf("embed %s: %w (no vectors were written; snapshot at %s)", todo[i].URI, err, snap)
 		}
-		writes = append(writes, pendingWrite{todo[i].ID, vec})
+		writes = append(writes, pendingWrite{todo[i].ID, vec, todo[i].L0Abstract})
 	}
 
 	// Phase 2: commit the new vectors, then rebind the identity last so a
@@ -235,7 +266,7 @@ func runDoctorRepair(db *store.DB, emb engine.Embedder, apply bool, srv serverId
 	// vectors don't fully occupy.
 	done := 0
 	for _, w := range writes {
-		if err := db.SaveVector(w.id, w.vec, emb.Model()); err != nil {
+		if err := db.SaveVectorFor(w.id, w.vec, emb.Model(), w.text); err != nil {
 			return fmt.Errorf("save vector (wrote %d/%d; snapshot at %s): %w", done, len(writes), snap, err)
 		}
 		done++
@@ -267,17 +298,35 @@ func resolveActiveEmbedder(db *store.DB, cfg config.Config) (engine.Embedder, er
 	case "none":
 		return nil, nil
 	case "ollama":
-		return engine.NewOllamaEmbedder(ollamaURL, embeddingModel, 768), nil
+		return newOllamaEmbedder(cfg.LLM, ollamaURL, embeddingModel), nil
 	case "tfidf":
-		return engine.NewHashEmbedder(0)
+		return newLexicalEmbedder(cfg.LLM)
 	default: // auto: probe Ollama, fall back to the hashed lexical embedder
 		if engine.ProbeOllama(ollamaURL, embeddingModel) {
-			return engine.NewOllamaEmbedder(ollamaURL, embeddingModel, 768), nil
+			return newOllamaEmbedder(cfg.LLM, ollamaURL, embeddingModel), nil
 		}
-		return engine.NewHashEmbedder(0)
+		return newLexicalEmbedder(cfg.LLM)
 	}
 }
 
+// newOllamaEmbedder is engine.NewOllamaEmbedder bounded by [llm]
+// embed_concurrency.
+func newOllamaEmbedder(cfg config.LLMConfig, url, model string) *engine.OllamaEmbedder {
+	emb := engine.NewOllamaEmbedder(url, model, 768)
+	emb.SetConcurrency(cfg.EmbedConcurrency)
+	return emb
+}
+
+// newLexicalEmbedder builds the hashed lexical fallback, stemmed when
+// cfg.LexicalStemming is set. serve and doctor must agree on this or doctor
+// would compare the corpus against the wrong vector space.
+func newLexicalEmbedder(cfg config.LLMConfig) (*engine.HashEmbedder, error) {
+	if cfg.LexicalStemming {
+		return engine.NewStemmedHashEmbedder(0)
+	}
+	return engine.NewHashEmbedder(0)
+}
+
 func buildDoctorReport(emb engine.Embedder, leaves []store.MemNode, vectors []store.VectorRecord, declared string, srv serverIdentity) doctorReport {
 	rep := doctorReport{
 		TotalLeaves:          len(leaves),
@@ -433,6 +482,10 @@ func diagnose(rep doctorReport) ([]string, bool) {
 		f = append(f, fmt.Sprintf("%d leaf node(s) have no embedding vector.", rep.MissingVectors))
 		healthy = false
 	}
+	if rep.CorruptVectors > 0 {
+		f = append(f, fmt.Sprintf("%d stored vector(s) are corrupt (BLOB length doesn't match their dimension) — search skips them. `continuity doctor --repair-vectors --apply` re-embeds them.", rep.CorruptVectors))
+		healthy = false
+	}
 	if rep.MixedDims {
 		f = append(f, "Stored vectors have mixed dimensions — cosine similarity is meaningless across them.")
 		healthy = false
@@ -521,6 +574,7 @@ func printDoctorReport(rep doctorReport) {
 	fmt.Printf("  mixed dimensions:   %v\n", rep.MixedDims)
 	fmt.Printf("  stale vectors:      %d\n", rep.StaleVectors)
 	fmt.Printf("  dim mismatch:       %d\n", rep.DimMismatch)
+	fmt.Printf("  corrupt vectors:    %d\n", rep.CorruptVectors)
 	fmt.Println()
 
 	s := rep.Smoke
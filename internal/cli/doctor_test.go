@@ -2,8 +2,13 @@ package cli
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/lazypower/continuity/internal/config"
+
 	"github.com/lazypower/continuity/internal/store"
 )
 
@@ -96,3 +101,31 @@ func TestDoctorRepairApplyReembedsAndRebinds(t *testing.T) {
 		t.Fatalf("apply must rebind identity, got %q ok=%v", gotID, ok)
 	}
 }
+
+func TestDedupEmbedderChoice(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	cfg := config.Default().LLM
+	cfg.OllamaURL = down.URL
+
+	if _, _, err := dedupEmbedder("ollama", cfg); err == nil {
+		t.Error("forced ollama with Ollama down should error, not fall back")
+	}
+
+	emb, desc, err := dedupEmbedder("auto", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emb.Model() != "hashtf" || !strings.Contains(desc, "reachable=false") {
+		t.Errorf("auto fallback = %s, %q; want hashtf with probe report", emb.Model(), desc)
+	}
+
+	emb, _, err = dedupEmbedder("tfidf", cfg)
+	if err != nil || emb.Model() != "hashtf" {
+		t.Errorf("tfidf = %v, %v", emb, err)
+	}
+
+	if _, _, err := dedupEmbedder("bert", cfg); err == nil {
+		t.Error("unknown choice should error")
+	}
+}
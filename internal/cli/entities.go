@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/lazypower/continuity/internal/hooks"
+	"github.com/spf13/cobra"
+)
+
+var entitiesType string
+
+var entitiesCmd = &cobra.Command{
+	Use:   "entities",
+	Short: "List structured entities (services, projects, people, ...)",
+	Long: `List the entities extraction has recognized, with their type, canonical
+name, location, and aliases. Filter by kind with --type.
+
+Only entities extracted since structured fields were introduced have them;
+older entities memories are still searchable by their free text.
+
+Examples:
+  continuity entities                  # everything, grouped by type
+  continuity entities --type service   # what services does this project use?`,
+	Args: cobra.NoArgs,
+	RunE: runEntities,
+}
+
+func init() {
+	entitiesCmd.Flags().StringVar(&entitiesType, "type", "", "Only list entities of this type (e.g. service, person, project)")
+}
+
+func runEntities(cmd *cobra.Command, args []string) error {
+	client := hooks.NewClient()
+	if !client.Healthy() {
+		return fmt.Errorf("continuity server is not running — start it with: continuity serve")
+	}
+
+	path := "/api/entities"
+	if t := strings.TrimSpace(entitiesType); t != "" {
+		path += "?type=" + url.QueryEscape(t)
+	}
+	data, err := client.Get(path)
+	if err != nil {
+		return fmt.Errorf("entities: %w", err)
+	}
+
+	var resp struct {
+		Count    int `json:"count"`
+		Entities []struct {
+			URI      string   `json:"uri"`
+			Type     string   `json:"type"`
+			Name     string   `json:"name"`
+			Location string   `json:"location"`
+			Aliases  []string `json:"aliases"`
+		} `json:"entities"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+
+	if resp.Count == 0 {
+		if entitiesType != "" {
+			fmt.Printf("No entities of type %q.\n", entitiesType)
+		} else {
+			fmt.Println("No structured entities yet.")
+		}
+		return nil
+	}
+
+	lastType := ""
+	for _, e := range resp.Entities {
+		if e.Type != lastType {
+			if lastType != "" {
+				fmt.Println()
+			}
+			fmt.Printf("%s:\n", e.Type)
+			lastType = e.Type
+		}
+		line := "  " + e.Name
+		if e.Location != "" {
+			line += "  (" + e.Location + ")"
+		}
+		if len(e.Aliases) > 0 {
+			line += "  aka " + strings.Join(e.Aliases, ", ")
+		}
+		fmt.Println(line)
+		fmt.Printf("    %s\n", e.URI)
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lazypower/continuity/internal/store"
+)
+
+var (
+	exportFormat  string
+	exportOutput  string
+	exportSession string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export every live memory as a readable document",
+	Long: `Export every live memory in one document, for periodic review or for
+pasting into an agent that doesn't have continuity installed.
+
+--format md (the default) writes Markdown: the relational profile first, then
+one section per category with each memory as a heading, its summary (L0), and
+its body (L1). Detail (L2) and retracted memories are left out.
+
+--format jsonl writes one JSON object per line with everything about each
+memory, retracted ones included, and its vector base64-encoded. It streams, so
+memory use stays flat however large the store, and ` + "`continuity import`" + `
+reads it back.
+
+--format csv writes one row per live memory for spreadsheet analysis: uri,
+category, l0, relevance, access_count, created_at, updated_at, and
+source_session, under a header row. Times are RFC 3339 in UTC. A text field
+starting with =, +, -, or @ gets a leading ' so a spreadsheet shows it rather
+than evaluating it as a formula.
+
+Session notes belong to the session that wrote them, so only those of
+--session (default: $CONTINUITY_SESSION_ID) are exported.
+
+Reads the database directly; the server need not be running.
+
+Examples:
+  continuity export > memories.md
+  continuity export --format md -o ~/review/memories.md
+  continuity export --format jsonl -o backup.jsonl
+  continuity export --format csv -o memories.csv`,
+	Args: cobra.NoArgs,
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", "md", "Output format: md, jsonl, or csv")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write to this file instead of stdout")
+	exportCmd.Flags().StringVar(&exportSession, "session", os.Getenv("CONTINUITY_SESSION_ID"), "Include this session's session notes")
+}
+
+// exportCategoryOrder is the section order of a Markdown export: who the
+// user is first, then how they work, then what happened. Categories not
+// listed follow alphabetically.
+var exportCategoryOrder = []string{
+	"profile", "preferences", "feedback", "constraints", "patterns", "cases",
+	"events", "entities", "reference", "moments",
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	if exportFormat != "md" && exportFormat != "jsonl" && exportFormat != "csv" {
+		return fmt.Errorf("invalid --format %q (valid: md, jsonl, csv)", exportFormat)
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer db.Close()
+
+	out := io.Writer(os.Stdout)
+	if exportOutput != "" {
+		// An export is the whole memory store in plain text; keep it
+		// owner-only like the database itself, in every format.
+		f, err := os.OpenFile(exportOutput, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", exportOutput, err)
+		}
+		defer f.Close()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+
+	session := exportSession
+	if session != "" {
+		if session, err = db.SessionKey(session, ""); err != nil {
+			return err
+		}
+	}
+
+	var count int
+	if exportFormat == "jsonl" {
+		if count, err = db.ExportJSONL(w, session); err != nil {
+			return fmt.Errorf("export: %w", err)
+		}
+	} else {
+		all, err := db.ListLeaves()
+		if err != nil {
+			return err
+		}
+		var leaves []store.MemNode
+		for _, n := range all {
+			if !n.IsForeignSessionNote(session) {
+				leaves = append(leaves, n)
+			}
+		}
+		if exportFormat == "csv" {
+			if err := writeCSVExport(w, leaves); err != nil {
+				return fmt.Errorf("write export: %w", err)
+			}
+		} else {
+			writeMarkdownExport(w, leaves, time.Now())
+		}
+		count = len(leaves)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write export: %w", err)
+	}
+	if exportOutput != "" {
+		fmt.Fprintf(os.Stderr, "exported %d memories to %s\n", count, exportOutput)
+	}
+	return nil
+}
+
+// writeMarkdownExport renders leaves as one Markdown document. The
+// relational profile gets its own section up front; every other leaf is
+// grouped under its category in exportCategoryOrder, sorted by URI so
+// successive exports diff cleanly.
+func writeMarkdownExport(w io.Writer, leaves []store.MemNode, now time.Time) {
+	fmt.Fprintln(w, "# Continuity memory export")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Exported %s — %d memories.\n", now.Format("2006-01-02 15:04 MST"), len(leaves))
+
+	byCategory := map[string][]store.MemNode{}
+	for _, n := range leaves {
+		if n.URI == "mem://user/profile/communication" {
+			if body := strings.TrimSpace(n.L1Overview); body != "" {
+				fmt.Fprintln(w)
+				fmt.Fprintln(w, "## Relational profile")
+				fmt.Fprintln(w)
+				fmt.Fprintln(w, body)
+			}
+			continue
+		}
+		byCategory[n.Category] = append(byCategory[n.Category], n)
+	}
+
+	for _, cat := range exportCategories(byCategory) {
+		nodes := byCategory[cat]
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].URI < nodes[j].URI })
+
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "## %s (%d)\n", titleCase(cat), len(nodes))
+		for _, n := range nodes {
+			fmt.Fprintln(w)
+			fmt.Fprintf(w, "### %s\n", n.URI[strings.LastIndexByte(n.URI, '/')+1:])
+			fmt.Fprintln(w)
+			fmt.Fprintf(w, "`%s`\n", n.URI)
+			if l0 := strings.TrimSpace(n.L0Abstract); l0 != "" {
+				fmt.Fprintln(w)
+				fmt.Fprintf(w, "**%s**\n", l0)
+			}
+			if l1 := strings.TrimSpace(n.L1Overview); l1 != "" && l1 != strings.TrimSpace(n.L0Abstract) {
+				fmt.Fprintln(w)
+				fmt.Fprintln(w, l1)
+			}
+		}
+	}
+}
+
+// csvExportHeader names the columns of a CSV export.
+var csvExportHeader = []string{"uri", "category", "l0", "relevance", "access_count", "created_at", "updated_at", "source_session"}
+
+// writeCSVExport writes leaves as CSV, one row per leaf sorted by URI, under
+// csvExportHeader. encoding/csv quotes any field holding a comma, quote, or
+// newline; text fields also go through csvText.
+func writeCSVExport(w io.Writer, leaves []store.MemNode) error {
+	sorted := append([]store.MemNode(nil), leaves...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].URI < sorted[j].URI })
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvExportHeader); err != nil {
+		return err
+	}
+	for _, n := range sorted {
+		if err := cw.Write([]string{
+			csvText(n.URI),
+			csvText(n.Category),
+			csvText(n.L0Abstract),
+			strconv.FormatFloat(n.Relevance, 'f', -1, 64),
+			strconv.Itoa(n.AccessCount),
+			time.UnixMilli(n.CreatedAt).UTC().Format(time.RFC3339),
+			time.UnixMilli(n.UpdatedAt).UTC().Format(time.RFC3339),
+			csvText(n.SourceSession),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvText defuses a field a spreadsheet would read as a formula: a memory's
+// text is whatever a transcript said, so "=HYPERLINK(...)" must not run when
+// the export is opened. A leading ' makes the cell plain text.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// exportCategories returns the categories present in byCategory, known ones
+// in exportCategoryOrder and the rest alphabetically after them.
+func exportCategories(byCategory map[string][]store.MemNode) []string {
+	var cats []string
+	known := map[string]bool{}
+	for _, c := range exportCategoryOrder {
+		known[c] = true
+		if len(byCategory[c]) > 0 {
+			cats = append(cats, c)
+		}
+	}
+	var rest []string
+	for c := range byCategory {
+		if !known[c] {
+			rest = append(rest, c)
+		}
+	}
+	sort.Strings(rest)
+	return append(cats, rest...)
+}
+
+// titleCase upper-cases the first letter of a category name for a heading.
+func titleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
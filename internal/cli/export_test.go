@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lazypower/continuity/internal/store"
+)
+
+func TestWriteMarkdownExport(t *testing.T) {
+	leaves := []store.MemNode{
+		{URI: "mem://user/events/moved-to-postgres", Category: "events", L0Abstract: "Moved to Postgres", L1Overview: "Moved the job queue from Redis to Postgres."},
+		{URI: "mem://user/profile/communication", Category: "profile", L1Overview: "Prefers terse answers."},
+		{URI: "mem://user/preferences/tabs", Category: "preferences", L0Abstract: "Uses tabs", L1Overview: "Uses tabs"},
+		{URI: "mem://user/preferences/devbox", Category: "preferences", L0Abstract: "Uses devbox", L1Overview: "Uses devbox for tooling."},
+	}
+	var b strings.Builder
+	writeMarkdownExport(&b, leaves, time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC))
+	out := b.String()
+
+	order := []string{
+		"# Continuity memory export",
+		"## Relational profile",
+		"Prefers terse answers.",
+		"## Preferences (2)",
+		"### devbox",
+		"**Uses devbox**",
+		"Uses devbox for tooling.",
+		"### tabs",
+		"## Events (1)",
+		"`mem://user/events/moved-to-postgres`",
+	}
+	last := -1
+	for _, s := range order {
+		i := strings.Index(out, s)
+		if i < 0 {
+			t.Fatalf("export missing %q:\n%s", s, out)
+		}
+		if i < last {
+			t.Errorf("%q out of order:\n%s", s, out)
+		}
+		last = i
+	}
+	if strings.Contains(out, "### communication") {
+		t.Error("the relational profile should not repeat under its category")
+	}
+	if strings.Count(out, "Uses tabs") != 1 {
+		t.Error("an L1 identical to its L0 should not be printed twice")
+	}
+}
+
+func TestWriteCSVExport(t *testing.T) {
+	leaves := []store.MemNode{
+		{URI: "mem://user/preferences/tabs", Category: "preferences", L0Abstract: "Uses tabs", Relevance: 0.5, AccessCount: 3, CreatedAt: 0, UpdatedAt: 60000, SourceSession: "s1"},
+		{URI: "mem://user/events/launch", Category: "events", L0Abstract: "Launched, finally: \"v1\"\nafter a long week", Relevance: 1},
+		{URI: "mem://user/patterns/formula", Category: "patterns", L0Abstract: "=HYPERLINK(\"http://x\")", Relevance: 1, SourceSession: "@s2"},
+	}
+	var b strings.Builder
+	if err := writeCSVExport(&b, leaves); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(b.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not valid CSV: %v\n%s", err, b.String())
+	}
+	want := [][]string{
+		{"uri", "category", "l0", "relevance", "access_count", "created_at", "updated_at", "source_session"},
+		{"mem://user/events/launch", "events", "Launched, finally: \"v1\"\nafter a long week", "1", "0", "1970-01-01T00:00:00Z", "1970-01-01T00:00:00Z", ""},
+		{"mem://user/patterns/formula", "patterns", "'=HYPERLINK(\"http://x\")", "1", "0", "1970-01-01T00:00:00Z", "1970-01-01T00:00:00Z", "'@s2"},
+		{"mem://user/preferences/tabs", "preferences", "Uses tabs", "0.5", "3", "1970-01-01T00:00:00Z", "1970-01-01T00:01:00Z", "s1"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q\nwant %q", rows, want)
+	}
+}
+
+func TestCheckJSONLines(t *testing.T) {
+	for in, ok := range map[string]bool{
+		"":                           true,
+		"\n{\"uri\":\"mem://x\"}\n":  true,
+		"SQLite format 3\x00...":     false,
+		`[{"uri":"mem://x"}]`:        false,
+		"# Continuity memory export": false,
+	} {
+		if err := checkJSONLines(bufio.NewReader(strings.NewReader(in))); (err == nil) != ok {
+			t.Errorf("checkJSONLines(%q) = %v, want ok=%v", in, err, ok)
+		}
+	}
+}
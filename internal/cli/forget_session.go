@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lazypower/continuity/internal/hooks"
+	"github.com/spf13/cobra"
+)
+
+var forgetSessionMemories bool
+
+var forgetSessionCmd = &cobra.Command{
+	Use:   "forget-session <session-id>",
+	Short: "Delete a session and what was recorded under it",
+	Long: `Delete a finished session: its row, observations, context injections, record
+of the memories it wrote, and any failed extraction. Memories it wrote stay
+but no longer name it as their source. An active session is refused.
+
+With --memories the forgetting cascades to the memories the session created.
+A memory another session also wrote, or one that is pinned or retracted, is
+kept and listed.
+
+Examples:
+  continuity forget-session 4f1c2a...
+  continuity forget-session 4f1c2a... --memories`,
+	Args: cobra.ExactArgs(1),
+	RunE: runForgetSession,
+}
+
+func init() {
+	forgetSessionCmd.Flags().BoolVar(&forgetSessionMemories, "memories", false, "Also delete the memories only this session created")
+}
+
+func runForgetSession(cmd *cobra.Command, args []string) error {
+	client := hooks.NewClient()
+	if !client.Healthy() {
+		return fmt.Errorf("continuity server is not running — start it with: continuity serve")
+	}
+	warnIfSkewed()
+
+	body, _ := json.Marshal(map[string]any{"session_id": strings.TrimSpace(args[0]), "memories": forgetSessionMemories})
+	data, err := client.Post("/api/sessions/forget", body)
+	if err != nil {
+		return fmt.Errorf("forget session: %w", err)
+	}
+	var rep struct {
+		SessionID string   `json:"session_id"`
+		Deleted   []string `json:"deleted"`
+		Kept      []string `json:"kept"`
+	}
+	if err := json.Unmarshal(data, &rep); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	fmt.Printf("forgot session %s\n", rep.SessionID)
+	for _, uri := range rep.Deleted {
+		fmt.Printf("  deleted %s\n", uri)
+	}
+	for _, uri := range rep.Kept {
+		fmt.Printf("  kept    %s (written by another session, pinned, or retracted)\n", uri)
+	}
+	return nil
+}
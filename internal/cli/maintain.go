@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/lazypower/continuity/internal/hooks"
+	"github.com/lazypower/continuity/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var maintainCheckpointOnly bool
+
+var maintainCmd = &cobra.Command{
+	Use:   "maintain",
+	Short: "Checkpoint the WAL and VACUUM the database",
+	Long: `Fold the write-ahead log back into the database and truncate it, then
+VACUUM to reclaim the pages deleted memories and sessions left behind.
+Prints the database and WAL sizes before and after.
+
+When the server is running the work is done by the server (POST /api/maintain),
+which already holds the database; otherwise the database is opened directly.
+Writes wait while it runs — seconds for a typical store, longer for a large one.
+--checkpoint-only skips the VACUUM.`,
+	Args: cobra.NoArgs,
+	RunE: runMaintain,
+}
+
+func init() {
+	maintainCmd.Flags().BoolVar(&maintainCheckpointOnly, "checkpoint-only", false, "Checkpoint and truncate the WAL without VACUUM")
+}
+
+func runMaintain(cmd *cobra.Command, args []string) error {
+	vacuum := !maintainCheckpointOnly
+
+	if client := hooks.NewClient(); client.Healthy() {
+		client.SetTimeout(15 * time.Minute)
+		path := "/api/maintain"
+		if !vacuum {
+			path += "?vacuum=false"
+		}
+		data, err := client.Post(path, nil)
+		if err != nil {
+			return fmt.Errorf("maintain: %w", err)
+		}
+		var rep store.MaintainReport
+		if err := json.Unmarshal(data, &rep); err != nil {
+			return fmt.Errorf("parse response: %w", err)
+		}
+		printMaintain(&rep)
+		return nil
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer db.Close()
+	rep, err := db.Maintain(vacuum)
+	if err != nil {
+		return err
+	}
+	printMaintain(rep)
+	return nil
+}
+
+func printMaintain(rep *store.MaintainReport) {
+	fmt.Printf("Database: %s → %s\n", formatBytes(rep.DBBytesBefore), formatBytes(rep.DBBytesAfter))
+	fmt.Printf("WAL:      %s → %s\n", formatBytes(rep.WALBytesBefore), formatBytes(rep.WALBytesAfter))
+	if !rep.Checkpointed {
+		fmt.Fprintln(os.Stderr, "warning: a reader kept the WAL checkpoint from finishing; run maintain again later")
+	}
+	if rep.Vacuumed {
+		saved := rep.DBBytesBefore + rep.WALBytesBefore - rep.DBBytesAfter - rep.WALBytesAfter
+		if saved > 0 {
+			fmt.Printf("Reclaimed %s\n", formatBytes(saved))
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lazypower/continuity/internal/hooks"
+	"github.com/spf13/cobra"
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge <uri-keep> <uri-merge>",
+	Short: "Manually merge one memory into another",
+	Long: `Fold <uri-merge> into <uri-keep>: the merged memory's detail is appended to
+the keeper, its ID is recorded in the keeper's merge provenance, and the merged
+memory (and its vector) is deleted.
+
+Use this for duplicates that fall below the automatic dedup threshold. Both
+memories must be live leaves in the same category.
+
+Example:
+  continuity merge mem://user/preferences/go-style mem://user/preferences/golang-style`,
+	Args: cobra.ExactArgs(2),
+	RunE: runMerge,
+}
+
+func runMerge(cmd *cobra.Command, args []string) error {
+	keep := strings.TrimSpace(args[0])
+	merge := strings.TrimSpace(args[1])
+	for _, uri := range []string{keep, merge} {
+		if !strings.HasPrefix(uri, "mem://") {
+			return fmt.Errorf("invalid URI %q: must start with mem://", uri)
+		}
+	}
+	if keep == merge {
+		return fmt.Errorf("cannot merge a memory into itself")
+	}
+
+	client := hooks.NewClient()
+	if !client.Healthy() {
+		return fmt.Errorf("continuity server is not running — start it with: continuity serve")
+	}
+	warnIfSkewed()
+
+	body, _ := json.Marshal(map[string]string{"keep": keep, "merge": merge})
+	data, err := client.Post("/api/memories/merge", body)
+	if err != nil {
+		return fmt.Errorf("merge: %w", err)
+	}
+
+	var resp struct {
+		URI    string `json:"uri"`
+		Merged string `json:"merged"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	fmt.Printf("merged: %s → %s\n", resp.Merged, resp.URI)
+	return nil
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lazypower/continuity/internal/hooks"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergeSessionsDetect bool
+	mergeSessionsGap    time.Duration
+	mergeSessionsLimit  int
+)
+
+var mergeSessionsCmd = &cobra.Command{
+	Use:   "merge-sessions <keep-id> <merge-id>",
+	Short: "Combine two session IDs that are one conversation",
+	Long: `Fold session <merge-id> into <keep-id>. Claude Code sometimes starts a new
+session ID when a conversation is resumed, so one conversation ends up as two
+sessions, each extracted with half the context.
+
+The merged session's observations, memory provenance and context injections
+move to the keeper, counts are summed, and the merged session is deleted.
+Both sessions must belong to the same project.
+
+With --detect, list likely candidates instead: sessions in the same project
+where one started within --gap of the other ending (or while it was still
+running). Nothing is merged; review the pairs and merge them by hand.
+
+Examples:
+  continuity merge-sessions --detect
+  continuity merge-sessions 4f1c2a... 9b7e03...`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if mergeSessionsDetect {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	RunE: runMergeSessions,
+}
+
+func init() {
+	mergeSessionsCmd.Flags().BoolVar(&mergeSessionsDetect, "detect", false, "List likely fragmented sessions instead of merging")
+	mergeSessionsCmd.Flags().DurationVar(&mergeSessionsGap, "gap", 10*time.Minute, "With --detect: largest gap between one session ending and the next starting")
+	mergeSessionsCmd.Flags().IntVar(&mergeSessionsLimit, "limit", 200, "With --detect: number of recent sessions to examine")
+}
+
+func runMergeSessions(cmd *cobra.Command, args []string) error {
+	client := hooks.NewClient()
+	if !client.Healthy() {
+		return fmt.Errorf("continuity server is not running — start it with: continuity serve")
+	}
+	warnIfSkewed()
+
+	if mergeSessionsDetect {
+		data, err := client.Get(fmt.Sprintf("/api/sessions?limit=%d", mergeSessionsLimit))
+		if err != nil {
+			return fmt.Errorf("sessions: %w", err)
+		}
+		var sessions []sessionRow
+		if err := json.Unmarshal(data, &sessions); err != nil {
+			return fmt.Errorf("parse sessions: %w", err)
+		}
+		pairs := fragmentedSessions(sessions, mergeSessionsGap)
+		if len(pairs) == 0 {
+			fmt.Println("No fragmented sessions found.")
+			return nil
+		}
+		for _, p := range pairs {
+			fmt.Printf("%s  %s\n", time.UnixMilli(p[0].StartedAt).Format("Jan 02 15:04"), filepath.Base(p[0].Project))
+			fmt.Printf("  continuity merge-sessions %s %s\n", p[0].SessionID, p[1].SessionID)
+		}
+		return nil
+	}
+
+	keep := strings.TrimSpace(args[0])
+	merge := strings.TrimSpace(args[1])
+	if keep == merge {
+		return fmt.Errorf("cannot merge a session into itself")
+	}
+
+	body, _ := json.Marshal(map[string]string{"keep": keep, "merge": merge})
+	data, err := client.Post("/api/sessions/merge", body)
+	if err != nil {
+		return fmt.Errorf("merge sessions: %w", err)
+	}
+	var s sessionRow
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	fmt.Printf("merged: %s → %s (%d tools, %s)\n", merge, s.SessionID, s.ToolCount, s.extractionStatus())
+	return nil
+}
+
+// fragmentedSessions returns pairs of sessions that look like one
+// conversation: same (known) project, and the later one started while the
+// earlier was still running or within gap of it ending. Each pair is ordered
+// earlier first, which is the one to keep. A session that is still active
+// has no end and overlaps anything that started after it.
+func fragmentedSessions(sessions []sessionRow, gap time.Duration) [][2]sessionRow {
+	sorted := append([]sessionRow(nil), sessions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].StartedAt < sorted[j].StartedAt })
+
+	var pairs [][2]sessionRow
+	last := map[string]sessionRow{} // project → latest-starting session seen
+	for _, s := range sorted {
+		if s.Project == "" {
+			continue
+		}
+		if prev, ok := last[s.Project]; ok {
+			if prev.EndedAt == nil || s.StartedAt <= *prev.EndedAt+gap.Milliseconds() {
+				pairs = append(pairs, [2]sessionRow{prev, s})
+			}
+		}
+		last[s.Project] = s
+	}
+	return pairs
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFragmentedSessions(t *testing.T) {
+	ms := func(min int) int64 { return int64(min) * time.Minute.Milliseconds() }
+	ended := func(min int) *int64 { v := ms(min); return &v }
+	sessions := []sessionRow{
+		{SessionID: "resumed", Project: "/p/a", StartedAt: ms(65), EndedAt: ended(90)},
+		{SessionID: "first", Project: "/p/a", StartedAt: ms(0), EndedAt: ended(60)},
+		{SessionID: "next-day", Project: "/p/a", StartedAt: ms(24 * 60)},
+		{SessionID: "other-project", Project: "/p/b", StartedAt: ms(70)},
+		{SessionID: "no-project", StartedAt: ms(66)},
+		{SessionID: "overlaps-active", Project: "/p/b", StartedAt: ms(300)},
+	}
+
+	pairs := fragmentedSessions(sessions, 10*time.Minute)
+	var got []string
+	for _, p := range pairs {
+		got = append(got, p[0].SessionID+"+"+p[1].SessionID)
+	}
+	want := []string{"first+resumed", "other-project+overlaps-active"}
+	if len(got) != len(want) {
+		t.Fatalf("pairs = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("pair %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
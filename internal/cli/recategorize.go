@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lazypower/continuity/internal/engine"
+	"github.com/lazypower/continuity/internal/llm"
+	"github.com/lazypower/continuity/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recategorizeDryRun bool
+	recategorizeYes    bool
+	recategorizeBatch  int
+)
+
+var recategorizeCmd = &cobra.Command{
+	Use:   "recategorize",
+	Short: "Review memory categories with the LLM and move misfiled memories",
+	Long: `Ask the configured LLM, a batch of memories per call, whether each memory is
+filed under the right category, and list the moves it proposes. Each move
+re-files the memory under the new category (and that category's owner) with the
+same slug; its vector, relevance, access history, and pin carry over.
+
+Nothing moves without confirmation: each proposal is shown with the model's
+reason and applied only on "y". --dry-run lists the proposals and exits; --yes
+applies every proposal without asking.
+
+Moments and session notes are left alone, as is the relational profile.`,
+	Args: cobra.NoArgs,
+	RunE: runRecategorize,
+}
+
+func init() {
+	recategorizeCmd.Flags().BoolVar(&recategorizeDryRun, "dry-run", false, "List proposed moves without applying any")
+	recategorizeCmd.Flags().BoolVarP(&recategorizeYes, "yes", "y", false, "Apply every proposed move without asking")
+	recategorizeCmd.Flags().IntVar(&recategorizeBatch, "batch-size", 25, "Memories reviewed per LLM call")
+}
+
+func runRecategorize(cmd *cobra.Command, args []string) error {
+	db, err := openDB()
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer db.Close()
+
+	lc, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if err := lc.resolveKey(); err != nil {
+		return err
+	}
+	client, err := llm.NewClient(lc.LLM)
+	if err != nil {
+		return fmt.Errorf("recategorize needs an LLM: %w", err)
+	}
+
+	eng := engine.New(db, client)
+	proposals, err := eng.ProposeRecategorizations(context.Background(), recategorizeBatch)
+	if err != nil {
+		return fmt.Errorf("recategorize: %w", err)
+	}
+	if len(proposals) == 0 {
+		fmt.Println("No moves proposed — every memory looks correctly filed.")
+		return nil
+	}
+
+	moved := 0
+	for i, p := range proposals {
+		fmt.Printf("\n[%d/%d] %s\n  %s\n  %s → %s", i+1, len(proposals), p.URI, p.L0, p.Category, p.Proposed)
+		if p.Reason != "" {
+			fmt.Printf(": %s", p.Reason)
+		}
+		fmt.Printf("\n  new URI: %s\n", p.Target)
+		if recategorizeDryRun {
+			continue
+		}
+		if !recategorizeYes && !promptYN("  Move? [y/N] ") {
+			continue
+		}
+
+		node, err := db.GetNodeByURI(p.URI)
+		if err != nil {
+			return fmt.Errorf("look up %s: %w", p.URI, err)
+		}
+		if node == nil {
+			fmt.Printf("  skipped: %s no longer exists\n", p.URI)
+			continue
+		}
+		if _, err := db.MoveNode(node.ID, p.Target, p.Proposed); err != nil {
+			var mve *store.MoveValidationError
+			if errors.As(err, &mve) {
+				fmt.Printf("  skipped: %s\n", mve.Message)
+				continue
+			}
+			return fmt.Errorf("move %s: %w", p.URI, err)
+		}
+		moved++
+		fmt.Printf("  moved\n")
+	}
+
+	if recategorizeDryRun {
+		fmt.Printf("\n[dry-run] %d move(s) proposed — rerun without --dry-run to review and apply\n", len(proposals))
+		return nil
+	}
+	fmt.Printf("\nMoved %d of %d proposed.\n", moved, len(proposals))
+	return nil
+}
@@ -18,12 +18,14 @@ var (
 	rememberDetail               string
 	rememberSession              string
 	rememberAcknowledgeRetracted bool
+	rememberMergeable            bool
 )
 
 var validCategorySet = map[string]bool{
 	"profile": true, "preferences": true, "entities": true,
 	"events": true, "patterns": true, "cases": true,
 	"moments": true, "feedback": true, "reference": true,
+	"constraints": true,
 }
 
 var rememberCmd = &cobra.Command{
@@ -43,12 +45,16 @@ Examples:
 
   continuity remember -c reference -n linear-ingest \
     -s "Pipeline bugs are tracked in Linear project INGEST." \
-    -b "Linear project 'INGEST' is where the team tracks all pipeline bugs. Check there when filing or referencing pipeline-related tickets."`,
+    -b "Linear project 'INGEST' is where the team tracks all pipeline bugs. Check there when filing or referencing pipeline-related tickets."
+
+Whether a later write to the same name merges in place or keeps a new
+version follows the category (preferences merge, events don't). Pass
+--mergeable or --mergeable=false to choose for this memory instead.`,
 	RunE: runRemember,
 }
 
 func init() {
-	rememberCmd.Flags().StringVarP(&rememberCategory, "category", "c", "", "Memory category (required: profile, preferences, feedback, entities, events, patterns, cases, moments, reference)")
+	rememberCmd.Flags().StringVarP(&rememberCategory, "category", "c", "", "Memory category (required: profile, preferences, feedback, constraints, entities, events, patterns, cases, moments, reference)")
 	rememberCmd.Flags().StringVarP(&rememberName, "name", "n", "", "URI slug name (required)")
 	rememberCmd.Flags().StringVarP(&rememberSummary, "summary", "s", "", "L0 abstract — one sentence, max 200 chars (required)")
 	rememberCmd.Flags().StringVarP(&rememberBody, "body", "b", "", "L1 overview — max 2000 chars, compress detail aggressively (required)")
@@ -56,6 +62,8 @@ func init() {
 	rememberCmd.Flags().StringVar(&rememberSession, "session", "", "Session ID for provenance (optional)")
 	rememberCmd.Flags().BoolVar(&rememberAcknowledgeRetracted, "acknowledge-retracted", false, "Proceed past a dedup match against retracted memory (use after inspecting with `show --include-retracted`)")
 
+	rememberCmd.Flags().BoolVar(&rememberMergeable, "mergeable", false, "Merge later writes to this name in place (true) or keep each as a version (false), overriding the category default")
+
 	rememberCmd.MarkFlagRequired("category")
 	rememberCmd.MarkFlagRequired("name")
 	rememberCmd.MarkFlagRequired("summary")
@@ -95,6 +103,9 @@ func runRemember(cmd *cobra.Command, args []string) error {
 	if rememberAcknowledgeRetracted {
 		payload["acknowledge_retracted"] = true
 	}
+	if cmd.Flags().Changed("mergeable") {
+		payload["mergeable"] = rememberMergeable
+	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
@@ -10,6 +10,7 @@ func TestValidCategorySet(t *testing.T) {
 	required := []string{
 		"profile", "preferences", "feedback", "entities",
 		"events", "patterns", "cases", "moments", "reference",
+		"constraints",
 	}
 	for _, c := range required {
 		if !validCategorySet[c] {
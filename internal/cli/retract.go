@@ -17,7 +17,7 @@ var (
 )
 
 var retractCmd = &cobra.Command{
-	Use:   "retract <uri>",
+	Use:   "retract <uri | ->",
 	Short: "Retract a memory (tombstone or supersession)",
 	Long: `Retract a memory you wrote. Memory is preserved as a marker but excluded from
 default reads — search, tree, context injection. Use --include-retracted on inspection
@@ -33,8 +33,13 @@ Examples:
 
   continuity retract mem://user/preferences/old-style \
     --reason "preference changed after 2026-04 review" \
-    --superseded-by mem://user/preferences/new-style`,
-	Args: cobra.ExactArgs(1),
+    --superseded-by mem://user/preferences/new-style
+
+  # Retract every search hit; "-" (or no argument with piped stdin) reads
+  # one URI per line.
+  continuity search --uri-only "scratch notes" -c events | \
+    continuity retract - --reason "scratch, no ongoing value"`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runRetract,
 }
 
@@ -45,14 +50,34 @@ func init() {
 }
 
 func runRetract(cmd *cobra.Command, args []string) error {
-	retractURI = strings.TrimSpace(args[0])
-	if !strings.HasPrefix(retractURI, "mem://") {
-		return fmt.Errorf("invalid URI %q: must start with mem://", retractURI)
-	}
 	if retractSupersededBy != "" && !strings.HasPrefix(retractSupersededBy, "mem://") {
 		return fmt.Errorf("invalid superseded-by URI %q: must start with mem://", retractSupersededBy)
 	}
 
+	var uris []string
+	switch {
+	case len(args) == 1 && args[0] != "-":
+		uris = []string{strings.TrimSpace(args[0])}
+	case len(args) == 1 || stdinIsPiped():
+		var err error
+		if uris, err = readURIs(os.Stdin); err != nil {
+			return err
+		}
+		if len(uris) == 0 {
+			return fmt.Errorf("no URIs on stdin")
+		}
+	default:
+		return fmt.Errorf("retract requires a URI (or - to read URIs from stdin)")
+	}
+	if len(uris) > 1 && retractSupersededBy != "" {
+		return fmt.Errorf("--superseded-by applies to a single memory; got %d URIs", len(uris))
+	}
+	for _, uri := range uris {
+		if !strings.HasPrefix(uri, "mem://") {
+			return fmt.Errorf("invalid URI %q: must start with mem://", uri)
+		}
+	}
+
 	// Non-blocking skew preflight: surface a stale server before we write.
 	warnIfSkewed()
 
@@ -61,8 +86,28 @@ func runRetract(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("continuity server is not running — start it with: continuity serve")
 	}
 
+	// Keep going past a failed URI so one bad line doesn't strand the rest
+	// of a piped batch; the exit status still reports the failure.
+	failed := 0
+	for _, uri := range uris {
+		retractURI = uri
+		if err := retractOne(client, uri); err != nil {
+			if len(uris) == 1 {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "error: %s: %v\n", uri, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d retractions failed", failed, len(uris))
+	}
+	return nil
+}
+
+func retractOne(client *hooks.Client, uri string) error {
 	payload := map[string]string{
-		"uri":    retractURI,
+		"uri":    uri,
 		"reason": retractReason,
 	}
 	if retractSupersededBy != "" {
@@ -90,8 +135,7 @@ func runRetract(cmd *cobra.Command, args []string) error {
 	}
 
 	if resp.Error != "" {
-		fmt.Fprintf(os.Stderr, "error: %s\n", resp.Error)
-		os.Exit(1)
+		return fmt.Errorf("%s", resp.Error)
 	}
 
 	if resp.SupersededBy != "" {
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lazypower/continuity/internal/engine"
+	"github.com/lazypower/continuity/internal/hooks"
+	"github.com/lazypower/continuity/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	retryFailedList bool
+	retryFailedAll  bool
+)
+
+var retryFailedCmd = &cobra.Command{
+	Use:   "retry-failed",
+	Short: "Re-run extractions that errored",
+	Long: `Queue another extraction of every session whose extraction errored (LLM
+down, transcript missing) and hasn't since succeeded. A retry that succeeds
+drops the session from the list; one that fails counts another attempt.
+
+Sessions that have failed engine.retry_failed_max_attempts times are skipped
+unless --all is given. --list prints the failures without retrying.
+
+Set engine.retry_failed_interval to have the server retry on its own.
+Requires a running server (continuity serve).`,
+	Args: cobra.NoArgs,
+	RunE: runRetryFailed,
+}
+
+func init() {
+	retryFailedCmd.Flags().BoolVar(&retryFailedList, "list", false, "List failed extractions without retrying them")
+	retryFailedCmd.Flags().BoolVar(&retryFailedAll, "all", false, "Also retry sessions past retry_failed_max_attempts")
+}
+
+func runRetryFailed(cmd *cobra.Command, args []string) error {
+	client := hooks.NewClient()
+	if !client.Healthy() {
+		return fmt.Errorf("continuity server is not running — start it with: continuity serve")
+	}
+
+	if retryFailedList {
+		data, err := client.Get("/api/sessions/failed-extractions")
+		if err != nil {
+			return fmt.Errorf("list failed extractions: %w", err)
+		}
+		var resp struct {
+			Failed []store.FailedExtraction `json:"failed"`
+		}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return fmt.Errorf("parse response: %w", err)
+		}
+		printFailedExtractions(resp.Failed)
+		return nil
+	}
+
+	path := "/api/sessions/retry-failed"
+	if retryFailedAll {
+		path += "?all=true"
+	}
+	data, err := client.Post(path, nil)
+	if err != nil {
+		return fmt.Errorf("retry failed extractions: %w", err)
+	}
+	var rep engine.RetryReport
+	if err := json.Unmarshal(data, &rep); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+
+	if len(rep.Queued) == 0 && len(rep.GaveUp) == 0 && rep.Deferred == 0 {
+		fmt.Println("No failed extractions.")
+		return nil
+	}
+	for _, id := range rep.Queued {
+		fmt.Printf("queued  %s\n", id)
+	}
+	for _, id := range rep.GaveUp {
+		fmt.Printf("skipped %s (max attempts reached; --all retries it)\n", id)
+	}
+	if rep.Deferred > 0 {
+		fmt.Printf("%d left for later (already running, or the queue is full)\n", rep.Deferred)
+	}
+	if len(rep.Queued) > 0 {
+		fmt.Println("check serve.log for progress — extraction runs asynchronously")
+	}
+	return nil
+}
+
+func printFailedExtractions(failed []store.FailedExtraction) {
+	if len(failed) == 0 {
+		fmt.Println("No failed extractions.")
+		return
+	}
+	for _, f := range failed {
+		fmt.Printf("%s  %d attempt(s), last %s\n", f.SessionID, f.Attempts,
+			time.UnixMilli(f.LastFailedAt).Format("Jan 02 15:04"))
+		if f.TranscriptPath != "" {
+			fmt.Printf("  transcript: %s\n", f.TranscriptPath)
+		}
+		fmt.Printf("  error: %s\n", f.Error)
+	}
+}
@@ -23,16 +23,31 @@ func init() {
 	rootCmd.AddCommand(treeCmd)
 	rootCmd.AddCommand(importCmd)
 	rootCmd.AddCommand(dedupCmd)
+	rootCmd.AddCommand(clustersCmd)
+	rootCmd.AddCommand(calibrateCmd)
+	rootCmd.AddCommand(maintainCmd)
+	rootCmd.AddCommand(entitiesCmd)
+	rootCmd.AddCommand(mergeCmd)
+	rootCmd.AddCommand(recategorizeCmd)
+	rootCmd.AddCommand(decayCmd)
 	rootCmd.AddCommand(rememberCmd)
 	rootCmd.AddCommand(retractCmd)
 	rootCmd.AddCommand(pinCmd)
 	rootCmd.AddCommand(unpinCmd)
+	rootCmd.AddCommand(boostCmd)
 	rootCmd.AddCommand(showCmd)
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(timelineCmd)
+	rootCmd.AddCommand(sessionsCmd)
+	rootCmd.AddCommand(mergeSessionsCmd)
+	rootCmd.AddCommand(forgetSessionCmd)
+	rootCmd.AddCommand(statsCmd)
 	rootCmd.AddCommand(installServiceCmd)
 	rootCmd.AddCommand(uninstallServiceCmd)
 	rootCmd.AddCommand(extractCmd)
+	rootCmd.AddCommand(retryFailedCmd)
 	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(exportCmd)
 	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(configCmd)
 }
@@ -22,8 +22,8 @@ import (
 
 // Server-side environment variables, read at serve start. These exist to make
 // hermetic subprocess tests possible (and pave the way for TFIDF CI coverage),
-// not as the production configuration surface — Phase 1 config.toml loading
-// remains the path for normal use.
+// not as the production configuration surface — config.toml is the path for
+// normal use. Env values win over the file.
 const (
 	envServeDB       = "CONTINUITY_DB"       // overrides Database.Path
 	envServePort     = "CONTINUITY_PORT"     // overrides Server.Port (int)
@@ -39,50 +39,131 @@ const (
 // README's "Embedding backends" section spells out the two shipped paths.
 const tfidfLexicalNotice = "  ! tfidf: hashed lexical fallback (keyword overlap, not semantic); install Ollama (nomic-embed-text) for semantic recall — see README \"Embedding backends\""
 
+var (
+	serveReadOnly bool
+	serveNoLLM    bool
+)
+
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start the HTTP API server",
-	RunE:  runServe,
-}
+	Long: `Start the HTTP API server.
 
-func runServe(cmd *cobra.Command, args []string) error {
-	cfg := config.Default()
+--readonly opens the database with SQLite's mode=ro for safe inspection of a
+live store: no migrations, no decay timer, no vector backfill, no metrics
+rollup, and every mutating endpoint (extraction, signals, remember, retract,
+pin, merge, session hooks) answers 503. Search, tree, profile, context, and
+health keep working; context renders as a preview and search skips access
+bookkeeping.
 
-	// Check for ANTHROPIC_API_KEY env override
-	if key := os.Getenv("ANTHROPIC_API_KEY"); key != "" {
-		cfg.LLM.Provider = "anthropic"
-		cfg.LLM.AnthropicKey = key
-	}
+--no-llm runs without an LLM: signals ("remember this: ...") are stored
+verbatim from the text after the trigger phrase, and session extraction is
+off. Set rule_based_signals under [engine] to fall back the same way only
+when the configured LLM is unavailable.`,
+	RunE: runServe,
+}
 
-	if err := applyServeEnvOverrides(&cfg); err != nil {
+func init() {
+	serveCmd.Flags().BoolVar(&serveReadOnly, "readonly", false, "Open the database read-only and refuse all writes")
+	serveCmd.Flags().BoolVar(&serveNoLLM, "no-llm", false, "Run without an LLM; capture signals verbatim")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	lc, err := loadConfig()
+	if err != nil {
 		return err
 	}
-
-	// Resolve database path
-	dbPath := cfg.Database.Path
-	if dbPath == "" {
-		var err error
-		dbPath, err = store.DefaultDBPath()
-		if err != nil {
-			return fmt.Errorf("resolve db path: %w", err)
+	if !serveNoLLM {
+		if err := lc.resolveKey(); err != nil {
+			return err
 		}
 	}
+	cfg := lc.Config
 
-	db, err := store.Open(dbPath)
+	dbPath, err := resolveDBPath(cfg)
+	if err != nil {
+		return fmt.Errorf("resolve db path: %w", err)
+	}
+
+	var db *store.DB
+	if serveReadOnly {
+		db, err = store.OpenReadOnly(dbPath)
+	} else {
+		db, err = store.Open(dbPath)
+	}
 	if err != nil {
 		return fmt.Errorf("open database: %w", err)
 	}
 	defer db.Close()
+	db.MaxURIDepth = cfg.Database.MaxURIDepth
+	db.DropL2 = !cfg.Engine.StoreL2
+	db.EnableNodeCache(cfg.Database.NodeCacheSize)
+	if err := db.SetDecayParams(decayParams(cfg.Engine)); err != nil {
+		return fmt.Errorf("config [engine]: %w", err)
+	}
+	if db.ReadOnly {
+		fmt.Fprintln(os.Stderr, "  mode: read-only (writes refused with 503)")
+	}
 
-	// Create LLM client and engine
+	// Create LLM client and engine. Without an LLM the engine exists only
+	// for rule-based signal capture (--no-llm or rule_based_signals).
 	var eng *engine.Engine
-	llmClient, err := llm.NewClient(cfg.LLM)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "warning: LLM not configured (%v), extraction disabled\n", err)
-	} else {
+	var llmClient llm.Client
+	if serveNoLLM {
+		cfg.Engine.RuleBasedSignals = true
+		fmt.Fprintln(os.Stderr, "  llm: none (--no-llm: signals captured verbatim, session extraction disabled)")
+	} else if llmClient, err = llm.NewClient(cfg.LLM); err != nil {
+		if cfg.Engine.RuleBasedSignals {
+			fmt.Fprintf(os.Stderr, "warning: LLM not configured (%v), signals captured verbatim, session extraction disabled\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "warning: LLM not configured (%v), extraction disabled\n", err)
+		}
+	}
+	if llmClient != nil || cfg.Engine.RuleBasedSignals {
+		if p := cfg.Engine.ExtractionPromptPath; p != "" {
+			if _, err := llm.LoadExtractionTemplate(p); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "  extraction prompt: %s\n", p)
+		}
+		if !engine.ValidMergeStrategy(cfg.Engine.MergeStrategy) {
+			return fmt.Errorf("config [engine]: unknown merge_strategy %q (want replace, union, or llm)", cfg.Engine.MergeStrategy)
+		}
+		if _, err := engine.ParseDecayInterval(cfg.Engine.DecayInterval); err != nil {
+			return fmt.Errorf("config [engine]: decay_interval %q: %v", cfg.Engine.DecayInterval, err)
+		}
+		if _, err := engine.ParseRelationalMinInterval(cfg.Engine.RelationalMinInterval); err != nil {
+			return fmt.Errorf("config [engine]: relational_min_interval %q: %v", cfg.Engine.RelationalMinInterval, err)
+		}
+		if _, err := engine.ParseRetryFailedInterval(cfg.Engine.RetryFailedInterval); err != nil {
+			return fmt.Errorf("config [engine]: retry_failed_interval %q: %v", cfg.Engine.RetryFailedInterval, err)
+		}
+		if err := engine.CheckSearchMinScore(cfg.Engine.SearchMinScore); err != nil {
+			return fmt.Errorf("config [engine]: search_min_score %v: %v", cfg.Engine.SearchMinScore, err)
+		}
+		if err := engine.CheckCategoryCaps(cfg.Engine.CategoryCaps); err != nil {
+			return fmt.Errorf("config [engine]: category_caps: %w", err)
+		}
+		if c := cfg.Engine.SignalDefaultCategory; c != "" && !engine.SignalCategory(c) {
+			return fmt.Errorf("config [engine]: signal_default_category %q is not a category signals can use", c)
+		}
 		eng = engine.New(db, llmClient)
-		eng.StartDecayTimer()
-		defer eng.Stop()
+		eng.SetConfig(cfg.Engine)
+		if cfg.Engine.MergeStrategy == engine.MergeLLM && llmClient != nil {
+			mergeClient, err := llm.NewMergeClient(cfg.LLM)
+			if err != nil {
+				return fmt.Errorf("merge client: %w", err)
+			}
+			eng.SetMergeLLM(mergeClient)
+			fmt.Fprintf(os.Stderr, "  merge: llm (%s)\n", cfg.LLM.MergeModel)
+		}
+		if !db.ReadOnly {
+			eng.StartDecayTimer()
+			eng.StartRetryTimer()
+			defer eng.Stop()
+		}
+	}
+	if llmClient != nil {
 		fmt.Fprintf(os.Stderr, "  llm: %s (%s)\n", cfg.LLM.Provider, cfg.LLM.Model)
 		if bin := llm.ProviderBinaryUnresolved(cfg.LLM); bin != "" {
 			fmt.Fprintf(os.Stderr,
@@ -106,13 +187,13 @@ func runServe(cmd *cobra.Command, args []string) error {
 		choice := resolveEmbedderChoice(ollamaURL, embeddingModel)
 		switch choice {
 		case "ollama":
-			emb := engine.NewOllamaEmbedder(ollamaURL, embeddingModel, 768)
+			emb := newOllamaEmbedder(cfg.LLM, ollamaURL, embeddingModel)
 			if eng != nil {
 				eng.SetEmbedder(emb)
 			}
 			fmt.Fprintf(os.Stderr, "  embedder: ollama (%s)\n", embeddingModel)
 		case "tfidf":
-			emb, tfidfErr := engine.NewHashEmbedder(0)
+			emb, tfidfErr := newLexicalEmbedder(cfg.LLM)
 			if tfidfErr != nil {
 				fmt.Fprintf(os.Stderr, "warning: tfidf embedder init failed: %v\n", tfidfErr)
 			} else {
@@ -127,13 +208,13 @@ func runServe(cmd *cobra.Command, args []string) error {
 		default:
 			// auto: probe Ollama, fall back to the hashed lexical embedder
 			if engine.ProbeOllama(ollamaURL, embeddingModel) {
-				emb := engine.NewOllamaEmbedder(ollamaURL, embeddingModel, 768)
+				emb := newOllamaEmbedder(cfg.LLM, ollamaURL, embeddingModel)
 				if eng != nil {
 					eng.SetEmbedder(emb)
 				}
 				fmt.Fprintf(os.Stderr, "  embedder: ollama (%s)\n", embeddingModel)
 			} else {
-				emb, tfidfErr := engine.NewHashEmbedder(0)
+				emb, tfidfErr := newLexicalEmbedder(cfg.LLM)
 				if tfidfErr != nil {
 					fmt.Fprintf(os.Stderr, "warning: tfidf embedder init failed: %v\n", tfidfErr)
 				} else {
@@ -157,22 +238,46 @@ func runServe(cmd *cobra.Command, args []string) error {
 				fmt.Fprintf(os.Stderr, "warning: vector identity reconcile failed: %v\n", err)
 			case !st.Match:
 				fmt.Fprintf(os.Stderr, "\n⚠ %s\n\n", st.Reason)
+			case db.ReadOnly:
+				fmt.Fprintf(os.Stderr, "  vectors: %s (no backfill: read-only)\n", st.Action)
 			default:
 				fmt.Fprintf(os.Stderr, "  vectors: %s\n", st.Action)
-				go func() {
-					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-					defer cancel()
-					if n, err := eng.EmbedMissing(ctx); err != nil {
+				// /api/ready reports 503 until this finishes.
+				eng.StartEmbedBackfill(5*time.Minute, func(n int, err error) {
+					if err != nil {
 						fmt.Fprintf(os.Stderr, "embed missing: %v\n", err)
 					} else if n > 0 {
 						fmt.Fprintf(os.Stderr, "  embedded %d missing nodes\n", n)
 					}
-				}()
+				})
 			}
 		}
 	}
 
 	srv := server.New(db, eng, VersionString())
+	if err := srv.SetContextLayout(cfg.Context.Sections, cfg.Context.Categories); err != nil {
+		return fmt.Errorf("config [context]: %w", err)
+	}
+	if err := srv.SetContextMaxAge(time.Duration(cfg.Context.MaxAgeDays) * 24 * time.Hour); err != nil {
+		return fmt.Errorf("config [context]: %w", err)
+	}
+	if err := srv.SetContextThrottle(cfg.Context.LightSessionObservations, cfg.Context.LightItems); err != nil {
+		return fmt.Errorf("config [context]: %w", err)
+	}
+	if err := srv.SetCORSOrigins(cfg.Server.CORSOrigins); err != nil {
+		return fmt.Errorf("config [server]: %w", err)
+	}
+	srv.SetSkipTools(cfg.Hooks.SkipTools)
+	srv.WarmContext()
+
+	// DB health monitor: probes on a timer and reconnects with backoff after
+	// repeated failures, so a DB locked past busy_timeout doesn't leave the
+	// daemon up but broken. Stops on shutdown.
+	monitorCtx, stopMonitor := context.WithCancel(context.Background())
+	defer stopMonitor()
+	monitor := store.NewHealthMonitor(db)
+	srv.SetHealthMonitor(monitor)
+	go monitor.Run(monitorCtx)
 	addr := cfg.ListenAddr()
 
 	httpServer := &http.Server{
@@ -199,8 +304,11 @@ func runServe(cmd *cobra.Command, args []string) error {
 	// serves" signal. Tick retention now, then surface what's still retained.
 	// Deliberately not in store.Open, so CLI subcommands that inspect or prune
 	// snapshots don't advance the counter — only a real serve boot does.
-	if err := db.TickSnapshotRetention(); err != nil {
-		fmt.Fprintf(os.Stderr, "warning: snapshot retention tick failed: %v\n", err)
+	// A read-only boot didn't exercise the schema's write path; it doesn't count.
+	if !db.ReadOnly {
+		if err := db.TickSnapshotRetention(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: snapshot retention tick failed: %v\n", err)
+		}
 	}
 	if snaps, _ := db.ListMigrationSnapshots(); len(snaps) > 0 {
 		for _, s := range snaps {
@@ -216,6 +324,9 @@ func runServe(cmd *cobra.Command, args []string) error {
 	// it only writes the metrics_daily ledger. Stops on shutdown.
 	rollupStop := make(chan struct{})
 	go func() {
+		if db.ReadOnly {
+			return
+		}
 		if err := db.RollupDailySnapshot(); err != nil {
 			fmt.Fprintf(os.Stderr, "metrics rollup (startup): %v\n", err)
 		}
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -21,7 +23,7 @@ func TestApplyServeEnvOverrides_NoEnv(t *testing.T) {
 	if err := applyServeEnvOverrides(&cfg); err != nil {
 		t.Fatal(err)
 	}
-	if cfg != want {
+	if !reflect.DeepEqual(cfg, want) {
 		t.Errorf("expected cfg unchanged when no env set; got %+v", cfg)
 	}
 }
@@ -81,7 +83,7 @@ func TestApplyServeEnvOverrides_WhitespaceIgnored(t *testing.T) {
 	if err := applyServeEnvOverrides(&cfg); err != nil {
 		t.Fatal(err)
 	}
-	if cfg != want {
+	if !reflect.DeepEqual(cfg, want) {
 		t.Errorf("whitespace-only env vars must be treated as unset; got %+v", cfg)
 	}
 }
@@ -147,3 +149,87 @@ func TestServeEnvConstants(t *testing.T) {
 		t.Errorf("env vars must share the CONTINUITY_ prefix: %q", envServeDB)
 	}
 }
+
+func TestLoadConfig_FileThenEnv(t *testing.T) {
+	clearServeEnv(t)
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	path := t.TempDir() + "/config.toml"
+	body := "[server]\nport = 40000\nbind = \"10.0.0.1\"\n[database]\npath = \"/tmp/from-file.db\"\n"
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envConfigPath, path)
+	t.Setenv(envServePort, "41000")
+
+	lc, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lc.Found || lc.Path != path {
+		t.Errorf("Found = %v, Path = %q", lc.Found, lc.Path)
+	}
+	if lc.Server.Port != 41000 {
+		t.Errorf("env should win over file: Port = %d", lc.Server.Port)
+	}
+	if lc.Server.Bind != "10.0.0.1" {
+		t.Errorf("file should win over default: Bind = %q", lc.Server.Bind)
+	}
+	if got, _ := resolveDBPath(lc.Config); got != "/tmp/from-file.db" {
+		t.Errorf("resolveDBPath = %q", got)
+	}
+	if !reflect.DeepEqual(lc.Env, []string{envServePort}) {
+		t.Errorf("Env = %v", lc.Env)
+	}
+}
+
+func TestLoadConfig_KeyFileBeatsEnv(t *testing.T) {
+	clearServeEnv(t)
+	dir := t.TempDir()
+	keyPath := dir + "/anthropic.key"
+	if err := os.WriteFile(keyPath, []byte("sk-file"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := dir + "/config.toml"
+	body := "[llm]\nanthropic_key_file = \"" + keyPath + "\"\n"
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envConfigPath, path)
+	t.Setenv("ANTHROPIC_API_KEY", "sk-env")
+
+	lc, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lc.LLM.AnthropicKey != "" || lc.KeySource != "" {
+		t.Errorf("loadConfig resolved the key (%q from %q); only resolveKey should", lc.LLM.AnthropicKey, lc.KeySource)
+	}
+	if err := lc.resolveKey(); err != nil {
+		t.Fatal(err)
+	}
+	if lc.LLM.AnthropicKey != "sk-file" || lc.KeySource != config.KeySourceFile {
+		t.Errorf("key = %q from %q, want sk-file from file", lc.LLM.AnthropicKey, lc.KeySource)
+	}
+	if lc.LLM.Provider != "anthropic" {
+		t.Errorf("ANTHROPIC_API_KEY should still select the anthropic provider; got %q", lc.LLM.Provider)
+	}
+}
+
+func TestLoadConfig_BrokenKeyFileOnlyFailsLLMCommands(t *testing.T) {
+	clearServeEnv(t)
+	dir := t.TempDir()
+	path := dir + "/config.toml"
+	body := "[llm]\nanthropic_key_file = \"" + dir + "/missing.key\"\n"
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envConfigPath, path)
+
+	lc, err := loadConfig()
+	if err != nil {
+		t.Fatalf("a database command must not need the key: %v", err)
+	}
+	if err := lc.resolveKey(); err == nil {
+		t.Error("resolveKey with a missing key file should fail")
+	}
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/lazypower/continuity/internal/hooks"
+	"github.com/spf13/cobra"
+)
+
+var sessionsLimit int
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions [session-id]",
+	Short: "List recent sessions and their extraction status",
+	Long: `List recent sessions, or show one session in detail.
+
+The extraction column explains sessions that produced no memories: a session
+the content gate passed over shows "skipped: <reason>" (e.g. too few user
+messages) instead of silently having nothing extracted. The detail view lists
+the memories the session created or updated.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSessions,
+}
+
+func init() {
+	sessionsCmd.Flags().IntVar(&sessionsLimit, "limit", 20, "Number of sessions to list")
+}
+
+type sessionRow struct {
+	SessionID    string `json:"session_id"`
+	Project      string `json:"project"`
+	Status       string `json:"status"`
+	StartedAt    int64  `json:"started_at"`
+	EndedAt      *int64 `json:"ended_at"`
+	MessageCount int    `json:"message_count"`
+	ToolCount    int    `json:"tool_count"`
+	ExtractedAt  *int64 `json:"extracted_at"`
+	Tone         string `json:"tone"`
+	SkipReason   string `json:"skip_reason"`
+
+	TranscriptPath string `json:"transcript_path"`
+
+	Memories []struct {
+		URI     string `json:"uri"`
+		Created bool   `json:"created"`
+	} `json:"memories"`
+}
+
+// extractionStatus renders the one-line extraction state of a session.
+func (s sessionRow) extractionStatus() string {
+	switch {
+	case s.ExtractedAt != nil:
+		return "extracted " + time.UnixMilli(*s.ExtractedAt).Format("Jan 02 15:04")
+	case s.SkipReason != "":
+		return "skipped: " + s.SkipReason
+	default:
+		return "pending"
+	}
+}
+
+func runSessions(cmd *cobra.Command, args []string) error {
+	client := hooks.NewClient()
+	if !client.Healthy() {
+		return fmt.Errorf("continuity server is not running — start it with: continuity serve")
+	}
+
+	if len(args) == 1 {
+		data, err := client.Get("/api/sessions/" + url.PathEscape(args[0]))
+		if err != nil {
+			return fmt.Errorf("session: %w", err)
+		}
+		var s sessionRow
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("parse session: %w", err)
+		}
+		fmt.Printf("Session:    %s\n", s.SessionID)
+		fmt.Printf("Project:    %s\n", s.Project)
+		fmt.Printf("Status:     %s\n", s.Status)
+		fmt.Printf("Started:    %s\n", time.UnixMilli(s.StartedAt).Format(time.RFC3339))
+		if s.EndedAt != nil {
+			fmt.Printf("Ended:      %s\n", time.UnixMilli(*s.EndedAt).Format(time.RFC3339))
+		}
+		fmt.Printf("Tools:      %d\n", s.ToolCount)
+		if s.Tone != "" {
+			fmt.Printf("Tone:       %s\n", s.Tone)
+		}
+		fmt.Printf("Extraction: %s\n", s.extractionStatus())
+		if s.TranscriptPath != "" {
+			fmt.Printf("Transcript: %s\n", s.TranscriptPath)
+		}
+		if len(s.Memories) > 0 {
+			fmt.Printf("\nMemories written (%d):\n", len(s.Memories))
+			for _, m := range s.Memories {
+				action := "updated"
+				if m.Created {
+					action = "created"
+				}
+				fmt.Printf("  %-8s %s\n", action, m.URI)
+			}
+		}
+		return nil
+	}
+
+	data, err := client.Get(fmt.Sprintf("/api/sessions?limit=%d", sessionsLimit))
+	if err != nil {
+		return fmt.Errorf("sessions: %w", err)
+	}
+	var sessions []sessionRow
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return fmt.Errorf("parse sessions: %w", err)
+	}
+
+	if len(sessions) == 0 {
+		fmt.Println("No sessions recorded yet.")
+		return nil
+	}
+
+	for _, s := range sessions {
+		project := "unknown"
+		if s.Project != "" {
+			project = filepath.Base(s.Project)
+		}
+		fmt.Printf("%s  %-20s %-10s %4d tools  %s\n",
+			time.UnixMilli(s.StartedAt).Format("Jan 02 15:04"),
+			project, s.Status, s.ToolCount, s.extractionStatus())
+		fmt.Printf("  %s\n", s.SessionID)
+	}
+	return nil
+}
@@ -51,13 +51,13 @@ func init() {
 // openDB) but WITHOUT running migrations. Inspecting or pruning snapshots must
 // not trigger a schema upgrade — see store.OpenNoMigrate for why.
 func openDBForSnapshot() (*store.DB, error) {
-	dbPath := os.Getenv("CONTINUITY_DB")
-	if dbPath == "" {
-		var err error
-		dbPath, err = store.DefaultDBPath()
-		if err != nil {
-			return nil, err
-		}
+	lc, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	dbPath, err := resolveDBPath(lc.Config)
+	if err != nil {
+		return nil, err
 	}
 	return store.OpenNoMigrate(dbPath)
 }
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/lazypower/continuity/internal/hooks"
+	"github.com/lazypower/continuity/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show memory usage statistics",
+	Long: `Summarize what continuity has learned: memories by category, vector
+coverage, relevance spread, sessions by status, extractions, and the database's
+size and schema version. Asks the running server (GET /api/stats) when there is
+one; otherwise opens the database read-only.`,
+	Args: cobra.NoArgs,
+	RunE: runStats,
+}
+
+var statsUsefulnessCmd = &cobra.Command{
+	Use:   "usefulness",
+	Short: "Show how often injected memories are later retrieved, per category",
+	Long: `Every SessionStart records which memories were injected into the session's
+context. When a search in the same session retrieves one of them, the injection
+is marked used. This reports injection→use rates per category — a low rate
+means a category rides the context window without earning its place.`,
+	Args: cobra.NoArgs,
+	RunE: runStatsUsefulness,
+}
+
+func init() {
+	statsCmd.AddCommand(statsUsefulnessCmd)
+}
+
+// statsReport is the stats payload, whether from GET /api/stats or the
+// database directly. Uptime is only known from the server.
+type statsReport struct {
+	store.Stats
+	DBPath        string  `json:"db_path"`
+	DBBytes       int64   `json:"db_bytes"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	// Prefer the running server, as search does; read the database directly
+	// only when there isn't one.
+	if client := hooks.NewClient(); client.Healthy() {
+		data, err := client.Get("/api/stats")
+		if err == nil {
+			var rep statsReport
+			if err := json.Unmarshal(data, &rep); err != nil {
+				return fmt.Errorf("parse response: %w", err)
+			}
+			printStats(rep)
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "warning: %v — reading the database directly\n", err)
+	}
+
+	lc, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	dbPath, err := resolveDBPath(lc.Config)
+	if err != nil {
+		return fmt.Errorf("resolve db path: %w", err)
+	}
+	db, err := store.OpenReadOnly(dbPath)
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer db.Close()
+
+	st, err := db.Stats()
+	if err != nil {
+		return err
+	}
+	printStats(statsReport{Stats: *st, DBPath: dbPath, DBBytes: store.FileSize(dbPath)})
+	return nil
+}
+
+func printStats(rep statsReport) {
+	st := rep.Stats
+	fmt.Printf("Database:   %s (%s, schema v%d)\n", rep.DBPath, formatBytes(rep.DBBytes), st.SchemaVersion)
+	if rep.UptimeSeconds > 0 {
+		fmt.Printf("Server:     up %s\n", time.Duration(rep.UptimeSeconds*float64(time.Second)).Round(time.Second))
+	}
+	fmt.Printf("Memories:   %d live, %d retracted\n", st.Leaves, st.Retracted)
+	fmt.Printf("Vectors:    %d/%d embedded (%.1f%%)", st.Embedded, st.Leaves, st.Coverage()*100)
+	if st.Corrupt > 0 {
+		fmt.Printf(", %d corrupt — run `continuity doctor --repair-vectors`", st.Corrupt)
+	}
+	fmt.Println()
+	if st.Leaves > 0 {
+		fmt.Printf("Relevance:  avg %.2f, min %.2f, max %.2f\n", st.RelevanceAvg, st.RelevanceMin, st.RelevanceMax)
+	}
+
+	statuses := make([]string, 0, len(st.Sessions))
+	for status := range st.Sessions {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	fmt.Printf("Sessions:   %d", st.SessionsTotal)
+	for _, status := range statuses {
+		fmt.Printf(", %d %s", st.Sessions[status], status)
+	}
+	fmt.Printf(" (%d extracted)\n", st.SessionsExtracted)
+
+	if len(st.Categories) > 0 {
+		fmt.Printf("\n%-14s %6s %7s\n", "CATEGORY", "COUNT", "SHARE")
+		for _, c := range st.Categories {
+			fmt.Printf("%-14s %6d %6.1f%%\n", c.Category, c.Count, c.Share*100)
+		}
+	}
+}
+
+// formatBytes renders n as B, KB, MB, or GB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	v, suffix := float64(n)/unit, "KB"
+	for _, next := range []string{"MB", "GB"} {
+		if v < unit {
+			break
+		}
+		v, suffix = v/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", v, suffix)
+}
+
+func runStatsUsefulness(cmd *cobra.Command, args []string) error {
+	db, err := openDB()
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.InjectionUsefulness()
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		fmt.Println("No context injections recorded yet.")
+		return nil
+	}
+
+	fmt.Printf("%-14s %9s %6s %7s\n", "CATEGORY", "INJECTED", "USED", "RATE")
+	var injected, used int
+	for _, r := range rows {
+		fmt.Printf("%-14s %9d %6d %6.1f%%\n", r.Category, r.Injected, r.Used, r.Rate*100)
+		injected += r.Injected
+		used += r.Used
+	}
+	fmt.Printf("%-14s %9d %6d %6.1f%%\n", "total", injected, used, float64(used)/float64(injected)*100)
+	return nil
+}
@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// A server reply missing the stats fields must print zeros, not panic.
+func TestPrintStatsEmptyReport(t *testing.T) {
+	var rep statsReport
+	if err := json.Unmarshal([]byte(`{"db_path":"/tmp/c.db"}`), &rep); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := captureStdout(t, func() error {
+		printStats(rep)
+		return nil
+	})
+	if !strings.Contains(out, "Memories:   0 live, 0 retracted") {
+		t.Errorf("output = %q, want zero counts", out)
+	}
+}
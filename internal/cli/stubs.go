@@ -1,28 +1,24 @@
 package cli
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
 
+	"github.com/lazypower/continuity/internal/config"
 	"github.com/lazypower/continuity/internal/engine"
 	"github.com/lazypower/continuity/internal/hooks"
 	"github.com/lazypower/continuity/internal/store"
 	"github.com/spf13/cobra"
 )
 
-// stubRun returns a RunE that prints a not-yet-implemented message to stderr
-// and exits 0 (hooks must never crash Claude Code).
-func stubRun(name string) func(*cobra.Command, []string) {
-	return func(cmd *cobra.Command, args []string) {
-		fmt.Fprintf(os.Stderr, "%s: not yet implemented\n", name)
-	}
-}
-
 var hookCmd = &cobra.Command{
 	Use:   "hook",
 	Short: "Handle Claude Code hook events",
@@ -77,25 +73,38 @@ func init() {
 
 	// Search flags
 	searchCmd.Flags().BoolVar(&searchSmart, "smart", false, "Use LLM-assisted search")
+	searchCmd.Flags().BoolVar(&searchRerank, "rerank", false, "With --smart, have the LLM reorder the top results for the query")
 	searchCmd.Flags().IntVarP(&searchLimit, "limit", "n", 10, "Maximum number of results")
 	searchCmd.Flags().StringVarP(&searchCategory, "category", "c", "", "Filter by category")
 	searchCmd.Flags().BoolVar(&searchExplain, "explain", false, "Show score decomposition (similarity, relevance) per result")
+	searchCmd.Flags().Float64Var(&searchFresh, "freshness", 0, "Weight (0-1) of a recency bonus that ranks newer memories higher")
+	searchCmd.Flags().Float64Var(&searchMinScore, "min-score", 0, "Drop results less similar than this (0-1) to the query (default: engine.search_min_score)")
+	searchCmd.Flags().BoolVar(&searchURIOnly, "uri-only", false, "Print only matching URIs, one per line (for piping into retract or tree)")
+	searchCmd.Flags().StringVar(&searchSession, "session", os.Getenv("CONTINUITY_SESSION_ID"), "Attribute this search to a session (default: the active session)")
 
 	// Profile flags
 	profileCmd.Flags().BoolVar(&profileVerbose, "verbose", false, "Show all profile and preference nodes")
+	profileCmd.Flags().BoolVar(&profileRebuild, "rebuild", false, "Rebuild the relational profile from recent session transcripts")
+	profileCmd.Flags().IntVar(&profileSessions, "sessions", 10, "Number of recent sessions to rebuild from (with --rebuild, max 100)")
 }
 
 // openDB is a helper that opens the database for CLI commands.
 func openDB() (*store.DB, error) {
-	dbPath := os.Getenv("CONTINUITY_DB")
-	if dbPath == "" {
-		var err error
-		dbPath, err = store.DefaultDBPath()
-		if err != nil {
-			return nil, err
-		}
+	lc, err := loadConfig()
+	if err != nil {
+		return nil, err
 	}
-	return store.Open(dbPath)
+	dbPath, err := resolveDBPath(lc.Config)
+	if err != nil {
+		return nil, err
+	}
+	db, err := store.Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	db.MaxURIDepth = lc.Database.MaxURIDepth
+	db.DropL2 = !lc.Engine.StoreL2
+	return db, nil
 }
 
 // --- search command ---
@@ -105,6 +114,11 @@ var (
 	searchLimit    int
 	searchCategory string
 	searchExplain  bool
+	searchSession  string
+	searchURIOnly  bool
+	searchFresh    float64
+	searchRerank   bool
+	searchMinScore float64
 )
 
 var searchCmd = &cobra.Command{
@@ -122,6 +136,9 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	if !client.Healthy() {
 		return fmt.Errorf("continuity server is not running — start it with: continuity serve")
 	}
+	if ok, reason := client.Ready(); !ok {
+		fmt.Fprintf(os.Stderr, "warning: server is not ready (%s) — results may be incomplete\n", reason)
+	}
 
 	// Build query params
 	params := url.Values{}
@@ -132,6 +149,18 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	}
 	if searchSmart {
 		params.Set("mode", "search")
+		if searchRerank {
+			params.Set("rerank", "true")
+		}
+	}
+	if searchSession != "" {
+		params.Set("session_id", searchSession)
+	}
+	if searchFresh > 0 {
+		params.Set("freshness", strconv.FormatFloat(searchFresh, 'f', -1, 64))
+	}
+	if cmd.Flags().Changed("min-score") {
+		params.Set("min_score", strconv.FormatFloat(searchMinScore, 'f', -1, 64))
 	}
 
 	data, err := client.Get("/api/search?" + params.Encode())
@@ -140,10 +169,11 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	}
 
 	var resp struct {
-		Query   string `json:"query"`
-		Mode    string `json:"mode"`
-		Count   int    `json:"count"`
-		Results []struct {
+		Query    string  `json:"query"`
+		Mode     string  `json:"mode"`
+		Count    int     `json:"count"`
+		MinScore float64 `json:"min_score"`
+		Results  []struct {
 			URI        string  `json:"uri"`
 			Category   string  `json:"category"`
 			L0Abstract string  `json:"l0_abstract"`
@@ -151,14 +181,29 @@ func runSearch(cmd *cobra.Command, args []string) error {
 			Score      float64 `json:"score"`
 			Similarity float64 `json:"similarity"`
 			Relevance  float64 `json:"relevance"`
+			Freshness  float64 `json:"freshness"`
+			Snippet    string  `json:"snippet"`
 		} `json:"results"`
 	}
 	if err := json.Unmarshal(data, &resp); err != nil {
 		return fmt.Errorf("parse response: %w", err)
 	}
 
+	if searchURIOnly {
+		// Nothing but URIs on stdout, so the output pipes cleanly:
+		//   continuity search --uri-only foo | continuity retract - -r "..."
+		for _, r := range resp.Results {
+			fmt.Println(r.URI)
+		}
+		return nil
+	}
+
 	if resp.Count == 0 {
-		fmt.Println("No results found.")
+		if resp.MinScore > 0 {
+			fmt.Printf("No relevant results (nothing has similarity %.2f or more).\n", resp.MinScore)
+		} else {
+			fmt.Println("No results found.")
+		}
 		return nil
 	}
 
@@ -167,16 +212,24 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		if searchExplain {
 			// Score decomposition — so ranking can be inspected from the CLI
 			// instead of curling /api/search and parsing JSON by hand.
-			fmt.Printf("   score=%.3f = similarity=%.3f x relevance=%.3f (x category boost)\n", r.Score, r.Similarity, r.Relevance)
+			fmt.Printf("   score=%.3f = similarity=%.3f x relevance=%.3f (x category boost)", r.Score, r.Similarity, r.Relevance)
+			if r.Freshness > 0 {
+				fmt.Printf(" + freshness=%.3f", r.Freshness)
+			}
+			fmt.Println()
 		}
 		fmt.Printf("   %s [%s]\n", r.L0Abstract, r.Category)
-		if r.L1Overview != "" {
-			// Show first 200 chars of L1
-			overview := r.L1Overview
-			if len(overview) > 200 {
-				overview = overview[:200] + "..."
+		// The server picks the L1 sentence that matched; an older server
+		// without snippets gets the opening of L1.
+		snippet := r.Snippet
+		if snippet == "" {
+			snippet = engine.Snippet(r.L1Overview, "")
+		}
+		if snippet != "" {
+			if stdoutIsTerminal() {
+				snippet = engine.HighlightTerms(snippet, query, "\033[1m", "\033[0m")
 			}
-			fmt.Printf("   %s\n", overview)
+			fmt.Printf("   %s\n", snippet)
 		}
 		fmt.Println()
 	}
@@ -184,17 +237,38 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// stdoutIsTerminal reports whether stdout is a terminal rather than a pipe or
+// file, so highlighting escapes never end up in piped output.
+func stdoutIsTerminal() bool {
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
 // --- profile command ---
 
-var profileVerbose bool
+var (
+	profileVerbose  bool
+	profileRebuild  bool
+	profileSessions int
+)
 
 var profileCmd = &cobra.Command{
 	Use:   "profile",
 	Short: "Show relational profile",
-	RunE:  runProfile,
+	Long: `Show the relational profile.
+
+With --rebuild, ask the running server to reconstruct the profile from the
+transcripts of the last --sessions completed sessions (oldest first). Use this
+after deleting or corrupting the profile instead of waiting for new sessions.
+Only sessions whose transcript path was recorded at extraction time qualify.`,
+	RunE: runProfile,
 }
 
 func runProfile(cmd *cobra.Command, args []string) error {
+	if profileRebuild {
+		return runProfileRebuild()
+	}
+
 	db, err := openDB()
 	if err != nil {
 		return fmt.Errorf("open db: %w", err)
@@ -225,7 +299,7 @@ func runProfile(cmd *cobra.Command, args []string) error {
 				if n.URI == "mem://user/profile/communication" {
 					continue
 				}
-				fmt.Printf("- %s: %s\n", n.URI, n.L0Abstract)
+				fmt.Printf("- %s: %s%s\n", n.URI, n.L0Abstract, relevanceMark(n))
 			}
 			fmt.Println()
 		}
@@ -235,7 +309,7 @@ func runProfile(cmd *cobra.Command, args []string) error {
 		if len(prefs) > 0 {
 			fmt.Println("## Preferences")
 			for _, n := range prefs {
-				fmt.Printf("- %s: %s\n", n.URI, n.L0Abstract)
+				fmt.Printf("- %s: %s%s\n", n.URI, n.L0Abstract, relevanceMark(n))
 			}
 			fmt.Println()
 		}
@@ -244,68 +318,98 @@ func runProfile(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runProfileRebuild() error {
+	warnIfSkewed()
+
+	client := hooks.NewClient()
+	if !client.Healthy() {
+		return fmt.Errorf("continuity server is not running — start it with: continuity serve")
+	}
+
+	body, err := json.Marshal(map[string]int{"sessions": profileSessions})
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	data, err := client.Post("/api/profile/rebuild", body)
+	if err != nil {
+		return fmt.Errorf("rebuild profile: %w", err)
+	}
+
+	var resp struct {
+		Sessions int `json:"sessions"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	fmt.Printf("Rebuilding relational profile from %d sessions in the background.\n", resp.Sessions)
+	fmt.Println("Run `continuity profile` in a minute or two to see the result.")
+	return nil
+}
+
 // --- tree command ---
 
-var treeIncludeRetracted bool
+var (
+	treeIncludeRetracted bool
+	treeDepth            int
+	treeVerbose          bool
+	treeSort             string
+)
 
 var treeCmd = &cobra.Command{
-	Use:   "tree [uri]",
+	Use:   "tree [uri | -]",
 	Short: "Browse memory tree",
-	Long:  "List memory tree nodes. With no argument, shows root dirs. With a URI, shows children.",
+	Long:  "List memory tree nodes. With no argument, shows root dirs. With a URI, shows children. With -, reads URIs from stdin (one per line) and lists the children of each. --depth N renders N levels below each as an indented tree; --verbose notes memories merged from others. --sort relevance or --sort updated lists the most relevant or most recently updated first.",
+	Args:  cobra.MaximumNArgs(1),
 	RunE:  runTree,
 }
 
 func init() {
 	treeCmd.Flags().BoolVar(&treeIncludeRetracted, "include-retracted", false, "Include retracted memories in the listing")
+	treeCmd.Flags().IntVar(&treeDepth, "depth", 0, "Recurse this many levels and print an indented tree (0: one flat level)")
+	treeCmd.Flags().BoolVarP(&treeVerbose, "verbose", "v", false, "Show how many memories each one was merged from")
+	treeCmd.Flags().StringVar(&treeSort, "sort", "uri", "Order children by uri, relevance, or updated")
 }
 
 func runTree(cmd *cobra.Command, args []string) error {
+	if treeDepth < 0 {
+		return fmt.Errorf("--depth must be >= 0, got %d", treeDepth)
+	}
+	order, err := store.ParseChildOrder(treeSort)
+	if err != nil {
+		return fmt.Errorf("--sort: %w", err)
+	}
+	treeSort = string(order)
+	list := printTreeChildren
+	if treeDepth > 0 {
+		list = func(db *store.DB, uri string) error { return printTreeDepth(db, uri, treeDepth) }
+	}
+
 	db, err := openDB()
 	if err != nil {
 		return fmt.Errorf("open db: %w", err)
 	}
 	defer db.Close()
 
-	if len(args) > 0 {
-		// Show children of the given URI
-		uri := args[0]
-		var children []store.MemNode
-		if treeIncludeRetracted {
-			children, err = db.GetChildrenIncludingRetracted(uri)
-		} else {
-			children, err = db.GetChildren(uri)
-		}
+	if len(args) > 0 && args[0] == "-" {
+		uris, err := readURIs(os.Stdin)
 		if err != nil {
-			return fmt.Errorf("get children: %w", err)
+			return err
 		}
-		if len(children) == 0 {
-			fmt.Printf("No children found for %s\n", uri)
-			return nil
-		}
-		fmt.Printf("## %s\n\n", uri)
-		for _, c := range children {
-			suffix := ""
-			if c.NodeType == "dir" {
-				var count int
-				if treeIncludeRetracted {
-					count, _ = db.CountChildren(c.URI)
-				} else {
-					count, _ = db.CountLiveChildren(c.URI)
-				}
-				suffix = fmt.Sprintf(" (%d children)", count)
+		for i, uri := range uris {
+			if i > 0 {
+				fmt.Println()
 			}
-			if c.IsRetracted() {
-				suffix += " [retracted]"
-			}
-			if c.L0Abstract != "" && !c.IsRetracted() {
-				fmt.Printf("  %s %s%s\n    %s\n", c.NodeType, c.URI, suffix, c.L0Abstract)
-			} else {
-				fmt.Printf("  %s %s%s\n", c.NodeType, c.URI, suffix)
+			if err := list(db, uri); err != nil {
+				return err
 			}
 		}
 		return nil
 	}
 
+	if len(args) > 0 {
+		return list(db, args[0])
+	}
+
 	// Show roots with child counts
 	roots, err := db.ListRoots()
 	if err != nil {
@@ -319,6 +423,17 @@ func runTree(cmd *cobra.Command, args []string) error {
 
 	fmt.Println("## Memory Tree")
 	fmt.Println()
+	if treeDepth > 0 {
+		for i, r := range roots {
+			if i > 0 {
+				fmt.Println()
+			}
+			if err := printTreeDepth(db, r.URI, treeDepth); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	for _, r := range roots {
 		var count int
 		if treeIncludeRetracted {
@@ -332,23 +447,203 @@ func runTree(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// treeChildren returns the children of uri, honoring --include-retracted and
+// --sort.
+func treeChildren(db *store.DB, uri string) ([]store.MemNode, error) {
+	var (
+		children []store.MemNode
+		err      error
+	)
+	if treeIncludeRetracted {
+		children, err = db.GetChildrenIncludingRetracted(uri, store.ChildOrder(treeSort))
+	} else {
+		children, err = db.GetChildren(uri, store.ChildOrder(treeSort))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get children: %w", err)
+	}
+	return children, nil
+}
+
+// treeChildCount counts the children of a dir, honoring --include-retracted.
+func treeChildCount(db *store.DB, uri string) int {
+	var count int
+	if treeIncludeRetracted {
+		count, _ = db.CountChildren(uri)
+	} else {
+		count, _ = db.CountLiveChildren(uri)
+	}
+	return count
+}
+
+// printTreeChildren lists the children of uri, honoring --include-retracted.
+func printTreeChildren(db *store.DB, uri string) error {
+	children, err := treeChildren(db, uri)
+	if err != nil {
+		return err
+	}
+	if len(children) == 0 {
+		fmt.Printf("No children found for %s\n", uri)
+		return nil
+	}
+	fmt.Printf("## %s\n\n", uri)
+	for _, c := range children {
+		suffix := ""
+		if c.NodeType == "dir" {
+			suffix = fmt.Sprintf(" (%d children)", treeChildCount(db, c.URI))
+		}
+		if c.IsRetracted() {
+			suffix += " [retracted]"
+		}
+		suffix += relevanceMark(c) + mergedFromMark(c)
+		if c.L0Abstract != "" && !c.IsRetracted() {
+			fmt.Printf("  %s %s%s\n    %s\n", c.NodeType, c.URI, suffix, c.L0Abstract)
+		} else {
+			fmt.Printf("  %s %s%s\n", c.NodeType, c.URI, suffix)
+		}
+		if c.NodeType == "leaf" && !c.IsRetracted() {
+			links, err := db.GetLinks(c.URI)
+			if err != nil {
+				return fmt.Errorf("get links: %w", err)
+			}
+			for _, l := range links {
+				if l.FromURI == c.URI {
+					fmt.Printf("    → %s %s\n", l.Relation, l.ToURI)
+				} else {
+					fmt.Printf("    ← %s %s\n", l.Relation, l.FromURI)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// mergedFromMark is the --verbose note on a memory that absorbed others,
+// by merge or dedup; empty otherwise.
+func mergedFromMark(n store.MemNode) string {
+	if !treeVerbose {
+		return ""
+	}
+	ids, err := store.ParseMergedFrom(n.MergedFrom)
+	if err != nil || len(ids) == 0 {
+		return ""
+	}
+	if len(ids) == 1 {
+		return " [merged from 1 source]"
+	}
+	return fmt.Sprintf(" [merged from %d sources]", len(ids))
+}
+
+// printTreeDepth renders the subtree under uri, depth levels deep, as an
+// indented tree: dirs end in "/" with their child count, leaves show their
+// relevance and L0. A dir at the depth limit keeps its count so it's clear
+// there is more below.
+func printTreeDepth(db *store.DB, uri string, depth int) error {
+	fmt.Println(uri)
+	return printSubtree(db, uri, depth, 1, map[string]bool{uri: true})
+}
+
+func printSubtree(db *store.DB, uri string, depth, level int, seen map[string]bool) error {
+	children, err := treeChildren(db, uri)
+	if err != nil {
+		return err
+	}
+	indent := strings.Repeat("  ", level)
+	for _, c := range children {
+		name := c.URI[strings.LastIndex(c.URI, "/")+1:]
+		// parent_uri is a plain self-referencing column, so nothing in the
+		// schema forbids a loop; never walk into a node twice.
+		if seen[c.URI] {
+			fmt.Printf("%s%s [cycle: %s]\n", indent, name, c.URI)
+			continue
+		}
+		seen[c.URI] = true
+
+		if c.NodeType == "dir" {
+			fmt.Printf("%s%s/ (%d)\n", indent, name, treeChildCount(db, c.URI))
+			if level < depth {
+				if err := printSubtree(db, c.URI, depth, level+1, seen); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		mark := ""
+		if c.IsRetracted() {
+			mark = " [retracted]"
+		} else if c.IsRelevanceSet() {
+			mark = " [hand-set]"
+		}
+		fmt.Printf("%s%s  %.2f%s%s", indent, name, c.Relevance, mark, mergedFromMark(c))
+		if c.L0Abstract != "" && !c.IsRetracted() {
+			fmt.Printf("  %s", c.L0Abstract)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
 // --- dedup command ---
 
 var (
-	dedupThreshold float64
-	dedupDryRun    bool
+	dedupThreshold      float64
+	dedupDryRun         bool
+	dedupEmbedderChoice string
 )
 
 var dedupCmd = &cobra.Command{
 	Use:   "dedup",
 	Short: "Deduplicate semantically similar memory nodes",
-	Long:  "Finds and merges duplicate memory nodes using cosine similarity. Uses Ollama if available, otherwise the hashed lexical fallback. When --threshold is not set, the default is calibrated to the active embedder (lower for the lexical fallback), matching the engine's automatic dedup.",
+	Long:  "Finds and merges duplicate memory nodes using cosine similarity. Uses Ollama if available, otherwise the hashed lexical fallback; --embedder forces one or the other, and a forced ollama that fails its probe is an error rather than a silent fallback. When --threshold is not set, the default is calibrated to the active embedder (lower for the lexical fallback), matching the engine's automatic dedup.",
 	RunE:  runDedup,
 }
 
 func init() {
 	dedupCmd.Flags().Float64Var(&dedupThreshold, "threshold", 0.65, "Cosine similarity threshold (0.0-1.0); default is embedder-aware when unset")
 	dedupCmd.Flags().BoolVar(&dedupDryRun, "dry-run", false, "Show what would be removed without deleting")
+	dedupCmd.Flags().StringVar(&dedupEmbedderChoice, "embedder", "auto", "Embedder to use: ollama, tfidf, or auto (probe Ollama, fall back to tfidf)")
+}
+
+// dedupEmbedder builds the embedder for `dedup` from an explicit choice and
+// returns a one-line description of what was picked and why. On auto the
+// Ollama probe result is part of the description, so a fallback to tfidf is
+// never a mystery.
+func dedupEmbedder(choice string, cfg config.LLMConfig) (engine.Embedder, string, error) {
+	ollamaURL := cfg.OllamaURL
+	if ollamaURL == "" {
+		ollamaURL = "http://localhost:11434"
+	}
+	embeddingModel := cfg.EmbeddingModel
+	if embeddingModel == "" {
+		embeddingModel = "nomic-embed-text"
+	}
+
+	switch strings.ToLower(strings.TrimSpace(choice)) {
+	case "tfidf":
+		emb, err := newLexicalEmbedder(cfg)
+		if err != nil {
+			return nil, "", fmt.Errorf("init tfidf embedder: %w", err)
+		}
+		return emb, "tfidf (forced)", nil
+	case "ollama":
+		probe := engine.ProbeOllamaDetail(ollamaURL, embeddingModel)
+		if !probe.OK() {
+			return nil, "", fmt.Errorf("--embedder ollama: %s at %s is unusable: %s", embeddingModel, ollamaURL, probe)
+		}
+		return newOllamaEmbedder(cfg, ollamaURL, embeddingModel), fmt.Sprintf("ollama (%s, forced)", embeddingModel), nil
+	case "", "auto":
+		probe := engine.ProbeOllamaDetail(ollamaURL, embeddingModel)
+		if probe.OK() {
+			return newOllamaEmbedder(cfg, ollamaURL, embeddingModel), fmt.Sprintf("ollama (%s; probe: %s)", embeddingModel, probe), nil
+		}
+		emb, err := newLexicalEmbedder(cfg)
+		if err != nil {
+			return nil, "", fmt.Errorf("init tfidf embedder: %w", err)
+		}
+		return emb, fmt.Sprintf("tfidf (fallback; ollama probe at %s: %s)", ollamaURL, probe), nil
+	default:
+		return nil, "", fmt.Errorf("--embedder %q: want ollama, tfidf, or auto", choice)
+	}
 }
 
 func runDedup(cmd *cobra.Command, args []string) error {
@@ -365,20 +660,15 @@ func runDedup(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Printf("Nodes before: %d\n", len(leavesBefore))
 
-	// Set up embedder
-	var emb engine.Embedder
-	ollamaURL := "http://localhost:11434"
-	embeddingModel := "nomic-embed-text"
-	if engine.ProbeOllama(ollamaURL, embeddingModel) {
-		emb = engine.NewOllamaEmbedder(ollamaURL, embeddingModel, 768)
-		fmt.Printf("Embedder: ollama (%s)\n", embeddingModel)
-	} else {
-		emb, err = engine.NewHashEmbedder(0)
-		if err != nil {
-			return fmt.Errorf("init tfidf embedder: %w", err)
-		}
-		fmt.Println("Embedder: tfidf (fallback)")
+	lc, err := loadConfig()
+	if err != nil {
+		return err
 	}
+	emb, desc, err := dedupEmbedder(dedupEmbedderChoice, lc.LLM)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Embedder: %s\n", desc)
 
 	eng := engine.New(db, nil)
 	eng.SetEmbedder(emb)
@@ -423,10 +713,74 @@ func runDedup(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// --- import command (still a stub) ---
+// --- import command ---
 
 var importCmd = &cobra.Command{
-	Use:   "import",
-	Short: "Import from claude-mem database",
-	Run:   stubRun("import"),
+	Use:   "import <file | ->",
+	Short: "Import memories from a jsonl export",
+	Long: `Import memories written by ` + "`continuity export --format jsonl`" + `, from a file
+or stdin ("-"). Records are read one at a time, so memory use stays flat
+however large the export. Each memory keeps its recorded relevance, pin,
+retraction and vector; a memory whose URI already exists is skipped, so an
+import can be re-run safely. A vector from a different embedding model or
+dimension than this database's is dropped, and the server re-embeds that
+memory when it next starts.
+
+Importing a claude-mem database is not yet supported.
+
+Reads and writes the database directly; the server need not be running.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	in := io.Reader(os.Stdin)
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	r := bufio.NewReader(in)
+	if err := checkJSONLines(r); err != nil {
+		return err
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer db.Close()
+
+	keep, err := engine.ImportVectorFilter(db)
+	if err != nil {
+		return fmt.Errorf("read vector identity: %w", err)
+	}
+	res, err := db.ImportJSONL(r, keep)
+	fmt.Printf("Imported: %d\nSkipped (URI exists): %d\n", res.Imported, res.Skipped)
+	if res.VectorsDropped > 0 {
+		fmt.Printf("Vectors dropped (other embedder; re-embedded by the next serve): %d\n", res.VectorsDropped)
+	}
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	return nil
+}
+
+// checkJSONLines peeks at the start of r and rejects anything that isn't a
+// jsonl export: a SQLite file (a claude-mem database) or a JSON array.
+func checkJSONLines(r *bufio.Reader) error {
+	head, _ := r.Peek(512)
+	trimmed := bytes.TrimLeft(head, " \t\r\n")
+	switch {
+	case len(trimmed) == 0:
+		return nil // empty input imports nothing
+	case bytes.HasPrefix(head, []byte("SQLite format 3")):
+		return fmt.Errorf("importing a claude-mem database is not yet supported")
+	case trimmed[0] != '{':
+		return fmt.Errorf("not a jsonl export (want one JSON object per line, from `continuity export --format jsonl`)")
+	}
+	return nil
 }
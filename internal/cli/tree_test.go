@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lazypower/continuity/internal/store"
+)
+
+func TestPrintTreeDepth(t *testing.T) {
+	db, err := store.OpenMemory()
+	if err != nil {
+		t.Fatalf("OpenMemory: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for _, n := range []*store.MemNode{
+		{URI: "mem://user/preferences/tabs", Category: "preferences", L0Abstract: "Tabs over spaces"},
+		{URI: "mem://user/events/ship", Category: "events", L0Abstract: "Shipped v2"},
+	} {
+		n.NodeType = "leaf"
+		if err := db.CreateNode(n); err != nil {
+			t.Fatalf("CreateNode %s: %v", n.URI, err)
+		}
+	}
+
+	out, err := captureStdout(t, func() error { return printTreeDepth(db, "mem://user", 2) })
+	if err != nil {
+		t.Fatalf("printTreeDepth: %v", err)
+	}
+	for _, want := range []string{
+		"mem://user\n",
+		"  preferences/ (1)\n",
+		"    tabs  1.00  Tabs over spaces\n",
+		"  events/ (1)\n",
+		"    ship  1.00  Shipped v2\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("depth 2 output missing %q:\n%s", want, out)
+		}
+	}
+
+	// Depth 1 stops at the category dirs but keeps their counts.
+	out, _ = captureStdout(t, func() error { return printTreeDepth(db, "mem://user", 1) })
+	if strings.Contains(out, "tabs") || !strings.Contains(out, "  preferences/ (1)\n") {
+		t.Errorf("depth 1 output:\n%s", out)
+	}
+
+	// --verbose counts merge sources.
+	if _, err := db.Exec(`UPDATE mem_nodes SET merged_from = '[7, 9]' WHERE uri = 'mem://user/events/ship'`); err != nil {
+		t.Fatalf("set merged_from: %v", err)
+	}
+	treeVerbose = true
+	out, _ = captureStdout(t, func() error { return printTreeDepth(db, "mem://user", 2) })
+	treeVerbose = false
+	if !strings.Contains(out, "ship  1.00 [merged from 2 sources]  Shipped v2") {
+		t.Errorf("verbose output:\n%s", out)
+	}
+
+	// A parent_uri loop is reported, not followed.
+	if _, err := db.Exec(`UPDATE mem_nodes SET parent_uri = 'mem://user/preferences' WHERE uri = 'mem://user'`); err != nil {
+		t.Fatalf("make cycle: %v", err)
+	}
+	out, err = captureStdout(t, func() error { return printTreeDepth(db, "mem://user", 10) })
+	if err != nil {
+		t.Fatalf("printTreeDepth with cycle: %v", err)
+	}
+	if !strings.Contains(out, "[cycle: mem://user]") {
+		t.Errorf("cycle not reported:\n%s", out)
+	}
+}
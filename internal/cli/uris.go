@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// stdinIsPiped reports whether stdin is a pipe or file rather than a terminal,
+// so commands only block on stdin when something is actually feeding it.
+func stdinIsPiped() bool {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice == 0
+}
+
+// readURIs reads one mem:// URI per line, as printed by `search --uri-only`.
+// Blank lines are skipped; anything else that isn't a mem:// URI is an error
+// rather than being silently passed on to a write command.
+func readURIs(r io.Reader) ([]string, error) {
+	var uris []string
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		uri := strings.TrimSpace(sc.Text())
+		if uri == "" {
+			continue
+		}
+		if !strings.HasPrefix(uri, "mem://") {
+			return nil, fmt.Errorf("stdin line %d: invalid URI %q: must start with mem://", line, uri)
+		}
+		uris = append(uris, uri)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read stdin: %w", err)
+	}
+	return uris, nil
+}
@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadURIs(t *testing.T) {
+	in := "mem://user/events/a\n\n  mem://user/events/b  \n"
+	got, err := readURIs(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("readURIs: %v", err)
+	}
+	want := []string{"mem://user/events/a", "mem://user/events/b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readURIs = %v, want %v", got, want)
+	}
+
+	if _, err := readURIs(strings.NewReader("mem://user/events/a\n1. [0.900] mem://user/events/b\n")); err == nil {
+		t.Error("expected error for non-URI line (e.g. piping search output without --uri-only)")
+	}
+}
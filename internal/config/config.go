@@ -2,22 +2,40 @@ package config
 
 import "fmt"
 
-// Config holds all continuity configuration.
-// Phase 0: types and defaults only. Phase 1 adds Load() with TOML parsing.
+// Config holds all continuity configuration. Default() is a complete
+// configuration; Load overlays ~/.continuity/config.toml on top of it.
 type Config struct {
 	Server   ServerConfig   `toml:"server"`
 	Database DatabaseConfig `toml:"database"`
 	LLM      LLMConfig      `toml:"llm"`
 	Hooks    HooksConfig    `toml:"hooks"`
+	Engine   EngineConfig   `toml:"engine"`
+	Context  ContextConfig  `toml:"context"`
 }
 
 type ServerConfig struct {
 	Bind string `toml:"bind"`
 	Port int    `toml:"port"`
+
+	// CORSOrigins lists the browser origins ("http://localhost:5173") allowed
+	// to call the API cross-origin; "http://localhost:*" allows any port on a
+	// loopback host. Empty (the default) sends no CORS headers, so browsers
+	// keep blocking cross-origin calls.
+	CORSOrigins []string `toml:"cors_origins"`
 }
 
 type DatabaseConfig struct {
 	Path string `toml:"path"`
+
+	// MaxURIDepth rejects memory URIs with more path segments than this
+	// (mem://user/profile/x is 3). Guards against degenerate hierarchies.
+	MaxURIDepth int `toml:"max_uri_depth"`
+
+	// NodeCacheSize enables an LRU of this many recently read memories in
+	// front of URI lookups, for the hot paths that re-read the same nodes.
+	// Writes invalidate it, including another process's. 0 (the default)
+	// disables it.
+	NodeCacheSize int `toml:"node_cache_size"`
 }
 
 type LLMConfig struct {
@@ -28,33 +46,317 @@ type LLMConfig struct {
 	OllamaModel    string `toml:"ollama_model"`    // e.g. "llama3.2"
 	EmbeddingModel string `toml:"embedding_model"` // e.g. "nomic-embed-text"
 	AnthropicKey   string `toml:"anthropic_key"`
+
+	// EmbedConcurrency bounds how many Ollama embedding requests run at once
+	// (2 by default), so concurrent searches, extractions and the startup
+	// backfill queue up instead of thrashing a single GPU. 0 is unbounded.
+	EmbedConcurrency int `toml:"embed_concurrency"`
+
+	// Alternatives to a plaintext anthropic_key: a file holding only the key
+	// (must be mode 0600), or the service name of a macOS keychain item.
+	// Precedence: anthropic_key > anthropic_key_file > anthropic_keychain >
+	// ANTHROPIC_API_KEY. See ResolveAnthropicKey.
+	AnthropicKeyFile  string `toml:"anthropic_key_file"`
+	AnthropicKeychain string `toml:"anthropic_keychain"`
+
+	// ClaudeBinary is the claude executable the claude-cli provider runs,
+	// "" meaning "claude" on $PATH. A bare name is looked up on $PATH and a
+	// leading ~/ is the home directory; an absolute path sidesteps a service
+	// manager's minimal PATH. ClaudeSystemPrompt, when set, is passed as the
+	// CLI's --system-prompt.
+	ClaudeBinary       string `toml:"claude_binary"`
+	ClaudeSystemPrompt string `toml:"claude_system_prompt"`
+
+	// LexicalStemming stems terms in the hashed lexical (tfidf) fallback
+	// embedder. It is a different vector space ("hashtf-stem"), so toggling
+	// it on an existing corpus locks search until the vectors are repaired.
+	LexicalStemming bool `toml:"lexical_stemming"`
+
+	// Generation parameters for the HTTP providers (anthropic, ollama).
+	// MaxTokens <= 0 falls back to 2048. Temperature 0 is honored
+	// (deterministic extraction). The claude CLI ignores both.
+	MaxTokens   int     `toml:"max_tokens"`
+	Temperature float64 `toml:"temperature"`
 }
 
 type HooksConfig struct {
 	Enabled bool `toml:"enabled"`
 	Timeout int  `toml:"timeout"` // seconds
+
+	// SkipTools names tools whose calls are never recorded as observations,
+	// on top of the built-in meta-tools (TodoWrite, Thinking, ...). The hook
+	// drops the built-ins before posting; the server refuses these.
+	SkipTools []string `toml:"skip_tools"`
+}
+
+// EngineConfig tunes the extraction pipeline.
+type EngineConfig struct {
+	// Content gate: sessions below either threshold are skipped (not marked
+	// extracted) and the reason is recorded on the session row.
+	MinUserMessages   int `toml:"min_user_messages"`
+	MinCondensedChars int `toml:"min_condensed_chars"`
+
+	// MaxCondensedChars caps the condensed transcript sent to the LLM by
+	// each extraction pass, so an outlier session can't run up an outsized
+	// bill. Oversized transcripts keep their head and tail. 0 disables it.
+	MaxCondensedChars int `toml:"max_condensed_chars"`
+
+	// GenericPhrases marks an extracted L0 as too vague to keep ("user is
+	// experienced with Go"). Matched case-insensitively as substrings.
+	GenericPhrases []string `toml:"generic_phrases"`
+
+	// ImmutableDedupThreshold is the cosine similarity at or above which an
+	// extracted candidate in an immutable category (events, entities, cases,
+	// ...) is treated as a restatement of an existing node and skipped instead
+	// of written under a suffixed URI. Kept high so distinct-but-similar events
+	// survive; 0 disables the check.
+	ImmutableDedupThreshold float64 `toml:"immutable_dedup_threshold"`
+
+	// ImmutableKeep bounds how many versions of one immutable slug survive:
+	// each collision writes a slug-{timestamp} copy, and once there are more
+	// than ImmutableKeep live ones the oldest are deleted. 0 keeps them all.
+	ImmutableKeep int `toml:"immutable_keep"`
+
+	// CategoryCaps bounds how many live leaves a category keeps, e.g.
+	// { preferences = 50 }. A write that takes a category past its cap
+	// deletes the lowest-scoring (relevance × access) memories in it; pinned
+	// ones are never deleted and don't count. Categories not listed, and a
+	// cap of 0, are unbounded (the default).
+	CategoryCaps map[string]int `toml:"category_caps"`
+
+	// Transcript skip rules (transcript.ParseOpts): assistant entries shorter
+	// than TranscriptMinLength bytes are dropped as noise, and entries that
+	// start with '{' are dropped as tool payloads unless TranscriptKeepJSON.
+	// User entries are kept at any length.
+	TranscriptMinLength int  `toml:"transcript_min_length"`
+	TranscriptKeepJSON  bool `toml:"transcript_keep_json"`
+
+	// Background extraction pool: at most Workers extractions/signals run
+	// concurrently, QueueSize more wait, and anything beyond that is refused
+	// with 503 so a burst of hook re-fires can't swamp the LLM provider.
+	Workers   int `toml:"workers"`
+	QueueSize int `toml:"queue_size"`
+
+	// Language memory content and the relational profile are written in
+	// (e.g. "German"). The extraction prompts ask the model to keep the
+	// user's own language instead of translating it. Empty or "English"
+	// leaves the prompts as they are.
+	Language string `toml:"language"`
+
+	// MergeStrategy decides what happens when extraction or a signal writes
+	// new content over an existing mergeable memory (profile, preferences,
+	// ...): "replace" (the default) keeps only the new version; "union" also
+	// keeps the old L2 paragraphs the new one lacks; "llm" additionally asks
+	// llm.merge_model for a merged L1 when both versions are substantial.
+	MergeStrategy string `toml:"merge_strategy"`
+
+	// DecayInterval is how often relevance decay runs, as a Go duration
+	// ("24h", "168h" for weekly). Whether a run is due is judged by wall
+	// clock against the last recorded run, so a machine that slept through
+	// several intervals decays once on wake rather than drifting.
+	DecayInterval string `toml:"decay_interval"`
+
+	// RetryFailedInterval is how often the server re-runs extractions that
+	// errored (LLM down, transcript missing), as a Go duration ("1h"). Empty
+	// or "0" (the default) retries only on `continuity retry-failed`.
+	RetryFailedInterval string `toml:"retry_failed_interval"`
+
+	// RetryFailedMaxAttempts stops background retries of a session after
+	// this many failed attempts; `continuity retry-failed --all` still tries.
+	// 0 retries without limit.
+	RetryFailedMaxAttempts int `toml:"retry_failed_max_attempts"`
+
+	// RelationalMinInterval is the least time between relational profile
+	// rewrites, as a Go duration ("6h" by default; "0" rewrites after every
+	// session). A session ending sooner after the last rewrite is skipped
+	// unless it has at least RelationalSubstantialMessages user messages.
+	RelationalMinInterval         string `toml:"relational_min_interval"`
+	RelationalSubstantialMessages int    `toml:"relational_substantial_messages"`
+
+	// DecayVerbose logs a summary of every scheduled decay run — how many
+	// memories fell below the context threshold or hit the floor, and where
+	// the new relevances landed — instead of just the update count.
+	DecayVerbose bool `toml:"decay_verbose"`
+
+	// DecayHalfLifeDays is how long a memory takes to lose half its
+	// relevance, measured from when it was created (90 by default).
+	// Retrieval doesn't reset that clock; it adds AccessBoost (0.3 by
+	// default, 0 turns it off), a bonus that itself halves every
+	// AccessBoostHalfLifeDays (14 by default) without another retrieval.
+	DecayHalfLifeDays       int     `toml:"decay_half_life_days"`
+	AccessBoost             float64 `toml:"access_boost"`
+	AccessBoostHalfLifeDays int     `toml:"access_boost_half_life_days"`
+
+	// ObservationRetentionDays bounds the observations table: tool-use rows
+	// older than this are deleted once their session has been extracted,
+	// on the decay schedule. 0 (the default) keeps them forever.
+	ObservationRetentionDays int `toml:"observation_retention_days"`
+
+	// ParentScoreWeight is how strongly smart search lifts a match whose
+	// siblings also match (0.2 by default; 0 ignores the tree).
+	// ParentScoreDepth is how many directory levels up that looks: 1 is
+	// the parent only, 2 adds the grandparent at half weight, and so on.
+	// Raise both for deeply nested memory trees.
+	ParentScoreWeight float64 `toml:"parent_score_weight"`
+	ParentScoreDepth  int     `toml:"parent_score_depth"`
+
+	// LinkScoreWeight is how strongly smart search lifts a match linked
+	// (see store.LinkNodes) to another match, scaled by the best linked
+	// match's similarity (0.1 by default; 0 ignores links).
+	LinkScoreWeight float64 `toml:"link_score_weight"`
+
+	// SearchMinScore drops search results whose similarity to the query is
+	// below it, so an off-topic query finds nothing rather than the least
+	// unrelated memories. 0 (the default) keeps every match; serve refuses a
+	// value outside [0, 1].
+	SearchMinScore float64 `toml:"search_min_score"`
+
+	// SignalDefaultCategory is where an explicit "remember this" lands when
+	// the message doesn't clearly fit another category; such messages are
+	// nearly always standing rules rather than events. A category named in
+	// the message itself ("remember this pattern: ...") overrides it. Empty
+	// leaves the choice wholly to the LLM.
+	SignalDefaultCategory string `toml:"signal_default_category"`
+
+	// RuleBasedSignals captures signals without an LLM when none is
+	// configured: the text after the trigger phrase is stored verbatim rather
+	// than the signal failing. `continuity serve --no-llm` turns it on.
+	RuleBasedSignals bool `toml:"rule_based_signals"`
+
+	// ExtractionPromptPath, when set, names a Go text/template file used in
+	// place of the built-in extraction prompt. It sees {{.Transcript}},
+	// {{.MaxCandidates}}, and {{.Language}}; the recursion-guard sentinel is
+	// prepended regardless. serve validates it at startup.
+	ExtractionPromptPath string `toml:"extraction_prompt_path"`
+
+	// SessionNotes lets extraction write notes that apply only to the
+	// session they came from (category "session"), such as a temporary
+	// constraint. They're injected only into that session's context and
+	// deleted when it ends. Off by default.
+	SessionNotes bool `toml:"session_notes"`
+
+	// AssistantInsights asks extraction to also keep non-obvious solutions
+	// the assistant worked out on its own, as cases, even when the user never
+	// flagged them. Such memories are stored with origin "assistant".
+	// Off by default.
+	AssistantInsights bool `toml:"assistant_insights"`
+
+	// StoreL2 keeps each memory's L2 full content (up to 40KB). Only L0 and
+	// L1 are injected into context or searched, so turning it off mostly
+	// trades the detail `show` prints for a smaller database. On by default.
+	StoreL2 bool `toml:"store_l2"`
+}
+
+// ContextConfig lays out the block injected at SessionStart.
+type ContextConfig struct {
+	// Sections to render, in order: working_with_you, pinned, constraints,
+	// session_notes, moments, profile, memories, sessions, current_session. A
+	// section left out is not injected at all.
+	Sections []string `toml:"sections"`
+
+	// Categories ranked into the profile and memories sections; their order
+	// breaks score ties. Moments, constraints, and the relational profile
+	// have their own sections and aren't listed here.
+	Categories []string `toml:"categories"`
+
+	// MaxAgeDays is the recency horizon of the ranked sections: a memory
+	// created more than this many days ago is never injected there, however
+	// relevant, unless it is pinned. It stays searchable. 0 (the default)
+	// sets no horizon.
+	MaxAgeDays int `toml:"max_age_days"`
+
+	// LightSessionObservations throttles priming after a quick session: when
+	// the previous session in the same project recorded fewer tool
+	// observations than this, the next SessionStart gets a light block of at
+	// most LightItems ranked memories. Pins, constraints, and the relational
+	// profile are unaffected. 0 (the default) turns it off; a hook can still
+	// ask for a light or full block (CONTINUITY_CONTEXT_WEIGHT).
+	LightSessionObservations int `toml:"light_session_observations"`
+	LightItems               int `toml:"light_items"`
 }
 
 // Default returns a Config with sensible defaults.
 func Default() Config {
 	return Config{
 		Server: ServerConfig{
-			Bind: "127.0.0.1",
-			Port: 37777,
+			Bind:        "127.0.0.1",
+			Port:        37777,
+			CORSOrigins: []string{},
 		},
 		Database: DatabaseConfig{
-			Path: "", // resolved at runtime via store.DefaultDBPath()
+			Path:        "", // resolved at runtime via store.DefaultDBPath()
+			MaxURIDepth: 6,
 		},
 		LLM: LLMConfig{
-			Provider:   "claude-cli",
-			Model:      "haiku",
-			MergeModel: "sonnet",
+			Provider:    "claude-cli",
+			Model:       "haiku",
+			MergeModel:  "sonnet",
+			MaxTokens:   2048,
+			Temperature: 0.3,
+
+			EmbedConcurrency: 2,
 		},
 		Hooks: HooksConfig{
-			Enabled: true,
-			Timeout: 120,
+			Enabled:   true,
+			Timeout:   120,
+			SkipTools: []string{},
 		},
+		Engine: EngineConfig{
+			MinUserMessages:   3,
+			MinCondensedChars: 100,
+			MaxCondensedChars: 100000,
+			GenericPhrases: []string{
+				"is experienced",
+				"is an experienced",
+				"is a skilled",
+				"is knowledgeable",
+				"prefers clean code",
+				"values code quality",
+				"writes tests",
+				"uses version control",
+				"cares about best practices",
+			},
+			ImmutableDedupThreshold: 0.92,
+			Workers:                 2,
+			QueueSize:               32,
+			Language:                "English",
+			MergeStrategy:           "replace",
+			CategoryCaps:            map[string]int{},
+			DecayInterval:           "24h",
+			RelationalMinInterval:   "6h",
+			RetryFailedMaxAttempts:  5,
+
+			RelationalSubstantialMessages: 20,
+			DecayHalfLifeDays:             90,
+			AccessBoost:                   0.3,
+			AccessBoostHalfLifeDays:       14,
+			SignalDefaultCategory:         "preferences",
+			ParentScoreWeight:             0.2,
+			ParentScoreDepth:              1,
+			LinkScoreWeight:               0.1,
+			TranscriptMinLength:           5,
+			StoreL2:                       true,
+		},
+		Context: ContextConfig{
+			Sections: []string{
+				"working_with_you", "pinned", "constraints", "session_notes", "moments",
+				"profile", "memories", "sessions", "current_session",
+			},
+			Categories: []string{
+				"profile", "preferences", "feedback", "patterns",
+				"events", "cases", "entities", "reference",
+			},
+			LightItems: 5,
+		},
+	}
+}
+
+// Redacted returns a copy of c with secrets masked, for display.
+func (c Config) Redacted() Config {
+	if c.LLM.AnthropicKey != "" {
+		c.LLM.AnthropicKey = "<redacted>"
 	}
+	return c
 }
 
 // ListenAddr returns the bind:port address string.
@@ -0,0 +1,25 @@
+//go:build darwin
+
+package config
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// keychainLookup reads the password of the generic keychain item with the
+// given service name, as stored by:
+//
+//	security add-generic-password -s <service> -a "$USER" -w <key>
+func keychainLookup(service string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-w").Output()
+	if err != nil {
+		return "", fmt.Errorf("keychain item not found or not readable: %w", err)
+	}
+	key := strings.TrimSpace(string(out))
+	if key == "" {
+		return "", fmt.Errorf("keychain item is empty")
+	}
+	return key, nil
+}
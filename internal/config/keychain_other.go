@@ -0,0 +1,10 @@
+//go:build !darwin
+
+package config
+
+import "fmt"
+
+// keychainLookup is macOS-only; elsewhere use anthropic_key_file.
+func keychainLookup(service string) (string, error) {
+	return "", fmt.Errorf("keychain lookup is only supported on macOS — use anthropic_key_file instead")
+}
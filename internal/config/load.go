@@ -0,0 +1,361 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultPath returns ~/.continuity/config.toml, next to the default database.
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home dir: %w", err)
+	}
+	return filepath.Join(home, ".continuity", "config.toml"), nil
+}
+
+// Load returns Default() overlaid with the TOML file at path. A missing file
+// is not an error — defaults are a complete configuration — and found reports
+// whether one was read. Unknown sections or keys ARE errors: a typo like
+// `provder = "ollama"` silently falling back to claude-cli is exactly the
+// confusion this file exists to prevent.
+func Load(path string) (cfg Config, found bool, err error) {
+	cfg = Default()
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, false, nil
+	}
+	if err != nil {
+		return cfg, false, fmt.Errorf("open config: %w", err)
+	}
+	defer f.Close()
+
+	if err := decode(f, &cfg); err != nil {
+		return cfg, true, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, true, nil
+}
+
+// decode applies a TOML document to cfg. It understands the subset the config
+// uses: [section] tables, and key = value where value is a basic string,
+// integer, float, boolean, an array of strings (which may span lines), or a
+// single-line inline table of integers. Comments and blank lines are
+// skipped. Keys map to fields by their `toml` tag.
+func decode(r io.Reader, cfg *Config) error {
+	var section reflect.Value
+	sectionName := ""
+
+	sc := bufio.NewScanner(r)
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := strings.TrimSpace(stripComment(sc.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return fmt.Errorf("line %d: malformed section header %q", lineNo, line)
+			}
+			sectionName = strings.TrimSpace(line[1 : len(line)-1])
+			f, ok := fieldByTag(reflect.ValueOf(cfg).Elem(), sectionName)
+			if !ok || f.Kind() != reflect.Struct {
+				return fmt.Errorf("line %d: unknown section [%s]", lineNo, sectionName)
+			}
+			section = f
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		key, raw = strings.TrimSpace(key), strings.TrimSpace(raw)
+		// An array may continue over the following lines until its closing
+		// bracket; errors still name the line the key is on.
+		keyLine := lineNo
+		for openArray(raw) {
+			if !sc.Scan() {
+				if err := sc.Err(); err != nil {
+					return err
+				}
+				return fmt.Errorf("line %d: %s.%s: unterminated array", keyLine, sectionName, key)
+			}
+			lineNo++
+			if next := strings.TrimSpace(stripComment(sc.Text())); next != "" {
+				raw += " " + next
+			}
+		}
+		if !section.IsValid() {
+			return fmt.Errorf("line %d: key %q outside a [section]", keyLine, key)
+		}
+		f, ok := fieldByTag(section, key)
+		if !ok {
+			return fmt.Errorf("line %d: unknown key %q in [%s]", keyLine, key, sectionName)
+		}
+		if err := setValue(f, raw); err != nil {
+			return fmt.Errorf("line %d: %s.%s: %w", keyLine, sectionName, key, err)
+		}
+	}
+	return sc.Err()
+}
+
+// fieldByTag finds the field of struct v whose toml tag is name.
+func fieldByTag(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("toml") == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func setValue(f reflect.Value, raw string) error {
+	switch f.Kind() {
+	case reflect.String:
+		s, err := parseString(raw)
+		if err != nil {
+			return err
+		}
+		f.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.ReplaceAll(raw, "_", ""))
+		if err != nil {
+			return fmt.Errorf("want an integer, got %s", raw)
+		}
+		f.SetInt(int64(n))
+	case reflect.Float64:
+		x, err := strconv.ParseFloat(strings.ReplaceAll(raw, "_", ""), 64)
+		if err != nil {
+			return fmt.Errorf("want a number, got %s", raw)
+		}
+		f.SetFloat(x)
+	case reflect.Bool:
+		switch raw {
+		case "true":
+			f.SetBool(true)
+		case "false":
+			f.SetBool(false)
+		default:
+			return fmt.Errorf("want true or false, got %s", raw)
+		}
+	case reflect.Slice:
+		if f.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", f.Type())
+		}
+		items, err := parseStringArray(raw)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		if f.Type().Key().Kind() != reflect.String || f.Type().Elem().Kind() != reflect.Int {
+			return fmt.Errorf("unsupported table type %s", f.Type())
+		}
+		m, err := parseIntTable(raw)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported field type %s", f.Type())
+	}
+	return nil
+}
+
+// parseIntTable parses a single-line inline table of integers,
+// { preferences = 50, "events" = 200 }. Keys are bare or quoted.
+func parseIntTable(raw string) (map[string]int, error) {
+	if !strings.HasPrefix(raw, "{") || !strings.HasSuffix(raw, "}") {
+		return nil, fmt.Errorf("want a { key = n, ... } table, got %s", raw)
+	}
+	m := map[string]int{}
+	body := strings.TrimSpace(raw[1 : len(raw)-1])
+	if body == "" {
+		return m, nil
+	}
+	for _, pair := range strings.Split(body, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("want key = n in %s", raw)
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if strings.HasPrefix(k, `"`) || strings.HasPrefix(k, "'") {
+			var err error
+			if k, err = parseString(k); err != nil {
+				return nil, err
+			}
+		}
+		n, err := strconv.Atoi(strings.ReplaceAll(v, "_", ""))
+		if k == "" || err != nil {
+			return nil, fmt.Errorf("want key = integer, got %s", strings.TrimSpace(pair))
+		}
+		m[k] = n
+	}
+	return m, nil
+}
+
+// parseString accepts a basic ("...", with Go-style escapes) or literal
+// ('...', verbatim) TOML string.
+func parseString(raw string) (string, error) {
+	if len(raw) >= 2 && raw[0] == '\'' && raw[len(raw)-1] == '\'' {
+		return raw[1 : len(raw)-1], nil
+	}
+	if len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"' {
+		s, err := strconv.Unquote(raw)
+		if err != nil {
+			return "", fmt.Errorf("bad string %s", raw)
+		}
+		return s, nil
+	}
+	return "", fmt.Errorf("want a quoted string, got %s", raw)
+}
+
+// openArray reports whether raw starts an array that doesn't close on the
+// same line: a [ with no ] after it outside a string.
+func openArray(raw string) bool {
+	if !strings.HasPrefix(raw, "[") {
+		return false
+	}
+	var quote byte
+	for i := 1; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ']':
+			return false
+		}
+	}
+	return true
+}
+
+// parseStringArray parses a ["a", "b"] array, joined onto one line by
+// decode. A trailing comma is allowed, as in TOML.
+func parseStringArray(raw string) ([]string, error) {
+	if !strings.HasPrefix(raw, "[") || !strings.HasSuffix(raw, "]") {
+		return nil, fmt.Errorf("want a [\"...\"] list, got %s", raw)
+	}
+	body := strings.TrimSpace(raw[1 : len(raw)-1])
+	items := []string{}
+	for body != "" {
+		quote := body[0]
+		if quote != '"' && quote != '\'' {
+			return nil, fmt.Errorf("want quoted list items, got %s", raw)
+		}
+		end := closingQuote(body, quote)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string in %s", raw)
+		}
+		s, err := parseString(body[:end+1])
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, s)
+		body = strings.TrimSpace(body[end+1:])
+		if strings.HasPrefix(body, ",") {
+			body = strings.TrimSpace(body[1:])
+		} else if body != "" {
+			return nil, fmt.Errorf("want a comma between list items in %s", raw)
+		}
+	}
+	return items, nil
+}
+
+// closingQuote returns the index of the quote closing s[0], honoring
+// backslash escapes in basic ("...") strings, or -1.
+func closingQuote(s string, quote byte) int {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// stripComment drops a trailing # comment that isn't inside a string.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// Write renders cfg as TOML that Load reads back: one [section] per group,
+// every key present, in declaration order.
+func Write(w io.Writer, cfg Config) error {
+	v := reflect.ValueOf(cfg)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "[%s]\n", t.Field(i).Tag.Get("toml"))
+		sv, st := v.Field(i), t.Field(i).Type
+		for j := 0; j < st.NumField(); j++ {
+			fmt.Fprintf(w, "%s = %s\n", st.Field(j).Tag.Get("toml"), formatValue(sv.Field(j)))
+		}
+	}
+	return nil
+}
+
+func formatValue(f reflect.Value) string {
+	switch f.Kind() {
+	case reflect.String:
+		return strconv.Quote(f.String())
+	case reflect.Float64:
+		return strconv.FormatFloat(f.Float(), 'f', -1, 64)
+	case reflect.Slice:
+		items := make([]string, f.Len())
+		for i := range items {
+			items[i] = strconv.Quote(f.Index(i).String())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case reflect.Map:
+		keys := make([]string, 0, f.Len())
+		for _, k := range f.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		pairs := make([]string, len(keys))
+		for i, k := range keys {
+			pairs[i] = fmt.Sprintf("%s = %d", strconv.Quote(k), f.MapIndex(reflect.ValueOf(k)).Int())
+		}
+		if len(pairs) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(pairs, ", ") + " }"
+	default:
+		return fmt.Sprint(f.Interface())
+	}
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadMissingFileIsDefaults(t *testing.T) {
+	cfg, found, err := Load(filepath.Join(t.TempDir(), "nope.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Error("found = true for a missing file")
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("missing file should yield Default(); got %+v", cfg)
+	}
+}
+
+func TestLoadOverlaysDefaults(t *testing.T) {
+	path := writeConfig(t, `
+# continuity config
+[server]
+port = 40_000
+
+[llm]
+provider = "ollama"   # local only
+ollama_model = 'llama3:8b'
+temperature = 0.1
+lexical_stemming = true
+
+[engine]
+generic_phrases = ["misc", "stuff # not a comment",]
+category_caps = { preferences = 50, "events" = 1_000 }
+`)
+	cfg, found, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Error("found = false")
+	}
+	if cfg.Server.Port != 40000 {
+		t.Errorf("Port = %d", cfg.Server.Port)
+	}
+	if cfg.Server.Bind != Default().Server.Bind {
+		t.Errorf("unset Bind should keep default; got %q", cfg.Server.Bind)
+	}
+	if cfg.LLM.Provider != "ollama" || cfg.LLM.OllamaModel != "llama3:8b" {
+		t.Errorf("LLM = %+v", cfg.LLM)
+	}
+	if cfg.LLM.Temperature != 0.1 || !cfg.LLM.LexicalStemming {
+		t.Errorf("Temperature = %v, LexicalStemming = %v", cfg.LLM.Temperature, cfg.LLM.LexicalStemming)
+	}
+	want := []string{"misc", "stuff # not a comment"}
+	if !reflect.DeepEqual(cfg.Engine.GenericPhrases, want) {
+		t.Errorf("GenericPhrases = %q, want %q", cfg.Engine.GenericPhrases, want)
+	}
+	if caps := map[string]int{"preferences": 50, "events": 1000}; !reflect.DeepEqual(cfg.Engine.CategoryCaps, caps) {
+		t.Errorf("CategoryCaps = %v, want %v", cfg.Engine.CategoryCaps, caps)
+	}
+}
+
+func TestLoadMultiLineArray(t *testing.T) {
+	path := writeConfig(t, `
+[engine]
+generic_phrases = [
+    "misc",   # a comment inside
+    'stuff ] in a string',
+
+    "more",
+]
+min_user_messages = 3
+`)
+	cfg, _, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"misc", "stuff ] in a string", "more"}; !reflect.DeepEqual(cfg.Engine.GenericPhrases, want) {
+		t.Errorf("GenericPhrases = %q, want %q", cfg.Engine.GenericPhrases, want)
+	}
+	if cfg.Engine.MinUserMessages != 3 {
+		t.Errorf("key after the array: MinUserMessages = %d", cfg.Engine.MinUserMessages)
+	}
+
+	_, _, err = Load(writeConfig(t, "[engine]\ngeneric_phrases = [\n  \"misc\",\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2: engine.generic_phrases") {
+		t.Errorf("unterminated array: err = %v, want it to name the key and its line", err)
+	}
+}
+
+func TestLoadRejectsUnknownAndMalformed(t *testing.T) {
+	cases := map[string]string{
+		"unknown key":     "[llm]\nprovder = \"ollama\"\n",
+		"unknown section": "[llms]\nprovider = \"ollama\"\n",
+		"no section":      "provider = \"ollama\"\n",
+		"bad int":         "[server]\nport = \"37777\"\n",
+		"bad bool":        "[llm]\nlexical_stemming = yes\n",
+		"bare string":     "[llm]\nprovider = ollama\n",
+		"bad table":       "[engine]\ncategory_caps = { preferences = lots }\n",
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := Load(writeConfig(t, body)); err == nil {
+				t.Errorf("expected error for %q", body)
+			}
+		})
+	}
+}
+
+func TestWriteRoundTrips(t *testing.T) {
+	cfg := Default()
+	cfg.LLM.Provider = "anthropic"
+	cfg.LLM.AnthropicKey = `sk-"quoted"`
+	cfg.Engine.GenericPhrases = []string{"a", "b"}
+	cfg.Engine.CategoryCaps = map[string]int{"preferences": 50, "cases": 20}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, cfg); err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := Load(writeConfig(t, buf.String()))
+	if err != nil {
+		t.Fatalf("reload: %v\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", got, cfg)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.LLM.AnthropicKey = "sk-secret"
+	var buf bytes.Buffer
+	Write(&buf, cfg.Redacted())
+	if strings.Contains(buf.String(), "sk-secret") {
+		t.Error("redacted output leaks the API key")
+	}
+	if cfg.LLM.AnthropicKey != "sk-secret" {
+		t.Error("Redacted mutated the receiver")
+	}
+}
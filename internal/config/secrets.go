@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Where ResolveAnthropicKey found the key, for display.
+const (
+	KeySourceConfig   = "config"   // anthropic_key in config.toml
+	KeySourceFile     = "file"     // anthropic_key_file
+	KeySourceKeychain = "keychain" // anthropic_keychain (macOS)
+	KeySourceEnv      = "env"      // ANTHROPIC_API_KEY
+)
+
+// ResolveAnthropicKey sets c.AnthropicKey from the first configured source, in
+// order: an explicit anthropic_key, anthropic_key_file, the macOS keychain
+// item named by anthropic_keychain, then envKey (ANTHROPIC_API_KEY). It
+// returns the source used, or "" when there is no key anywhere. A configured
+// source that fails — a missing or world-readable key file, an absent keychain
+// item — is an error rather than a silent fall-through to the next source:
+// the operator asked for that one.
+//
+// Anthropic is the only provider that takes a key today (claude-cli uses the
+// CLI's own login, Ollama none); a future provider's key resolves the same way.
+func (c *LLMConfig) ResolveAnthropicKey(envKey string) (string, error) {
+	switch {
+	case c.AnthropicKey != "":
+		return KeySourceConfig, nil
+	case c.AnthropicKeyFile != "":
+		key, err := readKeyFile(c.AnthropicKeyFile)
+		if err != nil {
+			return "", err
+		}
+		c.AnthropicKey = key
+		return KeySourceFile, nil
+	case c.AnthropicKeychain != "":
+		key, err := keychainLookup(c.AnthropicKeychain)
+		if err != nil {
+			return "", fmt.Errorf("anthropic_keychain %q: %w", c.AnthropicKeychain, err)
+		}
+		c.AnthropicKey = key
+		return KeySourceKeychain, nil
+	case envKey != "":
+		c.AnthropicKey = envKey
+		return KeySourceEnv, nil
+	}
+	return "", nil
+}
+
+// readKeyFile reads a secret from path (a leading ~/ is the home directory).
+// Like ssh with private keys, it refuses a file that group or others can read:
+// a key file is only better than a plaintext config entry if it is private.
+func readKeyFile(path string) (string, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("get home dir: %w", err)
+		}
+		path = filepath.Join(home, rest)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("key file: %w", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return "", fmt.Errorf("key file %s is accessible by other users (mode %04o) — chmod 600 it", path, info.Mode().Perm())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("key file: %w", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("key file %s is empty", path)
+	}
+	return key, nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writeKeyFile(t *testing.T, body string, mode os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "anthropic.key")
+	if err := os.WriteFile(path, []byte(body), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil { // umask-proof
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestResolveAnthropicKeyPrecedence(t *testing.T) {
+	file := writeKeyFile(t, "sk-from-file\n", 0o600)
+
+	tests := []struct {
+		name       string
+		cfg        LLMConfig
+		env        string
+		wantKey    string
+		wantSource string
+	}{
+		{"explicit beats everything", LLMConfig{AnthropicKey: "sk-explicit", AnthropicKeyFile: file}, "sk-env", "sk-explicit", KeySourceConfig},
+		{"file beats env", LLMConfig{AnthropicKeyFile: file}, "sk-env", "sk-from-file", KeySourceFile},
+		{"env is the fallback", LLMConfig{}, "sk-env", "sk-env", KeySourceEnv},
+		{"nothing anywhere", LLMConfig{}, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			source, err := cfg.ResolveAnthropicKey(tt.env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.AnthropicKey != tt.wantKey || source != tt.wantSource {
+				t.Errorf("got (%q, %q), want (%q, %q)", cfg.AnthropicKey, source, tt.wantKey, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestResolveAnthropicKeyFileErrors(t *testing.T) {
+	cfg := LLMConfig{AnthropicKeyFile: filepath.Join(t.TempDir(), "missing.key")}
+	if _, err := cfg.ResolveAnthropicKey("sk-env"); err == nil {
+		t.Error("missing key file should error, not fall through to env")
+	}
+
+	cfg = LLMConfig{AnthropicKeyFile: writeKeyFile(t, "  \n", 0o600)}
+	if _, err := cfg.ResolveAnthropicKey(""); err == nil || !strings.Contains(err.Error(), "empty") {
+		t.Errorf("empty key file: err = %v", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	cfg = LLMConfig{AnthropicKeyFile: writeKeyFile(t, "sk-leaky", 0o644)}
+	if _, err := cfg.ResolveAnthropicKey(""); err == nil || !strings.Contains(err.Error(), "chmod 600") {
+		t.Errorf("world-readable key file: err = %v", err)
+	}
+	if cfg.AnthropicKey != "" {
+		t.Error("key from a rejected file was still applied")
+	}
+}
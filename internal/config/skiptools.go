@@ -0,0 +1,21 @@
+package config
+
+import "slices"
+
+// builtinSkipTools are meta-tools that generate noise, not useful
+// observations. They are skipped whatever [hooks] skip_tools says.
+var builtinSkipTools = map[string]bool{
+	"TodoRead":   true,
+	"TodoWrite":  true,
+	"Thinking":   true,
+	"TaskList":   true,
+	"TaskCreate": true,
+	"TaskGet":    true,
+	"TaskUpdate": true,
+}
+
+// SkipTool reports whether observations of tool are dropped: it's a built-in
+// meta-tool or one of extra, the configured HooksConfig.SkipTools.
+func SkipTool(tool string, extra []string) bool {
+	return builtinSkipTools[tool] || slices.Contains(extra, tool)
+}
@@ -0,0 +1,15 @@
+package config
+
+import "testing"
+
+func TestSkipTool(t *testing.T) {
+	if !SkipTool("TodoWrite", nil) || SkipTool("Bash", nil) || SkipTool("Read", nil) {
+		t.Error("built-in set: want TodoWrite skipped and Bash, Read recorded")
+	}
+	if !SkipTool("Read", []string{"Read"}) {
+		t.Error("expected a configured skip_tools entry to be skipped")
+	}
+	if !SkipTool("TodoWrite", []string{"Read"}) {
+		t.Error("configured skip_tools should add to the built-in set, not replace it")
+	}
+}
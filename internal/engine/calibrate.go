@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/lazypower/continuity/internal/store"
+)
+
+// CalibrationPair is two texts and whether they should count as the same
+// memory.
+type CalibrationPair struct {
+	A         string `json:"a"`
+	B         string `json:"b"`
+	Duplicate bool   `json:"duplicate"`
+}
+
+// SimilarityStats summarizes the cosines of one class of pairs.
+type SimilarityStats struct {
+	Count          int
+	Min, Mean, Max float64
+	Scores         []float64 // sorted ascending
+}
+
+// CalibrationReport is the outcome of Calibrate: how the embedder scores
+// known duplicates against known-distinct pairs, and the threshold that best
+// separates them.
+type CalibrationReport struct {
+	Duplicates SimilarityStats
+	Distinct   SimilarityStats
+
+	// Suggested is the cutoff that classifies the most pairs correctly
+	// (duplicate when cosine >= Suggested), centered in the widest gap among
+	// equally good cutoffs. Accuracy is the fraction it gets right.
+	Suggested float64
+	Accuracy  float64
+
+	// Separable is true when every duplicate outscores every distinct pair,
+	// i.e. some threshold gets them all right.
+	Separable bool
+
+	// Current is the threshold dedup and the gates use for this embedder
+	// today (MatchThreshold), and CurrentAccuracy how it fares on these pairs.
+	Current         float64
+	CurrentAccuracy float64
+}
+
+// Calibrate embeds every pair with emb and reports the similarity
+// distribution of duplicates and distinct pairs and a threshold between them.
+// It needs at least one pair of each kind.
+func Calibrate(ctx context.Context, emb Embedder, pairs []CalibrationPair) (*CalibrationReport, error) {
+	var dups, distinct []float64
+	for _, p := range pairs {
+		a, err := emb.Embed(ctx, p.A)
+		if err != nil {
+			return nil, fmt.Errorf("embed %q: %w", truncateClean(p.A, 40), err)
+		}
+		b, err := emb.Embed(ctx, p.B)
+		if err != nil {
+			return nil, fmt.Errorf("embed %q: %w", truncateClean(p.B, 40), err)
+		}
+		sim := CosineSimilarity(a, b)
+		if p.Duplicate {
+			dups = append(dups, sim)
+		} else {
+			distinct = append(distinct, sim)
+		}
+	}
+	if len(dups) == 0 || len(distinct) == 0 {
+		return nil, fmt.Errorf("calibration needs both duplicate and distinct pairs (have %d and %d)", len(dups), len(distinct))
+	}
+
+	r := &CalibrationReport{
+		Duplicates: similarityStats(dups),
+		Distinct:   similarityStats(distinct),
+		Current:    MatchThreshold(emb),
+	}
+	r.Separable = r.Duplicates.Min > r.Distinct.Max
+	r.Suggested, r.Accuracy = bestThreshold(dups, distinct)
+	r.CurrentAccuracy = thresholdAccuracy(r.Current, dups, distinct)
+	return r, nil
+}
+
+func similarityStats(scores []float64) SimilarityStats {
+	sort.Float64s(scores)
+	sum := 0.0
+	for _, s := range scores {
+		sum += s
+	}
+	return SimilarityStats{
+		Count:  len(scores),
+		Min:    scores[0],
+		Mean:   sum / float64(len(scores)),
+		Max:    scores[len(scores)-1],
+		Scores: scores,
+	}
+}
+
+// bestThreshold tries a cutoff in every gap between adjacent scores and keeps
+// the most accurate, preferring the widest gap on a tie so the suggestion sits
+// as far from both classes as the data allows. The cutoff is rounded to two
+// places when that doesn't cost accuracy (a narrow gap can round across a
+// score); the accuracy returned is always the returned cutoff's own.
+func bestThreshold(dups, distinct []float64) (float64, float64) {
+	all := append(append([]float64(nil), dups...), distinct...)
+	sort.Float64s(all)
+
+	best, bestAcc, bestGap := all[0], -1.0, -1.0
+	for i := 0; i < len(all)-1; i++ {
+		gap := all[i+1] - all[i]
+		if gap == 0 {
+			continue
+		}
+		t := all[i] + gap/2
+		acc := thresholdAccuracy(t, dups, distinct)
+		if acc > bestAcc || (acc == bestAcc && gap > bestGap) {
+			best, bestAcc, bestGap = t, acc, gap
+		}
+	}
+	if bestAcc < 0 { // every score identical: nothing separates them
+		return best, thresholdAccuracy(best, dups, distinct)
+	}
+	if rounded := math.Round(best*100) / 100; thresholdAccuracy(rounded, dups, distinct) == bestAcc {
+		return rounded, bestAcc
+	}
+	return best, bestAcc
+}
+
+func thresholdAccuracy(t float64, dups, distinct []float64) float64 {
+	right := 0
+	for _, s := range dups {
+		if s >= t {
+			right++
+		}
+	}
+	for _, s := range distinct {
+		if s < t {
+			right++
+		}
+	}
+	return float64(right) / float64(len(dups)+len(distinct))
+}
+
+// PerturbPairs builds calibration pairs from live leaves when the operator has
+// none of their own. Each leaf's L0 paired with a light rewording of itself is
+// a duplicate; each leaf's L0 paired with another leaf's is distinct. The
+// rewordings are mechanical (case, a dropped word, a swapped pair), so they
+// model a restated fact loosely — hand-labelled pairs calibrate better. At
+// most n leaves are used; n <= 0 uses them all.
+func PerturbPairs(leaves []store.MemNode, n int) []CalibrationPair {
+	var texts []string
+	seen := map[string]bool{}
+	for _, l := range leaves {
+		t := strings.TrimSpace(l.L0Abstract)
+		if l.NodeType != "leaf" || l.IsRetracted() || len(strings.Fields(t)) < 4 || seen[t] {
+			continue
+		}
+		seen[t] = true
+		texts = append(texts, t)
+		if n > 0 && len(texts) == n {
+			break
+		}
+	}
+	if len(texts) < 2 {
+		return nil
+	}
+
+	pairs := make([]CalibrationPair, 0, 2*len(texts))
+	half := len(texts) / 2
+	for i, t := range texts {
+		pairs = append(pairs, CalibrationPair{A: t, B: perturb(t), Duplicate: true})
+		// Offset by half the set so neighbours — often written by the same
+		// session about the same thing — aren't paired as "distinct".
+		pairs = append(pairs, CalibrationPair{A: t, B: texts[(i+half)%len(texts)]})
+	}
+	return pairs
+}
+
+// perturb rewords s the way a later session might restate it: lower-cased,
+// trailing punctuation gone, the middle word dropped and the first two words
+// after it swapped.
+func perturb(s string) string {
+	words := strings.Fields(strings.ToLower(strings.TrimRight(s, ".!?")))
+	mid := len(words) / 2
+	words = append(words[:mid], words[mid+1:]...)
+	if mid+1 < len(words) {
+		words[mid], words[mid+1] = words[mid+1], words[mid]
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lazypower/continuity/internal/store"
+)
+
+func TestCalibrate(t *testing.T) {
+	emb, err := NewHashEmbedder(0)
+	if err != nil {
+		t.Fatalf("NewHashEmbedder: %v", err)
+	}
+	var leaves []store.MemNode
+	for _, l0 := range []string{
+		"Prefers tabs over spaces in Go code",
+		"Deploys the API with Fly.io from the main branch",
+		"SQLite busy errors come from a missing busy timeout",
+		"Reviews every pull request with a second model first",
+		"Keeps release notes in the CHANGELOG under Unreleased",
+		"Runs integration tests against a throwaway Postgres container",
+		"too short", // skipped: under four words
+	} {
+		leaves = append(leaves, store.MemNode{NodeType: "leaf", L0Abstract: l0})
+	}
+
+	pairs := PerturbPairs(leaves, 0)
+	if len(pairs) != 12 {
+		t.Fatalf("pairs = %d, want a duplicate and a distinct pair for each of 6 memories", len(pairs))
+	}
+	for _, p := range pairs {
+		if p.A == p.B {
+			t.Errorf("pair compares %q with itself", p.A)
+		}
+	}
+	if got := PerturbPairs(leaves, 3); len(got) != 6 {
+		t.Errorf("PerturbPairs(n=3) = %d pairs, want 6", len(got))
+	}
+
+	r, err := Calibrate(context.Background(), emb, pairs)
+	if err != nil {
+		t.Fatalf("Calibrate: %v", err)
+	}
+	if r.Duplicates.Count != 6 || r.Distinct.Count != 6 {
+		t.Errorf("counts = %d/%d, want 6/6", r.Duplicates.Count, r.Distinct.Count)
+	}
+	if !r.Separable || r.Accuracy != 1 {
+		t.Errorf("separable = %v accuracy = %v; dups %+v distinct %+v", r.Separable, r.Accuracy, r.Duplicates, r.Distinct)
+	}
+	if r.Suggested <= r.Distinct.Max || r.Suggested > r.Duplicates.Min {
+		t.Errorf("suggested %.2f not between distinct max %.2f and duplicate min %.2f", r.Suggested, r.Distinct.Max, r.Duplicates.Min)
+	}
+	if r.Current != MatchThreshold(emb) {
+		t.Errorf("current = %v, want MatchThreshold %v", r.Current, MatchThreshold(emb))
+	}
+
+	if _, err := Calibrate(context.Background(), emb, pairs[:1]); err == nil {
+		t.Error("Calibrate with only duplicates: want error")
+	}
+}
+
+func TestBestThresholdOverlap(t *testing.T) {
+	// One distinct pair outscores one duplicate: 5 of 6 is the best possible.
+	got, acc := bestThreshold([]float64{0.9, 0.8, 0.5}, []float64{0.2, 0.3, 0.6})
+	if acc < 0.83 || acc > 0.84 {
+		t.Errorf("accuracy = %v, want 5/6", acc)
+	}
+	if got <= 0.3 || got > 0.8 {
+		t.Errorf("threshold = %v, want between 0.3 and 0.8", got)
+	}
+
+	// The only separating gap is 0.801-0.806, and rounding its midpoint
+	// (0.8035) to 0.80 would misplace the 0.801 distinct pair. The cutoff
+	// stays unrounded, and the accuracy reported is its own.
+	dups, distinct := []float64{0.806, 0.9}, []float64{0.801, 0.1}
+	got, acc = bestThreshold(dups, distinct)
+	if acc != 1 || thresholdAccuracy(got, dups, distinct) != acc {
+		t.Errorf("threshold %v reports accuracy %v, actual %v", got, acc, thresholdAccuracy(got, dups, distinct))
+	}
+}
@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/lazypower/continuity/internal/store"
+)
+
+// greedyClusters groups the nodes behind vecs by cosine similarity: each
+// unclaimed node in order seeds a cluster and absorbs every later unclaimed
+// node within threshold of it. Only clusters of two or more are returned, as
+// ascending index lists with the seed first. vecs[i] is nil for nodes without
+// a usable vector; those never join a cluster. This is Dedup's clustering,
+// shared with Clusters so the report shows exactly what dedup would merge.
+func greedyClusters(vecs [][]float64, threshold float64) [][]int {
+	candidates := dedupCandidates(vecs)
+	claimed := make([]bool, len(vecs))
+
+	var clusters [][]int
+	for i := range vecs {
+		if claimed[i] || vecs[i] == nil {
+			continue
+		}
+		cluster := []int{i}
+		for _, j := range candidates(i) {
+			if claimed[j] || vecs[j] == nil {
+				continue
+			}
+			if CosineSimilarity(vecs[i], vecs[j]) >= threshold {
+				cluster = append(cluster, j)
+			}
+		}
+		if len(cluster) <= 1 {
+			continue
+		}
+		for _, idx := range cluster {
+			claimed[idx] = true
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+// Cluster is a group of same-category leaves whose vectors lie within the
+// clustering threshold of a common seed.
+type Cluster struct {
+	Category string
+	Members  []store.MemNode
+	// Representative is the member with the highest mean similarity to the
+	// others — the one whose L0 best speaks for the group.
+	Representative store.MemNode
+}
+
+// Clusters reports how leaves group by vector similarity at threshold, per
+// category, using the same clustering Dedup merges by. Unlike Dedup it only
+// reads: nothing is embedded, deleted, or touched, and leaves without a
+// vector in the active identity are left out. Largest clusters first.
+func (e *Engine) Clusters(threshold float64) ([]Cluster, error) {
+	if e.Embedder == nil {
+		return nil, fmt.Errorf("no embedder configured")
+	}
+
+	leaves, err := e.DB.ListLeaves()
+	if err != nil {
+		return nil, fmt.Errorf("list leaves: %w", err)
+	}
+	vectors, err := e.DB.AllVectors()
+	if err != nil {
+		return nil, fmt.Errorf("load vectors: %w", err)
+	}
+	activeID := EmbedderIdentity(e.Embedder)
+	vecMap := make(map[int64][]float64, len(vectors))
+	for _, v := range vectors {
+		if canonicalIdentity(v.Model, v.Dimensions) == activeID {
+			vecMap[v.NodeID] = v.Embedding
+		}
+	}
+
+	byCategory := make(map[string][]store.MemNode)
+	for _, n := range leaves {
+		byCategory[n.Category] = append(byCategory[n.Category], n)
+	}
+
+	var out []Cluster
+	for cat, nodes := range byCategory {
+		vecs := make([][]float64, len(nodes))
+		for i := range nodes {
+			vecs[i] = vecMap[nodes[i].ID]
+		}
+		for _, idxs := range greedyClusters(vecs, threshold) {
+			c := Cluster{Category: cat}
+			best, bestMean := idxs[0], -1.0
+			for _, i := range idxs {
+				c.Members = append(c.Members, nodes[i])
+				sum := 0.0
+				for _, j := range idxs {
+					if j != i {
+						sum += CosineSimilarity(vecs[i], vecs[j])
+					}
+				}
+				if mean := sum / float64(len(idxs)-1); mean > bestMean {
+					best, bestMean = i, mean
+				}
+			}
+			c.Representative = nodes[best]
+			out = append(out, c)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i].Members) != len(out[j].Members) {
+			return len(out[i].Members) > len(out[j].Members)
+		}
+		if out[i].Category != out[j].Category {
+			return out[i].Category < out[j].Category
+		}
+		return out[i].Representative.URI < out[j].Representative.URI
+	})
+	return out, nil
+}
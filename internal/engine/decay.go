@@ -9,4 +9,5 @@ package engine
 //   - Retrieval boosts: TouchNode resets relevance to 1.0
 //   - Exempt: mem://user/profile/communication (relational profile)
 //   - Computed in Go (not SQL) because modernc.org/sqlite lacks pow()
-//   - Runs on server startup + daily via Engine.StartDecayTimer()
+//   - Runs via Engine.StartDecayTimer() when engine.decay_interval (default
+//     24h) has passed since the last run, recorded in mem_meta
@@ -2,9 +2,14 @@ package engine
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
+	"github.com/lazypower/continuity/internal/config"
 	"github.com/lazypower/continuity/internal/llm"
 	"github.com/lazypower/continuity/internal/store"
 )
@@ -155,7 +160,7 @@ func TestExtractMemoriesSimilarityGate(t *testing.T) {
 	}
 
 	transcriptPath := makeTranscript(t)
-	err := extractMemories(db, mock, embedder, "test-session", transcriptPath)
+	_, _, err := extractMemories(db, mock, nil, embedder, config.Default().Engine, "test-session", transcriptPath)
 	if err != nil {
 		t.Fatalf("extractMemories: %v", err)
 	}
@@ -195,7 +200,7 @@ func TestExtractMemoriesNoEmbedder(t *testing.T) {
 	}
 
 	transcriptPath := makeTranscript(t)
-	err := extractMemories(db, mock, nil, "test-session", transcriptPath)
+	_, _, err := extractMemories(db, mock, nil, nil, config.Default().Engine, "test-session", transcriptPath)
 	if err != nil {
 		t.Fatalf("extractMemories: %v", err)
 	}
@@ -207,6 +212,64 @@ func TestExtractMemoriesNoEmbedder(t *testing.T) {
 	}
 }
 
+func TestExtractMemoriesReportsMergeDiff(t *testing.T) {
+	db := testDB(t)
+	if err := db.CreateNode(&store.MemNode{
+		URI: "mem://user/preferences/test-pref", NodeType: "leaf", Category: "preferences",
+		L0Abstract: "Old preference", L1Overview: "The overview before the merge",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := &llm.MockClient{Response: &llm.Response{Content: `[{
+		"category": "preferences", "uri_hint": "test-pref",
+		"l0": "Updated preference", "l1": "The overview after the merge"
+	}]`}}
+	_, merges, err := extractMemories(db, mock, nil, nil, config.Default().Engine, "test-session", makeTranscript(t))
+	if err != nil {
+		t.Fatalf("extractMemories: %v", err)
+	}
+	want := MergeDiff{
+		URI:    "mem://user/preferences/test-pref",
+		Before: "The overview before the merge",
+		After:  "The overview after the merge",
+	}
+	if len(merges) != 1 || merges[0] != want {
+		t.Fatalf("merges = %+v, want [%+v]", merges, want)
+	}
+
+	// Re-extracting the same content is skipped by UpsertNode, so no diff.
+	_, merges, err = extractMemories(db, mock, nil, nil, config.Default().Engine, "test-session", makeTranscript(t))
+	if err != nil {
+		t.Fatalf("extractMemories: %v", err)
+	}
+	if len(merges) != 0 {
+		t.Errorf("unchanged re-extraction reported merges: %+v", merges)
+	}
+}
+
+func TestExtractMemoriesCustomPrompt(t *testing.T) {
+	db := testDB(t)
+	path := filepath.Join(t.TempDir(), "prompt.tmpl")
+	if err := os.WriteFile(path, []byte("CUSTOM budget={{.MaxCandidates}}\n{{.Transcript}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default().Engine
+	cfg.ExtractionPromptPath = path
+
+	mock := &llm.MockClient{Response: &llm.Response{Content: "[]", Provider: "mock"}}
+	if _, _, err := extractMemories(db, mock, nil, nil, cfg, "test-session", makeTranscript(t)); err != nil {
+		t.Fatalf("extractMemories: %v", err)
+	}
+	if len(mock.Calls) != 1 {
+		t.Fatalf("LLM calls = %d, want 1", len(mock.Calls))
+	}
+	prompt := mock.Calls[0]
+	if !strings.HasPrefix(prompt, llm.InternalSentinel+" CUSTOM budget=3\n") {
+		t.Errorf("custom prompt not used (or sentinel missing): %q", prompt[:min(len(prompt), 80)])
+	}
+}
+
 func TestDedup(t *testing.T) {
 	db := testDB(t)
 	nodes := seedDuplicateNodes(t, db)
@@ -259,6 +322,69 @@ func TestDedup(t *testing.T) {
 	if len(leavesAfter) >= len(leavesBefore) {
 		t.Errorf("expected fewer leaves after dedup: before=%d, after=%d", len(leavesBefore), len(leavesAfter))
 	}
+
+	// Every removed node is recorded in exactly one survivor's provenance.
+	recorded := 0
+	for _, l := range leavesAfter {
+		ids, err := db.GetMergeProvenance(l.ID)
+		if err != nil {
+			t.Fatalf("GetMergeProvenance %s: %v", l.URI, err)
+		}
+		recorded += len(ids)
+	}
+	if recorded != removed {
+		t.Errorf("merged_from records %d sources across survivors, want %d removed", recorded, removed)
+	}
+}
+
+// dedupSurvivors seeds a fresh DB with the duplicate fixture, runs Dedup,
+// and returns the surviving categories, one entry per surviving leaf. Which
+// member of a cluster survives depends on updated_at ties, so URIs aren't
+// compared.
+func dedupSurvivors(t *testing.T, threshold float64) []string {
+	t.Helper()
+	db := testDB(t)
+	seedDuplicateNodes(t, db)
+	embedder, err := NewHashEmbedder(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := New(db, nil)
+	eng.SetEmbedder(embedder)
+	if _, err := eng.Dedup(context.Background(), threshold); err != nil {
+		t.Fatalf("Dedup: %v", err)
+	}
+	leaves, _ := db.ListLeaves()
+	var cats []string
+	for _, l := range leaves {
+		cats = append(cats, l.Category)
+	}
+	sort.Strings(cats)
+	return cats
+}
+
+func TestDedupLSHMatchesExact(t *testing.T) {
+	exact := dedupSurvivors(t, 0.70)
+	if len(exact) != 4 {
+		t.Fatalf("exact dedup left %d leaves, want 4: %v", len(exact), exact)
+	}
+
+	prev := lshMinCategory
+	lshMinCategory = 0
+	t.Cleanup(func() { lshMinCategory = prev })
+	approx := dedupSurvivors(t, 0.70)
+
+	if strings.Join(approx, ",") != strings.Join(exact, ",") {
+		t.Errorf("LSH dedup survivors differ from exact:\n exact  %v\n approx %v", exact, approx)
+	}
+}
+
+func TestDedupCandidatesSmallIsExhaustive(t *testing.T) {
+	vecs := [][]float64{{1, 0}, nil, {0, 1}, {1, 1}}
+	got := dedupCandidates(vecs)(0)
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates(0) = %v, want %v", got, want)
+	}
 }
 
 func TestDedupNoEmbedder(t *testing.T) {
@@ -311,3 +437,40 @@ func TestDeleteNode(t *testing.T) {
 		t.Error("expected vector to be deleted")
 	}
 }
+
+func TestClustersReportsWithoutDeleting(t *testing.T) {
+	db := testDB(t)
+	nodes := seedDuplicateNodes(t, db)
+	embedder, err := NewHashEmbedder(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, n := range nodes {
+		vec, _ := embedder.Embed(ctx, n.L0Abstract)
+		db.SaveVector(n.ID, vec, embedder.Model())
+	}
+	eng := New(db, nil)
+	eng.SetEmbedder(embedder)
+
+	clusters, err := eng.Clusters(0.70)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fixture has a 3-member profile cluster and a 2-member preferences
+	// cluster; the rest stand alone.
+	if len(clusters) != 2 {
+		t.Fatalf("got %d clusters, want 2: %+v", len(clusters), clusters)
+	}
+	if clusters[0].Category != "profile" || len(clusters[0].Members) != 3 {
+		t.Errorf("largest cluster = %s x%d, want profile x3", clusters[0].Category, len(clusters[0].Members))
+	}
+	if clusters[1].Category != "preferences" || len(clusters[1].Members) != 2 {
+		t.Errorf("second cluster = %s x%d, want preferences x2", clusters[1].Category, len(clusters[1].Members))
+	}
+
+	leaves, _ := db.ListLeaves()
+	if len(leaves) != len(nodes) {
+		t.Errorf("Clusters deleted nodes: %d leaves, want %d", len(leaves), len(nodes))
+	}
+}
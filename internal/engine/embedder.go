@@ -11,6 +11,8 @@ import (
 	"net/http"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 	"unicode"
 )
@@ -26,25 +28,47 @@ type Embedder interface {
 type OllamaEmbedder struct {
 	url    string
 	model  string
-	dims   int
+	dims   atomic.Int64 // learned from the first response; Embed runs concurrently
 	client *http.Client
+	sem    chan struct{} // nil: unbounded
 }
 
 // NewOllamaEmbedder creates an embedder using Ollama's API.
 func NewOllamaEmbedder(url, model string, dims int) *OllamaEmbedder {
-	return &OllamaEmbedder{
+	o := &OllamaEmbedder{
 		url:    url,
 		model:  model,
-		dims:   dims,
 		client: &http.Client{Timeout: 30 * time.Second},
 	}
+	o.dims.Store(int64(dims))
+	return o
 }
 
 func (o *OllamaEmbedder) Model() string   { return "ollama:" + o.model }
-func (o *OllamaEmbedder) Dimensions() int { return o.dims }
+func (o *OllamaEmbedder) Dimensions() int { return int(o.dims.Load()) }
+
+// SetConcurrency bounds how many Embed calls are in flight at once; callers
+// past the limit wait their turn (or for their context). n <= 0 removes the
+// bound. Call it before the embedder is shared.
+func (o *OllamaEmbedder) SetConcurrency(n int) {
+	if n <= 0 {
+		o.sem = nil
+		return
+	}
+	o.sem = make(chan struct{}, n)
+}
 
 // Embed sends text to Ollama's embed endpoint and returns the embedding vector.
 func (o *OllamaEmbedder) Embed(ctx context.Context, text string) ([]float64, error) {
+	if o.sem != nil {
+		select {
+		case o.sem <- struct{}{}:
+			defer func() { <-o.sem }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
 	reqBody := map[string]any{
 		"model": o.model,
 		"input": text,
@@ -85,12 +109,38 @@ func (o *OllamaEmbedder) Embed(ctx context.Context, text string) ([]float64, err
 		return nil, fmt.Errorf("ollama returned no embeddings")
 	}
 
-	o.dims = len(result.Embeddings[0])
+	o.dims.Store(int64(len(result.Embeddings[0])))
 	return result.Embeddings[0], nil
 }
 
 // ProbeOllama checks if Ollama is reachable and the embedding model is available.
 func ProbeOllama(url, model string) bool {
+	return ProbeOllamaDetail(url, model).OK()
+}
+
+// OllamaProbe is the outcome of probing an Ollama embedding endpoint, kept
+// separate from the bool so callers can say *why* Ollama was passed over.
+type OllamaProbe struct {
+	Reachable    bool
+	ModelPresent bool
+	Detail       string // reason Ollama is unusable; empty when OK
+}
+
+// OK reports whether the endpoint can embed with the requested model.
+func (p OllamaProbe) OK() bool { return p.Reachable && p.ModelPresent }
+
+func (p OllamaProbe) String() string {
+	s := fmt.Sprintf("reachable=%t model_present=%t", p.Reachable, p.ModelPresent)
+	if p.Detail != "" {
+		s += " (" + p.Detail + ")"
+	}
+	return s
+}
+
+// ProbeOllamaDetail embeds a test string with model at url. A connection
+// failure means unreachable; a 404 means Ollama answered but lacks the model
+// (it needs an `ollama pull`).
+func ProbeOllamaDetail(url, model string) OllamaProbe {
 	client := &http.Client{Timeout: 3 * time.Second}
 	reqBody, _ := json.Marshal(map[string]any{
 		"model": model,
@@ -98,10 +148,17 @@ func ProbeOllama(url, model string) bool {
 	})
 	resp, err := client.Post(url+"/api/embed", "application/json", bytes.NewReader(reqBody))
 	if err != nil {
-		return false
+		return OllamaProbe{Detail: err.Error()}
 	}
 	defer resp.Body.Close()
-	return resp.StatusCode == http.StatusOK
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return OllamaProbe{Reachable: true, ModelPresent: true}
+	case http.StatusNotFound:
+		return OllamaProbe{Reachable: true, Detail: fmt.Sprintf("model %q not found; run: ollama pull %s", model, model)}
+	default:
+		return OllamaProbe{Reachable: true, Detail: fmt.Sprintf("embed returned status %d", resp.StatusCode)}
+	}
 }
 
 // HashEmbedder is a fixed-dimension feature-hashed lexical embedder used as the
@@ -138,6 +195,7 @@ func ProbeOllama(url, model string) bool {
 // that any surviving content token yields a non-zero vector.
 type HashEmbedder struct {
 	dims int
+	stem bool
 }
 
 // defaultHashDims is the fixed feature-hash dimension. 2048 keeps collisions
@@ -161,7 +219,27 @@ func NewHashEmbedder(dims int) (*HashEmbedder, error) {
 	return &HashEmbedder{dims: dims}, nil
 }
 
-func (h *HashEmbedder) Model() string   { return "hashtf" }
+// NewStemmedHashEmbedder is NewHashEmbedder with light stemming applied to
+// each content term, so inflections ("running"/"run", "databases"/"database")
+// share a bucket. Stemming changes every vector, so it is a different vector
+// space with its own model name ("hashtf-stem"): switching an existing corpus
+// between the two locks on vector identity and requires an explicit
+// re-embed, exactly like any other embedder change.
+func NewStemmedHashEmbedder(dims int) (*HashEmbedder, error) {
+	h, err := NewHashEmbedder(dims)
+	if err != nil {
+		return nil, err
+	}
+	h.stem = true
+	return h, nil
+}
+
+func (h *HashEmbedder) Model() string {
+	if h.stem {
+		return "hashtf-stem"
+	}
+	return "hashtf"
+}
 func (h *HashEmbedder) Dimensions() int { return h.dims }
 
 // Embed generates a normalized hashed-TF vector for the given text. Sublinear
@@ -176,6 +254,9 @@ func (h *HashEmbedder) Embed(_ context.Context, text string) ([]float64, error)
 		if _, stop := lexicalStopwords[tok]; stop {
 			continue // see lexicalStopwords: static stand-in for IDF down-weighting
 		}
+		if h.stem {
+			tok = stemToken(tok)
+		}
 		tf[tok]++
 	}
 
@@ -270,12 +351,74 @@ const lexicalMatchThreshold = 0.5
 // lexical fallback gets a lower, separately-calibrated bar; semantic and unknown
 // embedders use the default.
 func MatchThreshold(emb Embedder) float64 {
-	if emb != nil && emb.Model() == "hashtf" {
+	if emb != nil && (emb.Model() == "hashtf" || emb.Model() == "hashtf-stem") {
 		return lexicalMatchThreshold
 	}
 	return defaultSimilarityThreshold
 }
 
+// embedCache is an Embedder that embeds each distinct text once. A search's
+// sub-queries overlap, and an extraction's gates embed the same candidate L0
+// several times; wrapped for the length of one request, the repeats cost a
+// map lookup instead of an Ollama round-trip. It is request-scoped on
+// purpose: nothing invalidates it, so never keep one past its request.
+type embedCache struct {
+	Embedder
+	mu   sync.Mutex
+	vecs map[string]*cachedEmbedding
+}
+
+// cachedEmbedding is one text's embedding, or the call still producing it.
+type cachedEmbedding struct {
+	done chan struct{}
+	vec  []float64
+	err  error
+}
+
+// withEmbedCache wraps emb in a fresh embedCache. Nil stays nil, so callers'
+// "no embedder" checks keep working, and an already cached embedder is
+// returned as is.
+func withEmbedCache(emb Embedder) Embedder {
+	if emb == nil {
+		return nil
+	}
+	if _, ok := emb.(*embedCache); ok {
+		return emb
+	}
+	return &embedCache{Embedder: emb, vecs: map[string]*cachedEmbedding{}}
+}
+
+// Embed returns text's embedding, calling the wrapped embedder only the
+// first time. Concurrent callers for the same text wait for that one call. A
+// failure isn't cached; the next caller tries again.
+func (c *embedCache) Embed(ctx context.Context, text string) ([]float64, error) {
+	c.mu.Lock()
+	if e, ok := c.vecs[text]; ok {
+		c.mu.Unlock()
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if e.err != nil {
+			return c.Embed(ctx, text)
+		}
+		return e.vec, nil
+	}
+	e := &cachedEmbedding{done: make(chan struct{})}
+	c.vecs[text] = e
+	c.mu.Unlock()
+
+	e.vec, e.err = c.Embedder.Embed(ctx, text)
+	if e.err != nil {
+		c.mu.Lock()
+		delete(c.vecs, text)
+		c.mu.Unlock()
+	}
+	close(e.done)
+	return e.vec, e.err
+}
+
 // hashBucket maps a term to its bucket in [0, dims) via a 64-bit FNV-1a hash.
 func hashBucket(term string, dims int) int {
 	hsh := fnv.New64a()
@@ -2,8 +2,14 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestTokenize(t *testing.T) {
@@ -302,3 +308,121 @@ func TestHashEmbedderCorpusIndependent(t *testing.T) {
 		t.Error("rare vocabulary embedded to all-zero — feature hashing must never have OOV")
 	}
 }
+
+func TestStemToken(t *testing.T) {
+	tests := map[string]string{
+		"running":   "run",
+		"run":       "run",
+		"databases": "database",
+		"database":  "database",
+		"queries":   "queri",
+		"query":     "queri",
+		"caresses":  "caress",
+		"agreed":    "agree",
+		"hopping":   "hop",
+		"hoping":    "hope",
+		"conflated": "conflate",
+		"falling":   "fall",
+		"sing":      "sing", // no vowel before -ing
+		"wal":       "wal",  // short tokens pass through
+		"v1s":       "v1s",  // digits pass through
+		"café":      "café", // non-ASCII passes through
+	}
+	for in, want := range tests {
+		if got := stemToken(in); got != want {
+			t.Errorf("stemToken(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestStemmedHashEmbedder(t *testing.T) {
+	ctx := context.Background()
+	plain, _ := NewHashEmbedder(0)
+	stemmed, _ := NewStemmedHashEmbedder(0)
+
+	if plain.Model() == stemmed.Model() {
+		t.Fatalf("stemmed embedder must be a distinct vector identity, both report %q", plain.Model())
+	}
+	if MatchThreshold(stemmed) != lexicalMatchThreshold {
+		t.Errorf("stemmed lexical embedder should use the lexical match threshold")
+	}
+
+	a, b := "running migrations on the databases", "run migration on database"
+	pa, _ := plain.Embed(ctx, a)
+	pb, _ := plain.Embed(ctx, b)
+	sa, _ := stemmed.Embed(ctx, a)
+	sb, _ := stemmed.Embed(ctx, b)
+
+	if sim := CosineSimilarity(sa, sb); math.Abs(sim-1) > 1e-9 {
+		t.Errorf("stemmed similarity = %.3f, want 1 (inflections should collapse)", sim)
+	}
+	if CosineSimilarity(pa, pb) >= CosineSimilarity(sa, sb) {
+		t.Errorf("stemming should raise similarity of inflected forms: plain %.3f, stemmed %.3f",
+			CosineSimilarity(pa, pb), CosineSimilarity(sa, sb))
+	}
+}
+
+func TestProbeOllamaDetail(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	p := ProbeOllamaDetail(srv.URL, "nomic-embed-text")
+	if !p.Reachable || p.ModelPresent || p.OK() {
+		t.Errorf("404 probe = %+v, want reachable without model", p)
+	}
+
+	srv.Close()
+	p = ProbeOllamaDetail(srv.URL, "nomic-embed-text")
+	if p.Reachable || p.Detail == "" {
+		t.Errorf("closed-server probe = %+v, want unreachable with detail", p)
+	}
+}
+
+func TestOllamaEmbedderConcurrencyLimit(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{"embeddings":[[0.1,0.2,0.3]]}`))
+	}))
+	defer srv.Close()
+
+	emb := NewOllamaEmbedder(srv.URL, "nomic-embed-text", 3)
+	emb.SetConcurrency(2)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := emb.Embed(context.Background(), "text"); err != nil {
+				t.Errorf("Embed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := peak.Load(); got > 2 {
+		t.Errorf("peak concurrent requests = %d, want at most 2", got)
+	}
+
+	// A caller waiting for a slot gives up with its context.
+	emb.SetConcurrency(1)
+	emb.sem <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := emb.Embed(ctx, "text"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Embed with a full limit = %v, want the context's deadline", err)
+	}
+}
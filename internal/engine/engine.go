@@ -2,11 +2,16 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/lazypower/continuity/internal/config"
 	"github.com/lazypower/continuity/internal/llm"
 	"github.com/lazypower/continuity/internal/store"
 	"github.com/lazypower/continuity/internal/transcript"
@@ -19,6 +24,25 @@ type Engine struct {
 	Embedder Embedder
 	stopCh   chan struct{}
 
+	// cfg tunes the extraction pipeline. Defaults to config.Default().Engine;
+	// serve replaces it via SetConfig.
+	cfg config.EngineConfig
+
+	// mergeLLM produces merged overviews under the "llm" merge strategy.
+	// Nil falls back to LLM. See SetMergeLLM.
+	mergeLLM llm.Client
+
+	// pool bounds concurrent extraction/signal jobs; see Enqueue.
+	pool workPool
+
+	// retrying holds the session IDs RetryFailed has queued and not yet
+	// finished, so overlapping retries don't queue a session twice.
+	retrying sync.Map
+
+	// backfilling is set while the startup EmbedMissing pass runs; search
+	// results are incomplete until it clears. See Ready.
+	backfilling atomic.Bool
+
 	// Vector-identity lock. Set by ReconcileVectorIdentity when the active
 	// embedder's identity differs from the corpus's declared identity. While
 	// locked, search must fail closed rather than compare query vectors against
@@ -41,9 +65,22 @@ func New(db *store.DB, client llm.Client) *Engine {
 		DB:     db,
 		LLM:    client,
 		stopCh: make(chan struct{}),
+		cfg:    config.Default().Engine,
 	}
 }
 
+// SetConfig replaces the engine tuning knobs. Worker-pool sizing only takes
+// effect if called before the first Enqueue.
+func (e *Engine) SetConfig(cfg config.EngineConfig) {
+	e.cfg = cfg
+}
+
+// SetMergeLLM sets the client (normally the configured merge_model) used to
+// merge a mergeable memory's old and new overviews.
+func (e *Engine) SetMergeLLM(client llm.Client) {
+	e.mergeLLM = client
+}
+
 // SetEmbedder configures the embedding provider.
 func (e *Engine) SetEmbedder(emb Embedder) {
 	e.Embedder = emb
@@ -54,9 +91,10 @@ func (e *Engine) SetEmbedder(emb Embedder) {
 // compatible with the corpus — none configured, or the vector identity is locked
 // — it DELETES any existing vector and leaves the node Pending. This is critical
 // on a content UPDATE: skipping the embed while leaving the old vector in place
-// would make search serve a vector describing the previous content once the
-// embedder returns (EmbedMissing only fills MISSING vectors). DeleteVector is a
-// no-op when none exists, so a fresh node simply stays Pending.
+// would make search serve a vector describing the previous content. EmbedMissing
+// re-embeds a stale vector only once the embedder is back, and can't tell a
+// vector with no recorded text hash is stale at all. DeleteVector is a no-op
+// when none exists, so a fresh node simply stays Pending.
 func (e *Engine) EmbedNode(ctx context.Context, node *store.MemNode) error {
 	if e.Embedder == nil || e.identityMismatch {
 		return e.DB.DeleteVector(node.ID)
@@ -65,83 +103,197 @@ func (e *Engine) EmbedNode(ctx context.Context, node *store.MemNode) error {
 	if text == "" {
 		return nil
 	}
+	// Already embedded from this exact text in this space: nothing to do.
+	if existing, err := e.DB.GetVector(node.ID); err == nil && existing != nil &&
+		existing.Model == e.Embedder.Model() && existing.TextHash == store.TextHash(text) {
+		return nil
+	}
 
 	vec, err := e.Embedder.Embed(ctx, text)
 	if err != nil {
 		return fmt.Errorf("embed node %s: %w", node.URI, err)
 	}
-	return e.DB.SaveVector(node.ID, vec, e.Embedder.Model())
+	return e.DB.SaveVectorFor(node.ID, vec, e.Embedder.Model(), text)
 }
 
 // EmbedMissing embeds leaf nodes that have NO vector yet, using the active
-// embedder. It deliberately does NOT re-embed nodes whose stored model differs
-// from the active embedder: re-embedding an existing corpus into a new vector
-// space is a corpus migration and must be explicit (snapshot-first repair), not
-// a silent side effect of startup. Callers run this only when the active
-// embedder matches the corpus's declared vector identity (see
-// ReconcileVectorIdentity); while the identity is locked, it must not run.
+// embedder, and re-embeds those whose vector was made from an L0 that has
+// since changed (VectorRecord.Stale). It deliberately does NOT re-embed nodes
+// whose stored model differs from the active embedder: re-embedding an
+// existing corpus into a new vector space is a corpus migration and must be
+// explicit (snapshot-first repair), not a silent side effect of startup.
+// Callers run this only when the active embedder matches the corpus's
+// declared vector identity (see ReconcileVectorIdentity); while the identity
+// is locked, it must not run.
+//
+// Nodes are embedded in batches of embedBatchSize, with a progress line
+// after each on a large backlog. Every vector is saved as it's made, so a
+// pass cut short by ctx loses nothing: the next pass picks up the nodes
+// still pending. Returns ctx's error when cut short.
 func (e *Engine) EmbedMissing(ctx context.Context) (int, error) {
+	n, _, err := e.embedMissing(ctx)
+	return n, err
+}
+
+// embedBatchSize is how many nodes EmbedMissing embeds between progress
+// lines and cancellation checks.
+const embedBatchSize = 50
+
+// embedMissing is EmbedMissing, also reporting how many nodes it left
+// pending (cut short, or failed to embed).
+func (e *Engine) embedMissing(ctx context.Context) (embedded, remaining int, err error) {
 	if e.Embedder == nil {
-		return 0, nil
+		return 0, 0, nil
 	}
 	if e.identityMismatch {
-		return 0, nil
+		return 0, 0, nil
 	}
 
 	leaves, err := e.DB.ListLeaves()
 	if err != nil {
-		return 0, fmt.Errorf("list leaves: %w", err)
+		return 0, 0, fmt.Errorf("list leaves: %w", err)
 	}
 
-	embedded := 0
+	var pending []*store.MemNode
 	for i := range leaves {
 		if leaves[i].L0Abstract == "" {
 			continue
 		}
 
-		// Fill only truly-missing vectors. A vector that exists under a
-		// different model is STALE, not missing — leave it for explicit repair
-		// rather than silently re-embedding it into the active vector space.
+		// Fill missing vectors, and refresh ones embedded from an older L0. A
+		// vector that exists under a different model is from another space,
+		// not missing — leave it for explicit repair rather than silently
+		// re-embedding it into the active vector space. A corrupt one is as
+		// good as missing and is overwritten.
 		existing, err := e.DB.GetVector(leaves[i].ID)
-		if err != nil {
+		if err != nil && !errors.Is(err, store.ErrCorruptVector) {
 			log.Printf("embed missing: get vector for %s: %v", leaves[i].URI, err)
 			continue
 		}
-		if existing != nil {
+		if existing != nil && (existing.Model != e.Embedder.Model() || !existing.Stale(leaves[i].L0Abstract)) {
 			continue
 		}
+		pending = append(pending, &leaves[i])
+	}
 
-		if err := e.EmbedNode(ctx, &leaves[i]); err != nil {
-			log.Printf("embed missing: %v", err)
-			continue
+	failed := 0
+	for start := 0; start < len(pending); start += embedBatchSize {
+		if err := ctx.Err(); err != nil {
+			return embedded, len(pending) - start + failed, err
+		}
+		for _, node := range pending[start:min(start+embedBatchSize, len(pending))] {
+			if err := e.EmbedNode(ctx, node); err != nil {
+				log.Printf("embed missing: %v", err)
+				failed++
+				continue
+			}
+			embedded++
+		}
+		if len(pending) > embedBatchSize {
+			done := min(start+embedBatchSize, len(pending))
+			log.Printf("embed missing: %d/%d (%d%%)", done, len(pending), done*100/len(pending))
+		}
+	}
+
+	return embedded, failed, nil
+}
+
+// Backfill retry backoff: after a pass that left nodes pending, the next
+// starts embedRetryMin later, doubling up to embedRetryMax.
+const (
+	embedRetryMin = time.Minute
+	embedRetryMax = 30 * time.Minute
+)
+
+// StartEmbedBackfill runs EmbedMissing in the background, each pass bounded
+// by timeout. A pass that leaves nodes pending — it ran out of time on a slow
+// embedder, or some embeds failed — is followed by another after a backoff,
+// until none are left or the engine stops. Until the first pass finishes,
+// Ready reports the engine as warming up: the process is live, but nodes
+// without vectors are invisible to search. done gets the total embedded and
+// the last pass's error once the backfill ends.
+func (e *Engine) StartEmbedBackfill(timeout time.Duration, done func(n int, err error)) {
+	e.backfilling.Store(true)
+	go func() {
+		total, wait := 0, embedRetryMin
+		for pass := 1; ; pass++ {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			n, remaining, err := e.embedMissing(ctx)
+			cancel()
+			e.backfilling.Store(false)
+			total += n
+
+			if remaining == 0 || (err != nil && !errors.Is(err, context.DeadlineExceeded)) {
+				if done != nil {
+					done(total, err)
+				}
+				return
+			}
+			log.Printf("embed missing: pass %d embedded %d, %d still pending; retrying in %s", pass, n, remaining, wait)
+			select {
+			case <-time.After(wait):
+				wait = min(wait*2, embedRetryMax)
+			case <-e.stopCh:
+				if done != nil {
+					done(total, err)
+				}
+				return
+			}
 		}
-		embedded++
+	}()
+}
+
+// Ready reports whether the engine can serve complete results. It is false,
+// with a short reason, while the startup embedding backfill is running.
+func (e *Engine) Ready() (bool, string) {
+	if e.backfilling.Load() {
+		return false, "embedding backfill in progress"
 	}
+	return true, ""
+}
+
+// defaultDecayInterval applies when engine.decay_interval is unset.
+const defaultDecayInterval = 24 * time.Hour
+
+// maxDecayCheck caps how long the timer sleeps between due-checks. Tickers
+// run on the monotonic clock, which stops while a laptop sleeps; checking at
+// least hourly against the wall clock catches up soon after wake.
+const maxDecayCheck = time.Hour
 
-	return embedded, nil
+// ParseDecayInterval parses engine.decay_interval. Empty means the 24h
+// default; anything else must be a positive Go duration.
+func ParseDecayInterval(s string) (time.Duration, error) {
+	if s == "" {
+		return defaultDecayInterval, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive")
+	}
+	return d, nil
 }
 
-// StartDecayTimer runs smart decay on startup and then daily.
+// StartDecayTimer runs smart decay on startup if it's due, then whenever
+// engine.decay_interval has elapsed since the last run.
 func (e *Engine) StartDecayTimer() {
-	// Run once at startup
-	if updated, err := e.DB.DecayAllNodes(); err != nil {
-		log.Printf("decay error: %v", err)
-	} else if updated > 0 {
-		log.Printf("decay: updated %d nodes", updated)
+	interval, err := ParseDecayInterval(e.cfg.DecayInterval)
+	if err != nil {
+		log.Printf("decay: bad decay_interval %q (%v), using %s", e.cfg.DecayInterval, err, defaultDecayInterval)
+		interval = defaultDecayInterval
 	}
+	e.decayIfDue(time.Now(), interval)
 
 	go func() {
-		ticker := time.NewTicker(24 * time.Hour)
+		ticker := time.NewTicker(min(interval, maxDecayCheck))
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ticker.C:
-				if updated, err := e.DB.DecayAllNodes(); err != nil {
-					log.Printf("decay error: %v", err)
-				} else if updated > 0 {
-					log.Printf("decay: updated %d nodes", updated)
-				}
+				e.decayIfDue(time.Now(), interval)
 			case <-e.stopCh:
 				return
 			}
@@ -149,6 +301,66 @@ func (e *Engine) StartDecayTimer() {
 	}()
 }
 
+// decayIfDue runs DecayAllNodes when interval has passed since the recorded
+// last run (or there is none), then records now. Decay itself is computed from
+// each node's own timestamps, so one late run applies exactly the decay a
+// week asleep accrued — the schedule only decides when it's written.
+func (e *Engine) decayIfDue(now time.Time, interval time.Duration) bool {
+	last, ok, err := e.DB.GetMeta(store.MetaLastDecay)
+	if err != nil {
+		log.Printf("decay: read last run: %v", err)
+	} else if ok {
+		if ms, err := strconv.ParseInt(last, 10, 64); err == nil && now.Sub(time.UnixMilli(ms)) < interval {
+			return false
+		}
+	}
+
+	if report, err := e.DB.DecayAllNodesReport(); err != nil {
+		log.Printf("decay error: %v", err)
+		return false
+	} else if e.cfg.DecayVerbose {
+		log.Printf("decay: %s", report)
+	} else if report.Updated > 0 {
+		log.Printf("decay: updated %d nodes", report.Updated)
+	}
+	if err := e.DB.SetMeta(store.MetaLastDecay, strconv.FormatInt(now.UnixMilli(), 10)); err != nil {
+		log.Printf("decay: record last run: %v", err)
+	}
+	e.pruneObservations(now)
+	e.pruneSessionNotes(now)
+	return true
+}
+
+// sessionNoteGrace is how long after its session stops a session note
+// survives when no SessionEnd cleared it, so a resume still finds it.
+const sessionNoteGrace = 24 * time.Hour
+
+// pruneSessionNotes sweeps session notes left behind by sessions that never
+// sent SessionEnd (a crash, a killed terminal). Rides the decay schedule.
+func (e *Engine) pruneSessionNotes(now time.Time) {
+	if n, err := e.DB.PruneStaleSessionNotes(now.Add(-sessionNoteGrace).UnixMilli()); err != nil {
+		log.Printf("session notes: prune: %v", err)
+	} else if n > 0 {
+		log.Printf("session notes: pruned %d left by ended sessions", n)
+	}
+}
+
+// pruneObservations drops extracted sessions' observations older than
+// engine.observation_retention_days. Rides the decay schedule: both are
+// housekeeping with no need to run more than daily.
+func (e *Engine) pruneObservations(now time.Time) {
+	days := e.cfg.ObservationRetentionDays
+	if days <= 0 {
+		return
+	}
+	cutoff := now.Add(-time.Duration(days) * 24 * time.Hour).UnixMilli()
+	if n, err := e.DB.PruneObservations(cutoff); err != nil {
+		log.Printf("observations: prune: %v", err)
+	} else if n > 0 {
+		log.Printf("observations: pruned %d older than %d days", n, days)
+	}
+}
+
 // Stop shuts down the engine's background goroutines.
 func (e *Engine) Stop() {
 	close(e.stopCh)
@@ -157,7 +369,9 @@ func (e *Engine) Stop() {
 // Dedup finds semantically duplicate leaf nodes and merges them.
 // For each category, it clusters nodes by cosine similarity above threshold,
 // keeps the most recently updated node per cluster, and deletes the rest.
-// Returns the number of nodes removed.
+// Categories of lshMinCategory nodes or more only compare pairs that share a
+// random-hyperplane bucket (see lsh.go), trading a little recall for avoiding
+// the O(n²) scan. Returns the number of nodes removed.
 func (e *Engine) Dedup(ctx context.Context, threshold float64) (int, error) {
 	if e.Embedder == nil {
 		return 0, fmt.Errorf("no embedder configured")
@@ -182,7 +396,7 @@ func (e *Engine) Dedup(ctx context.Context, threshold float64) (int, error) {
 			log.Printf("dedup: embed %s: %v", leaves[i].URI, err)
 			continue
 		}
-		e.DB.SaveVector(leaves[i].ID, vec, e.Embedder.Model())
+		e.DB.SaveVectorIfAbsent(leaves[i].ID, vec, e.Embedder.Model(), leaves[i].L0Abstract)
 	}
 
 	// Load all vectors and build lookup
@@ -209,41 +423,19 @@ func (e *Engine) Dedup(ctx context.Context, threshold float64) (int, error) {
 		byCategory[n.Category] = append(byCategory[n.Category], n)
 	}
 
+	// absorbed maps each cluster's survivor to the duplicates it replaces,
+	// so their IDs land in its merged_from.
+	absorbed := make(map[int64][]int64)
 	removed := 0
 	for cat, nodes := range byCategory {
-		// Track which nodes are already claimed by a cluster
-		claimed := make(map[int64]bool)
-
-		for i := 0; i < len(nodes); i++ {
-			if claimed[nodes[i].ID] {
-				continue
-			}
-			vecI, ok := vecMap[nodes[i].ID]
-			if !ok {
-				continue
-			}
-
-			// Start a cluster with this node as the initial keeper
-			cluster := []int{i}
-			for j := i + 1; j < len(nodes); j++ {
-				if claimed[nodes[j].ID] {
-					continue
-				}
-				vecJ, ok := vecMap[nodes[j].ID]
-				if !ok {
-					continue
-				}
-
-				sim := CosineSimilarity(vecI, vecJ)
-				if sim >= threshold {
-					cluster = append(cluster, j)
-				}
-			}
-
-			if len(cluster) <= 1 {
-				continue
-			}
+		// Large categories only compare LSH-bucketed candidates; small ones
+		// compare every pair.
+		vecs := make([][]float64, len(nodes))
+		for i := range nodes {
+			vecs[i] = vecMap[nodes[i].ID]
+		}
 
+		for _, cluster := range greedyClusters(vecs, threshold) {
 			// Find the most recently updated node in the cluster
 			bestIdx := cluster[0]
 			for _, idx := range cluster[1:] {
@@ -254,27 +446,21 @@ func (e *Engine) Dedup(ctx context.Context, threshold float64) (int, error) {
 
 			// Delete all others
 			for _, idx := range cluster {
-				claimed[nodes[idx].ID] = true
 				if idx == bestIdx {
 					continue
 				}
 				log.Printf("dedup: removing %s (duplicate of %s in %s)", nodes[idx].URI, nodes[bestIdx].URI, cat)
-				if err := e.DB.DeleteNode(nodes[idx].ID); err != nil {
-					log.Printf("dedup: delete %s: %v", nodes[idx].URI, err)
-					continue
-				}
+				absorbed[nodes[bestIdx].ID] = append(absorbed[nodes[bestIdx].ID], nodes[idx].ID)
 				removed++
 			}
 		}
 	}
 
-	// Clean up orphaned directory nodes
-	if orphans, err := e.DB.DeleteOrphanDirs(); err != nil {
-		log.Printf("dedup: cleanup orphan dirs: %v", err)
-	} else if orphans > 0 {
-		log.Printf("dedup: removed %d orphaned directory nodes", orphans)
+	// One transaction for every deletion and the orphaned-directory sweep: a
+	// failure leaves the tree exactly as it was, not half-deduplicated.
+	if err := e.DB.AbsorbDuplicates(absorbed); err != nil {
+		return 0, fmt.Errorf("delete duplicates: %w", err)
 	}
-
 	return removed, nil
 }
 
@@ -287,6 +473,10 @@ type RememberInput struct {
 	Detail    string // L2 content (optional)
 	SessionID string // optional provenance
 
+	// Mergeable overrides the category's merge behavior for this memory;
+	// nil keeps the default.
+	Mergeable *bool
+
 	// AcknowledgeRetracted, when true, bypasses the dedup-against-retracted gate.
 	// Set this only after the agent has fetched the matched memory's reason via
 	// `continuity show <uri> --include-retracted` and decided the candidate is
@@ -311,6 +501,8 @@ func (e *Engine) Remember(ctx context.Context, input RememberInput) (string, boo
 		L0:       input.Summary,
 		L1:       input.Body,
 		L2:       input.Detail,
+
+		Mergeable: input.Mergeable,
 	}
 
 	vc, err := validateCandidate(c)
@@ -319,8 +511,10 @@ func (e *Engine) Remember(ctx context.Context, input RememberInput) (string, boo
 	}
 	c = vc
 
-	owner := ownerForCategory(c.Category)
-	requestedURI := fmt.Sprintf("mem://%s/%s/%s", owner, c.Category, c.URIHint)
+	if c.Category == "session" {
+		return "", false, validationErrorf("session notes are written by extraction only")
+	}
+	requestedURI := candidateURI(c, input.SessionID)
 
 	existing, err := e.DB.GetNodeByURI(requestedURI)
 	if err != nil {
@@ -374,6 +568,7 @@ func (e *Engine) Remember(ctx context.Context, input RememberInput) (string, boo
 		L1Overview:    c.L1,
 		L2Content:     c.L2,
 		SourceSession: input.SessionID,
+		MergeOverride: c.Mergeable,
 	}
 
 	if err := e.DB.UpsertNode(node); err != nil {
@@ -383,10 +578,13 @@ func (e *Engine) Remember(ctx context.Context, input RememberInput) (string, boo
 	// UpsertNode mutates node.URI when an immutable-category slug collision
 	// triggers the timestamp-suffix path. created reflects whether a new row
 	// was inserted (fresh slug, OR collision-with-suffix), as opposed to an
-	// in-place merge of a mergeable category.
+	// in-place merge of a mergeable memory.
 	created := existing == nil || node.URI != requestedURI
 	storedURI := node.URI
 	log.Printf("remember: stored %s [%s] (created=%v)", storedURI, c.Category, created)
+	recordSessionMemory(e.DB, input.SessionID, requestedURI, existing, node, "remember")
+	pruneSlugVersions(e.DB, e.cfg.ImmutableKeep, requestedURI, node)
+	enforceCategoryCap(e.DB, e.cfg.CategoryCaps, node)
 
 	// Reconcile the stored vector with the new content UNCONDITIONALLY: EmbedNode
 	// embeds when possible, or clears a stale vector when no compatible embedder
@@ -410,6 +608,166 @@ func (e *Engine) Remember(ctx context.Context, input RememberInput) (string, boo
 	return storedURI, created, nil
 }
 
+// NodeInput is a full memory spec from an external tool (POST /api/nodes):
+// a git hook recording a deploy, a script importing notes. Unlike
+// RememberInput it goes through extraction's dedup, so a tool that reports
+// the same fact twice updates one memory instead of piling up copies.
+type NodeInput struct {
+	Category string
+	URIHint  string // slug; ignored when MergeTarget is set
+	L0       string
+	L1       string
+	L2       string
+
+	// MergeTarget is the URI of an existing live, mergeable memory to update
+	// in place. It must be in Category, or Category may be left empty.
+	MergeTarget string
+
+	SessionID string // optional provenance
+	Mergeable *bool  // nil keeps the category default
+}
+
+// NodeResult reports where StoreNode put a memory.
+type NodeResult struct {
+	URI     string
+	Created bool // a new row, as opposed to an update of an existing one
+
+	// Duplicate is set when the content restated an existing immutable
+	// memory and nothing was written; URI is that memory.
+	Duplicate bool
+}
+
+// StoreNode validates and stores a NodeInput the way extraction stores a
+// candidate: validateCandidate, the immutable near-duplicate gate, the
+// similarity redirect onto an equivalent live memory, the retracted-memory
+// gate, the configured merge strategy, and embedding. An explicit
+// MergeTarget skips the duplicate and similarity gates — the caller chose
+// the memory — but never the retracted-memory gate. Input the caller can fix
+// is a ValidationError; a retracted match is a RetractedMatchError.
+func (e *Engine) StoreNode(ctx context.Context, input NodeInput) (NodeResult, error) {
+	c := memoryCandidate{
+		Category:  input.Category,
+		URIHint:   input.URIHint,
+		L0:        input.L0,
+		L1:        input.L1,
+		L2:        input.L2,
+		Mergeable: input.Mergeable,
+	}
+
+	var target *store.MemNode
+	if input.MergeTarget != "" {
+		n, err := e.DB.GetNodeByURI(input.MergeTarget)
+		if err != nil {
+			return NodeResult{}, fmt.Errorf("look up merge target: %w", err)
+		}
+		switch {
+		case n == nil:
+			return NodeResult{}, validationErrorf("merge target not found: %s", input.MergeTarget)
+		case n.NodeType != "leaf":
+			return NodeResult{}, validationErrorf("merge target %s is a %s node; only leaf memories take content", input.MergeTarget, n.NodeType)
+		case n.IsRetracted():
+			return NodeResult{}, validationErrorf("merge target %s is retracted", input.MergeTarget)
+		case c.Category != "" && c.Category != n.Category:
+			return NodeResult{}, validationErrorf("merge target %s is in %s, not %s", input.MergeTarget, n.Category, c.Category)
+		case !n.Mergeable:
+			return NodeResult{}, validationErrorf("merge target %s keeps versions instead of merging; store a new memory with uri_hint", input.MergeTarget)
+		}
+		target = n
+		c.Category = n.Category
+		c.URIHint = n.URI[strings.LastIndex(n.URI, "/")+1:]
+	}
+
+	vc, err := validateCandidate(c)
+	if err != nil {
+		return NodeResult{}, fmt.Errorf("validate: %w", err)
+	}
+	c = vc
+	if c.Category == "session" {
+		return NodeResult{}, validationErrorf("session notes are written by extraction only")
+	}
+
+	// Fail closed while the vector identity is locked, as Remember does: the
+	// retracted-memory gate can't run, and there is no override here.
+	if e.identityMismatch {
+		return NodeResult{}, validationErrorf("vector identity is locked — the retracted-memory check cannot run; resolve with `continuity doctor` (and --repair-vectors)")
+	}
+	embedder := withEmbedCache(e.Embedder)
+
+	uri := candidateURI(c, input.SessionID)
+	if target != nil {
+		uri = target.URI
+	} else {
+		if dup, sim, err := immutableDuplicate(ctx, e.DB, embedder, c, e.cfg.ImmutableDedupThreshold); err != nil {
+			log.Printf("nodes: immutable dedup check failed: %v", err)
+		} else if dup != nil {
+			log.Printf("nodes: %s is a near-duplicate of %s (similarity: %.3f), not stored", uri, dup.URI, sim)
+			return NodeResult{URI: dup.URI, Duplicate: true}, nil
+		}
+		if embedder != nil {
+			match, sim, err := findSimilarNode(ctx, e.DB, embedder, c.L0, c.Category, MatchThreshold(embedder))
+			if err != nil {
+				log.Printf("nodes: similarity check failed: %v", err)
+			} else if match != nil {
+				log.Printf("nodes: merging %s → %s (similarity: %.3f)", uri, match.URI, sim)
+				uri = match.URI
+			}
+		}
+	}
+
+	if embedder != nil {
+		matches, err := findRetractedMatchesIn(ctx, e.DB, embedder, c.L0, c.Category, MatchThreshold(embedder))
+		if err != nil {
+			return NodeResult{}, fmt.Errorf("retracted-memory check failed (failing closed): %w", err)
+		}
+		if len(matches) > 0 {
+			uris := make([]string, len(matches))
+			for i, m := range matches {
+				uris[i] = m.URI
+			}
+			log.Printf("dedup-retracted: node candidate matches %d retracted node(s) hash=%s", len(matches), hashMatchedURIs(matches))
+			return NodeResult{}, &RetractedMatchError{MatchedURIs: uris}
+		}
+	}
+
+	existing, err := e.DB.GetNodeByURI(uri)
+	if err != nil {
+		return NodeResult{}, fmt.Errorf("check existing: %w", err)
+	}
+	if existing != nil && existing.IsRetracted() {
+		return NodeResult{}, validationErrorf("uri %s is retracted; choose a different uri_hint", uri)
+	}
+
+	node := &store.MemNode{
+		URI:           uri,
+		NodeType:      "leaf",
+		Category:      c.Category,
+		L0Abstract:    c.L0,
+		L1Overview:    c.L1,
+		L2Content:     c.L2,
+		SourceSession: input.SessionID,
+		MergeOverride: c.Mergeable,
+	}
+	e.merger().apply(ctx, e.DB, node)
+	if err := e.DB.UpsertNode(node); err != nil {
+		return NodeResult{}, fmt.Errorf("upsert: %w", err)
+	}
+	log.Printf("nodes: stored %s [%s]", node.URI, c.Category)
+	recordSessionMemory(e.DB, input.SessionID, uri, existing, node, "nodes")
+	if d, ok := mergeDiff(existing, node); ok {
+		logMergeDiff("nodes", d)
+	}
+	pruneSlugVersions(e.DB, e.cfg.ImmutableKeep, uri, node)
+	enforceCategoryCap(e.DB, e.cfg.CategoryCaps, node)
+
+	if stored, err := e.DB.GetNodeByURI(node.URI); err == nil && stored != nil {
+		if err := e.EmbedNode(ctx, stored); err != nil {
+			log.Printf("nodes: embed %s: %v", node.URI, err)
+		}
+	}
+
+	return NodeResult{URI: node.URI, Created: existing == nil || node.URI != uri}, nil
+}
+
 const maxMoments = 10
 
 // evictRedundantMoment checks the moments pool size and removes the most
@@ -503,7 +861,39 @@ func (e *Engine) evictRedundantMoment(ctx context.Context) (string, error) {
 // ExtractSignal processes a user-flagged signal prompt and creates a memory immediately.
 // This is designed to be called asynchronously (in a goroutine).
 func (e *Engine) ExtractSignal(ctx context.Context, sessionID, prompt string) error {
-	if e.LLM == nil {
+	return e.ExtractSignalAs(ctx, sessionID, prompt, "")
+}
+
+// SignalCategory reports whether category can be forced on a signal: any
+// writable category except moments, which pass their own qualification, and
+// session notes, which only extraction writes.
+func SignalCategory(category string) bool {
+	return validCategories[category] && category != "moments" && category != "session"
+}
+
+// CheckCategoryCaps validates engine.category_caps: each key must be a
+// memory category and each cap non-negative.
+func CheckCategoryCaps(caps map[string]int) error {
+	for cat, n := range caps {
+		if !validCategories[cat] {
+			return fmt.Errorf("unknown category %q", cat)
+		}
+		if n < 0 {
+			return fmt.Errorf("%s: cap must be 0 (unbounded) or more, got %d", cat, n)
+		}
+	}
+	return nil
+}
+
+// ExtractSignalAs is ExtractSignal with the memory's category fixed, for a
+// signal that named one ("remember this pattern: ..."). An empty category
+// leaves the choice to the LLM, nudged toward engine.signal_default_category.
+func (e *Engine) ExtractSignalAs(ctx context.Context, sessionID, prompt, category string) error {
+	if category != "" && !SignalCategory(category) {
+		return fmt.Errorf("signal: invalid category %q", category)
+	}
+	ruleBased := e.LLM == nil
+	if ruleBased && !e.cfg.RuleBasedSignals {
 		return fmt.Errorf("LLM not configured")
 	}
 
@@ -515,38 +905,72 @@ func (e *Engine) ExtractSignal(ctx context.Context, sessionID, prompt string) er
 		return nil
 	}
 
-	resp, err := e.LLM.Complete(ctx, llm.SignalExtractionPrompt(prompt))
-	if err != nil {
-		return fmt.Errorf("signal extraction LLM: %w", err)
-	}
-
-	candidates, err := parseExtractionResponse(resp.Content)
-	if err != nil {
-		return fmt.Errorf("parse signal response: %w", err)
+	var candidates []memoryCandidate
+	if ruleBased {
+		// No LLM: keep the user's own words. Cruder than extraction, but the
+		// intent is captured and a later session can refine it.
+		c, ok := ruleBasedSignal(prompt, category, e.cfg.SignalDefaultCategory)
+		if !ok {
+			return fmt.Errorf("signal: no trigger phrase to capture from")
+		}
+		candidates = []memoryCandidate{c}
+	} else {
+		resp, err := e.LLM.Complete(ctx, llm.SignalExtractionPrompt(prompt, e.cfg.Language, e.cfg.SignalDefaultCategory, category))
+		if err != nil {
+			return fmt.Errorf("signal extraction LLM: %w", err)
+		}
+		candidates, err = parseExtractionResponse(resp.Content)
+		if err != nil {
+			return fmt.Errorf("parse signal response: %w", err)
+		}
 	}
 
+	// The gates and the final embed all embed the candidate's L0; embed it once.
+	embedder := withEmbedCache(e.embedderIfUnlocked())
 	for _, c := range candidates {
+		if category != "" {
+			c.Category = category // the user's word beats the model's
+		}
 		vc, err := validateCandidate(c)
 		if err != nil {
 			log.Printf("signal: rejecting candidate %q: %v", c.URIHint, err)
 			continue
 		}
 		c = vc
+		// The generic-content floor is for model output; verbatim user text
+		// is taken as given, like a direct write.
+		if !ruleBased {
+			if err := scoreSpecificity(c, e.cfg.GenericPhrases); err != nil {
+				log.Printf("signal: rejecting candidate %q: %v", c.URIHint, err)
+				continue
+			}
+		}
 
-		owner := ownerForCategory(c.Category)
-		uri := fmt.Sprintf("mem://%s/%s/%s", owner, c.Category, c.URIHint)
+		if c.Category == "session" {
+			log.Printf("signal: rejecting candidate %q: session notes come from extraction only", c.URIHint)
+			continue
+		}
+		uri := candidateURI(c, sessionID)
 
 		// An LLM-supplied merge_target is intentionally NOT honored (see the matching
 		// note in extractMemories): trusting an LLM-chosen URI was a recurring gate
 		// bypass. The candidate always lands in its declared category, so the gate
 		// keys on c.Category.
 
+		// Immutable near-duplicate gate (see extractMemories).
+		if dup, sim, err := immutableDuplicate(ctx, e.DB, embedder, c, e.cfg.ImmutableDedupThreshold); err != nil {
+			log.Printf("signal: immutable dedup check failed: %v", err)
+		} else if dup != nil {
+			log.Printf("signal: skipping %s — near-duplicate of %s (similarity: %.3f)", uri, dup.URI, sim)
+			continue
+		}
+
 		// Retraction-resurrection gate (per-candidate, fail-closed): a signal
 		// candidate matching a retracted memory must not be written. Skip only the
 		// offending candidate; on a gate error skip it too rather than write unchecked.
 		// (Locked identity is handled above; embedder is nil here only in `none` mode.)
-		if emb := e.embedderIfUnlocked(); emb != nil && c.L0 != "" {
-			matches, err := e.findRetractedMatches(ctx, c.L0, c.Category, MatchThreshold(emb))
+		if embedder != nil && c.L0 != "" {
+			matches, err := findRetractedMatchesIn(ctx, e.DB, embedder, c.L0, c.Category, MatchThreshold(embedder))
 			if err != nil {
 				log.Printf("signal: retracted-check failed for %s — skipping candidate (fail-closed): %v", uri, err)
 				continue
@@ -560,7 +984,8 @@ func (e *Engine) ExtractSignal(ctx context.Context, sessionID, prompt string) er
 		// Exact retracted-URI guard (mirrors Remember): the constructed uri_hint can
 		// still collide with a retracted canonical node the vector gate can't catch
 		// (no same-identity vector). UpsertNode also enforces this atomically.
-		if existing, err := e.DB.GetNodeByURI(uri); err == nil && existing != nil && existing.IsRetracted() {
+		existing, err := e.DB.GetNodeByURI(uri)
+		if err == nil && existing != nil && existing.IsRetracted() {
 			log.Printf("signal: skipping %s — target URI is retracted (would resurrect)", uri)
 			continue
 		}
@@ -573,24 +998,26 @@ func (e *Engine) ExtractSignal(ctx context.Context, sessionID, prompt string) er
 			L1Overview:    c.L1,
 			L2Content:     c.L2,
 			SourceSession: sessionID,
+			MergeOverride: c.Mergeable,
 		}
 
+		e.merger().apply(ctx, e.DB, node)
 		if err := e.DB.UpsertNode(node); err != nil {
 			log.Printf("signal: failed to upsert %s: %v", uri, err)
 			continue
 		}
 		log.Printf("signal: stored %s [%s]", uri, c.Category)
+		recordSessionMemory(e.DB, sessionID, uri, existing, node, "signal")
+		if d, ok := mergeDiff(existing, node); ok {
+			logMergeDiff("signal", d)
+		}
+		pruneSlugVersions(e.DB, e.cfg.ImmutableKeep, uri, node)
+		enforceCategoryCap(e.DB, e.cfg.CategoryCaps, node)
 
-		// Keep the stored vector in sync; when locked/none, DELETE any stale vector
-		// so a content update can't leave search serving the previous content.
+		// Keep the stored vector in sync with the (possibly updated) content.
 		if stored, err := e.DB.GetNodeByURI(node.URI); err == nil && stored != nil {
-			if emb := e.embedderIfUnlocked(); emb != nil && stored.L0Abstract != "" {
-				if vec, err := emb.Embed(ctx, stored.L0Abstract); err == nil {
-					e.DB.SaveVector(stored.ID, vec, emb.Model())
-				}
-			} else {
-				e.DB.DeleteVector(stored.ID)
-			}
+			saveEntity(e.DB, stored.ID, c, "signal")
+			syncVector(ctx, e.DB, embedder, stored, "signal")
 		}
 	}
 
@@ -598,13 +1025,13 @@ func (e *Engine) ExtractSignal(ctx context.Context, sessionID, prompt string) er
 }
 
 // extractTone runs tone extraction for a session and stores the result.
-func extractTone(db *store.DB, client llm.Client, sessionID, transcriptPath string) error {
-	entries, err := transcript.ParseFile(transcriptPath)
+func extractTone(db *store.DB, client llm.Client, cfg config.EngineConfig, sessionID, transcriptPath string) error {
+	entries, err := parseTranscript(transcriptPath, cfg)
 	if err != nil {
 		return fmt.Errorf("parse transcript: %w", err)
 	}
 
-	condensed := transcript.Condense(entries)
+	condensed := condense(entries, cfg)
 	if len(condensed) < 100 {
 		return nil // too short for meaningful tone
 	}
@@ -639,12 +1066,14 @@ func extractTone(db *store.DB, client llm.Client, sessionID, transcriptPath stri
 // ExtractSession runs the full extraction pipeline for a completed session.
 // This is designed to be called asynchronously (in a goroutine).
 // Idempotent: skips sessions that have already been extracted.
-// Content-gated: sessions with insufficient content (fewer than 3 user
-// messages or <100 chars condensed) return nil WITHOUT marking the session
-// as extracted, so subsequent Stop/SessionEnd hooks get another chance once
-// the conversation grows.
+// Content-gated: sessions with insufficient content (fewer than
+// MinUserMessages user messages or fewer than MinCondensedChars condensed)
+// return nil WITHOUT marking the session as extracted, so subsequent
+// Stop/SessionEnd hooks get another chance once the conversation grows. The
+// reason is recorded on the session row (skip_reason) so it can be surfaced.
 func (e *Engine) ExtractSession(sessionID, transcriptPath string) error {
-	return e.extractSession(sessionID, transcriptPath, false)
+	_, err := e.extractSession(sessionID, transcriptPath, false)
+	return err
 }
 
 // ExtractSessionForce runs extraction while bypassing the idempotency guard.
@@ -652,36 +1081,75 @@ func (e *Engine) ExtractSession(sessionID, transcriptPath string) error {
 // session is a no-op. Used by `continuity extract --force` for reprocessing
 // sessions that were incorrectly marked as extracted.
 func (e *Engine) ExtractSessionForce(sessionID, transcriptPath string) error {
-	return e.extractSession(sessionID, transcriptPath, true)
+	_, err := e.extractSession(sessionID, transcriptPath, true)
+	return err
 }
 
-func (e *Engine) extractSession(sessionID, transcriptPath string, force bool) error {
+// ExtractResult reports what one extraction pass did, for callers that wait
+// on it rather than fire and forget.
+type ExtractResult struct {
+	Stored  []string    `json:"stored"`            // memory URIs written (created or merged into)
+	Merged  []MergeDiff `json:"merged,omitempty"`  // L1 before/after for each merge into an existing memory
+	Skipped string      `json:"skipped,omitempty"` // why nothing was extracted, when nothing was
+}
+
+// ExtractSessionResult is ExtractSession (or ExtractSessionForce, with force)
+// returning the accepted memories instead of only logging them.
+func (e *Engine) ExtractSessionResult(sessionID, transcriptPath string, force bool) (ExtractResult, error) {
+	return e.extractSession(sessionID, transcriptPath, force)
+}
+
+// extractSession runs one extraction and keeps the failed_extractions record
+// in step with how it went. See recordOutcome.
+func (e *Engine) extractSession(sessionID, transcriptPath string, force bool) (ExtractResult, error) {
+	res, err := e.runExtraction(sessionID, transcriptPath, force)
+	e.recordOutcome(sessionID, transcriptPath, res, err)
+	return res, err
+}
+
+func (e *Engine) runExtraction(sessionID, transcriptPath string, force bool) (ExtractResult, error) {
+	var res ExtractResult
 	if transcriptPath == "" {
-		return fmt.Errorf("no transcript path provided")
+		return res, fmt.Errorf("no transcript path provided")
+	}
+
+	sess, err := e.DB.GetSession(sessionID)
+	if err != nil {
+		return res, fmt.Errorf("check session: %w", err)
 	}
 
 	// Idempotency guard: skip if already extracted (unless forced)
-	if !force {
-		sess, err := e.DB.GetSession(sessionID)
-		if err != nil {
-			return fmt.Errorf("check session: %w", err)
-		}
-		if sess != nil && sess.ExtractedAt != nil {
-			log.Printf("extraction: skipping %s — already extracted", sessionID)
-			return nil
-		}
+	if !force && sess != nil && sess.ExtractedAt != nil {
+		log.Printf("extraction: skipping %s — already extracted", sessionID)
+		res.Skipped = skipAlreadyExtracted
+		return res, nil
+	}
+
+	// Hooks may send ~ or a path relative to the session's CWD; resolve it
+	// against the session's project before anything tries to open it.
+	var project string
+	if sess != nil {
+		project = sess.Project
+	}
+	transcriptPath, err = transcript.ResolvePath(transcriptPath, project)
+	if err != nil {
+		return res, err
 	}
 
 	// Pre-flight content gate — return without marking if there's not enough
 	// to extract yet. Parsing the transcript here is cheap; the downstream
 	// extractors re-parse but that's a separate concern.
-	ok, reason, err := hasEnoughContent(transcriptPath)
+	ok, reason, err := hasEnoughContent(transcriptPath, e.cfg)
 	if err != nil {
-		return fmt.Errorf("content gate: %w", err)
+		return res, fmt.Errorf("content gate: %w", err)
 	}
 	if !ok {
 		log.Printf("extraction: skipping %s — %s (not marking)", sessionID, reason)
-		return nil
+		if err := e.DB.SetSkipReason(sessionID, reason); err != nil {
+			log.Printf("extraction: failed to record skip reason for %s: %v", sessionID, err)
+		}
+		res.Skipped = reason
+		return res, nil
 	}
 
 	// Fail closed while the vector identity is locked: the active embedder is
@@ -692,20 +1160,30 @@ func (e *Engine) extractSession(sessionID, transcriptPath string, force bool) er
 	// re-extracts once the operator repairs (`continuity doctor --repair-vectors`).
 	if e.identityMismatch {
 		log.Printf("extraction: deferring %s — vector identity locked; run `continuity doctor --repair-vectors` (not marking extracted)", sessionID)
-		return nil
+		res.Skipped = skipIdentityLocked
+		return res, nil
+	}
+
+	// Only reachable under rule-based signals. The session stays unmarked so
+	// it extracts once an LLM is back.
+	if e.LLM == nil {
+		return res, fmt.Errorf("LLM not configured")
 	}
 
 	// embedderIfUnlocked: with the identity NOT locked, this is the active embedder
 	// (or nil only in `none` mode, where the operator opted out of the gate).
-	if err := extractMemories(e.DB, e.LLM, e.embedderIfUnlocked(), sessionID, transcriptPath); err != nil {
-		return fmt.Errorf("memory extraction: %w", err)
+	stored, merges, err := extractMemories(e.DB, e.LLM, e.merger(), e.embedderIfUnlocked(), e.cfg, sessionID, transcriptPath)
+	if err != nil {
+		return res, fmt.Errorf("memory extraction: %w", err)
 	}
+	res.Stored = stored
+	res.Merged = merges
 
-	if err := extractRelational(e.DB, e.LLM, sessionID, transcriptPath); err != nil {
-		return fmt.Errorf("relational extraction: %w", err)
+	if err := extractRelational(e.DB, e.LLM, e.cfg, sessionID, transcriptPath); err != nil {
+		return res, fmt.Errorf("relational extraction: %w", err)
 	}
 
-	if err := extractTone(e.DB, e.LLM, sessionID, transcriptPath); err != nil {
+	if err := extractTone(e.DB, e.LLM, e.cfg, sessionID, transcriptPath); err != nil {
 		log.Printf("tone extraction failed (non-fatal): %v", err)
 	}
 
@@ -714,23 +1192,57 @@ func (e *Engine) extractSession(sessionID, transcriptPath string, force bool) er
 		log.Printf("extraction: failed to mark %s as extracted: %v", sessionID, err)
 	}
 
-	return nil
+	return res, nil
+}
+
+// CondensedTranscript returns the text extraction would send the LLM for
+// the transcript at path: parsed under the configured skip rules, condensed,
+// and capped at max_condensed_chars. No LLM call and no writes; for seeing
+// what a session's memories were extracted from.
+func (e *Engine) CondensedTranscript(path string) (string, error) {
+	entries, err := parseTranscript(path, e.cfg)
+	if err != nil {
+		return "", fmt.Errorf("parse transcript: %w", err)
+	}
+	return condense(entries, e.cfg), nil
+}
+
+// condense condenses entries for an LLM pass, capped at the configured
+// max_condensed_chars budget.
+func condense(entries []transcript.ParsedEntry, cfg config.EngineConfig) string {
+	return transcript.Truncate(transcript.Condense(entries), cfg.MaxCondensedChars)
+}
+
+// parseTranscript parses a transcript under the configured skip rules.
+func parseTranscript(path string, cfg config.EngineConfig) ([]transcript.ParsedEntry, error) {
+	return transcript.ParseFileOpts(path, transcript.ParseOpts{
+		MinLength: cfg.TranscriptMinLength,
+		KeepJSON:  cfg.TranscriptKeepJSON,
+	})
 }
 
 // hasEnoughContent returns true when the transcript meets the extractors'
-// minimum thresholds (>=3 user messages AND >=100 chars condensed). This is
-// the single source of truth for the content gate — mirrored client-side in
-// the Stop hook to avoid unnecessary HTTP round-trips.
-func hasEnoughContent(transcriptPath string) (bool, string, error) {
-	entries, err := transcript.ParseFile(transcriptPath)
+// minimum thresholds (>=MinUserMessages user messages AND >=MinCondensedChars
+// condensed). This is the single source of truth for the content gate —
+// mirrored client-side in the Stop hook (with the default thresholds) to avoid
+// unnecessary HTTP round-trips. The returned reason is short and user-facing;
+// it is stored verbatim as the session's skip_reason.
+func hasEnoughContent(transcriptPath string, cfg config.EngineConfig) (bool, string, error) {
+	entries, err := parseTranscript(transcriptPath, cfg)
 	if err != nil {
 		return false, "", fmt.Errorf("parse transcript: %w", err)
 	}
-	if transcript.CountUserMessages(entries) < 3 {
-		return false, "fewer than 3 user messages", nil
+	ok, reason := contentGate(entries, cfg)
+	return ok, reason, nil
+}
+
+// contentGate applies the content thresholds to already-parsed entries.
+func contentGate(entries []transcript.ParsedEntry, cfg config.EngineConfig) (bool, string) {
+	if n := transcript.CountUserMessages(entries); n < cfg.MinUserMessages {
+		return false, fmt.Sprintf("too few user messages (%d < %d)", n, cfg.MinUserMessages)
 	}
-	if len(transcript.Condense(entries)) < 100 {
-		return false, "condensed transcript too short", nil
+	if n := len(transcript.Condense(entries)); n < cfg.MinCondensedChars {
+		return false, fmt.Sprintf("condensed transcript too short (%d < %d chars)", n, cfg.MinCondensedChars)
 	}
-	return true, "", nil
+	return true, ""
 }
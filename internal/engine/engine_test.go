@@ -8,7 +8,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/lazypower/continuity/internal/config"
 	"github.com/lazypower/continuity/internal/llm"
 	"github.com/lazypower/continuity/internal/store"
 )
@@ -85,7 +87,7 @@ func TestExtractSession(t *testing.T) {
 	engine := New(db, mock)
 
 	// Only test extraction, not relational (mock returns same response for both)
-	err := extractMemories(db, mock, nil, "test-session", transcriptPath)
+	_, _, err := extractMemories(db, mock, nil, nil, config.Default().Engine, "test-session", transcriptPath)
 	if err != nil {
 		t.Fatalf("extractMemories: %v", err)
 	}
@@ -126,7 +128,7 @@ func TestExtractSessionSkipsFewMessages(t *testing.T) {
 		{"type": "user", "message": map[string]any{"role": "user", "content": "Goodbye this is another test message"}},
 	})
 
-	err := extractMemories(db, mock, nil, "test-session", path)
+	_, _, err := extractMemories(db, mock, nil, nil, config.Default().Engine, "test-session", path)
 	if err != nil {
 		t.Fatalf("extractMemories: %v", err)
 	}
@@ -137,6 +139,42 @@ func TestExtractSessionSkipsFewMessages(t *testing.T) {
 	}
 }
 
+func TestExtractSkipsImmutableNearDuplicate(t *testing.T) {
+	response := func(hint string) string {
+		return `[{"category":"events","uri_hint":"` + hint + `",
+			"l0":"Migrated the continuity store from bbolt to SQLite with WAL mode",
+			"l1":"Moved the continuity storage layer off bbolt onto SQLite in WAL mode to get concurrent readers and real SQL queries."}]`
+	}
+
+	for _, tc := range []struct {
+		name      string
+		threshold float64
+		want      int
+	}{
+		{"enabled", 0.92, 1},
+		{"disabled", 0, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := testDB(t)
+			emb, _ := NewHashEmbedder(0)
+			cfg := config.Default().Engine
+			cfg.ImmutableDedupThreshold = tc.threshold
+
+			for i, hint := range []string{"sqlite-migration", "moved-to-sqlite"} {
+				mock := &llm.MockClient{Response: &llm.Response{Content: response(hint), Provider: "mock"}}
+				if _, _, err := extractMemories(db, mock, nil, emb, cfg, fmt.Sprintf("sess-%d", i), makeTranscript(t)); err != nil {
+					t.Fatalf("extractMemories: %v", err)
+				}
+			}
+
+			events, _ := db.FindByCategory("events")
+			if len(events) != tc.want {
+				t.Errorf("events = %d, want %d", len(events), tc.want)
+			}
+		})
+	}
+}
+
 func TestExtractRelational(t *testing.T) {
 	db := testDB(t)
 
@@ -159,7 +197,7 @@ User trusts agent with code generation and architectural decisions.`
 
 	transcriptPath := makeTranscript(t)
 
-	err := extractRelational(db, mock, "test-session", transcriptPath)
+	err := extractRelational(db, mock, config.Default().Engine, "test-session", transcriptPath)
 	if err != nil {
 		t.Fatalf("extractRelational: %v", err)
 	}
@@ -198,7 +236,7 @@ func TestExtractRelationalDedup(t *testing.T) {
 
 	transcriptPath := makeTranscript(t)
 
-	err := extractRelational(db, mock, "test-session", transcriptPath)
+	err := extractRelational(db, mock, config.Default().Engine, "test-session", transcriptPath)
 	if err != nil {
 		t.Fatalf("extractRelational: %v", err)
 	}
@@ -209,6 +247,59 @@ func TestExtractRelationalDedup(t *testing.T) {
 	}
 }
 
+func TestExtractRelationalMinInterval(t *testing.T) {
+	transcriptPath := makeTranscript(t) // 4 user messages
+	cases := []struct {
+		name      string
+		interval  string
+		threshold int
+		age       time.Duration
+		wantCalls int
+	}{
+		{"rewritten recently", "6h", 20, time.Hour, 0},
+		{"interval elapsed", "6h", 20, 7 * time.Hour, 1},
+		{"substantial session", "6h", 4, time.Hour, 1},
+		{"guard off", "0", 20, time.Minute, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := testDB(t)
+			db.UpsertNode(&store.MemNode{
+				URI:           relationalURI,
+				NodeType:      "leaf",
+				Category:      "profile",
+				L1Overview:    "Existing profile content",
+				SourceSession: "earlier-session",
+			})
+			db.Exec(`UPDATE mem_nodes SET updated_at = ? WHERE uri = ?`, time.Now().Add(-tc.age).UnixMilli(), relationalURI)
+
+			cfg := config.Default().Engine
+			cfg.RelationalMinInterval = tc.interval
+			cfg.RelationalSubstantialMessages = tc.threshold
+			mock := &llm.MockClient{Response: &llm.Response{Content: "NO_UPDATE", Provider: "mock"}}
+			if err := extractRelational(db, mock, cfg, "new-session", transcriptPath); err != nil {
+				t.Fatalf("extractRelational: %v", err)
+			}
+			if len(mock.Calls) != tc.wantCalls {
+				t.Errorf("LLM calls = %d, want %d", len(mock.Calls), tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestParseRelationalMinInterval(t *testing.T) {
+	for in, want := range map[string]time.Duration{"": 6 * time.Hour, "0": 0, "90m": 90 * time.Minute} {
+		if got, err := ParseRelationalMinInterval(in); err != nil || got != want {
+			t.Errorf("ParseRelationalMinInterval(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"-1h", "soon"} {
+		if _, err := ParseRelationalMinInterval(in); err == nil {
+			t.Errorf("ParseRelationalMinInterval(%q) accepted", in)
+		}
+	}
+}
+
 func TestExtractRelationalNoUpdate(t *testing.T) {
 	db := testDB(t)
 
@@ -218,7 +309,7 @@ func TestExtractRelationalNoUpdate(t *testing.T) {
 
 	transcriptPath := makeTranscript(t)
 
-	err := extractRelational(db, mock, "test-session", transcriptPath)
+	err := extractRelational(db, mock, config.Default().Engine, "test-session", transcriptPath)
 	if err != nil {
 		t.Fatalf("extractRelational: %v", err)
 	}
@@ -230,12 +321,72 @@ func TestExtractRelationalNoUpdate(t *testing.T) {
 	}
 }
 
+func TestRebuildProfile(t *testing.T) {
+	db := testDB(t)
+
+	// A corrupt profile that the rebuild must not use as its starting point.
+	db.UpsertNode(&store.MemNode{
+		URI: relationalURI, NodeType: "leaf", Category: "profile",
+		L0Abstract: "Relational profile", L1Overview: "CORRUPTED PROFILE TEXT", SourceSession: "old",
+	})
+
+	for _, id := range []string{"sess-a", "sess-b"} {
+		db.InitSession(id, "proj")
+		db.SetTranscriptPath(id, makeTranscript(t))
+		db.CompleteSession(id)
+	}
+
+	rebuilt := "## 1. FEEDBACK CALIBRATION\nDirect and specific; expects the agent to push back when it disagrees."
+	mock := &llm.MockClient{Response: &llm.Response{Content: rebuilt, Provider: "mock"}}
+	eng := New(db, mock)
+
+	res, err := eng.RebuildProfile(10)
+	if err != nil {
+		t.Fatalf("RebuildProfile: %v", err)
+	}
+	if res.Sessions != 2 || res.Applied != 2 {
+		t.Errorf("result = %+v, want 2 sessions, 2 applied", res)
+	}
+	if len(mock.Calls) != 2 {
+		t.Fatalf("LLM calls = %d, want 2", len(mock.Calls))
+	}
+	if strings.Contains(mock.Calls[0], "CORRUPTED PROFILE TEXT") {
+		t.Error("rebuild should start from an empty profile, not the existing one")
+	}
+
+	node, _ := db.GetNodeByURI(relationalURI)
+	if node == nil || node.L1Overview != rebuilt {
+		t.Fatalf("profile not rebuilt: %+v", node)
+	}
+}
+
+func TestRebuildProfileKeepsProfileWhenNothingApplies(t *testing.T) {
+	db := testDB(t)
+	db.UpsertNode(&store.MemNode{
+		URI: relationalURI, NodeType: "leaf", Category: "profile",
+		L0Abstract: "Relational profile", L1Overview: "existing profile", SourceSession: "old",
+	})
+	db.InitSession("sess-a", "proj")
+	db.SetTranscriptPath("sess-a", makeTranscript(t))
+	db.CompleteSession("sess-a")
+
+	mock := &llm.MockClient{Response: &llm.Response{Content: "NO_UPDATE", Provider: "mock"}}
+	if _, err := New(db, mock).RebuildProfile(10); err != nil {
+		t.Fatalf("RebuildProfile: %v", err)
+	}
+	node, _ := db.GetNodeByURI(relationalURI)
+	if node == nil || node.L1Overview != "existing profile" {
+		t.Errorf("profile should be untouched when no session produced an update, got %+v", node)
+	}
+}
+
 func TestParseExtractionResponse(t *testing.T) {
 	tests := []struct {
-		name    string
-		input   string
-		want    int
-		wantErr bool
+		name     string
+		input    string
+		want     int
+		wantHint string // uri_hint of the first candidate, when set
+		wantErr  bool
 	}{
 		{
 			name:  "plain json",
@@ -262,6 +413,52 @@ func TestParseExtractionResponse(t *testing.T) {
 			input:   "No memories to extract.",
 			wantErr: true,
 		},
+		{
+			name:     "brackets in content and trailing prose",
+			input:    "[{\"category\":\"patterns\",\"uri_hint\":\"slices\",\"l0\":\"Index with s[i], not s[i:]\",\"l1\":\"[WIP] notes\",\"l2\":\"full\"}]\nSee [1] for details.",
+			want:     1,
+			wantHint: "slices",
+		},
+		{
+			name:     "bracket in leading prose",
+			input:    "Memories [extracted below]:\n[{\"category\":\"events\",\"uri_hint\":\"deploy\",\"l0\":\"deployed\",\"l1\":\"deployed v2\",\"l2\":\"full\"}]",
+			want:     1,
+			wantHint: "deploy",
+		},
+		{
+			name:     "unbalanced brackets in l2 code example",
+			input:    "[{\"category\":\"cases\",\"uri_hint\":\"regex\",\"l0\":\"Unclosed class\",\"l1\":\"The pattern lacked a ]\",\"l2\":\"```go\\nre := regexp.MustCompile(`[a-z`)\\nxs := [][]int{{1}\\n```\"}]\nDone]",
+			want:     1,
+			wantHint: "regex",
+		},
+		{
+			name:     "two arrays takes the first",
+			input:    "[{\"category\":\"events\",\"uri_hint\":\"first\",\"l0\":\"a\",\"l1\":\"a\",\"l2\":\"a\"}]\n\nCorrected:\n[{\"category\":\"events\",\"uri_hint\":\"second\",\"l0\":\"b\",\"l1\":\"b\",\"l2\":\"b\"}, {\"category\":\"events\",\"uri_hint\":\"third\",\"l0\":\"c\",\"l1\":\"c\",\"l2\":\"c\"}]",
+			want:     1,
+			wantHint: "first",
+		},
+		{
+			name:     "trailing commas",
+			input:    "[{\"category\":\"profile\",\"uri_hint\":\"commas\",\"l0\":\"a, b,\",\"l1\":\"x\",\"l2\":\"y\",},\n]",
+			want:     1,
+			wantHint: "commas",
+		},
+		{
+			name:     "unpaired quote in trailing prose",
+			input:    "[{\"category\":\"profile\",\"uri_hint\":\"quote\",\"l0\":\"a\",\"l1\":\"b\",\"l2\":\"c\"}]\nNote: \"see [this]",
+			want:     1,
+			wantHint: "quote",
+		},
+		{
+			name:    "malformed array with empty brackets in a string",
+			input:   `[{"category":"patterns","name":"x","l0":"use []string here","l1":"he said "hi" then left"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "truncated array",
+			input:   "[{\"category\":\"profile\",\"uri_hint\":\"cut\",\"l0\":\"a [b]\",\"l1\":\"",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -274,6 +471,9 @@ func TestParseExtractionResponse(t *testing.T) {
 			if !tt.wantErr && len(got) != tt.want {
 				t.Errorf("parseExtractionResponse() got %d candidates, want %d", len(got), tt.want)
 			}
+			if tt.wantHint != "" && len(got) > 0 && got[0].URIHint != tt.wantHint {
+				t.Errorf("parseExtractionResponse() first candidate = %q, want %q", got[0].URIHint, tt.wantHint)
+			}
 		})
 	}
 }
@@ -340,6 +540,213 @@ Trusts agent with code generation.`
 	}
 }
 
+func TestExtractSessionResult(t *testing.T) {
+	db := testDB(t)
+	mock := &multiResponseMock{
+		responses: []*llm.Response{
+			{Content: `[{"category":"preferences","uri_hint":"go-style","l0":"Uses Go with minimal deps","l1":"Prefers Go with minimal dependencies and clean architecture","l2":"Full"}]`, Provider: "mock"},
+			{Content: "NO_UPDATE", Provider: "mock"},
+		},
+	}
+	eng := New(db, mock)
+	db.InitSession("result-test", "/tmp/proj")
+
+	res, err := eng.ExtractSessionResult("result-test", makeTranscript(t), false)
+	if err != nil {
+		t.Fatalf("ExtractSessionResult: %v", err)
+	}
+	if len(res.Stored) != 1 || res.Stored[0] != "mem://user/preferences/go-style" || res.Skipped != "" {
+		t.Errorf("result = %+v, want the one stored preference", res)
+	}
+
+	res, err = eng.ExtractSessionResult("result-test", makeTranscript(t), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Stored) != 0 || res.Skipped != "already extracted" {
+		t.Errorf("second pass = %+v, want skipped as already extracted", res)
+	}
+}
+
+func TestExtractMemoriesRepairsMalformedJSON(t *testing.T) {
+	db := testDB(t)
+	mock := &multiResponseMock{
+		responses: []*llm.Response{
+			{Content: `Here are the memories: {"category":"preferences", "uri_hint":"go-style" ...`, Provider: "mock"},
+			{Content: `[{"category":"preferences","uri_hint":"go-style","l0":"Uses Go with minimal deps","l1":"Prefers Go with minimal dependencies and clean architecture","l2":"Full"}]`, Provider: "mock"},
+		},
+	}
+	stored, _, err := extractMemories(db, mock, nil, nil, config.Default().Engine, "repair-test", makeTranscript(t))
+	if err != nil {
+		t.Fatalf("extractMemories: %v", err)
+	}
+	if len(stored) != 1 || mock.callIdx != 2 {
+		t.Errorf("stored %v after %d calls, want the repaired candidate after 2", stored, mock.callIdx)
+	}
+
+	// A repair that is still malformed fails the extraction without a third call.
+	mock = &multiResponseMock{
+		responses: []*llm.Response{
+			{Content: "I could not find anything worth remembering here.", Provider: "mock"},
+			{Content: "Sorry, still no JSON from me.", Provider: "mock"},
+			{Content: "[]", Provider: "mock"},
+		},
+	}
+	if _, _, err := extractMemories(db, mock, nil, nil, config.Default().Engine, "repair-test", makeTranscript(t)); err == nil || !strings.Contains(err.Error(), "after repair") {
+		t.Errorf("err = %v, want a failure after the one repair", err)
+	}
+	if mock.callIdx != 2 {
+		t.Errorf("made %d calls, want 2 (one repair attempt)", mock.callIdx)
+	}
+}
+
+func TestExtractMemoriesSessionNotes(t *testing.T) {
+	db := testDB(t)
+	if _, err := db.InitSession("notes-test", "/tmp/proj"); err != nil {
+		t.Fatal(err)
+	}
+	resp := &llm.Response{Content: `[{"category":"session","uri_hint":"staging-freeze","l0":"Don't touch the staging database today","l1":"The staging database is frozen for the load test until the session ends.","l2":""}]`, Provider: "mock"}
+	cfg := config.Default().Engine
+
+	// Off by default: the note is dropped.
+	stored, _, err := extractMemories(db, &llm.MockClient{Response: resp}, nil, nil, cfg, "notes-test", makeTranscript(t))
+	if err != nil || len(stored) != 0 {
+		t.Fatalf("session notes off: stored %v, err %v", stored, err)
+	}
+
+	cfg.SessionNotes = true
+	stored, _, err = extractMemories(db, &llm.MockClient{Response: resp}, nil, nil, cfg, "notes-test", makeTranscript(t))
+	if err != nil {
+		t.Fatalf("extractMemories: %v", err)
+	}
+	if len(stored) != 1 || stored[0] != "mem://user/session/notes-test/staging-freeze" {
+		t.Fatalf("stored %v, want the note under its session", stored)
+	}
+	if notes, _ := db.SessionNotes("notes-test"); len(notes) != 1 {
+		t.Errorf("SessionNotes = %d notes, want 1", len(notes))
+	}
+
+	// Once the session has ended, a late extraction writes no notes.
+	if _, err := db.DeleteSessionNotes("notes-test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.EndSession("notes-test"); err != nil {
+		t.Fatal(err)
+	}
+	stored, _, err = extractMemories(db, &llm.MockClient{Response: resp}, nil, nil, cfg, "notes-test", makeTranscript(t))
+	if err != nil || len(stored) != 0 {
+		t.Errorf("ended session: stored %v, err %v", stored, err)
+	}
+}
+
+func TestExtractMemoriesAssistantInsights(t *testing.T) {
+	db := testDB(t)
+	resp := &llm.Response{Content: `[{"category":"cases","uri_hint":"wal-checkpoint-stall","origin":"assistant","l0":"WAL grew unbounded because a long-lived reader blocked every checkpoint","l1":"The -wal file kept growing because an idle read transaction pinned it; closing the reader let wal_checkpoint(TRUNCATE) finish.","l2":"Found by tracing open transactions."}]`, Provider: "mock"}
+	cfg := config.Default().Engine
+
+	// Off by default: no rule in the prompt and no tag on the memory.
+	mock := &llm.MockClient{Response: resp}
+	if _, _, err := extractMemories(db, mock, nil, nil, cfg, "insights-off", makeTranscript(t)); err != nil {
+		t.Fatalf("extractMemories: %v", err)
+	}
+	if strings.Contains(mock.Calls[0], "Assistant insights") {
+		t.Error("prompt has the assistant insights rule while the option is off")
+	}
+	if n, _ := db.GetNodeByURI("mem://agent/cases/wal-checkpoint-stall"); n == nil || n.Origin != "" {
+		t.Fatalf("option off: node = %+v, want it stored untagged", n)
+	}
+	if _, err := db.Exec(`DELETE FROM mem_nodes WHERE uri = 'mem://agent/cases/wal-checkpoint-stall'`); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.AssistantInsights = true
+	mock = &llm.MockClient{Response: resp}
+	if _, _, err := extractMemories(db, mock, nil, nil, cfg, "insights-on", makeTranscript(t)); err != nil {
+		t.Fatalf("extractMemories: %v", err)
+	}
+	if !strings.Contains(mock.Calls[0], llm.AssistantInsightsRule) {
+		t.Error("prompt is missing the assistant insights rule")
+	}
+	n, _ := db.GetNodeByURI("mem://agent/cases/wal-checkpoint-stall")
+	if n == nil || n.Origin != store.OriginAssistant || n.L2Content != "Found by tracing open transactions." {
+		t.Errorf("option on: node = %+v, want the assistant origin and L2 as written", n)
+	}
+
+	// The origin doesn't live in L2, so dropping L2 doesn't lose it.
+	if _, err := db.Exec(`DELETE FROM mem_nodes WHERE uri = 'mem://agent/cases/wal-checkpoint-stall'`); err != nil {
+		t.Fatal(err)
+	}
+	db.DropL2 = true
+	mock = &llm.MockClient{Response: resp}
+	if _, _, err := extractMemories(db, mock, nil, nil, cfg, "insights-drop-l2", makeTranscript(t)); err != nil {
+		t.Fatalf("extractMemories: %v", err)
+	}
+	n, _ = db.GetNodeByURI("mem://agent/cases/wal-checkpoint-stall")
+	if n == nil || n.Origin != store.OriginAssistant || n.L2Content != "" {
+		t.Errorf("DropL2: node = %+v, want the assistant origin with no L2", n)
+	}
+}
+
+func TestExtractMemoriesLinksRelated(t *testing.T) {
+	db := testDB(t)
+	resp := &llm.Response{Content: `[
+		{"category":"cases","uri_hint":"sqlite-busy","l0":"SQLITE_BUSY under parallel writers fixed by a per-connection busy_timeout","l1":"Parallel writers hit SQLITE_BUSY because busy_timeout was set on one pooled connection only; setting it per connection fixed it.","l2":"","related_to":["mem://agent/patterns/per-conn-pragmas","mem://user/events/nowhere"]},
+		{"category":"patterns","uri_hint":"per-conn-pragmas","l0":"Apply SQLite pragmas per connection via the DSN, not one Exec","l1":"database/sql pools connections, so a PRAGMA run with db.Exec lands on one of them; put it in the DSN so every connection gets it.","l2":""}
+	]`, Provider: "mock"}
+
+	stored, _, err := extractMemories(db, &llm.MockClient{Response: resp}, nil, nil, config.Default().Engine, "links-test", makeTranscript(t))
+	if err != nil {
+		t.Fatalf("extractMemories: %v", err)
+	}
+	if len(stored) != 2 {
+		t.Fatalf("stored %v, want both candidates", stored)
+	}
+
+	links, err := db.GetLinks("mem://agent/cases/sqlite-busy")
+	if err != nil {
+		t.Fatalf("GetLinks: %v", err)
+	}
+	if len(links) != 1 {
+		t.Fatalf("links = %+v, want only the one to the stored pattern", links)
+	}
+	l := links[0]
+	if l.FromURI != "mem://agent/cases/sqlite-busy" || l.ToURI != "mem://agent/patterns/per-conn-pragmas" || l.Relation != store.RelatedTo {
+		t.Errorf("link = %+v", l)
+	}
+}
+
+func TestExtractMemoriesRecordsSessionMemories(t *testing.T) {
+	db := testDB(t)
+	db.CreateNode(&store.MemNode{
+		URI: "mem://user/preferences/editor", NodeType: "leaf", Category: "preferences",
+		L0Abstract: "Uses vim", L1Overview: "Uses vim for editing everything, every day.",
+	})
+	resp := &llm.Response{Content: `[
+		{"category":"preferences","uri_hint":"editor","l0":"Uses Helix as the primary editor","l1":"Switched from vim to Helix for editing; keeps the config minimal.","l2":""},
+		{"category":"events","uri_hint":"helix-switch","l0":"Switched the team's editor setup to Helix in October","l1":"Moved the shared editor config from vim to Helix during the October cleanup.","l2":""}
+	]`, Provider: "mock"}
+
+	if _, _, err := extractMemories(db, &llm.MockClient{Response: resp}, nil, nil, config.Default().Engine, "prov-test", makeTranscript(t)); err != nil {
+		t.Fatalf("extractMemories: %v", err)
+	}
+	got, err := db.SessionMemories("prov-test")
+	if err != nil {
+		t.Fatalf("SessionMemories: %v", err)
+	}
+	want := map[string]bool{
+		"mem://user/preferences/editor":  false, // merged into the existing memory
+		"mem://user/events/helix-switch": true,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("session memories = %+v, want %d", got, len(want))
+	}
+	for _, sm := range got {
+		if created, ok := want[sm.URI]; !ok || sm.Created != created {
+			t.Errorf("%s created=%v, want %v (known: %v)", sm.URI, sm.Created, created, ok)
+		}
+	}
+}
+
 func TestExtractSignal(t *testing.T) {
 	db := testDB(t)
 
@@ -386,6 +793,37 @@ func TestExtractSignal(t *testing.T) {
 	}
 }
 
+func TestExtractSignalAsForcesCategory(t *testing.T) {
+	db := testDB(t)
+	mock := &llm.MockClient{
+		Response: &llm.Response{Content: `[{
+			"category": "events",
+			"uri_hint": "retry-backoff",
+			"l0": "Wrap flaky network calls in retry with exponential backoff",
+			"l1": "Network calls to the registry are retried with exponential backoff and jitter."
+		}]`, Provider: "mock"},
+	}
+	eng := New(db, mock)
+
+	if err := eng.ExtractSignalAs(context.Background(), "s1", "remember this pattern: retry with backoff", "patterns"); err != nil {
+		t.Fatalf("ExtractSignalAs: %v", err)
+	}
+	if !strings.Contains(mock.Calls[0], `category MUST be "patterns"`) {
+		t.Error("prompt should tell the model the forced category")
+	}
+	node, err := db.GetNodeByURI("mem://agent/patterns/retry-backoff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node == nil || node.Category != "patterns" {
+		t.Fatalf("node = %+v, want it filed under patterns despite the model's answer", node)
+	}
+
+	if err := eng.ExtractSignalAs(context.Background(), "s1", "remember this", "moments"); err == nil {
+		t.Error("moments should not be forceable on a signal")
+	}
+}
+
 func TestExtractSignalNoLLM(t *testing.T) {
 	db := testDB(t)
 	eng := New(db, nil)
@@ -396,6 +834,59 @@ func TestExtractSignalNoLLM(t *testing.T) {
 	}
 }
 
+func TestExtractSignalRuleBased(t *testing.T) {
+	db := testDB(t)
+	eng := New(db, nil)
+	cfg := config.Default().Engine
+	cfg.RuleBasedSignals = true
+	eng.SetConfig(cfg)
+
+	if err := eng.ExtractSignal(context.Background(), "s1", "remember this: always use WAL mode for SQLite. It avoids lock errors."); err != nil {
+		t.Fatalf("ExtractSignal: %v", err)
+	}
+	node, err := db.GetNodeByURI("mem://user/preferences/always-use-wal-mode-for-sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node == nil {
+		t.Fatal("expected a verbatim preferences node")
+	}
+	if node.L0Abstract != "always use WAL mode for SQLite." {
+		t.Errorf("L0 = %q, want the text after the trigger", node.L0Abstract)
+	}
+
+	if err := eng.ExtractSignal(context.Background(), "s1", "nothing to see here"); err == nil {
+		t.Error("a prompt without a trigger should not be captured")
+	}
+}
+
+func TestRuleBasedSignal(t *testing.T) {
+	tests := []struct {
+		prompt, category string
+		wantCat, wantL0  string
+	}{
+		{"remember this: use tabs", "", "preferences", "use tabs"},
+		{"Don't forget that staging deploys need VPN", "", "preferences", "staging deploys need VPN"},
+		{"please never use CGO here", "", "preferences", "never use CGO here"},
+		{"the root cause was a stale cache key", "", "cases", "root cause was a stale cache key"},
+		{"remember this pattern: retry with jitter", "patterns", "patterns", "retry with jitter"},
+		{"remember this as a constraint no force pushes", "constraints", "constraints", "no force pushes"},
+	}
+	for _, tt := range tests {
+		c, ok := ruleBasedSignal(tt.prompt, tt.category, "preferences")
+		if !ok {
+			t.Errorf("ruleBasedSignal(%q) found nothing", tt.prompt)
+			continue
+		}
+		if c.Category != tt.wantCat || c.L0 != tt.wantL0 {
+			t.Errorf("ruleBasedSignal(%q) = %s %q, want %s %q", tt.prompt, c.Category, c.L0, tt.wantCat, tt.wantL0)
+		}
+	}
+	if _, ok := ruleBasedSignal("remember this:", "", "preferences"); ok {
+		t.Error("a bare trigger should capture nothing")
+	}
+}
+
 func TestRemember(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -757,6 +1248,50 @@ func TestExtractSessionGateSkipsWithoutMarking(t *testing.T) {
 	if sess.ExtractedAt != nil {
 		t.Errorf("expected extracted_at=nil after gate skip, got %v", *sess.ExtractedAt)
 	}
+	// The skip must be explainable, not silent.
+	if sess.SkipReason == nil || !strings.Contains(*sess.SkipReason, "too few user messages") {
+		t.Errorf("expected skip_reason mentioning too few user messages, got %v", sess.SkipReason)
+	}
+}
+
+// TestExtractSessionGateThresholdsConfigurable confirms the content gate reads
+// its thresholds from the engine config rather than hardcoded values, and that
+// a successful extraction clears a skip_reason left by an earlier attempt.
+func TestExtractSessionGateThresholdsConfigurable(t *testing.T) {
+	db := testDB(t)
+	mock := &multiResponseMock{
+		responses: []*llm.Response{
+			{Content: "[]", Provider: "mock"},
+			{Content: "NO_UPDATE", Provider: "mock"},
+			{Content: "focused", Provider: "mock"},
+		},
+	}
+
+	path := writeTranscript(t, []map[string]any{
+		{"type": "user", "message": map[string]any{"role": "user", "content": "Set up the release pipeline for the CLI tool with goreleaser"}},
+		{"type": "assistant", "message": map[string]any{"role": "assistant", "content": "I'll add a .goreleaser.yaml with darwin and linux targets."}},
+		{"type": "user", "message": map[string]any{"role": "user", "content": "Also sign the checksums file with cosign please"}},
+	})
+	if _, err := db.InitSession("cfg-gate", "test"); err != nil {
+		t.Fatalf("InitSession: %v", err)
+	}
+	db.SetSkipReason("cfg-gate", "too few user messages (1 < 3)")
+
+	eng := New(db, mock)
+	cfg := config.Default().Engine
+	cfg.MinUserMessages = 2
+	eng.SetConfig(cfg)
+	if err := eng.ExtractSession("cfg-gate", path); err != nil {
+		t.Fatalf("ExtractSession: %v", err)
+	}
+
+	sess, _ := db.GetSession("cfg-gate")
+	if sess.ExtractedAt == nil {
+		t.Error("expected extraction to run with MinUserMessages=2")
+	}
+	if sess.SkipReason != nil {
+		t.Errorf("expected skip_reason cleared after extraction, got %q", *sess.SkipReason)
+	}
 }
 
 // TestExtractSessionMarksWhenGatePasses confirms the happy path still marks
@@ -938,3 +1473,240 @@ func TestRememberReferenceImmutable(t *testing.T) {
 		t.Errorf("original L0 was overwritten: %q", original.L0Abstract)
 	}
 }
+
+func TestRememberMergeableOverride(t *testing.T) {
+	db := testDB(t)
+	eng := New(db, nil)
+	ctx := context.Background()
+	yes := true
+
+	in := RememberInput{
+		Category:  "events",
+		Name:      "postgres-migration",
+		Summary:   "Postgres migration is in progress.",
+		Body:      "Moving the job queue from Redis to Postgres; the schema is done.",
+		Mergeable: &yes,
+	}
+	uri1, _, err := eng.Remember(ctx, in)
+	if err != nil {
+		t.Fatalf("first Remember: %v", err)
+	}
+	in.Summary = "Postgres migration is finished."
+	in.Body = "The job queue now runs on Postgres; Redis has been decommissioned."
+	uri2, created, err := eng.Remember(ctx, in)
+	if err != nil {
+		t.Fatalf("second Remember: %v", err)
+	}
+	if uri2 != uri1 || created {
+		t.Errorf("mergeable event should update in place: %q → %q (created=%v)", uri1, uri2, created)
+	}
+	if n, _ := db.GetNodeByURI(uri1); n == nil || !n.Mergeable || !strings.Contains(n.L0Abstract, "finished") {
+		t.Errorf("node = %+v", n)
+	}
+}
+
+func TestDecayIfDue(t *testing.T) {
+	db := testDB(t)
+	eng := New(db, nil)
+	now := time.Now()
+
+	if !eng.decayIfDue(now, time.Hour) {
+		t.Fatal("first run should be due (no last_decay_at)")
+	}
+	if eng.decayIfDue(now.Add(30*time.Minute), time.Hour) {
+		t.Error("run within the interval should not be due")
+	}
+	// A week asleep: the next check after wake is due, once.
+	wake := now.Add(7 * 24 * time.Hour)
+	if !eng.decayIfDue(wake, time.Hour) {
+		t.Error("run after a week should be due")
+	}
+	if eng.decayIfDue(wake.Add(time.Minute), time.Hour) {
+		t.Error("catch-up run should record itself")
+	}
+}
+
+func TestParseDecayInterval(t *testing.T) {
+	for in, want := range map[string]time.Duration{"": 24 * time.Hour, "168h": 168 * time.Hour, "30m": 30 * time.Minute} {
+		if got, err := ParseDecayInterval(in); err != nil || got != want {
+			t.Errorf("ParseDecayInterval(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"weekly", "0s", "-1h"} {
+		if _, err := ParseDecayInterval(in); err == nil {
+			t.Errorf("ParseDecayInterval(%q): want error", in)
+		}
+	}
+}
+
+func TestDecayPrunesObservations(t *testing.T) {
+	db := testDB(t)
+	db.InitSession("s1", "proj")
+	db.AddObservation("s1", "", "Read", "{}", "ok")
+	db.MarkExtracted("s1")
+	eng := New(db, nil)
+	now := time.Now()
+
+	eng.decayIfDue(now, time.Hour)
+	if c, _ := db.GetSessionObservationCount("s1"); c != 1 {
+		t.Fatalf("retention 0 should keep observations, have %d", c)
+	}
+
+	cfg := config.Default().Engine
+	cfg.ObservationRetentionDays = 7
+	eng.SetConfig(cfg)
+	eng.decayIfDue(now.Add(8*24*time.Hour), time.Hour)
+	if c, _ := db.GetSessionObservationCount("s1"); c != 0 {
+		t.Errorf("observation past retention survived decay run (%d left)", c)
+	}
+}
+
+func TestDecayPrunesStaleSessionNotes(t *testing.T) {
+	db := testDB(t)
+	now := time.Now()
+	for _, id := range []string{"crashed", "live"} {
+		db.InitSession(id, "proj")
+		if err := db.CreateNode(&store.MemNode{
+			URI: "mem://user/session/" + id + "/note", NodeType: "leaf", Category: "session",
+			L0Abstract: "Note for " + id, SourceSession: id,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.CompleteSession("crashed")
+	eng := New(db, nil)
+
+	// Within the grace period a stopped session may still resume.
+	eng.decayIfDue(now, time.Hour)
+	if n, _ := db.GetNodeByURI("mem://user/session/crashed/note"); n == nil {
+		t.Fatal("a just-stopped session's note was pruned")
+	}
+	eng.decayIfDue(now.Add(2*sessionNoteGrace), time.Hour)
+	if n, _ := db.GetNodeByURI("mem://user/session/crashed/note"); n != nil {
+		t.Error("a note of a session stopped past the grace period survived decay")
+	}
+	if n, _ := db.GetNodeByURI("mem://user/session/live/note"); n == nil {
+		t.Error("an active session's note was pruned")
+	}
+}
+
+func TestFindHidesOtherSessionsNotes(t *testing.T) {
+	db := testDB(t)
+	embedder, err := NewHashEmbedder(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := &store.MemNode{
+		URI: "mem://user/session/s1/freeze", NodeType: "leaf", Category: "session",
+		L0Abstract: "Do not touch the staging database today", SourceSession: "s1",
+	}
+	if err := db.CreateNode(node); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := db.GetNodeByURI(node.URI)
+	vec, _ := embedder.Embed(context.Background(), node.L0Abstract)
+	db.SaveVectorFor(stored.ID, vec, embedder.Model(), node.L0Abstract)
+
+	for session, want := range map[string]int{"s1": 1, "s2": 0, "": 0} {
+		res, err := Find(context.Background(), db, embedder, "staging database", SearchOpts{SessionID: session})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != want {
+			t.Errorf("Find for session %q: %d results, want %d", session, len(res), want)
+		}
+	}
+}
+
+func TestStoreNodeDedupsLikeExtraction(t *testing.T) {
+	db := testDB(t)
+	eng := New(db, nil)
+	embedder, err := NewHashEmbedder(0)
+	if err != nil {
+		t.Fatalf("NewHashEmbedder: %v", err)
+	}
+	eng.SetEmbedder(embedder)
+	ctx := context.Background()
+
+	first, err := eng.StoreNode(ctx, NodeInput{
+		Category: "preferences",
+		URIHint:  "Editor",
+		L0:       "Prefers Neovim with the lazy plugin manager",
+		L1:       "Uses Neovim for all editing, plugins managed by lazy.nvim.",
+	})
+	if err != nil {
+		t.Fatalf("first StoreNode: %v", err)
+	}
+	if !first.Created || first.URI != "mem://user/preferences/editor" {
+		t.Fatalf("first = %+v, want created at the sanitized hint", first)
+	}
+
+	// Same fact under another slug lands on the existing memory.
+	again, err := eng.StoreNode(ctx, NodeInput{
+		Category: "preferences",
+		URIHint:  "vim-setup",
+		L0:       "Prefers Neovim with the lazy plugin manager",
+		L1:       "Neovim, with lazy.nvim managing plugins; config lives in dotfiles.",
+	})
+	if err != nil {
+		t.Fatalf("second StoreNode: %v", err)
+	}
+	if again.Created || again.URI != first.URI {
+		t.Errorf("second = %+v, want an update of %s", again, first.URI)
+	}
+
+	// An immutable restatement is reported, not stored.
+	deploy := NodeInput{
+		Category: "events",
+		URIHint:  "deploy-v2",
+		L0:       "Deployed v2.0.0 of the ingest service to production",
+		L1:       "Release v2.0.0 of ingest went to production from the main branch.",
+	}
+	if _, err := eng.StoreNode(ctx, deploy); err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+	deploy.URIHint = "deploy-v2-again"
+	dup, err := eng.StoreNode(ctx, deploy)
+	if err != nil {
+		t.Fatalf("deploy again: %v", err)
+	}
+	if !dup.Duplicate || dup.URI != "mem://user/events/deploy-v2" {
+		t.Errorf("deploy again = %+v, want a duplicate of deploy-v2", dup)
+	}
+	if n, _ := db.GetNodeByURI("mem://user/events/deploy-v2-again"); n != nil {
+		t.Error("duplicate was stored")
+	}
+
+	// An explicit merge target is updated in place.
+	merged, err := eng.StoreNode(ctx, NodeInput{
+		MergeTarget: first.URI,
+		L0:          "Prefers Helix over Neovim since 2026",
+		L1:          "Switched from Neovim to Helix; keeps a minimal config.",
+	})
+	if err != nil {
+		t.Fatalf("merge target: %v", err)
+	}
+	if merged.URI != first.URI || merged.Created {
+		t.Errorf("merge target = %+v, want an update of %s", merged, first.URI)
+	}
+	n, _ := db.GetNodeByURI(first.URI)
+	if n == nil || !strings.Contains(n.L1Overview, "Helix") {
+		t.Fatalf("merge target content not written: %+v", n)
+	}
+	if v, _ := db.GetVector(n.ID); v == nil {
+		t.Error("stored memory has no vector")
+	}
+
+	for name, in := range map[string]NodeInput{
+		"missing target":  {MergeTarget: "mem://user/preferences/nope", L0: "x", L1: "long enough overview text"},
+		"target category": {MergeTarget: first.URI, Category: "events", L0: "x", L1: "long enough overview text"},
+		"session note":    {Category: "session", URIHint: "note", L0: "x", L1: "long enough overview text"},
+		"short l1":        {Category: "events", URIHint: "short", L0: "x", L1: "tiny"},
+	} {
+		if _, err := eng.StoreNode(ctx, in); err == nil {
+			t.Errorf("%s: stored, want a validation error", name)
+		} else if ok, _ := IsValidationError(err); !ok {
+			t.Errorf("%s: err = %v, want a ValidationError", name, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"log"
+	"strings"
+
+	"github.com/lazypower/continuity/internal/store"
+)
+
+// maxEntityAliases caps how many aliases are kept per entity; past a handful
+// the model is brainstorming, not recalling.
+const maxEntityAliases = 8
+
+// entityFields is the structured half of an entities-category candidate, as
+// the extraction LLM emits it under "entity".
+type entityFields struct {
+	Type     string   `json:"type"`
+	Name     string   `json:"name"`
+	Location string   `json:"location"`
+	Aliases  []string `json:"aliases"`
+}
+
+// structuredEntity returns the cleaned structured fields of c, or ok=false
+// when c is not an entity or its fields are unusable (no type or name). The
+// type is lowercased so --type filters match regardless of how the model
+// capitalized it; aliases are trimmed, de-duplicated, and never repeat the name.
+func structuredEntity(c memoryCandidate) (store.Entity, bool) {
+	if c.Category != "entities" || c.Entity == nil {
+		return store.Entity{}, false
+	}
+	e := store.Entity{
+		Type:     strings.ToLower(strings.TrimSpace(c.Entity.Type)),
+		Name:     strings.TrimSpace(c.Entity.Name),
+		Location: strings.TrimSpace(c.Entity.Location),
+	}
+	if e.Type == "" || e.Name == "" {
+		return store.Entity{}, false
+	}
+	seen := map[string]bool{strings.ToLower(e.Name): true}
+	for _, a := range c.Entity.Aliases {
+		a = strings.TrimSpace(a)
+		if a == "" || seen[strings.ToLower(a)] {
+			continue
+		}
+		seen[strings.ToLower(a)] = true
+		e.Aliases = append(e.Aliases, a)
+		if len(e.Aliases) == maxEntityAliases {
+			break
+		}
+	}
+	return e, true
+}
+
+// saveEntity stores c's structured entity fields against nodeID, if it has
+// any. Failure is logged, not returned: the memory itself is already written
+// and its L1 carries the same facts in free text.
+func saveEntity(db *store.DB, nodeID int64, c memoryCandidate, logPrefix string) {
+	e, ok := structuredEntity(c)
+	if !ok {
+		return
+	}
+	if err := db.SaveEntity(nodeID, e); err != nil {
+		log.Printf("%s: save entity fields for node %d: %v", logPrefix, nodeID, err)
+	}
+}
@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/lazypower/continuity/internal/config"
+	"github.com/lazypower/continuity/internal/llm"
+)
+
+func TestExtractMemoriesStoresEntityFields(t *testing.T) {
+	db := testDB(t)
+
+	extractionResponse := `[
+		{
+			"category": "entities",
+			"uri_hint": "billing-api",
+			"l0": "billing-api: payments service behind the checkout flow",
+			"l1": "billing-api handles card capture and refunds; lives in services/billing.",
+			"l2": "Full",
+			"entity": {"type": " Service ", "name": "billing-api", "location": "services/billing", "aliases": ["billing", "Billing-API", " ", "billing"]}
+		},
+		{
+			"category": "entities",
+			"uri_hint": "half-structured",
+			"l0": "Half-structured entity with no usable type field",
+			"l1": "The model emitted an entity object but left the type blank.",
+			"l2": "Full",
+			"entity": {"type": "", "name": "ghost"}
+		},
+		{
+			"category": "preferences",
+			"uri_hint": "stray-entity-field",
+			"l0": "Preference that carries a stray entity object",
+			"l1": "Entity fields on non-entity categories are ignored.",
+			"l2": "Full",
+			"entity": {"type": "tool", "name": "devbox"}
+		}
+	]`
+	mock := &llm.MockClient{
+		Response: &llm.Response{Content: extractionResponse, Provider: "mock"},
+	}
+
+	stored, _, err := extractMemories(db, mock, nil, nil, config.Default().Engine, "test-session", makeTranscript(t))
+	if err != nil {
+		t.Fatalf("extractMemories: %v", err)
+	}
+	if len(stored) != 3 {
+		t.Fatalf("stored %d memories, want 3 (entity fields never gate the memory)", len(stored))
+	}
+
+	all, err := db.ListEntities("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("got %d entity rows, want 1: %+v", len(all), all)
+	}
+	e := all[0]
+	if e.Type != "service" || e.Name != "billing-api" || e.Location != "services/billing" {
+		t.Errorf("entity = %+v", e)
+	}
+	if len(e.Aliases) != 1 || e.Aliases[0] != "billing" {
+		t.Errorf("aliases = %q, want [billing] (trimmed, deduped, name dropped)", e.Aliases)
+	}
+	if e.URI != "mem://user/entities/billing-api" {
+		t.Errorf("URI = %q", e.URI)
+	}
+}
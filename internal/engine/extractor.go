@@ -8,15 +8,48 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lazypower/continuity/internal/config"
 	"github.com/lazypower/continuity/internal/llm"
 	"github.com/lazypower/continuity/internal/store"
-	"github.com/lazypower/continuity/internal/transcript"
 )
 
 // defaultSimilarityThreshold is the cosine similarity threshold for deduplication.
 // Candidates with similarity above this merge into existing nodes.
 const defaultSimilarityThreshold = 0.65
 
+// maxExtractionCandidates is how many memories one session extraction keeps.
+// The built-in prompt states the same budget; custom prompt templates see it
+// as {{.MaxCandidates}}.
+const maxExtractionCandidates = 3
+
+// extractionPrompt builds the session extraction prompt: the configured
+// template when ExtractionPromptPath is set, else the built-in. The template
+// is re-read on every extraction so edits apply without a restart; serve
+// validates it once at startup so a broken file fails loudly there first.
+// With session notes enabled, SessionNotesRule follows either; likewise
+// AssistantInsightsRule with assistant insights.
+func extractionPrompt(cfg config.EngineConfig, condensed string) (string, error) {
+	var prompt string
+	if cfg.ExtractionPromptPath == "" {
+		prompt = llm.ExtractionPrompt(condensed, cfg.Language)
+	} else {
+		tmpl, err := llm.LoadExtractionTemplate(cfg.ExtractionPromptPath)
+		if err != nil {
+			return "", err
+		}
+		if prompt, err = tmpl.Render(condensed, cfg.Language, maxExtractionCandidates); err != nil {
+			return "", err
+		}
+	}
+	if cfg.SessionNotes {
+		prompt += llm.SessionNotesRule
+	}
+	if cfg.AssistantInsights {
+		prompt += llm.AssistantInsightsRule
+	}
+	return prompt, nil
+}
+
 // memoryCandidate is the JSON structure returned by the extraction LLM.
 //
 // Note: there is intentionally no merge_target field. An LLM-chosen merge URI is
@@ -29,12 +62,63 @@ type memoryCandidate struct {
 	L0       string `json:"l0"`
 	L1       string `json:"l1"`
 	L2       string `json:"l2"`
+
+	// Entity is the optional structured form of an entities-category
+	// candidate. Ignored for every other category.
+	Entity *entityFields `json:"entity,omitempty"`
+
+	// Mergeable overrides the category default: true for an event that is
+	// really an evolving record, false for a preference to keep as a
+	// point-in-time version. Nil keeps the default.
+	Mergeable *bool `json:"mergeable,omitempty"`
+
+	// RelatedTo names other memories, by URI, this one relates to —
+	// usually other candidates of the same reply. Linked as RelatedTo once
+	// the whole batch is stored; URIs that don't resolve are dropped.
+	RelatedTo []string `json:"related_to,omitempty"`
+
+	// Origin is "assistant" for a solution the assistant found without the
+	// user flagging it (engine.assistant_insights). See tagAssistantInsight.
+	Origin string `json:"origin,omitempty"`
+}
+
+// tagAssistantInsight keeps c's origin only when assistant insights are on
+// and the model said so, normalized to store.OriginAssistant; otherwise it
+// clears it. The origin is stored in its own column rather than the text, so
+// it survives a dropped L2 and isn't repeated by merges.
+func tagAssistantInsight(c memoryCandidate, cfg config.EngineConfig) memoryCandidate {
+	if cfg.AssistantInsights && strings.EqualFold(strings.TrimSpace(c.Origin), store.OriginAssistant) {
+		c.Origin = store.OriginAssistant
+	} else {
+		c.Origin = ""
+	}
+	return c
+}
+
+// mergeable reports whether the candidate merges in place once stored.
+func (c memoryCandidate) mergeable() bool {
+	if c.Mergeable != nil {
+		return *c.Mergeable
+	}
+	return store.IsMergeable(c.Category)
+}
+
+// candidateURI returns the URI candidate c is written to:
+// mem://{owner}/{category}/{slug}, except that a session note goes under its
+// session (mem://user/session/{session}/{slug}) so notes from different
+// sessions never collide or merge.
+func candidateURI(c memoryCandidate, sessionID string) string {
+	if c.Category == "session" {
+		return fmt.Sprintf("mem://user/session/%s/%s", sanitizeURIHint(sessionID), c.URIHint)
+	}
+	return fmt.Sprintf("mem://%s/%s/%s", ownerForCategory(c.Category), c.Category, c.URIHint)
 }
 
 // ownerForCategory returns the URI owner for a given category.
 // "feedback" and "reference" intentionally take the default "user" branch:
 // feedback captures guidance the user has given (issue #24), and reference
 // captures pointers to systems the user works in (Linear, dashboards, rituals).
+// "constraints" (things the user said never to do) are the user's too.
 // An agent-side feedback tree is deferred to a later issue.
 func ownerForCategory(category string) string {
 	switch category {
@@ -45,11 +129,13 @@ func ownerForCategory(category string) string {
 	}
 }
 
-// validCategories defines the allowed memory categories.
+// validCategories defines the allowed memory categories. "session" holds
+// notes scoped to one session (engine.session_notes; see candidateURI).
 var validCategories = map[string]bool{
 	"profile": true, "preferences": true, "entities": true,
 	"events": true, "patterns": true, "cases": true,
 	"moments": true, "feedback": true, "reference": true,
+	"constraints": true, "session": true,
 }
 
 // findSimilarNode searches existing nodes for one semantically similar to the given
@@ -64,121 +150,183 @@ func findSimilarNode(ctx context.Context, db *store.DB, embedder Embedder,
 	}
 	activeID := EmbedderIdentity(embedder)
 
-	vectors, err := db.AllVectors()
+	// Only live leaves in the target category. Retracted nodes are excluded
+	// in SQL: returning one as a merge target would let a later UpsertNode
+	// silently overwrite the retracted row's content — resurrection through
+	// the back door. The dedup-against-retracted gate
+	// (engine.findRetractedMatches) is a separate path that intentionally
+	// finds these; this one must not.
+	vectors, err := db.SearchVectorsByCategory(category)
 	if err != nil {
 		return nil, 0, fmt.Errorf("load vectors: %w", err)
 	}
-	if len(vectors) == 0 {
-		return nil, 0, nil
-	}
 
-	nodeIDs := make([]int64, len(vectors))
-	for i, v := range vectors {
-		nodeIDs[i] = v.NodeID
-	}
-
-	nodes, err := db.GetNodesByIDs(nodeIDs)
-	if err != nil {
-		return nil, 0, fmt.Errorf("get nodes: %w", err)
-	}
-	nodeMap := make(map[int64]store.MemNode, len(nodes))
-	for _, n := range nodes {
-		nodeMap[n.ID] = n
-	}
-
-	var bestNode *store.MemNode
+	var bestID int64
 	bestSim := 0.0
-
 	for _, v := range vectors {
 		if canonicalIdentity(v.Model, v.Dimensions) != activeID {
 			continue // never compare across vector spaces
 		}
-		node, ok := nodeMap[v.NodeID]
-		if !ok || node.NodeType != "leaf" || node.Category != category {
-			continue
-		}
-		// Retracted nodes must not influence similarity selection. Returning
-		// one as a merge target lets a later UpsertNode silently overwrite the
-		// retracted row's content — resurrection through the back door. The
-		// dedup-against-retracted gate (engine.findRetractedMatches) is a
-		// separate path that intentionally finds these; this one must not.
-		if node.IsRetracted() {
-			continue
-		}
-
 		sim := CosineSimilarity(candidateVec, v.Embedding)
 		if sim > bestSim && sim >= threshold {
 			bestSim = sim
-			n := node // avoid capturing loop variable
-			bestNode = &n
+			bestID = v.NodeID
 		}
 	}
+	if bestID == 0 {
+		return nil, 0, nil
+	}
 
+	bestNode, err := db.GetNodeByID(bestID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get node: %w", err)
+	}
+	if bestNode == nil {
+		return nil, 0, nil
+	}
 	return bestNode, bestSim, nil
 }
 
+// pruneSlugVersions applies engine.immutable_keep after an UpsertNode that
+// suffixed requestedURI into a new version (node.URI differs). Failures are
+// logged; the write itself already succeeded.
+func pruneSlugVersions(db *store.DB, keep int, requestedURI string, node *store.MemNode) {
+	if keep <= 0 || node.URI == requestedURI {
+		return
+	}
+	slug := requestedURI[strings.LastIndex(requestedURI, "/")+1:]
+	n, err := db.PruneSlugVersions(slug, node.Category, keep)
+	if err != nil {
+		log.Printf("prune versions of %s: %v", requestedURI, err)
+		return
+	}
+	if n > 0 {
+		log.Printf("pruned %d old version(s) of %s (keeping %d)", n, requestedURI, keep)
+	}
+}
+
+// enforceCategoryCap evicts the lowest-scoring memories of node's category
+// once it holds more than engine.category_caps allows, sparing node itself.
+// Logged, never fatal: the write that triggered it has already succeeded.
+func enforceCategoryCap(db *store.DB, caps map[string]int, node *store.MemNode) {
+	limit := caps[node.Category]
+	if limit <= 0 {
+		return
+	}
+	evicted, err := db.EvictOverCap(node.Category, limit, node.URI)
+	if err != nil {
+		log.Printf("cap %s at %d: %v", node.Category, limit, err)
+		return
+	}
+	for _, uri := range evicted {
+		log.Printf("evicted %s (%s over its cap of %d)", uri, node.Category, limit)
+	}
+}
+
+// immutableDuplicate reports an existing live node in an immutable category
+// that the candidate restates. Immutable categories never merge in place —
+// UpsertNode suffixes a colliding slug into a fresh URI — so a reworded event
+// would otherwise accrue as a second node. The threshold is deliberately well
+// above MatchThreshold: two genuinely distinct events ("deployed v1.2",
+// "deployed v1.3") read alike, and collapsing them loses history. Returns nil
+// for a mergeable candidate, a nil embedder, or a zero (disabled) threshold.
+func immutableDuplicate(ctx context.Context, db *store.DB, embedder Embedder,
+	c memoryCandidate, threshold float64) (*store.MemNode, float64, error) {
+	if embedder == nil || threshold <= 0 || c.L0 == "" || c.mergeable() {
+		return nil, 0, nil
+	}
+	return findSimilarNode(ctx, db, embedder, c.L0, c.Category, threshold)
+}
+
 // extractMemories parses a transcript, condenses it, calls the LLM for extraction,
 // and persists the resulting memory candidates. If embedder is non-nil, newly
-// extracted nodes are embedded immediately.
-func extractMemories(db *store.DB, client llm.Client, embedder Embedder, sessionID, transcriptPath string) error {
-	entries, err := transcript.ParseFile(transcriptPath)
+// extracted nodes are embedded immediately. Returns the URIs of the memories
+// written, in candidate order.
+func extractMemories(db *store.DB, client llm.Client, merger *contentMerger, embedder Embedder, cfg config.EngineConfig, sessionID, transcriptPath string) ([]string, []MergeDiff, error) {
+	// The gates below each embed a candidate's L0; embed it once.
+	embedder = withEmbedCache(embedder)
+	entries, err := parseTranscript(transcriptPath, cfg)
 	if err != nil {
-		return fmt.Errorf("parse transcript: %w", err)
+		return nil, nil, fmt.Errorf("parse transcript: %w", err)
 	}
 
-	// Guard: skip if < 3 user messages
-	if transcript.CountUserMessages(entries) < 3 {
-		log.Printf("extraction: skipping %s — fewer than 3 user messages", sessionID)
-		return nil
+	// Guard: skip below the configured content thresholds
+	if ok, reason := contentGate(entries, cfg); !ok {
+		log.Printf("extraction: skipping %s — %s", sessionID, reason)
+		return nil, nil, nil
 	}
 
-	condensed := transcript.Condense(entries)
+	condensed := condense(entries, cfg)
 
-	// Guard: skip if < 100 chars condensed
-	if len(condensed) < 100 {
-		log.Printf("extraction: skipping %s — condensed too short (%d chars)", sessionID, len(condensed))
-		return nil
+	prompt, err := extractionPrompt(cfg, condensed)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	prompt := llm.ExtractionPrompt(condensed)
-
 	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
 	defer cancel()
 
 	resp, err := client.Complete(ctx, prompt)
 	if err != nil {
-		return fmt.Errorf("llm extraction: %w", err)
+		return nil, nil, fmt.Errorf("llm extraction: %w", err)
 	}
 
 	// Guard: skip if < 20 chars response
 	if len(resp.Content) < 20 {
 		log.Printf("extraction: skipping %s — LLM response too short (%d chars)", sessionID, len(resp.Content))
-		return nil
+		return nil, nil, nil
 	}
 
-	// Parse JSON response — extract array from response
+	// Parse JSON response — extract array from response. Models that are
+	// weak at JSON get one repair round-trip before the extraction fails.
 	candidates, err := parseExtractionResponse(resp.Content)
 	if err != nil {
-		return fmt.Errorf("parse extraction response: %w", err)
+		log.Printf("extraction: %s — response was not valid JSON (%v), asking for a repair", sessionID, err)
+		candidates, err = repairExtractionResponse(ctx, client, resp.Content)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse extraction response: %w", err)
+		}
+	}
+
+	// Hard cap: even if the LLM (or a custom prompt) asks for more, only keep
+	// the first maxExtractionCandidates.
+	if len(candidates) > maxExtractionCandidates {
+		log.Printf("extraction: capping %d candidates to %d for %s", len(candidates), maxExtractionCandidates, sessionID)
+		candidates = candidates[:maxExtractionCandidates]
 	}
 
-	// Hard cap: even if the LLM returns more, only keep the first 3
-	if len(candidates) > 3 {
-		log.Printf("extraction: capping %d candidates to 3 for %s", len(candidates), sessionID)
-		candidates = candidates[:3]
+	// Session notes are only worth writing while the session is live: the
+	// SessionEnd hook extracts after ending it, and a note written then would
+	// outlive the prune that ran at the end.
+	sessionLive := false
+	if sess, err := db.GetSession(sessionID); err == nil && sess != nil {
+		sessionLive = sess.EndedAt == nil
 	}
 
 	// Persist each candidate
+	var stored []string
+	var merges []MergeDiff
+	// written maps each candidate's own URI to where it landed (a similarity
+	// redirect can send it elsewhere), so related_to can name either.
+	written := make(map[string]string)
+	var related []pendingLinks
 	for _, c := range candidates {
-		vc, err := validateCandidate(c)
+		vc, err := validateCandidate(tagAssistantInsight(c, cfg))
 		if err != nil {
 			log.Printf("extraction: rejecting candidate %q: %v", c.URIHint, err)
 			continue
 		}
 		c = vc
+		if err := scoreSpecificity(c, cfg.GenericPhrases); err != nil {
+			log.Printf("extraction: rejecting candidate %q: %v", c.URIHint, err)
+			continue
+		}
+		if c.Category == "session" && (!cfg.SessionNotes || !sessionLive) {
+			log.Printf("extraction: dropping session note %q — session notes are off or %s has ended", c.URIHint, sessionID)
+			continue
+		}
 
-		owner := ownerForCategory(c.Category)
-		uri := fmt.Sprintf("mem://%s/%s/%s", owner, c.Category, c.URIHint)
+		uri := candidateURI(c, sessionID)
 
 		// An LLM-supplied merge_target is intentionally NOT honored. Dedup is owned
 		// by the system via findSimilarNode (embedding similarity) below — a path the
@@ -188,10 +336,20 @@ func extractMemories(db *store.DB, client llm.Client, embedder Embedder, session
 		// input to zero LLM-controlled URIs: a candidate always lands in its own
 		// declared category, so the gate simply keys on c.Category.
 
+		// Immutable near-duplicate gate: skip a candidate that restates an
+		// existing event/entity/case rather than suffixing it into a new URI.
+		if dup, sim, err := immutableDuplicate(ctx, db, embedder, c, cfg.ImmutableDedupThreshold); err != nil {
+			log.Printf("extraction: immutable dedup check failed: %v", err)
+		} else if dup != nil {
+			log.Printf("extraction: skipping %s — near-duplicate of %s (similarity: %.3f)", uri, dup.URI, sim)
+			continue
+		}
+
 		// Similarity gate: redirect to a semantically equivalent LIVE node in the
 		// same category if one exists (findSimilarNode skips retracted nodes, so it
-		// can never merge INTO a tombstone).
-		if embedder != nil && c.Category != "" {
+		// can never merge INTO a tombstone). Not for session notes: the match
+		// could be another session's note.
+		if embedder != nil && c.Category != "" && c.Category != "session" {
 			match, sim, err := findSimilarNode(ctx, db, embedder, c.L0, c.Category, MatchThreshold(embedder))
 			if err != nil {
 				log.Printf("extraction: similarity check failed: %v", err)
@@ -228,7 +386,8 @@ func extractMemories(db *store.DB, client llm.Client, embedder Embedder, session
 		// still collide with a retracted canonical node that has no same-identity
 		// vector. UpsertNode enforces this atomically too (ErrRetractedTarget), but
 		// skipping here keeps a clean per-candidate log and avoids a wasted write.
-		if existing, err := db.GetNodeByURI(uri); err == nil && existing != nil && existing.IsRetracted() {
+		existing, err := db.GetNodeByURI(uri)
+		if err == nil && existing != nil && existing.IsRetracted() {
 			log.Printf("extraction: skipping %s — target URI is retracted (would resurrect)", uri)
 			continue
 		}
@@ -241,38 +400,122 @@ func extractMemories(db *store.DB, client llm.Client, embedder Embedder, session
 			L1Overview:    c.L1,
 			L2Content:     c.L2,
 			SourceSession: sessionID,
+			MergeOverride: c.Mergeable,
+			Origin:        c.Origin,
 		}
 
+		merger.apply(ctx, db, node)
 		if err := db.UpsertNode(node); err != nil {
 			log.Printf("extraction: failed to upsert %s: %v", uri, err)
 			continue
 		}
 		log.Printf("extraction: stored %s [%s]", uri, c.Category)
+		recordSessionMemory(db, sessionID, uri, existing, node, "extraction")
+		if d, ok := mergeDiff(existing, node); ok {
+			logMergeDiff("extraction", d)
+			merges = append(merges, d)
+		}
+		pruneSlugVersions(db, cfg.ImmutableKeep, uri, node)
+		enforceCategoryCap(db, cfg.CategoryCaps, node)
+		stored = append(stored, node.URI)
+		written[candidateURI(c, sessionID)] = node.URI
+		if len(c.RelatedTo) > 0 {
+			related = append(related, pendingLinks{from: node.URI, to: c.RelatedTo})
+		}
 
 		// Keep the stored vector in sync with the (possibly updated) content.
 		// UpsertNode may have merged into an existing node — look it up for its ID.
-		// When no usable embedder is available (locked / none), DELETE any existing
-		// vector instead of skipping: otherwise a content update leaves a stale
-		// vector that search would serve once the embedder returns (EmbedMissing
-		// only fills MISSING vectors). DeleteVector is a no-op for a fresh node.
 		if stored, err := db.GetNodeByURI(node.URI); err == nil && stored != nil {
-			if embedder != nil && node.L0Abstract != "" {
-				if vec, err := embedder.Embed(ctx, node.L0Abstract); err != nil {
-					log.Printf("extraction: embed %s: %v", uri, err)
-				} else if err := db.SaveVector(stored.ID, vec, embedder.Model()); err != nil {
-					log.Printf("extraction: save vector %s: %v", uri, err)
-				}
-			} else if err := db.DeleteVector(stored.ID); err != nil {
-				log.Printf("extraction: clear stale vector %s: %v", uri, err)
+			saveEntity(db, stored.ID, c, "extraction")
+			syncVector(ctx, db, embedder, stored, "extraction")
+		}
+	}
+
+	linkRelated(db, related, written)
+	return stored, merges, nil
+}
+
+// syncVector replaces node's vector after its content was written, so search
+// never serves a vector describing the previous content. It always saves the
+// new embedding, even over a vector from a better model; when there is no
+// usable embedder (none, or the identity is locked) or the embed fails, it
+// deletes the old vector instead and leaves the node for EmbedMissing.
+// SaveVectorIfAbsent is no use here: its refusal would keep the stale vector.
+func syncVector(ctx context.Context, db *store.DB, embedder Embedder, node *store.MemNode, logPrefix string) {
+	if node.L0Abstract != "" && embedder != nil {
+		vec, err := embedder.Embed(ctx, node.L0Abstract)
+		if err == nil {
+			if err = db.SaveVectorFor(node.ID, vec, embedder.Model(), node.L0Abstract); err == nil {
+				return
 			}
 		}
+		log.Printf("%s: embed %s: %v", logPrefix, node.URI, err)
 	}
+	if err := db.DeleteVector(node.ID); err != nil {
+		log.Printf("%s: clear stale vector %s: %v", logPrefix, node.URI, err)
+	}
+}
+
+// recordSessionMemory notes that sessionID wrote node, which the write
+// aimed at uri: a creation when nothing lived there before or the write
+// landed on a new suffixed version. Failures are logged, never fatal.
+func recordSessionMemory(db *store.DB, sessionID, uri string, existing, node *store.MemNode, logPrefix string) {
+	created := existing == nil || node.URI != uri
+	if err := db.RecordSessionMemory(sessionID, node.URI, created); err != nil {
+		log.Printf("%s: %v", logPrefix, err)
+	}
+}
+
+// pendingLinks is a stored candidate's related_to list, linked once the
+// whole batch is written so candidates can name each other in any order.
+type pendingLinks struct {
+	from string
+	to   []string
+}
+
+// linkRelated records a RelatedTo link for each related URI that resolves
+// to a live memory, translating URIs of candidates redirected on write.
+// Unresolvable targets are logged and dropped; they never fail extraction.
+func linkRelated(db *store.DB, related []pendingLinks, written map[string]string) {
+	for _, p := range related {
+		for _, to := range p.to {
+			to = strings.TrimSpace(to)
+			if w, ok := written[to]; ok {
+				to = w
+			}
+			if to == "" || to == p.from {
+				continue
+			}
+			if _, err := db.LinkNodes(p.from, to, store.RelatedTo); err != nil {
+				log.Printf("extraction: not linking %s → %s: %v", p.from, to, err)
+			}
+		}
+	}
+}
 
-	return nil
+// repairExtractionResponse re-prompts once with the malformed response and
+// parses the reply. One attempt only: a model that can't produce JSON twice
+// in a row isn't going to on a third try, and each attempt costs a call.
+func repairExtractionResponse(ctx context.Context, client llm.Client, bad string) ([]memoryCandidate, error) {
+	resp, err := client.Complete(ctx, llm.JSONRepairPrompt(bad))
+	if err != nil {
+		return nil, fmt.Errorf("repair: %w", err)
+	}
+	candidates, err := parseExtractionResponse(resp.Content)
+	if err != nil {
+		return nil, fmt.Errorf("after repair: %w", err)
+	}
+	return candidates, nil
 }
 
-// parseExtractionResponse extracts a JSON array from the LLM response.
-// The response might contain markdown code fences or other wrapper text.
+// parseExtractionResponse extracts the candidate array from the LLM response.
+// The response might contain markdown code fences or other wrapper text, and
+// models put brackets inside L1/L2 (code examples, "[WIP]"), emit a second
+// array, or leave a trailing comma. Cutting from the first '[' to the last ']'
+// failed on all of those, losing every memory in the response. Instead it
+// tries, in order: the first complete top-level array a string-aware scan
+// finds from each '[', then the span from the first '[' to each ']' working
+// back from the last. Every try tolerates trailing commas.
 func parseExtractionResponse(content string) ([]memoryCandidate, error) {
 	content = strings.TrimSpace(content)
 
@@ -287,19 +530,113 @@ func parseExtractionResponse(content string) ([]memoryCandidate, error) {
 
 	content = strings.TrimSpace(content)
 
-	// Find the JSON array
-	start := strings.Index(content, "[")
-	end := strings.LastIndex(content, "]")
-	if start < 0 || end < 0 || end <= start {
+	first := strings.IndexByte(content, '[')
+	last := strings.LastIndexByte(content, ']')
+	if first < 0 || last < first {
 		return nil, fmt.Errorf("no JSON array found in response")
 	}
 
-	jsonStr := content[start : end+1]
+	// Only outermost arrays count: once one closes but fails to decode, resume
+	// after it, so a "[]" inside one of its strings is never taken for the
+	// whole (empty) answer.
+	for start := first; start >= 0; {
+		from := start + 1
+		if end, ok := scanJSONArray(content, start); ok {
+			if candidates, err := unmarshalCandidates(content[start : end+1]); err == nil {
+				return candidates, nil
+			}
+			from = end + 1
+		}
+		next := strings.IndexByte(content[from:], '[')
+		if next < 0 {
+			break
+		}
+		start = from + next
+	}
+
+	// Last resort: the old first-'['-to-last-']' cut, then trim the tail one
+	// ']' at a time.
+	candidates, err := unmarshalCandidates(content[first : last+1])
+	if err == nil {
+		return candidates, nil
+	}
+	for end := strings.LastIndexByte(content[:last], ']'); end > first; end = strings.LastIndexByte(content[:end], ']') {
+		if candidates, terr := unmarshalCandidates(content[first : end+1]); terr == nil {
+			return candidates, nil
+		}
+	}
+	return nil, fmt.Errorf("unmarshal candidates: %w", err)
+}
 
+// unmarshalCandidates decodes a JSON array of candidates, dropping trailing
+// commas first.
+func unmarshalCandidates(s string) ([]memoryCandidate, error) {
 	var candidates []memoryCandidate
-	if err := json.Unmarshal([]byte(jsonStr), &candidates); err != nil {
-		return nil, fmt.Errorf("unmarshal candidates: %w", err)
+	if err := json.Unmarshal([]byte(dropTrailingCommas(s)), &candidates); err != nil {
+		return nil, err
 	}
-
 	return candidates, nil
 }
+
+// scanJSONArray returns the index of the ']' closing the array that opens at
+// s[start], skipping brackets inside JSON strings. ok is false when the array
+// never closes.
+func scanJSONArray(s string, start int) (end int, ok bool) {
+	depth := 0
+	inString, escaped := false, false
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				return i, c == ']'
+			}
+		}
+	}
+	return 0, false
+}
+
+// dropTrailingCommas removes a ',' that directly precedes (whitespace aside)
+// a closing ']' or '}' outside JSON strings.
+func dropTrailingCommas(s string) string {
+	var b strings.Builder
+	inString, escaped := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		} else if c == '"' {
+			inString = true
+		} else if c == ',' {
+			rest := strings.TrimLeft(s[i+1:], " \t\r\n")
+			if rest != "" && (rest[0] == ']' || rest[0] == '}') {
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lazypower/continuity/internal/store"
+)
+
+// skipIdentityLocked is the Skipped reason while the vector identity is
+// locked. Such a session is deferred, not done, so any failure record stays.
+const skipIdentityLocked = "vector identity locked"
+
+// skipAlreadyExtracted is the Skipped reason for a session already marked
+// extracted. That run says nothing about the failure on record, which may be
+// newer, so the record stays.
+const skipAlreadyExtracted = "already extracted"
+
+// recordOutcome keeps failed_extractions in step with an extraction: an error
+// records (or re-counts) the session for a later retry; any outcome of a run
+// that actually looked at the transcript — memories stored, too little
+// content — settles it and drops its record.
+func (e *Engine) recordOutcome(sessionID, transcriptPath string, res ExtractResult, err error) {
+	if e.DB.ReadOnly {
+		return
+	}
+	if err != nil {
+		if rerr := e.DB.RecordFailedExtraction(sessionID, transcriptPath, err.Error()); rerr != nil {
+			log.Printf("extraction: record failure of %s: %v", sessionID, rerr)
+		}
+		return
+	}
+	if res.Skipped == skipIdentityLocked || res.Skipped == skipAlreadyExtracted {
+		return
+	}
+	if cerr := e.DB.ClearFailedExtraction(sessionID); cerr != nil {
+		log.Printf("extraction: clear failure of %s: %v", sessionID, cerr)
+	}
+}
+
+// RetryReport is what one RetryFailed pass did.
+type RetryReport struct {
+	Queued   []string `json:"queued"`             // session IDs queued for another attempt
+	GaveUp   []string `json:"gave_up,omitempty"`  // failed maxAttempts times already
+	Deferred int      `json:"deferred,omitempty"` // left for a later pass: in flight, or the queue was full
+}
+
+// RetryFailed queues another extraction of each session in
+// failed_extractions on the worker pool. Sessions that have failed
+// engine.retry_failed_max_attempts times are left alone unless all is set, as
+// are ones an earlier pass queued and that haven't finished. A retry reads
+// the session's stored transcript, falling back to the record's. Each retry
+// records its own outcome, so a success drops the record and a failure
+// counts another attempt.
+func (e *Engine) RetryFailed(all bool) (RetryReport, error) {
+	rep := RetryReport{Queued: []string{}}
+	maxAttempts := e.cfg.RetryFailedMaxAttempts
+	if all {
+		maxAttempts = 0
+	}
+	failed, err := e.DB.ListFailedExtractions()
+	if err != nil {
+		return rep, err
+	}
+	for i, f := range failed {
+		if maxAttempts > 0 && f.Attempts >= maxAttempts {
+			rep.GaveUp = append(rep.GaveUp, f.SessionID)
+			continue
+		}
+		if _, busy := e.retrying.LoadOrStore(f.SessionID, true); busy {
+			rep.Deferred++
+			continue
+		}
+		path := e.retryTranscriptPath(f)
+		err := e.Enqueue(func() {
+			defer e.retrying.Delete(f.SessionID)
+			if err := e.ExtractSession(f.SessionID, path); err != nil {
+				log.Printf("retry: extraction of %s failed again (attempt %d): %v", f.SessionID, f.Attempts+1, err)
+			} else {
+				log.Printf("retry: extraction of %s settled", f.SessionID)
+			}
+		})
+		if err != nil {
+			// Queue full: this and the rest wait for the next pass, bar
+			// those already out of attempts.
+			e.retrying.Delete(f.SessionID)
+			for _, rest := range failed[i:] {
+				if maxAttempts > 0 && rest.Attempts >= maxAttempts {
+					rep.GaveUp = append(rep.GaveUp, rest.SessionID)
+				} else {
+					rep.Deferred++
+				}
+			}
+			break
+		}
+		rep.Queued = append(rep.Queued, f.SessionID)
+	}
+	return rep, nil
+}
+
+// retryTranscriptPath is the transcript a retry reads: the one stored on the
+// session, which the extract handler keeps resolved and a session merge
+// points at the whole conversation, else the path the failure recorded.
+func (e *Engine) retryTranscriptPath(f store.FailedExtraction) string {
+	if sess, err := e.DB.GetSession(f.SessionID); err == nil && sess != nil && sess.TranscriptPath != nil && *sess.TranscriptPath != "" {
+		return *sess.TranscriptPath
+	}
+	return f.TranscriptPath
+}
+
+// ParseRetryFailedInterval parses engine.retry_failed_interval. Empty or
+// "0" turns background retries off; anything else must be a positive Go
+// duration.
+func ParseRetryFailedInterval(s string) (time.Duration, error) {
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive")
+	}
+	return d, nil
+}
+
+// StartRetryTimer runs RetryFailed every engine.retry_failed_interval. Does
+// nothing when the interval is off.
+func (e *Engine) StartRetryTimer() {
+	interval, err := ParseRetryFailedInterval(e.cfg.RetryFailedInterval)
+	if err != nil {
+		log.Printf("retry: bad retry_failed_interval %q (%v), not retrying", e.cfg.RetryFailedInterval, err)
+		return
+	}
+	if interval == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				rep, err := e.RetryFailed(false)
+				if err != nil {
+					log.Printf("retry: %v", err)
+				} else if len(rep.Queued) > 0 {
+					log.Printf("retry: queued %d failed extraction(s)", len(rep.Queued))
+				}
+			case <-e.stopCh:
+				return
+			}
+		}
+	}()
+}
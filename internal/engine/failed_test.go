@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lazypower/continuity/internal/config"
+	"github.com/lazypower/continuity/internal/llm"
+)
+
+func TestFailedExtractionsRecordedAndRetried(t *testing.T) {
+	db := testDB(t)
+	if _, err := db.InitSession("flaky", "test"); err != nil {
+		t.Fatalf("InitSession: %v", err)
+	}
+	path := makeTranscript(t)
+
+	eng := New(db, &llm.MockClient{Err: errors.New("llm down")})
+	cfg := config.Default().Engine
+	cfg.RetryFailedMaxAttempts = 2
+	eng.SetConfig(cfg)
+
+	for range 2 {
+		if err := eng.ExtractSession("flaky", path); err == nil {
+			t.Fatal("ExtractSession with the LLM down: want an error")
+		}
+	}
+	failed, err := db.ListFailedExtractions()
+	if err != nil {
+		t.Fatalf("ListFailedExtractions: %v", err)
+	}
+	if len(failed) != 1 || failed[0].SessionID != "flaky" || failed[0].Attempts != 2 || failed[0].TranscriptPath != path {
+		t.Fatalf("failed = %+v, want flaky with 2 attempts at %s", failed, path)
+	}
+
+	// Past max attempts, only an explicit retry-all picks it up.
+	rep, err := eng.RetryFailed(false)
+	if err != nil {
+		t.Fatalf("RetryFailed: %v", err)
+	}
+	if len(rep.Queued) != 0 || len(rep.GaveUp) != 1 {
+		t.Fatalf("RetryFailed(false) = %+v, want flaky given up on", rep)
+	}
+
+	eng.LLM = &multiResponseMock{
+		responses: []*llm.Response{
+			{Content: `[{"category":"preferences","uri_hint":"go-style","l0":"Uses Go","l1":"Prefers Go","l2":""}]`, Provider: "mock"},
+			{Content: "NO_UPDATE", Provider: "mock"},
+			{Content: "focused", Provider: "mock"},
+		},
+	}
+	if rep, err = eng.RetryFailed(true); err != nil || len(rep.Queued) != 1 {
+		t.Fatalf("RetryFailed(true) = %+v, %v; want flaky queued", rep, err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		failed, _ = db.ListFailedExtractions()
+		if len(failed) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("retry never settled: %+v", failed)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sess, _ := db.GetSession("flaky"); sess.ExtractedAt == nil {
+		t.Error("a successful retry should mark the session extracted")
+	}
+
+	// Skipping an already-extracted session settles nothing.
+	db.RecordFailedExtraction("flaky", path, "llm down")
+	if err := eng.ExtractSession("flaky", path); err != nil {
+		t.Fatalf("ExtractSession: %v", err)
+	}
+	if failed, _ = db.ListFailedExtractions(); len(failed) != 1 {
+		t.Errorf("an already-extracted skip dropped the failure record: %+v", failed)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/lazypower/continuity/internal/config"
 	"github.com/lazypower/continuity/internal/llm"
 	"github.com/lazypower/continuity/internal/store"
 )
@@ -64,7 +65,7 @@ func TestExtraction_SkipsRetractedMatch_KeepsRest(t *testing.T) {
 	]`
 	mock := &llm.MockClient{Response: &llm.Response{Content: resp, Provider: "mock"}}
 
-	if err := extractMemories(db, mock, emb, "sess-extract", makeTranscript(t)); err != nil {
+	if _, _, err := extractMemories(db, mock, nil, emb, config.Default().Engine, "sess-extract", makeTranscript(t)); err != nil {
 		t.Fatalf("extractMemories: %v", err)
 	}
 
@@ -99,7 +100,7 @@ func TestExtraction_SkipsExactRetractedURICollision(t *testing.T) {
 	resp := `[{"category":"preferences","uri_hint":"legacy-pref","l0":"totally different unrelated wording here","l1":"Body content with enough length to pass validation thresholds easily."}]`
 	mock := &llm.MockClient{Response: &llm.Response{Content: resp, Provider: "mock"}}
 
-	if err := extractMemories(db, mock, emb, "sess", makeTranscript(t)); err != nil {
+	if _, _, err := extractMemories(db, mock, nil, emb, config.Default().Engine, "sess", makeTranscript(t)); err != nil {
 		t.Fatalf("extractMemories: %v", err)
 	}
 	// Full-row equality — the retracted mergeable node must be byte-for-byte intact.
@@ -154,7 +155,7 @@ func TestExtraction_IgnoresMergeTarget(t *testing.T) {
 	resp := `[{"category":"events","uri_hint":"deploy-note","merge_target":"mem://user/preferences/live-pref","l0":"deployed the release on friday afternoon","l1":"Body content with enough length to pass validation thresholds easily."}]`
 	mock := &llm.MockClient{Response: &llm.Response{Content: resp, Provider: "mock"}}
 
-	if err := extractMemories(db, mock, emb, "sess", makeTranscript(t)); err != nil {
+	if _, _, err := extractMemories(db, mock, nil, emb, config.Default().Engine, "sess", makeTranscript(t)); err != nil {
 		t.Fatalf("extractMemories: %v", err)
 	}
 
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+
+	"github.com/lazypower/continuity/internal/store"
 )
 
 // canonicalIdentity maps a (model, dimensions) pair to a corpus-binding vector
@@ -165,6 +167,48 @@ func (e *Engine) reconcileVectorIdentity(ctx context.Context) (VectorIdentitySta
 	return st, nil
 }
 
+// ImportVectorFilter returns the keepVector check for store.ImportJSONL. An
+// imported vector is kept only under the corpus's identity: the declared one,
+// else the one its stored vectors share. A corpus with neither takes the first
+// identity imported, so an import never leaves it mixed; one that is already
+// mixed keeps none. Refused vectors are re-embedded by EmbedMissing.
+func ImportVectorFilter(db *store.DB) (func(model string, dims int) bool, error) {
+	want, ok, err := db.VectorIdentity()
+	if err != nil {
+		return nil, err
+	}
+	mixed := false
+	if !ok {
+		rows, err := db.VectorModelCounts()
+		if err != nil {
+			return nil, err
+		}
+		buckets := map[string]int{}
+		for _, r := range rows {
+			buckets[canonicalIdentity(r.Model, r.Dimensions)] += r.Count
+		}
+		switch len(buckets) {
+		case 0:
+		case 1:
+			for id := range buckets {
+				want = id
+			}
+		default:
+			mixed = true
+		}
+	}
+	return func(model string, dims int) bool {
+		if mixed {
+			return false
+		}
+		id := canonicalIdentity(model, dims)
+		if want == "" {
+			want = id
+		}
+		return id == want
+	}, nil
+}
+
 // sortedKeys returns a map's keys sorted, for deterministic messages.
 func sortedKeys(m map[string]int) []string {
 	keys := make([]string, 0, len(m))
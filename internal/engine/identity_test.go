@@ -2,6 +2,8 @@ package engine
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/lazypower/continuity/internal/store"
@@ -139,6 +141,34 @@ func TestReconcileMixedCorpusLocks(t *testing.T) {
 	}
 }
 
+func TestImportVectorFilter(t *testing.T) {
+	db := memTestDB(t)
+	keep, err := ImportVectorFilter(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A fresh corpus takes the first identity it is given, and only that one.
+	if !keep("ollama:nomic-embed-text", 768) || !keep("ollama:nomic-embed-text", 768) {
+		t.Error("fresh corpus should keep the first identity imported")
+	}
+	if keep("hashtf", 512) {
+		t.Error("a second identity would leave the corpus mixed")
+	}
+
+	if err := db.SetVectorIdentity("hashtf:512"); err != nil {
+		t.Fatal(err)
+	}
+	if keep, err = ImportVectorFilter(db); err != nil {
+		t.Fatal(err)
+	}
+	if keep("ollama:nomic-embed-text", 768) || !keep("hashtf", 512) {
+		t.Error("a declared corpus should keep only its own identity")
+	}
+	if keep("hashtf", 256) {
+		t.Error("same model at another dimension is another identity")
+	}
+}
+
 // TestFindSkipsForeignIdentityVectors pins Codex finding #5: even after the lock
 // passes, a stored vector under a foreign identity must not be scored — here a
 // foreign vector identical to the query (cosine 1.0) must still be skipped.
@@ -333,8 +363,8 @@ func TestRememberFailsClosedWhenLocked(t *testing.T) {
 
 // TestEmbedNodeClearsStaleVectorWhenLocked pins Codex round-5: when a content
 // update happens while locked, the OLD vector must be dropped (not left in place)
-// so search can't serve a vector for the previous content after the embedder
-// returns. EmbedMissing only fills MISSING vectors, so a survivor would be stale.
+// so search can't serve a vector for the previous content while locked, nor
+// after the embedder returns if the vector has no text hash to show it stale.
 func TestEmbedNodeClearsStaleVectorWhenLocked(t *testing.T) {
 	db := memTestDB(t)
 	id := seedLeaf(t, db, "mem://agent/patterns/a", "updated content")
@@ -376,6 +406,29 @@ func TestEmbedNodeClearsStaleVectorWhenNoEmbedder(t *testing.T) {
 	}
 }
 
+// TestSyncVectorReplacesBetterModelAfterUpdate: a content update on a
+// degraded embedder must still replace the old vector, even one from a
+// better model, since that vector describes the previous content.
+func TestSyncVectorReplacesBetterModelAfterUpdate(t *testing.T) {
+	db := memTestDB(t)
+	id := seedLeaf(t, db, "mem://agent/patterns/a", "updated content")
+	if err := db.SaveVectorFor(id, make([]float64, 8), "ollama:nomic-embed-text", "old content"); err != nil {
+		t.Fatal(err)
+	}
+
+	node, _ := db.GetNodeByURI("mem://agent/patterns/a")
+	syncVector(context.Background(), db, stubEmbedder{model: "hashtf", dims: 8}, node, "test")
+	v, _ := db.GetVector(id)
+	if v == nil || v.Model != "hashtf" || v.TextHash != store.TextHash("updated content") {
+		t.Fatalf("vector after update = %+v, want a hashtf vector of the new content", v)
+	}
+
+	syncVector(context.Background(), db, nil, node, "test")
+	if v, _ := db.GetVector(id); v != nil {
+		t.Fatalf("syncVector with no embedder must clear the vector, got %+v", v)
+	}
+}
+
 func TestEmbedMissingFillsTrulyMissing(t *testing.T) {
 	db := memTestDB(t)
 	id := seedLeaf(t, db, "mem://agent/patterns/a", "alpha")
@@ -395,3 +448,81 @@ func TestEmbedMissingFillsTrulyMissing(t *testing.T) {
 		t.Fatalf("missing node not embedded correctly: %+v", v)
 	}
 }
+
+func TestEmbedMissingRefreshesEditedL0(t *testing.T) {
+	db := memTestDB(t)
+	id := seedLeaf(t, db, "mem://agent/patterns/a", "alpha")
+	legacy := seedLeaf(t, db, "mem://agent/patterns/b", "beta")
+
+	e := New(db, nil)
+	emb := stubEmbedder{model: "m", dims: 8}
+	e.SetEmbedder(emb)
+	node, _ := db.GetNodeByURI("mem://agent/patterns/a")
+	if err := e.EmbedNode(context.Background(), node); err != nil {
+		t.Fatal(err)
+	}
+	// A vector from before text hashes were recorded is taken as current.
+	if err := db.SaveVector(legacy, []float64{1, 2, 3, 4, 5, 6, 7, 8}, "m"); err != nil {
+		t.Fatal(err)
+	}
+
+	node.L0Abstract = "alpha, reworded by a merge"
+	if err := db.UpdateNode(node); err != nil {
+		t.Fatal(err)
+	}
+	n, err := e.EmbedMissing(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("should re-embed only the edited node, embedded %d", n)
+	}
+	want, _ := emb.Embed(context.Background(), node.L0Abstract)
+	if v, _ := db.GetVector(id); v == nil || v.Embedding[0] != want[0] || v.Stale(node.L0Abstract) {
+		t.Errorf("edited node's vector not refreshed: %+v", v)
+	}
+
+	if n, _ := e.EmbedMissing(context.Background()); n != 0 {
+		t.Errorf("second pass re-embedded %d current vectors", n)
+	}
+}
+
+// cancellingEmbedder cancels its context after n embeds, standing in for a
+// backfill pass that runs out of time partway through.
+type cancellingEmbedder struct {
+	stubEmbedder
+	n      *int
+	cancel context.CancelFunc
+}
+
+func (c cancellingEmbedder) Embed(ctx context.Context, text string) ([]float64, error) {
+	if *c.n--; *c.n == 0 {
+		c.cancel()
+	}
+	return c.stubEmbedder.Embed(ctx, text)
+}
+
+func TestEmbedMissingResumesWhereItStopped(t *testing.T) {
+	db := memTestDB(t)
+	total := embedBatchSize + 10
+	for i := 0; i < total; i++ {
+		seedLeaf(t, db, fmt.Sprintf("mem://agent/patterns/p%d", i), fmt.Sprintf("pattern %d", i))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	budget := embedBatchSize
+	e := New(db, nil)
+	e.SetEmbedder(cancellingEmbedder{stubEmbedder{model: "stub", dims: 8}, &budget, cancel})
+
+	n, remaining, err := e.embedMissing(ctx)
+	if !errors.Is(err, context.Canceled) || n != embedBatchSize || remaining != total-embedBatchSize {
+		t.Fatalf("cut-short pass = %d embedded, %d remaining, %v", n, remaining, err)
+	}
+
+	// The next pass only does what's left.
+	e.SetEmbedder(stubEmbedder{model: "stub", dims: 8})
+	n, remaining, err = e.embedMissing(context.Background())
+	if err != nil || n != total-embedBatchSize || remaining != 0 {
+		t.Fatalf("resumed pass = %d embedded, %d remaining, %v", n, remaining, err)
+	}
+}
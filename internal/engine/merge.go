@@ -0,0 +1,161 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lazypower/continuity/internal/llm"
+	"github.com/lazypower/continuity/internal/store"
+)
+
+// Merge strategies for config.EngineConfig.MergeStrategy.
+const (
+	MergeReplace = "replace"
+	MergeUnion   = "union"
+	MergeLLM     = "llm"
+)
+
+// mergeMinChars is the L1 length below which an overview isn't worth an LLM
+// merge: a one-liner replaced by another one-liner loses nothing.
+const mergeMinChars = 80
+
+// ValidMergeStrategy reports whether s names a merge strategy. Empty means
+// the default (replace).
+func ValidMergeStrategy(s string) bool {
+	switch s {
+	case "", MergeReplace, MergeUnion, MergeLLM:
+		return true
+	}
+	return false
+}
+
+// contentMerger folds an existing mergeable memory's content into an incoming
+// write before UpsertNode overwrites it. A nil merger is last-writer-wins.
+type contentMerger struct {
+	strategy string
+	client   llm.Client // used by MergeLLM
+}
+
+// merger returns the engine's content merger, or nil under the replace
+// strategy.
+func (e *Engine) merger() *contentMerger {
+	switch e.cfg.MergeStrategy {
+	case MergeUnion:
+		return &contentMerger{strategy: MergeUnion}
+	case MergeLLM:
+		client := e.mergeLLM
+		if client == nil {
+			client = e.LLM
+		}
+		return &contentMerger{strategy: MergeLLM, client: client}
+	}
+	return nil
+}
+
+// apply rewrites node's L1/L2 to carry forward what the live mergeable node
+// at node.URI has and node lacks. Anything else — no existing node, one that
+// won't merge (store.MergesInto), a tombstone (UpsertNode refuses those itself) — is left
+// alone. An LLM failure keeps the incoming L1; the merge only ever adds. The
+// result stays within validateCandidate's caps: a long L2 union loses the
+// oldest carried-over paragraphs, an overview over maxL1Chars is refused.
+func (m *contentMerger) apply(ctx context.Context, db *store.DB, node *store.MemNode) {
+	if m == nil {
+		return
+	}
+	existing, err := db.GetNodeByURI(node.URI)
+	if err != nil || existing == nil || !store.MergesInto(existing) || existing.IsRetracted() {
+		return
+	}
+
+	node.L2Content = unionParagraphs(existing.L2Content, node.L2Content)
+	if len(node.L2Content) > maxL2Chars {
+		log.Printf("merge: %s: truncating merged L2 (%d → %d chars)", node.URI, len(node.L2Content), maxL2Chars)
+		node.L2Content = truncateClean(node.L2Content, maxL2Chars)
+	}
+
+	if m.strategy != MergeLLM || m.client == nil ||
+		len(existing.L1Overview) < mergeMinChars || len(node.L1Overview) < mergeMinChars ||
+		store.TextNearIdentical(existing.L1Overview, node.L1Overview) {
+		return
+	}
+	merged, err := mergeOverviews(ctx, m.client, existing.L1Overview, node.L1Overview)
+	if err != nil {
+		log.Printf("merge: %s: %v — keeping the new overview", node.URI, err)
+		return
+	}
+	log.Printf("merge: %s: merged overview (%d + %d → %d chars)", node.URI, len(existing.L1Overview), len(node.L1Overview), len(merged))
+	node.L1Overview = merged
+}
+
+// mergeOverviews asks client for one overview combining existing and incoming.
+// A reply longer than both inputs together is treated as the model rambling,
+// and one over maxL1Chars as too long to store.
+func mergeOverviews(ctx context.Context, client llm.Client, existing, incoming string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+	resp, err := client.Complete(ctx, llm.MergePrompt(existing, incoming))
+	if err != nil {
+		return "", fmt.Errorf("llm merge: %w", err)
+	}
+	merged := strings.TrimSpace(resp.Content)
+	switch {
+	case merged == "":
+		return "", fmt.Errorf("empty merge response")
+	case len(merged) > len(existing)+len(incoming), len(merged) > maxL1Chars:
+		return "", fmt.Errorf("merge response too long (%d chars)", len(merged))
+	}
+	return merged, nil
+}
+
+// unionParagraphs returns incoming followed by the blank-line-separated
+// paragraphs of existing that incoming doesn't already contain.
+func unionParagraphs(existing, incoming string) string {
+	if strings.TrimSpace(existing) == "" || store.TextNearIdentical(existing, incoming) {
+		return incoming
+	}
+	if strings.TrimSpace(incoming) == "" {
+		return existing
+	}
+	var kept []string
+	for _, p := range strings.Split(existing, "\n\n") {
+		p = strings.TrimSpace(p)
+		if p != "" && !strings.Contains(incoming, p) {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == 0 {
+		return incoming
+	}
+	return strings.TrimSpace(incoming) + "\n\n" + strings.Join(kept, "\n\n")
+}
+
+// MergeDiff is the before/after overview of a memory that an extraction
+// merged into, for auditing whether the merge helped.
+type MergeDiff struct {
+	URI    string `json:"uri"`
+	Before string `json:"before"` // L1 before the write
+	After  string `json:"after"`  // L1 as written
+}
+
+// mergeDiff compares before, the live node read ahead of an UpsertNode, with
+// after, the node as written. It reports a diff only when the write merged
+// into before: the same URI on a mergeable node, with content UpsertNode
+// didn't skip as near-identical.
+func mergeDiff(before, after *store.MemNode) (MergeDiff, bool) {
+	if before == nil || !store.MergesInto(before) || before.IsRetracted() || before.URI != after.URI {
+		return MergeDiff{}, false
+	}
+	if store.TextNearIdentical(before.L1Overview, after.L1Overview) &&
+		store.TextNearIdentical(before.L0Abstract, after.L0Abstract) {
+		return MergeDiff{}, false
+	}
+	return MergeDiff{URI: after.URI, Before: before.L1Overview, After: after.L1Overview}, true
+}
+
+// logMergeDiff logs d under prefix ("extraction", "signal").
+func logMergeDiff(prefix string, d MergeDiff) {
+	log.Printf("%s: merged into %s — L1 before: %q after: %q", prefix, d.URI, d.Before, d.After)
+}
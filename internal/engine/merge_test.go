@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lazypower/continuity/internal/llm"
+	"github.com/lazypower/continuity/internal/store"
+)
+
+func TestUnionParagraphs(t *testing.T) {
+	tests := []struct {
+		name, existing, incoming, want string
+	}{
+		{"no existing", "", "new", "new"},
+		{"no incoming", "old", "", "old"},
+		{"identical", "same text", "same text", "same text"},
+		{"keeps missing paragraph", "a\n\nb", "b\n\nc", "b\n\nc\n\na"},
+		{"all covered", "a", "a and more", "a and more"},
+	}
+	for _, tt := range tests {
+		if got := unionParagraphs(tt.existing, tt.incoming); got != tt.want {
+			t.Errorf("%s: unionParagraphs(%q, %q) = %q, want %q", tt.name, tt.existing, tt.incoming, got, tt.want)
+		}
+	}
+}
+
+func TestContentMergerApply(t *testing.T) {
+	db := testDB(t)
+	oldL1 := strings.Repeat("Uses tabs for indentation in Go and Makefiles. ", 3)
+	newL1 := strings.Repeat("Prefers tabs, and four-space soft tabs in YAML. ", 3)
+	if err := db.CreateNode(&store.MemNode{
+		URI: "mem://user/preferences/indent", NodeType: "leaf", Category: "preferences",
+		L0Abstract: "Indent with tabs.", L1Overview: oldL1, L2Content: "Said so in the gofmt session.",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	incoming := func() *store.MemNode {
+		return &store.MemNode{
+			URI: "mem://user/preferences/indent", NodeType: "leaf", Category: "preferences",
+			L0Abstract: "Tabs, except YAML.", L1Overview: newL1, L2Content: "Raised again over a CI config.",
+		}
+	}
+
+	// A nil merger (replace) leaves the write as it is.
+	n := incoming()
+	(*contentMerger)(nil).apply(context.Background(), db, n)
+	if n.L2Content != "Raised again over a CI config." {
+		t.Errorf("replace: L2 = %q", n.L2Content)
+	}
+
+	n = incoming()
+	(&contentMerger{strategy: MergeUnion}).apply(context.Background(), db, n)
+	if n.L1Overview != newL1 || !strings.Contains(n.L2Content, "gofmt session") {
+		t.Errorf("union: L1 = %q, L2 = %q", n.L1Overview, n.L2Content)
+	}
+
+	mock := &llm.MockClient{Response: &llm.Response{Content: "Tabs everywhere except YAML."}}
+	n = incoming()
+	(&contentMerger{strategy: MergeLLM, client: mock}).apply(context.Background(), db, n)
+	if n.L1Overview != "Tabs everywhere except YAML." || len(mock.Calls) != 1 {
+		t.Errorf("llm: L1 = %q after %d calls", n.L1Overview, len(mock.Calls))
+	}
+
+	// A failed merge keeps the incoming overview rather than losing the write.
+	n = incoming()
+	(&contentMerger{strategy: MergeLLM, client: &llm.MockClient{Err: errors.New("down")}}).apply(context.Background(), db, n)
+	if n.L1Overview != newL1 {
+		t.Errorf("llm failure: L1 = %q, want the incoming overview", n.L1Overview)
+	}
+
+	// A merged overview over the L1 cap is refused like any failed merge.
+	long := &llm.MockClient{Response: &llm.Response{Content: strings.Repeat("word ", maxL1Chars/5+1)}}
+	n = incoming()
+	n.L1Overview = strings.Repeat(newL1, maxL1Chars/len(newL1))
+	wantL1 := n.L1Overview
+	(&contentMerger{strategy: MergeLLM, client: long}).apply(context.Background(), db, n)
+	if n.L1Overview != wantL1 {
+		t.Errorf("over-cap merge: L1 is %d chars, want the incoming overview", len(n.L1Overview))
+	}
+}
+
+func TestContentMergerApplyCapsL2(t *testing.T) {
+	db := testDB(t)
+	para := strings.Repeat("x", 1000)
+	var old []string
+	for i := range 30 {
+		old = append(old, fmt.Sprintf("old %d %s", i, para))
+	}
+	if err := db.CreateNode(&store.MemNode{
+		URI: "mem://user/preferences/big", NodeType: "leaf", Category: "preferences",
+		L0Abstract: "Big.", L1Overview: "Big.", L2Content: strings.Join(old, "\n\n"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var incoming []string
+	for i := range 30 {
+		incoming = append(incoming, fmt.Sprintf("new %d %s", i, para))
+	}
+	n := &store.MemNode{
+		URI: "mem://user/preferences/big", NodeType: "leaf", Category: "preferences",
+		L0Abstract: "Bigger.", L1Overview: "Bigger.", L2Content: strings.Join(incoming, "\n\n"),
+	}
+	(&contentMerger{strategy: MergeUnion}).apply(context.Background(), db, n)
+	if len(n.L2Content) > maxL2Chars || !strings.HasPrefix(n.L2Content, "new 0 ") {
+		t.Errorf("merged L2 is %d chars (cap %d), starting %.10q", len(n.L2Content), maxL2Chars, n.L2Content)
+	}
+}
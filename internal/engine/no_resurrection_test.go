@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/lazypower/continuity/internal/config"
 	"github.com/lazypower/continuity/internal/llm"
 	"github.com/lazypower/continuity/internal/store"
 )
@@ -195,7 +196,7 @@ func TestNoResurrection_ExtractMemoriesDoesNotMutateRetracted(t *testing.T) {
 	}
 
 	transcriptPath := makeTranscript(t)
-	if err := extractMemories(db, mock, embedder, "test-session", transcriptPath); err != nil {
+	if _, _, err := extractMemories(db, mock, nil, embedder, config.Default().Engine, "test-session", transcriptPath); err != nil {
 		t.Fatalf("extractMemories: %v", err)
 	}
 
@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/lazypower/continuity/internal/llm"
+	"github.com/lazypower/continuity/internal/store"
+)
+
+// recategorizeBatchSize is how many memories go into one review prompt when
+// the caller doesn't say.
+const recategorizeBatchSize = 25
+
+// recategorizeMaxTokens bounds one review completion: a short reason per
+// proposed move, for at most a batch of memories.
+const recategorizeMaxTokens = 2048
+
+// recategorizable lists the categories Recategorize reviews and may propose.
+// Moments are qualified by their own path and session notes are scoped to a
+// session's URI tree, so neither moves in or out.
+var recategorizable = []string{
+	"profile", "preferences", "feedback", "constraints", "entities",
+	"events", "patterns", "cases", "reference",
+}
+
+// CategoryProposal is one suggested move: the memory at URI, filed under
+// Category, belongs under Proposed at Target.
+type CategoryProposal struct {
+	URI      string `json:"uri"`
+	L0       string `json:"l0_abstract"`
+	Category string `json:"category"`
+	Proposed string `json:"proposed"`
+	Target   string `json:"target"`
+	Reason   string `json:"reason"`
+}
+
+// MoveTarget returns the URI a memory at uri takes when re-filed under
+// category: the category's owner and the same slug. An unknown or
+// non-movable category is a ValidationError.
+func MoveTarget(uri, category string) (string, error) {
+	if !slices.Contains(recategorizable, category) {
+		return "", validationErrorf("cannot move to category %q (allowed: %s)", category, strings.Join(recategorizable, ", "))
+	}
+	rest := strings.TrimPrefix(uri, "mem://")
+	parts := strings.SplitN(rest, "/", 3)
+	if len(parts) < 3 || parts[2] == "" {
+		return "", validationErrorf("invalid memory URI %q", uri)
+	}
+	return fmt.Sprintf("mem://%s/%s/%s", ownerForCategory(category), category, parts[2]), nil
+}
+
+// ProposeRecategorizations asks the LLM, batchSize memories per call, whether
+// each live leaf is filed under the right category, and returns the moves it
+// suggests. Nothing is changed: applying a proposal is a separate store.MoveNode.
+// Proposals naming an unknown URI, an unmovable category, or a target URI
+// already in use are dropped. A batch whose reply can't be parsed is skipped.
+func (e *Engine) ProposeRecategorizations(ctx context.Context, batchSize int) ([]CategoryProposal, error) {
+	if e.LLM == nil {
+		return nil, fmt.Errorf("no LLM configured")
+	}
+	if batchSize <= 0 {
+		batchSize = recategorizeBatchSize
+	}
+
+	leaves, err := e.DB.ListLeaves()
+	if err != nil {
+		return nil, fmt.Errorf("list leaves: %w", err)
+	}
+	var nodes []store.MemNode
+	for _, n := range leaves {
+		if n.URI != relationalURI && slices.Contains(recategorizable, n.Category) {
+			nodes = append(nodes, n)
+		}
+	}
+
+	var out []CategoryProposal
+	for start := 0; start < len(nodes); start += batchSize {
+		batch := nodes[start:min(start+batchSize, len(nodes))]
+		items := make([]llm.CategoryItem, len(batch))
+		byURI := make(map[string]store.MemNode, len(batch))
+		for i, n := range batch {
+			items[i] = llm.CategoryItem{URI: n.URI, Category: n.Category, Summary: n.L0Abstract}
+			byURI[n.URI] = n
+		}
+
+		resp, err := e.LLM.Complete(llm.WithCallOptions(ctx, llm.CallOptions{MaxTokens: recategorizeMaxTokens}),
+			llm.RecategorizePrompt(items, recategorizable))
+		if err != nil {
+			return nil, fmt.Errorf("llm recategorize: %w", err)
+		}
+		moves, err := parseCategoryMoves(resp.Content)
+		if err != nil {
+			log.Printf("recategorize: skipping batch of %d: %v", len(batch), err)
+			continue
+		}
+
+		for _, m := range moves {
+			n, ok := byURI[m.URI]
+			if !ok || m.Category == n.Category {
+				continue
+			}
+			target, err := MoveTarget(n.URI, m.Category)
+			if err != nil {
+				continue
+			}
+			if taken, err := e.DB.GetNodeByURI(target); err != nil {
+				return nil, fmt.Errorf("look up %s: %w", target, err)
+			} else if taken != nil {
+				log.Printf("recategorize: %s → %s skipped, target exists", n.URI, target)
+				continue
+			}
+			delete(byURI, m.URI) // one proposal per memory
+			out = append(out, CategoryProposal{
+				URI:      n.URI,
+				L0:       n.L0Abstract,
+				Category: n.Category,
+				Proposed: m.Category,
+				Target:   target,
+				Reason:   m.Reason,
+			})
+		}
+	}
+	return out, nil
+}
+
+// categoryMove is one entry of a RecategorizePrompt reply.
+type categoryMove struct {
+	URI      string `json:"uri"`
+	Category string `json:"category"`
+	Reason   string `json:"reason"`
+}
+
+// parseCategoryMoves decodes the first complete JSON array of moves in an
+// LLM reply, tolerating code fences, surrounding prose, and trailing commas.
+func parseCategoryMoves(content string) ([]categoryMove, error) {
+	for start := strings.IndexByte(content, '['); start >= 0; {
+		from := start + 1
+		if end, ok := scanJSONArray(content, start); ok {
+			var moves []categoryMove
+			if err := json.Unmarshal([]byte(dropTrailingCommas(content[start:end+1])), &moves); err == nil {
+				return moves, nil
+			}
+			// Skip the whole array, not just its '[', so nothing nested
+			// inside it is mistaken for the answer.
+			from = end + 1
+		}
+		next := strings.IndexByte(content[from:], '[')
+		if next < 0 {
+			break
+		}
+		start = from + next
+	}
+	return nil, fmt.Errorf("no JSON array of moves found in response")
+}
@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lazypower/continuity/internal/llm"
+	"github.com/lazypower/continuity/internal/store"
+)
+
+func TestProposeRecategorizations(t *testing.T) {
+	db := testDB(t)
+	for _, n := range []*store.MemNode{
+		{URI: "mem://user/events/uses-devbox", Category: "events", L0Abstract: "Uses devbox for all development"},
+		{URI: "mem://user/preferences/dark-mode", Category: "preferences", L0Abstract: "Prefers dark mode"},
+		{URI: "mem://user/events/release-v2", Category: "events", L0Abstract: "Shipped v2"},
+		{URI: "mem://user/events/linear", Category: "events", L0Abstract: "Bugs live in Linear"},
+		{URI: "mem://user/reference/linear", Category: "reference", L0Abstract: "Linear project INGEST"},
+		{URI: "mem://user/moments/first-ship", Category: "moments", L0Abstract: "First ship together"},
+	} {
+		n.NodeType = "leaf"
+		if err := db.CreateNode(n); err != nil {
+			t.Fatalf("CreateNode %s: %v", n.URI, err)
+		}
+	}
+
+	mock := &llm.MockClient{Response: &llm.Response{Content: "Here you go:\n```json\n" + `[
+		{"uri": "mem://user/events/uses-devbox", "category": "preferences", "reason": "a workflow choice"},
+		{"uri": "mem://user/events/uses-devbox", "category": "patterns", "reason": "second opinion"},
+		{"uri": "mem://user/preferences/dark-mode", "category": "preferences", "reason": "unchanged"},
+		{"uri": "mem://user/events/release-v2", "category": "moments", "reason": "not movable"},
+		{"uri": "mem://user/events/linear", "category": "reference", "reason": "target taken"},
+		{"uri": "mem://user/events/unknown", "category": "cases", "reason": "not in batch"},
+	]` + "\n```"}}
+	e := New(db, mock)
+
+	got, err := e.ProposeRecategorizations(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("ProposeRecategorizations: %v", err)
+	}
+	if len(mock.Calls) != 1 {
+		t.Errorf("LLM calls = %d, want 1 batch", len(mock.Calls))
+	}
+	if len(got) != 1 {
+		t.Fatalf("proposals = %+v, want only the devbox move", got)
+	}
+	p := got[0]
+	if p.URI != "mem://user/events/uses-devbox" || p.Category != "events" || p.Proposed != "preferences" ||
+		p.Target != "mem://user/preferences/uses-devbox" || p.Reason != "a workflow choice" {
+		t.Errorf("proposal = %+v", p)
+	}
+
+	// Moments are never offered for review.
+	for _, call := range mock.Calls {
+		if strings.Contains(call, "first-ship") {
+			t.Error("moments memory was sent for review")
+		}
+	}
+
+	// Smaller batches mean more calls, same proposals.
+	mock.Calls = nil
+	got, err = e.ProposeRecategorizations(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("ProposeRecategorizations batch 2: %v", err)
+	}
+	if len(mock.Calls) != 3 {
+		t.Errorf("LLM calls = %d, want 3 batches of ≤2 for 5 memories", len(mock.Calls))
+	}
+	if len(got) != 1 {
+		t.Errorf("proposals = %d, want 1", len(got))
+	}
+}
+
+func TestMoveTarget(t *testing.T) {
+	cases := []struct {
+		uri, category, want string
+		wantErr             bool
+	}{
+		{"mem://user/events/uses-devbox", "preferences", "mem://user/preferences/uses-devbox", false},
+		{"mem://user/events/retry-trick", "patterns", "mem://agent/patterns/retry-trick", false},
+		{"mem://agent/cases/sqlite-lock", "reference", "mem://user/reference/sqlite-lock", false},
+		{"mem://user/events/x", "session", "", true},
+		{"mem://user/events/x", "bogus", "", true},
+		{"mem://user/events", "preferences", "", true},
+	}
+	for _, tc := range cases {
+		got, err := MoveTarget(tc.uri, tc.category)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("MoveTarget(%s, %s) err = %v, wantErr %v", tc.uri, tc.category, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("MoveTarget(%s, %s) = %q, want %q", tc.uri, tc.category, got, tc.want)
+		}
+		if err != nil {
+			if ok, _ := IsValidationError(err); !ok {
+				t.Errorf("MoveTarget(%s, %s) err = %v, want ValidationError", tc.uri, tc.category, err)
+			}
+		}
+	}
+}
+
+func TestParseCategoryMovesOutermostOnly(t *testing.T) {
+	// The outer array is malformed; the "[]" inside its reason must not be
+	// read as an empty answer.
+	_, err := parseCategoryMoves(`[{"uri":"mem://user/events/x","category":"patterns","reason":"uses []string, "quoted" badly"}]`)
+	if err == nil {
+		t.Error("malformed response parsed without error")
+	}
+
+	moves, err := parseCategoryMoves("Note [see below]:\n[{\"uri\":\"mem://user/events/x\",\"category\":\"patterns\",\"reason\":\"r\"}]")
+	if err != nil {
+		t.Fatalf("parseCategoryMoves: %v", err)
+	}
+	if len(moves) != 1 || moves[0].Category != "patterns" {
+		t.Errorf("moves = %+v", moves)
+	}
+}
@@ -2,10 +2,12 @@ package engine
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"strings"
 	"time"
 
+	"github.com/lazypower/continuity/internal/config"
 	"github.com/lazypower/continuity/internal/llm"
 	"github.com/lazypower/continuity/internal/store"
 	"github.com/lazypower/continuity/internal/transcript"
@@ -19,20 +21,55 @@ const relationalURI = "mem://user/profile/communication"
 // buildContext further caps what gets injected into session context.
 const maxRelationalChars = 1200
 
+// defaultRelationalMinInterval applies when engine.relational_min_interval
+// is unset.
+const defaultRelationalMinInterval = 6 * time.Hour
+
+// ParseRelationalMinInterval parses engine.relational_min_interval. Empty
+// means the 6h default and 0 turns the guard off; otherwise it must be a
+// non-negative Go duration.
+func ParseRelationalMinInterval(s string) (time.Duration, error) {
+	if s == "" {
+		return defaultRelationalMinInterval, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	return d, nil
+}
+
+// relationalTooSoon reports whether a session should leave the profile
+// alone: it was rewritten less than the minimum interval ago and the
+// session is too short to be worth an early refresh.
+func relationalTooSoon(cfg config.EngineConfig, profile *store.MemNode, userMessages int, now time.Time) (bool, time.Duration) {
+	interval, err := ParseRelationalMinInterval(cfg.RelationalMinInterval)
+	if err != nil {
+		log.Printf("relational: bad relational_min_interval %q (%v), using %s", cfg.RelationalMinInterval, err, defaultRelationalMinInterval)
+		interval = defaultRelationalMinInterval
+	}
+	if interval == 0 || profile == nil {
+		return false, 0
+	}
+	if cfg.RelationalSubstantialMessages > 0 && userMessages >= cfg.RelationalSubstantialMessages {
+		return false, 0
+	}
+	since := now.Sub(time.UnixMilli(profile.UpdatedAt))
+	return since < interval, since
+}
+
 // extractRelational runs the relational profiling pipeline.
 // It extracts how the user works, communicates, and gives feedback.
-func extractRelational(db *store.DB, client llm.Client, sessionID, transcriptPath string) error {
-	entries, err := transcript.ParseFile(transcriptPath)
+func extractRelational(db *store.DB, client llm.Client, cfg config.EngineConfig, sessionID, transcriptPath string) error {
+	entries, err := parseTranscript(transcriptPath, cfg)
 	if err != nil {
 		return err
 	}
 
-	if transcript.CountUserMessages(entries) < 3 {
-		return nil
-	}
-
-	condensed := transcript.Condense(entries)
-	if len(condensed) < 100 {
+	if ok, _ := contentGate(entries, cfg); !ok {
 		return nil
 	}
 
@@ -50,15 +87,35 @@ func extractRelational(db *store.DB, client llm.Client, sessionID, transcriptPat
 			return nil
 		}
 	}
+	if soon, since := relationalTooSoon(cfg, node, transcript.CountUserMessages(entries), time.Now()); soon {
+		log.Printf("relational: skipping %s — profile rewritten %s ago and the session is short", sessionID, since.Round(time.Minute))
+		return nil
+	}
+
+	content, ok, err := refineRelational(client, sessionID, existing, condense(entries, cfg), cfg.Language)
+	if err != nil || !ok {
+		return err
+	}
+	if err := writeRelational(db, sessionID, content); err != nil {
+		return err
+	}
+
+	log.Printf("relational: updated profile from session %s", sessionID)
+	return nil
+}
 
-	prompt := llm.RelationalPrompt(existing, condensed)
+// refineRelational asks the LLM to fold one condensed transcript into the
+// existing profile. ok is false when the model had nothing to add or its
+// reply failed the sanity checks; the caller keeps the existing profile.
+func refineRelational(client llm.Client, sessionID, existing, condensed, language string) (string, bool, error) {
+	prompt := llm.RelationalPrompt(existing, condensed, language)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
 	defer cancel()
 
 	resp, err := client.Complete(ctx, prompt)
 	if err != nil {
-		return err
+		return "", false, err
 	}
 
 	content := strings.TrimSpace(resp.Content)
@@ -66,11 +123,11 @@ func extractRelational(db *store.DB, client llm.Client, sessionID, transcriptPat
 	// No update signal — catch both exact match and embedded in a longer response
 	if strings.Contains(content, "NO_UPDATE") {
 		log.Printf("relational: no update for %s", sessionID)
-		return nil
+		return "", false, nil
 	}
 	if len(content) < 20 {
 		log.Printf("relational: response too short for %s (%d chars)", sessionID, len(content))
-		return nil
+		return "", false, nil
 	}
 
 	// Reject meta-descriptions — if it reads like commentary about the profile
@@ -86,14 +143,14 @@ func extractRelational(db *store.DB, client llm.Client, sessionID, transcriptPat
 	for _, phrase := range metaPhrases {
 		if strings.Contains(contentLower, phrase) {
 			log.Printf("relational: rejecting meta-description for %s", sessionID)
-			return nil
+			return "", false, nil
 		}
 	}
 
 	// Regression guard: reject absurdly short content that would clobber a richer profile
 	if len(content) < 50 {
 		log.Printf("relational: rejecting update for %s — content too short (%d chars)", sessionID, len(content))
-		return nil
+		return "", false, nil
 	}
 
 	// Size ceiling: truncate if unreasonably large
@@ -101,8 +158,11 @@ func extractRelational(db *store.DB, client llm.Client, sessionID, transcriptPat
 		log.Printf("relational: truncating profile content (%d → %d chars)", len(content), maxRelationalChars)
 		content = truncateClean(content, maxRelationalChars)
 	}
+	return content, true, nil
+}
 
-	// Upsert the relational profile node
+// writeRelational upserts the relational profile node.
+func writeRelational(db *store.DB, sessionID, content string) error {
 	profileNode := &store.MemNode{
 		URI:           relationalURI,
 		NodeType:      "leaf",
@@ -120,10 +180,65 @@ func extractRelational(db *store.DB, client llm.Client, sessionID, transcriptPat
 	// tombstone — and UpsertNode now refuses retracted targets atomically anyway
 	// (ErrRetractedTarget). It also never creates arbitrary nodes, and its L0 is a
 	// constant, so there is no per-candidate content for the L0-based gate to act on.
-	if err := db.UpsertNode(profileNode); err != nil {
-		return err
+	return db.UpsertNode(profileNode)
+}
+
+// ProfileRebuild summarizes a RebuildProfile run.
+type ProfileRebuild struct {
+	Sessions int // sessions considered
+	Applied  int // sessions that changed the profile
+	Skipped  int // unreadable transcripts or below the content gate
+}
+
+// RebuildProfile reconstructs the relational profile from scratch by replaying
+// the last limit sessions with a recorded transcript path, oldest first, so the
+// profile evolves in the order it originally did. The existing profile is
+// ignored as a starting point (it may be the corrupt thing being replaced) and
+// only overwritten once at the end, and only if at least one session produced
+// an update — a run where every LLM call fails leaves the old profile intact.
+func (e *Engine) RebuildProfile(limit int) (ProfileRebuild, error) {
+	var res ProfileRebuild
+	if e.LLM == nil {
+		return res, fmt.Errorf("no LLM configured")
 	}
 
-	log.Printf("relational: updated profile from session %s", sessionID)
-	return nil
+	sessions, err := e.DB.RecentTranscriptSessions(limit)
+	if err != nil {
+		return res, fmt.Errorf("list sessions: %w", err)
+	}
+	res.Sessions = len(sessions)
+
+	profile, lastSession := "", ""
+	for i := len(sessions) - 1; i >= 0; i-- {
+		sess := sessions[i]
+		entries, err := parseTranscript(*sess.TranscriptPath, e.cfg)
+		if err != nil {
+			log.Printf("relational rebuild: skipping %s — %v", sess.Key, err)
+			res.Skipped++
+			continue
+		}
+		if ok, _ := contentGate(entries, e.cfg); !ok {
+			res.Skipped++
+			continue
+		}
+		content, ok, err := refineRelational(e.LLM, sess.Key, profile, condense(entries, e.cfg), e.cfg.Language)
+		if err != nil {
+			log.Printf("relational rebuild: %s: %v", sess.Key, err)
+			res.Skipped++
+			continue
+		}
+		if ok {
+			profile, lastSession = content, sess.Key
+			res.Applied++
+		}
+	}
+
+	if profile == "" {
+		return res, nil
+	}
+	if err := writeRelational(e.DB, lastSession, profile); err != nil {
+		return res, fmt.Errorf("write profile: %w", err)
+	}
+	log.Printf("relational: rebuilt profile from %d of %d sessions", res.Applied, res.Sessions)
+	return res, nil
 }
@@ -45,7 +45,7 @@ func (e *Engine) Retract(ctx context.Context, input RetractInput) (bool, error)
 		// generic. store cannot import engine, hence the cross-layer re-wrap.
 		var rve *store.RetractValidationError
 		if errors.As(err, &rve) {
-			return false, validationErrorf("%s", rve.Message)
+			return false, &ValidationError{Message: rve.Message, err: err}
 		}
 		return false, err
 	}
@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/lazypower/continuity/internal/llm"
 	"github.com/lazypower/continuity/internal/store"
@@ -17,12 +20,119 @@ type SearchResult struct {
 	Node       store.MemNode `json:"node"`
 	Score      float64       `json:"score"`
 	Similarity float64       `json:"similarity"`
+	Freshness  float64       `json:"freshness,omitempty"` // recency bonus included in Score
+
+	// Snippet is the part of L1 that best matches the query (see Snippet).
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// freshnessTau is the decay constant of the recency bonus: a memory
+// freshnessTau old earns 1/e of the full FreshnessWeight.
+const freshnessTau = 14 * 24 * time.Hour
+
+// freshnessBonus is the additive recency term w * exp(-age/freshnessTau) for a
+// node created at createdAt (unix ms). Zero when w is zero.
+func freshnessBonus(w float64, createdAt int64, now time.Time) float64 {
+	if w == 0 {
+		return 0
+	}
+	age := now.Sub(time.UnixMilli(createdAt))
+	if age < 0 {
+		age = 0
+	}
+	return w * math.Exp(-float64(age)/float64(freshnessTau))
+}
+
+// sortResults orders results by score descending. Ties — common with the
+// lexical embedder, where many nodes score identically — break on URI then ID,
+// so the same query over the same corpus always returns the same order.
+func sortResults(results []SearchResult) {
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Node.URI != b.Node.URI {
+			return a.Node.URI < b.Node.URI
+		}
+		return a.Node.ID < b.Node.ID
+	})
 }
 
 // SearchOpts controls search behavior.
 type SearchOpts struct {
 	Limit    int    // max results (default 10)
 	Category string // filter by category (empty = all)
+
+	// SessionID, when set, attributes retrievals to a session: any returned
+	// memory that was injected into that session's context is marked used.
+	SessionID string
+
+	// FreshnessWeight adds a recency bonus of FreshnessWeight*exp(-age/14d) to
+	// each match's score, for queries where "what did we just decide" is the
+	// point. Zero (the default) ranks on similarity and relevance alone.
+	FreshnessWeight float64
+
+	// PerCategory, when set, keeps the top PerCategory results of each
+	// category instead of the top Limit overall (see GroupByCategory).
+	PerCategory int
+
+	// Rerank has Search ask the LLM to reorder its top candidates (twice
+	// the requested count) for the query before trimming. Find ignores it.
+	Rerank bool
+
+	// ParentWeight scales Search's tree-aware parent score: how much a
+	// match's siblings matching too lifts it. Zero means the default 0.2;
+	// negative turns tree scoring off.
+	ParentWeight float64
+
+	// ParentDepth is how many ancestor levels feed the parent score: 1 (the
+	// default for zero) uses the parent directory alone, 2 adds the
+	// grandparent at half weight, and so on.
+	ParentDepth int
+
+	// LinkWeight scales Search's link score: how much a match linked to
+	// another match is lifted, by that match's similarity. Zero means the
+	// default 0.1; negative turns link scoring off.
+	LinkWeight float64
+
+	// MinScore drops matches whose similarity to the query is below it,
+	// before relevance, boosts, or freshness are applied — a floor on how
+	// related a result must be, not on how it ranks. Zero keeps every match.
+	MinScore float64
+}
+
+// defaultParentWeight is Search's parent-score weight absent an override.
+const defaultParentWeight = 0.2
+
+// defaultLinkWeight is Search's link-score weight absent an override.
+const defaultLinkWeight = 0.1
+
+// SearchOpts returns the search options the engine's configuration implies:
+// the tree- and link-scoring knobs. Callers fill in the per-request fields.
+func (e *Engine) SearchOpts() SearchOpts {
+	weight := e.cfg.ParentScoreWeight
+	if weight <= 0 {
+		weight = -1 // configured off; zero in SearchOpts would mean the default
+	}
+	linkWeight := e.cfg.LinkScoreWeight
+	if linkWeight <= 0 {
+		linkWeight = -1
+	}
+	return SearchOpts{ParentWeight: weight, ParentDepth: e.cfg.ParentScoreDepth, LinkWeight: linkWeight, MinScore: e.cfg.SearchMinScore}
+}
+
+// CheckSearchMinScore validates engine.search_min_score: a similarity floor,
+// so a finite number in [0, 1]. NaN would drop every result and compares
+// false against both bounds, so it is rejected by name.
+func CheckSearchMinScore(x float64) error {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return fmt.Errorf("must be a finite number")
+	}
+	if x < 0 || x > 1 {
+		return fmt.Errorf("must be between 0 and 1")
+	}
+	return nil
 }
 
 func (o SearchOpts) limit() int {
@@ -32,6 +142,76 @@ func (o SearchOpts) limit() int {
 	return o.Limit
 }
 
+func (o SearchOpts) parentWeight() float64 {
+	switch {
+	case o.ParentWeight < 0:
+		return 0
+	case o.ParentWeight == 0:
+		return defaultParentWeight
+	}
+	return o.ParentWeight
+}
+
+func (o SearchOpts) linkWeight() float64 {
+	switch {
+	case o.LinkWeight < 0:
+		return 0
+	case o.LinkWeight == 0:
+		return defaultLinkWeight
+	}
+	return o.LinkWeight
+}
+
+func (o SearchOpts) parentDepth() int {
+	if o.ParentDepth <= 0 {
+		return 1
+	}
+	return o.ParentDepth
+}
+
+// capResults trims sorted results to opts: the top Limit overall, or with
+// PerCategory the top PerCategory of each category, rank order preserved.
+func capResults(results []SearchResult, opts SearchOpts) []SearchResult {
+	if opts.PerCategory <= 0 {
+		if limit := opts.limit(); len(results) > limit {
+			results = results[:limit]
+		}
+		return results
+	}
+	counts := make(map[string]int)
+	kept := results[:0]
+	for _, r := range results {
+		if counts[r.Node.Category] < opts.PerCategory {
+			counts[r.Node.Category]++
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// ResultGroup is one category's share of a grouped search.
+type ResultGroup struct {
+	Category string         `json:"category"`
+	Results  []SearchResult `json:"results"`
+}
+
+// GroupByCategory groups ranked results by category. Groups are ordered by
+// their best result, and each keeps its results' rank order.
+func GroupByCategory(results []SearchResult) []ResultGroup {
+	var groups []ResultGroup
+	index := make(map[string]int)
+	for _, r := range results {
+		i, ok := index[r.Node.Category]
+		if !ok {
+			i = len(groups)
+			index[r.Node.Category] = i
+			groups = append(groups, ResultGroup{Category: r.Node.Category})
+		}
+		groups[i].Results = append(groups[i].Results, r)
+	}
+	return groups
+}
+
 // categoryBoost returns a scoring multiplier for high-signal categories.
 // Moments are permanent relational anchors that passed a triple qualification
 // filter — they deserve a ranking boost to surface when marginally relevant.
@@ -94,6 +274,7 @@ func Find(ctx context.Context, db *store.DB, embedder Embedder, query string, op
 	skippedForeign := 0
 
 	// Score each vector
+	now := time.Now()
 	var results []SearchResult
 	for _, v := range vectors {
 		if canonicalIdentity(v.Model, v.Dimensions) != activeID {
@@ -108,6 +289,10 @@ func Find(ctx context.Context, db *store.DB, embedder Embedder, query string, op
 		if opts.Category != "" && node.Category != opts.Category {
 			continue
 		}
+		// Session notes are for the session that wrote them only.
+		if node.IsForeignSessionNote(opts.SessionID) {
+			continue
+		}
 		// Only score leaf nodes
 		if node.NodeType != "leaf" {
 			continue
@@ -120,13 +305,20 @@ func Find(ctx context.Context, db *store.DB, embedder Embedder, query string, op
 		}
 
 		similarity := CosineSimilarity(queryVec, v.Embedding)
+		if similarity < opts.MinScore {
+			continue
+		}
 		score := similarity * node.Relevance * categoryBoost(node.Category)
 
+		// The recency bonus only reorders matches; it never turns a
+		// zero-similarity node into a result.
 		if score > 0 {
+			fresh := freshnessBonus(opts.FreshnessWeight, node.CreatedAt, now)
 			results = append(results, SearchResult{
 				Node:       node,
-				Score:      score,
+				Score:      score + fresh,
 				Similarity: similarity,
+				Freshness:  fresh,
 			})
 		}
 	}
@@ -135,25 +327,34 @@ func Find(ctx context.Context, db *store.DB, embedder Embedder, query string, op
 		log.Printf("search: skipped %d stored vector(s) not matching active identity %s (run `continuity doctor`)", skippedForeign, activeID)
 	}
 
-	// Sort by score descending
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
-	})
+	sortResults(results)
+	results = capResults(results, opts)
 
-	// Limit results
-	limit := opts.limit()
-	if len(results) > limit {
-		results = results[:limit]
+	for i := range results {
+		results[i].Snippet = Snippet(results[i].Node.L1Overview, query)
 	}
 
-	// Touch accessed nodes (retrieval boost)
+	// Touch accessed nodes (retrieval boost), and credit the injection if this
+	// memory was already in the session's context. A read-only store
+	// (serve --readonly) skips the bookkeeping: it is observation, not use.
+	if db.ReadOnly {
+		return results, nil
+	}
 	for _, r := range results {
 		db.TouchNode(r.Node.URI)
+		if opts.SessionID != "" {
+			if _, err := db.MarkInjectionUsed(opts.SessionID, r.Node.URI); err != nil {
+				log.Printf("search: mark injection used: %v", err)
+			}
+		}
 	}
 
 	return results, nil
 }
 
+// searchIntentMaxTokens bounds the intent-decomposition completion.
+const searchIntentMaxTokens = 512
+
 // subQuery represents a decomposed search intent.
 type subQuery struct {
 	Query string `json:"query"`
@@ -161,7 +362,8 @@ type subQuery struct {
 }
 
 // Search performs LLM-assisted search with intent decomposition.
-// Score = 0.5*similarity + 0.3*relevance + 0.2*parentScore.
+// Score = 0.5*similarity + 0.3*relevance + w*parentScore + l*linkScore,
+// w = opts.ParentWeight, l = opts.LinkWeight.
 func Search(ctx context.Context, db *store.DB, embedder Embedder, client llm.Client, query string, opts SearchOpts) ([]SearchResult, error) {
 	if client == nil {
 		// Fall back to Find() if no LLM available
@@ -169,8 +371,10 @@ func Search(ctx context.Context, db *store.DB, embedder Embedder, client llm.Cli
 	}
 
 	// Decompose query into sub-queries
+	// The intent reply is a short JSON list; cap the generation budget so a
+	// search never waits on a full extraction-sized completion.
 	prompt := llm.SearchIntentPrompt(query)
-	resp, err := client.Complete(ctx, prompt)
+	resp, err := client.Complete(llm.WithCallOptions(ctx, llm.CallOptions{MaxTokens: searchIntentMaxTokens}), prompt)
 	if err != nil {
 		log.Printf("search intent decomposition failed, falling back to find: %v", err)
 		return Find(ctx, db, embedder, query, opts)
@@ -184,18 +388,74 @@ func Search(ctx context.Context, db *store.DB, embedder Embedder, client llm.Cli
 
 	// Run Find() for each sub-query with expanded limit
 	expandedOpts := SearchOpts{
-		Limit:    opts.limit() * 3,
-		Category: opts.Category,
+		Limit:       opts.limit() * 3,
+		Category:    opts.Category,
+		SessionID:   opts.SessionID,
+		PerCategory: opts.PerCategory * 3,
+		MinScore:    opts.MinScore,
+	}
+
+	// Sub-queries often repeat the query or each other; embed each text once.
+	seen := findSubQueries(ctx, db, withEmbedCache(embedder), subQueries, expandedOpts)
+
+	// Build ancestor score map for tree-aware scoring
+	depth, weight := opts.parentDepth(), opts.parentWeight()
+	ancestorScores := buildAncestorScores(seen, depth)
+
+	// Graph expansion: a match linked to another strong match is lifted.
+	linkW := opts.linkWeight()
+	var linkScores map[string]float64
+	if linkW > 0 {
+		linkScores = buildLinkScores(db, seen)
 	}
 
-	// Collect all results across sub-queries, deduplicate by node ID (max score wins)
+	// Re-score with full formula: (0.5*similarity + 0.3*relevance + w*parentScore + l*linkScore) * categoryBoost + freshness
+	now := time.Now()
+	var results []SearchResult
+	for _, r := range seen {
+		ps := parentScore(r.Node.URI, ancestorScores, depth)
+		r.Freshness = freshnessBonus(opts.FreshnessWeight, r.Node.CreatedAt, now)
+		r.Score = (0.5*r.Similarity+0.3*r.Node.Relevance+weight*ps+linkW*linkScores[r.Node.URI])*categoryBoost(r.Node.Category) + r.Freshness
+		results = append(results, r)
+	}
+
+	sortResults(results)
+	if opts.Rerank {
+		results = rerank(ctx, client, query, rerankPool(results, opts))
+	}
+	results = capResults(results, opts)
+
+	// Each Find snipped against its own sub-query; the caller asked this one.
+	for i := range results {
+		results[i].Snippet = Snippet(results[i].Node.L1Overview, query)
+	}
+
+	return results, nil
+}
+
+// findSubQueries runs Find for every sub-query concurrently — each embeds and
+// scans independently — and deduplicates by node ID, max score winning. Results
+// are merged in sub-query order once all finish, so ties resolve exactly as a
+// sequential loop would.
+func findSubQueries(ctx context.Context, db *store.DB, embedder Embedder, subQueries []subQuery, opts SearchOpts) map[int64]SearchResult {
+	perQuery := make([][]SearchResult, len(subQueries))
+	var wg sync.WaitGroup
+	for i, sq := range subQueries {
+		wg.Add(1)
+		go func(i int, q string) {
+			defer wg.Done()
+			results, err := Find(ctx, db, embedder, q, opts)
+			if err != nil {
+				log.Printf("sub-query find failed for %q: %v", q, err)
+				return
+			}
+			perQuery[i] = results
+		}(i, sq.Query)
+	}
+	wg.Wait()
+
 	seen := make(map[int64]SearchResult)
-	for _, sq := range subQueries {
-		results, err := Find(ctx, db, embedder, sq.Query, expandedOpts)
-		if err != nil {
-			log.Printf("sub-query find failed for %q: %v", sq.Query, err)
-			continue
-		}
+	for _, results := range perQuery {
 		for _, r := range results {
 			existing, exists := seen[r.Node.ID]
 			if !exists || r.Score > existing.Score {
@@ -203,51 +463,160 @@ func Search(ctx context.Context, db *store.DB, embedder Embedder, client llm.Cli
 			}
 		}
 	}
+	return seen
+}
 
-	// Build parent score map for tree-aware scoring
-	parentScores := buildParentScores(db, seen)
+// buildAncestorScores computes, for every directory up to depth levels above
+// a result, the average similarity of the results beneath it.
+func buildAncestorScores(results map[int64]SearchResult, depth int) map[string]float64 {
+	scores := make(map[string]float64)
+	counts := make(map[string]int)
 
-	// Re-score with full formula: (0.5*similarity + 0.3*relevance + 0.2*parentScore) * categoryBoost
-	var results []SearchResult
-	for _, r := range seen {
-		ps := parentScores[r.Node.ParentURI]
-		r.Score = (0.5*r.Similarity + 0.3*r.Node.Relevance + 0.2*ps) * categoryBoost(r.Node.Category)
-		results = append(results, r)
+	for _, r := range results {
+		uri := r.Node.URI
+		for level := 0; level < depth; level++ {
+			if uri = parentOf(uri); uri == "" {
+				break
+			}
+			scores[uri] += r.Similarity
+			counts[uri]++
+		}
 	}
 
-	// Sort by score descending
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
-	})
+	for uri := range scores {
+		scores[uri] /= float64(counts[uri])
+	}
+	return scores
+}
+
+// buildLinkScores maps each result's URI to the best similarity among the
+// results it is linked to, in either direction. Results with no linked
+// result are absent (score 0). A failed lookup only logs: links refine the
+// ranking, they never block a search.
+func buildLinkScores(db *store.DB, results map[int64]SearchResult) map[string]float64 {
+	sims := make(map[string]float64, len(results))
+	uris := make([]string, 0, len(results))
+	for _, r := range results {
+		sims[r.Node.URI] = r.Similarity
+		uris = append(uris, r.Node.URI)
+	}
+	links, err := db.LinksAmong(uris)
+	if err != nil {
+		log.Printf("search: load links: %v", err)
+		return nil
+	}
+	scores := make(map[string]float64)
+	for _, l := range links {
+		scores[l.FromURI] = max(scores[l.FromURI], sims[l.ToURI])
+		scores[l.ToURI] = max(scores[l.ToURI], sims[l.FromURI])
+	}
+	return scores
+}
 
-	// Limit results
-	limit := opts.limit()
-	if len(results) > limit {
-		results = results[:limit]
+// parentScore blends the ancestor scores of uri's first depth ancestors,
+// each level counting half as much as the one below it. With depth 1 it is
+// just the parent directory's average.
+func parentScore(uri string, ancestorScores map[string]float64, depth int) float64 {
+	var sum, weights float64
+	w := 1.0
+	for level := 0; level < depth; level++ {
+		if uri = parentOf(uri); uri == "" {
+			break
+		}
+		sum += w * ancestorScores[uri]
+		weights += w
+		w /= 2
+	}
+	if weights == 0 {
+		return 0
 	}
+	return sum / weights
+}
 
-	return results, nil
+// parentOf returns the directory above a mem:// URI, or "" at an owner root
+// ("mem://user").
+func parentOf(uri string) string {
+	rest, ok := strings.CutPrefix(uri, "mem://")
+	if !ok {
+		return ""
+	}
+	i := strings.LastIndexByte(rest, '/')
+	if i <= 0 {
+		return ""
+	}
+	return "mem://" + rest[:i]
 }
 
-// buildParentScores computes average similarity of sibling nodes for tree-aware scoring.
-func buildParentScores(db *store.DB, results map[int64]SearchResult) map[string]float64 {
-	parentScores := make(map[string]float64)
-	parentCounts := make(map[string]int)
+// rerankMaxTokens bounds the rerank completion: a list of at most a few
+// dozen URIs.
+const rerankMaxTokens = 1024
+
+// rerankPool is the head of sorted results worth handing the reranker: twice
+// what will be kept, overall or per category.
+func rerankPool(results []SearchResult, opts SearchOpts) []SearchResult {
+	if opts.PerCategory > 0 {
+		opts.PerCategory *= 2
+	} else {
+		opts.Limit = opts.limit() * 2
+	}
+	return capResults(results, opts)
+}
 
-	for _, r := range results {
-		if r.Node.ParentURI != "" {
-			parentScores[r.Node.ParentURI] += r.Similarity
-			parentCounts[r.Node.ParentURI]++
-		}
+// rerank asks client to order results for query. URIs the model returns come
+// first in its order; anything it left out follows in the original order. Any
+// failure — call error, unparseable reply — returns results unchanged.
+func rerank(ctx context.Context, client llm.Client, query string, results []SearchResult) []SearchResult {
+	if len(results) < 2 {
+		return results
+	}
+	items := make([]llm.RankItem, len(results))
+	for i, r := range results {
+		items[i] = llm.RankItem{URI: r.Node.URI, Summary: r.Node.L0Abstract}
+	}
+	resp, err := client.Complete(llm.WithCallOptions(ctx, llm.CallOptions{MaxTokens: rerankMaxTokens}), llm.RelevancePrompt(query, items))
+	if err != nil {
+		log.Printf("search rerank failed, keeping vector order: %v", err)
+		return results
+	}
+	order := parseURIList(resp.Content)
+	if len(order) == 0 {
+		log.Printf("search rerank: unparseable response, keeping vector order")
+		return results
 	}
 
-	for uri := range parentScores {
-		if parentCounts[uri] > 0 {
-			parentScores[uri] /= float64(parentCounts[uri])
+	byURI := make(map[string]int, len(results))
+	for i, r := range results {
+		byURI[r.Node.URI] = i
+	}
+	placed := make([]bool, len(results))
+	out := make([]SearchResult, 0, len(results))
+	for _, uri := range order {
+		if i, ok := byURI[uri]; ok && !placed[i] {
+			placed[i] = true
+			out = append(out, results[i])
+		}
+	}
+	for i, r := range results {
+		if !placed[i] {
+			out = append(out, r)
 		}
 	}
+	return out
+}
 
-	return parentScores
+// parseURIList extracts a JSON array of strings from an LLM response,
+// tolerating code fences and surrounding prose.
+func parseURIList(content string) []string {
+	start := strings.Index(content, "[")
+	end := strings.LastIndex(content, "]")
+	if start < 0 || end <= start {
+		return nil
+	}
+	var uris []string
+	if err := json.Unmarshal([]byte(content[start:end+1]), &uris); err != nil {
+		return nil
+	}
+	return uris
 }
 
 // parseSubQueries extracts the JSON array of sub-queries from the LLM response.
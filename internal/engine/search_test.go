@@ -2,7 +2,12 @@ package engine
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/lazypower/continuity/internal/llm"
 	"github.com/lazypower/continuity/internal/store"
@@ -79,6 +84,140 @@ func TestFindBasic(t *testing.T) {
 	}
 }
 
+func TestFindMinScore(t *testing.T) {
+	db := testDB(t)
+	nodes := seedTestNodes(t, db)
+	embedder, _ := NewHashEmbedder(0)
+	embedTestNodes(t, db, embedder, nodes)
+	ctx := context.Background()
+
+	all, err := Find(ctx, db, embedder, "SQLite WAL mode", SearchOpts{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) < 2 {
+		t.Fatalf("got %d results without a floor, want several", len(all))
+	}
+	floor := (all[0].Similarity + all[len(all)-1].Similarity) / 2
+
+	kept, err := Find(ctx, db, embedder, "SQLite WAL mode", SearchOpts{Limit: 10, MinScore: floor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) == 0 || len(kept) >= len(all) {
+		t.Fatalf("floor %.3f kept %d of %d results, want some but not all", floor, len(kept), len(all))
+	}
+	for _, r := range kept {
+		if r.Similarity < floor {
+			t.Errorf("%s has similarity %.3f below the floor %.3f", r.Node.URI, r.Similarity, floor)
+		}
+	}
+
+	// An off-topic query finds nothing rather than the least unrelated.
+	none, err := Find(ctx, db, embedder, "knitting patterns for winter scarves", SearchOpts{Limit: 10, MinScore: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(none) != 0 {
+		t.Errorf("off-topic query with a floor returned %d results, want none", len(none))
+	}
+}
+
+func TestFindTiesOrderByURI(t *testing.T) {
+	db := testDB(t)
+	// Identical L0s embed identically, so every score ties. Insert in reverse
+	// URI order so storage order can't pass for the tiebreak.
+	var nodes []*store.MemNode
+	for _, slug := range []string{"delta", "charlie", "bravo", "alpha"} {
+		n := &store.MemNode{URI: "mem://user/preferences/" + slug, NodeType: "leaf", Category: "preferences",
+			L0Abstract: "Runs integration tests against a disposable Postgres container"}
+		if err := db.CreateNode(n); err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, n)
+	}
+	embedder, _ := NewHashEmbedder(0)
+	embedTestNodes(t, db, embedder, nodes)
+
+	results, err := Find(context.Background(), db, embedder, "integration tests Postgres container", SearchOpts{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"alpha", "bravo", "charlie", "delta"}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, slug := range want {
+		if got := results[i].Node.URI; got != "mem://user/preferences/"+slug {
+			t.Errorf("results[%d] = %s, want %s", i, got, slug)
+		}
+	}
+}
+
+func TestFindFreshnessWeight(t *testing.T) {
+	db := testDB(t)
+	var nodes []*store.MemNode
+	for _, slug := range []string{"old", "new"} {
+		n := &store.MemNode{URI: "mem://user/preferences/" + slug, NodeType: "leaf", Category: "preferences",
+			L0Abstract: "Deploys the staging cluster with a blue green rollout"}
+		if err := db.CreateNode(n); err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, n)
+	}
+	// Age the first node by 60 days.
+	aged := time.Now().Add(-60 * 24 * time.Hour).UnixMilli()
+	if _, err := db.Exec(`UPDATE mem_nodes SET created_at = ? WHERE id = ?`, aged, nodes[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	embedder, _ := NewHashEmbedder(0)
+	embedTestNodes(t, db, embedder, nodes)
+	ctx := context.Background()
+
+	// Default: no recency term.
+	results, err := Find(ctx, db, embedder, "staging blue green rollout", SearchOpts{Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Freshness != 0 || results[1].Freshness != 0 {
+		t.Errorf("freshness applied with weight 0: %+v", results)
+	}
+
+	results, err = Find(ctx, db, embedder, "staging blue green rollout", SearchOpts{Limit: 5, FreshnessWeight: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Node.URI != "mem://user/preferences/new" {
+		t.Errorf("top = %s, want the recent memory", results[0].Node.URI)
+	}
+	if f := results[0].Freshness; f < 0.49 || f > 0.5 {
+		t.Errorf("new node freshness = %.3f, want ~0.5", f)
+	}
+	if f := results[1].Freshness; f > 0.5*math.Exp(-4)+0.01 {
+		t.Errorf("60-day-old freshness = %.3f, want ~%.3f", f, 0.5*math.Exp(-60.0/14))
+	}
+}
+
+func TestSortResultsTiebreak(t *testing.T) {
+	results := []SearchResult{
+		{Node: store.MemNode{ID: 3, URI: "mem://b"}, Score: 0.5},
+		{Node: store.MemNode{ID: 2, URI: "mem://a"}, Score: 0.5},
+		{Node: store.MemNode{ID: 1, URI: "mem://a"}, Score: 0.5},
+		{Node: store.MemNode{ID: 4, URI: "mem://z"}, Score: 0.9},
+	}
+	sortResults(results)
+	var got []int64
+	for _, r := range results {
+		got = append(got, r.Node.ID)
+	}
+	want := []int64{4, 1, 2, 3}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("order = %v, want %v (score desc, then URI, then ID)", got, want)
+		}
+	}
+}
+
 func TestFindWithCategory(t *testing.T) {
 	db := testDB(t)
 	nodes := seedTestNodes(t, db)
@@ -156,6 +295,155 @@ func TestSearchWithMockLLM(t *testing.T) {
 	}
 }
 
+// countingEmbedder counts Embed calls per text.
+type countingEmbedder struct {
+	Embedder
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *countingEmbedder) Embed(ctx context.Context, text string) ([]float64, error) {
+	c.mu.Lock()
+	c.calls[text]++
+	c.mu.Unlock()
+	return c.Embedder.Embed(ctx, text)
+}
+
+func TestSearchEmbedsRepeatedSubQueryOnce(t *testing.T) {
+	db := testDB(t)
+	nodes := seedTestNodes(t, db)
+
+	hash, _ := NewHashEmbedder(0)
+	embedTestNodes(t, db, hash, nodes)
+	embedder := &countingEmbedder{Embedder: hash, calls: map[string]int{}}
+
+	mockLLM := &llm.MockClient{
+		Response: &llm.Response{
+			Content: `[{"query": "Go developer", "type": "MEMORY"}, {"query": "SQLite WAL", "type": "RESOURCE"}, {"query": "Go developer", "type": "PATTERN"}]`,
+		},
+	}
+	if _, err := Search(context.Background(), db, embedder, mockLLM, "Go and SQLite", SearchOpts{Limit: 5}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if n := embedder.calls["Go developer"]; n != 1 {
+		t.Errorf("repeated sub-query embedded %d times, want 1", n)
+	}
+
+	// The cache lives for one request: a second search embeds again.
+	if _, err := Search(context.Background(), db, embedder, mockLLM, "Go and SQLite", SearchOpts{Limit: 5}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if n := embedder.calls["Go developer"]; n != 2 {
+		t.Errorf("after a second search: embedded %d times, want 2", n)
+	}
+}
+
+func TestParentScoreDepth(t *testing.T) {
+	results := map[int64]SearchResult{
+		1: {Node: store.MemNode{URI: "mem://user/preferences/go/style"}, Similarity: 0.8},
+		2: {Node: store.MemNode{URI: "mem://user/preferences/go/testing"}, Similarity: 0.4},
+		3: {Node: store.MemNode{URI: "mem://user/preferences/editor"}, Similarity: 0.3},
+	}
+
+	one := buildAncestorScores(results, 1)
+	if got := parentScore("mem://user/preferences/go/style", one, 1); math.Abs(got-0.6) > 1e-9 {
+		t.Errorf("depth 1 parent score = %v, want the siblings' average 0.6", got)
+	}
+	if _, ok := one["mem://user"]; ok {
+		t.Error("depth 1 should not score grandparents")
+	}
+
+	// Depth 2: parent 0.6 at weight 1, grandparent (0.8+0.4+0.3)/3 = 0.5 at
+	// weight 0.5.
+	two := buildAncestorScores(results, 2)
+	want := (0.6 + 0.5*0.5) / 1.5
+	if got := parentScore("mem://user/preferences/go/style", two, 2); math.Abs(got-want) > 1e-9 {
+		t.Errorf("depth 2 parent score = %v, want %v", got, want)
+	}
+
+	if got := (SearchOpts{}).parentWeight(); got != 0.2 {
+		t.Errorf("default parent weight = %v, want 0.2", got)
+	}
+	if got := (SearchOpts{ParentWeight: -1}).parentWeight(); got != 0 {
+		t.Errorf("negative parent weight = %v, want off", got)
+	}
+	if got := parentOf("mem://user"); got != "" {
+		t.Errorf("parentOf(owner root) = %q, want empty", got)
+	}
+}
+
+func TestBuildLinkScores(t *testing.T) {
+	db := testDB(t)
+	nodes := seedTestNodes(t, db)
+	// The case links to the preference it was solved with.
+	if _, err := db.LinkNodes("mem://agent/cases/sqlite-wal", "mem://user/preferences/sqlite", store.RelatedTo); err != nil {
+		t.Fatalf("LinkNodes: %v", err)
+	}
+
+	results := map[int64]SearchResult{
+		nodes[1].ID: {Node: *nodes[1], Similarity: 0.9},
+		nodes[4].ID: {Node: *nodes[4], Similarity: 0.3},
+		nodes[0].ID: {Node: *nodes[0], Similarity: 0.7},
+	}
+	scores := buildLinkScores(db, results)
+	if got := scores["mem://agent/cases/sqlite-wal"]; got != 0.9 {
+		t.Errorf("case link score = %v, want its linked preference's 0.9", got)
+	}
+	if got := scores["mem://user/preferences/sqlite"]; got != 0.3 {
+		t.Errorf("preference link score = %v, want 0.3 (links count both ways)", got)
+	}
+	if got, ok := scores["mem://user/profile/go-dev"]; ok {
+		t.Errorf("unlinked result scored %v", got)
+	}
+
+	if got := (SearchOpts{}).linkWeight(); got != 0.1 {
+		t.Errorf("default link weight = %v, want 0.1", got)
+	}
+	if got := (SearchOpts{LinkWeight: -1}).linkWeight(); got != 0 {
+		t.Errorf("negative link weight = %v, want off", got)
+	}
+}
+
+func TestFindSubQueriesMatchesSequential(t *testing.T) {
+	// The sub-queries run on separate connections, and a private ":memory:"
+	// database is empty on every connection but the first.
+	db, err := store.OpenMemoryShared(t.Name())
+	if err != nil {
+		t.Fatalf("OpenMemoryShared: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	nodes := seedTestNodes(t, db)
+	embedder, _ := NewHashEmbedder(0)
+	embedTestNodes(t, db, embedder, nodes)
+
+	ctx := context.Background()
+	subQueries := []subQuery{{Query: "Go developer"}, {Query: "SQLite WAL"}, {Query: "testing preferences"}}
+	opts := SearchOpts{Limit: 15}
+
+	want := make(map[int64]SearchResult)
+	for _, sq := range subQueries {
+		results, err := Find(ctx, db, embedder, sq.Query, opts)
+		if err != nil {
+			t.Fatalf("Find(%q): %v", sq.Query, err)
+		}
+		for _, r := range results {
+			if existing, ok := want[r.Node.ID]; !ok || r.Score > existing.Score {
+				want[r.Node.ID] = r
+			}
+		}
+	}
+
+	got := findSubQueries(ctx, db, embedder, subQueries, opts)
+	if len(got) != len(want) || len(got) == 0 {
+		t.Fatalf("concurrent found %d nodes, sequential %d", len(got), len(want))
+	}
+	for id, w := range want {
+		if g, ok := got[id]; !ok || g.Score != w.Score || g.Similarity != w.Similarity {
+			t.Errorf("node %d: concurrent %+v, sequential %+v", id, g.Score, w.Score)
+		}
+	}
+}
+
 func TestSearchFallsBackToFind(t *testing.T) {
 	db := testDB(t)
 	nodes := seedTestNodes(t, db)
@@ -247,6 +535,19 @@ func TestFindMomentsBoost(t *testing.T) {
 	}
 }
 
+func TestCheckSearchMinScore(t *testing.T) {
+	for _, x := range []float64{0, 0.35, 1} {
+		if err := CheckSearchMinScore(x); err != nil {
+			t.Errorf("CheckSearchMinScore(%v) = %v, want ok", x, err)
+		}
+	}
+	for _, x := range []float64{-0.1, 1.5, math.NaN(), math.Inf(1), math.Inf(-1)} {
+		if err := CheckSearchMinScore(x); err == nil {
+			t.Errorf("CheckSearchMinScore(%v) = nil, want an error", x)
+		}
+	}
+}
+
 func TestParseSubQueries(t *testing.T) {
 	tests := []struct {
 		input string
@@ -266,3 +567,104 @@ func TestParseSubQueries(t *testing.T) {
 		}
 	}
 }
+
+func TestSnippet(t *testing.T) {
+	l1 := "The user builds CLI tools in Go. They run SQLite in WAL mode for concurrent readers. Tests use table-driven style."
+	tests := []struct{ query, want string }{
+		{"sqlite wal", "They run SQLite in WAL mode for concurrent readers."},
+		{"testing", "Tests use table-driven style."}, // stemmed: testing ~ tests
+		{"kubernetes", l1}, // no overlap: opening of L1
+	}
+	for _, tt := range tests {
+		if got := Snippet(l1, tt.query); got != tt.want {
+			t.Errorf("Snippet(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	// A long matching sentence is clipped around the match.
+	long := strings.Repeat("filler words here ", 20) + "the deploy pipeline uses Buildkite " + strings.Repeat("and more trailing text ", 10)
+	got := Snippet(long, "buildkite")
+	if len(got) > snippetMaxChars+6 || !strings.Contains(got, "Buildkite") || !strings.HasPrefix(got, "...") || !strings.HasSuffix(got, "...") {
+		t.Errorf("clipped snippet = %q", got)
+	}
+}
+
+func TestHighlightTerms(t *testing.T) {
+	got := HighlightTerms("Queries hit the WAL file.", "query wal", "[", "]")
+	if want := "[Queries] hit the [WAL] file."; got != want {
+		t.Errorf("HighlightTerms = %q, want %q", got, want)
+	}
+}
+
+func TestFindPerCategoryGrouped(t *testing.T) {
+	db := testDB(t)
+	var nodes []*store.MemNode
+	for _, n := range []struct{ cat, slug string }{
+		{"preferences", "sqlite-a"}, {"preferences", "sqlite-b"}, {"preferences", "sqlite-c"},
+		{"cases", "sqlite-wal"}, {"entities", "sqlite"},
+	} {
+		node := &store.MemNode{URI: "mem://user/" + n.cat + "/" + n.slug, NodeType: "leaf", Category: n.cat,
+			L0Abstract: "SQLite usage notes " + n.slug}
+		if err := db.CreateNode(node); err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, node)
+	}
+	embedder, _ := NewHashEmbedder(0)
+	embedTestNodes(t, db, embedder, nodes)
+
+	results, err := Find(context.Background(), db, embedder, "sqlite usage", SearchOpts{PerCategory: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := GroupByCategory(results)
+	counts := map[string]int{}
+	for _, g := range groups {
+		counts[g.Category] = len(g.Results)
+		for i := 1; i < len(g.Results); i++ {
+			if g.Results[i].Score > g.Results[i-1].Score {
+				t.Errorf("%s: results out of rank order", g.Category)
+			}
+		}
+	}
+	if counts["preferences"] != 2 || counts["cases"] != 1 || counts["entities"] != 1 || len(groups) != 3 {
+		t.Errorf("per-category counts = %v, want preferences:2 cases:1 entities:1", counts)
+	}
+	if groups[0].Results[0].Score != results[0].Score {
+		t.Error("groups should be ordered by their best result")
+	}
+}
+
+func TestRerank(t *testing.T) {
+	results := []SearchResult{
+		{Node: store.MemNode{URI: "mem://a", L0Abstract: "a"}, Score: 0.9},
+		{Node: store.MemNode{URI: "mem://b", L0Abstract: "b"}, Score: 0.8},
+		{Node: store.MemNode{URI: "mem://c", L0Abstract: "c"}, Score: 0.7},
+	}
+	uris := func(rs []SearchResult) string {
+		var out []string
+		for _, r := range rs {
+			out = append(out, r.Node.URI)
+		}
+		return strings.Join(out, ",")
+	}
+
+	// Listed URIs lead in the model's order; unknown ones are ignored and
+	// unlisted ones keep their vector order after.
+	mock := &llm.MockClient{Response: &llm.Response{Content: "```json\n[\"mem://c\", \"mem://zzz\", \"mem://a\"]\n```"}}
+	if got := uris(rerank(context.Background(), mock, "q", results)); got != "mem://c,mem://a,mem://b" {
+		t.Errorf("rerank = %s", got)
+	}
+	if len(mock.Calls) != 1 || !strings.Contains(mock.Calls[0], "mem://b: b") {
+		t.Errorf("prompt should list every candidate: %v", mock.Calls)
+	}
+
+	for _, m := range []*llm.MockClient{
+		{Response: &llm.Response{Content: "I think c is best."}},
+		{Err: fmt.Errorf("down")},
+	} {
+		if got := uris(rerank(context.Background(), m, "q", results)); got != "mem://a,mem://b,mem://c" {
+			t.Errorf("fallback rerank = %s, want the original order", got)
+		}
+	}
+}
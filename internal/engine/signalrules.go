@@ -0,0 +1,107 @@
+package engine
+
+import "strings"
+
+// signalRule maps a trigger phrase to the category a verbatim capture files
+// under. Category "" means the configured signal_default_category. Strip says
+// whether the phrase itself is dropped from the captured text: "remember this:
+// X" means X, but "always use X" loses its point without the "always use".
+type signalRule struct {
+	Trigger  string
+	Category string
+	Strip    bool
+}
+
+// signalRules mirror the hook's trigger list (hooks.signalTriggers), in the
+// order they're tried.
+var signalRules = []signalRule{
+	{"remember this", "", true},
+	{"don't forget", "", true},
+	{"always use", "preferences", false},
+	{"never use", "preferences", false},
+	{"always do", "preferences", false},
+	{"never do", "preferences", false},
+	{"architecture decision", "events", false},
+	{"root cause was", "cases", false},
+	{"the fix was", "cases", false},
+}
+
+// signalHintWords bounds the URI hint derived from a verbatim capture.
+const signalHintWords = 6
+
+// ruleBasedSignal builds a memory candidate from a signal prompt without an
+// LLM: the text from (or after) the first trigger phrase becomes L0 and the
+// whole prompt L1. A non-empty category overrides the one the trigger implies;
+// defaultCategory stands in for triggers that imply none. Returns false when
+// the prompt has no trigger or nothing follows it.
+func ruleBasedSignal(prompt, category, defaultCategory string) (memoryCandidate, bool) {
+	prompt = strings.TrimSpace(prompt)
+	lower := strings.ToLower(prompt)
+
+	for _, r := range signalRules {
+		i := strings.Index(lower, r.Trigger)
+		if i < 0 {
+			continue
+		}
+		text := prompt[i:]
+		if r.Strip {
+			text = trimSignalLead(prompt[i+len(r.Trigger):])
+		}
+		text = firstSentence(text)
+		if text == "" {
+			return memoryCandidate{}, false
+		}
+
+		cat := category
+		if cat == "" {
+			cat = r.Category
+		}
+		if cat == "" {
+			cat = defaultCategory
+		}
+		if cat == "" {
+			cat = "preferences"
+		}
+		return memoryCandidate{
+			Category: cat,
+			URIHint:  signalHint(text),
+			L0:       text,
+			L1:       prompt,
+		}, true
+	}
+	return memoryCandidate{}, false
+}
+
+// trimSignalLead drops the glue between "remember this" and what's to be
+// remembered: a named category ("pattern:", "as a constraint"), punctuation,
+// and a leading "that".
+func trimSignalLead(s string) string {
+	if i := strings.IndexByte(s, ':'); i >= 0 && len(strings.Fields(s[:i])) <= 3 {
+		s = s[i+1:]
+	}
+	s = strings.TrimLeft(s, " \t\n,;:-—")
+	if f := strings.Fields(s); len(f) > 3 && strings.EqualFold(f[0], "as") && (strings.EqualFold(f[1], "a") || strings.EqualFold(f[1], "an")) {
+		s = strings.Join(f[3:], " ")
+	}
+	if strings.HasPrefix(strings.ToLower(s), "that ") {
+		s = s[len("that "):]
+	}
+	return strings.TrimSpace(s)
+}
+
+// firstSentence returns s up to its first sentence end or line break.
+func firstSentence(s string) string {
+	if sentences := splitSentences(s); len(sentences) > 0 {
+		return sentences[0]
+	}
+	return ""
+}
+
+// signalHint derives a URI hint from the first few words of text.
+func signalHint(text string) string {
+	words := strings.Fields(text)
+	if len(words) > signalHintWords {
+		words = words[:signalHintWords]
+	}
+	return sanitizeURIHint(strings.Join(words, " "))
+}
@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// snippetMaxChars bounds a result snippet, matching the prefix the CLI used
+// to print.
+const snippetMaxChars = 200
+
+// Snippet returns the part of text most relevant to query: the sentence
+// sharing the most distinct (stemmed, non-stopword) terms with it, trimmed to
+// snippetMaxChars around the first match. With no overlap — a purely semantic
+// hit — it falls back to the opening of text.
+func Snippet(text, query string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ""
+	}
+	terms := queryTerms(query)
+
+	best, bestHits := "", 0
+	for _, s := range splitSentences(text) {
+		if hits := countTermHits(s, terms); hits > bestHits {
+			best, bestHits = s, hits
+		}
+	}
+	if bestHits == 0 {
+		return clipSnippet(text, 0)
+	}
+	start := 0
+	if span, ok := firstTermSpan(best, terms); ok {
+		start = span[0]
+	}
+	return clipSnippet(best, start)
+}
+
+// HighlightTerms wraps each word of s that matches a query term in open and
+// closing markers (e.g. ANSI bold). Words are compared the way Snippet
+// compares them, so "queries" highlights for a query of "query".
+func HighlightTerms(s, query, open, close string) string {
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, span := range wordSpans(s) {
+		if _, ok := terms[stemToken(strings.ToLower(s[span[0]:span[1]]))]; !ok {
+			continue
+		}
+		b.WriteString(s[last:span[0]])
+		b.WriteString(open)
+		b.WriteString(s[span[0]:span[1]])
+		b.WriteString(close)
+		last = span[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// queryTerms is the set of stemmed query tokens worth matching on.
+func queryTerms(query string) map[string]struct{} {
+	terms := map[string]struct{}{}
+	for _, tok := range tokenize(query) {
+		if _, stop := lexicalStopwords[tok]; stop {
+			continue
+		}
+		terms[stemToken(tok)] = struct{}{}
+	}
+	return terms
+}
+
+// countTermHits counts the distinct query terms that occur in s.
+func countTermHits(s string, terms map[string]struct{}) int {
+	hit := map[string]bool{}
+	for _, tok := range tokenize(s) {
+		st := stemToken(tok)
+		if _, ok := terms[st]; ok {
+			hit[st] = true
+		}
+	}
+	return len(hit)
+}
+
+// firstTermSpan returns the byte span of the first word in s matching a term.
+func firstTermSpan(s string, terms map[string]struct{}) ([2]int, bool) {
+	for _, span := range wordSpans(s) {
+		if _, ok := terms[stemToken(strings.ToLower(s[span[0]:span[1]]))]; ok {
+			return span, true
+		}
+	}
+	return [2]int{}, false
+}
+
+// wordSpans returns the byte spans of s's alphanumeric runs — the same
+// boundaries tokenize uses.
+func wordSpans(s string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range s {
+		word := unicode.IsLetter(r) || unicode.IsNumber(r)
+		switch {
+		case word && start < 0:
+			start = i
+		case !word && start >= 0:
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(s)})
+	}
+	return spans
+}
+
+// splitSentences splits text at sentence ends and line breaks.
+func splitSentences(text string) []string {
+	var out []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		end := c == '\n' ||
+			((c == '.' || c == '!' || c == '?') && (i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\n'))
+		if !end {
+			continue
+		}
+		if s := strings.TrimSpace(text[start : i+1]); s != "" {
+			out = append(out, s)
+		}
+		start = i + 1
+	}
+	if s := strings.TrimSpace(text[start:]); s != "" {
+		out = append(out, s)
+	}
+	return out
+}
+
+// clipSnippet cuts s to snippetMaxChars, starting shortly before byte offset
+// at on a word boundary, with ellipses marking what was cut.
+func clipSnippet(s string, at int) string {
+	if len(s) <= snippetMaxChars {
+		return s
+	}
+	start := 0
+	if at > snippetMaxChars/4 {
+		start = at - snippetMaxChars/4
+		if i := strings.IndexByte(s[start:], ' '); i >= 0 && start+i < at {
+			start += i + 1
+		}
+		for !utf8.RuneStart(s[start]) {
+			start++
+		}
+	}
+	end := start + snippetMaxChars
+	if end >= len(s) {
+		end = len(s)
+	} else if i := strings.LastIndexByte(s[start:end], ' '); i > 0 {
+		end = start + i
+	} else {
+		for end > start && !utf8.RuneStart(s[end]) {
+			end--
+		}
+	}
+	out := strings.TrimSpace(s[start:end])
+	if start > 0 {
+		out = "..." + out
+	}
+	if end < len(s) {
+		out += "..."
+	}
+	return out
+}
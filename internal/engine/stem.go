@@ -0,0 +1,116 @@
+package engine
+
+import "strings"
+
+// stemToken is a light Porter-style stemmer: steps 1a–1c of Porter (1980),
+// which fold plurals and -ed/-ing inflections ("databases" → "database",
+// "running" → "run", "queries"/"query" → "queri"). The derivational steps
+// (2–5) are deliberately left out — they conflate words a lexical safety net
+// should keep apart ("general"/"generous") for little extra recall. Output is
+// a matching key, not a word. Tokens with non-ASCII letters or digits, and
+// tokens of three letters or fewer, pass through unchanged.
+func stemToken(w string) string {
+	if len(w) <= 3 {
+		return w
+	}
+	for i := 0; i < len(w); i++ {
+		if w[i] < 'a' || w[i] > 'z' {
+			return w
+		}
+	}
+
+	// Step 1a: plurals.
+	switch {
+	case strings.HasSuffix(w, "sses"):
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "ies"):
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "ss"):
+	case strings.HasSuffix(w, "s"):
+		w = w[:len(w)-1]
+	}
+
+	// Step 1b: -eed, -ed, -ing.
+	switch {
+	case strings.HasSuffix(w, "eed"):
+		if porterMeasure(w[:len(w)-3]) > 0 {
+			w = w[:len(w)-1]
+		}
+	case strings.HasSuffix(w, "ed") && porterHasVowel(w[:len(w)-2]):
+		w = porterStep1bTidy(w[:len(w)-2])
+	case strings.HasSuffix(w, "ing") && porterHasVowel(w[:len(w)-3]):
+		w = porterStep1bTidy(w[:len(w)-3])
+	}
+
+	// Step 1c: terminal y → i when the stem has a vowel.
+	if strings.HasSuffix(w, "y") && porterHasVowel(w[:len(w)-1]) {
+		w = w[:len(w)-1] + "i"
+	}
+	return w
+}
+
+// porterStep1bTidy repairs a stem after -ed/-ing removal: restore a dropped
+// "e" (conflat(ed) → conflate, hop(ing) → hope) and undouble a final
+// consonant (hopp(ing) → hop), except l/s/z (fall, hiss, fizz).
+func porterStep1bTidy(w string) string {
+	switch {
+	case strings.HasSuffix(w, "at"), strings.HasSuffix(w, "bl"), strings.HasSuffix(w, "iz"):
+		return w + "e"
+	case len(w) >= 2 && w[len(w)-1] == w[len(w)-2] && porterConsonant(w, len(w)-1):
+		if c := w[len(w)-1]; c != 'l' && c != 's' && c != 'z' {
+			return w[:len(w)-1]
+		}
+	case porterMeasure(w) == 1 && porterCVC(w):
+		return w + "e"
+	}
+	return w
+}
+
+// porterConsonant reports whether w[i] is a consonant in Porter's sense:
+// not a vowel, and y only when it follows a vowel.
+func porterConsonant(w string, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !porterConsonant(w, i-1)
+	}
+	return true
+}
+
+// porterMeasure counts the VC sequences in w ([C](VC)^m[V]).
+func porterMeasure(w string) int {
+	m := 0
+	inVowel := false
+	for i := 0; i < len(w); i++ {
+		if porterConsonant(w, i) {
+			if inVowel {
+				m++
+			}
+			inVowel = false
+		} else {
+			inVowel = true
+		}
+	}
+	return m
+}
+
+func porterHasVowel(w string) bool {
+	for i := 0; i < len(w); i++ {
+		if !porterConsonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// porterCVC reports whether w ends consonant-vowel-consonant with the final
+// consonant not w, x, or y (hop, but not snow or box).
+func porterCVC(w string) bool {
+	n := len(w)
+	if n < 3 || !porterConsonant(w, n-1) || porterConsonant(w, n-2) || !porterConsonant(w, n-3) {
+		return false
+	}
+	c := w[n-1]
+	return c != 'w' && c != 'x' && c != 'y'
+}
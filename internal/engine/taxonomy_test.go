@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"context"
 	"testing"
 
 	"github.com/lazypower/continuity/internal/store"
@@ -19,6 +20,7 @@ func TestOwnerForCategory(t *testing.T) {
 		{"entities", "user"},
 		{"events", "user"},
 		{"reference", "user"},
+		{"constraints", "user"},
 		{"moments", "user"},
 		{"patterns", "agent"},
 		{"cases", "agent"},
@@ -43,6 +45,7 @@ func TestMergeableCategory(t *testing.T) {
 		{"preferences", true},
 		{"patterns", true},
 		{"feedback", true},
+		{"constraints", true},
 		{"entities", false},
 		{"events", false},
 		{"cases", false},
@@ -64,14 +67,25 @@ func TestValidCategoriesContainsAll(t *testing.T) {
 	required := []string{
 		"profile", "preferences", "entities", "events",
 		"patterns", "cases", "moments", "feedback", "reference",
+		"constraints",
 	}
 	for _, c := range required {
 		if !validCategories[c] {
 			t.Errorf("validCategories missing %q", c)
 		}
 	}
-	if validCategories["session"] {
-		t.Error("validCategories must not accept 'session' — it's a sentinel, not a writable category")
+	// session notes are written by extraction alone: never forced on a
+	// signal, never a direct write.
+	if !validCategories["session"] {
+		t.Error("validCategories must accept 'session' for extracted session notes")
+	}
+	if SignalCategory("session") {
+		t.Error("a signal must not be forced into 'session'")
+	}
+	if _, _, err := New(testDB(t), nil).Remember(context.Background(), RememberInput{
+		Category: "session", Name: "x", Summary: "x", Body: "x", SessionID: "s1",
+	}); err == nil {
+		t.Error("Remember must refuse 'session'")
 	}
 	if validCategories["bogus"] {
 		t.Error("validCategories accepted 'bogus'")
@@ -112,3 +112,85 @@ func truncateClean(s string, maxLen int) string {
 	}
 	return strings.TrimSpace(truncated)
 }
+
+// categoryDescriptions paraphrase the extraction prompt's category glosses. An
+// L0 whose every content word already appears in its category's description
+// says nothing beyond "this is a <category>" and is rejected as generic.
+var categoryDescriptions = map[string]string{
+	"profile":     "who the user is identity skills non-negotiable preferences developer engineer",
+	"preferences": "user preferences tools workflows changeable choices configurational settings",
+	"feedback":    "directional guidance the user gave about how to approach work corrections confirmations",
+	"constraints": "things the user explicitly said not to do never stop avoid",
+	"entities":    "people projects services that will be referenced again",
+	"events":      "significant decisions or milestones",
+	"patterns":    "reusable techniques the user has validated",
+	"cases":       "non-obvious problem solution pairs worth remembering",
+	"reference":   "pointers to external systems dashboards team rituals where information lives",
+	"moments":     "meaningful moments shared with the user",
+}
+
+// scoreSpecificity rejects LLM-extracted candidates that are too generic to be
+// worth injecting, even though they passed validateCandidate. The extraction
+// prompt already forbids vague observations; this is the floor for when the
+// model emits them anyway. Checks, in order:
+//   - L0 contains a configured generic phrase ("is experienced", "writes tests")
+//   - L1 is shorter than L0 (the overview adds nothing beyond the abstract)
+//   - L0 is a near-restatement of its category description
+//
+// Applied only on the LLM paths (session extraction, signals). Direct writes via
+// Remember are explicit intent and are not second-guessed.
+func scoreSpecificity(c memoryCandidate, genericPhrases []string) error {
+	l0 := strings.ToLower(c.L0)
+	for _, p := range genericPhrases {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p != "" && strings.Contains(l0, p) {
+			return validationErrorf("generic L0 (matches %q)", p)
+		}
+	}
+
+	if len(c.L1) < len(c.L0) {
+		return validationErrorf("L1 shorter than L0 (%d < %d chars) — overview adds nothing", len(c.L1), len(c.L0))
+	}
+
+	if desc, ok := categoryDescriptions[c.Category]; ok {
+		descWords := make(map[string]bool)
+		for _, w := range specificityWords(desc) {
+			descWords[w] = true
+		}
+		words := specificityWords(l0)
+		covered := 0
+		for _, w := range words {
+			if descWords[w] {
+				covered++
+			}
+		}
+		if len(words) > 0 && covered == len(words) {
+			return validationErrorf("L0 restates the %s category description", c.Category)
+		}
+	}
+
+	return nil
+}
+
+// specificityFiller are words that carry no specificity of their own. "user" is
+// here because every memory is about the user.
+var specificityFiller = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "about": true,
+	"that": true, "has": true, "are": true, "user": true, "users": true,
+}
+
+// specificityWords lowercases s and returns its words longer than two letters,
+// minus specificityFiller.
+func specificityWords(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var out []string
+	for _, f := range fields {
+		if len(f) <= 2 || specificityFiller[f] {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
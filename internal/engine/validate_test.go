@@ -3,6 +3,8 @@ package engine
 import (
 	"strings"
 	"testing"
+
+	"github.com/lazypower/continuity/internal/config"
 )
 
 func TestSanitizeURIHint(t *testing.T) {
@@ -205,3 +207,65 @@ func TestTruncateClean(t *testing.T) {
 		t.Error("truncated result has trailing space")
 	}
 }
+
+func TestScoreSpecificity(t *testing.T) {
+	phrases := config.Default().Engine.GenericPhrases
+	tests := []struct {
+		name    string
+		c       memoryCandidate
+		wantErr string
+	}{
+		{
+			name: "specific passes",
+			c: memoryCandidate{Category: "preferences", L0: "Uses modernc.org/sqlite to avoid CGO in release builds",
+				L1: "Chose modernc.org/sqlite over mattn/go-sqlite3 so goreleaser can cross-compile without a C toolchain."},
+		},
+		{
+			name: "banned phrase",
+			c: memoryCandidate{Category: "profile", L0: "User is experienced with Go",
+				L1: "The user has written a lot of Go and knows the language well."},
+			wantErr: "generic L0",
+		},
+		{
+			name: "banned phrase case-insensitive",
+			c: memoryCandidate{Category: "patterns", L0: "Always Writes Tests before merging",
+				L1: "Runs the suite locally before opening a pull request on any repo."},
+			wantErr: "generic L0",
+		},
+		{
+			name: "L1 shorter than L0",
+			c: memoryCandidate{Category: "cases", L0: "SQLite busy errors under concurrent hook writes fixed by busy_timeout pragma",
+				L1: "Set busy_timeout=5000."},
+			wantErr: "L1 shorter than L0",
+		},
+		{
+			name: "restates category",
+			c: memoryCandidate{Category: "preferences", L0: "User preferences for tools and workflows",
+				L1: "The user has preferences about which tools and workflows are used."},
+			wantErr: "restates the preferences category",
+		},
+	}
+	for _, tt := range tests {
+		err := scoreSpecificity(tt.c, phrases)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: err = %v, want containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestScoreSpecificityCustomPhrases(t *testing.T) {
+	c := memoryCandidate{Category: "profile", L0: "Deeply passionate about software craftsmanship",
+		L1: "Talks about craftsmanship a lot in reviews and planning discussions."}
+	if err := scoreSpecificity(c, nil); err != nil {
+		t.Fatalf("no phrases configured: unexpected error %v", err)
+	}
+	if err := scoreSpecificity(c, []string{"passionate about"}); err == nil {
+		t.Error("expected configured phrase to reject candidate")
+	}
+}
@@ -18,12 +18,18 @@ import (
 // classifies via IsValidationError.
 type ValidationError struct {
 	Message string
+
+	err error // store error this re-wraps, if any; keeps store.ErrNotFound etc. visible
 }
 
 func (e *ValidationError) Error() string {
 	return e.Message
 }
 
+func (e *ValidationError) Unwrap() error {
+	return e.err
+}
+
 // validationErrorf constructs a *ValidationError with a formatted message.
 func validationErrorf(format string, args ...any) error {
 	return &ValidationError{Message: fmt.Sprintf(format, args...)}
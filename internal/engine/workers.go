@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"errors"
+	"log"
+	"sync"
+)
+
+// ErrQueueFull is returned by Enqueue when every worker is busy and the
+// backlog is at capacity. Callers should shed the work (HTTP 503) rather than
+// spawn it anyway — the point of the pool is that a burst of hook re-fires
+// can't launch dozens of concurrent LLM calls.
+var ErrQueueFull = errors.New("extraction queue full")
+
+// Default pool sizing, used when EngineConfig leaves Workers/QueueSize unset.
+const (
+	defaultWorkers   = 2
+	defaultQueueSize = 32
+)
+
+// workPool is a fixed set of workers draining a bounded job channel. Workers
+// start on first use so SetConfig (called after New) can still size the pool.
+type workPool struct {
+	once sync.Once
+	jobs chan func()
+}
+
+// Enqueue schedules job on the background worker pool without blocking. At
+// most cfg.Workers jobs run at once and up to cfg.QueueSize more wait; beyond
+// that Enqueue returns ErrQueueFull. Jobs still queued when the engine stops
+// are dropped — extraction is re-triggerable, so nothing is lost for good.
+func (e *Engine) Enqueue(job func()) error {
+	e.pool.once.Do(e.startWorkers)
+	select {
+	case e.pool.jobs <- job:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (e *Engine) startWorkers() {
+	workers := e.cfg.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	queue := e.cfg.QueueSize
+	if queue <= 0 {
+		queue = defaultQueueSize
+	}
+	e.pool.jobs = make(chan func(), queue)
+	for i := 0; i < workers; i++ {
+		go e.worker()
+	}
+}
+
+func (e *Engine) worker() {
+	for {
+		select {
+		case job := <-e.pool.jobs:
+			runJob(job)
+		case <-e.stopCh:
+			return
+		}
+	}
+}
+
+// runJob isolates a panicking job so it can't take a worker down with it.
+func runJob(job func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("background job panicked: %v", r)
+		}
+	}()
+	job()
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lazypower/continuity/internal/config"
+)
+
+func TestEnqueueBoundsConcurrency(t *testing.T) {
+	db := testDB(t)
+	eng := New(db, nil)
+	defer eng.Stop()
+	cfg := config.Default().Engine
+	cfg.Workers = 1
+	cfg.QueueSize = 1
+	eng.SetConfig(cfg)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	// Occupies the single worker.
+	if err := eng.Enqueue(func() {
+		defer wg.Done()
+		close(started)
+		<-release
+	}); err != nil {
+		t.Fatalf("first Enqueue: %v", err)
+	}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker never picked up the first job")
+	}
+
+	// Waits in the queue.
+	if err := eng.Enqueue(func() { wg.Done() }); err != nil {
+		t.Fatalf("second Enqueue should queue: %v", err)
+	}
+
+	// Worker busy, queue full: shed.
+	if err := eng.Enqueue(func() { t.Error("shed job must not run") }); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("third Enqueue err = %v, want ErrQueueFull", err)
+	}
+
+	close(release)
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued job never ran")
+	}
+}
+
+func TestEnqueueSurvivesPanickingJob(t *testing.T) {
+	db := testDB(t)
+	eng := New(db, nil)
+	defer eng.Stop()
+	cfg := config.Default().Engine
+	cfg.Workers = 1
+	eng.SetConfig(cfg)
+
+	if err := eng.Enqueue(func() { panic("boom") }); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	ran := make(chan struct{})
+	if err := eng.Enqueue(func() { close(ran) }); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker did not survive a panicking job")
+	}
+}
@@ -2,10 +2,12 @@ package hooks
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -16,6 +18,9 @@ const (
 	httpTimeout = 5 * time.Second
 )
 
+// envTimeout overrides httpTimeout for every request a Client makes.
+const envTimeout = "CONTINUITY_TIMEOUT"
+
 // Client talks to the continuity server.
 type Client struct {
 	http      *http.Client
@@ -47,14 +52,47 @@ func ResolveServerURL() string {
 	return fmt.Sprintf("http://%s:%s", bind, port)
 }
 
-// NewClient creates a new hook HTTP client targeting ResolveServerURL().
+// ResolveTimeout returns the per-request timeout: CONTINUITY_TIMEOUT as a Go
+// duration ("30s") or a bare number of seconds ("30"), else httpTimeout. The
+// default is tight because hooks block the editor; a server busy extracting
+// can need longer. Unparseable or non-positive values fall back to the
+// default rather than failing a hook.
+func ResolveTimeout() time.Duration {
+	raw := strings.TrimSpace(os.Getenv(envTimeout))
+	if raw == "" {
+		return httpTimeout
+	}
+	if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+		return d
+	}
+	if n, err := strconv.ParseFloat(raw, 64); err == nil && n > 0 {
+		return time.Duration(n * float64(time.Second))
+	}
+	return httpTimeout
+}
+
+// NewClient creates a new hook HTTP client targeting ResolveServerURL(). The
+// client owns a keep-alive transport, so handlers that make several calls
+// (submit's init then signal, start's health check then context) reuse one
+// connection instead of dialing per request.
 func NewClient() *Client {
 	return &Client{
-		http:      &http.Client{Timeout: httpTimeout},
+		http: &http.Client{
+			Timeout: ResolveTimeout(),
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				MaxIdleConnsPerHost: 2,
+				IdleConnTimeout:     30 * time.Second,
+			},
+		},
 		serverURL: ResolveServerURL(),
 	}
 }
 
+// SetTimeout replaces the per-request timeout, for the few calls (a VACUUM,
+// say) that legitimately run far longer than a hook may.
+func (c *Client) SetTimeout(d time.Duration) { c.http.Timeout = d }
+
 // ServerURL returns the resolved base URL this client targets.
 func (c *Client) ServerURL() string { return c.serverURL }
 
@@ -94,12 +132,70 @@ func (c *Client) Get(path string) ([]byte, error) {
 	return data, nil
 }
 
+// GetConditional sends a GET with If-None-Match: etag (when non-empty). On a
+// 304 it returns notModified with no body; otherwise the body and the
+// response's ETag, if any.
+func (c *Client) GetConditional(path, etag string) (data []byte, newETag string, notModified bool, err error) {
+	req, err := http.NewRequest(http.MethodGet, c.serverURL+path, nil)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("GET %s: %w", path, err)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("GET %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, etag, true, nil
+	}
+
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("read response %s: %w", path, err)
+	}
+	if resp.StatusCode >= 400 {
+		return data, "", false, fmt.Errorf("GET %s: status %d: %s", path, resp.StatusCode, data)
+	}
+	return data, resp.Header.Get("ETag"), false, nil
+}
+
+// Ready checks the server's readiness probe. A live server can still be
+// warming up (embedding its backlog), in which case searches are incomplete;
+// reason says why. A server that predates /api/ready (404) counts as ready —
+// it has no way to say otherwise. Unreachable is not ready.
+func (c *Client) Ready() (bool, string) {
+	resp, err := c.http.Get(c.serverURL + "/api/ready")
+	if err != nil {
+		return false, "server unreachable"
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNotFound:
+		return true, ""
+	}
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	io.Copy(io.Discard, resp.Body)
+	if body.Reason == "" {
+		body.Reason = fmt.Sprintf("status %d", resp.StatusCode)
+	}
+	return false, body.Reason
+}
+
 // Healthy checks if the server is reachable.
 func (c *Client) Healthy() bool {
 	resp, err := c.http.Get(c.serverURL + "/api/health")
 	if err != nil {
 		return false
 	}
+	// Drain before closing so the connection goes back to the pool for the
+	// handler's next request.
+	io.Copy(io.Discard, resp.Body)
 	resp.Body.Close()
 	return resp.StatusCode == http.StatusOK
 }
@@ -3,7 +3,7 @@ package hooks
 import "encoding/json"
 
 func handleEnd(client *Client, input *HookInput) {
-	if _, err := client.Post("/api/sessions/"+input.SessionID+"/end", nil); err != nil {
+	if _, err := client.Post(sessionPath(input, "end"), nil); err != nil {
 		ExitError(err)
 		return
 	}
@@ -18,6 +18,6 @@ func handleEnd(client *Client, input *HookInput) {
 		body, _ := json.Marshal(map[string]string{
 			"transcript_path": input.TranscriptPath,
 		})
-		client.Post("/api/sessions/"+input.SessionID+"/extract", body)
+		client.Post(sessionPath(input, "extract"), body)
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
 )
 
 const maxHookInputSize = 10 << 20 // 10MB
@@ -63,3 +64,14 @@ func Handle(event string, stdin io.Reader) {
 		ExitError(fmt.Errorf("unknown hook event: %s", event))
 	}
 }
+
+// sessionPath is the API path of one of input's session endpoints. It carries
+// the working directory as ?project= so that, should the session ID also be
+// known in an unrelated project, the server addresses this project's session.
+func sessionPath(input *HookInput, action string) string {
+	path := "/api/sessions/" + input.SessionID + "/" + action
+	if input.CWD != "" {
+		path += "?project=" + url.QueryEscape(input.CWD)
+	}
+	return path
+}
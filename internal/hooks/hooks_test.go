@@ -7,8 +7,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // captureStdout replaces os.Stdout with a pipe, runs fn, then returns what was written.
@@ -92,6 +94,88 @@ func TestHandleStartWithServer(t *testing.T) {
 	}
 }
 
+func TestHandleStartReusesCachedContext(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	const etag = `W/"v1"`
+	var full, notModified int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		json.NewEncoder(w).Encode(map[string]string{"context": "<context>cached block</context>"})
+	}))
+	defer ts.Close()
+
+	client := &Client{http: ts.Client(), serverURL: ts.URL}
+	input := &HookInput{SessionID: "s1", HookEventName: "SessionStart"}
+
+	for i := 0; i < 2; i++ {
+		out := captureStdout(t, func() { handleStart(client, input) })
+		if !strings.Contains(out, "cached block") {
+			t.Errorf("start %d: output missing context: %s", i, out)
+		}
+	}
+	if full != 1 || notModified != 1 {
+		t.Errorf("full = %d, notModified = %d; want one of each", full, notModified)
+	}
+}
+
+func TestHandleStartCachesPerSession(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var full, notModified int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sess := r.URL.Query().Get("session_id")
+		etag := `W/"` + sess + `"`
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		json.NewEncoder(w).Encode(map[string]string{"context": "<context>block for " + sess + "</context>"})
+	}))
+	defer ts.Close()
+	client := &Client{http: ts.Client(), serverURL: ts.URL}
+
+	// Two sessions start side by side, then the first resumes: its cached
+	// block must still be there, not overwritten by the second's.
+	for _, id := range []string{"s1", "s2", "s1"} {
+		out := captureStdout(t, func() { handleStart(client, &HookInput{SessionID: id, HookEventName: "SessionStart"}) })
+		if !strings.Contains(out, "block for "+id) {
+			t.Errorf("%s: output = %s", id, out)
+		}
+	}
+	if full != 2 || notModified != 1 {
+		t.Errorf("full = %d, notModified = %d; want 2 and 1", full, notModified)
+	}
+}
+
+func TestPruneContextCache(t *testing.T) {
+	dir := t.TempDir()
+	old, fresh := filepath.Join(dir, "old.json"), filepath.Join(dir, "fresh.json")
+	for _, p := range []string{old, fresh} {
+		if err := os.WriteFile(p, []byte("{}"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stale := time.Now().Add(-2 * contextCacheMaxAge)
+	if err := os.Chtimes(old, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+	pruneContextCache(dir, time.Now().Add(-contextCacheMaxAge))
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("stale entry survived the sweep")
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Errorf("fresh entry removed: %v", err)
+	}
+}
+
 func TestHandleStartEmptyOnServerDown(t *testing.T) {
 	// Point at unreachable port so Healthy() returns false
 	t.Setenv("CONTINUITY_URL", "http://127.0.0.1:1")
@@ -136,6 +220,11 @@ func TestSkipTools(t *testing.T) {
 	if !input.ShouldSkipTool() {
 		t.Error("expected Thinking to be skipped")
 	}
+
+	input.ToolName = "Read"
+	if input.ShouldSkipTool() {
+		t.Error("expected Read to be recorded by default")
+	}
 }
 
 func TestHookInputParsing(t *testing.T) {
@@ -267,6 +356,28 @@ func TestHasSignal(t *testing.T) {
 	}
 }
 
+func TestSignalCategory(t *testing.T) {
+	tests := []struct {
+		prompt string
+		want   string
+	}{
+		{"remember this pattern: retry with backoff", "patterns"},
+		{"Remember this constraint: no CGO", "constraints"},
+		{"remember this as a preference - tabs over spaces", "preferences"},
+		{"remember this as an event: we moved to Postgres", "events"},
+		{"remember this rule: squash before merge", "constraints"},
+		{"remember this: always use WAL mode", ""},
+		{"remember this case we hit yesterday", ""},
+		{"remember this banana: yellow", ""},
+		{"always use devbox", ""},
+	}
+	for _, tt := range tests {
+		if got := signalCategory(tt.prompt); got != tt.want {
+			t.Errorf("signalCategory(%q) = %q, want %q", tt.prompt, got, tt.want)
+		}
+	}
+}
+
 func TestHandleSubmitSignalDetection(t *testing.T) {
 	var signalReceived bool
 
@@ -350,3 +461,29 @@ func TestClientPostAndGet(t *testing.T) {
 		t.Errorf("GET result = %q, want ok", result["result"])
 	}
 }
+
+func TestClientReady(t *testing.T) {
+	status, reason := http.StatusServiceUnavailable, "embedding backfill in progress"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]any{"ready": status == http.StatusOK, "reason": reason})
+	}))
+	defer ts.Close()
+	t.Setenv("CONTINUITY_URL", ts.URL)
+	client := NewClient()
+
+	if ok, got := client.Ready(); ok || got != reason {
+		t.Errorf("Ready() = %v, %q; want false, %q", ok, got, reason)
+	}
+
+	// A server that predates /api/ready must not be reported as warming up.
+	status = http.StatusNotFound
+	if ok, _ := client.Ready(); !ok {
+		t.Error("404 from an older server should count as ready")
+	}
+
+	status, reason = http.StatusOK, ""
+	if ok, _ := client.Ready(); !ok {
+		t.Error("200 should be ready")
+	}
+}
@@ -1,6 +1,10 @@
 package hooks
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/lazypower/continuity/internal/config"
+)
 
 // HookInput represents the JSON that Claude Code sends on stdin to hook handlers.
 // All fields are optional — different events populate different subsets.
@@ -31,18 +35,10 @@ type HookInput struct {
 	Reason string `json:"reason,omitempty"`
 }
 
-// skipTools are meta-tools that generate noise, not useful observations.
-var skipTools = map[string]bool{
-	"TodoRead":  true,
-	"TodoWrite": true,
-	"Thinking":  true,
-	"TaskList":     true,
-	"TaskCreate":   true,
-	"TaskGet":      true,
-	"TaskUpdate":   true,
-}
-
-// ShouldSkipTool returns true if this tool should not be recorded as an observation.
+// ShouldSkipTool returns true if this tool is a built-in meta-tool, never
+// worth posting as an observation. Tools the user lists under [hooks]
+// skip_tools are refused by the server, which has the config loaded, so the
+// hook doesn't read it on every tool call.
 func (h *HookInput) ShouldSkipTool() bool {
-	return skipTools[h.ToolName]
+	return config.SkipTool(h.ToolName, nil)
 }
@@ -1,6 +1,12 @@
 package hooks
 
-import "testing"
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
 
 func TestResolveServerURL(t *testing.T) {
 	tests := []struct {
@@ -65,3 +71,53 @@ func TestNewClientUsesResolver(t *testing.T) {
 		t.Errorf("NewClient targeted %q, want http://127.0.0.1:45454", c.ServerURL())
 	}
 }
+
+func TestResolveTimeout(t *testing.T) {
+	tests := []struct {
+		env  string
+		want time.Duration
+	}{
+		{"", httpTimeout},
+		{"30s", 30 * time.Second},
+		{"1m30s", 90 * time.Second},
+		{"12", 12 * time.Second},
+		{"2.5", 2500 * time.Millisecond},
+		{"  45s ", 45 * time.Second},
+		{"0", httpTimeout},
+		{"-3s", httpTimeout},
+		{"soon", httpTimeout},
+	}
+	for _, tt := range tests {
+		t.Setenv("CONTINUITY_TIMEOUT", tt.env)
+		if got := ResolveTimeout(); got != tt.want {
+			t.Errorf("ResolveTimeout(%q) = %v, want %v", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestNewClientReusesConnection(t *testing.T) {
+	var mu sync.Mutex
+	conns := map[string]bool{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		conns[r.RemoteAddr] = true
+		mu.Unlock()
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+	t.Setenv("CONTINUITY_URL", srv.URL)
+
+	c := NewClient()
+	if !c.Healthy() {
+		t.Fatal("server not healthy")
+	}
+	if _, err := c.Post("/api/sessions/init", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Post("/api/sessions/s/signal", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if len(conns) != 1 {
+		t.Errorf("3 requests used %d connections, want 1 (keep-alive)", len(conns))
+	}
+}
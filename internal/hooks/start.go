@@ -1,8 +1,14 @@
 package hooks
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 func handleStart(client *Client, input *HookInput) {
@@ -11,13 +17,40 @@ func handleStart(client *Client, input *HookInput) {
 	if input.SessionID != "" {
 		params.Set("session_id", input.SessionID)
 	}
+	// CONTINUITY_CONTEXT_CATEGORIES scopes the ranked memories to the work at
+	// hand, e.g. "patterns,cases" for a debugging session. The server drops
+	// names it doesn't know, so a typo narrows nothing rather than failing.
+	if cats := strings.TrimSpace(os.Getenv("CONTINUITY_CONTEXT_CATEGORIES")); cats != "" {
+		params.Set("categories", cats)
+	}
+	// The project lets the server size the block by the last session there
+	// (context.light_session_observations); CONTINUITY_CONTEXT_WEIGHT=light
+	// or full overrides that guess. Any other value is ignored rather than
+	// costing the session its context.
+	if input.CWD != "" {
+		params.Set("project", input.CWD)
+	}
+	if weight := strings.ToLower(strings.TrimSpace(os.Getenv("CONTINUITY_CONTEXT_WEIGHT"))); weight == "light" || weight == "full" {
+		params.Set("weight", weight)
+	}
 
-	data, err := client.Get("/api/context?" + params.Encode())
+	// A resumed or compacted session usually finds nothing changed; send the
+	// block it was last given's ETag so the server can answer 304 instead of
+	// rebuilding it. Tags are per session, so the cache is too: a new session
+	// has nothing cached and always gets a full block, and sessions starting
+	// side by side don't overwrite each other's.
+	cacheKey := contextCacheKey(input.SessionID, input.CWD)
+	cached := readContextCache(cacheKey, client.ServerURL())
+	data, etag, notModified, err := client.GetConditional("/api/context?"+params.Encode(), cached.ETag)
 	if err != nil {
 		// Degrade gracefully — return empty context
 		WriteSessionStartOutput("")
 		return
 	}
+	if notModified {
+		WriteSessionStartOutput(cached.Context)
+		return
+	}
 
 	var resp struct {
 		Context string `json:"context"`
@@ -27,5 +60,108 @@ func handleStart(client *Client, input *HookInput) {
 		return
 	}
 
+	if etag != "" {
+		writeContextCache(cacheKey, contextCache{ServerURL: client.ServerURL(), ETag: etag, Context: resp.Context})
+	}
 	WriteSessionStartOutput(resp.Context)
 }
+
+// contextCache is the last context block SessionStart injected into one
+// session, kept under ~/.continuity/context-cache/ for conditional GETs.
+type contextCache struct {
+	ServerURL string `json:"server_url"`
+	ETag      string `json:"etag"`
+	Context   string `json:"context"`
+}
+
+// contextCacheMaxAge is how long a session's cached block is kept after it
+// was last written. A session resumed later than that just gets a full block.
+const contextCacheMaxAge = 7 * 24 * time.Hour
+
+// contextCacheKey names the cache file for a session in a project. Empty when
+// there is no session ID, which turns caching off.
+func contextCacheKey(sessionID, project string) string {
+	if sessionID == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(sessionID + "\x00" + project))
+	return hex.EncodeToString(sum[:16])
+}
+
+func contextCacheDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".continuity", "context-cache"), nil
+}
+
+// readContextCache returns the cached block for key on serverURL, or a zero
+// value (no ETag, so an unconditional GET) when there is none or it belongs
+// to a different server.
+func readContextCache(key, serverURL string) contextCache {
+	if key == "" {
+		return contextCache{}
+	}
+	dir, err := contextCacheDir()
+	if err != nil {
+		return contextCache{}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, key+".json"))
+	if err != nil {
+		return contextCache{}
+	}
+	var c contextCache
+	if json.Unmarshal(data, &c) != nil || c.ServerURL != serverURL {
+		return contextCache{}
+	}
+	return c
+}
+
+// writeContextCache best-effort persists c under key and sweeps entries past
+// contextCacheMaxAge; a failure only costs the session's next SessionStart a
+// full rebuild.
+func writeContextCache(key string, c contextCache) {
+	if key == "" {
+		return
+	}
+	dir, err := contextCacheDir()
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return
+	}
+	path := filepath.Join(dir, key+".json")
+	tmp, err := os.CreateTemp(dir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(data)
+	if cerr := tmp.Close(); werr != nil || cerr != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	pruneContextCache(dir, time.Now().Add(-contextCacheMaxAge))
+}
+
+// pruneContextCache removes cache entries last written before cutoff.
+func pruneContextCache(dir string, cutoff time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && info.ModTime().Before(cutoff) {
+			os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
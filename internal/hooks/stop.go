@@ -3,11 +3,12 @@ package hooks
 import (
 	"encoding/json"
 
+	"github.com/lazypower/continuity/internal/config"
 	"github.com/lazypower/continuity/internal/transcript"
 )
 
 func handleStop(client *Client, input *HookInput) {
-	if _, err := client.Post("/api/sessions/"+input.SessionID+"/complete", nil); err != nil {
+	if _, err := client.Post(sessionPath(input, "complete"), nil); err != nil {
 		ExitError(err)
 		return
 	}
@@ -21,22 +22,25 @@ func handleStop(client *Client, input *HookInput) {
 			"transcript_path": input.TranscriptPath,
 		})
 		// Fire and forget — extraction is async (202 Accepted)
-		client.Post("/api/sessions/"+input.SessionID+"/extract", body)
+		client.Post(sessionPath(input, "extract"), body)
 	}
 }
 
 // shouldExtract mirrors engine.hasEnoughContent so Stop can skip the HTTP
-// call on turns that wouldn't pass the server-side gate anyway.
+// call on turns that wouldn't pass the server-side gate anyway. The hook
+// process has no server config, so it applies the default thresholds; a
+// server configured lower still gets a chance at SessionEnd.
 func shouldExtract(transcriptPath string) bool {
 	entries, err := transcript.ParseFile(transcriptPath)
 	if err != nil {
 		// If we can't read the transcript, let SessionEnd sort it out.
 		return false
 	}
-	if transcript.CountUserMessages(entries) < 3 {
+	gate := config.Default().Engine
+	if transcript.CountUserMessages(entries) < gate.MinUserMessages {
 		return false
 	}
-	if len(transcript.Condense(entries)) < 100 {
+	if len(transcript.Condense(entries)) < gate.MinCondensedChars {
 		return false
 	}
 	return true
@@ -2,6 +2,7 @@ package hooks
 
 import (
 	"encoding/json"
+	"regexp"
 	"strings"
 )
 
@@ -43,6 +44,47 @@ func hasSignal(prompt string) bool {
 	return false
 }
 
+// signalCategoryRe catches a signal that names its own category: "remember
+// this pattern: ..." or "remember this as a constraint". The bare-word form
+// needs the colon so "remember this case we hit" doesn't force a category.
+var signalCategoryRe = regexp.MustCompile(`remember this (?:as (?:an? )?([a-z]+)|([a-z]+)\s*:)`)
+
+// signalCategoryWords maps what users call a category to the category itself.
+var signalCategoryWords = map[string]string{
+	"preference":  "preferences",
+	"preferences": "preferences",
+	"constraint":  "constraints",
+	"constraints": "constraints",
+	"rule":        "constraints",
+	"pattern":     "patterns",
+	"patterns":    "patterns",
+	"case":        "cases",
+	"cases":       "cases",
+	"event":       "events",
+	"events":      "events",
+	"decision":    "events",
+	"entity":      "entities",
+	"entities":    "entities",
+	"feedback":    "feedback",
+	"reference":   "reference",
+	"references":  "reference",
+	"profile":     "profile",
+}
+
+// signalCategory returns the category a signal prompt asks for, or "" to let
+// the server decide.
+func signalCategory(prompt string) string {
+	m := signalCategoryRe.FindStringSubmatch(strings.ToLower(prompt))
+	if m == nil {
+		return ""
+	}
+	word := m[1]
+	if word == "" {
+		word = m[2]
+	}
+	return signalCategoryWords[word]
+}
+
 func handleSubmit(client *Client, input *HookInput) {
 	// Guard: skip prompts from Continuity's own LLM calls to prevent recursion.
 	// When the server calls claude -p for extraction, that spawns a new session
@@ -53,8 +95,9 @@ func handleSubmit(client *Client, input *HookInput) {
 
 	// Initialize/resume session on first user prompt
 	body, err := json.Marshal(map[string]string{
-		"session_id": input.SessionID,
-		"project":    input.CWD,
+		"session_id":      input.SessionID,
+		"project":         input.CWD,
+		"transcript_path": input.TranscriptPath,
 	})
 	if err != nil {
 		ExitError(err)
@@ -68,13 +111,15 @@ func handleSubmit(client *Client, input *HookInput) {
 
 	// Check for signal keywords — fire and forget
 	if input.Prompt != "" && hasSignal(input.Prompt) {
-		signalBody, err := json.Marshal(map[string]string{
-			"prompt": input.Prompt,
-		})
+		signal := map[string]string{"prompt": input.Prompt}
+		if cat := signalCategory(input.Prompt); cat != "" {
+			signal["category"] = cat
+		}
+		signalBody, err := json.Marshal(signal)
 		if err != nil {
 			return // non-critical, don't block
 		}
 		// POST to signal endpoint — ignore errors (async on server side)
-		client.Post("/api/sessions/"+input.SessionID+"/signal", signalBody)
+		client.Post(sessionPath(input, "signal"), signalBody)
 	}
 }
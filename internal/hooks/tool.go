@@ -1,6 +1,8 @@
 package hooks
 
-import "encoding/json"
+import (
+	"encoding/json"
+)
 
 func handleTool(client *Client, input *HookInput) {
 	if input.ShouldSkipTool() {
@@ -11,7 +13,10 @@ func handleTool(client *Client, input *HookInput) {
 	toolInput := string(input.ToolInput)
 	toolResponse := string(input.ToolResponse)
 
+	// tool_use_id lets the server drop a retried POST whose first attempt
+	// landed but whose response didn't make it back.
 	body, err := json.Marshal(map[string]string{
+		"tool_use_id":   input.ToolUseID,
 		"tool_name":     input.ToolName,
 		"tool_input":    toolInput,
 		"tool_response": toolResponse,
@@ -21,7 +26,7 @@ func handleTool(client *Client, input *HookInput) {
 		return
 	}
 
-	if _, err := client.Post("/api/sessions/"+input.SessionID+"/observations", body); err != nil {
+	if _, err := client.Post(sessionPath(input, "observations"), body); err != nil {
 		ExitError(err)
 		return
 	}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"time"
 )
@@ -14,26 +15,37 @@ const anthropicAPI = "https://api.anthropic.com/v1/messages"
 
 // Anthropic calls the Anthropic Messages API directly.
 type Anthropic struct {
-	apiKey  string
-	model   string
-	client  *http.Client
+	url         string // anthropicAPI; overridden in tests
+	apiKey      string
+	model       string
+	maxTokens   int
+	temperature float64
+	client      *http.Client
 }
 
-// NewAnthropic creates a new Anthropic API client.
-func NewAnthropic(apiKey, model string) *Anthropic {
+// NewAnthropic creates a new Anthropic API client. maxTokens <= 0 uses
+// DefaultMaxTokens.
+func NewAnthropic(apiKey, model string, maxTokens int, temperature float64) *Anthropic {
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
 	return &Anthropic{
-		apiKey: apiKey,
-		model:  model,
-		client: &http.Client{Timeout: 120 * time.Second},
+		url:         anthropicAPI,
+		apiKey:      apiKey,
+		model:       model,
+		maxTokens:   maxTokens,
+		temperature: temperature,
+		client:      &http.Client{Timeout: 120 * time.Second},
 	}
 }
 
 // Complete sends a prompt to the Anthropic API.
 func (a *Anthropic) Complete(ctx context.Context, prompt string) (*Response, error) {
+	maxTokens, temperature := resolveGeneration(ctx, a.maxTokens, a.temperature)
 	reqBody := map[string]any{
 		"model":       a.model,
-		"max_tokens":  2048,
-		"temperature": 0.3,
+		"max_tokens":  maxTokens,
+		"temperature": temperature,
 		"messages": []map[string]string{
 			{"role": "user", "content": prompt},
 		},
@@ -44,7 +56,7 @@ func (a *Anthropic) Complete(ctx context.Context, prompt string) (*Response, err
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", anthropicAPI, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", a.url, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -63,8 +75,9 @@ func (a *Anthropic) Complete(ctx context.Context, prompt string) (*Response, err
 		return nil, fmt.Errorf("read response: %w", err)
 	}
 
+	reqID := requestID(resp.Header)
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("anthropic api status %d: %s", resp.StatusCode, respBody)
+		return nil, fmt.Errorf("anthropic api status %d%s: %s", resp.StatusCode, requestIDSuffix(reqID), respBody)
 	}
 
 	var result struct {
@@ -85,9 +98,13 @@ func (a *Anthropic) Complete(ctx context.Context, prompt string) (*Response, err
 		text = result.Content[0].Text
 	}
 
+	log.Printf("llm: anthropic %s: %d input + %d output tokens%s",
+		a.model, result.Usage.InputTokens, result.Usage.OutputTokens, requestIDSuffix(reqID))
+
 	return &Response{
 		Content:    text,
 		Provider:   "anthropic",
 		TokensUsed: result.Usage.InputTokens + result.Usage.OutputTokens,
+		RequestID:  reqID,
 	}, nil
 }
@@ -3,7 +3,9 @@ package llm
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -13,24 +15,41 @@ import (
 
 // ClaudeCLI calls the Claude CLI (`claude -p`) as a subprocess.
 type ClaudeCLI struct {
-	model   string
-	timeout time.Duration
+	binary       string
+	model        string
+	systemPrompt string
+	timeout      time.Duration
 }
 
-// NewClaudeCLI creates a new Claude CLI client.
-func NewClaudeCLI(model string) *ClaudeCLI {
+// NewClaudeCLI creates a new Claude CLI client running binary ("" means
+// "claude" on $PATH). A non-empty systemPrompt is passed as --system-prompt.
+func NewClaudeCLI(binary, model, systemPrompt string) *ClaudeCLI {
+	if binary == "" {
+		binary = "claude"
+	}
 	return &ClaudeCLI{
-		model:   model,
-		timeout: 120 * time.Second,
+		binary:       binary,
+		model:        model,
+		systemPrompt: systemPrompt,
+		timeout:      120 * time.Second,
 	}
 }
 
+// args returns the flags for one non-interactive, single-turn call.
+func (c *ClaudeCLI) args() []string {
+	args := []string{"-p", "--model", c.model, "--max-turns", "1"}
+	if c.systemPrompt != "" {
+		args = append(args, "--system-prompt", c.systemPrompt)
+	}
+	return args
+}
+
 // Complete sends a prompt to the Claude CLI and returns the response.
 func (c *ClaudeCLI) Complete(ctx context.Context, prompt string) (*Response, error) {
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "claude", "-p", "--model", c.model, "--max-turns", "1")
+	cmd := exec.CommandContext(ctx, c.binary, c.args()...)
 	cmd.Stdin = strings.NewReader(prompt)
 
 	// Pin the subprocess to a dedicated empty directory. Without this, claude -p
@@ -47,6 +66,9 @@ func (c *ClaudeCLI) Complete(ctx context.Context, prompt string) (*Response, err
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+			return nil, fmt.Errorf("claude cli: cannot run %q: %w (set claude_binary under [llm] to the claude executable)", c.binary, err)
+		}
 		return nil, fmt.Errorf("claude cli: %w (stderr: %s)", err, stderr.String())
 	}
 
@@ -81,3 +103,17 @@ func filterEnv(env []string) []string {
 	}
 	return filtered
 }
+
+// resolveExecutable expands a leading ~ in path to the home directory and
+// resolves it like the shell would: a bare name is looked up on $PATH, a path
+// with a separator must itself be an executable regular file.
+func resolveExecutable(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("get home dir: %w", err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return exec.LookPath(path)
+}
@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os/exec"
 
 	"github.com/lazypower/continuity/internal/config"
@@ -18,6 +19,7 @@ type Response struct {
 	Content    string
 	Provider   string
 	TokensUsed int
+	RequestID  string // provider's request ID for support tickets; "" when not sent
 }
 
 // NewClient creates an LLM client based on the config provider setting.
@@ -28,7 +30,15 @@ func NewClient(cfg config.LLMConfig) (Client, error) {
 		if model == "" {
 			model = "haiku"
 		}
-		return NewClaudeCLI(model), nil
+		binary := cfg.ClaudeBinary
+		if binary != "" {
+			resolved, err := resolveExecutable(binary)
+			if err != nil {
+				return nil, fmt.Errorf("claude_binary: %w", err)
+			}
+			binary = resolved
+		}
+		return NewClaudeCLI(binary, model, cfg.ClaudeSystemPrompt), nil
 	case "anthropic":
 		if cfg.AnthropicKey == "" {
 			return nil, fmt.Errorf("anthropic provider requires ANTHROPIC_API_KEY or config")
@@ -37,7 +47,7 @@ func NewClient(cfg config.LLMConfig) (Client, error) {
 		if model == "" {
 			model = "claude-haiku-4-5-20251001"
 		}
-		return NewAnthropic(cfg.AnthropicKey, model), nil
+		return NewAnthropic(cfg.AnthropicKey, model, cfg.MaxTokens, cfg.Temperature), nil
 	case "ollama":
 		url := cfg.OllamaURL
 		if url == "" {
@@ -47,25 +57,57 @@ func NewClient(cfg config.LLMConfig) (Client, error) {
 		if model == "" {
 			model = "llama3.2"
 		}
-		return NewOllama(url, model), nil
+		return NewOllama(url, model, cfg.MaxTokens, cfg.Temperature), nil
 	default:
 		return nil, fmt.Errorf("unknown LLM provider: %q", cfg.Provider)
 	}
 }
 
+// NewMergeClient creates the client used for merge decisions: the configured
+// provider, but with cfg.MergeModel in place of cfg.Model. Ollama has a single
+// model setting, so it merges with ollama_model. For the anthropic provider,
+// merge_model must be a full model ID, not a claude CLI alias like "sonnet".
+func NewMergeClient(cfg config.LLMConfig) (Client, error) {
+	if cfg.MergeModel != "" {
+		cfg.Model = cfg.MergeModel
+	}
+	return NewClient(cfg)
+}
+
 // ProviderBinaryUnresolved reports the external CLI binary a provider needs when
 // that binary is NOT resolvable on the current $PATH, or "" when the provider
 // needs no external binary (or its binary is present). It lets serve print one
 // clear startup warning instead of a per-extraction failure buried in the log —
 // the common service-managed case where launchd/systemd lacks the login PATH
 // (issue #41). Providers that don't shell out (anthropic, ollama-over-HTTP)
-// return "".
+// return "". An explicit claude_binary is resolved as given.
 func ProviderBinaryUnresolved(cfg config.LLMConfig) string {
 	if cfg.Provider != "claude-cli" {
 		return ""
 	}
-	if _, err := exec.LookPath("claude"); err != nil {
-		return "claude"
+	bin := cfg.ClaudeBinary
+	if bin == "" {
+		bin = "claude"
+	}
+	if _, err := exec.LookPath(bin); err != nil {
+		return bin
 	}
 	return ""
 }
+
+// requestID returns the provider's request ID from a response: Anthropic sends
+// request-id, OpenAI-compatible servers and most proxies x-request-id.
+func requestID(h http.Header) string {
+	if id := h.Get("request-id"); id != "" {
+		return id
+	}
+	return h.Get("x-request-id")
+}
+
+// requestIDSuffix formats id for an error or log line, or "" when there is none.
+func requestIDSuffix(id string) string {
+	if id == "" {
+		return ""
+	}
+	return " (request-id " + id + ")"
+}
@@ -2,6 +2,11 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -19,6 +24,56 @@ func TestNewClientClaudeCLI(t *testing.T) {
 	}
 }
 
+func TestClaudeCLIBinaryAndFlags(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "fake-claude")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho \"$@\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(config.LLMConfig{Provider: "claude-cli", Model: "sonnet", ClaudeBinary: bin, ClaudeSystemPrompt: "Be terse."})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	resp, err := client.Complete(context.Background(), "p")
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if want := "-p --model sonnet --max-turns 1 --system-prompt Be terse."; resp.Content != want {
+		t.Errorf("args = %q, want %q", resp.Content, want)
+	}
+
+	notExec := filepath.Join(dir, "claude.txt")
+	if err := os.WriteFile(notExec, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{notExec, filepath.Join(dir, "missing"), dir} {
+		if _, err := NewClient(config.LLMConfig{Provider: "claude-cli", ClaudeBinary: path}); err == nil || !strings.Contains(err.Error(), "claude_binary") {
+			t.Errorf("claude_binary %s: err = %v, want a claude_binary error", path, err)
+		}
+	}
+}
+
+func TestClaudeCLIBinaryResolution(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "fake-claude"), []byte("#!/bin/sh\necho ok\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv("HOME", dir)
+	for _, binary := range []string{"fake-claude", "~/fake-claude"} {
+		client, err := NewClient(config.LLMConfig{Provider: "claude-cli", ClaudeBinary: binary})
+		if err != nil {
+			t.Fatalf("claude_binary %s: NewClient: %v", binary, err)
+		}
+		if got, want := client.(*ClaudeCLI).binary, filepath.Join(dir, "fake-claude"); got != want {
+			t.Errorf("claude_binary %s: binary = %q, want %q", binary, got, want)
+		}
+	}
+	if _, err := NewClient(config.LLMConfig{Provider: "claude-cli", ClaudeBinary: "no-such-claude"}); err == nil {
+		t.Error("bare name missing from $PATH: want a claude_binary error")
+	}
+}
+
 func TestNewClientAnthropic(t *testing.T) {
 	cfg := config.LLMConfig{Provider: "anthropic", AnthropicKey: "test-key", Model: "claude-haiku-4-5-20251001"}
 	client, err := NewClient(cfg)
@@ -80,10 +135,11 @@ func TestExtractionPromptsHaveSentinel(t *testing.T) {
 		name   string
 		prompt string
 	}{
-		{"ExtractionPrompt", ExtractionPrompt("some transcript")},
-		{"RelationalPrompt", RelationalPrompt("", "some transcript")},
-		{"SignalExtractionPrompt", SignalExtractionPrompt("remember this")},
+		{"ExtractionPrompt", ExtractionPrompt("some transcript", "")},
+		{"RelationalPrompt", RelationalPrompt("", "some transcript", "")},
+		{"SignalExtractionPrompt", SignalExtractionPrompt("remember this", "", "", "")},
 		{"SearchIntentPrompt", SearchIntentPrompt("find something")},
+		{"MergePrompt", MergePrompt("old overview", "new overview")},
 	}
 
 	for _, tt := range tests {
@@ -96,6 +152,79 @@ func TestExtractionPromptsHaveSentinel(t *testing.T) {
 	}
 }
 
+func TestPromptLanguage(t *testing.T) {
+	for _, lang := range []string{"", "English", "en"} {
+		if got, want := ExtractionPrompt("t", lang), ExtractionPrompt("t", ""); got != want {
+			t.Errorf("language %q should leave the extraction prompt unchanged", lang)
+		}
+	}
+
+	prompts := map[string]string{
+		"extraction": ExtractionPrompt("t", "German"),
+		"relational": RelationalPrompt("", "t", "German"),
+		"signal":     SignalExtractionPrompt("t", "German", "preferences", ""),
+	}
+	for name, p := range prompts {
+		if !strings.Contains(p, "in German") || !strings.Contains(p, "do not translate") {
+			t.Errorf("%s prompt missing the German language rule", name)
+		}
+		if strings.Contains(p, "%!") {
+			t.Errorf("%s prompt has a formatting error", name)
+		}
+	}
+}
+
+func TestSignalPromptCategory(t *testing.T) {
+	if p := SignalExtractionPrompt("t", "", "", ""); strings.Contains(p, "category MUST") || strings.Contains(p, "standing rule") {
+		t.Error("no default or forced category should add no category rule")
+	}
+	if p := SignalExtractionPrompt("t", "", "preferences", ""); !strings.Contains(p, `use "preferences"`) {
+		t.Error("default category should be offered for ambiguous signals")
+	}
+	p := SignalExtractionPrompt("t", "", "preferences", "patterns")
+	if !strings.Contains(p, `category MUST be "patterns"`) || strings.Contains(p, `use "preferences"`) {
+		t.Error("a forced category should replace the default nudge")
+	}
+}
+
+func TestExtractionTemplate(t *testing.T) {
+	write := func(body string) string {
+		path := filepath.Join(t.TempDir(), "prompt.tmpl")
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tmpl, err := LoadExtractionTemplate(write("Extract at most {{.MaxCandidates}} memories from:\n{{.Transcript}}"))
+	if err != nil {
+		t.Fatalf("LoadExtractionTemplate: %v", err)
+	}
+	got, err := tmpl.Render("USER: hi", "", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := InternalSentinel + " Extract at most 3 memories from:\nUSER: hi"; got != want {
+		t.Errorf("Render = %q, want %q", got, want)
+	}
+
+	// A template that already leads with the sentinel doesn't get it twice.
+	tmpl, _ = LoadExtractionTemplate(write(InternalSentinel + " {{.Transcript}}"))
+	if got, _ := tmpl.Render("x", "", 3); strings.Count(got, InternalSentinel) != 1 {
+		t.Errorf("sentinel duplicated: %q", got)
+	}
+
+	for name, body := range map[string]string{
+		"syntax error":  "{{.Transcript",
+		"unknown field": "{{.Transcript}} {{.Budget}}",
+		"no transcript": "Extract memories.",
+	} {
+		if _, err := LoadExtractionTemplate(write(body)); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+}
+
 func TestMockClient(t *testing.T) {
 	mock := &MockClient{
 		Response: &Response{Content: "test response", Provider: "mock"},
@@ -115,3 +244,74 @@ func TestMockClient(t *testing.T) {
 		t.Errorf("call[0] = %q, want %q", mock.Calls[0], "test prompt")
 	}
 }
+
+func TestOllamaGenerationParams(t *testing.T) {
+	var got struct {
+		Options struct {
+			Temperature float64 `json:"temperature"`
+			NumPredict  int     `json:"num_predict"`
+		} `json:"options"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Write([]byte(`{"response":"ok"}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(config.LLMConfig{Provider: "ollama", OllamaURL: srv.URL, MaxTokens: 4096, Temperature: 0})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	if _, err := client.Complete(context.Background(), "p"); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if got.Options.NumPredict != 4096 || got.Options.Temperature != 0 {
+		t.Errorf("configured params = %+v, want num_predict 4096, temperature 0", got.Options)
+	}
+
+	temp := 0.7
+	ctx := WithCallOptions(context.Background(), CallOptions{MaxTokens: 256, Temperature: &temp})
+	if _, err := client.Complete(ctx, "p"); err != nil {
+		t.Fatalf("Complete with override: %v", err)
+	}
+	if got.Options.NumPredict != 256 || got.Options.Temperature != 0.7 {
+		t.Errorf("override params = %+v, want num_predict 256, temperature 0.7", got.Options)
+	}
+}
+
+func TestAnthropicRequestID(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("request-id", "req_123")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"content":[{"text":"ok"}],"usage":{"input_tokens":3,"output_tokens":2}}`))
+	}))
+	defer srv.Close()
+
+	a := NewAnthropic("k", "m", 0, 0.3)
+	a.url = srv.URL
+
+	resp, err := a.Complete(context.Background(), "p")
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if resp.RequestID != "req_123" || resp.TokensUsed != 5 {
+		t.Errorf("response = %+v, want request ID req_123 and 5 tokens", resp)
+	}
+
+	status = http.StatusTooManyRequests
+	_, err = a.Complete(context.Background(), "p")
+	if err == nil || !strings.Contains(err.Error(), "request-id req_123") {
+		t.Errorf("error = %v, want it to carry the request ID", err)
+	}
+}
+
+func TestNewAnthropicDefaultsMaxTokens(t *testing.T) {
+	a := NewAnthropic("k", "m", 0, 0.3)
+	if a.maxTokens != DefaultMaxTokens {
+		t.Errorf("maxTokens = %d, want %d", a.maxTokens, DefaultMaxTokens)
+	}
+}
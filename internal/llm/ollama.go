@@ -12,29 +12,37 @@ import (
 
 // Ollama calls a local Ollama instance.
 type Ollama struct {
-	url    string
-	model  string
-	client *http.Client
+	url         string
+	model       string
+	maxTokens   int
+	temperature float64
+	client      *http.Client
 }
 
-// NewOllama creates a new Ollama client.
-func NewOllama(url, model string) *Ollama {
+// NewOllama creates a new Ollama client. maxTokens <= 0 uses DefaultMaxTokens.
+func NewOllama(url, model string, maxTokens int, temperature float64) *Ollama {
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
 	return &Ollama{
-		url:    url,
-		model:  model,
-		client: &http.Client{Timeout: 120 * time.Second},
+		url:         url,
+		model:       model,
+		maxTokens:   maxTokens,
+		temperature: temperature,
+		client:      &http.Client{Timeout: 120 * time.Second},
 	}
 }
 
 // Complete sends a prompt to Ollama's generate endpoint.
 func (o *Ollama) Complete(ctx context.Context, prompt string) (*Response, error) {
+	maxTokens, temperature := resolveGeneration(ctx, o.maxTokens, o.temperature)
 	reqBody := map[string]any{
 		"model":  o.model,
 		"prompt": prompt,
 		"stream": false,
 		"options": map[string]any{
-			"temperature": 0.3,
-			"num_predict": 2048,
+			"temperature": temperature,
+			"num_predict": maxTokens,
 		},
 	}
 
@@ -61,7 +69,7 @@ func (o *Ollama) Complete(ctx context.Context, prompt string) (*Response, error)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("ollama api status %d: %s", resp.StatusCode, respBody)
+		return nil, fmt.Errorf("ollama api status %d%s: %s", resp.StatusCode, requestIDSuffix(requestID(resp.Header)), respBody)
 	}
 
 	var result struct {
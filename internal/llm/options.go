@@ -0,0 +1,37 @@
+package llm
+
+import "context"
+
+// DefaultMaxTokens is used when the config leaves MaxTokens unset. There is
+// no temperature counterpart: 0 is a valid setting, so config.Default()
+// carries the default instead.
+const DefaultMaxTokens = 2048
+
+// CallOptions overrides generation parameters for a single Complete call.
+// Zero fields fall back to the client's configured values.
+type CallOptions struct {
+	MaxTokens   int
+	Temperature *float64
+}
+
+type callOptionsKey struct{}
+
+// WithCallOptions returns a context carrying per-call generation overrides.
+// Clients that control generation parameters (Anthropic, Ollama) honor them;
+// the claude CLI has no such flags and ignores them.
+func WithCallOptions(ctx context.Context, opts CallOptions) context.Context {
+	return context.WithValue(ctx, callOptionsKey{}, opts)
+}
+
+// resolveGeneration merges any per-call override in ctx over the client's
+// configured max tokens and temperature.
+func resolveGeneration(ctx context.Context, maxTokens int, temperature float64) (int, float64) {
+	opts, _ := ctx.Value(callOptionsKey{}).(CallOptions)
+	if opts.MaxTokens > 0 {
+		maxTokens = opts.MaxTokens
+	}
+	if opts.Temperature != nil {
+		temperature = *opts.Temperature
+	}
+	return maxTokens, temperature
+}
@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// ExtractionTemplateData is what a custom extraction prompt template sees.
+type ExtractionTemplateData struct {
+	Transcript    string // condensed session transcript
+	MaxCandidates int    // memories kept per session; extras are dropped
+	Language      string // configured memory language ("" means English)
+}
+
+// ExtractionTemplate is a user-supplied replacement for ExtractionPrompt,
+// written as a Go text/template over ExtractionTemplateData.
+type ExtractionTemplate struct {
+	tmpl *template.Template
+}
+
+// transcriptProbe stands in for the transcript when validating a template.
+const transcriptProbe = "\x00continuity-transcript-probe\x00"
+
+// LoadExtractionTemplate reads and validates the template at path. Beyond
+// parsing, it renders the template once against sample data — so a reference
+// to a field that doesn't exist fails here, not mid-extraction — and rejects a
+// template that never includes {{.Transcript}}, which would extract from
+// nothing.
+func LoadExtractionTemplate(path string) (*ExtractionTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read extraction prompt: %w", err)
+	}
+	tmpl, err := template.New("extraction").Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("parse extraction prompt %s: %w", path, err)
+	}
+	t := &ExtractionTemplate{tmpl: tmpl}
+	out, err := t.Render(transcriptProbe, "", 3)
+	if err != nil {
+		return nil, fmt.Errorf("extraction prompt %s: %w", path, err)
+	}
+	if !strings.Contains(out, transcriptProbe) {
+		return nil, fmt.Errorf("extraction prompt %s never uses {{.Transcript}}", path)
+	}
+	return t, nil
+}
+
+// Render executes the template. The internal sentinel is always prepended —
+// a template can't drop it, and with it the hook's recursion guard.
+func (t *ExtractionTemplate) Render(condensed, language string, maxCandidates int) (string, error) {
+	var buf bytes.Buffer
+	err := t.tmpl.Execute(&buf, ExtractionTemplateData{
+		Transcript:    condensed,
+		MaxCandidates: maxCandidates,
+		Language:      language,
+	})
+	if err != nil {
+		return "", fmt.Errorf("render extraction prompt: %w", err)
+	}
+	body := strings.TrimPrefix(strings.TrimSpace(buf.String()), InternalSentinel)
+	return InternalSentinel + " " + strings.TrimSpace(body), nil
+}
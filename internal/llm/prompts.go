@@ -1,6 +1,9 @@
 package llm
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // InternalSentinel is prefixed to all prompts sent by Continuity's extraction engine.
 // The hook handler checks for this prefix to skip internal prompts and prevent
@@ -9,8 +12,22 @@ import "fmt"
 // Must match hooks.internalSentinel exactly.
 const InternalSentinel = "[continuity-internal]"
 
-// ExtractionPrompt generates the prompt for memory extraction from a session transcript.
-func ExtractionPrompt(condensed string) string {
+// languageRule is the rules-list line asking the model to write `what` in
+// language while leaving `keep` (the parts the parser relies on) in English.
+// Empty for English — the prompts' native language — so the default prompts
+// are unchanged.
+func languageRule(language, what, keep string) string {
+	l := strings.TrimSpace(language)
+	if l == "" || strings.EqualFold(l, "english") || strings.EqualFold(l, "en") {
+		return ""
+	}
+	return fmt.Sprintf("- Write %s in %s. Preserve the user's own language and wording — do not translate what they said. %s stay in English.\n", what, l, keep)
+}
+
+// ExtractionPrompt generates the prompt for memory extraction from a session
+// transcript. language (e.g. "German") is the language memory content is
+// written in; empty means English.
+func ExtractionPrompt(condensed, language string) string {
 	return fmt.Sprintf(`%s You are a memory extraction system. Analyze this session transcript and extract ONLY high-signal memories that would cause the agent to make mistakes or miss context without them.
 
 TRANSCRIPT:
@@ -20,6 +37,7 @@ Categories:
 - profile: Who the user IS — identity, skills, non-negotiable preferences (e.g., "Senior Go developer, requires spec-first workflow")
 - preferences: Tools, workflows, changeable choices, configurational settings (e.g., "Uses devbox for all development", "use sonnet for eval analysis")
 - feedback: Directional guidance the user gave about HOW TO APPROACH WORK — corrections AND confirmations, with a why grounded in incident or stance. L1 MUST be shaped as: "<rule>. Why: <reason/incident>. How to apply: <when this kicks in>". (e.g., "Don't say 'locked the door' in release notes — say 'dialing in on security'", "Yeah, the bundled PR was the right call here")
+- constraints: Things the user explicitly said NEVER to do, or to stop doing — hard prohibitions, not advice. L0 states the prohibition directly (e.g., "Never add explanatory comments to code", "Do not push to main without asking")
 - entities: People, projects, services that will be referenced again (e.g., "Fiona: companion AI agent at /Users/chuck/Code/habitat/")
 - events: Significant decisions or milestones (e.g., "Deployed v2.1 to production") — NOT routine coding actions
 - patterns: Reusable techniques the user has validated (e.g., "Embed Svelte SPA via go:embed for single-binary distribution")
@@ -27,12 +45,13 @@ Categories:
 - reference: Pointers to external systems, dashboards, team rituals where information lives (e.g., "Pipeline bugs are tracked in Linear project INGEST", "Oncall watches grafana.internal/d/api-latency")
 
 feedback vs preferences vs patterns — the boundary rule:
+- "never/stop doing X" as a hard rule → constraints
 - "do/don't X (behavior)" + a why → feedback
 - "X is always set to Y (configurational knob/setting)" → preferences
 - "the technique for X is Y (transferable knowledge)" → patterns
 
 URI scheme: mem://{owner}/{category}/{slug}
-- owner is "user" for profile, preferences, feedback, entities, events, reference
+- owner is "user" for profile, preferences, feedback, constraints, entities, events, reference
 - owner is "agent" for patterns, cases
 
 BUDGET: Maximum 3 memories per session. Most sessions produce 0-1.
@@ -54,22 +73,28 @@ Rules:
 - l0: One sentence, MAXIMUM 200 CHARACTERS. Injected into every session — brevity is critical. Specific enough to deduplicate against.
 - l1: Structured overview, MAXIMUM 2000 CHARACTERS (~300 words). Concrete and actionable. This is the primary context injection tier — compress aggressively.
 - l2: Full content with all context, MAXIMUM 40000 CHARACTERS. Only retrieved on-demand.
-- Return ONLY a JSON array, no other text
+- entity: ONLY for the entities category, also give structured fields — type (one lowercase word: person, project, service, tool, repository, organization, or other), name (canonical name), location (path or URL, "" if none), aliases (other names used for it, may be empty). Omit "entity" for every other category.
+- mergeable: optional. Omit it to follow the category. Set true only for an events memory that is one evolving record, false only for a preference to keep as a point-in-time record.
+- related_to: optional. URIs (mem://{owner}/{category}/{slug}) of other memories in this reply that this one relates to — e.g. a case and the pattern it validated. Omit it when there are none.
+%s- Return ONLY a JSON array, no other text
 
 Return a JSON array:
 [{
-  "category": "profile|preferences|feedback|entities|events|patterns|cases|reference",
+  "category": "profile|preferences|feedback|constraints|entities|events|patterns|cases|reference",
   "uri_hint": "slug-name",
   "l0": "single sentence abstract",
   "l1": "structured overview (for feedback: <rule>. Why: <reason>. How to apply: <when>.)",
-  "l2": "full content"
+  "l2": "full content",
+  "entity": {"type": "service", "name": "canonical name", "location": "path or URL", "aliases": ["other name"]}
 }]
 
-If nothing meets the extraction bar, return: []`, InternalSentinel, condensed)
+If nothing meets the extraction bar, return: []`, InternalSentinel, condensed,
+		languageRule(language, "l0, l1, and l2", "JSON keys, category names, and uri_hint slugs (lowercase ASCII)"))
 }
 
 // RelationalPrompt generates the prompt for relational profile extraction.
-func RelationalPrompt(existing, condensed string) string {
+// language is as for ExtractionPrompt.
+func RelationalPrompt(existing, condensed, language string) string {
 	profileContext := "This is the first session — no existing profile."
 	if existing != "" {
 		profileContext = fmt.Sprintf("EXISTING PROFILE:\n%s", existing)
@@ -109,14 +134,20 @@ Rules:
 - BAD: "The user has a collaborative style and gives feedback casually"
 - GOOD: "Gives feedback as collaborative questions ('wanna do it?') rather than directives. Praises specific results ('That tree is exactly what I had in mind'). Corrects mistakes as questions ('did we hallucinate...?'), not blame."
 - Merge with existing profile: keep observations that are still accurate, add new ones from this session, drop anything contradicted by new evidence
-- If this session adds no new relational signal, return "NO_UPDATE"
+%s- If this session adds no new relational signal, return "NO_UPDATE"
 
-Return the profile as structured text with the 4 section headers.`, InternalSentinel, profileContext, condensed)
+Return the profile as structured text with the 4 section headers.`, InternalSentinel, profileContext, condensed,
+		languageRule(language, "the profile", "The 4 section headers and NO_UPDATE"))
 }
 
 // SignalExtractionPrompt generates the prompt for extracting a memory from a user-flagged signal.
 // This is simpler than full session extraction — the user has explicitly asked for something to be remembered.
-func SignalExtractionPrompt(prompt string) string {
+// language is as for ExtractionPrompt.
+//
+// forceCategory, when the user named one ("remember this pattern: ..."), is the
+// category the memory must take; otherwise defaultCategory (may be empty) is
+// where an ambiguous signal should land.
+func SignalExtractionPrompt(prompt, language, defaultCategory, forceCategory string) string {
 	return fmt.Sprintf(`%s The user has explicitly flagged something to remember. Extract ONE structured memory from their message.
 
 USER MESSAGE:
@@ -126,6 +157,7 @@ Categorize into one of:
 - profile: User identity, skills, coding style
 - preferences: Tools, workflows, changeable configurational choices (e.g., "always use devbox")
 - feedback: Directional guidance the user gave about HOW to approach work, with a why (corrections + confirmations). L1 MUST be shaped as: "<rule>. Why: <reason>. How to apply: <when this kicks in>".
+- constraints: Things the user explicitly said never to do or to stop doing — hard prohibitions. L0 states the prohibition directly (e.g., "Never add explanatory comments")
 - entities: People, projects, services
 - events: Decisions, deployments, actions
 - patterns: Reusable techniques, transferable solutions
@@ -133,12 +165,13 @@ Categorize into one of:
 - reference: Pointers to external systems, dashboards, team rituals (e.g., "bugs tracked in Linear project X")
 
 feedback vs preferences vs patterns — the boundary:
+- "never/stop doing X" as a hard rule → constraints
 - "do/don't X (behavior)" + a why → feedback
 - "X is always set to Y (setting)" → preferences
 - "the technique for X is Y (knowledge)" → patterns
 
 URI scheme: mem://{owner}/{category}/{slug}
-- owner is "user" for profile, preferences, feedback, entities, events, reference
+- owner is "user" for profile, preferences, feedback, constraints, entities, events, reference
 - owner is "agent" for patterns, cases
 
 Rules:
@@ -146,16 +179,160 @@ Rules:
 - l0: One sentence, MAXIMUM 200 CHARACTERS. Injected into every session — brevity is critical.
 - l1: Structured overview, MAXIMUM 2000 CHARACTERS (~300 words). Concrete and actionable. Compress aggressively.
 - l2: Full content with all context, MAXIMUM 40000 CHARACTERS. Only retrieved on-demand.
-- Return ONLY a JSON array with one element, no other text
+- entity: ONLY for the entities category, also give structured fields — type (one lowercase word: person, project, service, tool, repository, organization, or other), name (canonical name), location (path or URL, "" if none), aliases (other names used for it, may be empty). Omit "entity" for every other category.
+- mergeable: optional. Omit it to follow the category. Set true only for an events memory that is one evolving record, false only for a preference to keep as a point-in-time record.
+%s%s- Return ONLY a JSON array with one element, no other text
 
 Return a JSON array:
 [{
-  "category": "profile|preferences|feedback|entities|events|patterns|cases|reference",
+  "category": "profile|preferences|feedback|constraints|entities|events|patterns|cases|reference",
   "uri_hint": "slug-name",
   "l0": "single sentence, max 200 chars",
   "l1": "structured overview, max 2000 chars (for feedback: <rule>. Why: <reason>. How to apply: <when>.)",
-  "l2": "full content, max 40000 chars"
-}]`, InternalSentinel, prompt)
+  "l2": "full content, max 40000 chars",
+  "entity": {"type": "service", "name": "canonical name", "location": "path or URL", "aliases": ["other name"]}
+}]`, InternalSentinel, prompt,
+		signalCategoryRule(defaultCategory, forceCategory),
+		languageRule(language, "l0, l1, and l2", "JSON keys, category names, and uri_hint slugs (lowercase ASCII)"))
+}
+
+// signalCategoryRule steers a signal's category: forced when the user named
+// one, otherwise a default for messages that don't clearly fit elsewhere.
+func signalCategoryRule(defaultCategory, forceCategory string) string {
+	switch {
+	case forceCategory != "":
+		return fmt.Sprintf("- The user asked for this to be remembered as %s — category MUST be %q\n", forceCategory, forceCategory)
+	case defaultCategory != "":
+		return fmt.Sprintf("- \"Remember this: always/never ...\" is almost always a standing rule, not an event. If the message doesn't clearly fit another category, use %q\n", defaultCategory)
+	}
+	return ""
+}
+
+// RankItem is one search candidate offered to RelevancePrompt.
+type RankItem struct {
+	URI     string
+	Summary string
+}
+
+// RelevancePrompt generates the prompt for reranking search candidates by
+// how well they answer query.
+func RelevancePrompt(query string, items []RankItem) string {
+	var b strings.Builder
+	for _, it := range items {
+		fmt.Fprintf(&b, "- %s: %s\n", it.URI, it.Summary)
+	}
+	return fmt.Sprintf(`%s Rank these stored memories by how directly each answers the search query.
+
+QUERY: %s
+
+CANDIDATES:
+%s
+Rules:
+- Most relevant first; a memory that only shares a word with the query ranks low
+- Use the URIs exactly as given; leave out any that are irrelevant
+- Return ONLY a JSON array of URIs, no other text
+
+Return a JSON array:
+["mem://...", "mem://..."]`, InternalSentinel, query, b.String())
+}
+
+// CategoryItem is one stored memory offered to RecategorizePrompt.
+type CategoryItem struct {
+	URI      string
+	Category string
+	Summary  string
+}
+
+// RecategorizePrompt generates the prompt for reviewing whether each memory
+// is filed under the right category. categories lists the allowed targets.
+func RecategorizePrompt(items []CategoryItem, categories []string) string {
+	var b strings.Builder
+	for _, it := range items {
+		fmt.Fprintf(&b, "- %s [%s]: %s\n", it.URI, it.Category, it.Summary)
+	}
+	return fmt.Sprintf(`%s Review whether each stored memory is filed under the right category.
+
+Categories:
+- profile: who the user is — identity, skills, non-negotiable preferences
+- preferences: tools, workflows, changeable choices, configurational settings
+- feedback: directional guidance on how to approach work, with a why
+- constraints: things the user said never to do — hard prohibitions
+- entities: people, projects, services that will be referenced again
+- events: significant decisions or milestones
+- patterns: reusable techniques the user has validated
+- cases: non-obvious problem→solution pairs
+- reference: pointers to external systems, dashboards, team rituals
+
+MEMORIES:
+%s
+Rules:
+- Only list memories that clearly belong in a different category; when in doubt, leave it out
+- category must be one of: %s
+- Use the URIs exactly as given
+- reason: one short sentence
+- Return ONLY a JSON array, no other text
+
+Return a JSON array (empty if every memory is filed correctly):
+[{"uri": "mem://...", "category": "new category", "reason": "why it fits better"}]`,
+		InternalSentinel, b.String(), strings.Join(categories, ", "))
+}
+
+// SessionNotesRule is appended to the extraction prompt when session notes
+// are enabled (engine.session_notes), offering the model the "session"
+// category for context that shouldn't outlive the session.
+const SessionNotesRule = `
+
+Session notes: besides lasting memories, you may return notes that matter only for the rest of THIS session — a temporary constraint or working agreement (e.g., "Don't touch the staging database today", "Keep the old API until this refactor lands"). Give them category "session". They are shown only to this session when its context is rebuilt (resume, compaction) and deleted when it ends, so never use "session" for anything that should outlast it. They count toward the memory budget.`
+
+// AssistantInsightsRule is appended to the extraction prompt when
+// engine.assistant_insights is on, asking the model to also keep solutions
+// the assistant found that the user never called out.
+const AssistantInsightsRule = `
+
+Assistant insights: also look at what the ASSISTANT worked out. When the assistant found a non-obvious solution — a root cause, a workaround, a fix that took real digging — keep it even if the user never remarked on it, as a "cases" memory (problem→solution). Mark each such memory with "origin": "assistant". Skip routine work, and skip anything the user corrected or rejected. They still have to meet the extraction bar and count toward the memory budget.`
+
+// MergePrompt generates the prompt for merging a new version of a mergeable
+// memory's overview into the stored one.
+func MergePrompt(existing, incoming string) string {
+	return fmt.Sprintf(`%s Merge two versions of the same memory into one overview.
+
+EXISTING:
+%s
+
+NEW:
+%s
+
+Rules:
+- Keep every specific detail from both versions that is still true
+- Where they conflict, the NEW version wins — it is more recent
+- Do not add anything that is in neither version
+- Keep it about as long as the longer of the two
+- Write in the language the versions are written in; do not translate
+- Return ONLY the merged overview text, no preamble, no quotes`, InternalSentinel, existing, incoming)
+}
+
+// maxRepairEcho bounds how much of a malformed response JSONRepairPrompt
+// sends back, so a runaway response can't make the retry expensive.
+const maxRepairEcho = 8000
+
+// JSONRepairPrompt asks the model to restate a response that should have
+// been a JSON array but didn't parse. The bad output is echoed back, capped
+// at maxRepairEcho bytes.
+func JSONRepairPrompt(bad string) string {
+	if len(bad) > maxRepairEcho {
+		bad = bad[:maxRepairEcho]
+	}
+	return fmt.Sprintf(`%s Your previous output was not valid JSON.
+
+PREVIOUS OUTPUT:
+%s
+
+Return the same content as a valid JSON array.
+
+Rules:
+- Return ONLY the JSON array, no other text, no code fences
+- Keep every object and field from the previous output; do not add or drop any
+- If the previous output contained nothing usable, return []`, InternalSentinel, bad)
 }
 
 // TonePrompt generates the prompt for extracting session emotional arc.
@@ -3,10 +3,12 @@ package server
 import (
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"log"
 	"math"
 	"net/http"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -21,7 +23,67 @@ func (s *Server) handleGetContext(w http.ResponseWriter, r *http.Request) {
 	// so moment rotation advances. A preview that consumed rotation would change
 	// the very thing it claims to show — the panel is an honesty instrument.
 	preview := r.URL.Query().Get("preview") == "true"
-	ctx := s.renderContext(r.URL.Query().Get("session_id"), preview)
+	// A read-only server can't record rotation or injections; render as a
+	// preview rather than fail the writes piecemeal.
+	if s.db.ReadOnly {
+		preview = true
+	}
+
+	categories := parseContextCategories(r.URL.Query().Get("categories"))
+	sessionID := r.URL.Query().Get("session_id")
+	if sessionID != "" {
+		sessionID = s.sessionKey(r, sessionID)
+	}
+	light, err := s.lightContext(r.URL.Query().Get("weight"), r.URL.Query().Get("project"), sessionID)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Conditional GET: a SessionStart hook that cached the last block sends
+	// its ETag back; if nothing the block is built from has changed, skip the
+	// rebuild. A version lookup failure just means no caching, never a failed
+	// request. A scoped block is a different block, so the scope is part of
+	// the tag, and so is the session: a block carries that session's notes
+	// and its own place in the recent-sessions list, and must never answer
+	// another session's If-None-Match. A 304 doesn't advance moment rotation,
+	// but it does record the cached block's injections for the session — when
+	// the server still knows them; otherwise the block is rebuilt.
+	key, err := s.contextKey(categories, light)
+	etag := ""
+	if err != nil {
+		log.Printf("context: version: %v", err)
+	} else {
+		etag = contextETag(key, sessionID)
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			injected, known := s.servedContext(etag)
+			if preview || sessionID == "" || known {
+				if !preview && sessionID != "" {
+					if err := s.db.RecordInjections(sessionID, injected); err != nil {
+						log.Printf("context: record injections for %s: %v", sessionID, err)
+					}
+				}
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+	}
+
+	var (
+		ctx      string
+		injected []store.InjectedMemory
+		ok       bool
+	)
+	if !preview && key != "" && !light {
+		ctx, injected, ok = s.takeWarmContext(key, sessionID, categories)
+	}
+	if !ok {
+		ctx, injected = s.renderContextWeighted(sessionID, preview, categories, light)
+	}
+	if !preview && sessionID != "" && etag != "" {
+		s.rememberServedContext(etag, injected)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
@@ -29,6 +91,112 @@ func (s *Server) handleGetContext(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// contextKey identifies the state a context block renders from: the store's
+// ContextVersion plus the ?categories= scope, if any, and whether it is a
+// light block.
+func (s *Server) contextKey(categories []string, light bool) (string, error) {
+	v, err := s.db.ContextVersion()
+	if err != nil {
+		return "", err
+	}
+	if len(categories) > 0 {
+		v += ";" + strings.Join(categories, ",")
+	}
+	if light {
+		v += ";light"
+	}
+	return v, nil
+}
+
+// contextETag is the weak ETag of the block rendered at key for sessionID.
+// The session is hashed in rather than quoted, so any ID makes a valid tag.
+func contextETag(key, sessionID string) string {
+	if sessionID == "" {
+		return `W/"` + key + `"`
+	}
+	h := fnv.New64a()
+	h.Write([]byte(sessionID))
+	return fmt.Sprintf(`W/"%s;s=%016x"`, key, h.Sum64())
+}
+
+// maxServedContexts bounds the served map; past it, an arbitrary entry is
+// forgotten and that session's next conditional GET rebuilds its block.
+const maxServedContexts = 256
+
+// rememberServedContext notes the memories the block tagged etag injected.
+func (s *Server) rememberServedContext(etag string, injected []store.InjectedMemory) {
+	s.servedMu.Lock()
+	defer s.servedMu.Unlock()
+	if s.served == nil {
+		s.served = make(map[string][]store.InjectedMemory)
+	}
+	if _, ok := s.served[etag]; !ok && len(s.served) >= maxServedContexts {
+		for k := range s.served {
+			delete(s.served, k)
+			break
+		}
+	}
+	s.served[etag] = injected
+}
+
+// servedContext returns what the block tagged etag injected, if the server
+// served it and still remembers.
+func (s *Server) servedContext(etag string) ([]store.InjectedMemory, bool) {
+	s.servedMu.Lock()
+	defer s.servedMu.Unlock()
+	injected, ok := s.served[etag]
+	return injected, ok
+}
+
+// Context weights a SessionStart hook may ask for (?weight=).
+const (
+	weightLight = "light"
+	weightFull  = "full"
+)
+
+// SetContextThrottle configures light blocks: after a session in the same
+// project that recorded fewer than minObservations tool observations, the
+// next SessionStart ranks at most items memories into the block. Zero
+// minObservations turns the automatic throttle off; a ?weight=light request
+// still gets items. Negative values are an error.
+func (s *Server) SetContextThrottle(minObservations, items int) error {
+	if minObservations < 0 {
+		return fmt.Errorf("light_session_observations must not be negative, got %d", minObservations)
+	}
+	if items < 0 {
+		return fmt.Errorf("light_items must not be negative, got %d", items)
+	}
+	s.contextLightObservations, s.contextLightItems = minObservations, items
+	return nil
+}
+
+// lightContext decides whether a context request gets a light block. An
+// explicit weight wins. Otherwise, with the throttle on, a block is light
+// when the previous session in project was a quick one: SessionStart fires
+// before the new session has any observations of its own, so the last one
+// is the best guess at what kind of work follows. No project, no previous
+// session, or a lookup failure means a full block.
+func (s *Server) lightContext(weight, project, sessionID string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(weight)) {
+	case weightLight:
+		return true, nil
+	case weightFull:
+		return false, nil
+	case "":
+	default:
+		return false, fmt.Errorf("invalid weight %q (want %s or %s)", weight, weightLight, weightFull)
+	}
+	if s.contextLightObservations <= 0 || project == "" {
+		return false, nil
+	}
+	prev, err := s.db.PreviousSession(project, sessionID)
+	if err != nil {
+		log.Printf("context: previous session for %s: %v", project, err)
+		return false, nil
+	}
+	return prev != nil && prev.ToolCount < s.contextLightObservations, nil
+}
+
 // Context injection budgets.
 // These are defense-in-depth limits — if extraction and validation are working
 // correctly, content should already fit. When these fire, it means upstream
@@ -44,21 +212,170 @@ const (
 	maxPinnedItems = store.MaxPins
 )
 
+// Context sections, by the names config.ContextConfig.Sections uses.
+// defaultContextSections is the order they render in unless configured
+// otherwise; a section left out of the configured list is not built at all,
+// so it neither spends budget nor records injections.
+const (
+	sectionWorkingWithYou = "working_with_you"
+	sectionPinned         = "pinned"
+	sectionConstraints    = "constraints"
+	sectionSessionNotes   = "session_notes"
+	sectionMoments        = "moments"
+	sectionProfile        = "profile"
+	sectionMemories       = "memories"
+	sectionSessions       = "sessions"
+	sectionCurrentSession = "current_session"
+)
+
+var defaultContextSections = []string{
+	sectionWorkingWithYou, sectionPinned, sectionConstraints, sectionSessionNotes, sectionMoments,
+	sectionProfile, sectionMemories, sectionSessions, sectionCurrentSession,
+}
+
+// defaultContextCategories are the categories ranked into "Your Profile" and
+// "Recent Memories", in tiebreak order (see renderContext). Moments,
+// constraints, and the relational profile have sections of their own, so none
+// of them is listed.
+var defaultContextCategories = []string{
+	"profile", "preferences", "feedback", "patterns", "events", "cases", "entities", "reference",
+}
+
+// SetContextLayout configures which context sections render and in what
+// order, and which categories are ranked into the memory sections (their
+// order is the tiebreak for equal scores). An empty list keeps the default.
+// Unknown section or category names are an error, so a typo doesn't silently
+// drop a section.
+func (s *Server) SetContextLayout(sections, categories []string) error {
+	if err := checkNames("section", sections, defaultContextSections); err != nil {
+		return err
+	}
+	if err := checkNames("category", categories, defaultContextCategories); err != nil {
+		return err
+	}
+	s.contextSections, s.contextCategories = sections, categories
+	return nil
+}
+
+// SetContextMaxAge sets the recency horizon of the ranked sections: memories
+// created longer than maxAge ago are left out of "Your Profile" and "Recent
+// Memories" (pins, constraints, and moments are unaffected). Zero removes the
+// horizon; a negative age is an error.
+func (s *Server) SetContextMaxAge(maxAge time.Duration) error {
+	if maxAge < 0 {
+		return fmt.Errorf("max age must not be negative, got %s", maxAge)
+	}
+	s.contextMaxAge = maxAge
+	return nil
+}
+
+// checkNames reports the first name not in known, or a repeated name.
+func checkNames(kind string, names, known []string) error {
+	seen := make(map[string]bool, len(names))
+	for _, n := range names {
+		if !slices.Contains(known, n) {
+			return fmt.Errorf("unknown context %s %q (want one of %s)", kind, n, strings.Join(known, ", "))
+		}
+		if seen[n] {
+			return fmt.Errorf("context %s %q listed twice", kind, n)
+		}
+		seen[n] = true
+	}
+	return nil
+}
+
+// parseContextCategories parses the ?categories= scope of a context request:
+// a comma-separated list of ranked categories, e.g. "patterns,cases" for a
+// debugging session. Empty means no scope. Unknown and repeated names are
+// logged and dropped rather than rejected: the scope comes from a hook's
+// environment, and a typo there must not cost the session its context. A
+// scope with no known names is no scope.
+func parseContextCategories(raw string) []string {
+	var cats []string
+	seen := map[string]bool{}
+	for _, c := range strings.Split(raw, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" || seen[c] {
+			continue
+		}
+		seen[c] = true
+		if !slices.Contains(defaultContextCategories, c) {
+			log.Printf("context: ignoring unknown category %q in scope", c)
+			continue
+		}
+		cats = append(cats, c)
+	}
+	return cats
+}
+
+// sectionOrder returns the configured section order, or the default.
+func (s *Server) sectionOrder() []string {
+	if len(s.contextSections) > 0 {
+		return s.contextSections
+	}
+	return defaultContextSections
+}
+
+// rankedCategories returns the configured context categories, or the default.
+func (s *Server) rankedCategories() []string {
+	if len(s.contextCategories) > 0 {
+		return s.contextCategories
+	}
+	return defaultContextCategories
+}
+
 // buildContext creates the context markdown for a real session injection.
 // It advances moment rotation (TouchNode) as a side effect — this is the
 // SessionStart path. For a side-effect-free render (the Cold Boot preview),
-// use renderContext(sessionID, true).
+// use renderContext(sessionID, true, nil).
 func (s *Server) buildContext(currentSessionID string) string {
-	return s.renderContext(currentSessionID, false)
+	return s.renderContext(currentSessionID, false, nil)
 }
 
 // renderContext builds the context markdown. When preview is true, it makes no
 // writes — moment rotation is NOT advanced — so callers can show exactly what a
 // cold SessionStart would inject without consuming the rotation that injection
 // would. Enforces a hard character budget to prevent context bloat.
-func (s *Server) renderContext(currentSessionID string, preview bool) string {
+//
+// Sections are built in a fixed priority order — the order the budget is
+// spent in — and then written in the configured order (SetContextLayout), so
+// moving a section down the page doesn't starve it of budget.
+//
+// categories, when non-empty, replaces the configured ranked categories for
+// this render only (the ?categories= scope); nil uses rankedCategories.
+//
+// A real injection (not preview) with a session id also records which memories
+// were injected (context_injections), so a later search in the same session can
+// mark them used — the instrumentation behind `continuity stats usefulness`.
+func (s *Server) renderContext(currentSessionID string, preview bool, categories []string) string {
+	block, _ := s.renderContextWeighted(currentSessionID, preview, categories, false)
+	return block
+}
+
+// renderContextWeighted is renderContext for a light or full block; a light
+// one ranks at most contextLightItems memories (see lightContext). It also
+// returns the memories the block injected.
+func (s *Server) renderContextWeighted(currentSessionID string, preview bool, categories []string, light bool) (string, []store.InjectedMemory) {
+	block, injected := s.composeContext(currentSessionID, preview, categories, light)
+	if !preview && currentSessionID != "" {
+		if err := s.db.RecordInjections(currentSessionID, injected); err != nil {
+			log.Printf("context: record injections for %s: %v", currentSessionID, err)
+		}
+	}
+	return block, injected
+}
+
+// composeContext is renderContextWeighted without recording injections: it
+// returns the block and the memories in it. Moment rotation still advances
+// unless preview is set.
+func (s *Server) composeContext(currentSessionID string, preview bool, categories []string, light bool) (string, []store.InjectedMemory) {
 	var b strings.Builder
 	budget := maxContextTotal
+	var injected []store.InjectedMemory
+
+	order := s.sectionOrder()
+	show := func(section string) bool { return slices.Contains(order, section) }
+	parts := make(map[string]string, len(order))
 
 	now := time.Now()
 	header := fmt.Sprintf("<context>\n## Continuity — Session Memory\nCurrent: %s\n", now.Format("2006-01-02 15:04 (Mon)"))
@@ -68,7 +385,7 @@ func (s *Server) renderContext(currentSessionID string, preview bool) string {
 	// Gap signal: if last session on this project was >7 days ago, flag it
 	if lastSessions, err := s.db.GetRecentSessions(1); err == nil && len(lastSessions) > 0 {
 		last := lastSessions[0]
-		if last.SessionID != currentSessionID {
+		if last.Key != currentSessionID {
 			gap := now.Sub(time.UnixMilli(last.StartedAt))
 			if gap.Hours() > 7*24 {
 				gapLine := fmt.Sprintf("Last session: %d days ago (%s)\n",
@@ -87,7 +404,7 @@ func (s *Server) renderContext(currentSessionID string, preview bool) string {
 	// session simply lacks a "Working With You" block until the profile is
 	// re-synthesized by a future extraction.
 	relProfile, err := s.db.GetNodeByURI("mem://user/profile/communication")
-	if err == nil && relProfile != nil && !relProfile.IsRetracted() && relProfile.L1Overview != "" {
+	if err == nil && relProfile != nil && !relProfile.IsRetracted() && relProfile.L1Overview != "" && show(sectionWorkingWithYou) {
 		section := "\n### Working With You\n"
 		content := relProfile.L1Overview
 		if len(content) > maxRelationalContext {
@@ -95,8 +412,9 @@ func (s *Server) renderContext(currentSessionID string, preview bool) string {
 			content = truncateAtSentence(content, maxRelationalContext)
 		}
 		section += content + "\n"
-		b.WriteString(section)
+		parts[sectionWorkingWithYou] = section
 		budget -= len(section)
+		injected = append(injected, store.InjectedMemory{URI: relProfile.URI, Category: relProfile.Category})
 	}
 
 	// Operator pins (declared contract) — the highest-priority resident content
@@ -107,7 +425,11 @@ func (s *Server) renderContext(currentSessionID string, preview bool) string {
 	// function — the single retraction chokepoint. pinnedURIs records what was
 	// shown so the ranked sections below don't render the same node twice.
 	pinnedURIs := make(map[string]bool)
-	if pinned, err := s.db.ListPinned(); err == nil && len(pinned) > 0 {
+	var pinned []store.MemNode
+	if show(sectionPinned) {
+		pinned, err = s.db.ListPinned()
+	}
+	if err == nil && len(pinned) > 0 {
 		const pinnedHeader = "\n### Pinned\n"
 		section := pinnedHeader
 		used := 0
@@ -136,16 +458,92 @@ func (s *Server) renderContext(currentSessionID string, preview bool) string {
 			}
 			section += line
 			pinnedURIs[p.URI] = true
+			injected = append(injected, store.InjectedMemory{URI: p.URI, Category: p.Category})
 			used++
 		}
 		if section != pinnedHeader {
-			b.WriteString(section)
+			parts[sectionPinned] = section
+			budget -= len(section)
+		}
+	}
+
+	// Constraints — things the user said never to do. Violating one costs more
+	// than missing a preference, so they come straight after the pins and,
+	// unlike the ranked sections below, aren't filtered by decayed relevance:
+	// an old "never force-push" is as binding as a new one.
+	var constraints []store.MemNode
+	if show(sectionConstraints) {
+		constraints, err = s.db.FindByCategory("constraints")
+	}
+	if err == nil && len(constraints) > 0 {
+		sort.SliceStable(constraints, func(i, j int) bool {
+			return nodeScore(constraints[i]) > nodeScore(constraints[j])
+		})
+		const constraintsHeader = "\n### Constraints\n"
+		section := constraintsHeader
+		for _, c := range constraints {
+			if pinnedURIs[c.URI] || c.L0Abstract == "" {
+				continue
+			}
+			l0 := c.L0Abstract
+			if len(l0) > maxItemContext {
+				l0 = truncateAtSentence(l0, maxItemContext)
+			}
+			line := fmt.Sprintf("- %s\n", l0)
+			if budget-len(section)-len(line) < 0 {
+				log.Printf("context: budget exhausted in constraints section")
+				break
+			}
+			section += line
+			injected = append(injected, store.InjectedMemory{URI: c.URI, Category: c.Category})
+		}
+		if section != constraintsHeader {
+			parts[sectionConstraints] = section
+			budget -= len(section)
+		}
+	}
+
+	// Session notes — what extraction scoped to this session alone, such as a
+	// temporary constraint. Only the session that wrote them sees them (when
+	// its context is rebuilt on resume or compaction); they're deleted when
+	// it ends.
+	var notes []store.MemNode
+	if currentSessionID != "" && show(sectionSessionNotes) {
+		var nerr error
+		if notes, nerr = s.db.SessionNotes(currentSessionID); nerr != nil {
+			log.Printf("context: session notes for %s: %v", currentSessionID, nerr)
+		}
+	}
+	if len(notes) > 0 {
+		const notesHeader = "\n### This Session\n"
+		section := notesHeader
+		for _, n := range notes {
+			if pinnedURIs[n.URI] || n.L0Abstract == "" {
+				continue
+			}
+			l0 := n.L0Abstract
+			if len(l0) > maxItemContext {
+				l0 = truncateAtSentence(l0, maxItemContext)
+			}
+			line := fmt.Sprintf("- %s\n", l0)
+			if budget-len(section)-len(line) < 0 {
+				log.Printf("context: budget exhausted in session notes section")
+				break
+			}
+			section += line
+			injected = append(injected, store.InjectedMemory{URI: n.URI, Category: n.Category})
+		}
+		if section != notesHeader {
+			parts[sectionSessionNotes] = section
 			budget -= len(section)
 		}
 	}
 
 	// Reserve space for session footer (~300 chars for 5 sessions + current)
-	const footerReserve = 400
+	footerReserve := 0
+	if show(sectionSessions) || show(sectionCurrentSession) {
+		footerReserve = 400
+	}
 	itemBudget := budget - footerReserve
 	if itemBudget < 0 {
 		itemBudget = 0
@@ -153,7 +551,10 @@ func (s *Server) renderContext(currentSessionID string, preview bool) string {
 
 	// Inject moments — small, permanent, high-value relational anchors
 	// Uses diversity sampling: rotation via last_access, greedy max-diversity selection
-	moments, err := s.db.FindByCategory("moments")
+	var moments []store.MemNode
+	if show(sectionMoments) {
+		moments, err = s.db.FindByCategory("moments")
+	}
 	if err == nil && len(moments) > 0 {
 		// Drop any moment already shown as a pin so it isn't rendered twice.
 		if len(pinnedURIs) > 0 {
@@ -174,19 +575,21 @@ func (s *Server) renderContext(currentSessionID string, preview bool) string {
 					l0 = truncateAtSentence(l0, maxItemContext)
 				}
 				section += fmt.Sprintf("- %s\n", l0)
+				injected = append(injected, store.InjectedMemory{URI: m.URI, Category: m.Category})
 				// Touch for rotation tracking — next session deprioritizes these.
 				// Skipped in preview: a preview must not consume the rotation it shows.
 				if !preview {
 					s.db.TouchNode(m.URI)
 				}
 			}
-			b.WriteString(section)
+			parts[sectionMoments] = section
 			budget -= len(section)
 		}
 	}
 
 	// Collect all non-relational leaves, rank by signal strength
 	type rankedItem struct {
+		uri      string
 		category string
 		l0       string
 		score    float64
@@ -200,9 +603,16 @@ func (s *Server) renderContext(currentSessionID string, preview bool) string {
 	// intended priority, and (2) as a deterministic tiebreaker for the
 	// stable sort below when scores are equal (common for freshly written
 	// nodes where Relevance=1.0 and AccessCount=0). Don't add a new category
-	// to either end of this list without thinking about which section it
-	// joins downstream.
-	for _, cat := range []string{"profile", "preferences", "feedback", "patterns", "events", "cases", "entities", "reference"} {
+	// to either end of defaultContextCategories without thinking about which
+	// section it joins downstream. Categories whose section isn't shown are
+	// skipped so they don't spend the item budget.
+	if len(categories) == 0 {
+		categories = s.rankedCategories()
+	}
+	for _, cat := range categories {
+		if !show(contextSectionFor(cat)) {
+			continue
+		}
 		nodes, err := s.db.FindByCategory(cat)
 		if err != nil {
 			continue
@@ -214,11 +624,16 @@ func (s *Server) renderContext(currentSessionID string, preview bool) string {
 			if pinnedURIs[n.URI] {
 				continue // already shown in the Pinned section
 			}
-			if n.L0Abstract == "" || n.Relevance < 0.3 {
+			if n.L0Abstract == "" || n.Relevance < store.ContextMinRelevance {
+				continue
+			}
+			// Recency horizon: a memory kept relevant by access can still
+			// be too old to prime a session. Pins were placed above.
+			if s.contextMaxAge > 0 && now.Sub(time.UnixMilli(n.CreatedAt)) > s.contextMaxAge {
 				continue
 			}
 			score := nodeScore(n)
-			items = append(items, rankedItem{cat, n.L0Abstract, score})
+			items = append(items, rankedItem{n.URI, cat, n.L0Abstract, score})
 		}
 	}
 
@@ -228,8 +643,12 @@ func (s *Server) renderContext(currentSessionID string, preview bool) string {
 	sort.SliceStable(items, func(i, j int) bool {
 		return items[i].score > items[j].score
 	})
-	if len(items) > maxContextItems {
-		items = items[:maxContextItems]
+	maxItems := maxContextItems
+	if light {
+		maxItems = min(maxItems, s.contextLightItems)
+	}
+	if len(items) > maxItems {
+		items = items[:maxItems]
 	}
 
 	// Split into profile/prefs vs other, enforcing per-item and total budget
@@ -248,7 +667,7 @@ func (s *Server) renderContext(currentSessionID string, preview bool) string {
 		// without a category tag. Feedback rides with profile/preferences because
 		// it's directional guidance that shapes how the agent should act (issue #24)
 		// — not a labelled "memory" you'd browse but identity-shaping context.
-		isProfileSection := it.category == "profile" || it.category == "preferences" || it.category == "feedback"
+		isProfileSection := contextSectionFor(it.category) == sectionProfile
 		if isProfileSection {
 			line = fmt.Sprintf("- %s\n", l0)
 		} else {
@@ -261,6 +680,7 @@ func (s *Server) renderContext(currentSessionID string, preview bool) string {
 		}
 		itemBudget -= len(line)
 		itemsUsed++
+		injected = append(injected, store.InjectedMemory{URI: it.uri, Category: it.category})
 
 		if isProfileSection {
 			profileLines = append(profileLines, line)
@@ -270,25 +690,22 @@ func (s *Server) renderContext(currentSessionID string, preview bool) string {
 	}
 
 	if len(profileLines) > 0 {
-		b.WriteString("\n### Your Profile\n")
-		for _, line := range profileLines {
-			b.WriteString(line)
-		}
+		parts[sectionProfile] = "\n### Your Profile\n" + strings.Join(profileLines, "")
 	}
-
 	if len(memoryLines) > 0 {
-		b.WriteString("\n### Recent Memories\n")
-		for _, line := range memoryLines {
-			b.WriteString(line)
-		}
+		parts[sectionMemories] = "\n### Recent Memories\n" + strings.Join(memoryLines, "")
 	}
 
 	// Recent sessions
-	sessions, err := s.db.GetRecentSessions(5)
+	var sessions []store.Session
+	if show(sectionSessions) {
+		sessions, err = s.db.GetRecentSessions(5)
+	}
 	if err == nil && len(sessions) > 0 {
-		b.WriteString("\n### Recent Sessions\n")
+		var sb strings.Builder
+		sb.WriteString("\n### Recent Sessions\n")
 		for _, sess := range sessions {
-			if sess.SessionID == currentSessionID {
+			if sess.Key == currentSessionID {
 				continue
 			}
 			ts := time.UnixMilli(sess.StartedAt).Format("2006-01-02 15:04")
@@ -302,20 +719,37 @@ func (s *Server) renderContext(currentSessionID string, preview bool) string {
 			if sess.Tone != nil && *sess.Tone != "" {
 				toneSuffix = fmt.Sprintf(" — %s", *sess.Tone)
 			}
-			b.WriteString(fmt.Sprintf("- [%s] %s: %s (%d tools used)%s\n", ts, project, sess.Status, sess.ToolCount, toneSuffix))
+			sb.WriteString(fmt.Sprintf("- [%s] %s: %s (%d tools used)%s\n", ts, project, sess.Status, sess.ToolCount, toneSuffix))
 		}
+		parts[sectionSessions] = sb.String()
 	}
 
 	// Current session info
-	if currentSessionID != "" {
+	if currentSessionID != "" && show(sectionCurrentSession) {
 		count, err := s.db.GetSessionObservationCount(currentSessionID)
 		if err == nil && count > 0 {
-			b.WriteString(fmt.Sprintf("\n### Current Session\n%d tool uses recorded this session\n", count))
+			parts[sectionCurrentSession] = fmt.Sprintf("\n### Current Session\n%d tool uses recorded this session\n", count)
 		}
 	}
 
+	for _, section := range order {
+		b.WriteString(parts[section])
+	}
+
 	b.WriteString("</context>")
-	return b.String()
+	return b.String(), injected
+}
+
+// contextSectionFor returns the section a ranked category renders in.
+// Profile, preferences, and feedback collapse into "Your Profile"; everything
+// else is a tagged line under "Recent Memories".
+func contextSectionFor(category string) string {
+	switch category {
+	case "profile", "preferences", "feedback":
+		return sectionProfile
+	default:
+		return sectionMemories
+	}
 }
 
 // truncateAtSentence truncates to maxLen, preferring sentence boundaries.
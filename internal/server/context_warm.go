@@ -0,0 +1,102 @@
+package server
+
+import (
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lazypower/continuity/internal/store"
+)
+
+// warmContext is a context block rendered ahead of the SessionStart that
+// will want it, so that request skips the category scans. It is keyed by
+// contextKey: any node write changes the key and so invalidates it.
+type warmContext struct {
+	key        string
+	categories []string
+	block      string
+	injected   []store.InjectedMemory
+}
+
+// WarmContext renders the default context block in the background, so the
+// first SessionStart after the server starts gets it without a cold scan of
+// every category. A no-op on a read-only server, which never injects.
+func (s *Server) WarmContext() {
+	if s.db.ReadOnly {
+		return
+	}
+	go s.warmContext(nil)
+}
+
+// warmContext renders the block for categories as a preview (no rotation,
+// no injections recorded) and holds it for takeWarmContext. The key is read
+// before rendering, so a write that lands mid-render leaves a block keyed to
+// the state before it, which the next request won't match.
+func (s *Server) warmContext(categories []string) {
+	key, err := s.contextKey(categories, false)
+	if err != nil {
+		log.Printf("context: pre-warm: %v", err)
+		return
+	}
+	start := time.Now()
+	block, injected := s.composeContext("", true, categories, false)
+
+	s.warmMu.Lock()
+	s.warm = &warmContext{key: key, categories: categories, block: block, injected: injected}
+	s.warmMu.Unlock()
+	log.Printf("context: pre-warmed in %s", time.Since(start).Round(time.Millisecond))
+}
+
+// takeWarmContext serves a real SessionStart injection from the warm block
+// when it was rendered at key, for the same scope, and sessionID is new —
+// an existing session renders differently (its own tool count, and it's
+// left out of the recent-sessions list). The block's clock line is
+// restamped, its moments are touched so rotation advances as it would have,
+// and its injections are recorded for the session and returned. The block
+// is single-use: a fresh one is rendered in the background to reflect the
+// rotation.
+func (s *Server) takeWarmContext(key, sessionID string, categories []string) (string, []store.InjectedMemory, bool) {
+	s.warmMu.Lock()
+	warm := s.warm
+	if warm == nil || warm.key != key || !slices.Equal(warm.categories, categories) {
+		s.warmMu.Unlock()
+		return "", nil, false
+	}
+	if sessionID != "" {
+		if sess, err := s.db.GetSession(sessionID); err != nil || sess != nil {
+			s.warmMu.Unlock()
+			return "", nil, false
+		}
+	}
+	s.warm = nil
+	s.warmMu.Unlock()
+
+	for _, m := range warm.injected {
+		if m.Category == "moments" {
+			s.db.TouchNode(m.URI)
+		}
+	}
+	if sessionID != "" {
+		if err := s.db.RecordInjections(sessionID, warm.injected); err != nil {
+			log.Printf("context: record injections for %s: %v", sessionID, err)
+		}
+	}
+	go s.warmContext(categories)
+	return restampContext(warm.block, time.Now()), warm.injected, true
+}
+
+// restampContext replaces the "Current:" line of a block rendered earlier
+// with now.
+func restampContext(block string, now time.Time) string {
+	i := strings.Index(block, "\nCurrent: ")
+	if i < 0 {
+		return block
+	}
+	i++
+	end := i + strings.IndexByte(block[i:], '\n')
+	if end < i {
+		return block
+	}
+	return block[:i] + "Current: " + now.Format("2006-01-02 15:04 (Mon)") + block[end:]
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lazypower/continuity/internal/store"
+)
+
+func TestWarmContextServesFirstSessionStart(t *testing.T) {
+	srv := testServer(t)
+	if err := srv.db.CreateNode(&store.MemNode{
+		URI: "mem://user/preferences/warm", NodeType: "leaf", Category: "preferences", L0Abstract: "Likes a warm cache",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	srv.db.InitSession("existing", "/tmp/proj")
+
+	get := func(sessionID string) string {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newTestRequest("GET", "/api/context?session_id="+sessionID, nil))
+		var resp map[string]string
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp["context"]
+	}
+	// warmWithMarker pre-renders, then tags the held block so a response
+	// served from it can be told from a fresh render.
+	warmWithMarker := func() {
+		srv.warmContext(nil)
+		srv.warm.block = strings.Replace(srv.warm.block, "</context>", "<!-- warm -->\n</context>", 1)
+	}
+
+	// An existing session renders its own block; the warm one stays held.
+	warmWithMarker()
+	if ctx := get("existing"); strings.Contains(ctx, "<!-- warm -->") {
+		t.Error("an existing session must not get the pre-rendered block")
+	}
+
+	// A new session gets the warm block, restamped, with its injections recorded.
+	injected := func() int {
+		rates, _ := srv.db.InjectionUsefulness()
+		if len(rates) == 0 {
+			return 0
+		}
+		return rates[0].Injected
+	}
+	before := injected()
+	ctx := get("new-session")
+	if !strings.Contains(ctx, "<!-- warm -->") || !strings.Contains(ctx, "Likes a warm cache") {
+		t.Fatalf("new session should be served the warm block:\n%s", ctx)
+	}
+	if !strings.Contains(ctx, "Current: "+time.Now().Format("2006-01-02")) {
+		t.Errorf("warm block should carry the current time:\n%s", ctx)
+	}
+	if got := injected(); got != before+1 {
+		t.Errorf("injections from the warm block not recorded: %d, want %d", got, before+1)
+	}
+	waitForWarm(t, srv)
+
+	// A node write invalidates the held block.
+	warmWithMarker()
+	if err := srv.db.CreateNode(&store.MemNode{
+		URI: "mem://user/preferences/later", NodeType: "leaf", Category: "preferences", L0Abstract: "Written after the warm-up",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if ctx := get("another-session"); strings.Contains(ctx, "<!-- warm -->") || !strings.Contains(ctx, "Written after the warm-up") {
+		t.Errorf("a write should invalidate the warm block:\n%s", ctx)
+	}
+}
+
+// waitForWarm waits for the background re-render a served warm block kicks
+// off, so it doesn't outlive the test's database.
+func waitForWarm(t *testing.T, srv *Server) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		srv.warmMu.Lock()
+		warm := srv.warm
+		srv.warmMu.Unlock()
+		if warm != nil {
+			return
+		}
+	}
+	t.Fatal("context was not re-warmed")
+}
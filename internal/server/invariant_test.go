@@ -143,7 +143,7 @@ func TestInvariant_StoreLayerReadPathsExcludeRetracted(t *testing.T) {
 	})
 
 	t.Run("GetChildren(events)", func(t *testing.T) {
-		got, err := srv.db.GetChildren("mem://user/events")
+		got, err := srv.db.GetChildren("mem://user/events", store.ChildOrderURI)
 		if err != nil {
 			t.Fatal(err)
 		}
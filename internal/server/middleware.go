@@ -1,8 +1,12 @@
 package server
 
 import (
+	"fmt"
+	"mime"
 	"net"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 )
 
@@ -26,7 +30,7 @@ func normalizeHost(host string) string {
 func localhostOnly(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		host := normalizeHost(r.Host)
-		if host != "localhost" && host != "127.0.0.1" && host != "::1" {
+		if !isLoopback(host) {
 			jsonError(w, "forbidden", http.StatusForbidden)
 			return
 		}
@@ -44,6 +48,107 @@ func securityHeaders(next http.Handler) http.Handler {
 	})
 }
 
+// corsMaxAge is how long, in seconds, a browser may cache a preflight answer.
+const corsMaxAge = "600"
+
+// SetCORSOrigins allows browsers on origins to call the API. Each origin is a
+// scheme and host with an optional port, as a browser sends it in the Origin
+// header. A port of "*" matches any port, but only on a loopback host
+// ("http://localhost:*", "http://127.0.0.1:*"); there is no match-anything
+// origin, since any web page could then drive the API. With none set (the
+// default) no CORS headers are sent at all.
+func (s *Server) SetCORSOrigins(origins []string) error {
+	for _, o := range origins {
+		base, anyPort := strings.CutSuffix(o, ":*")
+		u, err := url.Parse(base)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			strings.Contains(u.Host, "*") || u.Path != "" || u.RawQuery != "" || u.User != nil {
+			return fmt.Errorf("invalid CORS origin %q (want scheme://host[:port])", o)
+		}
+		if anyPort && (u.Port() != "" || !isLoopback(u.Hostname())) {
+			return fmt.Errorf("invalid CORS origin %q (a \"*\" port is only allowed on localhost, 127.0.0.1 or [::1])", o)
+		}
+	}
+	s.corsOrigins = origins
+	return nil
+}
+
+// isLoopback reports whether a normalized hostname names this machine.
+func isLoopback(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// corsAllowed reports whether origin matches one of the configured origins,
+// either exactly or through a loopback "scheme://host:*" pattern.
+func (s *Server) corsAllowed(origin string) bool {
+	if slices.Contains(s.corsOrigins, origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	for _, o := range s.corsOrigins {
+		base, anyPort := strings.CutSuffix(o, ":*")
+		if !anyPort {
+			continue
+		}
+		if p, err := url.Parse(base); err == nil && p.Scheme == u.Scheme && p.Hostname() == u.Hostname() {
+			return true
+		}
+	}
+	return false
+}
+
+// cors answers preflight requests and marks responses readable by an allowed
+// origin. Requests from other origins, or without one, pass through
+// untouched: the browser then blocks the response as it would with no CORS
+// support at all.
+func (s *Server) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if len(s.corsOrigins) == 0 || origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		if !s.corsAllowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE")
+			headers := r.Header.Get("Access-Control-Request-Headers")
+			if headers == "" {
+				headers = "Content-Type"
+			}
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+			w.Header().Set("Access-Control-Max-Age", corsMaxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireJSON refuses a request body declared as anything but JSON with 415.
+// Every API body is JSON; a form or text/plain POST is either a client bug
+// or a browser's cross-site "simple request", which skips the CORS preflight.
+// A body with no Content-Type is let through for hand-rolled clients.
+func requireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "" && r.ContentLength != 0 {
+			if mt, _, err := mime.ParseMediaType(ct); err != nil || mt != "application/json" {
+				jsonError(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // limitRequestBody caps the size of incoming request bodies to prevent OOM.
 func limitRequestBody(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -33,7 +33,7 @@ func TestRenderContext_PreviewDoesNotTouchMoments(t *testing.T) {
 	// is the only writer on the moments path and it increments access_count.
 	// (last_access is stamped at CreateNode time, so its non-nil-ness is not a
 	// touch indicator.)
-	_ = srv.renderContext("", true)
+	_ = srv.renderContext("", true, nil)
 	for i := 0; i < 4; i++ {
 		n, _ := srv.db.GetNodeByURI(fmt.Sprintf("mem://agent/moments/m-%d", i))
 		if n == nil {
@@ -98,6 +98,25 @@ func TestPinEndpoint_WorksWithoutEngine(t *testing.T) {
 	}
 }
 
+// TestPinEndpoint_MissingMemoryIs404 checks that the store's ErrNotFound kind
+// reaches the client as 404 with the store's message, not a blanket 400.
+func TestPinEndpoint_MissingMemoryIs404(t *testing.T) {
+	srv := testServer(t)
+
+	for _, path := range []string{"/api/memories/pin", "/api/memories/unpin"} {
+		req := newTestRequest("POST", path, strings.NewReader(`{"uri":"mem://user/feedback/missing"}`))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s missing: status %d (body %s), want 404", path, w.Code, w.Body.String())
+		}
+		if !strings.Contains(w.Body.String(), "memory not found") {
+			t.Errorf("%s missing: body %s, want the store's reason", path, w.Body.String())
+		}
+	}
+}
+
 // TestBuildContext_PinnedSection verifies a pinned memory rides the cold-boot
 // window in its own ### Pinned section and is NOT duplicated in Recent Memories.
 func TestBuildContext_PinnedSection(t *testing.T) {
@@ -160,3 +179,32 @@ func TestBuildContext_RetractedPinSilent(t *testing.T) {
 		t.Errorf("retracted pin leaked into context window:\n%s", ctx)
 	}
 }
+
+func TestRelevanceEndpoint(t *testing.T) {
+	srv := testServer(t)
+	uri := "mem://user/preferences/annoying"
+	if err := srv.db.UpsertNode(&store.MemNode{URI: uri, NodeType: "leaf", Category: "preferences", L0Abstract: "annoying", L1Overview: "body"}); err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newTestRequest("POST", "/api/memories/relevance", strings.NewReader(body)))
+		return w
+	}
+	if w := post(`{"uri":"` + uri + `","relevance":0.25}`); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"set"`) {
+		t.Fatalf("set: %d %s", w.Code, w.Body.String())
+	}
+	if n, _ := srv.db.GetNodeByURI(uri); n.Relevance != 0.25 || !n.IsRelevanceSet() {
+		t.Errorf("node = %v set=%v, want 0.25 hand-set", n.Relevance, n.IsRelevanceSet())
+	}
+	if w := post(`{"uri":"` + uri + `","clear":true}`); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"cleared"`) {
+		t.Errorf("clear: %d %s", w.Code, w.Body.String())
+	}
+	if w := post(`{"uri":"` + uri + `"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("neither value nor clear: %d, want 400", w.Code)
+	}
+	if w := post(`{"uri":"mem://user/preferences/missing","relevance":1}`); w.Code != http.StatusNotFound {
+		t.Errorf("missing memory: %d, want 404", w.Code)
+	}
+}
@@ -7,14 +7,17 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/lazypower/continuity/internal/config"
 	"github.com/lazypower/continuity/internal/engine"
 	"github.com/lazypower/continuity/internal/store"
+	"github.com/lazypower/continuity/internal/transcript"
 )
 
 // jsonError writes a JSON error response with proper Content-Type and encoding.
@@ -25,10 +28,55 @@ func jsonError(w http.ResponseWriter, msg string, code int) {
 	json.NewEncoder(w).Encode(map[string]string{"error": msg})
 }
 
+// storeErrorStatus maps the store error kinds in err's chain to an HTTP
+// status: ErrNotFound is 404, ErrConflict 409, ErrLocked 503 (retryable).
+// Anything else gets fallback.
+func storeErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, store.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, store.ErrLocked):
+		return http.StatusServiceUnavailable
+	}
+	return fallback
+}
+
+// storeError writes err as a storeErrorStatus response whose message names
+// the status rather than a generic "internal error", so a client can tell a
+// retryable busy database from a real failure.
+func storeError(w http.ResponseWriter, err error) {
+	code := storeErrorStatus(err, http.StatusInternalServerError)
+	msg := "internal error"
+	switch code {
+	case http.StatusNotFound:
+		msg = "not found"
+	case http.StatusConflict:
+		msg = "conflict"
+	case http.StatusServiceUnavailable:
+		msg = "database busy, retry"
+	}
+	jsonError(w, msg, code)
+}
+
+// sessionKey resolves the session ID a hook sends, run in the ?project= it
+// names, to the session's store Key (see store.SessionKey). A failed lookup
+// falls back to the ID as sent.
+func (s *Server) sessionKey(r *http.Request, sessionID string) string {
+	key, err := s.db.SessionKey(sessionID, r.URL.Query().Get("project"))
+	if err != nil {
+		log.Printf("session key for %s: %v", sessionID, err)
+		return sessionID
+	}
+	return key
+}
+
 func (s *Server) handleSessionInit(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		SessionID string `json:"session_id"`
-		Project   string `json:"project"`
+		SessionID      string `json:"session_id"`
+		Project        string `json:"project"`
+		TranscriptPath string `json:"transcript_path"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		jsonError(w, "invalid json", http.StatusBadRequest)
@@ -42,22 +90,26 @@ func (s *Server) handleSessionInit(w http.ResponseWriter, r *http.Request) {
 	sess, err := s.db.InitSession(req.SessionID, req.Project)
 	if err != nil {
 		log.Printf("init session: %v", err)
-		jsonError(w, "internal error", http.StatusInternalServerError)
+		storeError(w, err)
 		return
 	}
+	if err := s.db.SetTranscriptPath(sess.Key, req.TranscriptPath); err != nil {
+		log.Printf("init session: record transcript path for %s: %v", sess.Key, err)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]any{
-		"session_id": sess.SessionID,
+		"session_id": sess.Key,
 		"status":     sess.Status,
 		"tool_count": sess.ToolCount,
 	})
 }
 
 func (s *Server) handleAddObservation(w http.ResponseWriter, r *http.Request) {
-	sessionID := chi.URLParam(r, "sessionID")
+	sessionID := s.sessionKey(r, chi.URLParam(r, "sessionID"))
 
 	var req struct {
+		ToolUseID    string `json:"tool_use_id"`
 		ToolName     string `json:"tool_name"`
 		ToolInput    string `json:"tool_input"`
 		ToolResponse string `json:"tool_response"`
@@ -72,9 +124,23 @@ func (s *Server) handleAddObservation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.db.AddObservation(sessionID, req.ToolName, req.ToolInput, req.ToolResponse); err != nil {
+	if config.SkipTool(req.ToolName, s.skipTools) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "skipped"})
+		return
+	}
+
+	added, err := s.db.AddObservation(sessionID, req.ToolUseID, req.ToolName, req.ToolInput, req.ToolResponse)
+	if err != nil {
 		log.Printf("add observation: %v", err)
-		jsonError(w, "internal error", http.StatusInternalServerError)
+		storeError(w, err)
+		return
+	}
+	if !added {
+		// A retry of a tool use already recorded: succeed without counting
+		// it twice.
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "duplicate"})
 		return
 	}
 
@@ -87,7 +153,7 @@ func (s *Server) handleAddObservation(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleCompleteSession(w http.ResponseWriter, r *http.Request) {
-	sessionID := chi.URLParam(r, "sessionID")
+	sessionID := s.sessionKey(r, chi.URLParam(r, "sessionID"))
 
 	if err := s.db.CompleteSession(sessionID); err != nil {
 		// Not finding an active session is not a server error — the session
@@ -103,20 +169,26 @@ func (s *Server) handleCompleteSession(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleEndSession(w http.ResponseWriter, r *http.Request) {
-	sessionID := chi.URLParam(r, "sessionID")
+	sessionID := s.sessionKey(r, chi.URLParam(r, "sessionID"))
 
 	if err := s.db.EndSession(sessionID); err != nil {
 		log.Printf("end session: %v", err)
-		jsonError(w, "internal error", http.StatusInternalServerError)
+		storeError(w, err)
 		return
 	}
+	// Session notes apply only while the session runs.
+	if n, err := s.db.DeleteSessionNotes(sessionID); err != nil {
+		log.Printf("end session: prune session notes for %s: %v", sessionID, err)
+	} else if n > 0 {
+		log.Printf("end session: pruned %d session note(s) for %s", n, sessionID)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "ended"})
 }
 
 func (s *Server) handleExtractSession(w http.ResponseWriter, r *http.Request) {
-	sessionID := chi.URLParam(r, "sessionID")
+	sessionID := s.sessionKey(r, chi.URLParam(r, "sessionID"))
 
 	var req struct {
 		TranscriptPath string `json:"transcript_path"`
@@ -127,15 +199,33 @@ func (s *Server) handleExtractSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Store the resolved path when there is one, so later re-processing
+	// doesn't depend on the hook's shell environment. An unresolvable path is
+	// kept as sent; extraction reports what it tried.
+	if sess, err := s.db.GetSession(sessionID); err == nil && sess != nil {
+		if p, err := transcript.ResolvePath(req.TranscriptPath, sess.Project); err == nil {
+			req.TranscriptPath = p
+		}
+	}
+
+	// Remember where the transcript lives so the session can be re-processed
+	// later (profile rebuild) without the hook resending it.
+	if err := s.db.SetTranscriptPath(sessionID, req.TranscriptPath); err != nil {
+		log.Printf("extract: record transcript path for %s: %v", sessionID, err)
+	}
+
 	if s.engine == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "engine not configured"})
+		jsonError(w, "engine not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.URL.Query().Get("sync") == "true" {
+		s.extractSessionSync(w, r, sessionID, req.TranscriptPath, req.Force)
 		return
 	}
 
-	// Async extraction — return 202 immediately
-	go func() {
+	// Async extraction on the bounded worker pool — 202 once queued
+	err := s.engine.Enqueue(func() {
 		var err error
 		if req.Force {
 			err = s.engine.ExtractSessionForce(sessionID, req.TranscriptPath)
@@ -145,18 +235,67 @@ func (s *Server) handleExtractSession(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			log.Printf("extraction failed for %s: %v", sessionID, err)
 		}
-	}()
+	})
+	if err != nil {
+		queueFull(w, err)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusAccepted)
 	json.NewEncoder(w).Encode(map[string]string{"status": "extracting"})
 }
 
+// syncExtractTimeout bounds how long ?sync=true holds the request open.
+// Extraction makes up to three sequential LLM calls, each allowed two minutes.
+const syncExtractTimeout = 5 * time.Minute
+
+// extractSessionSync runs extraction on the worker pool and waits for it,
+// answering 200 with the memories written. It still goes through the pool so
+// synchronous callers can't exceed the concurrency bound. On timeout the job
+// keeps running; the caller gets a 504 and can check `continuity sessions`.
+func (s *Server) extractSessionSync(w http.ResponseWriter, r *http.Request, sessionID, transcriptPath string, force bool) {
+	type outcome struct {
+		res engine.ExtractResult
+		err error
+	}
+	done := make(chan outcome, 1)
+	err := s.engine.Enqueue(func() {
+		res, err := s.engine.ExtractSessionResult(sessionID, transcriptPath, force)
+		done <- outcome{res, err}
+	})
+	if err != nil {
+		queueFull(w, err)
+		return
+	}
+
+	// Outlive the server-wide write timeout for this one response.
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(syncExtractTimeout + 10*time.Second))
+
+	select {
+	case out := <-done:
+		if out.err != nil {
+			log.Printf("extraction failed for %s: %v", sessionID, out.err)
+			jsonError(w, out.err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if out.res.Stored == nil {
+			out.res.Stored = []string{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(out.res)
+	case <-time.After(syncExtractTimeout):
+		jsonError(w, "extraction still running after "+syncExtractTimeout.String(), http.StatusGatewayTimeout)
+	case <-r.Context().Done():
+	}
+}
+
 func (s *Server) handleSignal(w http.ResponseWriter, r *http.Request) {
-	sessionID := chi.URLParam(r, "sessionID")
+	sessionID := s.sessionKey(r, chi.URLParam(r, "sessionID"))
 
 	var req struct {
-		Prompt string `json:"prompt"`
+		Prompt   string `json:"prompt"`
+		Category string `json:"category"` // optional; forces the memory's category
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		jsonError(w, "invalid json", http.StatusBadRequest)
@@ -166,28 +305,43 @@ func (s *Server) handleSignal(w http.ResponseWriter, r *http.Request) {
 		jsonError(w, "prompt required", http.StatusBadRequest)
 		return
 	}
+	if req.Category != "" && !engine.SignalCategory(req.Category) {
+		jsonError(w, "invalid category: "+req.Category, http.StatusBadRequest)
+		return
+	}
 
 	if s.engine == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "engine not configured"})
+		jsonError(w, "engine not configured", http.StatusServiceUnavailable)
 		return
 	}
 
-	// Async extraction — return 202 immediately
-	go func() {
+	// Async extraction on the bounded worker pool — 202 once queued. The
+	// timeout starts when a worker picks the job up, not while it waits.
+	err := s.engine.Enqueue(func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 		defer cancel()
-		if err := s.engine.ExtractSignal(ctx, sessionID, req.Prompt); err != nil {
+		if err := s.engine.ExtractSignalAs(ctx, sessionID, req.Prompt, req.Category); err != nil {
 			log.Printf("signal extraction failed for %s: %v", sessionID, err)
 		}
-	}()
+	})
+	if err != nil {
+		queueFull(w, err)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusAccepted)
 	json.NewEncoder(w).Encode(map[string]string{"status": "processing"})
 }
 
+// queueFull reports a refused Enqueue. Retry-After gives hooks and scripts a
+// hint; the stop hook re-fires on the next turn anyway.
+func queueFull(w http.ResponseWriter, err error) {
+	log.Printf("shedding background job: %v", err)
+	w.Header().Set("Retry-After", "30")
+	jsonError(w, err.Error(), http.StatusServiceUnavailable)
+}
+
 // handleUnmarkEmptyExtractions clears extracted_at on every session marked
 // as extracted but with zero memories attributed. This is the backfill path
 // for sessions that were silently locked out by the pre-fix mark-on-skip
@@ -196,7 +350,7 @@ func (s *Server) handleUnmarkEmptyExtractions(w http.ResponseWriter, r *http.Req
 	n, err := s.db.UnmarkEmptyExtractions()
 	if err != nil {
 		log.Printf("unmark empty extractions: %v", err)
-		jsonError(w, "internal error", http.StatusInternalServerError)
+		storeError(w, err)
 		return
 	}
 
@@ -207,12 +361,47 @@ func (s *Server) handleUnmarkEmptyExtractions(w http.ResponseWriter, r *http.Req
 	})
 }
 
+// handleListFailedExtractions lists the sessions whose extraction errored
+// and hasn't since succeeded.
+func (s *Server) handleListFailedExtractions(w http.ResponseWriter, r *http.Request) {
+	failed, err := s.db.ListFailedExtractions()
+	if err != nil {
+		log.Printf("list failed extractions: %v", err)
+		storeError(w, err)
+		return
+	}
+	if failed == nil {
+		failed = []store.FailedExtraction{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"failed": failed})
+}
+
+// handleRetryFailed queues another extraction of each failed session on the
+// worker pool and answers 202 with what it queued. Sessions past
+// engine.retry_failed_max_attempts are skipped unless ?all=true.
+func (s *Server) handleRetryFailed(w http.ResponseWriter, r *http.Request) {
+	if s.engine == nil {
+		jsonError(w, "engine not configured", http.StatusServiceUnavailable)
+		return
+	}
+	rep, err := s.engine.RetryFailed(r.URL.Query().Get("all") == "true")
+	if err != nil {
+		log.Printf("retry failed extractions: %v", err)
+		storeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(rep)
+}
+
 func (s *Server) handleGetMemory(w http.ResponseWriter, r *http.Request) {
 	uri := r.URL.Query().Get("uri")
 	if uri == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "uri parameter required"})
+		jsonError(w, "uri parameter required", http.StatusBadRequest)
 		return
 	}
 	includeRetracted := r.URL.Query().Get("include_retracted") == "true"
@@ -220,7 +409,7 @@ func (s *Server) handleGetMemory(w http.ResponseWriter, r *http.Request) {
 	node, err := s.db.GetNodeByURI(uri)
 	if err != nil {
 		log.Printf("get memory: %v", err)
-		jsonError(w, "internal error", http.StatusInternalServerError)
+		storeError(w, err)
 		return
 	}
 	if node == nil {
@@ -264,6 +453,21 @@ func (s *Server) handleGetMemory(w http.ResponseWriter, r *http.Request) {
 		"updated_at":   node.UpdatedAt,
 		"access_count": node.AccessCount,
 	}
+	if node.Origin != "" {
+		out["origin"] = node.Origin
+	}
+	// The sessions that wrote it, when recorded (see store.SessionMemory).
+	if sessions, err := s.db.MemorySessions(node.URI); err != nil {
+		log.Printf("get memory: sessions for %s: %v", node.URI, err)
+	} else if len(sessions) > 0 {
+		out["sessions"] = sessions
+	}
+	// The IDs of the nodes merged into it (manual merge or dedup).
+	if ids, err := store.ParseMergedFrom(node.MergedFrom); err != nil {
+		log.Printf("get memory: merged_from for %s: %v", node.URI, err)
+	} else if len(ids) > 0 {
+		out["merged_from"] = ids
+	}
 	if node.IsRetracted() {
 		out["retracted"] = true
 		out["tombstoned_at"] = *node.TombstonedAt
@@ -284,6 +488,7 @@ func (s *Server) handleRemember(w http.ResponseWriter, r *http.Request) {
 		Detail               string `json:"detail"`
 		SessionID            string `json:"session_id"`
 		AcknowledgeRetracted bool   `json:"acknowledge_retracted"`
+		Mergeable            *bool  `json:"mergeable"` // nil: the category default
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		jsonError(w, "invalid json", http.StatusBadRequest)
@@ -295,9 +500,7 @@ func (s *Server) handleRemember(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if s.engine == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "engine not configured"})
+		jsonError(w, "engine not configured", http.StatusServiceUnavailable)
 		return
 	}
 
@@ -310,8 +513,9 @@ func (s *Server) handleRemember(w http.ResponseWriter, r *http.Request) {
 		Summary:              req.Summary,
 		Body:                 req.Body,
 		Detail:               req.Detail,
-		SessionID:            req.SessionID,
+		SessionID:            s.sessionKey(r, req.SessionID),
 		AcknowledgeRetracted: req.AcknowledgeRetracted,
+		Mergeable:            req.Mergeable,
 	})
 	if err != nil {
 		// Dedup-against-retracted gate fired. Surface URIs only — reasons stay
@@ -331,11 +535,11 @@ func (s *Server) handleRemember(w http.ResponseWriter, r *http.Request) {
 		// out-of-bounds tier, retracted slug collision) are safe to surface
 		// verbatim. Internal failures stay generic — logged, not leaked.
 		if isValidation, msg := engine.IsValidationError(err); isValidation {
-			jsonError(w, msg, http.StatusBadRequest)
+			jsonError(w, msg, storeErrorStatus(err, http.StatusBadRequest))
 			return
 		}
 		log.Printf("remember: %v", err)
-		jsonError(w, "failed to store memory", http.StatusBadRequest)
+		jsonError(w, "failed to store memory", storeErrorStatus(err, http.StatusBadRequest))
 		return
 	}
 
@@ -351,6 +555,86 @@ func (s *Server) handleRemember(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"status": status, "uri": uri})
 }
 
+// handleCreateNode stores a full memory spec from an external tool. Unlike
+// POST /api/memories, the write goes through extraction's dedup: content
+// restating an existing memory updates it (or, for an immutable category, is
+// reported as a duplicate) rather than landing at a new URI.
+func (s *Server) handleCreateNode(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Category    string `json:"category"`
+		URIHint     string `json:"uri_hint"`
+		MergeTarget string `json:"merge_target"`
+		L0          string `json:"l0"`
+		L1          string `json:"l1"`
+		L2          string `json:"l2"`
+		SessionID   string `json:"session_id"`
+		Mergeable   *bool  `json:"mergeable"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if req.URIHint == "" && req.MergeTarget == "" {
+		jsonError(w, "uri_hint or merge_target is required", http.StatusBadRequest)
+		return
+	}
+	if req.MergeTarget == "" && req.Category == "" {
+		jsonError(w, "category is required with uri_hint", http.StatusBadRequest)
+		return
+	}
+
+	if s.engine == nil {
+		jsonError(w, "engine not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	res, err := s.engine.StoreNode(ctx, engine.NodeInput{
+		Category:    req.Category,
+		URIHint:     req.URIHint,
+		MergeTarget: req.MergeTarget,
+		L0:          req.L0,
+		L1:          req.L1,
+		L2:          req.L2,
+		SessionID:   s.sessionKey(r, req.SessionID),
+		Mergeable:   req.Mergeable,
+	})
+	if err != nil {
+		if isMatch, uris := engine.IsRetractedMatch(err); isMatch {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]any{
+				"status":       "matches_retracted",
+				"matched_uris": uris,
+			})
+			return
+		}
+		if isValidation, msg := engine.IsValidationError(err); isValidation {
+			jsonError(w, msg, storeErrorStatus(err, http.StatusBadRequest))
+			return
+		}
+		log.Printf("nodes: %v", err)
+		jsonError(w, "failed to store memory", storeErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	status := "updated"
+	code := http.StatusOK
+	switch {
+	case res.Duplicate:
+		status = "duplicate"
+	case res.Created:
+		status = "created"
+		code = http.StatusCreated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"status": status, "uri": res.URI})
+}
+
 func (s *Server) handleRetract(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		URI          string `json:"uri"`
@@ -371,9 +655,7 @@ func (s *Server) handleRetract(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if s.engine == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "engine not configured"})
+		jsonError(w, "engine not configured", http.StatusServiceUnavailable)
 		return
 	}
 
@@ -387,11 +669,11 @@ func (s *Server) handleRetract(w http.ResponseWriter, r *http.Request) {
 	})
 	if err != nil {
 		if isValidation, msg := engine.IsValidationError(err); isValidation {
-			jsonError(w, msg, http.StatusBadRequest)
+			jsonError(w, msg, storeErrorStatus(err, http.StatusBadRequest))
 			return
 		}
 		log.Printf("retract: %v", err)
-		jsonError(w, "failed to retract memory", http.StatusBadRequest)
+		jsonError(w, "failed to retract memory", storeErrorStatus(err, http.StatusBadRequest))
 		return
 	}
 
@@ -436,11 +718,11 @@ func (s *Server) handlePin(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		var pve *store.PinValidationError
 		if errors.As(err, &pve) {
-			jsonError(w, pve.Message, http.StatusBadRequest)
+			jsonError(w, pve.Message, storeErrorStatus(err, http.StatusBadRequest))
 			return
 		}
 		log.Printf("pin: %v", err)
-		jsonError(w, "failed to pin memory", http.StatusBadRequest)
+		jsonError(w, "failed to pin memory", storeErrorStatus(err, http.StatusBadRequest))
 		return
 	}
 
@@ -452,6 +734,117 @@ func (s *Server) handlePin(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]any{"status": status, "uri": req.URI})
 }
 
+// handleRelevance hand-sets a memory's relevance ({"uri", "relevance"}) or,
+// with "clear": true, hands it back to decay. Store-native like handlePin.
+func (s *Server) handleRelevance(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URI       string   `json:"uri"`
+		Relevance *float64 `json:"relevance"`
+		Clear     bool     `json:"clear"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if req.URI == "" {
+		jsonError(w, "uri is required", http.StatusBadRequest)
+		return
+	}
+	if !strings.HasPrefix(req.URI, "mem://") {
+		jsonError(w, fmt.Sprintf("invalid URI %q: must start with mem://", req.URI), http.StatusBadRequest)
+		return
+	}
+	if req.Clear == (req.Relevance != nil) {
+		jsonError(w, "exactly one of relevance or clear is required", http.StatusBadRequest)
+		return
+	}
+
+	resp := map[string]any{"uri": req.URI}
+	var err error
+	if req.Clear {
+		var cleared bool
+		cleared, err = s.db.ClearRelevance(req.URI)
+		resp["status"] = "cleared"
+		if !cleared {
+			resp["status"] = "not_set"
+		}
+	} else {
+		var stored float64
+		stored, err = s.db.SetRelevance(req.URI, *req.Relevance)
+		resp["status"] = "set"
+		resp["relevance"] = stored
+	}
+	if err != nil {
+		var rve *store.RelevanceValidationError
+		if errors.As(err, &rve) {
+			jsonError(w, rve.Message, storeErrorStatus(err, http.StatusBadRequest))
+			return
+		}
+		log.Printf("relevance: %v", err)
+		jsonError(w, "failed to set relevance", storeErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleMerge folds one memory into another (manual dedup). Store-native like
+// handlePin: both URIs are resolved here and db.MergeNodes enforces the rules.
+func (s *Server) handleMerge(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Keep  string `json:"keep"`
+		Merge string `json:"merge"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if req.Keep == "" || req.Merge == "" {
+		jsonError(w, "keep and merge are required", http.StatusBadRequest)
+		return
+	}
+
+	ids := make([]int64, 2)
+	for i, uri := range []string{req.Keep, req.Merge} {
+		if !strings.HasPrefix(uri, "mem://") {
+			jsonError(w, fmt.Sprintf("invalid URI %q: must start with mem://", uri), http.StatusBadRequest)
+			return
+		}
+		node, err := s.db.GetNodeByURI(uri)
+		if err != nil {
+			log.Printf("merge: %v", err)
+			storeError(w, err)
+			return
+		}
+		if node == nil {
+			jsonError(w, "memory not found: "+uri, http.StatusNotFound)
+			return
+		}
+		ids[i] = node.ID
+	}
+
+	kept, err := s.db.MergeNodes(ids[0], ids[1])
+	if err != nil {
+		var mve *store.MergeValidationError
+		if errors.As(err, &mve) {
+			jsonError(w, mve.Message, storeErrorStatus(err, http.StatusBadRequest))
+			return
+		}
+		log.Printf("merge: %v", err)
+		jsonError(w, "failed to merge memories", storeErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	log.Printf("merge: %s absorbed %s", req.Keep, req.Merge)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":      "merged",
+		"uri":         kept.URI,
+		"merged":      req.Merge,
+		"merged_from": kept.MergedFrom,
+	})
+}
+
 // handleUnpin clears an operator pin. Idempotent. Store-native (see handlePin).
 func (s *Server) handleUnpin(w http.ResponseWriter, r *http.Request) {
 	var req struct {
@@ -474,11 +867,11 @@ func (s *Server) handleUnpin(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		var pve *store.PinValidationError
 		if errors.As(err, &pve) {
-			jsonError(w, pve.Message, http.StatusBadRequest)
+			jsonError(w, pve.Message, storeErrorStatus(err, http.StatusBadRequest))
 			return
 		}
 		log.Printf("unpin: %v", err)
-		jsonError(w, "failed to unpin memory", http.StatusBadRequest)
+		jsonError(w, "failed to unpin memory", storeErrorStatus(err, http.StatusBadRequest))
 		return
 	}
 
@@ -497,7 +890,7 @@ func (s *Server) handleListPinned(w http.ResponseWriter, r *http.Request) {
 	pinned, err := s.db.ListPinned()
 	if err != nil {
 		log.Printf("list pinned: %v", err)
-		jsonError(w, "internal error", http.StatusInternalServerError)
+		storeError(w, err)
 		return
 	}
 
@@ -532,6 +925,60 @@ func (s *Server) handleListPinned(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleListEntities returns the structured entities extracted from
+// entities-category memories, optionally filtered by ?type= (case-insensitive).
+func (s *Server) handleListEntities(w http.ResponseWriter, r *http.Request) {
+	entityType := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("type")))
+	entities, err := s.db.ListEntities(entityType)
+	if err != nil {
+		log.Printf("list entities: %v", err)
+		storeError(w, err)
+		return
+	}
+
+	type entityJSON struct {
+		URI      string   `json:"uri"`
+		Type     string   `json:"type"`
+		Name     string   `json:"name"`
+		Location string   `json:"location,omitempty"`
+		Aliases  []string `json:"aliases,omitempty"`
+		L0       string   `json:"l0_abstract"`
+	}
+
+	out := make([]entityJSON, 0, len(entities))
+	for _, e := range entities {
+		out = append(out, entityJSON{
+			URI:      e.URI,
+			Type:     e.Type,
+			Name:     e.Name,
+			Location: e.Location,
+			Aliases:  e.Aliases,
+			L0:       e.L0,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"count":    len(out),
+		"entities": out,
+	})
+}
+
+// searchSessionID attributes a search to a session for injection-usefulness
+// tracking. An explicit ?session_id= wins; otherwise the search is credited to
+// the most recently started active session — the agent calling `continuity
+// search` mid-conversation is, in the single-user case, in that session.
+func (s *Server) searchSessionID(r *http.Request) string {
+	if id := r.URL.Query().Get("session_id"); id != "" {
+		return s.sessionKey(r, id)
+	}
+	recent, err := s.db.GetRecentSessions(1)
+	if err != nil || len(recent) == 0 || recent[0].Status != "active" {
+		return ""
+	}
+	return recent[0].Key
+}
+
 func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	if query == "" {
@@ -556,10 +1003,22 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 
 	category := r.URL.Query().Get("category")
 
+	// ?group=category returns the top ?limit (default 3) of each category
+	// instead of one global ranking.
+	group := r.URL.Query().Get("group")
+	switch group {
+	case "":
+	case "category":
+		if r.URL.Query().Get("limit") == "" {
+			limit = 3
+		}
+	default:
+		jsonError(w, "group must be category", http.StatusBadRequest)
+		return
+	}
+
 	if s.engine == nil || s.engine.Embedder == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "search not available — no embedder configured"})
+		jsonError(w, "search not available — no embedder configured", http.StatusServiceUnavailable)
 		return
 	}
 
@@ -568,16 +1027,42 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	// be meaningless (cosine across dimensions is 0). Surface the repair path
 	// instead of silently returning noise.
 	if locked, reason := s.engine.VectorIdentityLocked(); locked {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": reason})
+		jsonError(w, reason, http.StatusServiceUnavailable)
 		return
 	}
 
-	opts := engine.SearchOpts{
-		Limit:    limit,
-		Category: category,
+	// ?freshness= weights the recency bonus, clamped to [0, 1]; absent or
+	// unparseable means off. NaN and ±Inf parse but can't be clamped.
+	var freshness float64
+	if f := r.URL.Query().Get("freshness"); f != "" {
+		if x, err := strconv.ParseFloat(f, 64); err == nil {
+			if math.IsNaN(x) || math.IsInf(x, 0) {
+				jsonError(w, "freshness must be a finite number", http.StatusBadRequest)
+				return
+			}
+			freshness = math.Max(0, math.Min(1, x))
+		}
+	}
+
+	opts := s.engine.SearchOpts()
+	// ?min_score= overrides engine.search_min_score, clamped to [0, 1].
+	if m := r.URL.Query().Get("min_score"); m != "" {
+		x, err := strconv.ParseFloat(m, 64)
+		if err != nil || math.IsNaN(x) || math.IsInf(x, 0) {
+			jsonError(w, "min_score must be a finite number", http.StatusBadRequest)
+			return
+		}
+		opts.MinScore = math.Max(0, math.Min(1, x))
+	}
+	opts.Limit = limit
+	opts.Category = category
+	opts.SessionID = s.searchSessionID(r)
+	opts.FreshnessWeight = freshness
+	if group == "category" {
+		opts.PerCategory = limit
 	}
+	// ?rerank=true (mode=search only) has the LLM reorder the top candidates.
+	opts.Rerank = r.URL.Query().Get("rerank") == "true"
 
 	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
 	defer cancel()
@@ -594,7 +1079,7 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		log.Printf("search: %v", err)
-		jsonError(w, "internal error", http.StatusInternalServerError)
+		storeError(w, err)
 		return
 	}
 
@@ -606,30 +1091,288 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 		Score      float64 `json:"score"`
 		Similarity float64 `json:"similarity"`
 		Relevance  float64 `json:"relevance"`
+		Freshness  float64 `json:"freshness,omitempty"`
+		Snippet    string  `json:"snippet,omitempty"`
+	}
+
+	toJSON := func(results []engine.SearchResult) []resultJSON {
+		out := make([]resultJSON, len(results))
+		for i, r := range results {
+			out[i] = resultJSON{
+				URI:        r.Node.URI,
+				Category:   r.Node.Category,
+				L0Abstract: r.Node.L0Abstract,
+				L1Overview: r.Node.L1Overview,
+				Score:      r.Score,
+				Similarity: r.Similarity,
+				Relevance:  r.Node.Relevance,
+				Freshness:  r.Freshness,
+				Snippet:    r.Snippet,
+			}
+		}
+		return out
+	}
+
+	body := map[string]any{
+		"query": query,
+		"mode":  mode,
+		"count": len(results),
+	}
+	if opts.MinScore > 0 {
+		body["min_score"] = opts.MinScore
+	}
+	if group == "category" {
+		type groupJSON struct {
+			Category string       `json:"category"`
+			Results  []resultJSON `json:"results"`
+		}
+		groups := []groupJSON{}
+		for _, g := range engine.GroupByCategory(results) {
+			groups = append(groups, groupJSON{Category: g.Category, Results: toJSON(g.Results)})
+		}
+		body["groups"] = groups
+	} else {
+		body["results"] = toJSON(results)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+// sessionDetail is the JSON shape for the sessions list and detail endpoints.
+// skip_reason is set when the extraction content gate passed over the session,
+// so "no memories" reads as "skipped: too few messages" rather than a mystery.
+// session_id is the session's store Key, which every session endpoint accepts.
+type sessionDetail struct {
+	SessionID    string `json:"session_id"`
+	Project      string `json:"project"`
+	Status       string `json:"status"`
+	StartedAt    int64  `json:"started_at"`
+	EndedAt      *int64 `json:"ended_at,omitempty"`
+	MessageCount int    `json:"message_count"`
+	ToolCount    int    `json:"tool_count"`
+	ExtractedAt  *int64 `json:"extracted_at,omitempty"`
+	Tone         string `json:"tone,omitempty"`
+	SkipReason   string `json:"skip_reason,omitempty"`
+
+	TranscriptPath string `json:"transcript_path,omitempty"`
+
+	// Memories lists what the session wrote; detail endpoint only.
+	Memories []store.SessionMemory `json:"memories,omitempty"`
+}
+
+func toSessionDetail(sess *store.Session) sessionDetail {
+	d := sessionDetail{
+		SessionID:    sess.Key,
+		Project:      sess.Project,
+		Status:       sess.Status,
+		StartedAt:    sess.StartedAt,
+		EndedAt:      sess.EndedAt,
+		MessageCount: sess.MessageCount,
+		ToolCount:    sess.ToolCount,
+		ExtractedAt:  sess.ExtractedAt,
+	}
+	if sess.Tone != nil {
+		d.Tone = *sess.Tone
+	}
+	if sess.SkipReason != nil {
+		d.SkipReason = *sess.SkipReason
 	}
+	if sess.TranscriptPath != nil {
+		d.TranscriptPath = *sess.TranscriptPath
+	}
+	return d
+}
 
-	out := make([]resultJSON, len(results))
-	for i, r := range results {
-		out[i] = resultJSON{
-			URI:        r.Node.URI,
-			Category:   r.Node.Category,
-			L0Abstract: r.Node.L0Abstract,
-			L1Overview: r.Node.L1Overview,
-			Score:      r.Score,
-			Similarity: r.Similarity,
-			Relevance:  r.Node.Relevance,
+// handleListSessions returns the most recent sessions (default 20, ?limit=N).
+func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 && n <= 500 {
+			limit = n
 		}
 	}
 
+	sessions, err := s.db.GetRecentSessions(limit)
+	if err != nil {
+		log.Printf("list sessions: %v", err)
+		storeError(w, err)
+		return
+	}
+
+	out := make([]sessionDetail, 0, len(sessions))
+	for i := range sessions {
+		out = append(out, toSessionDetail(&sessions[i]))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// handleGetSession returns one session by its session_id.
+func (s *Server) handleGetSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := s.sessionKey(r, chi.URLParam(r, "sessionID"))
+
+	sess, err := s.db.GetSession(sessionID)
+	if err != nil {
+		log.Printf("get session: %v", err)
+		storeError(w, err)
+		return
+	}
+	if sess == nil {
+		jsonError(w, "session not found", http.StatusNotFound)
+		return
+	}
+	detail := toSessionDetail(sess)
+	if detail.Memories, err = s.db.SessionMemories(sessionID); err != nil {
+		log.Printf("get session: memories: %v", err)
+		storeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// handleCondensedSession returns the condensed transcript extraction sees
+// for a session, re-read from its recorded transcript path. Read-only.
+func (s *Server) handleCondensedSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := s.sessionKey(r, chi.URLParam(r, "sessionID"))
+	if s.engine == nil {
+		jsonError(w, "engine not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	sess, err := s.db.GetSession(sessionID)
+	if err != nil {
+		log.Printf("condensed: %v", err)
+		storeError(w, err)
+		return
+	}
+	if sess == nil {
+		jsonError(w, "session not found", http.StatusNotFound)
+		return
+	}
+	if sess.TranscriptPath == nil || *sess.TranscriptPath == "" {
+		jsonError(w, "no transcript path recorded for this session", http.StatusNotFound)
+		return
+	}
+	path, err := transcript.ResolvePath(*sess.TranscriptPath, sess.Project)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	condensed, err := s.engine.CondensedTranscript(path)
+	if err != nil {
+		log.Printf("condensed %s: %v", sessionID, err)
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]any{
-		"query":   query,
-		"mode":    mode,
-		"count":   len(out),
-		"results": out,
+		"session_id":      sessionID,
+		"transcript_path": path,
+		"chars":           len(condensed),
+		"condensed":       condensed,
 	})
 }
 
+// handleMergeSessions folds one session into another (see
+// store.MergeSessions) and returns the combined session.
+func (s *Server) handleMergeSessions(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Keep  string `json:"keep"`
+		Merge string `json:"merge"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if req.Keep == "" || req.Merge == "" {
+		jsonError(w, "keep and merge are required", http.StatusBadRequest)
+		return
+	}
+	req.Keep, req.Merge = s.sessionKey(r, req.Keep), s.sessionKey(r, req.Merge)
+	for _, id := range []string{req.Keep, req.Merge} {
+		sess, err := s.db.GetSession(id)
+		if err != nil {
+			log.Printf("merge sessions: %v", err)
+			storeError(w, err)
+			return
+		}
+		if sess == nil {
+			jsonError(w, "session not found: "+id, http.StatusNotFound)
+			return
+		}
+	}
+
+	sess, err := s.db.MergeSessions(req.Keep, req.Merge)
+	if err != nil {
+		var mve *store.MergeValidationError
+		if errors.As(err, &mve) {
+			jsonError(w, mve.Message, storeErrorStatus(err, http.StatusBadRequest))
+			return
+		}
+		log.Printf("merge sessions: %v", err)
+		jsonError(w, "failed to merge sessions", storeErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	log.Printf("merge sessions: %s absorbed %s", req.Keep, req.Merge)
+
+	// The merge cleared the extraction mark; re-extract the whole
+	// conversation now unless it is still running, in which case the stop
+	// hook will. A full queue only delays it to the next retry or hook.
+	if s.engine != nil && sess.Status != "active" && sess.TranscriptPath != nil {
+		keep, path := sess.Key, *sess.TranscriptPath
+		if err := s.engine.Enqueue(func() {
+			if err := s.engine.ExtractSession(keep, path); err != nil {
+				log.Printf("extraction failed for merged session %s: %v", keep, err)
+			}
+		}); err != nil {
+			log.Printf("merge sessions: queue extraction for %s: %v", keep, err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toSessionDetail(sess))
+}
+
+// handleForgetSession deletes a finished session and what was recorded
+// under it, and with "memories" the memories it alone created (see
+// store.ForgetSession).
+func (s *Server) handleForgetSession(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SessionID string `json:"session_id"`
+		Memories  bool   `json:"memories"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if req.SessionID == "" {
+		jsonError(w, "session_id is required", http.StatusBadRequest)
+		return
+	}
+	key := s.sessionKey(r, req.SessionID)
+
+	rep, err := s.db.ForgetSession(key, req.Memories)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) || errors.Is(err, store.ErrConflict) {
+			jsonError(w, err.Error(), storeErrorStatus(err, http.StatusBadRequest))
+			return
+		}
+		log.Printf("forget session: %v", err)
+		storeError(w, err)
+		return
+	}
+	log.Printf("forget session: %s (%d memories deleted, %d kept)", key, len(rep.Deleted), len(rep.Kept))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rep)
+}
+
 func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
 	sinceStr := r.URL.Query().Get("since")
 	sinceMs := int64(0)
@@ -646,7 +1389,7 @@ func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
 	sessions, err := s.db.GetSessionsSince(sinceMs)
 	if err != nil {
 		log.Printf("timeline: %v", err)
-		jsonError(w, "internal error", http.StatusInternalServerError)
+		storeError(w, err)
 		return
 	}
 
@@ -680,18 +1423,125 @@ func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	m, err := s.db.ComputeMetrics()
 	if err != nil {
 		log.Printf("metrics: %v", err)
-		jsonError(w, "internal error", http.StatusInternalServerError)
+		storeError(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(m)
 }
 
+// statsResponse is store.Stats plus what only the running server knows.
+type statsResponse struct {
+	*store.Stats
+	Coverage      float64 `json:"coverage"` // embedded / leaves, 0..1
+	DBPath        string  `json:"db_path"`
+	DBBytes       int64   `json:"db_bytes"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+}
+
+// handleStats serves the `continuity stats` summary, so dashboards and the
+// CLI can read it without opening the database beside the server.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	st, err := s.db.Stats()
+	if err != nil {
+		log.Printf("stats: %v", err)
+		storeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statsResponse{
+		Stats:         st,
+		Coverage:      st.Coverage(),
+		DBPath:        s.db.Path,
+		DBBytes:       store.FileSize(s.db.Path),
+		UptimeSeconds: time.Since(s.started).Seconds(),
+	})
+}
+
+// maintainTimeout bounds how long POST /api/maintain may hold its response
+// open; VACUUM rewrites the whole database.
+const maintainTimeout = 10 * time.Minute
+
+// handleMaintain checkpoints and truncates the WAL and VACUUMs the database
+// (?vacuum=false: checkpoint only), returning the file sizes before and after.
+// It runs inline on the server's own connection, so no CLI has to contend
+// with the server for the lock.
+func (s *Server) handleMaintain(w http.ResponseWriter, r *http.Request) {
+	vacuum := r.URL.Query().Get("vacuum") != "false"
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(maintainTimeout))
+
+	start := time.Now()
+	rep, err := s.db.Maintain(vacuum)
+	if err != nil {
+		log.Printf("maintain: %v", err)
+		jsonError(w, "maintenance failed", storeErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	log.Printf("maintain: %d+%d bytes -> %d+%d (db+wal) in %s", rep.DBBytesBefore, rep.WALBytesBefore,
+		rep.DBBytesAfter, rep.WALBytesAfter, time.Since(start).Round(time.Millisecond))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rep)
+}
+
+// handleRebuildProfile reconstructs the relational profile from the last N
+// sessions' stored transcripts (body {"sessions": N}, default 10, max 100).
+// Runs on the worker pool like extraction: N sequential LLM calls is far too
+// slow to hold the request open.
+func (s *Server) handleRebuildProfile(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Sessions int `json:"sessions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		jsonError(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	n := req.Sessions
+	if n <= 0 {
+		n = 10
+	}
+	if n > 100 {
+		n = 100
+	}
+
+	if s.engine == nil {
+		jsonError(w, "engine not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	sessions, err := s.db.RecentTranscriptSessions(n)
+	if err != nil {
+		log.Printf("profile rebuild: %v", err)
+		storeError(w, err)
+		return
+	}
+	if len(sessions) == 0 {
+		jsonError(w, "no completed sessions with a recorded transcript path", http.StatusConflict)
+		return
+	}
+
+	err = s.engine.Enqueue(func() {
+		if _, err := s.engine.RebuildProfile(n); err != nil {
+			log.Printf("profile rebuild failed: %v", err)
+		}
+	})
+	if err != nil {
+		queueFull(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":   "rebuilding",
+		"sessions": len(sessions),
+	})
+}
+
 func (s *Server) handleProfile(w http.ResponseWriter, r *http.Request) {
 	relProfile, err := s.db.GetNodeByURI("mem://user/profile/communication")
 	if err != nil {
 		log.Printf("profile: %v", err)
-		jsonError(w, "internal error", http.StatusInternalServerError)
+		storeError(w, err)
 		return
 	}
 
@@ -737,6 +1587,11 @@ func (s *Server) handleProfile(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleTree(w http.ResponseWriter, r *http.Request) {
 	uri := r.URL.Query().Get("uri")
 	includeRetracted := r.URL.Query().Get("include_retracted") == "true"
+	order, err := store.ParseChildOrder(r.URL.Query().Get("sort"))
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	type treeNodeJSON struct {
 		URI        string `json:"uri"`
@@ -747,16 +1602,25 @@ func (s *Server) handleTree(w http.ResponseWriter, r *http.Request) {
 		Children   int    `json:"children,omitempty"`
 		Retracted  bool   `json:"retracted,omitempty"`
 		Pinned     bool   `json:"pinned,omitempty"`
+
+		// Relevance is reported for leaves; RelevanceSet marks a hand-set
+		// value (`continuity boost`).
+		Relevance    float64 `json:"relevance,omitempty"`
+		RelevanceSet bool    `json:"relevance_set,omitempty"`
+
+		// Links are a live leaf's relationships, both directions.
+		Links []store.Link `json:"links,omitempty"`
 	}
 
 	var nodes []treeNodeJSON
+	sessionID := s.searchSessionID(r)
 
 	if uri == "" {
 		// List roots (dirs are never retracted; no flag needed)
 		roots, err := s.db.ListRoots()
 		if err != nil {
 			log.Printf("tree roots: %v", err)
-			jsonError(w, "internal error", http.StatusInternalServerError)
+			storeError(w, err)
 			return
 		}
 		for _, r := range roots {
@@ -776,18 +1640,20 @@ func (s *Server) handleTree(w http.ResponseWriter, r *http.Request) {
 	} else {
 		// List children
 		var children []store.MemNode
-		var err error
 		if includeRetracted {
-			children, err = s.db.GetChildrenIncludingRetracted(uri)
+			children, err = s.db.GetChildrenIncludingRetracted(uri, order)
 		} else {
-			children, err = s.db.GetChildren(uri)
+			children, err = s.db.GetChildren(uri, order)
 		}
 		if err != nil {
 			log.Printf("tree children: %v", err)
-			jsonError(w, "internal error", http.StatusInternalServerError)
+			storeError(w, err)
 			return
 		}
 		for _, c := range children {
+			if c.IsForeignSessionNote(sessionID) {
+				continue
+			}
 			tn := treeNodeJSON{
 				URI:       c.URI,
 				NodeType:  c.NodeType,
@@ -795,11 +1661,22 @@ func (s *Server) handleTree(w http.ResponseWriter, r *http.Request) {
 				Retracted: c.IsRetracted(),
 				Pinned:    c.IsPinned(),
 			}
+			if c.NodeType == "leaf" {
+				tn.Relevance = c.Relevance
+				tn.RelevanceSet = c.IsRelevanceSet()
+			}
 			// Suppress content fields on retracted nodes — same absence-not-empty
 			// principle as handleGetMemory.
 			if !c.IsRetracted() {
 				tn.L0Abstract = c.L0Abstract
 				tn.L1Overview = c.L1Overview
+				if c.NodeType == "leaf" {
+					links, err := s.db.GetLinks(c.URI)
+					if err != nil {
+						log.Printf("tree links: %v", err)
+					}
+					tn.Links = links
+				}
 			}
 			if c.NodeType == "dir" {
 				var count int
@@ -3,11 +3,15 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/lazypower/continuity/internal/config"
 	"github.com/lazypower/continuity/internal/engine"
 	"github.com/lazypower/continuity/internal/store"
 )
@@ -67,6 +71,52 @@ func TestAddObservation(t *testing.T) {
 	}
 }
 
+func TestAddObservationRetryIsIdempotent(t *testing.T) {
+	srv := testServer(t)
+
+	req := newTestRequest("POST", "/api/sessions/init", strings.NewReader(`{"session_id":"test-001","project":"/tmp/myproject"}`))
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+
+	obsBody := `{"tool_use_id":"toolu_01","tool_name":"Bash","tool_input":"{}","tool_response":"ok"}`
+	for i, want := range []int{http.StatusCreated, http.StatusOK} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newTestRequest("POST", "/api/sessions/test-001/observations", strings.NewReader(obsBody)))
+		if w.Code != want {
+			t.Fatalf("POST #%d status = %d, want %d; body: %s", i+1, w.Code, want, w.Body.String())
+		}
+	}
+
+	sess, err := srv.db.GetSession("test-001")
+	if err != nil || sess == nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.ToolCount != 1 {
+		t.Errorf("tool_count = %d after a retried POST, want 1", sess.ToolCount)
+	}
+}
+
+func TestAddObservationSkipsConfiguredTools(t *testing.T) {
+	srv := testServer(t)
+	srv.SetSkipTools([]string{"Read"})
+
+	req := newTestRequest("POST", "/api/sessions/init", strings.NewReader(`{"session_id":"test-001","project":"/tmp/myproject"}`))
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+
+	for tool, want := range map[string]int{"Read": http.StatusOK, "TodoWrite": http.StatusOK, "Bash": http.StatusCreated} {
+		w := httptest.NewRecorder()
+		body := `{"tool_name":"` + tool + `","tool_input":"{}","tool_response":"ok"}`
+		srv.ServeHTTP(w, newTestRequest("POST", "/api/sessions/test-001/observations", strings.NewReader(body)))
+		if w.Code != want {
+			t.Errorf("%s: status = %d, want %d; body: %s", tool, w.Code, want, w.Body.String())
+		}
+	}
+
+	sess, _ := srv.db.GetSession("test-001")
+	if sess == nil || sess.ToolCount != 1 {
+		t.Errorf("session = %+v, want only the Bash observation counted", sess)
+	}
+}
+
 func TestCompleteSession(t *testing.T) {
 	srv := testServer(t)
 
@@ -117,6 +167,56 @@ func TestEndSession(t *testing.T) {
 	}
 }
 
+func TestSessionInitRecordsTranscriptPath(t *testing.T) {
+	srv := testServer(t)
+
+	body := `{"session_id":"test-001","project":"/tmp/myproject","transcript_path":"/tmp/t.jsonl"}`
+	req := newTestRequest("POST", "/api/sessions/init", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("init: status = %d; body: %s", w.Code, w.Body.String())
+	}
+
+	req = newTestRequest("GET", "/api/sessions/test-001", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	var got map[string]any
+	json.NewDecoder(w.Body).Decode(&got)
+	if got["transcript_path"] != "/tmp/t.jsonl" {
+		t.Errorf("transcript_path = %v, want /tmp/t.jsonl", got["transcript_path"])
+	}
+}
+
+func TestSessionMemoriesBothDirections(t *testing.T) {
+	srv := testServer(t)
+	srv.ServeHTTP(httptest.NewRecorder(), newTestRequest("POST", "/api/sessions/init", strings.NewReader(`{"session_id":"prov","project":"/tmp/p"}`)))
+	srv.db.CreateNode(&store.MemNode{URI: "mem://user/events/shipped", NodeType: "leaf", Category: "events", L0Abstract: "Shipped"})
+	if err := srv.db.RecordSessionMemory("prov", "mem://user/events/shipped", true); err != nil {
+		t.Fatalf("RecordSessionMemory: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, newTestRequest("GET", "/api/sessions/prov", nil))
+	var sess struct {
+		Memories []store.SessionMemory `json:"memories"`
+	}
+	json.NewDecoder(w.Body).Decode(&sess)
+	if len(sess.Memories) != 1 || sess.Memories[0].URI != "mem://user/events/shipped" || !sess.Memories[0].Created {
+		t.Errorf("session memories = %+v", sess.Memories)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, newTestRequest("GET", "/api/memories?uri=mem://user/events/shipped", nil))
+	var mem struct {
+		Sessions []store.SessionMemory `json:"sessions"`
+	}
+	json.NewDecoder(w.Body).Decode(&mem)
+	if len(mem.Sessions) != 1 || mem.Sessions[0].SessionID != "prov" {
+		t.Errorf("memory sessions = %+v", mem.Sessions)
+	}
+}
+
 func TestSignalRouteNoEngine(t *testing.T) {
 	srv := testServer(t) // engine is nil
 
@@ -130,6 +230,39 @@ func TestSignalRouteNoEngine(t *testing.T) {
 	}
 }
 
+func TestSignalRouteQueueFull(t *testing.T) {
+	srv := testServerWithEngine(t)
+	cfg := config.Default().Engine
+	cfg.Workers = 1
+	cfg.QueueSize = 1
+	srv.engine.SetConfig(cfg)
+	defer srv.engine.Stop()
+
+	// Saturate the pool: one job running, one waiting.
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	if err := srv.engine.Enqueue(func() { close(started); <-release }); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	<-started
+	if err := srv.engine.Enqueue(func() {}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	body := `{"prompt":"remember this: always use WAL mode"}`
+	req := newTestRequest("POST", "/api/sessions/test-001/signal", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("signal with full queue: status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("queue-full response should carry Retry-After")
+	}
+}
+
 func TestSignalRouteMissingPrompt(t *testing.T) {
 	srv := testServer(t)
 
@@ -177,6 +310,282 @@ func TestGetContext(t *testing.T) {
 	}
 }
 
+func TestGetContextCategoriesScope(t *testing.T) {
+	srv := testServer(t)
+	for _, n := range []store.MemNode{
+		{URI: "mem://agent/patterns/retry-backoff", Category: "patterns", L0Abstract: "Retries use jittered backoff"},
+		{URI: "mem://user/events/launch", Category: "events", L0Abstract: "Launched the beta"},
+	} {
+		n.NodeType = "leaf"
+		if err := srv.db.CreateNode(&n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newTestRequest("GET", path, nil))
+		return w
+	}
+
+	full := get("/api/context")
+	if !strings.Contains(full.Body.String(), "jittered backoff") || !strings.Contains(full.Body.String(), "Launched the beta") {
+		t.Fatalf("unscoped context should rank both: %s", full.Body.String())
+	}
+
+	scoped := get("/api/context?categories=patterns,cases")
+	if scoped.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", scoped.Code, scoped.Body.String())
+	}
+	if !strings.Contains(scoped.Body.String(), "jittered backoff") || strings.Contains(scoped.Body.String(), "Launched the beta") {
+		t.Errorf("scoped context should rank only patterns and cases: %s", scoped.Body.String())
+	}
+	if scoped.Header().Get("ETag") == full.Header().Get("ETag") {
+		t.Error("a scoped block must not share the unscoped block's ETag")
+	}
+
+	// A typo in the hook's environment drops that name, not the context.
+	typo := get("/api/context?categories=patterns,paterns")
+	if typo.Code != http.StatusOK {
+		t.Fatalf("unknown category: status = %d, want 200", typo.Code)
+	}
+	if typo.Header().Get("ETag") != get("/api/context?categories=patterns").Header().Get("ETag") {
+		t.Error("an unknown category should be dropped from the scope")
+	}
+	if only := get("/api/context?categories=paterns"); only.Header().Get("ETag") != full.Header().Get("ETag") {
+		t.Error("a scope of only unknown categories should render the unscoped block")
+	}
+}
+
+func TestGetContextLightWeight(t *testing.T) {
+	srv := testServer(t)
+	for i := range 8 {
+		if err := srv.db.CreateNode(&store.MemNode{
+			URI: fmt.Sprintf("mem://agent/patterns/p%d", i), NodeType: "leaf", Category: "patterns",
+			L0Abstract: fmt.Sprintf("Pattern number %d", i),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := srv.SetContextThrottle(10, 2); err != nil {
+		t.Fatal(err)
+	}
+	// The last session in /tmp/proj was a quick one: a single tool call.
+	srv.db.InitSession("quick", "/tmp/proj")
+	srv.db.IncrementToolCount("quick")
+	srv.db.CompleteSession("quick")
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newTestRequest("GET", path, nil))
+		return w
+	}
+	items := func(w *httptest.ResponseRecorder) int { return strings.Count(w.Body.String(), "Pattern number") }
+
+	full := get("/api/context?session_id=next")
+	light := get("/api/context?session_id=next&project=/tmp/proj")
+	if items(full) != 8 || items(light) != 2 {
+		t.Errorf("items: no project %d, after a quick session %d; want 8 and 2", items(full), items(light))
+	}
+	if full.Header().Get("ETag") == light.Header().Get("ETag") {
+		t.Error("a light block must not share the full block's ETag")
+	}
+	if n := items(get("/api/context?project=/tmp/proj&weight=full")); n != 8 {
+		t.Errorf("weight=full after a quick session: %d items, want 8", n)
+	}
+	if n := items(get("/api/context?weight=light")); n != 2 {
+		t.Errorf("weight=light: %d items, want 2", n)
+	}
+	if n := items(get("/api/context?project=/tmp/other")); n != 8 {
+		t.Errorf("project with no history: %d items, want 8", n)
+	}
+	if w := get("/api/context?weight=heavy"); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown weight: status = %d, want 400", w.Code)
+	}
+
+	// A substantial previous session gets the full block back.
+	srv.db.Exec(`UPDATE sessions SET tool_count = 40 WHERE session_id = 'quick'`)
+	if n := items(get("/api/context?project=/tmp/proj")); n != 8 {
+		t.Errorf("after a long session: %d items, want 8", n)
+	}
+}
+
+func TestSessionIDReusedAcrossProjects(t *testing.T) {
+	srv := testServer(t)
+	post := func(path, body string) {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newTestRequest("POST", path, strings.NewReader(body)))
+		if w.Code >= 300 {
+			t.Fatalf("POST %s: status %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+	for _, project := range []string{"/code/alpha", "/code/beta"} {
+		post("/api/sessions/init", `{"session_id":"dup","project":"`+project+`"}`)
+	}
+	post("/api/sessions/dup/observations?project=/code/alpha/cmd", `{"tool_name":"Bash","tool_input":"{}","tool_response":"ok"}`)
+	post("/api/sessions/dup/complete?project=/code/alpha", "")
+
+	alpha, _ := srv.db.GetProjectSession("dup", "/code/alpha")
+	beta, _ := srv.db.GetProjectSession("dup", "/code/beta")
+	if alpha.ToolCount != 1 || alpha.Status != "completed" {
+		t.Errorf("alpha = %+v, want its tool call and completed", alpha)
+	}
+	if beta.ToolCount != 0 || beta.Status != "active" {
+		t.Errorf("beta = %+v, want it untouched", beta)
+	}
+	if n, _ := srv.db.GetSessionObservationCount(beta.Key); n != 0 {
+		t.Errorf("beta observations = %d, want 0", n)
+	}
+
+	// The detail endpoint answers to the key it lists.
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, newTestRequest("GET", "/api/sessions/"+beta.Key, nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"project":"/code/beta"`) {
+		t.Errorf("GET beta by key: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSessionNotesScopedToTheirSession(t *testing.T) {
+	srv := testServer(t)
+	for _, id := range []string{"s1", "s2"} {
+		srv.ServeHTTP(httptest.NewRecorder(), newTestRequest("POST", "/api/sessions/init", strings.NewReader(`{"session_id":"`+id+`","project":"/tmp/proj"}`)))
+	}
+	if err := srv.db.CreateNode(&store.MemNode{
+		URI: "mem://user/session/s1/staging-freeze", NodeType: "leaf", Category: "session",
+		L0Abstract: "Don't touch the staging database today", SourceSession: "s1",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(sessionID, etag string) *httptest.ResponseRecorder {
+		req := newTestRequest("GET", "/api/context?session_id="+sessionID, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	s1 := get("s1", "")
+	if ctx := s1.Body.String(); !strings.Contains(ctx, "### This Session") || !strings.Contains(ctx, "staging database") {
+		t.Errorf("s1 should see its note: %s", ctx)
+	}
+	if ctx := get("s2", "").Body.String(); strings.Contains(ctx, "staging database") {
+		t.Errorf("s2 must not see s1's note: %s", ctx)
+	}
+	// The hook caches one block for every session: s2 revalidating with
+	// s1's tag must get its own block, not a 304 that replays s1's note.
+	if w := get("s2", s1.Header().Get("ETag")); w.Code != http.StatusOK || strings.Contains(w.Body.String(), "staging database") {
+		t.Errorf("s2 with s1's ETag: status %d; want 200 without s1's note", w.Code)
+	}
+	for id, want := range map[string]bool{"s1": true, "s2": false} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newTestRequest("GET", "/api/tree?uri=mem://user/session/s1&session_id="+id, nil))
+		if got := strings.Contains(w.Body.String(), "staging-freeze"); got != want {
+			t.Errorf("tree for %s lists s1's note: %v, want %v", id, got, want)
+		}
+	}
+
+	srv.ServeHTTP(httptest.NewRecorder(), newTestRequest("POST", "/api/sessions/s1/end", nil))
+	if n, _ := srv.db.GetNodeByURI("mem://user/session/s1/staging-freeze"); n != nil {
+		t.Error("ending the session should prune its notes")
+	}
+}
+
+func TestGetContextETag(t *testing.T) {
+	srv := testServer(t)
+
+	get := func(etag string) *httptest.ResponseRecorder {
+		req := newTestRequest("GET", "/api/context", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first GET: status %d, ETag %q", first.Code, etag)
+	}
+
+	if w := get(etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("unchanged store: status %d, body %q; want empty 304", w.Code, w.Body.String())
+	}
+
+	if err := srv.db.CreateNode(&store.MemNode{
+		URI: "mem://user/preferences/etag-pref", NodeType: "leaf", Category: "preferences",
+		L0Abstract: "Prefers conditional requests",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	w := get(etag)
+	if w.Code != http.StatusOK {
+		t.Fatalf("after a write: status %d, want 200", w.Code)
+	}
+	if w.Header().Get("ETag") == etag {
+		t.Error("ETag unchanged after a node write")
+	}
+	if !strings.Contains(w.Body.String(), "Prefers conditional requests") {
+		t.Errorf("rebuilt context missing new memory: %s", w.Body.String())
+	}
+}
+
+func TestGetContextETagPerSession(t *testing.T) {
+	srv := testServer(t)
+	if err := srv.db.CreateNode(&store.MemNode{
+		URI: "mem://user/preferences/etag-pref", NodeType: "leaf", Category: "preferences",
+		L0Abstract: "Prefers conditional requests",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(sessionID, etag string) *httptest.ResponseRecorder {
+		req := newTestRequest("GET", "/api/context?session_id="+sessionID, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	injections := func(sessionID string) int {
+		var n int
+		srv.db.QueryRow(`SELECT COUNT(*) FROM context_injections WHERE session_id = ?`, sessionID).Scan(&n)
+		return n
+	}
+
+	first := get("sess-a", "")
+	etagA := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etagA == "" || injections("sess-a") == 0 {
+		t.Fatalf("first GET: status %d, ETag %q, %d injections", first.Code, etagA, injections("sess-a"))
+	}
+
+	// Another session's cached tag must not get it a 304 of sess-a's block.
+	if w := get("sess-b", etagA); w.Code != http.StatusOK || w.Header().Get("ETag") == etagA {
+		t.Errorf("sess-b with sess-a's ETag: status %d, ETag %q; want 200 with its own tag", w.Code, w.Header().Get("ETag"))
+	}
+
+	// A 304 still records the cached block's injections.
+	srv.db.Exec(`DELETE FROM context_injections WHERE session_id = 'sess-a'`)
+	if w := get("sess-a", etagA); w.Code != http.StatusNotModified {
+		t.Fatalf("sess-a revalidating: status %d, want 304", w.Code)
+	}
+	if injections("sess-a") == 0 {
+		t.Error("a 304 recorded no injections for the session")
+	}
+
+	// A tag the server never served (it restarted, say) gets the block rebuilt.
+	srv.servedMu.Lock()
+	srv.served = nil
+	srv.servedMu.Unlock()
+	if w := get("sess-a", etagA); w.Code != http.StatusOK {
+		t.Errorf("sess-a after the server forgot its block: status %d, want 200", w.Code)
+	}
+}
+
 func testServerWithEngine(t *testing.T) *Server {
 	t.Helper()
 	db, err := store.OpenMemory()
@@ -216,6 +625,46 @@ func TestRememberRouteInvalidCategorySurfacesReason(t *testing.T) {
 	}
 }
 
+func TestRememberRouteRecordsSessionMemory(t *testing.T) {
+	srv := testServerWithEngine(t)
+	srv.db.InitSession("writer", "/tmp/proj")
+
+	body := `{"category":"events","name":"deploy","summary":"Deployed the API to staging","body":"Deployed the API to staging after the migration review passed.","session_id":"writer"}`
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, newTestRequest("POST", "/api/memories", strings.NewReader(body)))
+	if w.Code != http.StatusOK && w.Code != http.StatusCreated {
+		t.Fatalf("remember: status %d, body %s", w.Code, w.Body.String())
+	}
+	body = `{"category":"events","uri_hint":"release","l0":"Tagged release 1.4","l1":"Tagged and published release 1.4 of the API.","session_id":"writer"}`
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, newTestRequest("POST", "/api/nodes", strings.NewReader(body)))
+	if w.Code != http.StatusOK && w.Code != http.StatusCreated {
+		t.Fatalf("nodes: status %d, body %s", w.Code, w.Body.String())
+	}
+
+	got, err := srv.db.SessionMemories("writer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !got[0].Created || !got[1].Created {
+		t.Errorf("session memories = %+v, want both writes recorded as creations", got)
+	}
+
+	// Forgetting the session can take what it created with it.
+	srv.db.CompleteSession("writer")
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, newTestRequest("POST", "/api/sessions/forget", strings.NewReader(`{"session_id":"writer","memories":true}`)))
+	var rep store.ForgetReport
+	if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil || w.Code != http.StatusOK || len(rep.Deleted) != 2 {
+		t.Errorf("forget: status %d, report %+v (%v)", w.Code, rep, err)
+	}
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, newTestRequest("POST", "/api/sessions/forget", strings.NewReader(`{"session_id":"writer"}`)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("forgetting it again: status %d, want 404", w.Code)
+	}
+}
+
 // TestRememberRouteRetractedMatchStillSequestered verifies the issue #35 change
 // did not disturb the retracted-match 409 path: a write that semantically
 // collides with a retracted memory must still get a 409 with matched URIs and
@@ -359,8 +808,8 @@ func TestRememberRouteInternalErrorStaysGenericOverHTTP(t *testing.T) {
 // TestRetractRouteStoreDomainRejectionSurfacesReason is the issue #35 follow-up:
 // store-level domain rejections (here, retracting a URI that does not exist) used
 // to fall through Engine.Retract unclassified and collapse into the generic
-// "failed to retract memory". They must now be surfaced as 400 with their real,
-// client-safe reason.
+// "failed to retract memory". They must now be surfaced with their real,
+// client-safe reason — 404 here, since the store tags it ErrNotFound.
 func TestRetractRouteStoreDomainRejectionSurfacesReason(t *testing.T) {
 	srv := testServerWithEngine(t)
 
@@ -371,8 +820,8 @@ func TestRetractRouteStoreDomainRejectionSurfacesReason(t *testing.T) {
 	w := httptest.NewRecorder()
 	srv.ServeHTTP(w, req)
 
-	if w.Code != http.StatusBadRequest {
-		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusNotFound, w.Body.String())
 	}
 
 	var resp map[string]string
@@ -408,6 +857,44 @@ func TestRememberRoute(t *testing.T) {
 	}
 }
 
+func TestCreateNodeRoute(t *testing.T) {
+	srv := testServerWithEngine(t)
+	post := func(body string) (int, map[string]string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newTestRequest("POST", "/api/nodes", strings.NewReader(body)))
+		var resp map[string]string
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	code, resp := post(`{"category":"events","uri_hint":"deploy-ingest-v2","l0":"Deployed ingest v2 to production","l1":"Ingest v2.0.0 deployed to production by the release hook."}`)
+	if code != http.StatusCreated || resp["status"] != "created" || resp["uri"] != "mem://user/events/deploy-ingest-v2" {
+		t.Fatalf("create = %d %v", code, resp)
+	}
+
+	code, resp = post(`{"category":"patterns","uri_hint":"deploy-checklist","l0":"Deploys run the smoke suite before flipping traffic","l1":"Every production deploy runs the smoke suite, then flips traffic."}`)
+	if code != http.StatusCreated {
+		t.Fatalf("create pattern = %d %v", code, resp)
+	}
+	code, resp = post(`{"merge_target":"mem://agent/patterns/deploy-checklist","l0":"Deploys run the smoke suite before flipping traffic","l1":"Every production deploy runs the smoke suite, then flips traffic gradually."}`)
+	if code != http.StatusOK || resp["status"] != "updated" || resp["uri"] != "mem://agent/patterns/deploy-checklist" {
+		t.Errorf("merge_target = %d %v, want 200 updated on the same URI", code, resp)
+	}
+
+	for _, body := range []string{
+		`{"category":"events","l0":"x","l1":"long enough overview text"}`,
+		`{"uri_hint":"x","l0":"x","l1":"long enough overview text"}`,
+		`{"category":"bogus","uri_hint":"x","l0":"x","l1":"long enough overview text"}`,
+		`{"merge_target":"mem://user/events/missing","l0":"x","l1":"long enough overview text"}`,
+		`{"merge_target":"mem://user/events/deploy-ingest-v2","l0":"x","l1":"long enough overview text"}`,
+	} {
+		if code, resp := post(body); code != http.StatusBadRequest || resp["error"] == "" {
+			t.Errorf("%s = %d %v, want 400 with a reason", body, code, resp)
+		}
+	}
+}
+
 // TestRememberRouteFeedbackAndReference confirms the POST /api/memories
 // endpoint accepts the categories added in issue #24. Without an end-to-end
 // API test, a regression that breaks one of these categories in extraction
@@ -693,3 +1180,375 @@ func TestExtractSessionRouteAcceptsForce(t *testing.T) {
 		t.Fatalf("status = %d, want 202; body: %s", w.Code, w.Body.String())
 	}
 }
+
+// TestExtractSessionRouteSync verifies ?sync=true waits for the pass and
+// reports its outcome in the body instead of answering 202.
+func TestExtractSessionRouteSync(t *testing.T) {
+	srv := testServerWithEngine(t)
+	srv.db.InitSession("extract-sync", "proj")
+
+	// One user message: the content gate skips it without an LLM call.
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	line := `{"type":"user","message":{"role":"user","content":"hello there"}}` + "\n"
+	if err := os.WriteFile(path, []byte(line), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	body := fmt.Sprintf(`{"transcript_path":%q}`, path)
+	req := newTestRequest("POST", "/api/sessions/extract-sync/extract?sync=true", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var res engine.ExtractResult
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Stored == nil || len(res.Stored) != 0 || !strings.Contains(res.Skipped, "too few user messages") {
+		t.Errorf("result = %+v, want no memories and the skip reason", res)
+	}
+}
+
+func TestMergeRoute(t *testing.T) {
+	srv := testServer(t)
+	for _, uri := range []string{"mem://user/preferences/go-style", "mem://user/preferences/golang-style"} {
+		if err := srv.db.CreateNode(&store.MemNode{
+			URI: uri, NodeType: "leaf", Category: "preferences",
+			L0Abstract: "prefers stdlib", L1Overview: "prefers the standard library", L2Content: uri,
+		}); err != nil {
+			t.Fatalf("CreateNode: %v", err)
+		}
+	}
+
+	body := `{"keep":"mem://user/preferences/go-style","merge":"mem://user/preferences/golang-style"}`
+	req := newTestRequest("POST", "/api/memories/merge", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+
+	kept, _ := srv.db.GetNodeByURI("mem://user/preferences/go-style")
+	if kept == nil || !strings.Contains(kept.L2Content, "golang-style") {
+		t.Errorf("keeper L2 missing absorbed content: %+v", kept)
+	}
+	if gone, _ := srv.db.GetNodeByURI("mem://user/preferences/golang-style"); gone != nil {
+		t.Error("merged node still present")
+	}
+
+	// Merging a node that no longer exists is a 404, not a 500.
+	req = newTestRequest("POST", "/api/memories/merge", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("repeat merge status = %d, want 404", w.Code)
+	}
+}
+
+// TestContextInjectionMarkedUsedBySearch covers the usefulness instrumentation
+// end to end: SessionStart context records what it injected, and a search in the
+// same session that retrieves one of those memories marks it used. Previews must
+// not record anything.
+func TestContextInjectionMarkedUsedBySearch(t *testing.T) {
+	srv := testServerWithEngine(t)
+	ctx := context.Background()
+	embedder, err := engine.NewHashEmbedder(0)
+	if err != nil {
+		t.Fatalf("embedder: %v", err)
+	}
+	srv.engine.SetEmbedder(embedder)
+
+	uri, _, err := srv.engine.Remember(ctx, engine.RememberInput{
+		Category: "preferences",
+		Name:     "sqlite-wal",
+		Summary:  "Always enable WAL mode for SQLite databases",
+		Body:     "SQLite databases should run with journal_mode=WAL for concurrent readers.",
+	})
+	if err != nil {
+		t.Fatalf("Remember: %v", err)
+	}
+	srv.db.InitSession("sess-use", "proj")
+
+	srv.renderContext("sess-use", true, nil)
+	if rows, _ := srv.db.InjectionUsefulness(); len(rows) != 0 {
+		t.Fatalf("preview recorded injections: %+v", rows)
+	}
+
+	srv.buildContext("sess-use")
+	req := newTestRequest("GET", "/api/search?q=sqlite+wal+mode", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("search status = %d; body: %s", w.Code, w.Body.String())
+	}
+
+	rows, err := srv.db.InjectionUsefulness()
+	if err != nil {
+		t.Fatalf("InjectionUsefulness: %v", err)
+	}
+	if len(rows) != 1 || rows[0].Category != "preferences" || rows[0].Used != 1 {
+		t.Errorf("usefulness = %+v, want %s injected and used", rows, uri)
+	}
+}
+
+func TestRebuildProfileRoute(t *testing.T) {
+	srv := testServerWithEngine(t)
+
+	// Nothing recorded yet: refuse rather than queue a no-op.
+	req := newTestRequest("POST", "/api/profile/rebuild", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("rebuild with no transcripts: status = %d, want %d; body: %s", w.Code, http.StatusConflict, w.Body.String())
+	}
+
+	// The extract call records the transcript path even without an engine run.
+	srv.db.InitSession("sess-rb", "/tmp/proj")
+	body := `{"transcript_path":"/tmp/does-not-exist.jsonl"}`
+	req = newTestRequest("POST", "/api/sessions/sess-rb/extract", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	sess, _ := srv.db.GetSession("sess-rb")
+	if sess.TranscriptPath == nil || *sess.TranscriptPath != "/tmp/does-not-exist.jsonl" {
+		t.Fatalf("TranscriptPath = %v, want recorded from extract call", sess.TranscriptPath)
+	}
+	srv.db.CompleteSession("sess-rb")
+
+	req = newTestRequest("POST", "/api/profile/rebuild", strings.NewReader(`{"sessions":5}`))
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("rebuild: status = %d, want %d; body: %s", w.Code, http.StatusAccepted, w.Body.String())
+	}
+	var resp map[string]any
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp["sessions"] != float64(1) {
+		t.Errorf("sessions = %v, want 1", resp["sessions"])
+	}
+}
+
+func TestReadOnlyServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ro.db")
+	rw, err := store.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw.CreateNode(&store.MemNode{
+		URI: "mem://user/moments/ro-moment", NodeType: "leaf", Category: "moments",
+		L0Abstract: "A moment visible to a read-only server",
+	})
+	rw.Close()
+
+	db, err := store.OpenReadOnly(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	srv := New(db, engine.New(db, nil), "test-version")
+
+	for _, tc := range []struct {
+		method, path, body string
+		want               int
+	}{
+		{"GET", "/api/health", "", http.StatusOK},
+		{"GET", "/api/context?session_id=ro-sess", "", http.StatusOK},
+		{"GET", "/api/tree", "", http.StatusOK},
+		{"POST", "/api/sessions/init", `{"session_id":"ro-sess","project":"/tmp"}`, http.StatusServiceUnavailable},
+		{"POST", "/api/memories", `{"category":"events","name":"x","summary":"y","body":"z"}`, http.StatusServiceUnavailable},
+	} {
+		req := newTestRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s %s: status %d, want %d; body: %s", tc.method, tc.path, w.Code, tc.want, w.Body.String())
+		}
+		if tc.path == "/api/context?session_id=ro-sess" && !strings.Contains(w.Body.String(), "read-only server") {
+			t.Errorf("context missing stored moment: %s", w.Body.String())
+		}
+	}
+}
+
+func TestListEntitiesRoute(t *testing.T) {
+	srv := testServer(t)
+	for i, e := range []store.Entity{
+		{Type: "service", Name: "billing-api", Location: "services/billing"},
+		{Type: "person", Name: "Fiona"},
+	} {
+		node := &store.MemNode{
+			URI:        fmt.Sprintf("mem://user/entities/e%d", i),
+			NodeType:   "leaf",
+			Category:   "entities",
+			L0Abstract: e.Name,
+			L1Overview: e.Name + " — entity body content.",
+		}
+		if err := srv.db.CreateNode(node); err != nil {
+			t.Fatal(err)
+		}
+		if err := srv.db.SaveEntity(node.ID, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, newTestRequest("GET", "/api/entities?type=Service", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d; body: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Count    int `json:"count"`
+		Entities []struct {
+			Name     string `json:"name"`
+			Location string `json:"location"`
+		} `json:"entities"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != 1 || resp.Entities[0].Name != "billing-api" || resp.Entities[0].Location != "services/billing" {
+		t.Errorf("?type=Service = %+v, want just billing-api", resp)
+	}
+}
+
+func TestSearchRouteRejectsNonFiniteParams(t *testing.T) {
+	srv := testServerWithEngine(t)
+	embedder, err := engine.NewHashEmbedder(0)
+	if err != nil {
+		t.Fatalf("embedder: %v", err)
+	}
+	srv.engine.SetEmbedder(embedder)
+
+	for _, q := range []string{"freshness=NaN", "freshness=Inf", "min_score=NaN", "min_score=-Inf", "min_score=high"} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newTestRequest("GET", "/api/search?q=sqlite&"+q, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", q, w.Code)
+		}
+	}
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, newTestRequest("GET", "/api/search?q=sqlite&freshness=2&min_score=0.5", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("finite out-of-range values: status = %d, want 200 (clamped)", w.Code)
+	}
+}
+
+func TestSearchRouteGroupByCategory(t *testing.T) {
+	srv := testServerWithEngine(t)
+	embedder, err := engine.NewHashEmbedder(0)
+	if err != nil {
+		t.Fatalf("embedder: %v", err)
+	}
+	srv.engine.SetEmbedder(embedder)
+	for _, uri := range []string{
+		"mem://user/preferences/sqlite-a", "mem://user/preferences/sqlite-b",
+		"mem://user/preferences/sqlite-c", "mem://agent/cases/sqlite-wal",
+	} {
+		n := &store.MemNode{URI: uri, NodeType: "leaf", Category: strings.Split(uri, "/")[3], L0Abstract: "SQLite notes " + uri}
+		if err := srv.db.CreateNode(n); err != nil {
+			t.Fatal(err)
+		}
+		if err := srv.engine.EmbedNode(context.Background(), n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, newTestRequest("GET", "/api/search?q=sqlite+notes&group=category&limit=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d; body: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Count  int `json:"count"`
+		Groups []struct {
+			Category string            `json:"category"`
+			Results  []json.RawMessage `json:"results"`
+		} `json:"groups"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]int{}
+	for _, g := range body.Groups {
+		got[g.Category] = len(g.Results)
+	}
+	if body.Count != 3 || got["preferences"] != 2 || got["cases"] != 1 {
+		t.Errorf("count = %d, groups = %v; want 3 with preferences:2 cases:1", body.Count, got)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, newTestRequest("GET", "/api/search?q=x&group=session", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown group: status = %d, want 400", w.Code)
+	}
+}
+
+func TestMergeSessionsRoute(t *testing.T) {
+	srv := testServer(t)
+	srv.db.InitSession("resumed-a", "/tmp/proj")
+	srv.db.InitSession("resumed-b", "/tmp/proj")
+	srv.db.InitSession("elsewhere", "/tmp/other")
+	srv.db.IncrementToolCount("resumed-a")
+	srv.db.IncrementToolCount("resumed-b")
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newTestRequest("POST", "/api/sessions/merge", strings.NewReader(body)))
+		return w
+	}
+
+	w := post(`{"keep":"resumed-a","merge":"resumed-b"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var got sessionDetail
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.SessionID != "resumed-a" || got.ToolCount != 2 {
+		t.Errorf("merged session = %+v", got)
+	}
+
+	if w := post(`{"keep":"resumed-a","merge":"resumed-b"}`); w.Code != http.StatusNotFound {
+		t.Errorf("repeat merge status = %d, want 404", w.Code)
+	}
+	if w := post(`{"keep":"resumed-a","merge":"elsewhere"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("cross-project merge status = %d, want 400", w.Code)
+	}
+}
+
+func TestCondensedSessionRoute(t *testing.T) {
+	srv := testServerWithEngine(t)
+	srv.db.InitSession("condensed", "proj")
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newTestRequest("GET", "/api/sessions/condensed/condensed", nil))
+		return w
+	}
+	if w := get(); w.Code != http.StatusNotFound {
+		t.Errorf("no transcript recorded: status = %d, want 404", w.Code)
+	}
+
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	line := `{"type":"user","message":{"role":"user","content":"Why does the retry loop double its backoff?"}}` + "\n"
+	if err := os.WriteFile(path, []byte(line), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	srv.db.SetTranscriptPath("condensed", path)
+
+	w := get()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		TranscriptPath string `json:"transcript_path"`
+		Condensed      string `json:"condensed"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.TranscriptPath != path || !strings.Contains(resp.Condensed, "double its backoff") {
+		t.Errorf("response = %+v", resp)
+	}
+}
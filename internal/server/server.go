@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/lazypower/continuity/internal/buildinfo"
+	"github.com/lazypower/continuity/internal/config"
 	"github.com/lazypower/continuity/internal/engine"
 	"github.com/lazypower/continuity/internal/store"
 )
@@ -20,6 +22,44 @@ type Server struct {
 	router  chi.Router
 	version string
 	started time.Time
+
+	// monitor, when set, reports whether the DB health monitor currently
+	// considers the database degraded. Nil means no monitor (tests, CLI).
+	monitor *store.HealthMonitor
+
+	// contextSections and contextCategories lay out the SessionStart context
+	// block (SetContextLayout). Nil means the defaults.
+	contextSections   []string
+	contextCategories []string
+
+	// contextMaxAge is the ranked sections' recency horizon
+	// (SetContextMaxAge). Zero means none.
+	contextMaxAge time.Duration
+
+	// contextLightObservations and contextLightItems throttle priming after
+	// a quick session (SetContextThrottle). Zero observations turns the
+	// automatic throttle off.
+	contextLightObservations int
+	contextLightItems        int
+
+	// corsOrigins are the browser origins allowed cross-origin access
+	// (SetCORSOrigins). Empty means no CORS headers.
+	corsOrigins []string
+
+	// skipTools are tools, beyond the built-in set, whose
+	// observations are refused (SetSkipTools).
+	skipTools []string
+
+	// warm is a pre-rendered context block awaiting the next SessionStart
+	// (WarmContext). Guarded by warmMu.
+	warmMu sync.Mutex
+	warm   *warmContext
+
+	// served remembers what each recent per-session context block injected,
+	// by ETag, so a 304 can still record the injections (servedContext).
+	// Guarded by servedMu.
+	servedMu sync.Mutex
+	served   map[string][]store.InjectedMemory
 }
 
 // New creates a new Server with the given database, engine, and version string.
@@ -30,11 +70,28 @@ func New(db *store.DB, eng *engine.Engine, version string) *Server {
 		engine:  eng,
 		version: version,
 		started: time.Now(),
+
+		// A light block holds context.light_items memories unless
+		// SetContextThrottle says otherwise.
+		contextLightItems: config.Default().Context.LightItems,
 	}
 	s.routes()
 	return s
 }
 
+// SetHealthMonitor attaches the DB health monitor whose state /api/health
+// reports.
+func (s *Server) SetHealthMonitor(m *store.HealthMonitor) {
+	s.monitor = m
+}
+
+// SetSkipTools refuses observations of tools, on top of the built-in skip
+// set (config.SkipTool). The hook filters only the built-in set, so the
+// configured [hooks] skip_tools are applied here.
+func (s *Server) SetSkipTools(tools []string) {
+	s.skipTools = tools
+}
+
 // ServeHTTP implements http.Handler.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.router.ServeHTTP(w, r)
@@ -45,10 +102,15 @@ func (s *Server) routes() {
 	r.Use(middleware.Recoverer)
 	r.Use(securityHeaders)
 	r.Use(localhostOnly)
+	r.Use(s.cors)
 	r.Use(limitRequestBody)
 
 	r.Route("/api", func(r chi.Router) {
+		r.Use(s.readOnlyGuard)
+		r.Use(requireJSON)
+
 		r.Get("/health", s.handleHealth)
+		r.Get("/ready", s.handleReady)
 
 		// Session + observation + context routes
 		r.Post("/sessions/init", s.handleSessionInit)
@@ -60,6 +122,10 @@ func (s *Server) routes() {
 		// Phase 2: extraction
 		r.Post("/sessions/{sessionID}/extract", s.handleExtractSession)
 		r.Post("/sessions/unmark-empty-extractions", s.handleUnmarkEmptyExtractions)
+		r.Get("/sessions/failed-extractions", s.handleListFailedExtractions)
+		r.Post("/sessions/retry-failed", s.handleRetryFailed)
+		r.Post("/sessions/merge", s.handleMergeSessions)
+		r.Post("/sessions/forget", s.handleForgetSession)
 
 		// Phase 4: signal keywords
 		r.Post("/sessions/{sessionID}/signal", s.handleSignal)
@@ -67,19 +133,28 @@ func (s *Server) routes() {
 		// Phase 3: retrieval routes
 		r.Get("/search", s.handleSearch)
 		r.Get("/profile", s.handleProfile)
+		r.Post("/profile/rebuild", s.handleRebuildProfile)
 		r.Get("/tree", s.handleTree)
 		r.Get("/timeline", s.handleTimeline)
 		r.Get("/metrics", s.handleMetrics)
+		r.Get("/stats", s.handleStats)
+		r.Post("/maintain", s.handleMaintain)
+
+		r.Get("/sessions", s.handleListSessions)
+		r.Get("/sessions/{sessionID}", s.handleGetSession)
+		r.Get("/sessions/{sessionID}/condensed", s.handleCondensedSession)
 
-		// Stub routes — return 501 until implemented
-		r.Get("/sessions", stub("sessions"))
-		r.Get("/sessions/{sessionID}", stub("session detail"))
 		r.Post("/memories", s.handleRemember)
 		r.Get("/memories", s.handleGetMemory)
 		r.Post("/memories/retract", s.handleRetract)
 		r.Post("/memories/pin", s.handlePin)
+		r.Post("/memories/relevance", s.handleRelevance)
 		r.Post("/memories/unpin", s.handleUnpin)
+		r.Post("/memories/merge", s.handleMerge)
 		r.Get("/memories/pinned", s.handleListPinned)
+		r.Get("/entities", s.handleListEntities)
+
+		r.Post("/nodes", s.handleCreateNode)
 	})
 
 	// Serve embedded UI at all non-API paths
@@ -88,11 +163,32 @@ func (s *Server) routes() {
 	s.router = r
 }
 
+// readOnlyGuard refuses every mutating request with 503 when the store was
+// opened read-only (`serve --readonly`). All writes in the API go through
+// POST, so gating by method covers them without per-route bookkeeping; the
+// GET routes with incidental writes (context rotation, search access counts)
+// skip those writes themselves when db.ReadOnly is set.
+func (s *Server) readOnlyGuard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.db.ReadOnly && r.Method != http.MethodGet && r.Method != http.MethodHead {
+			jsonError(w, "server is read-only (serve --readonly)", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	dbOK := true
 	if err := s.db.Ping(); err != nil {
 		dbOK = false
 	}
+	// A pooled connection can Ping fine while queries against the file fail;
+	// the monitor's probe is the better signal once it has one.
+	mon := s.monitor.Status()
+	if mon.Degraded {
+		dbOK = false
+	}
 
 	// Current applied schema version of the open DB. Best-effort: a read error
 	// surfaces as 0 rather than failing the health check, since the endpoint's
@@ -127,8 +223,15 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 		"pid":            os.Getpid(),
 		"started_at":     s.started.Unix(),
 		"db_path":        s.db.Path,
+		"readonly":       s.db.ReadOnly,
 		"exe":            exe,
 
+		// DB health-monitor state: degraded after repeated failed probes, until
+		// a probe or reconnect succeeds.
+		"db_degraded":   mon.Degraded,
+		"db_error":      mon.LastError,
+		"db_reconnects": mon.Reconnects,
+
 		// Vector-identity fields: what the live server embeds with, and whether
 		// search is locked due to a corpus/embedder mismatch.
 		"active_embedder":        activeEmbedder,
@@ -136,12 +239,25 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func stub(name string) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotImplemented)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": name + " not yet implemented",
-		})
+// handleReady is the readiness probe, distinct from /api/health (liveness):
+// 200 only once the DB answers, the schema is fully migrated, and the engine's
+// startup embedding backfill has finished. Until then searches would silently
+// miss un-embedded memories, so latency-sensitive clients check this first.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	reason := ""
+	if err := s.db.Ping(); err != nil {
+		reason = "database unreachable"
+	} else if v, err := s.db.SchemaVersion(); err != nil || v < store.HeadSchemaVersion() {
+		reason = "migrations pending"
+	} else if s.engine != nil {
+		_, reason = s.engine.Ready()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if reason != "" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]any{"ready": false, "reason": reason})
+		return
 	}
+	json.NewEncoder(w).Encode(map[string]any{"ready": true})
 }
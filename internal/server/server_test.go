@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/lazypower/continuity/internal/buildinfo"
 	"github.com/lazypower/continuity/internal/store"
@@ -315,6 +317,47 @@ func TestBuildContextFeedbackInProfileSection(t *testing.T) {
 	}
 }
 
+func TestBuildContextMaxAge(t *testing.T) {
+	srv := testServer(t)
+	for _, n := range []struct{ uri, l0 string }{
+		{"mem://user/events/old-launch", "Launched the first prototype"},
+		{"mem://user/events/old-pinned", "Chose SQLite as the only store"},
+		{"mem://user/events/new-release", "Released v2 with the new context layout"},
+	} {
+		if err := srv.db.UpsertNode(&store.MemNode{URI: n.uri, NodeType: "leaf", Category: "events", L0Abstract: n.l0}); err != nil {
+			t.Fatalf("upsert %s: %v", n.uri, err)
+		}
+	}
+	twoYearsAgo := time.Now().AddDate(-2, 0, 0).UnixMilli()
+	srv.db.Exec(`UPDATE mem_nodes SET created_at = ? WHERE uri LIKE 'mem://user/events/old-%'`, twoYearsAgo)
+	if _, err := srv.db.PinNode("mem://user/events/old-pinned"); err != nil {
+		t.Fatalf("PinNode: %v", err)
+	}
+
+	// No horizon by default: still relevant, so still injected.
+	if ctx := srv.buildContext(""); !strings.Contains(ctx, "Launched the first prototype") {
+		t.Fatalf("old memory missing without a horizon:\n%s", ctx)
+	}
+
+	if err := srv.SetContextMaxAge(365 * 24 * time.Hour); err != nil {
+		t.Fatalf("SetContextMaxAge: %v", err)
+	}
+	ctx := srv.buildContext("")
+	if strings.Contains(ctx, "Launched the first prototype") {
+		t.Errorf("memory older than the horizon was injected:\n%s", ctx)
+	}
+	if !strings.Contains(ctx, "Released v2") {
+		t.Errorf("recent memory missing:\n%s", ctx)
+	}
+	if !strings.Contains(ctx, "Chose SQLite as the only store") {
+		t.Errorf("pinned memory dropped by the horizon:\n%s", ctx)
+	}
+
+	if err := srv.SetContextMaxAge(-time.Hour); err == nil {
+		t.Error("negative max age accepted")
+	}
+}
+
 func TestBuildContextSessionTone(t *testing.T) {
 	srv := testServer(t)
 
@@ -329,6 +372,71 @@ func TestBuildContextSessionTone(t *testing.T) {
 	}
 }
 
+func TestBuildContextConstraintsSection(t *testing.T) {
+	srv := testServer(t)
+	for _, n := range []store.MemNode{
+		{URI: "mem://user/preferences/tabs", Category: "preferences", L0Abstract: "Prefers tabs.", Relevance: 1.0},
+		// Decayed well below the ranked sections' cutoff; still injected.
+		{URI: "mem://user/constraints/no-force-push", Category: "constraints", L0Abstract: "Never force-push to main.", Relevance: 0.1},
+	} {
+		n.NodeType = "leaf"
+		if err := srv.db.UpsertNode(&n); err != nil {
+			t.Fatalf("upsert %s: %v", n.URI, err)
+		}
+	}
+
+	ctx := srv.buildContext("")
+	con, prof := strings.Index(ctx, "### Constraints\n- Never force-push to main."), strings.Index(ctx, "### Your Profile")
+	if con < 0 || prof < 0 || con > prof {
+		t.Errorf("want the Constraints section ahead of Your Profile:\n%s", ctx)
+	}
+	if strings.Count(ctx, "Never force-push") != 1 {
+		t.Errorf("constraint rendered more than once:\n%s", ctx)
+	}
+}
+
+func TestBuildContextSectionLayout(t *testing.T) {
+	srv := testServer(t)
+	srv.db.InitSession("sess-old", "myproject")
+	srv.db.CompleteSession("sess-old")
+	for _, n := range []store.MemNode{
+		{URI: "mem://user/preferences/tabs", Category: "preferences", L0Abstract: "Prefers tabs."},
+		{URI: "mem://agent/patterns/retry", Category: "patterns", L0Abstract: "Retries flaky tests once."},
+		{URI: "mem://user/events/launch", Category: "events", L0Abstract: "Launched v2 in March."},
+	} {
+		n.NodeType, n.Relevance = "leaf", 0.9
+		if err := srv.db.UpsertNode(&n); err != nil {
+			t.Fatalf("upsert %s: %v", n.URI, err)
+		}
+	}
+
+	if err := srv.SetContextLayout([]string{"memories", "profile"}, []string{"preferences", "events"}); err != nil {
+		t.Fatalf("SetContextLayout: %v", err)
+	}
+	ctx := srv.buildContext("sess-current")
+
+	if strings.Contains(ctx, "### Recent Sessions") {
+		t.Errorf("Recent Sessions rendered though not configured:\n%s", ctx)
+	}
+	mem, prof := strings.Index(ctx, "### Recent Memories"), strings.Index(ctx, "### Your Profile")
+	if mem < 0 || prof < 0 || mem > prof {
+		t.Errorf("want Recent Memories before Your Profile:\n%s", ctx)
+	}
+	if strings.Contains(ctx, "Retries flaky tests") {
+		t.Errorf("patterns rendered though not a configured category:\n%s", ctx)
+	}
+	if !strings.Contains(ctx, "[events] Launched v2") {
+		t.Errorf("configured events category missing:\n%s", ctx)
+	}
+
+	if err := srv.SetContextLayout([]string{"recent_sessions"}, nil); err == nil {
+		t.Error("unknown section name accepted")
+	}
+	if err := srv.SetContextLayout(nil, []string{"moments"}); err == nil {
+		t.Error("moments accepted as a ranked category")
+	}
+}
+
 func TestTruncateAtSentence(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -354,6 +462,59 @@ func TestTruncateAtSentence(t *testing.T) {
 	}
 }
 
+// gatedEmbedder blocks every Embed until release is closed, holding the
+// startup backfill open so readiness can be observed mid-flight.
+type gatedEmbedder struct{ release chan struct{} }
+
+func (g gatedEmbedder) Embed(ctx context.Context, _ string) ([]float64, error) {
+	select {
+	case <-g.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return []float64{1, 0, 0}, nil
+}
+func (gatedEmbedder) Model() string   { return "gated" }
+func (gatedEmbedder) Dimensions() int { return 3 }
+
+func TestReadyEndpoint(t *testing.T) {
+	srv := testServerWithEngine(t)
+	gate := gatedEmbedder{release: make(chan struct{})}
+	srv.engine.SetEmbedder(gate)
+	if err := srv.db.CreateNode(&store.MemNode{
+		URI: "mem://user/events/unembedded", NodeType: "leaf", Category: "events", L0Abstract: "needs a vector",
+	}); err != nil {
+		t.Fatalf("CreateNode: %v", err)
+	}
+
+	ready := func() (int, map[string]any) {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newTestRequest("GET", "/api/ready", nil))
+		var body map[string]any
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	done := make(chan struct{})
+	srv.engine.StartEmbedBackfill(time.Minute, func(int, error) { close(done) })
+
+	if code, body := ready(); code != http.StatusServiceUnavailable || body["ready"] != false {
+		t.Fatalf("during backfill: status = %d body = %v, want 503 ready=false", code, body)
+	}
+	// Liveness is unaffected.
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, newTestRequest("GET", "/api/health", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("health during backfill: status = %d, want 200", w.Code)
+	}
+
+	close(gate.release)
+	<-done
+	if code, body := ready(); code != http.StatusOK || body["ready"] != true {
+		t.Fatalf("after backfill: status = %d body = %v, want 200 ready=true", code, body)
+	}
+}
+
 func TestHealthEndpoint(t *testing.T) {
 	srv := testServer(t)
 
@@ -405,38 +566,145 @@ func TestHealthEndpoint(t *testing.T) {
 	}
 }
 
-func TestStubRoutes(t *testing.T) {
+func TestSessionRoutes(t *testing.T) {
 	srv := testServer(t)
 
-	// These routes are still stubs (501)
-	stubs := []struct {
-		method string
-		path   string
-	}{
-		{"GET", "/api/sessions"},
-		{"GET", "/api/sessions/abc123"},
+	srv.db.InitSession("sess-skip", "proj")
+	srv.db.SetSkipReason("sess-skip", "too few user messages (1 < 3)")
+
+	req := newTestRequest("GET", "/api/sessions/sess-skip", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("detail status = %d, want 200", w.Code)
+	}
+	var detail map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("decode detail: %v", err)
+	}
+	if detail["skip_reason"] != "too few user messages (1 < 3)" {
+		t.Errorf("skip_reason = %v", detail["skip_reason"])
 	}
 
-	for _, s := range stubs {
-		req := newTestRequest(s.method, s.path, nil)
+	req = newTestRequest("GET", "/api/sessions/missing", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("missing session status = %d, want 404", w.Code)
+	}
+
+	req = newTestRequest("GET", "/api/sessions", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list status = %d, want 200", w.Code)
+	}
+	var list []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(list) != 1 || list[0]["session_id"] != "sess-skip" {
+		t.Errorf("list = %v, want one session sess-skip", list)
+	}
+}
+
+func TestRequireJSON(t *testing.T) {
+	srv := testServer(t)
+	body := `{"session_id":"s1","project":"proj"}`
+	for ct, want := range map[string]int{
+		"application/json":                  http.StatusOK,
+		"application/json; charset=utf-8":   http.StatusOK,
+		"":                                  http.StatusOK,
+		"text/plain;charset=UTF-8":          http.StatusUnsupportedMediaType,
+		"application/x-www-form-urlencoded": http.StatusUnsupportedMediaType,
+	} {
+		req := newTestRequest("POST", "/api/sessions/init", strings.NewReader(body))
+		if ct != "" {
+			req.Header.Set("Content-Type", ct)
+		}
 		w := httptest.NewRecorder()
 		srv.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("Content-Type %q: status %d, want %d", ct, w.Code, want)
+		}
+		if w.Code != http.StatusOK {
+			var resp map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp["error"] == "" {
+				t.Errorf("Content-Type %q: error body %q is not a JSON error", ct, w.Body.String())
+			}
+		}
+	}
+}
 
-		if w.Code != http.StatusNotImplemented {
-			t.Errorf("%s %s: status = %d, want %d", s.method, s.path, w.Code, http.StatusNotImplemented)
+func TestCORS(t *testing.T) {
+	srv := testServer(t)
+	request := func(method, origin string) *httptest.ResponseRecorder {
+		req := newTestRequest(method, "/api/health", nil)
+		req.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", "POST")
+			req.Header.Set("Access-Control-Request-Headers", "content-type")
 		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
 
-		var body map[string]string
-		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
-			t.Errorf("%s %s: decode body: %v", s.method, s.path, err)
-			continue
+	// Off by default: no CORS headers for anyone.
+	if w := request("GET", "http://localhost:5173"); w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("CORS headers sent with no origins configured")
+	}
+
+	if err := srv.SetCORSOrigins([]string{"http://localhost:5173"}); err != nil {
+		t.Fatal(err)
+	}
+	w := request(http.MethodOptions, "http://localhost:5173")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "http://localhost:5173" ||
+		w.Header().Get("Access-Control-Allow-Headers") != "content-type" {
+		t.Errorf("preflight: status %d, headers %v", w.Code, w.Header())
+	}
+	if w := request("GET", "http://localhost:5173"); w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "http://localhost:5173" {
+		t.Errorf("allowed GET: status %d, headers %v", w.Code, w.Header())
+	}
+	if w := request("GET", "http://evil.example"); w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("an unlisted origin was allowed")
+	}
+
+	// A "*" port matches any port on that loopback host, and nothing else.
+	if err := srv.SetCORSOrigins([]string{"http://localhost:*", "http://[::1]:*"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, origin := range []string{"http://localhost:3000", "http://localhost", "http://[::1]:8080"} {
+		if w := request("GET", origin); w.Header().Get("Access-Control-Allow-Origin") != origin {
+			t.Errorf("%s should match a loopback wildcard", origin)
 		}
-		if body["error"] == "" {
-			t.Errorf("%s %s: expected error message in body", s.method, s.path)
+	}
+	for _, origin := range []string{"https://localhost:3000", "http://127.0.0.1:3000", "http://localhost.evil.example:80"} {
+		if w := request("GET", origin); w.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("%s should not match a loopback wildcard", origin)
+		}
+	}
+
+	for _, bad := range []string{"localhost:5173", "http://localhost:5173/", "file:///tmp",
+		"*", "http://*", "http://evil.example:*", "http://localhost:5173:*"} {
+		if err := srv.SetCORSOrigins([]string{bad}); err == nil {
+			t.Errorf("SetCORSOrigins(%q) should fail", bad)
 		}
 	}
 }
 
+func TestJSONErrorEscapes(t *testing.T) {
+	w := httptest.NewRecorder()
+	jsonError(w, `bad "quoted" \ value`, http.StatusBadRequest)
+	var resp map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("error body is not valid JSON: %v (%s)", err, w.Body.String())
+	}
+	if resp["error"] != `bad "quoted" \ value` {
+		t.Errorf("error = %q", resp["error"])
+	}
+}
+
 func TestSearchRoute(t *testing.T) {
 	srv := testServer(t)
 
@@ -459,6 +727,54 @@ func TestSearchRoute(t *testing.T) {
 	}
 }
 
+func TestStatsRoute(t *testing.T) {
+	srv := testServer(t)
+	srv.db.CreateNode(&store.MemNode{URI: "mem://user/preferences/tabs", NodeType: "leaf", Category: "preferences", L0Abstract: "Tabs"})
+	srv.db.InitSession("stats-sess", "/tmp/proj")
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, newTestRequest("GET", "/api/stats", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("stats: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var body struct {
+		Leaves        int            `json:"leaves"`
+		Categories    []any          `json:"categories"`
+		Sessions      map[string]int `json:"sessions"`
+		Coverage      float64        `json:"coverage"`
+		UptimeSeconds float64        `json:"uptime_seconds"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Leaves != 1 || len(body.Categories) != 1 || body.Sessions["active"] != 1 {
+		t.Errorf("stats = %s", w.Body.String())
+	}
+	if body.UptimeSeconds <= 0 {
+		t.Errorf("uptime_seconds = %v, want > 0", body.UptimeSeconds)
+	}
+}
+
+func TestMaintainRoute(t *testing.T) {
+	srv := testServer(t)
+
+	for _, path := range []string{"/api/maintain", "/api/maintain?vacuum=false"} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, newTestRequest("POST", path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d (%s), want 200", path, w.Code, w.Body.String())
+		}
+		var rep store.MaintainReport
+		if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil {
+			t.Fatalf("%s: decode body: %v", path, err)
+		}
+		if want := path == "/api/maintain"; rep.Vacuumed != want {
+			t.Errorf("%s: vacuumed = %v, want %v", path, rep.Vacuumed, want)
+		}
+	}
+}
+
 func TestProfileRoute(t *testing.T) {
 	srv := testServer(t)
 
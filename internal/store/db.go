@@ -3,6 +3,7 @@ package store
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 
@@ -13,6 +14,25 @@ import (
 type DB struct {
 	*sql.DB
 	Path string
+
+	// ReadOnly is set by OpenReadOnly: SQLite itself rejects writes, and
+	// callers with optional writes (access bookkeeping) skip them.
+	ReadOnly bool
+
+	// MaxURIDepth caps the path segments of a created node's URI; zero means
+	// DefaultMaxURIDepth. See CheckURIDepth.
+	MaxURIDepth int
+
+	// DropL2 makes CreateNode, UpsertNode, and UpdateNode store an empty L2
+	// whatever the node carries. Merges and imports still write theirs.
+	DropL2 bool
+
+	// nodeCache, when enabled, serves repeat GetNodeByURI reads. See
+	// EnableNodeCache.
+	nodeCache *nodeCache
+
+	// decay overrides DefaultDecayParams. See SetDecayParams.
+	decay *DecayParams
 }
 
 // DefaultDBPath returns the default database path: ~/.continuity/continuity.db
@@ -36,15 +56,15 @@ func Open(path string) (*DB, error) {
 	// permissions on creation, so pre-existing dirs/files need explicit chmod.
 	hardenPermissions(dir, path)
 
-	sqlDB, err := sql.Open("sqlite", path)
+	sqlDB, err := sql.Open("sqlite", fileDSN(path, nil, connPragmas))
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite: %w", err)
 	}
 
 	db := &DB{DB: sqlDB, Path: path}
-	if err := db.configurePragmas(); err != nil {
+	if err := db.Ping(); err != nil {
 		sqlDB.Close()
-		return nil, err
+		return nil, fmt.Errorf("open sqlite: %w", err)
 	}
 	if err := db.migrate(); err != nil {
 		sqlDB.Close()
@@ -70,31 +90,85 @@ func OpenNoMigrate(path string) (*DB, error) {
 	}
 	hardenPermissions(dir, path)
 
-	sqlDB, err := sql.Open("sqlite", path)
+	sqlDB, err := sql.Open("sqlite", fileDSN(path, nil, connPragmas))
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite: %w", err)
 	}
 
 	db := &DB{DB: sqlDB, Path: path}
-	if err := db.configurePragmas(); err != nil {
+	if err := db.Ping(); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	return db, nil
+}
+
+// OpenReadOnly opens an existing database with SQLite's mode=ro, so no
+// statement on the handle can write — not migrations, not pragmas, not a
+// stray access-count bump. It never creates the file and never migrates; a
+// database whose schema is behind this binary is refused, since the read
+// paths assume the current schema. For `serve --readonly` inspection of a live
+// store.
+func OpenReadOnly(path string) (*DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("open read-only: %w", err)
+	}
+	dsn := fileDSN(path, url.Values{"mode": {"ro"}}, []string{
+		"query_only(ON)",
+		"foreign_keys(ON)",
+		"mmap_size(268435456)",
+		"busy_timeout(5000)",
+	})
+	sqlDB, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite read-only: %w", err)
+	}
+
+	db := &DB{DB: sqlDB, Path: path, ReadOnly: true}
+	current, err := db.SchemaVersion()
+	if err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("read schema version: %w", err)
+	}
+	if head := HeadSchemaVersion(); current < head {
 		sqlDB.Close()
-		return nil, err
+		return nil, fmt.Errorf("database schema is v%d, this binary expects v%d — start it once without --readonly to migrate", current, head)
 	}
 	return db, nil
 }
 
 // OpenMemory opens an in-memory SQLite database for testing.
 func OpenMemory() (*DB, error) {
-	sqlDB, err := sql.Open("sqlite", ":memory:")
+	sqlDB, err := sql.Open("sqlite", ":memory:?"+pragmaQuery(nil, connPragmas))
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite memory: %w", err)
 	}
 
 	db := &DB{DB: sqlDB, Path: ":memory:"}
-	if err := db.configurePragmas(); err != nil {
+	if err := db.migrate(); err != nil {
 		sqlDB.Close()
-		return nil, err
+		return nil, fmt.Errorf("migrate: %w", err)
 	}
+	return db, nil
+}
+
+// OpenMemoryShared opens a named in-memory database in SQLite shared-cache
+// mode. Unlike OpenMemory (":memory:" is private to each connection), every
+// handle opened with the same name sees the same database, so a test can drive
+// the server and a CLI code path against one store. The database lives until
+// the last handle using it is closed. Path reports ":memory:" so snapshot logic
+// treats it like any other in-memory DB.
+func OpenMemoryShared(name string) (*DB, error) {
+	if name == "" {
+		return nil, fmt.Errorf("open sqlite shared memory: name required")
+	}
+	dsn := "file:" + url.PathEscape(name) + "?" + pragmaQuery(url.Values{"mode": {"memory"}, "cache": {"shared"}}, connPragmas)
+	sqlDB, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite shared memory: %w", err)
+	}
+
+	db := &DB{DB: sqlDB, Path: ":memory:"}
 	if err := db.migrate(); err != nil {
 		sqlDB.Close()
 		return nil, fmt.Errorf("migrate: %w", err)
@@ -102,6 +176,14 @@ func OpenMemory() (*DB, error) {
 	return db, nil
 }
 
+// Close releases the node cache's connection, then closes the database.
+func (db *DB) Close() error {
+	if db.nodeCache != nil {
+		db.nodeCache.close()
+	}
+	return db.DB.Close()
+}
+
 // hardenPermissions tightens file/directory permissions for existing installs.
 // MkdirAll/OpenFile only set permissions on creation — this fixes pre-existing files.
 func hardenPermissions(dir, dbPath string) {
@@ -115,18 +197,34 @@ func hardenPermissions(dir, dbPath string) {
 	}
 }
 
-func (db *DB) configurePragmas() error {
-	pragmas := []string{
-		"PRAGMA journal_mode=WAL",
-		"PRAGMA synchronous=NORMAL",
-		"PRAGMA foreign_keys=ON",
-		"PRAGMA mmap_size=268435456", // 256MB
-		"PRAGMA busy_timeout=5000",
+// connPragmas configure every read-write connection. They go in the DSN as
+// _pragma parameters, which the driver runs on each connection it opens:
+// most pragmas are per connection, so running them once with Exec would
+// reach only whichever pooled connection served that statement, and a
+// connection dialled later (under load, or after Reconnect) would have no
+// busy_timeout and no foreign keys.
+var connPragmas = []string{
+	"journal_mode(WAL)",
+	"synchronous(NORMAL)",
+	"foreign_keys(ON)",
+	"mmap_size(268435456)", // 256MB
+	"busy_timeout(5000)",
+}
+
+// fileDSN returns a file: URI for path carrying params and pragmas.
+func fileDSN(path string, params url.Values, pragmas []string) string {
+	return (&url.URL{Scheme: "file", Path: path, RawQuery: pragmaQuery(params, pragmas)}).String()
+}
+
+// pragmaQuery encodes params plus one _pragma parameter per pragma, each
+// written as name(value).
+func pragmaQuery(params url.Values, pragmas []string) string {
+	q := url.Values{}
+	for k, v := range params {
+		q[k] = v
 	}
 	for _, p := range pragmas {
-		if _, err := db.Exec(p); err != nil {
-			return fmt.Errorf("pragma %q: %w", p, err)
-		}
+		q.Add("_pragma", p)
 	}
-	return nil
+	return q.Encode()
 }
@@ -108,6 +108,11 @@ func TestSessionsConstraints(t *testing.T) {
 	if err != nil {
 		t.Fatalf("valid insert failed: %v", err)
 	}
+	// A writer that doesn't set session_key gets its session_id.
+	var key string
+	if err := db.QueryRow(`SELECT session_key FROM sessions WHERE session_id = 'sess-001'`).Scan(&key); err != nil || key != "sess-001" {
+		t.Errorf("default session_key = %q (%v), want sess-001", key, err)
+	}
 
 	// Invalid status
 	_, err = db.Exec(`
@@ -299,6 +304,28 @@ func TestReferenceCategory(t *testing.T) {
 	}
 }
 
+// TestConstraintsCategory covers the v17 rebuild: the new category is
+// accepted, and v12's pinned_at survived the table swap.
+func TestConstraintsCategory(t *testing.T) {
+	db, err := OpenMemory()
+	if err != nil {
+		t.Fatalf("OpenMemory: %v", err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		INSERT INTO mem_nodes (uri, node_type, category, pinned_at, created_at, updated_at)
+		VALUES ('mem://user/constraints/no-force-push', 'leaf', 'constraints', 3000, 1000, 1000)
+	`)
+	if err != nil {
+		t.Fatalf("constraints category insert failed: %v", err)
+	}
+	var pinnedAt int64
+	if err := db.QueryRow(`SELECT pinned_at FROM mem_nodes WHERE uri = 'mem://user/constraints/no-force-push'`).Scan(&pinnedAt); err != nil || pinnedAt != 3000 {
+		t.Errorf("pinned_at = %d, %v; want 3000", pinnedAt, err)
+	}
+}
+
 // TestMigration9PreservesRetractionColumns confirms the v9 table swap carries
 // the v8 retraction columns through without data loss. If the v9 schema ever
 // drifts from the column list in CREATE TABLE, this catches it.
@@ -403,3 +430,77 @@ func TestForeignKeysEnabled(t *testing.T) {
 		t.Errorf("foreign_keys = %d, want 1", fk)
 	}
 }
+
+func TestOpenMemoryShared(t *testing.T) {
+	a, err := OpenMemoryShared(t.Name())
+	if err != nil {
+		t.Fatalf("OpenMemoryShared (a): %v", err)
+	}
+	defer a.Close()
+	b, err := OpenMemoryShared(t.Name())
+	if err != nil {
+		t.Fatalf("OpenMemoryShared (b): %v", err)
+	}
+	defer b.Close()
+
+	if err := a.CreateNode(&MemNode{
+		URI: "mem://user/events/shared", NodeType: "leaf", Category: "events", L0Abstract: "written via a",
+	}); err != nil {
+		t.Fatalf("CreateNode via a: %v", err)
+	}
+
+	got, err := b.GetNodeByURI("mem://user/events/shared")
+	if err != nil {
+		t.Fatalf("GetNodeByURI via b: %v", err)
+	}
+	if got == nil || got.L0Abstract != "written via a" {
+		t.Fatalf("node written via a not visible via b: %+v", got)
+	}
+
+	// A different name is a different database.
+	c, err := OpenMemoryShared(t.Name() + "-other")
+	if err != nil {
+		t.Fatalf("OpenMemoryShared (c): %v", err)
+	}
+	defer c.Close()
+	if n, _ := c.GetNodeByURI("mem://user/events/shared"); n != nil {
+		t.Error("differently named shared DB should not see the node")
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ro.db")
+	if _, err := OpenReadOnly(path); err == nil {
+		t.Fatal("OpenReadOnly should not create a missing database")
+	}
+
+	rw, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := rw.CreateNode(&MemNode{
+		URI: "mem://user/events/ro", NodeType: "leaf", Category: "events", L0Abstract: "before read-only",
+	}); err != nil {
+		t.Fatalf("CreateNode: %v", err)
+	}
+	rw.Close()
+
+	ro, err := OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	defer ro.Close()
+	if !ro.ReadOnly {
+		t.Error("ReadOnly not set")
+	}
+
+	got, err := ro.GetNodeByURI("mem://user/events/ro")
+	if err != nil || got == nil {
+		t.Fatalf("read via read-only handle: %v, %v", got, err)
+	}
+	if err := ro.CreateNode(&MemNode{
+		URI: "mem://user/events/blocked", NodeType: "leaf", Category: "events", L0Abstract: "should fail",
+	}); err == nil {
+		t.Error("write through a read-only handle succeeded")
+	}
+}
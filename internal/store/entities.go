@@ -0,0 +1,82 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Entity is the structured view of an entities-category memory: what kind of
+// thing it is, its canonical name, where it lives, and what else it is called.
+// It supplements the node's free-text L1, which remains the source of truth.
+type Entity struct {
+	NodeID    int64
+	URI       string // joined from mem_nodes on read
+	L0        string // joined from mem_nodes on read
+	Type      string // lowercase kind, e.g. "service", "person", "project"
+	Name      string
+	Location  string // path or URL; empty when not applicable
+	Aliases   []string
+	UpdatedAt int64
+}
+
+// SaveEntity inserts or replaces the structured fields for nodeID.
+func (db *DB) SaveEntity(nodeID int64, e Entity) error {
+	aliases := e.Aliases
+	if aliases == nil {
+		aliases = []string{}
+	}
+	raw, err := json.Marshal(aliases)
+	if err != nil {
+		return fmt.Errorf("encode aliases: %w", err)
+	}
+	_, err = db.Exec(`
+		INSERT INTO entities (node_id, entity_type, name, location, aliases, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(node_id) DO UPDATE SET
+			entity_type = excluded.entity_type, name = excluded.name,
+			location = excluded.location, aliases = excluded.aliases,
+			updated_at = excluded.updated_at
+	`, nodeID, e.Type, e.Name, e.Location, string(raw), time.Now().UnixMilli())
+	if err != nil {
+		return fmt.Errorf("save entity: %w", err)
+	}
+	return nil
+}
+
+// ListEntities returns the structured entities of live leaves, optionally
+// restricted to one type, ordered by type then name. Retracted nodes are
+// excluded like every other read path.
+func (db *DB) ListEntities(entityType string) ([]Entity, error) {
+	query := `
+		SELECT e.node_id, n.uri, n.l0_abstract, e.entity_type, e.name, e.location, e.aliases, e.updated_at
+		FROM entities e
+		JOIN mem_nodes n ON n.id = e.node_id
+		WHERE n.tombstoned_at IS NULL AND n.node_type = 'leaf'`
+	var args []any
+	if entityType != "" {
+		query += ` AND e.entity_type = ?`
+		args = append(args, entityType)
+	}
+	query += ` ORDER BY e.entity_type, e.name COLLATE NOCASE`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list entities: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Entity
+	for rows.Next() {
+		var e Entity
+		var aliases string
+		if err := rows.Scan(&e.NodeID, &e.URI, &e.L0, &e.Type, &e.Name, &e.Location, &aliases, &e.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan entity: %w", err)
+		}
+		if err := json.Unmarshal([]byte(aliases), &e.Aliases); err != nil {
+			return nil, fmt.Errorf("decode aliases for node %d: %w", e.NodeID, err)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
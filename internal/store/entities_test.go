@@ -0,0 +1,64 @@
+package store
+
+import "testing"
+
+func TestSaveAndListEntities(t *testing.T) {
+	db := testDB(t)
+	api := seedNode(t, db, "mem://user/entities/billing-api", "entities", "billing-api: payments service")
+	pg := seedNode(t, db, "mem://user/entities/postgres", "entities", "postgres: primary datastore")
+	fiona := seedNode(t, db, "mem://user/entities/fiona", "entities", "Fiona: companion agent")
+
+	if err := db.SaveEntity(api.ID, Entity{Type: "service", Name: "billing-api", Location: "services/billing", Aliases: []string{"billing"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveEntity(pg.ID, Entity{Type: "service", Name: "Postgres"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveEntity(fiona.ID, Entity{Type: "person", Name: "Fiona"}); err != nil {
+		t.Fatal(err)
+	}
+	// Re-saving replaces rather than duplicates.
+	if err := db.SaveEntity(api.ID, Entity{Type: "service", Name: "billing-api", Location: "services/billing-v2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	services, err := db.ListEntities("service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("got %d services, want 2: %+v", len(services), services)
+	}
+	if services[0].Name != "billing-api" || services[1].Name != "Postgres" {
+		t.Errorf("order = %s, %s; want billing-api, Postgres", services[0].Name, services[1].Name)
+	}
+	if services[0].Location != "services/billing-v2" || len(services[0].Aliases) != 0 {
+		t.Errorf("re-save not applied: %+v", services[0])
+	}
+	if services[0].URI != api.URI {
+		t.Errorf("URI = %q, want %q", services[0].URI, api.URI)
+	}
+
+	all, _ := db.ListEntities("")
+	if len(all) != 3 {
+		t.Errorf("unfiltered = %d, want 3", len(all))
+	}
+
+	// Retracted nodes drop out of the listing.
+	if _, err := db.RetractNode(fiona.URI, "test", ""); err != nil {
+		t.Fatal(err)
+	}
+	people, _ := db.ListEntities("person")
+	if len(people) != 0 {
+		t.Errorf("retracted entity still listed: %+v", people)
+	}
+
+	// Deleting the node cascades.
+	if err := db.DeleteNode(pg.ID); err != nil {
+		t.Fatal(err)
+	}
+	services, _ = db.ListEntities("service")
+	if len(services) != 1 {
+		t.Errorf("after delete: %d services, want 1", len(services))
+	}
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Error kinds a caller can act on without matching message text. Store
+// errors wrap one of these when the failure has such a meaning; test with
+// errors.Is. The HTTP layer maps them to 404, 409 and 503.
+var (
+	// ErrNotFound: the memory or session the call names doesn't exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict: the write collides with existing state — a taken URI,
+	// a retracted target, a uniqueness constraint.
+	ErrConflict = errors.New("conflict")
+
+	// ErrLocked: SQLite stayed busy past the busy timeout. Retrying later
+	// can succeed.
+	ErrLocked = errors.New("database is locked")
+)
+
+// kindError tags err with one of the kinds above without changing its
+// message, so a typed validation error still surfaces verbatim and still
+// matches errors.As.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+func withKind(kind, err error) error {
+	return &kindError{kind: kind, err: err}
+}
+
+// classify tags a raw SQLite failure in err's chain: busy or locked is
+// ErrLocked, a constraint violation ErrConflict. Anything else, including
+// nil, is returned as is.
+func classify(err error) error {
+	var se *sqlite.Error
+	if !errors.As(err, &se) {
+		return err
+	}
+	switch se.Code() & 0xff { // primary code; extended codes carry detail above it
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return withKind(ErrLocked, err)
+	case sqlite3.SQLITE_CONSTRAINT:
+		return withKind(ErrConflict, err)
+	}
+	return err
+}
+
+// Exec is sql.DB.Exec with SQLite failures classified, so every store
+// method built on it reports ErrLocked and ErrConflict.
+func (db *DB) Exec(query string, args ...any) (sql.Result, error) {
+	res, err := db.DB.Exec(query, args...)
+	return res, classify(err)
+}
+
+// Query is sql.DB.Query with SQLite failures classified, like Exec.
+func (db *DB) Query(query string, args ...any) (*sql.Rows, error) {
+	rows, err := db.DB.Query(query, args...)
+	return rows, classify(err)
+}
+
+// QueryRow is sql.DB.QueryRow with SQLite failures classified when the row
+// is scanned. sql.ErrNoRows passes through unchanged.
+func (db *DB) QueryRow(query string, args ...any) *Row {
+	return &Row{db.DB.QueryRow(query, args...)}
+}
+
+// Row is sql.Row with a classifying Scan.
+type Row struct{ *sql.Row }
+
+// Scan is sql.Row.Scan with SQLite failures classified.
+func (r *Row) Scan(dest ...any) error {
+	return classify(r.Row.Scan(dest...))
+}
+
+// Begin is sql.DB.Begin returning a Tx whose statements and commit are
+// classified, so a write transaction reports ErrLocked and ErrConflict like
+// a single Exec does.
+func (db *DB) Begin() (*Tx, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, classify(err)
+	}
+	return &Tx{tx}, nil
+}
+
+// Tx is sql.Tx with SQLite failures classified.
+type Tx struct{ *sql.Tx }
+
+// Exec is sql.Tx.Exec with SQLite failures classified.
+func (tx *Tx) Exec(query string, args ...any) (sql.Result, error) {
+	res, err := tx.Tx.Exec(query, args...)
+	return res, classify(err)
+}
+
+// Query is sql.Tx.Query with SQLite failures classified.
+func (tx *Tx) Query(query string, args ...any) (*sql.Rows, error) {
+	rows, err := tx.Tx.Query(query, args...)
+	return rows, classify(err)
+}
+
+// QueryRow is sql.Tx.QueryRow with a classifying Scan.
+func (tx *Tx) QueryRow(query string, args ...any) *Row {
+	return &Row{tx.Tx.QueryRow(query, args...)}
+}
+
+// Commit is sql.Tx.Commit with SQLite failures classified; a commit that
+// can't take the write lock is ErrLocked.
+func (tx *Tx) Commit() error {
+	return classify(tx.Tx.Commit())
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+	db := testDB(t)
+	node := seedNode(t, db, "mem://user/preferences/tabs", "preferences", "tabs over spaces")
+
+	// Not found keeps its typed validation error and message.
+	_, err := db.PinNode("mem://user/preferences/missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("PinNode missing = %v, want ErrNotFound", err)
+	}
+	var pve *PinValidationError
+	if !errors.As(err, &pve) || err.Error() != "memory not found: mem://user/preferences/missing" {
+		t.Errorf("PinNode missing = %v, want the PinValidationError message unchanged", err)
+	}
+
+	// A UNIQUE violation from SQLite is a conflict.
+	dup := &MemNode{URI: node.URI, NodeType: "leaf", Category: "preferences", L0Abstract: "dup"}
+	if err := db.CreateNode(dup); !errors.Is(err, ErrConflict) {
+		t.Errorf("CreateNode duplicate URI = %v, want ErrConflict", err)
+	}
+
+	// So is a move onto a taken URI, and an upsert into a retracted one.
+	seedNode(t, db, "mem://user/events/tabs", "events", "taken")
+	if _, err := db.MoveNode(node.ID, "mem://user/events/tabs", "events"); !errors.Is(err, ErrConflict) {
+		t.Errorf("MoveNode onto taken URI = %v, want ErrConflict", err)
+	}
+	if !errors.Is(ErrRetractedTarget, ErrConflict) {
+		t.Error("ErrRetractedTarget is not an ErrConflict")
+	}
+
+	// Plain failures carry no kind.
+	if _, err := db.PinNode("mem://user/preferences"); errors.Is(err, ErrNotFound) || errors.Is(err, ErrConflict) {
+		t.Errorf("PinNode directory = %v, want no error kind", err)
+	}
+}
+
+func TestErrLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.db")
+	holder, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer holder.Close()
+	node := seedNode(t, holder, "mem://user/preferences/tabs", "preferences", "tabs over spaces")
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open second handle: %v", err)
+	}
+	defer db.Close()
+	// One connection so the zero busy timeout applies to every call below.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA busy_timeout=0"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hold the write lock from the other handle.
+	tx, err := holder.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`UPDATE mem_nodes SET access_count = access_count + 1 WHERE id = ?`, node.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// A plain Exec, a scanned QueryRow and a transaction all report it.
+	if _, err := db.PinNode(node.URI); !errors.Is(err, ErrLocked) {
+		t.Errorf("PinNode while locked = %v, want ErrLocked", err)
+	}
+	var id int64
+	err = db.QueryRow(`UPDATE mem_nodes SET access_count = 0 WHERE id = ? RETURNING id`, node.ID).Scan(&id)
+	if !errors.Is(err, ErrLocked) {
+		t.Errorf("QueryRow while locked = %v, want ErrLocked", err)
+	}
+	if _, err := db.MoveNode(node.ID, "mem://user/events/tabs", "events"); !errors.Is(err, ErrLocked) {
+		t.Errorf("MoveNode while locked = %v, want ErrLocked", err)
+	}
+}
@@ -0,0 +1,239 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+// ExportRecord is one line of a JSON-lines export: a leaf with all of its
+// state, and its vector when it has one. IDs are not carried; an import
+// assigns fresh ones, so merged_from is kept only as history.
+type ExportRecord struct {
+	URI             string        `json:"uri"`
+	Category        string        `json:"category"`
+	L0              string        `json:"l0,omitempty"`
+	L1              string        `json:"l1,omitempty"`
+	L2              string        `json:"l2,omitempty"`
+	Mergeable       *bool         `json:"mergeable,omitempty"` // nil (older exports): the category default
+	MergedFrom      string        `json:"merged_from,omitempty"`
+	Relevance       float64       `json:"relevance"`
+	LastAccess      *int64        `json:"last_access,omitempty"`
+	AccessCount     int           `json:"access_count,omitempty"`
+	SourceSession   string        `json:"source_session,omitempty"`
+	CreatedAt       int64         `json:"created_at"`
+	UpdatedAt       int64         `json:"updated_at"`
+	TombstonedAt    *int64        `json:"tombstoned_at,omitempty"`
+	TombstoneReason string        `json:"tombstone_reason,omitempty"`
+	SupersededBy    string        `json:"superseded_by,omitempty"`
+	PinnedAt        *int64        `json:"pinned_at,omitempty"`
+	RelevanceSetAt  *int64        `json:"relevance_set_at,omitempty"`
+	Origin          string        `json:"origin,omitempty"`
+	Vector          *ExportVector `json:"vector,omitempty"`
+}
+
+// ExportVector is a stored embedding in an export: the little-endian float64
+// BLOB, base64-encoded, with the model and dimensions it was written under.
+type ExportVector struct {
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+	Embedding  string `json:"embedding"`
+	TextHash   string `json:"text_hash,omitempty"`
+}
+
+// ImportResult counts what ImportJSONL did.
+type ImportResult struct {
+	Imported       int // new leaves written
+	Skipped        int // records whose URI already exists
+	VectorsDropped int // imported leaves whose vector keepVector refused
+}
+
+// ExportJSONL writes every leaf, retracted ones included, as one
+// ExportRecord per line. Rows are streamed straight from the query, so memory
+// use doesn't grow with the store. Directories are left out; an import
+// recreates them, and so are session notes of any session but sessionID
+// (IsForeignSessionNote). Returns the number of records written.
+func (db *DB) ExportJSONL(w io.Writer, sessionID string) (int, error) {
+	rows, err := db.Query(`
+		SELECT n.id, n.uri, n.parent_uri, n.node_type, n.category, n.l0_abstract, n.l1_overview, n.l2_content,
+			n.mergeable, n.merged_from, n.relevance, n.last_access, n.access_count, n.source_session, n.created_at, n.updated_at,
+			n.tombstoned_at, n.tombstone_reason, n.superseded_by, n.pinned_at, n.relevance_set_at, n.origin,
+			v.embedding, v.model, v.dimensions, v.text_hash
+		FROM mem_nodes n LEFT JOIN mem_vectors v ON v.node_id = n.id
+		WHERE n.node_type = 'leaf'
+		ORDER BY n.id
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("export nodes: %w", err)
+	}
+	defer rows.Close()
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	count := 0
+	for rows.Next() {
+		var blob []byte
+		var model, textHash sql.NullString
+		var dims sql.NullInt64
+		n, err := scanNode(rows, &blob, &model, &dims, &textHash)
+		if err != nil {
+			return count, err
+		}
+		if n.IsForeignSessionNote(sessionID) {
+			continue
+		}
+		rec := exportRecordOf(n)
+		if blob != nil {
+			if _, err := decodeEmbedding(blob, int(dims.Int64)); err != nil {
+				log.Printf("export: leaving out the vector of %s: %v", n.URI, err)
+			} else {
+				rec.Vector = &ExportVector{
+					Model:      model.String,
+					Dimensions: int(dims.Int64),
+					Embedding:  base64.StdEncoding.EncodeToString(blob),
+					TextHash:   textHash.String,
+				}
+			}
+		}
+		if err := enc.Encode(rec); err != nil {
+			return count, fmt.Errorf("write %s: %w", n.URI, err)
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+func exportRecordOf(n MemNode) ExportRecord {
+	return ExportRecord{
+		URI:             n.URI,
+		Category:        n.Category,
+		L0:              n.L0Abstract,
+		L1:              n.L1Overview,
+		L2:              n.L2Content,
+		Mergeable:       &n.Mergeable,
+		MergedFrom:      n.MergedFrom,
+		Relevance:       n.Relevance,
+		LastAccess:      n.LastAccess,
+		AccessCount:     n.AccessCount,
+		SourceSession:   n.SourceSession,
+		CreatedAt:       n.CreatedAt,
+		UpdatedAt:       n.UpdatedAt,
+		TombstonedAt:    n.TombstonedAt,
+		TombstoneReason: n.TombstoneReason,
+		SupersededBy:    n.SupersededBy,
+		PinnedAt:        n.PinnedAt,
+		RelevanceSetAt:  n.RelevanceSetAt,
+		Origin:          n.Origin,
+	}
+}
+
+// ImportJSONL reads ExportRecords from r one at a time and writes each as a
+// new leaf with its recorded state and vector. A record whose URI already
+// exists is skipped, never merged or overwritten, so re-running an import is
+// harmless. Stops at the first malformed record; everything before it stays
+// imported.
+//
+// keepVector, when non-nil, decides per record whether its vector is written:
+// one from another embedder is in a different vector space from this corpus,
+// so the leaf is imported without it and left for EmbedMissing to re-embed.
+func (db *DB) ImportJSONL(r io.Reader, keepVector func(model string, dims int) bool) (ImportResult, error) {
+	var res ImportResult
+	dec := json.NewDecoder(r)
+	for i := 1; ; i++ {
+		var rec ExportRecord
+		if err := dec.Decode(&rec); errors.Is(err, io.EOF) {
+			return res, nil
+		} else if err != nil {
+			return res, fmt.Errorf("record %d: %w", i, err)
+		}
+		dropped := false
+		if v := rec.Vector; v != nil && keepVector != nil && !keepVector(v.Model, v.Dimensions) {
+			rec.Vector, dropped = nil, true
+		}
+		created, err := db.importRecord(rec)
+		if err != nil {
+			return res, fmt.Errorf("record %d (%s): %w", i, rec.URI, err)
+		}
+		if created {
+			res.Imported++
+			if dropped {
+				res.VectorsDropped++
+			}
+		} else {
+			res.Skipped++
+		}
+	}
+}
+
+// importRecord writes one record and its vector in a transaction. Returns
+// false when the URI is already taken.
+func (db *DB) importRecord(rec ExportRecord) (bool, error) {
+	if !strings.HasPrefix(rec.URI, "mem://") || rec.Category == "" {
+		return false, fmt.Errorf("uri and category required")
+	}
+	var blob []byte
+	if v := rec.Vector; v != nil {
+		var err error
+		if blob, err = base64.StdEncoding.DecodeString(v.Embedding); err != nil {
+			return false, fmt.Errorf("vector: %w", err)
+		}
+		if _, err := decodeEmbedding(blob, v.Dimensions); err != nil {
+			return false, fmt.Errorf("vector: %w", err)
+		}
+	}
+	if err := db.EnsureParentDirs(rec.URI, rec.Category); err != nil {
+		return false, fmt.Errorf("ensure parents: %w", err)
+	}
+	defer db.invalidateNodes(rec.URI)
+
+	mergeable := 0
+	if rec.Mergeable != nil && *rec.Mergeable || rec.Mergeable == nil && IsMergeable(rec.Category) {
+		mergeable = 1
+	}
+	now := time.Now().UnixMilli()
+	if rec.CreatedAt == 0 {
+		rec.CreatedAt = now
+	}
+	if rec.UpdatedAt == 0 {
+		rec.UpdatedAt = rec.CreatedAt
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		INSERT INTO mem_nodes (uri, parent_uri, node_type, category, l0_abstract, l1_overview, l2_content,
+			mergeable, merged_from, relevance, last_access, access_count, source_session, created_at, updated_at,
+			tombstoned_at, tombstone_reason, superseded_by, pinned_at, relevance_set_at, origin)
+		VALUES (?, NULLIF(?, ''), 'leaf', ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, NULLIF(?, ''))
+		ON CONFLICT(uri) DO NOTHING
+	`, rec.URI, parentURIOf(rec.URI), rec.Category, rec.L0, rec.L1, rec.L2,
+		mergeable, rec.MergedFrom, rec.Relevance, rec.LastAccess, rec.AccessCount, rec.SourceSession,
+		rec.CreatedAt, rec.UpdatedAt,
+		rec.TombstonedAt, rec.TombstoneReason, rec.SupersededBy, rec.PinnedAt, rec.RelevanceSetAt, rec.Origin)
+	if err != nil {
+		return false, fmt.Errorf("insert node: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	if blob != nil {
+		id, _ := res.LastInsertId()
+		if _, err := tx.Exec(`
+			INSERT INTO mem_vectors (node_id, embedding, model, dimensions, created_at, text_hash)
+			VALUES (?, ?, ?, ?, ?, NULLIF(?, ''))
+		`, id, blob, rec.Vector.Model, rec.Vector.Dimensions, now, rec.Vector.TextHash); err != nil {
+			return false, fmt.Errorf("insert vector: %w", err)
+		}
+	}
+	return true, tx.Commit()
+}
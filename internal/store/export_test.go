@@ -0,0 +1,116 @@
+package store
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExportImportJSONL(t *testing.T) {
+	src := testDB(t)
+	for _, uri := range []string{
+		"mem://user/preferences/editor",
+		"mem://user/preferences/old-editor",
+		"mem://agent/patterns/wal-mode",
+	} {
+		if err := src.CreateNode(&MemNode{URI: uri, NodeType: "leaf", Category: uriCategory(uri), L0Abstract: "about " + uri, L1Overview: "Overview of " + uri}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	editor, _ := src.GetNodeByURI("mem://user/preferences/editor")
+	if err := src.SaveVector(editor.ID, []float64{0.25, -1, 3.5}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.PinNode("mem://user/preferences/editor"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.RetractNode("mem://user/preferences/old-editor", "outdated", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.SetRelevance("mem://agent/patterns/wal-mode", 0.4); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another session's note stays out of the export.
+	if err := src.CreateNode(&MemNode{URI: "mem://user/session/other/freeze", NodeType: "leaf", Category: "session", L0Abstract: "Freeze staging", SourceSession: "other"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	n, err := src.ExportJSONL(&buf, "")
+	if err != nil {
+		t.Fatalf("ExportJSONL: %v", err)
+	}
+	if n != 3 || strings.Count(buf.String(), "\n") != 3 {
+		t.Fatalf("exported %d records:\n%s", n, buf.String())
+	}
+	export := buf.String()
+
+	dst := testDB(t)
+	res, err := dst.ImportJSONL(strings.NewReader(export), nil)
+	if err != nil {
+		t.Fatalf("ImportJSONL: %v", err)
+	}
+	if res.Imported != 3 || res.Skipped != 0 {
+		t.Fatalf("first import = %+v", res)
+	}
+
+	got, _ := dst.GetNodeByURI("mem://user/preferences/editor")
+	if got == nil || got.PinnedAt == nil || got.L1Overview != editor.L1Overview || got.CreatedAt != editor.CreatedAt || !got.Mergeable {
+		t.Errorf("imported editor = %+v", got)
+	}
+	if vec, _ := dst.GetVector(got.ID); vec == nil || vec.Model != "test" || len(vec.Embedding) != 3 || vec.Embedding[2] != 3.5 {
+		t.Errorf("imported vector = %+v", vec)
+	}
+	if old, _ := dst.GetNodeByURI("mem://user/preferences/old-editor"); old == nil || !old.IsRetracted() || old.TombstoneReason != "outdated" {
+		t.Errorf("retraction not carried over: %+v", old)
+	}
+	if wal, _ := dst.GetNodeByURI("mem://agent/patterns/wal-mode"); wal == nil || !wal.IsRelevanceSet() || wal.Relevance != 0.4 {
+		t.Errorf("hand-set relevance not carried over: %+v", wal)
+	}
+	if dir, _ := dst.GetNodeByURI("mem://user/preferences"); dir == nil || dir.NodeType != "dir" {
+		t.Error("import should recreate parent directories")
+	}
+
+	// Re-importing skips what's there rather than duplicating or overwriting.
+	if res, err := dst.ImportJSONL(strings.NewReader(export), nil); err != nil || res.Imported != 0 || res.Skipped != 3 {
+		t.Errorf("re-import = %+v, %v", res, err)
+	}
+
+	if _, err := dst.ImportJSONL(strings.NewReader(`{"uri":"mem://user/preferences/x","category":"preferences"}`+"\n{not json\n"), nil); err == nil || !strings.Contains(err.Error(), "record 2") {
+		t.Errorf("malformed record: err = %v, want it to name record 2", err)
+	}
+}
+
+func TestImportJSONLDropsRefusedVectors(t *testing.T) {
+	src := testDB(t)
+	n := seedNode(t, src, "mem://user/preferences/editor", "preferences", "Uses vim")
+	if err := src.SaveVector(n.ID, []float64{1, 0, 0}, "other-model"); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := src.ExportJSONL(&buf, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := testDB(t)
+	res, err := dst.ImportJSONL(&buf, func(model string, dims int) bool { return model == "test" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Imported != 1 || res.VectorsDropped != 1 {
+		t.Errorf("import = %+v, want the leaf imported and its vector dropped", res)
+	}
+	got, _ := dst.GetNodeByURI("mem://user/preferences/editor")
+	if got == nil {
+		t.Fatal("leaf not imported")
+	}
+	if vec, _ := dst.GetVector(got.ID); vec != nil {
+		t.Errorf("refused vector was written: %+v", vec)
+	}
+}
+
+// uriCategory returns the category segment of a mem://owner/category/slug URI.
+func uriCategory(uri string) string {
+	return strings.Split(strings.TrimPrefix(uri, "mem://"), "/")[1]
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// FailedExtraction is a session whose extraction errored and hasn't since
+// succeeded: where its transcript is, the last error, and how many attempts
+// have failed.
+type FailedExtraction struct {
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
+	Error          string `json:"error"`
+	Attempts       int    `json:"attempts"`
+	FirstFailedAt  int64  `json:"first_failed_at"`
+	LastFailedAt   int64  `json:"last_failed_at"`
+}
+
+// RecordFailedExtraction notes a failed extraction of sessionID, counting the
+// attempt and keeping the latest error and transcript path.
+func (db *DB) RecordFailedExtraction(sessionID, transcriptPath, errMsg string) error {
+	now := time.Now().UnixMilli()
+	_, err := db.Exec(`
+		INSERT INTO failed_extractions (session_id, transcript_path, error, attempts, first_failed_at, last_failed_at)
+		VALUES (?, ?, ?, 1, ?, ?)
+		ON CONFLICT (session_id) DO UPDATE SET
+			transcript_path = excluded.transcript_path, error = excluded.error,
+			attempts = attempts + 1, last_failed_at = excluded.last_failed_at
+	`, sessionID, transcriptPath, errMsg, now, now)
+	if err != nil {
+		return fmt.Errorf("record failed extraction: %w", err)
+	}
+	return nil
+}
+
+// ClearFailedExtraction drops sessionID's failure record, if it has one.
+func (db *DB) ClearFailedExtraction(sessionID string) error {
+	if _, err := db.Exec(`DELETE FROM failed_extractions WHERE session_id = ?`, sessionID); err != nil {
+		return fmt.Errorf("clear failed extraction: %w", err)
+	}
+	return nil
+}
+
+// ListFailedExtractions returns every recorded failure, oldest first.
+func (db *DB) ListFailedExtractions() ([]FailedExtraction, error) {
+	rows, err := db.Query(`
+		SELECT session_id, transcript_path, error, attempts, first_failed_at, last_failed_at
+		FROM failed_extractions
+		ORDER BY first_failed_at, session_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list failed extractions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []FailedExtraction
+	for rows.Next() {
+		var f FailedExtraction
+		if err := rows.Scan(&f.SessionID, &f.TranscriptPath, &f.Error, &f.Attempts, &f.FirstFailedAt, &f.LastFailedAt); err != nil {
+			return nil, fmt.Errorf("scan failed extraction: %w", err)
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// InjectedMemory identifies one memory rendered into a session's context block.
+type InjectedMemory struct {
+	URI      string
+	Category string
+}
+
+// CategoryUsefulness is the injection→use rate for one category: how many
+// injected memories a later search in the same session actually retrieved.
+type CategoryUsefulness struct {
+	Category string  `json:"category"`
+	Injected int     `json:"injected"`
+	Used     int     `json:"used"`
+	Rate     float64 `json:"rate"` // used / injected, 0..1
+}
+
+// RecordInjections records which memories were injected into a session's
+// context. Re-injecting the same memory into the same session (a resumed
+// session re-running SessionStart) keeps the original row, so a use already
+// recorded is not reset.
+func (db *DB) RecordInjections(sessionID string, injected []InjectedMemory) error {
+	if sessionID == "" || len(injected) == 0 {
+		return nil
+	}
+	now := time.Now().UnixMilli()
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin record injections: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, m := range injected {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO context_injections (session_id, uri, category, injected_at)
+			VALUES (?, ?, ?, ?)
+		`, sessionID, m.URI, m.Category, now); err != nil {
+			return fmt.Errorf("record injection %s: %w", m.URI, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// MarkInjectionUsed stamps used_at on the injection of uri into sessionID, if
+// there was one. Only the first use is recorded. Returns whether a row changed.
+func (db *DB) MarkInjectionUsed(sessionID, uri string) (bool, error) {
+	if sessionID == "" {
+		return false, nil
+	}
+	res, err := db.Exec(`
+		UPDATE context_injections SET used_at = ?
+		WHERE session_id = ? AND uri = ? AND used_at IS NULL
+	`, time.Now().UnixMilli(), sessionID, uri)
+	if err != nil {
+		return false, fmt.Errorf("mark injection used: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// InjectionUsefulness returns injection→use rates per category, highest
+// injection count first.
+func (db *DB) InjectionUsefulness() ([]CategoryUsefulness, error) {
+	rows, err := db.Query(`
+		SELECT category, COUNT(*), COUNT(used_at)
+		FROM context_injections
+		GROUP BY category
+		ORDER BY COUNT(*) DESC, category ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("injection usefulness: %w", err)
+	}
+	defer rows.Close()
+
+	var out []CategoryUsefulness
+	for rows.Next() {
+		var u CategoryUsefulness
+		if err := rows.Scan(&u.Category, &u.Injected, &u.Used); err != nil {
+			return nil, fmt.Errorf("scan usefulness: %w", err)
+		}
+		if u.Injected > 0 {
+			u.Rate = float64(u.Used) / float64(u.Injected)
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// ContextVersion fingerprints the state the context block is rendered from:
+// node writes (every content, pin, and retraction change bumps updated_at;
+// the count catches deletions) and the recent-sessions list (starts, ends,
+// status, tool counts, tones). Equal versions render the same context, modulo
+// the "Current:" clock line and moment rotation — both of which a caller
+// reusing a cached block accepts. Relevance decay does not bump updated_at and
+// so does not change the version; it moves too slowly to matter between rapid
+// session restarts.
+func (db *DB) ContextVersion() (string, error) {
+	var nodeMax, nodeCount int64
+	if err := db.QueryRow(`
+		SELECT COALESCE(MAX(updated_at), 0), COUNT(*) FROM mem_nodes
+	`).Scan(&nodeMax, &nodeCount); err != nil {
+		return "", fmt.Errorf("context version (nodes): %w", err)
+	}
+	var sessCount, started, ended, tools, tones, active int64
+	if err := db.QueryRow(`
+		SELECT COUNT(*), COALESCE(MAX(started_at), 0), COALESCE(MAX(ended_at), 0),
+			COALESCE(SUM(tool_count), 0), COUNT(tone), COALESCE(SUM(status = 'active'), 0)
+		FROM sessions
+	`).Scan(&sessCount, &started, &ended, &tools, &tones, &active); err != nil {
+		return "", fmt.Errorf("context version (sessions): %w", err)
+	}
+	h := fnv.New64a()
+	fmt.Fprint(h, nodeMax, nodeCount, sessCount, started, ended, tools, tones, active)
+	return fmt.Sprintf("%016x", h.Sum64()), nil
+}
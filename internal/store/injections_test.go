@@ -0,0 +1,45 @@
+package store
+
+import "testing"
+
+func TestInjectionUsefulness(t *testing.T) {
+	db := testDB(t)
+
+	err := db.RecordInjections("sess-1", []InjectedMemory{
+		{URI: "mem://user/preferences/a", Category: "preferences"},
+		{URI: "mem://user/preferences/b", Category: "preferences"},
+		{URI: "mem://user/events/c", Category: "events"},
+	})
+	if err != nil {
+		t.Fatalf("RecordInjections: %v", err)
+	}
+
+	used, err := db.MarkInjectionUsed("sess-1", "mem://user/preferences/a")
+	if err != nil || !used {
+		t.Fatalf("MarkInjectionUsed = %v, %v; want true", used, err)
+	}
+	// Second use is not double-counted; other sessions don't match.
+	if used, _ := db.MarkInjectionUsed("sess-1", "mem://user/preferences/a"); used {
+		t.Error("repeat use should not re-stamp")
+	}
+	if used, _ := db.MarkInjectionUsed("sess-2", "mem://user/events/c"); used {
+		t.Error("use in a different session must not count")
+	}
+
+	// A resumed session re-injecting must not reset the recorded use.
+	db.RecordInjections("sess-1", []InjectedMemory{{URI: "mem://user/preferences/a", Category: "preferences"}})
+
+	rows, err := db.InjectionUsefulness()
+	if err != nil {
+		t.Fatalf("InjectionUsefulness: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d categories, want 2", len(rows))
+	}
+	if rows[0].Category != "preferences" || rows[0].Injected != 2 || rows[0].Used != 1 || rows[0].Rate != 0.5 {
+		t.Errorf("preferences row = %+v, want 2 injected / 1 used", rows[0])
+	}
+	if rows[1].Category != "events" || rows[1].Used != 0 {
+		t.Errorf("events row = %+v, want 0 used", rows[1])
+	}
+}
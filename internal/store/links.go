@@ -0,0 +1,157 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RelatedTo is the relation extraction records between memories that
+// reference each other. Other relation names are free-form lowercase words.
+const RelatedTo = "related_to"
+
+// Link is one typed edge between two memories, by URI.
+type Link struct {
+	FromURI   string `json:"from"`
+	ToURI     string `json:"to"`
+	Relation  string `json:"relation"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// LinkValidationError signals that a link was rejected for a user/domain
+// reason (endpoint missing, directory, retracted, self-link) rather than an
+// internal failure. Message is safe to surface verbatim. Mirrors
+// MergeValidationError.
+type LinkValidationError struct {
+	Message string
+}
+
+func (e *LinkValidationError) Error() string {
+	return e.Message
+}
+
+func linkValidationErrorf(format string, args ...any) error {
+	return &LinkValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+// LinkNodes records that fromURI relates to toURI under relation (RelatedTo
+// when empty). Both ends must be live leaves and distinct. Idempotent:
+// returns false when the link already existed.
+func (db *DB) LinkNodes(fromURI, toURI, relation string) (bool, error) {
+	relation = strings.ToLower(strings.TrimSpace(relation))
+	if relation == "" {
+		relation = RelatedTo
+	}
+	if fromURI == toURI {
+		return false, linkValidationErrorf("cannot link a memory to itself: %s", fromURI)
+	}
+	for _, uri := range []string{fromURI, toURI} {
+		node, err := db.GetNodeByURI(uri)
+		if err != nil {
+			return false, fmt.Errorf("look up %s: %w", uri, err)
+		}
+		switch {
+		case node == nil:
+			return false, withKind(ErrNotFound, linkValidationErrorf("memory not found: %s", uri))
+		case node.NodeType != "leaf":
+			return false, linkValidationErrorf("cannot link %s node: %s (only leaf memories link)", node.NodeType, uri)
+		case node.IsRetracted():
+			return false, linkValidationErrorf("cannot link retracted memory: %s", uri)
+		}
+	}
+
+	res, err := db.Exec(`
+		INSERT OR IGNORE INTO links (from_uri, to_uri, relation, created_at)
+		VALUES (?, ?, ?, ?)
+	`, fromURI, toURI, relation, time.Now().UnixMilli())
+	if err != nil {
+		return false, fmt.Errorf("link nodes: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// linkColumns selects a link whose ends are both live leaves; rows naming a
+// deleted or retracted memory are left in place but never returned.
+const linkColumns = `
+	SELECT l.from_uri, l.to_uri, l.relation, l.created_at
+	FROM links l
+	JOIN mem_nodes f ON f.uri = l.from_uri AND f.node_type = 'leaf' AND f.tombstoned_at IS NULL
+	JOIN mem_nodes t ON t.uri = l.to_uri AND t.node_type = 'leaf' AND t.tombstoned_at IS NULL
+`
+
+// GetLinks returns the live links touching uri in either direction, oldest
+// first.
+func (db *DB) GetLinks(uri string) ([]Link, error) {
+	return db.queryLinks(linkColumns+`
+		WHERE l.from_uri = ? OR l.to_uri = ?
+		ORDER BY l.created_at, l.from_uri, l.to_uri
+	`, uri, uri)
+}
+
+// LinksAmong returns the live links whose ends are both in uris.
+func (db *DB) LinksAmong(uris []string) ([]Link, error) {
+	if len(uris) < 2 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(uris)), ",")
+	args := make([]any, 0, 2*len(uris))
+	for _, u := range uris {
+		args = append(args, u)
+	}
+	args = append(args, args...)
+	return db.queryLinks(linkColumns+`
+		WHERE l.from_uri IN (`+placeholders+`) AND l.to_uri IN (`+placeholders+`)
+	`, args...)
+}
+
+func (db *DB) queryLinks(query string, args ...any) ([]Link, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []Link
+	for rows.Next() {
+		var l Link
+		if err := rows.Scan(&l.FromURI, &l.ToURI, &l.Relation, &l.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan link: %w", err)
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// deleteNodeLinks drops the links touching node id, ahead of deleting it.
+// Links are keyed by URI, so left behind they would attach to whatever
+// memory is later written at that URI.
+func deleteNodeLinks(tx *Tx, id int64) error {
+	if _, err := tx.Exec(`
+		DELETE FROM links
+		WHERE from_uri = (SELECT uri FROM mem_nodes WHERE id = ?1)
+			OR to_uri = (SELECT uri FROM mem_nodes WHERE id = ?1)
+	`, id); err != nil {
+		return fmt.Errorf("delete links of node %d: %w", id, err)
+	}
+	return nil
+}
+
+// repointLinks moves the links of node fromID onto node toID, for a merge or
+// dedup that folds one memory into another. A link the survivor already has
+// stays on fromID to go with it; one that would now join the survivor to
+// itself is dropped.
+func repointLinks(tx *Tx, fromID, toID int64) error {
+	for _, q := range []string{
+		`UPDATE OR IGNORE links SET from_uri = (SELECT uri FROM mem_nodes WHERE id = ?2)
+			WHERE from_uri = (SELECT uri FROM mem_nodes WHERE id = ?1)`,
+		`UPDATE OR IGNORE links SET to_uri = (SELECT uri FROM mem_nodes WHERE id = ?2)
+			WHERE to_uri = (SELECT uri FROM mem_nodes WHERE id = ?1)`,
+		`DELETE FROM links WHERE from_uri = to_uri`,
+	} {
+		if _, err := tx.Exec(q, fromID, toID); err != nil {
+			return fmt.Errorf("repoint links of node %d: %w", fromID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLinkNodes(t *testing.T) {
+	db := testDB(t)
+	a := seedNode(t, db, "mem://agent/cases/a", "cases", "a")
+	seedNode(t, db, "mem://agent/patterns/b", "patterns", "b")
+	seedNode(t, db, "mem://user/events/c", "events", "c")
+
+	newly, err := db.LinkNodes(a.URI, "mem://agent/patterns/b", "")
+	if err != nil || !newly {
+		t.Fatalf("LinkNodes = %v, %v; want a new link", newly, err)
+	}
+	if newly, err := db.LinkNodes(a.URI, "mem://agent/patterns/b", RelatedTo); err != nil || newly {
+		t.Errorf("relink = %v, %v; want idempotent", newly, err)
+	}
+	if _, err := db.LinkNodes("mem://user/events/c", a.URI, "Follows"); err != nil {
+		t.Fatalf("LinkNodes: %v", err)
+	}
+
+	links, err := db.GetLinks(a.URI)
+	if err != nil {
+		t.Fatalf("GetLinks: %v", err)
+	}
+	if len(links) != 2 {
+		t.Fatalf("links = %+v, want 2 (both directions)", links)
+	}
+	if links[0].ToURI != "mem://agent/patterns/b" || links[0].Relation != RelatedTo {
+		t.Errorf("first link = %+v, want related_to b", links[0])
+	}
+	if links[1].FromURI != "mem://user/events/c" || links[1].Relation != "follows" {
+		t.Errorf("second link = %+v, want incoming follows from c", links[1])
+	}
+
+	among, err := db.LinksAmong([]string{a.URI, "mem://agent/patterns/b"})
+	if err != nil {
+		t.Fatalf("LinksAmong: %v", err)
+	}
+	if len(among) != 1 {
+		t.Errorf("LinksAmong = %+v, want only the a↔b link", among)
+	}
+
+	// A retracted end hides the link without deleting it.
+	if _, err := db.RetractNode("mem://agent/patterns/b", "wrong", ""); err != nil {
+		t.Fatalf("RetractNode: %v", err)
+	}
+	if links, _ := db.GetLinks(a.URI); len(links) != 1 {
+		t.Errorf("links after retracting b = %+v, want only c's", links)
+	}
+}
+
+func TestLinkNodes_Refusals(t *testing.T) {
+	db := testDB(t)
+	a := seedNode(t, db, "mem://agent/cases/a", "cases", "a")
+	c := seedNode(t, db, "mem://agent/cases/c", "cases", "c")
+	if _, err := db.RetractNode(c.URI, "wrong", ""); err != nil {
+		t.Fatalf("RetractNode: %v", err)
+	}
+
+	cases := []struct {
+		name, to, want string
+	}{
+		{"self", a.URI, "itself"},
+		{"missing", "mem://agent/cases/nope", "not found"},
+		{"directory", "mem://agent/cases", "only leaf"},
+		{"retracted", c.URI, "retracted"},
+	}
+	for _, tc := range cases {
+		_, err := db.LinkNodes(a.URI, tc.to, RelatedTo)
+		var lve *LinkValidationError
+		if !errors.As(err, &lve) {
+			t.Errorf("%s: err = %v, want LinkValidationError", tc.name, err)
+			continue
+		}
+		if !strings.Contains(lve.Message, tc.want) {
+			t.Errorf("%s: message %q missing %q", tc.name, lve.Message, tc.want)
+		}
+	}
+}
+
+func TestLinksFollowMergesAndDeletes(t *testing.T) {
+	db := testDB(t)
+	keep := seedNode(t, db, "mem://agent/cases/keep", "cases", "keep")
+	dup := seedNode(t, db, "mem://agent/cases/dup", "cases", "dup")
+	seedNode(t, db, "mem://agent/patterns/b", "patterns", "b")
+	for _, l := range [][2]string{{dup.URI, "mem://agent/patterns/b"}, {"mem://agent/patterns/b", dup.URI}, {keep.URI, dup.URI}} {
+		if _, err := db.LinkNodes(l[0], l[1], ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rowsTouching := func(uri string) int {
+		var n int
+		db.QueryRow(`SELECT COUNT(*) FROM links WHERE from_uri = ? OR to_uri = ?`, uri, uri).Scan(&n)
+		return n
+	}
+
+	if _, err := db.MergeNodes(keep.ID, dup.ID); err != nil {
+		t.Fatalf("MergeNodes: %v", err)
+	}
+	if links, _ := db.GetLinks(keep.URI); len(links) != 2 {
+		t.Errorf("keeper links after merge = %+v, want dup's two links to b moved over and no self-link", links)
+	}
+	if n := rowsTouching(dup.URI); n != 0 {
+		t.Errorf("%d link rows still name the merged memory", n)
+	}
+
+	// Dedup moves links the same way.
+	twin := seedNode(t, db, "mem://agent/cases/twin", "cases", "twin")
+	if _, err := db.LinkNodes(twin.URI, "mem://agent/patterns/b", "follows"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AbsorbDuplicates(map[int64][]int64{keep.ID: {twin.ID}}); err != nil {
+		t.Fatalf("AbsorbDuplicates: %v", err)
+	}
+	if links, _ := db.GetLinks(keep.URI); len(links) != 3 {
+		t.Errorf("keeper links after dedup = %+v, want the twin's follows link too", links)
+	}
+
+	// A deleted memory's links go with it, so a new memory at its URI
+	// doesn't inherit them.
+	if err := db.DeleteNodes([]int64{keep.ID}); err != nil {
+		t.Fatalf("DeleteNodes: %v", err)
+	}
+	if n := rowsTouching(keep.URI); n != 0 {
+		t.Errorf("%d link rows survive deleting the memory", n)
+	}
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMaintain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "maintain.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	body := strings.Repeat("padding ", 500)
+	for i := range 200 {
+		if err := db.CreateNode(&MemNode{
+			URI: fmt.Sprintf("mem://user/events/n%d", i), NodeType: "leaf", Category: "events",
+			L0Abstract: "bulk", L2Content: body,
+		}); err != nil {
+			t.Fatalf("CreateNode: %v", err)
+		}
+	}
+	if _, err := db.Exec(`DELETE FROM mem_nodes WHERE node_type = 'leaf'`); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	r, err := db.Maintain(true)
+	if err != nil {
+		t.Fatalf("Maintain: %v", err)
+	}
+	if !r.Checkpointed || !r.Vacuumed {
+		t.Errorf("report = %+v, want checkpointed and vacuumed", r)
+	}
+	if r.WALBytesAfter != 0 {
+		t.Errorf("WAL after = %d bytes, want truncated", r.WALBytesAfter)
+	}
+	if before := r.DBBytesBefore + r.WALBytesBefore; r.DBBytesAfter >= before {
+		t.Errorf("size after = %d, want below %d once deleted pages are reclaimed", r.DBBytesAfter, before)
+	}
+
+	r, err = db.Maintain(false)
+	if err != nil {
+		t.Fatalf("Maintain checkpoint only: %v", err)
+	}
+	if r.Vacuumed {
+		t.Error("Maintain(false) vacuumed")
+	}
+	db.Close()
+
+	ro, err := OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	defer ro.Close()
+	if _, err := ro.Maintain(true); err == nil {
+		t.Error("Maintain on a read-only database: want error")
+	}
+}
@@ -0,0 +1,263 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MergeValidationError signals that a manual merge was rejected for a
+// user/domain reason (node missing, directory, retracted, category mismatch)
+// rather than an internal failure. Message is safe to surface verbatim.
+// Mirrors PinValidationError.
+type MergeValidationError struct {
+	Message string
+}
+
+func (e *MergeValidationError) Error() string {
+	return e.Message
+}
+
+func mergeValidationErrorf(format string, args ...any) error {
+	return &MergeValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+// mergeSeparator joins the keeper's L2 with the absorbed node's content.
+const mergeSeparator = "\n\n---\n\n"
+
+// MergeNodes folds mergeID into keepID: the merged node's L2 (or L1 when it
+// has no L2) is appended to the keeper's L2, its ID — plus anything it had
+// itself absorbed — is recorded in the keeper's merged_from, its access count
+// carries over, its links and session records move to the keeper, and the
+// merged node and its vector are deleted. The keeper's L0 is untouched, so its vector stays valid.
+//
+// This is the manual counterpart to Dedup for pairs the similarity threshold
+// gets wrong. Both nodes must be live leaves in the same category; retracted
+// nodes are refused (a merge would either resurrect a tombstone's content or
+// silently drop it). A pin on the merged node moves to the keeper. Runs in a
+// single transaction so a failure leaves both nodes intact.
+func (db *DB) MergeNodes(keepID, mergeID int64) (*MemNode, error) {
+	defer db.invalidateNodes()
+	if keepID == mergeID {
+		return nil, mergeValidationErrorf("cannot merge a memory into itself")
+	}
+
+	keep, err := db.GetNodeByID(keepID)
+	if err != nil {
+		return nil, fmt.Errorf("look up keeper: %w", err)
+	}
+	merge, err := db.GetNodeByID(mergeID)
+	if err != nil {
+		return nil, fmt.Errorf("look up merge source: %w", err)
+	}
+	if keep == nil {
+		return nil, withKind(ErrNotFound, mergeValidationErrorf("memory not found: id %d", keepID))
+	}
+	if merge == nil {
+		return nil, withKind(ErrNotFound, mergeValidationErrorf("memory not found: id %d", mergeID))
+	}
+	for _, n := range []*MemNode{keep, merge} {
+		if n.NodeType != "leaf" {
+			return nil, mergeValidationErrorf("cannot merge %s node: %s (only leaf memories merge)", n.NodeType, n.URI)
+		}
+		if n.IsRetracted() {
+			return nil, mergeValidationErrorf("cannot merge retracted memory: %s", n.URI)
+		}
+	}
+	// The keeper may predate the depth limit; don't grow content under a URI
+	// the store would no longer create.
+	if err := db.CheckURIDepth(keep.URI); err != nil {
+		return nil, mergeValidationErrorf("cannot merge into %s: %v", keep.URI, err)
+	}
+	if keep.Category != merge.Category {
+		return nil, mergeValidationErrorf("cannot merge across categories: %s is %s, %s is %s",
+			keep.URI, keep.Category, merge.URI, merge.Category)
+	}
+
+	absorbed := merge.L2Content
+	if strings.TrimSpace(absorbed) == "" {
+		absorbed = merge.L1Overview
+	}
+	l2 := keep.L2Content
+	switch {
+	case strings.TrimSpace(absorbed) == "":
+	case strings.TrimSpace(l2) == "":
+		l2 = absorbed
+	default:
+		l2 = l2 + mergeSeparator + absorbed
+	}
+
+	mergedFrom, err := appendMergedFrom(keep.MergedFrom, map[int64]string{mergeID: merge.MergedFrom})
+	if err != nil {
+		return nil, err
+	}
+
+	pinnedAt := keep.PinnedAt
+	if pinnedAt == nil {
+		pinnedAt = merge.PinnedAt
+	}
+
+	now := time.Now().UnixMilli()
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin merge: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE mem_nodes SET l2_content = ?, merged_from = ?, access_count = access_count + ?,
+			pinned_at = ?, updated_at = ?
+		WHERE id = ? AND tombstoned_at IS NULL
+	`, l2, mergedFrom, merge.AccessCount, pinnedAt, now, keepID)
+	if err != nil {
+		return nil, fmt.Errorf("update keeper: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, mergeValidationErrorf("cannot merge retracted memory: %s", keep.URI)
+	}
+	var live int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM mem_nodes WHERE id = ? AND tombstoned_at IS NULL`, mergeID).Scan(&live); err != nil {
+		return nil, fmt.Errorf("recheck merge source: %w", err)
+	}
+	if live == 0 {
+		return nil, mergeValidationErrorf("cannot merge retracted memory: %s", merge.URI)
+	}
+	if err := absorbNodeTx(tx, mergeID, keepID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit merge: %w", err)
+	}
+
+	db.DeleteOrphanDirs()
+	return db.GetNodeByID(keepID)
+}
+
+// appendMergedFrom returns the keeper's merged_from JSON array extended with
+// the absorbed IDs and whatever those nodes had themselves absorbed, each
+// listed once, in the order first seen.
+func appendMergedFrom(keepJSON string, absorbed map[int64]string) (sql.NullString, error) {
+	ids, err := ParseMergedFrom(keepJSON)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	add := func(id int64) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	// Map order is random; walk the absorbed IDs sorted so the array is
+	// stable.
+	order := make([]int64, 0, len(absorbed))
+	for id := range absorbed {
+		order = append(order, id)
+	}
+	sort.Slice(order, func(i, j int) bool { return order[i] < order[j] })
+	for _, id := range order {
+		prior, err := ParseMergedFrom(absorbed[id])
+		if err != nil {
+			return sql.NullString{}, err
+		}
+		for _, p := range prior {
+			add(p)
+		}
+		add(id)
+	}
+	b, err := json.Marshal(ids)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("encode merged_from: %w", err)
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+// ParseMergedFrom decodes a merged_from column: a JSON array of the IDs of
+// the nodes folded into a memory. Empty means none.
+func ParseMergedFrom(raw string) ([]int64, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var ids []int64
+	if err := json.Unmarshal([]byte(raw), &ids); err != nil {
+		return nil, fmt.Errorf("parse merged_from %q: %w", raw, err)
+	}
+	return ids, nil
+}
+
+// GetMergeProvenance returns the IDs of the nodes merged into node id, by
+// MergeNodes or by dedup, oldest merge first. The IDs no longer resolve —
+// merged nodes are deleted — but they count and identify the sources.
+func (db *DB) GetMergeProvenance(id int64) ([]int64, error) {
+	var raw sql.NullString
+	err := db.QueryRow(`SELECT merged_from FROM mem_nodes WHERE id = ?`, id).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, withKind(ErrNotFound, fmt.Errorf("memory not found: id %d", id))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get merged_from: %w", err)
+	}
+	return ParseMergedFrom(raw.String)
+}
+
+// AbsorbDuplicates deletes each keeper's duplicates, recording their IDs in
+// the keeper's merged_from, then sweeps the directories left empty — all in
+// one transaction, like DeleteNodes. Dedup uses it so a cluster's survivor
+// keeps the provenance, links and session records MergeNodes would have left. Unlike
+// MergeNodes, the duplicates' content is not carried over.
+func (db *DB) AbsorbDuplicates(dups map[int64][]int64) error {
+	defer db.invalidateNodes()
+	if len(dups) == 0 {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin absorb duplicates: %w", err)
+	}
+	defer tx.Rollback()
+
+	mergedFromOf := func(id int64) (string, error) {
+		var raw sql.NullString
+		if err := tx.QueryRow(`SELECT merged_from FROM mem_nodes WHERE id = ?`, id).Scan(&raw); err != nil && err != sql.ErrNoRows {
+			return "", fmt.Errorf("get merged_from for node %d: %w", id, err)
+		}
+		return raw.String, nil
+	}
+	for keepID, ids := range dups {
+		keepJSON, err := mergedFromOf(keepID)
+		if err != nil {
+			return err
+		}
+		absorbed := make(map[int64]string, len(ids))
+		for _, id := range ids {
+			if absorbed[id], err = mergedFromOf(id); err != nil {
+				return err
+			}
+		}
+		mergedFrom, err := appendMergedFrom(keepJSON, absorbed)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE mem_nodes SET merged_from = ? WHERE id = ?`, mergedFrom, keepID); err != nil {
+			return fmt.Errorf("update merged_from for node %d: %w", keepID, err)
+		}
+		for _, id := range ids {
+			if err := absorbNodeTx(tx, id, keepID); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := tx.Exec(deleteOrphanDirsSQL); err != nil {
+		return fmt.Errorf("delete orphan dirs: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit absorb duplicates: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMergeNodes_AppendsContentAndDeletesSource(t *testing.T) {
+	db := testDB(t)
+	keep := seedNode(t, db, "mem://user/preferences/go-style", "preferences", "prefers stdlib Go")
+	merge := seedNode(t, db, "mem://user/preferences/golang-style", "preferences", "likes standard library")
+	if err := db.SaveVector(merge.ID, []float64{1, 0, 0}, "hashtf"); err != nil {
+		t.Fatalf("SaveVector: %v", err)
+	}
+	db.Exec(`UPDATE mem_nodes SET l2_content = 'keeper detail' WHERE id = ?`, keep.ID)
+	db.Exec(`UPDATE mem_nodes SET l2_content = 'absorbed detail', access_count = 4 WHERE id = ?`, merge.ID)
+
+	kept, err := db.MergeNodes(keep.ID, merge.ID)
+	if err != nil {
+		t.Fatalf("MergeNodes: %v", err)
+	}
+	if !strings.Contains(kept.L2Content, "keeper detail") || !strings.Contains(kept.L2Content, "absorbed detail") {
+		t.Errorf("L2 = %q, want both details", kept.L2Content)
+	}
+	if kept.L0Abstract != "prefers stdlib Go" {
+		t.Errorf("L0 changed to %q; keeper L0 must be untouched", kept.L0Abstract)
+	}
+	if kept.AccessCount != 4 {
+		t.Errorf("AccessCount = %d, want 4 carried over", kept.AccessCount)
+	}
+	if want := fmt.Sprintf("[%d]", merge.ID); kept.MergedFrom != want {
+		t.Errorf("MergedFrom = %q, want %q", kept.MergedFrom, want)
+	}
+
+	gone, _ := db.GetNodeByID(merge.ID)
+	if gone != nil {
+		t.Error("merged node should be deleted")
+	}
+	if v, _ := db.GetVector(merge.ID); v != nil {
+		t.Error("merged node's vector should be deleted")
+	}
+}
+
+func TestMergeProvenance(t *testing.T) {
+	db := testDB(t)
+	a := seedNode(t, db, "mem://user/preferences/a", "preferences", "a")
+	b := seedNode(t, db, "mem://user/preferences/b", "preferences", "b")
+	c := seedNode(t, db, "mem://user/preferences/c", "preferences", "c")
+	d := seedNode(t, db, "mem://user/preferences/d", "preferences", "d")
+
+	// b absorbs c, then a absorbs b: a's provenance covers both.
+	if _, err := db.MergeNodes(b.ID, c.ID); err != nil {
+		t.Fatalf("MergeNodes b<-c: %v", err)
+	}
+	if _, err := db.MergeNodes(a.ID, b.ID); err != nil {
+		t.Fatalf("MergeNodes a<-b: %v", err)
+	}
+	// A stale or hand-edited merged_from naming an ID twice is listed once.
+	db.Exec(`UPDATE mem_nodes SET merged_from = ? WHERE id = ?`, fmt.Sprintf("[%d]", c.ID), d.ID)
+	if err := db.AbsorbDuplicates(map[int64][]int64{a.ID: {d.ID}}); err != nil {
+		t.Fatalf("AbsorbDuplicates: %v", err)
+	}
+
+	got, err := db.GetMergeProvenance(a.ID)
+	if err != nil {
+		t.Fatalf("GetMergeProvenance: %v", err)
+	}
+	if want := fmt.Sprint([]int64{c.ID, b.ID, d.ID}); fmt.Sprint(got) != want {
+		t.Errorf("provenance = %v, want %s", got, want)
+	}
+	if n, _ := db.GetNodeByID(d.ID); n != nil {
+		t.Error("absorbed duplicate not deleted")
+	}
+	if ids, err := db.GetMergeProvenance(d.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetMergeProvenance on a deleted node = %v, %v; want ErrNotFound", ids, err)
+	}
+}
+
+func TestMergeNodes_Refusals(t *testing.T) {
+	db := testDB(t)
+	a := seedNode(t, db, "mem://user/preferences/a", "preferences", "a")
+	b := seedNode(t, db, "mem://user/events/b", "events", "b")
+	c := seedNode(t, db, "mem://user/preferences/c", "preferences", "c")
+	if _, err := db.RetractNode(c.URI, "wrong", ""); err != nil {
+		t.Fatalf("RetractNode: %v", err)
+	}
+	dir, _ := db.GetNodeByURI("mem://user/preferences")
+
+	cases := []struct {
+		name          string
+		keep, merge   int64
+		wantSubstring string
+	}{
+		{"self", a.ID, a.ID, "into itself"},
+		{"cross-category", a.ID, b.ID, "across categories"},
+		{"retracted", a.ID, c.ID, "retracted"},
+		{"directory", dir.ID, a.ID, "only leaf"},
+		{"missing", a.ID, 99999, "not found"},
+	}
+	for _, tc := range cases {
+		_, err := db.MergeNodes(tc.keep, tc.merge)
+		var mve *MergeValidationError
+		if !errors.As(err, &mve) {
+			t.Errorf("%s: err = %v, want MergeValidationError", tc.name, err)
+			continue
+		}
+		if !strings.Contains(mve.Message, tc.wantSubstring) {
+			t.Errorf("%s: message %q missing %q", tc.name, mve.Message, tc.wantSubstring)
+		}
+	}
+
+	// Refusals must leave both nodes intact.
+	if n, _ := db.GetNodeByID(a.ID); n == nil {
+		t.Error("keeper deleted by a refused merge")
+	}
+	if n, _ := db.GetNodeByID(b.ID); n == nil {
+		t.Error("source deleted by a refused merge")
+	}
+}
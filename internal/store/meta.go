@@ -11,6 +11,10 @@ import (
 // against this at startup so it cannot be silently switched by environment.
 const MetaVectorIdentity = "vector_identity"
 
+// MetaLastDecay is the mem_meta key holding when DecayAllNodes last ran
+// (unix ms), so the decay schedule survives restarts and sleep.
+const MetaLastDecay = "last_decay_at"
+
 // GetMeta returns the value for a mem_meta key. ok is false when the key is
 // absent (distinct from an empty-string value).
 func (db *DB) GetMeta(key string) (value string, ok bool, err error) {
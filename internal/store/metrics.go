@@ -108,6 +108,7 @@ func (db *DB) ComputeMetrics() (*Metrics, error) {
 	}
 
 	now := time.Now().UnixMilli()
+	params := db.decayParams()
 	m := &Metrics{GeneratedAt: now}
 
 	catCounts := map[string]int{}
@@ -131,7 +132,7 @@ func (db *DB) ComputeMetrics() (*Metrics, error) {
 		// Live (active) node.
 		m.Summary.ActiveTotal++
 		catCounts[n.Category]++
-		eff := effectiveRelevance(n, now)
+		eff := effectiveRelevance(n, now, params)
 		mn := toMetricNode(n, now)
 		mn.Relevance = eff
 		active = append(active, mn)
@@ -247,29 +248,13 @@ func (db *DB) ComputeMetrics() (*Metrics, error) {
 }
 
 // effectiveRelevance computes a node's current relevance live from its
-// timestamps using the same 90-day half-life as DecayAllNodes, floored at 0.1.
-// Decay-exempt nodes (relational profile, moments) keep their stored relevance.
-func effectiveRelevance(n *MemNode, now int64) float64 {
+// timestamps using the same DecayParams as DecayAllNodes. Decay-exempt nodes
+// (relational profile, moments) keep their stored relevance.
+func effectiveRelevance(n *MemNode, now int64, p DecayParams) float64 {
 	if n.URI == "mem://user/profile/communication" || n.Category == "moments" {
 		return n.Relevance
 	}
-	refTime := n.CreatedAt
-	if n.LastAccess != nil {
-		refTime = *n.LastAccess
-	}
-	elapsed := float64(now - refTime)
-	if elapsed <= 0 {
-		return 1.0
-	}
-	halfLifeMs := float64(90 * 24 * 60 * 60 * 1000)
-	v := pow05(elapsed / halfLifeMs)
-	if v < 0.1 {
-		return 0.1
-	}
-	if v > 1.0 {
-		return 1.0
-	}
-	return v
+	return p.relevanceAt(n.CreatedAt, n.LastAccess, now)
 }
 
 func toMetricNode(n *MemNode, now int64) MetricNode {
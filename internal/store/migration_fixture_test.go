@@ -125,12 +125,13 @@ func assertSnapshotNetEngaged(t *testing.T, dbPath string) {
 	if err != nil {
 		t.Fatalf("list snapshots: %v", err)
 	}
+	last := lastRiskyVersion()
 	if len(snaps) != 1 {
-		t.Fatalf("retained snapshots = %d, want 1 (pre-v9 net)", len(snaps))
+		t.Fatalf("retained snapshots = %d, want 1 (pre-v%d net)", len(snaps), last)
 	}
 	s := snaps[0]
-	if s.TargetVersion != 9 || s.PreVersion != 8 {
-		t.Errorf("snapshot pre/target = %d/%d, want 8/9", s.PreVersion, s.TargetVersion)
+	if s.TargetVersion != last || s.PreVersion != last-1 {
+		t.Errorf("snapshot pre/target = %d/%d, want %d/%d", s.PreVersion, s.TargetVersion, last-1, last)
 	}
 	// File must live in this DB's namespaced dir and exist on disk.
 	wantDir := snapshotDirForDB(dbPath)
@@ -140,9 +141,10 @@ func assertSnapshotNetEngaged(t *testing.T, dbPath string) {
 	if _, err := os.Stat(s.Path); err != nil {
 		t.Fatalf("snapshot file missing: %v", err)
 	}
-	// Recoverable: the snapshot is a valid SQLite image at the pre-v9 schema
-	// still holding the seeded baseline (a v5 category node survives).
-	assertRawSchema(t, s.Path, 8)
+	// Recoverable: the snapshot is a valid SQLite image at the schema before
+	// the last risky migration, still holding the seeded baseline (a v5
+	// category node survives).
+	assertRawSchema(t, s.Path, last-1)
 	snap, err := sql.Open("sqlite", s.Path+"?mode=ro")
 	if err != nil {
 		t.Fatalf("open snapshot: %v", err)
@@ -282,6 +282,265 @@ CREATE TABLE mem_meta (
 		// retraction exclusion as every other read path). See store/pins.go.
 		SQL: `ALTER TABLE mem_nodes ADD COLUMN pinned_at INTEGER;`,
 	},
+	{
+		Version:     13,
+		Description: "sessions: add skip_reason for content-gated extractions",
+		// Additive column; no user data touched. Records why the extraction
+		// content gate passed over a session (too few messages, condensed
+		// transcript too short) so "no memories" is explainable instead of silent.
+		// Cleared when a later extraction succeeds.
+		SQL: `ALTER TABLE sessions ADD COLUMN skip_reason TEXT;`,
+	},
+	{
+		Version:     14,
+		Description: "context_injections: which memories were injected per session, and whether they were used",
+		// Additive table; no user data touched. One row per (session, memory)
+		// injected at SessionStart; used_at is stamped when a search in the same
+		// session retrieves that memory. Drives `continuity stats usefulness`.
+		SQL: `
+CREATE TABLE context_injections (
+    session_id  TEXT NOT NULL,
+    uri         TEXT NOT NULL,
+    category    TEXT NOT NULL,
+    injected_at INTEGER NOT NULL,
+    used_at     INTEGER,
+    PRIMARY KEY (session_id, uri)
+);
+CREATE INDEX idx_injections_category ON context_injections(category);
+`,
+	},
+	{
+		Version:     15,
+		Description: "sessions: add transcript_path so sessions can be re-processed",
+		// Additive nullable column. Populated from the extract call; rows from
+		// before this migration stay NULL and are simply not re-processable.
+		SQL: `ALTER TABLE sessions ADD COLUMN transcript_path TEXT;`,
+	},
+	{
+		Version:     16,
+		Description: "entities: structured fields for entities-category memories",
+		// Additive table; no user data touched. One optional row per entities
+		// leaf holding what the extraction LLM parsed out (type, name, path,
+		// aliases) so entities can be listed by type. The free-text L1 stays
+		// authoritative; a node without a row is simply unstructured.
+		SQL: `
+CREATE TABLE entities (
+    node_id     INTEGER PRIMARY KEY,
+    entity_type TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    location    TEXT NOT NULL DEFAULT '',
+    aliases     TEXT NOT NULL DEFAULT '[]',  -- JSON array of strings
+    updated_at  INTEGER NOT NULL,
+    FOREIGN KEY (node_id) REFERENCES mem_nodes(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_entities_type ON entities(entity_type);
+`,
+	},
+	{
+		Version:     17,
+		Description: "mem_nodes: add constraints category (things not to do)",
+		Risky:       true, // full-table rebuild via INSERT SELECT *; column-order parity is by developer discipline
+		// Same rebuild as v9, with v12's pinned_at carried as the last column.
+		// FK-off is enforced by the migration runner; see v9's note.
+		SQL: `
+CREATE TABLE mem_nodes_new (
+    id             INTEGER PRIMARY KEY,
+    uri            TEXT NOT NULL UNIQUE,
+    parent_uri     TEXT,
+    node_type      TEXT NOT NULL CHECK (node_type IN ('dir', 'leaf')),
+    category       TEXT NOT NULL CHECK (category IN ('profile', 'preferences', 'entities', 'events', 'patterns', 'cases', 'moments', 'feedback', 'reference', 'constraints', 'session')),
+
+    -- Three-tier content
+    l0_abstract    TEXT,
+    l1_overview    TEXT,
+    l2_content     TEXT,
+
+    -- Merge control
+    mergeable      INTEGER NOT NULL DEFAULT 0,
+    merged_from    TEXT,
+
+    -- Decay
+    relevance      REAL NOT NULL DEFAULT 1.0,
+    last_access    INTEGER,
+    access_count   INTEGER NOT NULL DEFAULT 0,
+
+    -- Metadata
+    source_session TEXT,
+    created_at     INTEGER NOT NULL,
+    updated_at     INTEGER NOT NULL,
+
+    -- Retraction (added in v8)
+    tombstoned_at    INTEGER,
+    tombstone_reason TEXT,
+    superseded_by    TEXT,
+
+    -- Operator pin (added in v12)
+    pinned_at        INTEGER,
+
+    FOREIGN KEY (parent_uri) REFERENCES mem_nodes_new(uri)
+);
+
+INSERT INTO mem_nodes_new SELECT * FROM mem_nodes;
+DROP TABLE mem_nodes;
+ALTER TABLE mem_nodes_new RENAME TO mem_nodes;
+
+CREATE INDEX idx_nodes_parent    ON mem_nodes(parent_uri);
+CREATE INDEX idx_nodes_category  ON mem_nodes(category);
+CREATE INDEX idx_nodes_relevance ON mem_nodes(relevance DESC);
+`,
+	},
+	{
+		Version:     18,
+		Description: "observations: add tool_use_id so hook retries don't double-record",
+		// Additive nullable column plus a unique index. Existing rows (and
+		// writers that don't send an ID) stay NULL, and NULLs never collide in
+		// a SQLite unique index, so only a repeated non-empty ID is refused.
+		SQL: `
+ALTER TABLE observations ADD COLUMN tool_use_id TEXT;
+CREATE UNIQUE INDEX idx_obs_tool_use ON observations(tool_use_id);
+`,
+	},
+	{
+		Version:     19,
+		Description: "mem_nodes: add relevance_set_at for hand-set relevance",
+		// Additive column; no user data touched. Non-NULL marks relevance as
+		// set by the operator (`continuity boost`): decay and access boosts
+		// leave it alone until the override is cleared. See store/relevance.go.
+		SQL: `ALTER TABLE mem_nodes ADD COLUMN relevance_set_at INTEGER;`,
+	},
+	{
+		Version:     20,
+		Description: "mem_vectors: add text_hash of the embedded text",
+		// Additive column. Vectors written before it stay NULL — unknown, and
+		// trusted as current rather than re-embedding the whole corpus on
+		// upgrade. See store.TextHash.
+		SQL: `ALTER TABLE mem_vectors ADD COLUMN text_hash TEXT;`,
+	},
+	{
+		Version:     21,
+		Description: "links: typed relationships between memories",
+		// Additive table; no user data touched. Keyed by URI like
+		// superseded_by, so a link survives its endpoints being rewritten in
+		// place; readers join against live nodes and ignore dangling rows.
+		// See store/links.go.
+		SQL: `
+CREATE TABLE links (
+    from_uri   TEXT NOT NULL,
+    to_uri     TEXT NOT NULL,
+    relation   TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    PRIMARY KEY (from_uri, to_uri, relation)
+);
+CREATE INDEX idx_links_to ON links(to_uri);
+`,
+	},
+	{
+		Version:     22,
+		Description: "session_memories: which memories each session wrote",
+		// Additive table; no user data touched. sessions.summary_node holds
+		// one node and stays unused; a session writes many. Keyed by URI like
+		// links, and read joined against live nodes. Memories written before
+		// this version have no rows (source_session still names their latest
+		// writer). See store/session_memories.go.
+		SQL: `
+CREATE TABLE session_memories (
+    session_id  TEXT NOT NULL,
+    uri         TEXT NOT NULL,
+    created     INTEGER NOT NULL DEFAULT 0,
+    recorded_at INTEGER NOT NULL,
+    PRIMARY KEY (session_id, uri)
+);
+CREATE INDEX idx_session_memories_uri ON session_memories(uri);
+`,
+	},
+	{
+		Version:     23,
+		Description: "failed_extractions: sessions whose extraction errored",
+		// Additive table; no user data touched. One row per session, rewritten
+		// on each failed attempt and deleted once extraction succeeds. See
+		// store/failed_extractions.go.
+		SQL: `
+CREATE TABLE failed_extractions (
+    session_id      TEXT PRIMARY KEY,
+    transcript_path TEXT NOT NULL,
+    error           TEXT NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 1,
+    first_failed_at INTEGER NOT NULL,
+    last_failed_at  INTEGER NOT NULL
+);
+`,
+	},
+	{
+		Version:     24,
+		Description: "sessions: unique on (session_id, project) instead of session_id",
+		Risky:       true, // full-table rebuild; columns are copied by name
+		// The same session ID seen in an unrelated project becomes a second
+		// row instead of reactivating the first. Row ids are kept, and a NULL
+		// project (never written by InitSession) becomes '' so the pair is
+		// always comparable. See InitSession.
+		SQL: `
+CREATE TABLE sessions_new (
+    id              INTEGER PRIMARY KEY,
+    session_id      TEXT NOT NULL,
+    project         TEXT NOT NULL DEFAULT '',
+    started_at      INTEGER NOT NULL,
+    ended_at        INTEGER,
+    status          TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'completed', 'failed')),
+    summary_node    INTEGER,
+    message_count   INTEGER NOT NULL DEFAULT 0,
+    tool_count      INTEGER NOT NULL DEFAULT 0,
+    extracted_at    INTEGER,
+    tone            TEXT,
+    skip_reason     TEXT,
+    transcript_path TEXT,
+
+    UNIQUE (session_id, project),
+    FOREIGN KEY (summary_node) REFERENCES mem_nodes(id)
+);
+
+INSERT INTO sessions_new (id, session_id, project, started_at, ended_at, status, summary_node,
+    message_count, tool_count, extracted_at, tone, skip_reason, transcript_path)
+SELECT id, session_id, COALESCE(project, ''), started_at, ended_at, status, summary_node,
+    message_count, tool_count, extracted_at, tone, skip_reason, transcript_path
+FROM sessions;
+DROP TABLE sessions;
+ALTER TABLE sessions_new RENAME TO sessions;
+
+CREATE INDEX idx_sessions_status     ON sessions(status);
+CREATE INDEX idx_sessions_started_at ON sessions(started_at DESC);
+CREATE INDEX idx_sessions_project    ON sessions(project);
+`,
+	},
+	{
+		Version:     25,
+		Description: "sessions: session_key, the per-row handle child rows are stored under",
+		// Additive column, unique index and default trigger; no user data
+		// touched. The first row for a session ID keeps the ID as its key, so
+		// observations, injections, session memories and failed extractions
+		// already stored under it still belong to it. A later row for the same
+		// ID gets its own key. A row inserted without one (an older writer)
+		// defaults to its session_id. See SessionKey.
+		SQL: `
+ALTER TABLE sessions ADD COLUMN session_key TEXT;
+UPDATE sessions SET session_key = CASE
+    WHEN id = (SELECT MIN(s.id) FROM sessions s WHERE s.session_id = sessions.session_id) THEN session_id
+    ELSE session_id || '~' || id END;
+CREATE UNIQUE INDEX idx_sessions_key ON sessions(session_key);
+CREATE TRIGGER sessions_default_key AFTER INSERT ON sessions
+WHEN NEW.session_key IS NULL
+BEGIN
+    UPDATE sessions SET session_key = NEW.session_id WHERE id = NEW.id;
+END;
+`,
+	},
+	{
+		Version:     26,
+		Description: "mem_nodes: origin, who surfaced an extracted memory",
+		// Additive column; no user data touched. NULL for every memory the
+		// user flagged, which is all of them before this version. See
+		// MemNode.Origin.
+		SQL: `ALTER TABLE mem_nodes ADD COLUMN origin TEXT;`,
+	},
 }
 
 // headVersion is the highest schema version this binary knows how to apply.
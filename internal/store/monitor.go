@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Health-monitor timing. A single failed probe is noise (a writer holding the
+// lock a moment past busy_timeout); monitorFailThreshold in a row means the
+// handle is stuck and worth recovering. Recovery attempts back off from
+// monitorInterval up to monitorMaxBackoff so a long outage doesn't spin.
+const (
+	monitorInterval      = 15 * time.Second
+	monitorMaxBackoff    = 5 * time.Minute
+	monitorProbeTimeout  = 10 * time.Second
+	monitorFailThreshold = 3
+)
+
+// Probe checks that the database is actually usable: Ping proves a connection
+// can be had, and a read of schema_versions proves the file behind it answers
+// (Ping alone succeeds on a pooled connection to a locked or vanished file).
+func (db *DB) Probe(ctx context.Context) error {
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping: %w", err)
+	}
+	var v int
+	if err := db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_versions").Scan(&v); err != nil {
+		return fmt.Errorf("read schema_versions: %w", err)
+	}
+	return nil
+}
+
+// Reconnect recycles the connection pool: idle connections are closed so the
+// next query dials the file afresh, and the result is probed. New connections
+// get their pragmas from the DSN (see connPragmas), so nothing needs
+// reapplying here. The *DB handle itself is shared by the engine, server, and
+// workers, so swapping it out (close + Open) under them would race; recycling
+// its connections recovers the same stale-file-handle and poisoned-connection
+// cases without doing so. Connections checked out at the time are recycled as
+// they are returned. An in-memory database lives only in its connections, so
+// for one this just probes.
+func (db *DB) Reconnect(ctx context.Context) error {
+	if db.Path == ":memory:" {
+		return db.Probe(ctx)
+	}
+	db.SetMaxIdleConns(0)
+	db.SetMaxIdleConns(2) // database/sql's default
+	return db.Probe(ctx)
+}
+
+// MonitorStatus is a point-in-time view of a HealthMonitor.
+type MonitorStatus struct {
+	Degraded   bool
+	LastError  string // most recent probe error; empty when healthy
+	Failures   int    // consecutive failed probes
+	Reconnects int    // successful recoveries since start
+}
+
+// HealthMonitor probes the database on a timer and, after repeated failures,
+// reconnects with backoff, so a long-running server recovers from a DB that
+// was locked or briefly unavailable instead of staying up but broken until
+// someone restarts it.
+type HealthMonitor struct {
+	db *DB
+
+	mu     sync.Mutex
+	status MonitorStatus
+}
+
+// NewHealthMonitor returns a monitor for db. Call Run to start it.
+func NewHealthMonitor(db *DB) *HealthMonitor {
+	return &HealthMonitor{db: db}
+}
+
+// Status returns the monitor's current view. Safe for concurrent use; a nil
+// monitor reports healthy.
+func (m *HealthMonitor) Status() MonitorStatus {
+	if m == nil {
+		return MonitorStatus{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+// Run probes until ctx is cancelled.
+func (m *HealthMonitor) Run(ctx context.Context) {
+	wait := monitorInterval
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		if m.Check(ctx) {
+			wait = monitorInterval
+		} else if m.Status().Degraded {
+			wait = min(wait*2, monitorMaxBackoff)
+		}
+	}
+}
+
+// Check runs one probe — and, once the failure threshold is reached, one
+// reconnect attempt — and reports whether the database is healthy afterwards.
+func (m *HealthMonitor) Check(ctx context.Context) bool {
+	pctx, cancel := context.WithTimeout(ctx, monitorProbeTimeout)
+	defer cancel()
+
+	err := m.db.Probe(pctx)
+	m.mu.Lock()
+	if err == nil {
+		if m.status.Degraded {
+			log.Printf("db monitor: database healthy again")
+		}
+		m.status.Degraded, m.status.LastError, m.status.Failures = false, "", 0
+		m.mu.Unlock()
+		return true
+	}
+	m.status.Failures++
+	m.status.LastError = err.Error()
+	if m.status.Failures < monitorFailThreshold {
+		m.mu.Unlock()
+		return false
+	}
+	if !m.status.Degraded {
+		log.Printf("db monitor: %d consecutive probe failures, degraded: %v", m.status.Failures, err)
+	}
+	m.status.Degraded = true
+	m.mu.Unlock()
+
+	rerr := m.db.Reconnect(pctx)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rerr != nil {
+		m.status.LastError = rerr.Error()
+		log.Printf("db monitor: reconnect failed: %v", rerr)
+		return false
+	}
+	m.status.Degraded, m.status.LastError, m.status.Failures = false, "", 0
+	m.status.Reconnects++
+	log.Printf("db monitor: reconnected to %s", m.db.Path)
+	return true
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestHealthMonitorDegradesAndRecovers(t *testing.T) {
+	// An unmigrated database has no schema_versions, so every probe fails
+	// until the table appears — a stand-in for a file that stops answering.
+	db, err := OpenNoMigrate(filepath.Join(t.TempDir(), "mon.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	m := NewHealthMonitor(db)
+	for i := 1; i < monitorFailThreshold; i++ {
+		if m.Check(ctx) {
+			t.Fatalf("probe %d succeeded against a broken db", i)
+		}
+		if m.Status().Degraded {
+			t.Fatalf("degraded after %d failure(s), threshold is %d", i, monitorFailThreshold)
+		}
+	}
+	if m.Check(ctx) {
+		t.Fatal("reconnect should fail while the db is still broken")
+	}
+	st := m.Status()
+	if !st.Degraded || st.LastError == "" || st.Failures != monitorFailThreshold {
+		t.Fatalf("status after threshold = %+v, want degraded with an error", st)
+	}
+
+	if _, err := db.Exec(`CREATE TABLE schema_versions (version INTEGER PRIMARY KEY, description TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Check(ctx) {
+		t.Fatalf("check failed after the db recovered: %+v", m.Status())
+	}
+	if st := m.Status(); st.Degraded || st.LastError != "" || st.Failures != 0 {
+		t.Errorf("status after recovery = %+v, want healthy", st)
+	}
+}
+
+func TestReconnectKeepsDataReachable(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "re.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	seedNode(t, db, "mem://user/profile/a", "profile", "still here after reconnect")
+
+	if err := db.Reconnect(context.Background()); err != nil {
+		t.Fatalf("Reconnect: %v", err)
+	}
+	if n, _ := db.GetNodeByURI("mem://user/profile/a"); n == nil {
+		t.Error("node unreachable after Reconnect")
+	}
+}
+
+func TestReconnectedConnectionsKeepPragmas(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "pragmas.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	ctx := context.Background()
+	if err := db.Reconnect(ctx); err != nil {
+		t.Fatalf("Reconnect: %v", err)
+	}
+
+	// Hold several connections at once so the pool has to dial new ones.
+	var conns []*sql.Conn
+	for range 3 {
+		c, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conns = append(conns, c)
+	}
+	for i, c := range conns {
+		var fk, timeout int
+		if err := c.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&fk); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&timeout); err != nil {
+			t.Fatal(err)
+		}
+		if fk != 1 || timeout != 5000 {
+			t.Errorf("connection %d: foreign_keys = %d, busy_timeout = %d; want 1, 5000", i, fk, timeout)
+		}
+		c.Close()
+	}
+}
+
+func TestNilHealthMonitorReportsHealthy(t *testing.T) {
+	var m *HealthMonitor
+	if st := m.Status(); st.Degraded {
+		t.Errorf("nil monitor status = %+v, want healthy", st)
+	}
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// MoveValidationError signals that a move was rejected for a user/domain
+// reason (node missing, directory, retracted, target taken) rather than an
+// internal failure. Message is safe to surface verbatim. Mirrors
+// MergeValidationError.
+type MoveValidationError struct {
+	Message string
+}
+
+func (e *MoveValidationError) Error() string {
+	return e.Message
+}
+
+func moveValidationErrorf(format string, args ...any) error {
+	return &MoveValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+// MoveNode re-files the leaf id under category at newURI. The node keeps its
+// ID — and so its vector, access history, relevance, and pin — while its URI,
+// parent, and category change. A mergeable flag that followed the old
+// category's default follows the new one; an explicit per-node override is
+// kept. Injection records, links, session provenance, and superseded_by
+// pointers naming the old URI are repointed, and the structured entity row is
+// dropped when the node leaves "entities". Runs in a single transaction;
+// directories left empty are cleaned up afterwards.
+func (db *DB) MoveNode(id int64, newURI, category string) (*MemNode, error) {
+	defer db.invalidateNodes()
+
+	node, err := db.GetNodeByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("look up node: %w", err)
+	}
+	if node == nil {
+		return nil, withKind(ErrNotFound, moveValidationErrorf("memory not found: id %d", id))
+	}
+	if node.NodeType != "leaf" {
+		return nil, moveValidationErrorf("cannot move %s node: %s (only leaf memories move)", node.NodeType, node.URI)
+	}
+	if node.IsRetracted() {
+		return nil, moveValidationErrorf("cannot move retracted memory: %s", node.URI)
+	}
+	if node.URI == newURI {
+		return nil, moveValidationErrorf("%s is already at that URI", node.URI)
+	}
+	if err := db.CheckURIDepth(newURI); err != nil {
+		return nil, moveValidationErrorf("cannot move to %s: %v", newURI, err)
+	}
+	existing, err := db.GetNodeByURI(newURI)
+	if err != nil {
+		return nil, fmt.Errorf("look up target: %w", err)
+	}
+	if existing != nil {
+		return nil, withKind(ErrConflict, moveValidationErrorf("cannot move %s: %s already exists", node.URI, newURI))
+	}
+
+	keepsMerging := IsMergeable(category)
+	if node.Mergeable != IsMergeable(node.Category) {
+		keepsMerging = node.Mergeable // an explicit override travels with the node
+	}
+	mergeable := 0
+	if keepsMerging {
+		mergeable = 1
+	}
+
+	if err := db.EnsureParentDirs(newURI, category); err != nil {
+		return nil, fmt.Errorf("ensure parent dirs: %w", err)
+	}
+
+	now := time.Now().UnixMilli()
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin move: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE mem_nodes SET uri = ?, parent_uri = ?, category = ?, mergeable = ?, updated_at = ?
+		WHERE id = ? AND tombstoned_at IS NULL
+	`, newURI, parentURIOf(newURI), category, mergeable, now, id)
+	if err != nil {
+		return nil, fmt.Errorf("update node: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, moveValidationErrorf("cannot move retracted memory: %s", node.URI)
+	}
+	if _, err := tx.Exec(`UPDATE mem_nodes SET superseded_by = ? WHERE superseded_by = ?`, newURI, node.URI); err != nil {
+		return nil, fmt.Errorf("repoint superseded_by: %w", err)
+	}
+	// A session can only have one row per URI; one already recorded for
+	// newURI (an earlier memory there) wins.
+	if _, err := tx.Exec(`UPDATE OR IGNORE context_injections SET uri = ? WHERE uri = ?`, newURI, node.URI); err != nil {
+		return nil, fmt.Errorf("repoint injections: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE OR IGNORE session_memories SET uri = ? WHERE uri = ?`, newURI, node.URI); err != nil {
+		return nil, fmt.Errorf("repoint session memories: %w", err)
+	}
+	for _, col := range []string{"from_uri", "to_uri"} {
+		if _, err := tx.Exec(`UPDATE OR IGNORE links SET `+col+` = ? WHERE `+col+` = ?`, newURI, node.URI); err != nil {
+			return nil, fmt.Errorf("repoint links: %w", err)
+		}
+	}
+	if category != "entities" {
+		if _, err := tx.Exec(`DELETE FROM entities WHERE node_id = ?`, id); err != nil {
+			return nil, fmt.Errorf("delete entity fields: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit move: %w", err)
+	}
+
+	db.DeleteOrphanDirs()
+	return db.GetNodeByID(id)
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMoveNode_RefilesAndKeepsIdentity(t *testing.T) {
+	db := testDB(t)
+	node := seedNode(t, db, "mem://user/events/go-style", "events", "prefers stdlib Go")
+	if err := db.SaveVector(node.ID, []float64{1, 0, 0}, "hashtf"); err != nil {
+		t.Fatalf("SaveVector: %v", err)
+	}
+	db.Exec(`UPDATE mem_nodes SET access_count = 3 WHERE id = ?`, node.ID)
+	old := seedNode(t, db, "mem://user/events/old-style", "events", "older take")
+	db.Exec(`UPDATE mem_nodes SET superseded_by = ? WHERE id = ?`, node.URI, old.ID)
+	if err := db.RecordInjections("s1", []InjectedMemory{{URI: node.URI, Category: "events"}}); err != nil {
+		t.Fatalf("RecordInjections: %v", err)
+	}
+	seedNode(t, db, "mem://agent/cases/devbox", "cases", "linked case")
+	if _, err := db.LinkNodes("mem://agent/cases/devbox", node.URI, RelatedTo); err != nil {
+		t.Fatalf("LinkNodes: %v", err)
+	}
+
+	moved, err := db.MoveNode(node.ID, "mem://user/preferences/go-style", "preferences")
+	if err != nil {
+		t.Fatalf("MoveNode: %v", err)
+	}
+	if moved.ID != node.ID || moved.URI != "mem://user/preferences/go-style" || moved.Category != "preferences" {
+		t.Errorf("moved = id %d %s [%s], want same id at the new URI", moved.ID, moved.URI, moved.Category)
+	}
+	if moved.ParentURI != "mem://user/preferences" {
+		t.Errorf("ParentURI = %q", moved.ParentURI)
+	}
+	if moved.AccessCount != 3 {
+		t.Errorf("AccessCount = %d, want 3 kept", moved.AccessCount)
+	}
+	if !moved.Mergeable {
+		t.Error("default mergeable should follow the new category (preferences merge)")
+	}
+	if v, _ := db.GetVector(node.ID); v == nil {
+		t.Error("vector lost in the move")
+	}
+	if n, _ := db.GetNodeByURI("mem://user/events/go-style"); n != nil {
+		t.Error("old URI still resolves")
+	}
+	if o, _ := db.GetNodeByID(old.ID); o.SupersededBy != moved.URI {
+		t.Errorf("superseded_by = %q, want repointed to %s", o.SupersededBy, moved.URI)
+	}
+	if links, _ := db.GetLinks(moved.URI); len(links) != 1 || links[0].ToURI != moved.URI {
+		t.Errorf("links = %+v, want the case's link repointed", links)
+	}
+	var injected string
+	db.QueryRow(`SELECT uri FROM context_injections WHERE session_id = 's1'`).Scan(&injected)
+	if injected != moved.URI {
+		t.Errorf("injection uri = %q, want %s", injected, moved.URI)
+	}
+}
+
+func TestMoveNode_Refusals(t *testing.T) {
+	db := testDB(t)
+	a := seedNode(t, db, "mem://user/preferences/a", "preferences", "a")
+	seedNode(t, db, "mem://user/events/a", "events", "taken")
+	c := seedNode(t, db, "mem://user/preferences/c", "preferences", "c")
+	if _, err := db.RetractNode(c.URI, "wrong", ""); err != nil {
+		t.Fatalf("RetractNode: %v", err)
+	}
+	dir, _ := db.GetNodeByURI("mem://user/preferences")
+
+	cases := []struct {
+		name          string
+		id            int64
+		uri           string
+		wantSubstring string
+	}{
+		{"target taken", a.ID, "mem://user/events/a", "already exists"},
+		{"retracted", c.ID, "mem://user/events/c", "retracted"},
+		{"directory", dir.ID, "mem://user/events/x", "only leaf"},
+		{"missing", 99999, "mem://user/events/x", "not found"},
+		{"same uri", a.ID, a.URI, "already at"},
+	}
+	for _, tc := range cases {
+		_, err := db.MoveNode(tc.id, tc.uri, "events")
+		var mve *MoveValidationError
+		if !errors.As(err, &mve) {
+			t.Errorf("%s: err = %v, want MoveValidationError", tc.name, err)
+			continue
+		}
+		if !strings.Contains(mve.Message, tc.wantSubstring) {
+			t.Errorf("%s: message %q missing %q", tc.name, mve.Message, tc.wantSubstring)
+		}
+	}
+	if n, _ := db.GetNodeByURI(a.URI); n == nil || n.Category != "preferences" {
+		t.Error("refused move changed the node")
+	}
+}
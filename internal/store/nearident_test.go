@@ -6,19 +6,19 @@ import (
 )
 
 func TestTextNearIdentical_Exact(t *testing.T) {
-	if !textNearIdentical("hello world", "hello world") {
+	if !TextNearIdentical("hello world", "hello world") {
 		t.Error("identical strings should be near-identical")
 	}
 }
 
 func TestTextNearIdentical_Empty(t *testing.T) {
-	if textNearIdentical("", "hello") {
+	if TextNearIdentical("", "hello") {
 		t.Error("empty vs non-empty should not be near-identical")
 	}
-	if textNearIdentical("hello", "") {
+	if TextNearIdentical("hello", "") {
 		t.Error("non-empty vs empty should not be near-identical")
 	}
-	if !textNearIdentical("", "") {
+	if !TextNearIdentical("", "") {
 		t.Error("both empty should be near-identical")
 	}
 }
@@ -26,7 +26,7 @@ func TestTextNearIdentical_Empty(t *testing.T) {
 func TestTextNearIdentical_MinorDiff(t *testing.T) {
 	a := "User prefers Go with minimal dependencies and clean code style"
 	b := "User prefers Go with minimal dependencies and clean code styles"
-	if !textNearIdentical(a, b) {
+	if !TextNearIdentical(a, b) {
 		t.Error("strings differing by one char should be near-identical")
 	}
 }
@@ -34,13 +34,13 @@ func TestTextNearIdentical_MinorDiff(t *testing.T) {
 func TestTextNearIdentical_MajorDiff(t *testing.T) {
 	a := "User prefers Go with minimal dependencies"
 	b := "System uses Python with maximum frameworks and heavy abstraction layers"
-	if textNearIdentical(a, b) {
+	if TextNearIdentical(a, b) {
 		t.Error("very different strings should not be near-identical")
 	}
 }
 
 func TestTextNearIdentical_Whitespace(t *testing.T) {
-	if !textNearIdentical("  hello world  ", "hello world") {
+	if !TextNearIdentical("  hello world  ", "hello world") {
 		t.Error("strings differing only by whitespace should be near-identical")
 	}
 }
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// nodeCache is a size-bounded LRU of GetNodeByURI results, keyed by URI.
+// Only found nodes are cached; a miss always goes to SQLite.
+//
+// Correctness under concurrent writers rests on two rules. Every write to
+// mem_nodes invalidates after it commits — by URI where the writer knows it,
+// the whole cache where it only knows IDs. And a reader only stores what it
+// read if no invalidation happened since it started (the generation check),
+// so a row read just before a write can't be cached after it. Writes from
+// another process never call invalidate, so before serving a hit the cache
+// also checks PRAGMA data_version (see DB.syncNodeCache).
+type nodeCache struct {
+	mu    sync.Mutex
+	size  int
+	gen   uint64
+	order *list.List // front = most recently used; values are *MemNode
+	items map[string]*list.Element
+
+	// verMu guards conn and version. conn is held only for PRAGMA
+	// data_version, which is per connection: it changes when any other
+	// connection — another process's, or another of this pool's — has
+	// committed since this one last asked.
+	verMu   sync.Mutex
+	conn    *sql.Conn
+	version int64
+}
+
+func newNodeCache(size int) *nodeCache {
+	return &nodeCache{size: size, order: list.New(), items: map[string]*list.Element{}}
+}
+
+// get returns a copy of the cached node for uri, and the generation to pass
+// to put on a miss.
+func (c *nodeCache) get(uri string) (*MemNode, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[uri]; ok {
+		c.order.MoveToFront(el)
+		return copyNode(el.Value.(*MemNode)), c.gen
+	}
+	return nil, c.gen
+}
+
+// put caches a copy of n unless the cache was invalidated since gen.
+func (c *nodeCache) put(n *MemNode, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if el, ok := c.items[n.URI]; ok {
+		el.Value = copyNode(n)
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[n.URI] = c.order.PushFront(copyNode(n))
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*MemNode).URI)
+	}
+}
+
+// invalidate drops the given URIs, or everything when none are given.
+func (c *nodeCache) invalidate(uris ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	if len(uris) == 0 {
+		c.order.Init()
+		c.items = map[string]*list.Element{}
+		return
+	}
+	for _, uri := range uris {
+		if el, ok := c.items[uri]; ok {
+			c.order.Remove(el)
+			delete(c.items, uri)
+		}
+	}
+}
+
+// copyNode returns a copy of n that shares no pointers with it, so callers
+// can't mutate what the cache holds.
+func copyNode(n *MemNode) *MemNode {
+	cp := *n
+	if n.LastAccess != nil {
+		v := *n.LastAccess
+		cp.LastAccess = &v
+	}
+	if n.TombstonedAt != nil {
+		v := *n.TombstonedAt
+		cp.TombstonedAt = &v
+	}
+	if n.PinnedAt != nil {
+		v := *n.PinnedAt
+		cp.PinnedAt = &v
+	}
+	if n.RelevanceSetAt != nil {
+		v := *n.RelevanceSetAt
+		cp.RelevanceSetAt = &v
+	}
+	return &cp
+}
+
+// close releases the connection held for data_version.
+func (c *nodeCache) close() {
+	c.verMu.Lock()
+	defer c.verMu.Unlock()
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+// EnableNodeCache turns on an LRU cache of up to size GetNodeByURI results.
+// size <= 0 leaves caching off. Call before the DB is shared; the cache is
+// not swapped safely under concurrent use. For a file database the cache
+// holds one pooled connection for its cross-process check.
+func (db *DB) EnableNodeCache(size int) {
+	if db.nodeCache != nil {
+		db.nodeCache.close()
+	}
+	if size <= 0 {
+		db.nodeCache = nil
+		return
+	}
+	db.nodeCache = newNodeCache(size)
+}
+
+// syncNodeCache empties the node cache when another connection has
+// committed since the last check, so a write by another process (a CLI
+// command beside the server) is never hidden behind a cached row. Writes
+// through this DB bump data_version too and so also empty it; they
+// invalidate precisely anyway. An in-memory database can't be shared with
+// another process, and a second connection to one would open a different
+// database, so it is skipped. On error the caller should read SQLite.
+func (db *DB) syncNodeCache() error {
+	if db.Path == ":memory:" {
+		return nil
+	}
+	c := db.nodeCache
+	c.verMu.Lock()
+	defer c.verMu.Unlock()
+	if c.conn == nil {
+		conn, err := db.Conn(context.Background())
+		if err != nil {
+			return err
+		}
+		c.conn = conn
+	}
+	var v int64
+	if err := c.conn.QueryRowContext(context.Background(), "PRAGMA data_version").Scan(&v); err != nil {
+		c.conn.Close()
+		c.conn = nil
+		return err
+	}
+	if v != c.version {
+		c.version = v
+		c.invalidate()
+	}
+	return nil
+}
+
+// invalidateNodes drops uris from the node cache, or the whole cache when
+// none are given. A no-op with caching off.
+func (db *DB) invalidateNodes(uris ...string) {
+	if db.nodeCache != nil {
+		db.nodeCache.invalidate(uris...)
+	}
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNodeCacheReflectsWrites(t *testing.T) {
+	db := testDB(t)
+	db.EnableNodeCache(8)
+
+	uri := "mem://user/preferences/editor"
+	if err := db.CreateNode(&MemNode{URI: uri, NodeType: "leaf", Category: "preferences", L0Abstract: "Uses vim", L1Overview: "Uses vim everywhere."}); err != nil {
+		t.Fatal(err)
+	}
+	first, err := db.GetNodeByURI(uri)
+	if err != nil || first == nil {
+		t.Fatalf("GetNodeByURI: %v %v", first, err)
+	}
+	first.L0Abstract = "mutated by caller"
+	if again, _ := db.GetNodeByURI(uri); again.L0Abstract != "Uses vim" {
+		t.Fatalf("caller mutation leaked into the cache: %q", again.L0Abstract)
+	}
+
+	if err := db.UpsertNode(&MemNode{URI: uri, NodeType: "leaf", Category: "preferences", L0Abstract: "Switched to Helix", L1Overview: "Moved from vim to Helix for editing."}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := db.GetNodeByURI(uri); got.L0Abstract != "Switched to Helix" {
+		t.Errorf("after upsert, cached read = %q", got.L0Abstract)
+	}
+
+	got, _ := db.GetNodeByURI(uri)
+	got.L1Overview = "Helix, with vim keybindings."
+	if err := db.UpdateNode(got); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := db.GetNodeByURI(uri); got.L1Overview != "Helix, with vim keybindings." {
+		t.Errorf("after update, cached read = %q", got.L1Overview)
+	}
+
+	if _, err := db.RetractNode(uri, "wrong", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := db.GetNodeByURI(uri); !got.IsRetracted() {
+		t.Error("after retract, cached read should show the tombstone")
+	}
+
+	if err := db.DeleteNode(got.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := db.GetNodeByURI(uri); got != nil {
+		t.Error("after delete, cached read should be nil")
+	}
+}
+
+func TestNodeCacheSeesOtherProcessWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shared.db")
+	server, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	server.EnableNodeCache(8)
+	// A second handle stands in for another process: its writes never
+	// touch the server's cache.
+	cli, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cli.Close() })
+
+	uri := "mem://user/preferences/editor"
+	seedNode(t, server, uri, "preferences", "Uses vim")
+	if got, _ := server.GetNodeByURI(uri); got == nil || got.L0Abstract != "Uses vim" {
+		t.Fatalf("first read = %+v", got)
+	}
+	if _, err := cli.RetractNode(uri, "wrong", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := server.GetNodeByURI(uri); got == nil || !got.IsRetracted() {
+		t.Errorf("cached read after another process retracted = %+v, want the tombstone", got)
+	}
+}
+
+func TestNodeCacheEvictsAndRejectsStalePuts(t *testing.T) {
+	c := newNodeCache(2)
+	_, gen := c.get("a")
+	c.put(&MemNode{URI: "a"}, gen)
+	c.put(&MemNode{URI: "b"}, gen)
+	c.get("a") // a is now most recent
+	c.put(&MemNode{URI: "c"}, gen)
+	if n, _ := c.get("b"); n != nil {
+		t.Error("least recently used entry should be evicted")
+	}
+	if n, _ := c.get("a"); n == nil {
+		t.Error("recently used entry should survive")
+	}
+
+	// A read that started before an invalidation must not be cached.
+	_, gen = c.get("d")
+	c.invalidate("d")
+	c.put(&MemNode{URI: "d"}, gen)
+	if n, _ := c.get("d"); n != nil {
+		t.Error("a put from before an invalidation should be dropped")
+	}
+}
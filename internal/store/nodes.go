@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"time"
 )
@@ -13,13 +15,37 @@ import (
 // by overwriting a mergeable row in place nor by spawning a live duplicate. This
 // is the atomic store-level backstop behind the callers' pre-checks: it closes
 // the check-then-write race where a concurrent retraction lands between a
-// caller's guard and the write.
-var ErrRetractedTarget = errors.New("refusing to upsert into a retracted node")
+// caller's guard and the write. It is an ErrConflict.
+var ErrRetractedTarget = withKind(ErrConflict, errors.New("refusing to upsert into a retracted node"))
+
+// DefaultMaxURIDepth is the deepest URI (in path segments) CreateNode accepts
+// when DB.MaxURIDepth is unset. Every URI the write paths build today is three
+// deep (owner/category/slug); the headroom is for future nesting, not for
+// whatever a malformed hint would otherwise produce.
+const DefaultMaxURIDepth = 6
+
+// ErrURITooDeep is returned for a URI with more path segments than the DB's
+// depth limit. Checked before any parent directory is created, so a rejected
+// URI leaves no stray dirs behind.
+var ErrURITooDeep = errors.New("uri exceeds maximum depth")
+
+// CheckURIDepth returns ErrURITooDeep (wrapped with the URI and limit) when
+// uri is deeper than the DB's limit.
+func (db *DB) CheckURIDepth(uri string) error {
+	limit := db.MaxURIDepth
+	if limit <= 0 {
+		limit = DefaultMaxURIDepth
+	}
+	if n := len(uriSegments(uri)); n > limit {
+		return fmt.Errorf("%w: %s has %d segments, max %d", ErrURITooDeep, uri, n, limit)
+	}
+	return nil
+}
 
-// textNearIdentical returns true if two strings are >95% similar by character overlap.
+// TextNearIdentical returns true if two strings are >95% similar by character overlap.
 // Uses a simple normalized edit-distance-like metric: shared bigram ratio.
 // This is intentionally cheap — no embeddings needed at the store layer.
-func textNearIdentical(a, b string) bool {
+func TextNearIdentical(a, b string) bool {
 	a = strings.TrimSpace(a)
 	b = strings.TrimSpace(b)
 	if a == b {
@@ -89,13 +115,36 @@ type MemNode struct {
 
 	// Operator pin (declared contract). nil when the node is not pinned.
 	PinnedAt *int64
+
+	// When the operator hand-set Relevance (`continuity boost`). nil while
+	// decay and access boosts manage it.
+	RelevanceSetAt *int64
+
+	// Origin is OriginAssistant for a memory extraction kept because the
+	// assistant, not the user, surfaced it; empty otherwise. Set on create
+	// and kept by later merges into the node.
+	Origin string
+
+	// MergeOverride, set by a writer, replaces the category default for
+	// whether this node merges in place (see MergesInto). Reads never set
+	// it; Mergeable is the stored flag.
+	MergeOverride *bool
 }
 
+// OriginAssistant is the Origin of a memory the assistant surfaced without
+// the user flagging it.
+const OriginAssistant = "assistant"
+
 // IsRetracted reports whether this node has been retracted.
 func (n *MemNode) IsRetracted() bool {
 	return n.TombstonedAt != nil
 }
 
+// IsRelevanceSet reports whether this node's relevance was set by hand.
+func (n *MemNode) IsRelevanceSet() bool {
+	return n.RelevanceSetAt != nil
+}
+
 // IsPinned reports whether this node is an operator-declared pin.
 func (n *MemNode) IsPinned() bool {
 	return n.PinnedAt != nil
@@ -106,12 +155,17 @@ func (n *MemNode) IsPinned() bool {
 // previous engine.mergeableCategory mirror is gone; do not reintroduce it.
 // feedback joined v0.6.0 (issue #24) — near-duplicate rules ("be terse" /
 // "stay concise") should consolidate, not accrete. reference deliberately
-// stays out: each external pointer is distinct, like entities.
+// stays out: each external pointer is distinct, like entities. constraints
+// (v17) merge like feedback: a restated "don't" should sharpen the one rule.
+// session notes live under their session's own URI, so each re-extraction of
+// a session updates its notes instead of stacking versions.
 var mergeableCategories = map[string]bool{
 	"profile":     true,
 	"preferences": true,
 	"patterns":    true,
 	"feedback":    true,
+	"constraints": true,
+	"session":     true,
 }
 
 // IsMergeable reports whether the given category supports in-place merging.
@@ -122,12 +176,25 @@ func IsMergeable(category string) bool {
 	return mergeableCategories[category]
 }
 
-// CreateNode inserts a new mem_node. Sets mergeable based on category.
-// Automatically ensures parent directory nodes exist.
+// MergesInto reports whether a write over the live existing node at the same
+// URI updates it in place. That is existing's own stored flag: a writer's
+// MergeOverride applies only when it creates the node, so no later candidate
+// can turn an immutable memory into one it overwrites.
+func MergesInto(existing *MemNode) bool {
+	return existing.Mergeable
+}
+
+// CreateNode inserts a new mem_node. Sets mergeable from node.MergeOverride,
+// or the category default when there is none. Automatically ensures parent
+// directory nodes exist.
 func (db *DB) CreateNode(node *MemNode) error {
 	now := time.Now().UnixMilli()
+	isMergeable := IsMergeable(node.Category)
+	if node.MergeOverride != nil {
+		isMergeable = *node.MergeOverride
+	}
 	mergeable := 0
-	if IsMergeable(node.Category) {
+	if isMergeable {
 		mergeable = 1
 	}
 
@@ -141,19 +208,19 @@ func (db *DB) CreateNode(node *MemNode) error {
 
 	result, err := db.Exec(`
 		INSERT INTO mem_nodes (uri, parent_uri, node_type, category, l0_abstract, l1_overview, l2_content,
-			mergeable, merged_from, relevance, last_access, access_count, source_session, created_at, updated_at)
-		VALUES (?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			mergeable, merged_from, relevance, last_access, access_count, source_session, created_at, updated_at, origin)
+		VALUES (?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''))
 	`, node.URI, parentURI, node.NodeType, node.Category,
-		node.L0Abstract, node.L1Overview, node.L2Content,
+		node.L0Abstract, node.L1Overview, db.l2(node.L2Content),
 		mergeable, node.MergedFrom,
-		1.0, now, 0, node.SourceSession, now, now)
+		1.0, now, 0, node.SourceSession, now, now, node.Origin)
 	if err != nil {
 		return fmt.Errorf("create node: %w", err)
 	}
 
 	id, _ := result.LastInsertId()
 	node.ID = id
-	node.Mergeable = IsMergeable(node.Category)
+	node.Mergeable = isMergeable
 	node.Relevance = 1.0
 	node.CreatedAt = now
 	node.UpdatedAt = now
@@ -162,21 +229,39 @@ func (db *DB) CreateNode(node *MemNode) error {
 
 // GetNodeByURI returns a node by its URI, or nil if not found.
 // Retraction state is included on the returned node; callers decide how to handle.
+// Served from the node cache when one is enabled.
 func (db *DB) GetNodeByURI(uri string) (*MemNode, error) {
+	if db.nodeCache == nil || db.syncNodeCache() != nil {
+		return db.getNodeByURI(uri)
+	}
+	n, gen := db.nodeCache.get(uri)
+	if n != nil {
+		return n, nil
+	}
+	n, err := db.getNodeByURI(uri)
+	if err == nil && n != nil {
+		db.nodeCache.put(n, gen)
+	}
+	return n, err
+}
+
+// getNodeByURI always reads SQLite. The store's own write paths use it: a
+// retraction or pin check must never see a cached row.
+func (db *DB) getNodeByURI(uri string) (*MemNode, error) {
 	var n MemNode
 	var mergeable int
-	var lastAccess, tombstonedAt, pinnedAt sql.NullInt64
-	var parentURI, l0, l1, l2, mergedFrom, sourceSession, tombstoneReason, supersededBy sql.NullString
+	var lastAccess, tombstonedAt, pinnedAt, relevanceSetAt sql.NullInt64
+	var parentURI, l0, l1, l2, mergedFrom, sourceSession, tombstoneReason, supersededBy, origin sql.NullString
 	err := db.QueryRow(`
 		SELECT id, uri, parent_uri, node_type, category, l0_abstract, l1_overview, l2_content,
 			mergeable, merged_from, relevance, last_access, access_count, source_session, created_at, updated_at,
-			tombstoned_at, tombstone_reason, superseded_by, pinned_at
+			tombstoned_at, tombstone_reason, superseded_by, pinned_at, relevance_set_at, origin
 		FROM mem_nodes WHERE uri = ?
 	`, uri).Scan(&n.ID, &n.URI, &parentURI, &n.NodeType, &n.Category,
 		&l0, &l1, &l2,
 		&mergeable, &mergedFrom, &n.Relevance, &lastAccess, &n.AccessCount,
 		&sourceSession, &n.CreatedAt, &n.UpdatedAt,
-		&tombstonedAt, &tombstoneReason, &supersededBy, &pinnedAt)
+		&tombstonedAt, &tombstoneReason, &supersededBy, &pinnedAt, &relevanceSetAt, &origin)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -198,20 +283,34 @@ func (db *DB) GetNodeByURI(uri string) (*MemNode, error) {
 	}
 	n.TombstoneReason = tombstoneReason.String
 	n.SupersededBy = supersededBy.String
+	n.Origin = origin.String
 	if pinnedAt.Valid {
 		n.PinnedAt = &pinnedAt.Int64
 	}
+	if relevanceSetAt.Valid {
+		n.RelevanceSetAt = &relevanceSetAt.Int64
+	}
 	return &n, nil
 }
 
+// l2 is the L2 content to write for a node: content itself, or empty when
+// the DB drops L2.
+func (db *DB) l2(content string) string {
+	if db.DropL2 {
+		return ""
+	}
+	return content
+}
+
 // UpdateNode updates a node's content tiers and updated_at.
 func (db *DB) UpdateNode(node *MemNode) error {
+	defer db.invalidateNodes() // keyed by ID, so the URI may not be the cached one
 	now := time.Now().UnixMilli()
 	_, err := db.Exec(`
 		UPDATE mem_nodes SET l0_abstract = ?, l1_overview = ?, l2_content = ?,
 			merged_from = ?, source_session = ?, updated_at = ?
 		WHERE id = ?
-	`, node.L0Abstract, node.L1Overview, node.L2Content,
+	`, node.L0Abstract, node.L1Overview, db.l2(node.L2Content),
 		node.MergedFrom, node.SourceSession, now, node.ID)
 	if err != nil {
 		return fmt.Errorf("update node: %w", err)
@@ -221,9 +320,12 @@ func (db *DB) UpdateNode(node *MemNode) error {
 }
 
 // UpsertNode creates a new node or merges into an existing one.
-// For mergeable categories, updates in place. For immutable, creates new.
+// A mergeable node (MergesInto) is updated in place; an immutable one gets a
+// new timestamp-suffixed version. node.MergeOverride counts only when the
+// node is created.
 func (db *DB) UpsertNode(node *MemNode) error {
-	existing, err := db.GetNodeByURI(node.URI)
+	defer db.invalidateNodes(node.URI)
+	existing, err := db.getNodeByURI(node.URI)
 	if err != nil {
 		return err
 	}
@@ -239,23 +341,24 @@ func (db *DB) UpsertNode(node *MemNode) error {
 		return ErrRetractedTarget
 	}
 
-	if existing.Mergeable {
+	if MergesInto(existing) {
 		// Skip if new content is near-identical to existing (avoid churn)
-		if textNearIdentical(existing.L1Overview, node.L1Overview) &&
-			textNearIdentical(existing.L0Abstract, node.L0Abstract) {
+		if TextNearIdentical(existing.L1Overview, node.L1Overview) &&
+			TextNearIdentical(existing.L0Abstract, node.L0Abstract) {
 			return nil
 		}
 		// Tombstone-guarded in-place update: if the row is retracted between the
 		// read above and this write, 0 rows change — report the refusal rather
 		// than silently overwriting (resurrecting) the tombstone. Same columns as
-		// UpdateNode, preserving merged_from.
+		// UpdateNode, preserving merged_from; an origin already recorded stays.
 		now := time.Now().UnixMilli()
 		res, err := db.Exec(`
 			UPDATE mem_nodes SET l0_abstract = ?, l1_overview = ?, l2_content = ?,
-				merged_from = ?, source_session = ?, updated_at = ?
+				mergeable = 1, merged_from = ?, source_session = ?, updated_at = ?,
+				origin = COALESCE(origin, NULLIF(?, ''))
 			WHERE id = ? AND tombstoned_at IS NULL
-		`, node.L0Abstract, node.L1Overview, node.L2Content,
-			existing.MergedFrom, node.SourceSession, now, existing.ID)
+		`, node.L0Abstract, node.L1Overview, db.l2(node.L2Content),
+			existing.MergedFrom, node.SourceSession, now, node.Origin, existing.ID)
 		if err != nil {
 			return fmt.Errorf("update node: %w", err)
 		}
@@ -263,6 +366,7 @@ func (db *DB) UpsertNode(node *MemNode) error {
 			return ErrRetractedTarget // raced retraction between read and write
 		}
 		node.URI = existing.URI
+		node.Mergeable = true
 		return nil
 	}
 
@@ -277,7 +381,7 @@ func (db *DB) UpsertNode(node *MemNode) error {
 	// above and this insert, the suffixed node would resurrect just-retracted
 	// content. Re-check and compensate (delete + fail closed). The suffixed node is
 	// only ever visible after UpsertNode returns, so this transient insert is safe.
-	base, rerr := db.GetNodeByURI(baseURI)
+	base, rerr := db.getNodeByURI(baseURI)
 	if rerr != nil || (base != nil && base.IsRetracted()) {
 		// Either the base is retracted, OR we can't prove it isn't (re-read failed).
 		// Both fail closed: undo the insert so no unproven live node survives.
@@ -292,13 +396,153 @@ func (db *DB) UpsertNode(node *MemNode) error {
 	return nil
 }
 
+// PruneSlugVersions deletes all but the keep most recent live versions of an
+// immutable slug in category: the node at baseSlug itself plus the
+// baseSlug-{timestamp} copies UpsertNode writes on each collision. Pinned
+// versions are never pruned and don't count against keep; tombstones stay
+// so a retraction still blocks resurrection. keep <= 0 means unlimited.
+// Returns the number of nodes deleted.
+func (db *DB) PruneSlugVersions(baseSlug, category string, keep int) (int, error) {
+	if keep <= 0 || baseSlug == "" {
+		return 0, nil
+	}
+	// LIKE narrows the scan; the exact slug match happens below so a % or _
+	// in the slug can't widen it.
+	rows, err := db.Query(`
+		SELECT id, uri FROM mem_nodes
+		WHERE category = ? AND node_type = 'leaf' AND tombstoned_at IS NULL AND pinned_at IS NULL
+			AND (uri LIKE '%/' || ? OR uri LIKE '%/' || ? || '-%')
+		ORDER BY created_at DESC, id DESC
+	`, category, baseSlug, baseSlug)
+	if err != nil {
+		return 0, fmt.Errorf("list slug versions: %w", err)
+	}
+	var ids []int64
+	kept := 0
+	for rows.Next() {
+		var id int64
+		var uri string
+		if err := rows.Scan(&id, &uri); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan slug version: %w", err)
+		}
+		if !isSlugVersion(uri[strings.LastIndex(uri, "/")+1:], baseSlug) {
+			continue
+		}
+		if kept < keep {
+			kept++
+			continue
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if err := db.DeleteNodes(ids); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// EvictOverCap deletes the lowest-scoring live leaves of category until at
+// most limit remain, scoring each by relevance × (1 + log2(access_count)),
+// the same rank context injection uses. Pinned leaves are never evicted and
+// don't count against limit; neither does the relational profile. keepURI,
+// the memory just written, is spared so a write can't evict itself.
+// Tombstones stay so a retraction still blocks resurrection. limit <= 0
+// means unlimited. Returns the URIs deleted.
+func (db *DB) EvictOverCap(category string, limit int, keepURI string) ([]string, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	rows, err := db.Query(`
+		SELECT id, uri, relevance, access_count FROM mem_nodes
+		WHERE category = ? AND node_type = 'leaf' AND tombstoned_at IS NULL AND pinned_at IS NULL
+			AND uri != 'mem://user/profile/communication'
+	`, category)
+	if err != nil {
+		return nil, fmt.Errorf("list %s for cap: %w", category, err)
+	}
+	type scored struct {
+		id    int64
+		uri   string
+		score float64
+	}
+	var nodes []scored
+	for rows.Next() {
+		var n scored
+		var relevance float64
+		var access int
+		if err := rows.Scan(&n.id, &n.uri, &relevance, &access); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan %s for cap: %w", category, err)
+		}
+		n.score = relevance
+		if access > 0 {
+			n.score *= 1 + math.Log2(float64(access))
+		}
+		nodes = append(nodes, n)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(nodes) <= limit {
+		return nil, nil
+	}
+
+	// Lowest score first; among equals the oldest row goes first.
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].score != nodes[j].score {
+			return nodes[i].score < nodes[j].score
+		}
+		return nodes[i].id < nodes[j].id
+	})
+	var ids []int64
+	var uris []string
+	for _, n := range nodes {
+		if len(nodes)-len(ids) <= limit {
+			break
+		}
+		if n.uri == keepURI {
+			continue
+		}
+		ids = append(ids, n.id)
+		uris = append(uris, n.uri)
+	}
+	if err := db.DeleteNodes(ids); err != nil {
+		return nil, err
+	}
+	return uris, nil
+}
+
+// isSlugVersion reports whether slug is base or base-{unix millis}, the suffix
+// UpsertNode appends to an immutable collision. Requiring the full 13 digits
+// keeps "release-2024" from counting as a version of "release".
+func isSlugVersion(slug, base string) bool {
+	if slug == base {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(slug, base+"-")
+	if !ok || len(suffix) != 13 {
+		return false
+	}
+	for _, r := range suffix {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // FindByCategory returns live leaf nodes for a given category, ordered by relevance DESC.
 // Retracted nodes are excluded — use FindByCategoryIncludingRetracted for inspection.
 func (db *DB) FindByCategory(category string) ([]MemNode, error) {
 	rows, err := db.Query(`
 		SELECT id, uri, parent_uri, node_type, category, l0_abstract, l1_overview, l2_content,
 			mergeable, merged_from, relevance, last_access, access_count, source_session, created_at, updated_at,
-			tombstoned_at, tombstone_reason, superseded_by, pinned_at
+			tombstoned_at, tombstone_reason, superseded_by, pinned_at, relevance_set_at, origin
 		FROM mem_nodes WHERE category = ? AND node_type = 'leaf' AND tombstoned_at IS NULL
 		ORDER BY relevance DESC
 	`, category)
@@ -316,7 +560,7 @@ func (db *DB) ListLeaves() ([]MemNode, error) {
 	rows, err := db.Query(`
 		SELECT id, uri, parent_uri, node_type, category, l0_abstract, l1_overview, l2_content,
 			mergeable, merged_from, relevance, last_access, access_count, source_session, created_at, updated_at,
-			tombstoned_at, tombstone_reason, superseded_by, pinned_at
+			tombstoned_at, tombstone_reason, superseded_by, pinned_at, relevance_set_at, origin
 		FROM mem_nodes WHERE node_type = 'leaf' AND tombstoned_at IS NULL
 		ORDER BY relevance DESC
 	`)
@@ -328,22 +572,166 @@ func (db *DB) ListLeaves() ([]MemNode, error) {
 	return scanNodes(rows)
 }
 
-// TouchNode updates last_access and increments access_count (retrieval boost).
+// DecayParams shapes relevance decay. A leaf's relevance is measured from
+// its creation: it halves every HalfLife. A retrieval adds a bonus of up to
+// AccessBoost on top, and the bonus itself halves every AccessHalfLife after
+// the last retrieval. The sum is capped at 1 and floored at 0.1, so access
+// can lift a memory for a while but never resets its age.
+type DecayParams struct {
+	HalfLife       time.Duration
+	AccessBoost    float64
+	AccessHalfLife time.Duration
+}
+
+// DefaultDecayParams is the decay used until SetDecayParams is called:
+// a 90-day half-life, and a 0.3 access bonus with a 14-day half-life.
+func DefaultDecayParams() DecayParams {
+	return DecayParams{
+		HalfLife:       90 * 24 * time.Hour,
+		AccessBoost:    0.3,
+		AccessHalfLife: 14 * 24 * time.Hour,
+	}
+}
+
+// SetDecayParams replaces the decay used by DecayAllNodes, TouchNode and the
+// metrics' effective relevance. Both half-lives must be positive and the
+// boost within [0, 1].
+func (db *DB) SetDecayParams(p DecayParams) error {
+	if p.HalfLife <= 0 {
+		return fmt.Errorf("decay half-life must be positive, got %s", p.HalfLife)
+	}
+	if p.AccessHalfLife <= 0 {
+		return fmt.Errorf("access boost half-life must be positive, got %s", p.AccessHalfLife)
+	}
+	if p.AccessBoost < 0 || p.AccessBoost > 1 {
+		return fmt.Errorf("access boost must be between 0 and 1, got %v", p.AccessBoost)
+	}
+	db.decay = &p
+	return nil
+}
+
+func (db *DB) decayParams() DecayParams {
+	if db.decay == nil {
+		return DefaultDecayParams()
+	}
+	return *db.decay
+}
+
+// relevanceAt is the relevance p assigns at now to a leaf created at
+// createdAt and last retrieved at lastAccess (nil if never).
+func (p DecayParams) relevanceAt(createdAt int64, lastAccess *int64, now int64) float64 {
+	v := 1.0
+	if age := now - createdAt; age > 0 {
+		v = pow05(float64(age) / float64(p.HalfLife.Milliseconds()))
+	}
+	if lastAccess != nil {
+		since := max(now-*lastAccess, 0)
+		v += p.AccessBoost * pow05(float64(since)/float64(p.AccessHalfLife.Milliseconds()))
+	}
+	return min(max(v, DecayFloor), 1.0)
+}
+
+// TouchNode updates last_access and increments access_count (retrieval
+// boost). Relevance rises to what decay assigns a node retrieved just now —
+// its age-based relevance plus the full access bonus — and never falls.
+// Hand-set relevance is left as set.
 func (db *DB) TouchNode(uri string) error {
+	defer db.invalidateNodes(uri)
 	now := time.Now().UnixMilli()
+	var createdAt int64
+	if err := db.QueryRow(`SELECT created_at FROM mem_nodes WHERE uri = ?`, uri).Scan(&createdAt); errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("touch node: %w", err)
+	}
+	boosted := db.decayParams().relevanceAt(createdAt, &now, now)
 	_, err := db.Exec(`
-		UPDATE mem_nodes SET last_access = ?, access_count = access_count + 1, relevance = 1.0
+		UPDATE mem_nodes SET last_access = ?, access_count = access_count + 1,
+			relevance = CASE WHEN relevance_set_at IS NULL THEN MAX(relevance, ?) ELSE relevance END
 		WHERE uri = ?
-	`, now, uri)
+	`, now, boosted, uri)
 	if err != nil {
 		return fmt.Errorf("touch node: %w", err)
 	}
 	return nil
 }
 
-// DecayAllNodes applies time-based decay to all non-exempt nodes.
-// 90-day half-life, floor of 0.1. Profile nodes are exempt.
+// DecayFloor is the lowest relevance decay assigns: memories fade but are
+// never fully forgotten.
+const DecayFloor = 0.1
+
+// ContextMinRelevance is the relevance below which a memory is no longer
+// ranked into the SessionStart context. Search still finds it.
+const ContextMinRelevance = 0.3
+
+// DecayReport summarizes one decay run.
+type DecayReport struct {
+	Considered   int // decayable leaves examined
+	Updated      int // leaves whose relevance was lowered
+	BelowContext int // of Updated, how many fell below ContextMinRelevance
+	AtFloor      int // of Updated, how many reached DecayFloor
+
+	// Distribution bins the new relevance of every updated leaf, 0.1 wide
+	// from DecayFloor to 1.0.
+	Distribution []HistBin
+}
+
+// String renders the report as one log line.
+func (r DecayReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "updated %d of %d nodes; %d fell below the context threshold (%.1f), %d at the floor (%.1f)",
+		r.Updated, r.Considered, r.BelowContext, ContextMinRelevance, r.AtFloor, DecayFloor)
+	if r.Updated > 0 {
+		b.WriteString("; new relevance:")
+		for _, bin := range r.Distribution {
+			if bin.Count > 0 {
+				fmt.Fprintf(&b, " [%.1f-%.1f) %d", bin.Lo, bin.Hi, bin.Count)
+			}
+		}
+	}
+	return b.String()
+}
+
+// decayReportBins is the number of 0.1-wide bins in DecayReport.Distribution.
+const decayReportBins = 9
+
+func newDecayReport() DecayReport {
+	r := DecayReport{Distribution: make([]HistBin, decayReportBins)}
+	for i := range r.Distribution {
+		lo := DecayFloor + float64(i)/10
+		r.Distribution[i] = HistBin{Lo: lo, Hi: lo + 0.1}
+	}
+	return r
+}
+
+// record counts one relevance lowered from before to after.
+func (r *DecayReport) record(before, after float64) {
+	r.Updated++
+	if before >= ContextMinRelevance && after < ContextMinRelevance {
+		r.BelowContext++
+	}
+	if after <= DecayFloor {
+		r.AtFloor++
+	}
+	bin := int((after - DecayFloor) * 10)
+	r.Distribution[min(max(bin, 0), decayReportBins-1)].Count++
+}
+
+// DecayAllNodes applies time-based decay (see DecayParams) to all non-exempt
+// nodes and returns how many it lowered. See DecayAllNodesReport.
 func (db *DB) DecayAllNodes() (int, error) {
+	r, err := db.DecayAllNodesReport()
+	return r.Updated, err
+}
+
+// DecayAllNodesReport applies time-based decay (see DecayParams) to all
+// non-exempt nodes and summarizes the run. Age is always measured from
+// created_at; last_access only feeds the bounded access bonus. Profile nodes
+// and hand-set relevance are exempt. Safe to run alongside TouchNode: a node
+// touched mid-run keeps its boost.
+func (db *DB) DecayAllNodesReport() (DecayReport, error) {
+	defer db.invalidateNodes()
+	report := newDecayReport()
 	// Fetch all decayable nodes
 	rows, err := db.Query(`
 		SELECT id, uri, relevance, last_access, created_at
@@ -351,9 +739,10 @@ func (db *DB) DecayAllNodes() (int, error) {
 		WHERE node_type = 'leaf'
 			AND uri != 'mem://user/profile/communication'
 			AND category != 'moments'
+			AND relevance_set_at IS NULL
 	`)
 	if err != nil {
-		return 0, fmt.Errorf("query decayable nodes: %w", err)
+		return report, fmt.Errorf("query decayable nodes: %w", err)
 	}
 	defer rows.Close()
 
@@ -369,7 +758,7 @@ func (db *DB) DecayAllNodes() (int, error) {
 		var t decayTarget
 		var lastAccess sql.NullInt64
 		if err := rows.Scan(&t.id, new(string), &t.relevance, &lastAccess, &t.createdAt); err != nil {
-			return 0, fmt.Errorf("scan decay target: %w", err)
+			return report, fmt.Errorf("scan decay target: %w", err)
 		}
 		if lastAccess.Valid {
 			t.lastAccess = &lastAccess.Int64
@@ -377,41 +766,41 @@ func (db *DB) DecayAllNodes() (int, error) {
 		targets = append(targets, t)
 	}
 	if err := rows.Err(); err != nil {
-		return 0, err
+		return report, err
 	}
+	report.Considered = len(targets)
 
 	now := time.Now().UnixMilli()
-	halfLifeMs := float64(90 * 24 * 60 * 60 * 1000) // 90 days in ms
-	updated := 0
+	params := db.decayParams()
 
 	for _, t := range targets {
-		refTime := t.createdAt
-		if t.lastAccess != nil {
-			refTime = *t.lastAccess
-		}
-
-		elapsed := float64(now - refTime)
-		if elapsed <= 3600000 { // skip anything under 1 hour
+		if now-t.createdAt <= 3600000 { // skip anything under 1 hour old
 			continue
 		}
 
-		// decay = 0.5 ^ (elapsed / halfLife)
-		decay := pow05(elapsed / halfLifeMs)
-		newRelevance := decay
-		if newRelevance < 0.1 {
-			newRelevance = 0.1
-		}
+		newRelevance := params.relevanceAt(t.createdAt, t.lastAccess, now)
 		if newRelevance >= t.relevance {
 			continue // relevance can only decrease via decay
 		}
 
-		if _, err := db.Exec(`UPDATE mem_nodes SET relevance = ? WHERE id = ?`, newRelevance, t.id); err != nil {
-			return updated, fmt.Errorf("update decay: %w", err)
+		// Compare-and-set against the snapshot read above: a TouchNode (or a
+		// hand-set relevance) landing between the read and this write has
+		// already put the row in a newer state, and writing a value computed
+		// from the stale last_access would undo that retrieval boost.
+		res, err := db.Exec(`
+			UPDATE mem_nodes SET relevance = ?
+			WHERE id = ? AND relevance = ? AND last_access IS ? AND relevance_set_at IS NULL
+		`, newRelevance, t.id, t.relevance, t.lastAccess)
+		if err != nil {
+			return report, fmt.Errorf("update decay: %w", err)
 		}
-		updated++
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue // changed under us; the next run decays from the new state
+		}
+		report.record(t.relevance, newRelevance)
 	}
 
-	return updated, nil
+	return report, nil
 }
 
 // pow05 computes 0.5^x using repeated squaring approach.
@@ -459,7 +848,11 @@ func exp(x float64) float64 {
 
 // EnsureParentDirs creates directory nodes for a given leaf URI.
 // e.g., for "mem://user/profile/coding-style", ensures "mem://user" and "mem://user/profile" exist.
+// URIs deeper than the depth limit are refused with ErrURITooDeep.
 func (db *DB) EnsureParentDirs(uri, category string) error {
+	if err := db.CheckURIDepth(uri); err != nil {
+		return err
+	}
 	segments := uriSegments(uri) // ["user", "profile", "coding-style"]
 	if len(segments) <= 1 {
 		return nil // top-level URI, no parents needed
@@ -474,7 +867,7 @@ func (db *DB) EnsureParentDirs(uri, category string) error {
 			parentURI = &p
 		}
 
-		existing, err := db.GetNodeByURI(dirURI)
+		existing, err := db.getNodeByURI(dirURI)
 		if err != nil {
 			return err
 		}
@@ -557,18 +950,18 @@ func parentURIOf(uri string) string {
 func (db *DB) GetNodeByID(id int64) (*MemNode, error) {
 	var n MemNode
 	var mergeable int
-	var lastAccess, tombstonedAt, pinnedAt sql.NullInt64
-	var parentURI, l0, l1, l2, mergedFrom, sourceSession, tombstoneReason, supersededBy sql.NullString
+	var lastAccess, tombstonedAt, pinnedAt, relevanceSetAt sql.NullInt64
+	var parentURI, l0, l1, l2, mergedFrom, sourceSession, tombstoneReason, supersededBy, origin sql.NullString
 	err := db.QueryRow(`
 		SELECT id, uri, parent_uri, node_type, category, l0_abstract, l1_overview, l2_content,
 			mergeable, merged_from, relevance, last_access, access_count, source_session, created_at, updated_at,
-			tombstoned_at, tombstone_reason, superseded_by, pinned_at
+			tombstoned_at, tombstone_reason, superseded_by, pinned_at, relevance_set_at, origin
 		FROM mem_nodes WHERE id = ?
 	`, id).Scan(&n.ID, &n.URI, &parentURI, &n.NodeType, &n.Category,
 		&l0, &l1, &l2,
 		&mergeable, &mergedFrom, &n.Relevance, &lastAccess, &n.AccessCount,
 		&sourceSession, &n.CreatedAt, &n.UpdatedAt,
-		&tombstonedAt, &tombstoneReason, &supersededBy, &pinnedAt)
+		&tombstonedAt, &tombstoneReason, &supersededBy, &pinnedAt, &relevanceSetAt, &origin)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -590,22 +983,63 @@ func (db *DB) GetNodeByID(id int64) (*MemNode, error) {
 	}
 	n.TombstoneReason = tombstoneReason.String
 	n.SupersededBy = supersededBy.String
+	n.Origin = origin.String
 	if pinnedAt.Valid {
 		n.PinnedAt = &pinnedAt.Int64
 	}
+	if relevanceSetAt.Valid {
+		n.RelevanceSetAt = &relevanceSetAt.Int64
+	}
 	return &n, nil
 }
 
-// GetChildren returns live direct children of a given parent URI.
-// Retracted nodes are excluded — use GetChildrenIncludingRetracted for inspection.
-func (db *DB) GetChildren(parentURI string) ([]MemNode, error) {
+// ChildOrder is how GetChildren sorts the children it returns.
+type ChildOrder string
+
+const (
+	ChildOrderURI       ChildOrder = "uri"       // alphabetical, the default
+	ChildOrderRelevance ChildOrder = "relevance" // most relevant first
+	ChildOrderUpdated   ChildOrder = "updated"   // most recently updated first
+)
+
+// childOrderBy maps each ChildOrder to its ORDER BY clause. Ties fall back to
+// the URI so listings stay stable.
+var childOrderBy = map[ChildOrder]string{
+	ChildOrderURI:       "uri",
+	ChildOrderRelevance: "relevance DESC, uri",
+	ChildOrderUpdated:   "updated_at DESC, uri",
+}
+
+// ParseChildOrder validates a child ordering name; empty means ChildOrderURI.
+func ParseChildOrder(s string) (ChildOrder, error) {
+	if s == "" {
+		return ChildOrderURI, nil
+	}
+	o := ChildOrder(strings.ToLower(s))
+	if _, ok := childOrderBy[o]; !ok {
+		return "", fmt.Errorf("unknown order %q (want uri, relevance, or updated)", s)
+	}
+	return o, nil
+}
+
+// orderBy is the ORDER BY clause for o; an unknown order sorts by URI.
+func (o ChildOrder) orderBy() string {
+	if clause, ok := childOrderBy[o]; ok {
+		return clause
+	}
+	return childOrderBy[ChildOrderURI]
+}
+
+// GetChildren returns live direct children of a given parent URI, sorted by
+// order. Retracted nodes are excluded — use GetChildrenIncludingRetracted for
+// inspection.
+func (db *DB) GetChildren(parentURI string, order ChildOrder) ([]MemNode, error) {
 	rows, err := db.Query(`
 		SELECT id, uri, parent_uri, node_type, category, l0_abstract, l1_overview, l2_content,
 			mergeable, merged_from, relevance, last_access, access_count, source_session, created_at, updated_at,
-			tombstoned_at, tombstone_reason, superseded_by, pinned_at
+			tombstoned_at, tombstone_reason, superseded_by, pinned_at, relevance_set_at, origin
 		FROM mem_nodes WHERE parent_uri = ? AND tombstoned_at IS NULL
-		ORDER BY uri
-	`, parentURI)
+		ORDER BY `+order.orderBy(), parentURI)
 	if err != nil {
 		return nil, fmt.Errorf("get children: %w", err)
 	}
@@ -618,7 +1052,7 @@ func (db *DB) ListRoots() ([]MemNode, error) {
 	rows, err := db.Query(`
 		SELECT id, uri, parent_uri, node_type, category, l0_abstract, l1_overview, l2_content,
 			mergeable, merged_from, relevance, last_access, access_count, source_session, created_at, updated_at,
-			tombstoned_at, tombstone_reason, superseded_by, pinned_at
+			tombstoned_at, tombstone_reason, superseded_by, pinned_at, relevance_set_at, origin
 		FROM mem_nodes WHERE parent_uri IS NULL
 		ORDER BY uri
 	`)
@@ -655,7 +1089,7 @@ func (db *DB) GetNodesByIDs(ids []int64) ([]MemNode, error) {
 	query := fmt.Sprintf(`
 		SELECT id, uri, parent_uri, node_type, category, l0_abstract, l1_overview, l2_content,
 			mergeable, merged_from, relevance, last_access, access_count, source_session, created_at, updated_at,
-			tombstoned_at, tombstone_reason, superseded_by, pinned_at
+			tombstoned_at, tombstone_reason, superseded_by, pinned_at, relevance_set_at, origin
 		FROM mem_nodes WHERE id IN (%s)
 	`, ph)
 
@@ -667,27 +1101,95 @@ func (db *DB) GetNodesByIDs(ids []int64) ([]MemNode, error) {
 	return scanNodes(rows)
 }
 
-// DeleteNode removes a node and its associated vector by ID.
+// DeleteNode removes a node and its associated vector and links by ID.
 func (db *DB) DeleteNode(id int64) error {
-	if err := db.DeleteVector(id); err != nil {
+	defer db.invalidateNodes()
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin delete node: %w", err)
+	}
+	defer tx.Rollback()
+	if err := deleteNodeTx(tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deleteNodeTx deletes node id with the rows that hang off it: its vector,
+// its links and the session_memories rows naming it. Every node deletion
+// goes through here, so none leaves dependents behind for a later memory at
+// the same URI to inherit.
+func deleteNodeTx(tx *Tx, id int64) error {
+	if _, err := tx.Exec("DELETE FROM mem_vectors WHERE node_id = ?", id); err != nil {
 		return fmt.Errorf("delete vector for node %d: %w", id, err)
 	}
-	_, err := db.Exec("DELETE FROM mem_nodes WHERE id = ?", id)
-	if err != nil {
+	if err := deleteNodeLinks(tx, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM session_memories WHERE uri = (SELECT uri FROM mem_nodes WHERE id = ?)`, id); err != nil {
+		return fmt.Errorf("delete session memories of node %d: %w", id, err)
+	}
+	if _, err := tx.Exec("DELETE FROM mem_nodes WHERE id = ?", id); err != nil {
 		return fmt.Errorf("delete node %d: %w", id, err)
 	}
 	return nil
 }
 
-// DeleteOrphanDirs removes directory nodes that have no children.
-func (db *DB) DeleteOrphanDirs() (int, error) {
-	result, err := db.Exec(`
+// absorbNodeTx folds node fromID into toID for a merge or dedup: fromID's
+// links and session_memories rows move to toID, then fromID is deleted
+// (deleteNodeTx).
+func absorbNodeTx(tx *Tx, fromID, toID int64) error {
+	if err := repointLinks(tx, fromID, toID); err != nil {
+		return err
+	}
+	if err := repointSessionMemories(tx, fromID, toID); err != nil {
+		return err
+	}
+	return deleteNodeTx(tx, fromID)
+}
+
+// DeleteNodes deletes the given nodes, their vectors and links, then the directory
+// nodes left without children, all in one transaction: either every node goes
+// or none does. Bulk callers (dedup) use this instead of a DeleteNode commit
+// per node.
+func (db *DB) DeleteNodes(ids []int64) error {
+	defer db.invalidateNodes()
+	if len(ids) == 0 {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin delete nodes: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		if err := deleteNodeTx(tx, id); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(deleteOrphanDirsSQL); err != nil {
+		return fmt.Errorf("delete orphan dirs: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit delete nodes: %w", err)
+	}
+	return nil
+}
+
+// deleteOrphanDirsSQL removes directory nodes that have no children.
+const deleteOrphanDirsSQL = `
 		DELETE FROM mem_nodes WHERE node_type = 'dir'
 		AND id NOT IN (
 			SELECT DISTINCT p.id FROM mem_nodes p
 			JOIN mem_nodes c ON c.parent_uri = p.uri
 		)
-	`)
+	`
+
+// DeleteOrphanDirs removes directory nodes that have no children.
+func (db *DB) DeleteOrphanDirs() (int, error) {
+	defer db.invalidateNodes()
+	result, err := db.Exec(deleteOrphanDirsSQL)
 	if err != nil {
 		return 0, fmt.Errorf("delete orphan dirs: %w", err)
 	}
@@ -718,36 +1220,51 @@ func (db *DB) CountLiveChildren(parentURI string) (int, error) {
 func scanNodes(rows *sql.Rows) ([]MemNode, error) {
 	var nodes []MemNode
 	for rows.Next() {
-		var n MemNode
-		var mergeable int
-		var lastAccess, tombstonedAt, pinnedAt sql.NullInt64
-		var parentURI, l0, l1, l2, mergedFrom, sourceSession, tombstoneReason, supersededBy sql.NullString
-		if err := rows.Scan(&n.ID, &n.URI, &parentURI, &n.NodeType, &n.Category,
-			&l0, &l1, &l2,
-			&mergeable, &mergedFrom, &n.Relevance, &lastAccess, &n.AccessCount,
-			&sourceSession, &n.CreatedAt, &n.UpdatedAt,
-			&tombstonedAt, &tombstoneReason, &supersededBy, &pinnedAt); err != nil {
-			return nil, fmt.Errorf("scan node: %w", err)
-		}
-		n.ParentURI = parentURI.String
-		n.L0Abstract = l0.String
-		n.L1Overview = l1.String
-		n.L2Content = l2.String
-		n.MergedFrom = mergedFrom.String
-		n.SourceSession = sourceSession.String
-		n.Mergeable = mergeable != 0
-		if lastAccess.Valid {
-			n.LastAccess = &lastAccess.Int64
-		}
-		if tombstonedAt.Valid {
-			n.TombstonedAt = &tombstonedAt.Int64
-		}
-		n.TombstoneReason = tombstoneReason.String
-		n.SupersededBy = supersededBy.String
-		if pinnedAt.Valid {
-			n.PinnedAt = &pinnedAt.Int64
+		n, err := scanNode(rows)
+		if err != nil {
+			return nil, err
 		}
 		nodes = append(nodes, n)
 	}
 	return nodes, rows.Err()
 }
+
+// scanNode scans one row of the standard node column list, followed by any
+// extra columns into extra.
+func scanNode(rows *sql.Rows, extra ...any) (MemNode, error) {
+	var n MemNode
+	var mergeable int
+	var lastAccess, tombstonedAt, pinnedAt, relevanceSetAt sql.NullInt64
+	var parentURI, l0, l1, l2, mergedFrom, sourceSession, tombstoneReason, supersededBy, origin sql.NullString
+	dest := []any{&n.ID, &n.URI, &parentURI, &n.NodeType, &n.Category,
+		&l0, &l1, &l2,
+		&mergeable, &mergedFrom, &n.Relevance, &lastAccess, &n.AccessCount,
+		&sourceSession, &n.CreatedAt, &n.UpdatedAt,
+		&tombstonedAt, &tombstoneReason, &supersededBy, &pinnedAt, &relevanceSetAt, &origin}
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return n, fmt.Errorf("scan node: %w", err)
+	}
+	n.ParentURI = parentURI.String
+	n.L0Abstract = l0.String
+	n.L1Overview = l1.String
+	n.L2Content = l2.String
+	n.MergedFrom = mergedFrom.String
+	n.SourceSession = sourceSession.String
+	n.Mergeable = mergeable != 0
+	if lastAccess.Valid {
+		n.LastAccess = &lastAccess.Int64
+	}
+	if tombstonedAt.Valid {
+		n.TombstonedAt = &tombstonedAt.Int64
+	}
+	n.TombstoneReason = tombstoneReason.String
+	n.SupersededBy = supersededBy.String
+	n.Origin = origin.String
+	if pinnedAt.Valid {
+		n.PinnedAt = &pinnedAt.Int64
+	}
+	if relevanceSetAt.Valid {
+		n.RelevanceSetAt = &relevanceSetAt.Int64
+	}
+	return n, nil
+}
@@ -2,7 +2,13 @@ package store
 
 import (
 	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestCreateNode(t *testing.T) {
@@ -111,6 +117,73 @@ func TestUpdateNode(t *testing.T) {
 	}
 }
 
+func TestGetChildrenOrder(t *testing.T) {
+	db := testDB(t)
+	for _, slug := range []string{"alpha", "bravo", "charlie"} {
+		seedNode(t, db, "mem://agent/patterns/"+slug, "patterns", slug)
+	}
+	db.SetRelevance("mem://agent/patterns/alpha", 0.2)
+	db.SetRelevance("mem://agent/patterns/bravo", 0.5)
+	db.Exec(`UPDATE mem_nodes SET updated_at = 5 WHERE uri = 'mem://agent/patterns/charlie'`)
+
+	slugs := func(order ChildOrder) string {
+		children, err := db.GetChildren("mem://agent/patterns", order)
+		if err != nil {
+			t.Fatalf("GetChildren(%s): %v", order, err)
+		}
+		var out []string
+		for _, c := range children {
+			out = append(out, c.L0Abstract)
+		}
+		return strings.Join(out, ",")
+	}
+	if got := slugs(ChildOrderURI); got != "alpha,bravo,charlie" {
+		t.Errorf("GetChildren(uri) = %s", got)
+	}
+	if got := slugs(ChildOrderRelevance); got != "charlie,bravo,alpha" {
+		t.Errorf("GetChildren(relevance) = %s, want charlie,bravo,alpha", got)
+	}
+	if got := slugs(ChildOrderUpdated); !strings.HasSuffix(got, ",charlie") {
+		t.Errorf("GetChildren(updated) = %s, want the stalest (charlie) last", got)
+	}
+
+	if o, err := ParseChildOrder("Relevance"); err != nil || o != ChildOrderRelevance {
+		t.Errorf("ParseChildOrder(Relevance) = %q, %v", o, err)
+	}
+	if _, err := ParseChildOrder("size"); err == nil {
+		t.Error("ParseChildOrder(size): want an error")
+	}
+}
+
+func TestDropL2(t *testing.T) {
+	db := testDB(t)
+	db.DropL2 = true
+
+	node := &MemNode{
+		URI: "mem://user/profile/coding-style", NodeType: "leaf", Category: "profile",
+		L0Abstract: "Tabs", L1Overview: "Prefers tabs", L2Content: "The full story of tabs",
+	}
+	if err := db.CreateNode(node); err != nil {
+		t.Fatalf("CreateNode: %v", err)
+	}
+	node.L0Abstract = "Tabs, width 4"
+	node.L1Overview = "Prefers tabs, four wide"
+	if err := db.UpsertNode(node); err != nil {
+		t.Fatalf("UpsertNode: %v", err)
+	}
+	if err := db.UpdateNode(node); err != nil {
+		t.Fatalf("UpdateNode: %v", err)
+	}
+
+	found, _ := db.GetNodeByURI(node.URI)
+	if found.L2Content != "" {
+		t.Errorf("l2_content = %q, want it dropped", found.L2Content)
+	}
+	if found.L1Overview != "Prefers tabs, four wide" {
+		t.Errorf("l1_overview = %q, want the upserted overview", found.L1Overview)
+	}
+}
+
 func TestUpsertNodeMergeable(t *testing.T) {
 	db := testDB(t)
 
@@ -173,6 +246,119 @@ func TestUpsertNodeImmutable(t *testing.T) {
 	}
 }
 
+func TestUpsertNodeMergeOverride(t *testing.T) {
+	db := testDB(t)
+	yes, no := true, false
+
+	// An event marked mergeable is stored so, and later writes without an
+	// override still merge into it.
+	if err := db.UpsertNode(&MemNode{URI: "mem://user/events/migration", NodeType: "leaf", Category: "events", L0Abstract: "Migration started", MergeOverride: &yes}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpsertNode(&MemNode{URI: "mem://user/events/migration", NodeType: "leaf", Category: "events", L0Abstract: "Migration finished"}); err != nil {
+		t.Fatal(err)
+	}
+	events, _ := db.FindByCategory("events")
+	if len(events) != 1 || !events[0].Mergeable || events[0].L0Abstract != "Migration finished" {
+		t.Errorf("events = %+v, want one mergeable node updated in place", events)
+	}
+
+	// A preference frozen at creation keeps each write as a new version.
+	for _, l0 := range []string{"Uses vim", "Uses helix"} {
+		if err := db.UpsertNode(&MemNode{URI: "mem://user/preferences/editor", NodeType: "leaf", Category: "preferences", L0Abstract: l0, MergeOverride: &no}); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * time.Millisecond) // distinct suffixes
+	}
+	prefs, _ := db.FindByCategory("preferences")
+	if len(prefs) != 2 {
+		t.Fatalf("expected 2 preference versions, got %d", len(prefs))
+	}
+	for _, n := range prefs {
+		if n.Mergeable {
+			t.Errorf("%s stored mergeable despite the override", n.URI)
+		}
+	}
+
+	// An override can't make an existing immutable memory overwritable.
+	if err := db.UpsertNode(&MemNode{URI: "mem://user/events/launch", NodeType: "leaf", Category: "events", L0Abstract: "Launched v1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpsertNode(&MemNode{URI: "mem://user/events/launch", NodeType: "leaf", Category: "events", L0Abstract: "Rewritten", MergeOverride: &yes}); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := db.GetNodeByURI("mem://user/events/launch"); n == nil || n.L0Abstract != "Launched v1" || n.Mergeable {
+		t.Errorf("immutable event = %+v, want it untouched", n)
+	}
+}
+
+func TestPruneSlugVersions(t *testing.T) {
+	db := testDB(t)
+
+	var uris []string
+	for i := 0; i < 4; i++ {
+		n := &MemNode{URI: "mem://user/events/deployed-v2", NodeType: "leaf", Category: "events", L0Abstract: fmt.Sprintf("Deployed v2 (%d)", i)}
+		if err := db.UpsertNode(n); err != nil {
+			t.Fatal(err)
+		}
+		uris = append(uris, n.URI)
+		time.Sleep(2 * time.Millisecond) // distinct suffixes
+	}
+	// Lookalikes that aren't versions of the slug.
+	db.CreateNode(&MemNode{URI: "mem://user/events/deployed-v2-2024", NodeType: "leaf", Category: "events", L0Abstract: "other"})
+	db.CreateNode(&MemNode{URI: "mem://user/events/deployed-v20", NodeType: "leaf", Category: "events", L0Abstract: "other"})
+
+	if n, err := db.PruneSlugVersions("deployed-v2", "events", 0); err != nil || n != 0 {
+		t.Fatalf("keep 0 (unlimited): pruned %d, %v", n, err)
+	}
+	n, err := db.PruneSlugVersions("deployed-v2", "events", 2)
+	if err != nil || n != 2 {
+		t.Fatalf("keep 2: pruned %d, %v; want 2", n, err)
+	}
+	for i, uri := range uris {
+		got, _ := db.GetNodeByURI(uri)
+		if want := i >= 2; (got != nil) != want {
+			t.Errorf("version %d (%s): present = %v, want %v", i, uri, got != nil, want)
+		}
+	}
+	if nodes, _ := db.FindByCategory("events"); len(nodes) != 4 {
+		t.Errorf("expected the 2 kept versions and 2 lookalikes, got %d nodes", len(nodes))
+	}
+}
+
+func TestEvictOverCap(t *testing.T) {
+	db := testDB(t)
+	for _, slug := range []string{"stale", "used", "pinned", "fresh", "new"} {
+		if err := db.CreateNode(&MemNode{URI: "mem://user/preferences/" + slug, NodeType: "leaf", Category: "preferences", L0Abstract: slug}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Exec(`UPDATE mem_nodes SET relevance = 0.2 WHERE uri IN ('mem://user/preferences/stale', 'mem://user/preferences/used', 'mem://user/preferences/pinned', 'mem://user/preferences/new')`)
+	db.Exec(`UPDATE mem_nodes SET access_count = 8 WHERE uri = 'mem://user/preferences/used'`)
+	if _, err := db.PinNode("mem://user/preferences/pinned"); err != nil {
+		t.Fatal(err)
+	}
+
+	if evicted, err := db.EvictOverCap("preferences", 0, ""); err != nil || evicted != nil {
+		t.Fatalf("limit 0 (unlimited): evicted %v, %v", evicted, err)
+	}
+	// Four unpinned leaves against a cap of 2. Stale and new score lowest
+	// (0.2), but new was just written and is spared; next is used, whose
+	// accesses lift it to 0.8 — still below fresh's 1.0.
+	evicted, err := db.EvictOverCap("preferences", 2, "mem://user/preferences/new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"mem://user/preferences/stale", "mem://user/preferences/used"}; !slices.Equal(evicted, want) {
+		t.Errorf("evicted %v, want %v", evicted, want)
+	}
+	for _, slug := range []string{"pinned", "fresh", "new"} {
+		if n, _ := db.GetNodeByURI("mem://user/preferences/" + slug); n == nil {
+			t.Errorf("%s should survive the cap", slug)
+		}
+	}
+}
+
 func TestFindByCategory(t *testing.T) {
 	db := testDB(t)
 
@@ -325,6 +511,128 @@ func TestDecaySkipsMoments(t *testing.T) {
 	}
 }
 
+func TestDecayAllNodesReport(t *testing.T) {
+	db := testDB(t)
+	day := int64(24 * 60 * 60 * 1000)
+	now := time.Now().UnixMilli()
+	for uri, age := range map[string]int64{
+		"mem://user/events/recent":  30 * day,   // 0.5^(30/90) ≈ 0.79
+		"mem://user/events/old":     150 * day,  // ≈ 0.31, still ranked
+		"mem://user/events/older":   200 * day,  // ≈ 0.21, drops out of context
+		"mem://user/events/ancient": 1000 * day, // floored
+	} {
+		db.CreateNode(&MemNode{URI: uri, NodeType: "leaf", Category: "events"})
+		db.Exec(`UPDATE mem_nodes SET created_at = ?, last_access = NULL WHERE uri = ?`, now-age, uri)
+	}
+	db.CreateNode(&MemNode{URI: "mem://user/events/fresh", NodeType: "leaf", Category: "events"})
+
+	r, err := db.DecayAllNodesReport()
+	if err != nil {
+		t.Fatalf("DecayAllNodesReport: %v", err)
+	}
+	if r.Considered != 5 || r.Updated != 4 {
+		t.Errorf("considered/updated = %d/%d, want 5/4", r.Considered, r.Updated)
+	}
+	if r.BelowContext != 2 {
+		t.Errorf("BelowContext = %d, want 2 (older, ancient)", r.BelowContext)
+	}
+	if r.AtFloor != 1 {
+		t.Errorf("AtFloor = %d, want 1 (ancient)", r.AtFloor)
+	}
+	want := map[int]int{0: 1, 1: 1, 2: 1, 6: 1} // bins from 0.1: floor, 0.2x, 0.3x, 0.7x
+	total := 0
+	for i, bin := range r.Distribution {
+		total += bin.Count
+		if bin.Count != want[i] {
+			t.Errorf("bin %d [%.1f-%.1f) = %d, want %d", i, bin.Lo, bin.Hi, bin.Count, want[i])
+		}
+	}
+	if total != r.Updated {
+		t.Errorf("distribution total = %d, want %d", total, r.Updated)
+	}
+	if s := r.String(); !strings.Contains(s, "updated 4 of 5 nodes") || !strings.Contains(s, "2 fell below") {
+		t.Errorf("String() = %q", s)
+	}
+
+	// A second pass doesn't count memories that were already below the
+	// threshold or already at the floor.
+	if r, _ := db.DecayAllNodesReport(); r.BelowContext != 0 || r.AtFloor != 0 {
+		t.Errorf("second run = %s, want no new crossings", r)
+	}
+}
+
+// TestDecayConcurrentWithTouch runs decay and retrievals against the same
+// nodes at once. Decay computes from a snapshot; a touch landing between its
+// read and write must not be overwritten by a value computed from the stale,
+// never-accessed state.
+func TestDecayConcurrentWithTouch(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "race.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	// One connection: statements from both sides interleave one at a time,
+	// which is exactly the window under test, without lock-contention errors.
+	db.SetMaxOpenConns(1)
+
+	const nodes, touches = 40, 5
+	uris := make([]string, nodes)
+	for i := range uris {
+		uris[i] = fmt.Sprintf("mem://user/events/n%d", i)
+		if err := db.CreateNode(&MemNode{URI: uris[i], NodeType: "leaf", Category: "events"}); err != nil {
+			t.Fatalf("CreateNode: %v", err)
+		}
+	}
+	createdAt := time.Now().Add(-200 * 24 * time.Hour).UnixMilli()
+	db.Exec(`UPDATE mem_nodes SET created_at = ?, relevance = 1.0, last_access = NULL WHERE node_type = 'leaf'`, createdAt)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < touches; i++ {
+				for _, uri := range uris {
+					if err := db.TouchNode(uri); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			if _, err := db.DecayAllNodes(); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("concurrent touch/decay: %v", err)
+	}
+
+	now := time.Now().UnixMilli()
+	for _, uri := range uris {
+		n, _ := db.GetNodeByURI(uri)
+		if n.AccessCount != 4*touches {
+			t.Errorf("%s access_count = %d, want %d", uri, n.AccessCount, 4*touches)
+		}
+		// Whatever the interleaving, the node ends at least where decay puts
+		// a node last retrieved at its last_access.
+		floor := db.decayParams().relevanceAt(n.CreatedAt, n.LastAccess, now)
+		if n.Relevance < floor {
+			t.Errorf("%s relevance = %.4f, below %.4f for its last access: a touch was overwritten by decay", uri, n.Relevance, floor)
+		}
+	}
+}
+
 func TestFindByCategoryMoments(t *testing.T) {
 	db := testDB(t)
 
@@ -384,3 +692,68 @@ func TestUpsertNode_RefusesRetractedTarget(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateNodeRejectsDeepURI(t *testing.T) {
+	db := testDB(t)
+	db.MaxURIDepth = 4
+
+	ok := &MemNode{URI: "mem://user/profile/area/topic", NodeType: "leaf", Category: "profile", L0Abstract: "four deep"}
+	if err := db.CreateNode(ok); err != nil {
+		t.Fatalf("4-segment URI at limit 4: %v", err)
+	}
+
+	deep := &MemNode{URI: "mem://user/profile/a/b/c", NodeType: "leaf", Category: "profile", L0Abstract: "five deep"}
+	err := db.CreateNode(deep)
+	if !errors.Is(err, ErrURITooDeep) {
+		t.Fatalf("CreateNode(5 segments) = %v, want ErrURITooDeep", err)
+	}
+	// Rejected before any parent dir was created.
+	if n, _ := db.GetNodeByURI("mem://user/profile/a"); n != nil {
+		t.Error("rejected URI left a parent dir behind")
+	}
+
+	db.MaxURIDepth = 0 // falls back to DefaultMaxURIDepth
+	if err := db.CheckURIDepth("mem://user/profile/a/b/c"); err != nil {
+		t.Errorf("5 segments under default %d: %v", DefaultMaxURIDepth, err)
+	}
+	if err := db.CheckURIDepth("mem://a/b/c/d/e/f/g"); !errors.Is(err, ErrURITooDeep) {
+		t.Errorf("7 segments under default: %v, want ErrURITooDeep", err)
+	}
+}
+
+func TestDeleteNodes(t *testing.T) {
+	db := testDB(t)
+	a := seedNode(t, db, "mem://user/events/a", "events", "event a")
+	b := seedNode(t, db, "mem://user/events/b", "events", "event b")
+	keep := seedNode(t, db, "mem://user/profile/keep", "profile", "kept")
+	for _, n := range []*MemNode{a, b, keep} {
+		if err := db.SaveVector(n.ID, []float64{1, 0}, "test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := db.DeleteNodes([]int64{a.ID, b.ID}); err != nil {
+		t.Fatalf("DeleteNodes: %v", err)
+	}
+	for _, n := range []*MemNode{a, b} {
+		if got, _ := db.GetNodeByID(n.ID); got != nil {
+			t.Errorf("%s still present", n.URI)
+		}
+		if v, _ := db.GetVector(n.ID); v != nil {
+			t.Errorf("%s vector still present", n.URI)
+		}
+	}
+	if dir, _ := db.GetNodeByURI("mem://user/events"); dir != nil {
+		t.Error("emptied mem://user/events dir was not swept")
+	}
+	if dir, _ := db.GetNodeByURI("mem://user"); dir == nil {
+		t.Error("mem://user still has children and must survive")
+	}
+	if v, _ := db.GetVector(keep.ID); v == nil {
+		t.Error("unrelated vector deleted")
+	}
+
+	if err := db.DeleteNodes(nil); err != nil {
+		t.Errorf("DeleteNodes(nil) = %v", err)
+	}
+}
@@ -21,7 +21,10 @@ type Observation struct {
 }
 
 // AddObservation stores a tool use observation. Truncates large fields to prevent DB bloat.
-func (db *DB) AddObservation(sessionID, toolName, toolInput, toolResponse string) error {
+// toolUseID, when non-empty, is an idempotency key: a second write with the
+// same ID (a hook retrying after a dropped response) is ignored and added is
+// false.
+func (db *DB) AddObservation(sessionID, toolUseID, toolName, toolInput, toolResponse string) (added bool, err error) {
 	if len(toolInput) > maxToolFieldSize {
 		log.Printf("observation: tool_input truncated for session %s: %d → %d bytes", sessionID, len(toolInput), maxToolFieldSize)
 		toolInput = toolInput[:maxToolFieldSize]
@@ -31,15 +34,23 @@ func (db *DB) AddObservation(sessionID, toolName, toolInput, toolResponse string
 		toolResponse = toolResponse[:maxToolFieldSize]
 	}
 
+	var id any
+	if toolUseID != "" {
+		id = toolUseID
+	}
 	now := time.Now().UnixMilli()
-	_, err := db.Exec(`
-		INSERT INTO observations (session_id, tool_name, tool_input, tool_response, created_at)
-		VALUES (?, ?, ?, ?, ?)
-	`, sessionID, toolName, toolInput, toolResponse, now)
+	res, err := db.Exec(`
+		INSERT OR IGNORE INTO observations (session_id, tool_use_id, tool_name, tool_input, tool_response, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, sessionID, id, toolName, toolInput, toolResponse, now)
+	if err != nil {
+		return false, fmt.Errorf("add observation: %w", err)
+	}
+	n, err := res.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("add observation: %w", err)
+		return false, fmt.Errorf("add observation: %w", err)
 	}
-	return nil
+	return n > 0, nil
 }
 
 // GetObservations returns all observations for a session, ordered by created_at.
@@ -86,6 +97,25 @@ func (db *DB) GetRecentObservations(limit int) ([]Observation, error) {
 	return obs, rows.Err()
 }
 
+// PruneObservations deletes observations created before olderThan (Unix ms)
+// whose session has been extracted. Rows of unextracted sessions stay, however
+// old, so a deferred extraction still has them. Returns the rows deleted.
+func (db *DB) PruneObservations(olderThan int64) (int, error) {
+	res, err := db.Exec(`
+		DELETE FROM observations
+		WHERE created_at < ?
+			AND session_id IN (SELECT session_key FROM sessions WHERE extracted_at IS NOT NULL)
+	`, olderThan)
+	if err != nil {
+		return 0, fmt.Errorf("prune observations: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("prune observations: %w", err)
+	}
+	return int(n), nil
+}
+
 // GetSessionObservationCount returns the number of observations for a session.
 func (db *DB) GetSessionObservationCount(sessionID string) (int, error) {
 	var count int
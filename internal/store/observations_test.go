@@ -12,7 +12,7 @@ func TestAddObservation(t *testing.T) {
 	}
 	defer db.Close()
 
-	err = db.AddObservation("sess-001", "Bash", `{"command":"ls"}`, "file1 file2")
+	_, err = db.AddObservation("sess-001", "", "Bash", `{"command":"ls"}`, "file1 file2")
 	if err != nil {
 		t.Fatalf("AddObservation: %v", err)
 	}
@@ -35,6 +35,35 @@ func TestAddObservation(t *testing.T) {
 	}
 }
 
+func TestAddObservationIdempotent(t *testing.T) {
+	db, err := OpenMemory()
+	if err != nil {
+		t.Fatalf("OpenMemory: %v", err)
+	}
+	defer db.Close()
+
+	for i, want := range []bool{true, false} {
+		added, err := db.AddObservation("sess-001", "toolu_01", "Bash", "{}", "out")
+		if err != nil {
+			t.Fatalf("AddObservation #%d: %v", i+1, err)
+		}
+		if added != want {
+			t.Errorf("AddObservation #%d added = %v, want %v", i+1, added, want)
+		}
+	}
+	// Writers without an ID are never deduplicated.
+	for i := 0; i < 2; i++ {
+		if added, err := db.AddObservation("sess-001", "", "Bash", "{}", "out"); err != nil || !added {
+			t.Errorf("AddObservation without ID = %v, %v; want added", added, err)
+		}
+	}
+
+	obs, _ := db.GetObservations("sess-001")
+	if len(obs) != 3 {
+		t.Errorf("got %d observations, want 3", len(obs))
+	}
+}
+
 func TestAddObservationTruncation(t *testing.T) {
 	db, err := OpenMemory()
 	if err != nil {
@@ -44,7 +73,7 @@ func TestAddObservationTruncation(t *testing.T) {
 
 	bigInput := strings.Repeat("i", 20*1024)    // 20KB
 	bigResponse := strings.Repeat("r", 20*1024)  // 20KB
-	err = db.AddObservation("sess-001", "Bash", bigInput, bigResponse)
+	_, err = db.AddObservation("sess-001", "", "Bash", bigInput, bigResponse)
 	if err != nil {
 		t.Fatalf("AddObservation: %v", err)
 	}
@@ -81,9 +110,9 @@ func TestGetRecentObservations(t *testing.T) {
 	}
 	defer db.Close()
 
-	db.AddObservation("sess-001", "Bash", "{}", "out1")
-	db.AddObservation("sess-001", "Read", "{}", "out2")
-	db.AddObservation("sess-002", "Edit", "{}", "out3")
+	db.AddObservation("sess-001", "", "Bash", "{}", "out1")
+	db.AddObservation("sess-001", "", "Read", "{}", "out2")
+	db.AddObservation("sess-002", "", "Edit", "{}", "out3")
 
 	obs, err := db.GetRecentObservations(2)
 	if err != nil {
@@ -103,9 +132,9 @@ func TestGetSessionObservationCount(t *testing.T) {
 	}
 	defer db.Close()
 
-	db.AddObservation("sess-001", "Bash", "{}", "out1")
-	db.AddObservation("sess-001", "Read", "{}", "out2")
-	db.AddObservation("sess-002", "Edit", "{}", "out3")
+	db.AddObservation("sess-001", "", "Bash", "{}", "out1")
+	db.AddObservation("sess-001", "", "Read", "{}", "out2")
+	db.AddObservation("sess-002", "", "Edit", "{}", "out3")
 
 	count, err := db.GetSessionObservationCount("sess-001")
 	if err != nil {
@@ -115,3 +144,37 @@ func TestGetSessionObservationCount(t *testing.T) {
 		t.Errorf("count = %d, want 2", count)
 	}
 }
+
+func TestPruneObservations(t *testing.T) {
+	db := testDB(t)
+	for _, sid := range []string{"done", "pending"} {
+		if _, err := db.InitSession(sid, "proj"); err != nil {
+			t.Fatal(err)
+		}
+		for _, tool := range []string{"Old", "New"} {
+			if _, err := db.AddObservation(sid, "", tool, "{}", "ok"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := db.MarkExtracted("done"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE observations SET created_at = 1000 WHERE tool_name = 'Old'`); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := db.PruneObservations(2000)
+	if err != nil {
+		t.Fatalf("PruneObservations: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("pruned %d, want 1 (only the extracted session's old row)", n)
+	}
+	if c, _ := db.GetSessionObservationCount("done"); c != 1 {
+		t.Errorf("extracted session kept %d observations, want 1", c)
+	}
+	if c, _ := db.GetSessionObservationCount("pending"); c != 2 {
+		t.Errorf("unextracted session kept %d observations, want 2", c)
+	}
+}
@@ -59,16 +59,17 @@ func (db *DB) CountLivePins() (int, error) {
 // nodes (a retraction is an unlearning; pinning retracted content would be a
 // contradiction, and the read paths exclude it anyway — fail closed at write).
 func (db *DB) PinNode(uri string) (newly bool, err error) {
+	defer db.invalidateNodes(uri)
 	if uri == "" {
 		return false, pinValidationErrorf("uri required")
 	}
 
-	target, err := db.GetNodeByURI(uri)
+	target, err := db.getNodeByURI(uri)
 	if err != nil {
 		return false, fmt.Errorf("look up target: %w", err)
 	}
 	if target == nil {
-		return false, pinValidationErrorf("memory not found: %s", uri)
+		return false, withKind(ErrNotFound, pinValidationErrorf("memory not found: %s", uri))
 	}
 	if target.NodeType != "leaf" {
 		return false, pinValidationErrorf("cannot pin %s node: %s (only leaf memories are pinnable)", target.NodeType, uri)
@@ -119,16 +120,17 @@ func (db *DB) PinNode(uri string) (newly bool, err error) {
 // unpin; false when the memory was not pinned (idempotent). A node that does not
 // exist is reported as a validation error (the operator named a URI that isn't there).
 func (db *DB) UnpinNode(uri string) (newly bool, err error) {
+	defer db.invalidateNodes(uri)
 	if uri == "" {
 		return false, pinValidationErrorf("uri required")
 	}
 
-	target, err := db.GetNodeByURI(uri)
+	target, err := db.getNodeByURI(uri)
 	if err != nil {
 		return false, fmt.Errorf("look up target: %w", err)
 	}
 	if target == nil {
-		return false, pinValidationErrorf("memory not found: %s", uri)
+		return false, withKind(ErrNotFound, pinValidationErrorf("memory not found: %s", uri))
 	}
 	if !target.IsPinned() {
 		return false, nil
@@ -154,7 +156,7 @@ func (db *DB) ListPinned() ([]MemNode, error) {
 	rows, err := db.Query(`
 		SELECT id, uri, parent_uri, node_type, category, l0_abstract, l1_overview, l2_content,
 			mergeable, merged_from, relevance, last_access, access_count, source_session, created_at, updated_at,
-			tombstoned_at, tombstone_reason, superseded_by, pinned_at
+			tombstoned_at, tombstone_reason, superseded_by, pinned_at, relevance_set_at, origin
 		FROM mem_nodes
 		WHERE pinned_at IS NOT NULL AND tombstoned_at IS NULL AND node_type = 'leaf'
 		ORDER BY pinned_at ASC
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -192,6 +193,6 @@ func TestPinNode_EnforcesCap(t *testing.T) {
 }
 
 func isPinValidation(err error) bool {
-	_, ok := err.(*PinValidationError)
-	return ok
+	var pve *PinValidationError
+	return errors.As(err, &pve)
 }
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// RelevanceValidationError signals that a relevance override was rejected for
+// a user/domain reason (memory not found, directory, retracted). Like
+// PinValidationError, its Message is safe to surface verbatim.
+type RelevanceValidationError struct {
+	Message string
+}
+
+func (e *RelevanceValidationError) Error() string {
+	return e.Message
+}
+
+func relevanceValidationErrorf(format string, args ...any) error {
+	return &RelevanceValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+// SetRelevance hand-sets a memory's relevance, clamped to [0, 1], and marks
+// it as set so decay and access boosts leave it alone: a demoted memory stays
+// demoted when it's next retrieved, a boosted one doesn't fade. Returns the
+// relevance actually stored. ClearRelevance hands it back to decay.
+func (db *DB) SetRelevance(uri string, relevance float64) (float64, error) {
+	defer db.invalidateNodes(uri)
+	relevance = min(max(relevance, 0), 1)
+	if err := db.checkRelevanceTarget(uri); err != nil {
+		return 0, err
+	}
+	now := time.Now().UnixMilli()
+	res, err := db.Exec(`
+		UPDATE mem_nodes SET relevance = ?, relevance_set_at = ?
+		WHERE uri = ? AND tombstoned_at IS NULL
+	`, relevance, now, uri)
+	if err != nil {
+		return 0, fmt.Errorf("set relevance: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, relevanceValidationErrorf("cannot set relevance of retracted memory: %s", uri)
+	}
+	return relevance, nil
+}
+
+// ClearRelevance drops a hand-set relevance override. The stored value stays
+// until the next decay run or retrieval recomputes it. Returns false when
+// there was no override (idempotent).
+func (db *DB) ClearRelevance(uri string) (bool, error) {
+	defer db.invalidateNodes(uri)
+	if err := db.checkRelevanceTarget(uri); err != nil {
+		return false, err
+	}
+	res, err := db.Exec(`
+		UPDATE mem_nodes SET relevance_set_at = NULL
+		WHERE uri = ? AND relevance_set_at IS NOT NULL
+	`, uri)
+	if err != nil {
+		return false, fmt.Errorf("clear relevance: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// checkRelevanceTarget refuses anything but a live leaf memory.
+func (db *DB) checkRelevanceTarget(uri string) error {
+	if uri == "" {
+		return relevanceValidationErrorf("uri required")
+	}
+	target, err := db.getNodeByURI(uri)
+	if err != nil {
+		return fmt.Errorf("look up target: %w", err)
+	}
+	if target == nil {
+		return withKind(ErrNotFound, relevanceValidationErrorf("memory not found: %s", uri))
+	}
+	if target.NodeType != "leaf" {
+		return relevanceValidationErrorf("cannot set relevance of %s node: %s (only leaf memories)", target.NodeType, uri)
+	}
+	if target.IsRetracted() {
+		return relevanceValidationErrorf("cannot set relevance of retracted memory: %s", uri)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSetRelevanceSurvivesDecayAndTouch(t *testing.T) {
+	db := testDB(t)
+	uri := "mem://user/preferences/old-editor"
+	if err := db.CreateNode(&MemNode{URI: uri, NodeType: "leaf", Category: "preferences", L0Abstract: "Used emacs"}); err != nil {
+		t.Fatal(err)
+	}
+	// Age it so decay would otherwise apply.
+	old := time.Now().Add(-200 * 24 * time.Hour).UnixMilli()
+	if _, err := db.Exec(`UPDATE mem_nodes SET created_at = ?, last_access = ? WHERE uri = ?`, old, old, uri); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := db.SetRelevance(uri, 1.7)
+	if err != nil {
+		t.Fatalf("SetRelevance: %v", err)
+	}
+	if got != 1 {
+		t.Errorf("stored %v, want clamped to 1", got)
+	}
+	if _, err := db.DecayAllNodes(); err != nil {
+		t.Fatal(err)
+	}
+	n, _ := db.GetNodeByURI(uri)
+	if n.Relevance != 1 || !n.IsRelevanceSet() {
+		t.Errorf("after decay: relevance %v set=%v, want 1 and hand-set", n.Relevance, n.IsRelevanceSet())
+	}
+
+	if _, err := db.SetRelevance(uri, 0.2); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.TouchNode(uri); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := db.GetNodeByURI(uri); n.Relevance != 0.2 {
+		t.Errorf("after touch: relevance %v, want the demotion to hold", n.Relevance)
+	}
+
+	if cleared, err := db.ClearRelevance(uri); err != nil || !cleared {
+		t.Fatalf("ClearRelevance = %v, %v", cleared, err)
+	}
+	if cleared, _ := db.ClearRelevance(uri); cleared {
+		t.Error("second clear should report nothing to clear")
+	}
+	if err := db.TouchNode(uri); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := db.GetNodeByURI(uri); n.Relevance <= 0.2 || n.IsRelevanceSet() {
+		t.Errorf("after clear + touch: relevance %v set=%v, want automatic boost", n.Relevance, n.IsRelevanceSet())
+	}
+}
+
+func TestSetRelevanceRejects(t *testing.T) {
+	db := testDB(t)
+	uri := "mem://user/preferences/gone"
+	if err := db.CreateNode(&MemNode{URI: uri, NodeType: "leaf", Category: "preferences", L0Abstract: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.RetractNode(uri, "wrong", ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{"mem://user/preferences/missing", "mem://user/preferences", uri} {
+		var rve *RelevanceValidationError
+		if _, err := db.SetRelevance(target, 0.5); !errors.As(err, &rve) {
+			t.Errorf("SetRelevance(%s) = %v, want a RelevanceValidationError", target, err)
+		}
+	}
+}
+
+func TestAccessDoesNotResetAge(t *testing.T) {
+	db := testDB(t)
+	uri := "mem://user/events/old-migration"
+	if err := db.CreateNode(&MemNode{URI: uri, NodeType: "leaf", Category: "events", L0Abstract: "Migrated to Postgres"}); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-365 * 24 * time.Hour).UnixMilli()
+	if _, err := db.Exec(`UPDATE mem_nodes SET created_at = ?, relevance = 0.1 WHERE uri = ?`, old, uri); err != nil {
+		t.Fatal(err)
+	}
+
+	// One retrieval lifts a year-old memory by the bonus, not to the top.
+	if err := db.TouchNode(uri); err != nil {
+		t.Fatal(err)
+	}
+	n, _ := db.GetNodeByURI(uri)
+	if n.Relevance < 0.35 || n.Relevance > 0.45 {
+		t.Errorf("after touch: relevance %v, want about 0.1 floor-level age plus the 0.3 bonus", n.Relevance)
+	}
+
+	// Once the bonus has worn off, decay takes it back down.
+	later := time.Now().Add(-60 * 24 * time.Hour).UnixMilli()
+	if _, err := db.Exec(`UPDATE mem_nodes SET last_access = ? WHERE uri = ?`, later, uri); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.DecayAllNodes(); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := db.GetNodeByURI(uri); n.Relevance > 0.15 {
+		t.Errorf("after decay: relevance %v, want near the floor once the bonus has faded", n.Relevance)
+	}
+
+	// Without a bonus, a touch leaves the age-based relevance alone.
+	if err := db.SetDecayParams(DecayParams{HalfLife: 90 * 24 * time.Hour, AccessHalfLife: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := db.GetNodeByURI(uri)
+	if err := db.TouchNode(uri); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := db.GetNodeByURI(uri); n.Relevance != before.Relevance {
+		t.Errorf("touch with no access bonus: relevance %v → %v", before.Relevance, n.Relevance)
+	}
+	if err := db.SetDecayParams(DecayParams{HalfLife: 0, AccessHalfLife: time.Hour}); err == nil {
+		t.Error("a zero half-life should be rejected")
+	}
+}
@@ -57,6 +57,7 @@ func retractValidationErrorf(format string, args ...any) error {
 // (see systemOwnedURIs) — both shapes have semantics that retraction would
 // silently corrupt.
 func (db *DB) RetractNode(uri, reason, supersededBy string) (newly bool, err error) {
+	defer db.invalidateNodes(uri)
 	if uri == "" {
 		return false, retractValidationErrorf("uri required")
 	}
@@ -67,12 +68,12 @@ func (db *DB) RetractNode(uri, reason, supersededBy string) (newly bool, err err
 		return false, retractValidationErrorf("system-owned: %s cannot be retracted via the public verb", uri)
 	}
 
-	target, err := db.GetNodeByURI(uri)
+	target, err := db.getNodeByURI(uri)
 	if err != nil {
 		return false, fmt.Errorf("look up target: %w", err)
 	}
 	if target == nil {
-		return false, retractValidationErrorf("memory not found: %s", uri)
+		return false, withKind(ErrNotFound, retractValidationErrorf("memory not found: %s", uri))
 	}
 	if target.NodeType != "leaf" {
 		return false, retractValidationErrorf("cannot retract %s node: %s (only leaf memories are retractable)", target.NodeType, uri)
@@ -86,12 +87,12 @@ func (db *DB) RetractNode(uri, reason, supersededBy string) (newly bool, err err
 		if supersededBy == uri {
 			return false, retractValidationErrorf("self-supersession: %s cannot supersede itself", uri)
 		}
-		successor, err := db.GetNodeByURI(supersededBy)
+		successor, err := db.getNodeByURI(supersededBy)
 		if err != nil {
 			return false, fmt.Errorf("look up successor: %w", err)
 		}
 		if successor == nil {
-			return false, retractValidationErrorf("successor not found: %s", supersededBy)
+			return false, withKind(ErrNotFound, retractValidationErrorf("successor not found: %s", supersededBy))
 		}
 	}
 
@@ -127,7 +128,7 @@ func (db *DB) FindByCategoryIncludingRetracted(category string) ([]MemNode, erro
 	rows, err := db.Query(`
 		SELECT id, uri, parent_uri, node_type, category, l0_abstract, l1_overview, l2_content,
 			mergeable, merged_from, relevance, last_access, access_count, source_session, created_at, updated_at,
-			tombstoned_at, tombstone_reason, superseded_by, pinned_at
+			tombstoned_at, tombstone_reason, superseded_by, pinned_at, relevance_set_at, origin
 		FROM mem_nodes WHERE category = ? AND node_type = 'leaf'
 		ORDER BY relevance DESC
 	`, category)
@@ -144,7 +145,7 @@ func (db *DB) ListLeavesIncludingRetracted() ([]MemNode, error) {
 	rows, err := db.Query(`
 		SELECT id, uri, parent_uri, node_type, category, l0_abstract, l1_overview, l2_content,
 			mergeable, merged_from, relevance, last_access, access_count, source_session, created_at, updated_at,
-			tombstoned_at, tombstone_reason, superseded_by, pinned_at
+			tombstoned_at, tombstone_reason, superseded_by, pinned_at, relevance_set_at, origin
 		FROM mem_nodes WHERE node_type = 'leaf'
 		ORDER BY relevance DESC
 	`)
@@ -156,15 +157,15 @@ func (db *DB) ListLeavesIncludingRetracted() ([]MemNode, error) {
 }
 
 // GetChildrenIncludingRetracted returns all direct children of a parent URI,
-// including retracted ones. For the --include-retracted inspection path.
-func (db *DB) GetChildrenIncludingRetracted(parentURI string) ([]MemNode, error) {
+// including retracted ones, sorted by order. For the --include-retracted
+// inspection path.
+func (db *DB) GetChildrenIncludingRetracted(parentURI string, order ChildOrder) ([]MemNode, error) {
 	rows, err := db.Query(`
 		SELECT id, uri, parent_uri, node_type, category, l0_abstract, l1_overview, l2_content,
 			mergeable, merged_from, relevance, last_access, access_count, source_session, created_at, updated_at,
-			tombstoned_at, tombstone_reason, superseded_by, pinned_at
+			tombstoned_at, tombstone_reason, superseded_by, pinned_at, relevance_set_at, origin
 		FROM mem_nodes WHERE parent_uri = ?
-		ORDER BY uri
-	`, parentURI)
+		ORDER BY `+order.orderBy(), parentURI)
 	if err != nil {
 		return nil, fmt.Errorf("get children (incl retracted): %w", err)
 	}
@@ -303,7 +303,7 @@ func TestReadFilters_DefaultExcludesRetracted(t *testing.T) {
 	})
 
 	t.Run("GetChildren", func(t *testing.T) {
-		children, err := db.GetChildren("mem://user/events")
+		children, err := db.GetChildren("mem://user/events", ChildOrderURI)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -348,7 +348,7 @@ func TestReadFilters_IncludingRetractedReturnsAll(t *testing.T) {
 	})
 
 	t.Run("GetChildrenIncludingRetracted", func(t *testing.T) {
-		children, err := db.GetChildrenIncludingRetracted("mem://user/events")
+		children, err := db.GetChildrenIncludingRetracted("mem://user/events", ChildOrderURI)
 		if err != nil {
 			t.Fatal(err)
 		}
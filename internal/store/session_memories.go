@@ -0,0 +1,93 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// SessionMemory records that a session wrote a memory: Created when the
+// write made it, otherwise an update of one that already existed.
+type SessionMemory struct {
+	SessionID  string `json:"session_id"`
+	URI        string `json:"uri"`
+	Created    bool   `json:"created"`
+	RecordedAt int64  `json:"recorded_at"`
+}
+
+// RecordSessionMemory notes that sessionID wrote uri. Idempotent per pair;
+// a later write never downgrades a recorded creation to an update.
+func (db *DB) RecordSessionMemory(sessionID, uri string, created bool) error {
+	if sessionID == "" || uri == "" {
+		return nil
+	}
+	_, err := db.Exec(`
+		INSERT INTO session_memories (session_id, uri, created, recorded_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (session_id, uri) DO UPDATE SET created = MAX(created, excluded.created)
+	`, sessionID, uri, created, time.Now().UnixMilli())
+	if err != nil {
+		return fmt.Errorf("record session memory: %w", err)
+	}
+	return nil
+}
+
+// sessionMemoryColumns selects records whose memory is still a live leaf.
+// Rows go with a deleted memory; those of a retracted one stay but are never
+// returned.
+const sessionMemoryColumns = `
+	SELECT sm.session_id, sm.uri, sm.created, sm.recorded_at
+	FROM session_memories sm
+	JOIN mem_nodes n ON n.uri = sm.uri AND n.node_type = 'leaf' AND n.tombstoned_at IS NULL
+`
+
+// SessionMemories returns the live memories sessionID wrote, in the order it
+// wrote them.
+func (db *DB) SessionMemories(sessionID string) ([]SessionMemory, error) {
+	return db.querySessionMemories(sessionMemoryColumns+`
+		WHERE sm.session_id = ?
+		ORDER BY sm.recorded_at, sm.uri
+	`, sessionID)
+}
+
+// MemorySessions returns the sessions that wrote uri, oldest first.
+func (db *DB) MemorySessions(uri string) ([]SessionMemory, error) {
+	return db.querySessionMemories(sessionMemoryColumns+`
+		WHERE sm.uri = ?
+		ORDER BY sm.recorded_at, sm.session_id
+	`, uri)
+}
+
+func (db *DB) querySessionMemories(query string, args ...any) ([]SessionMemory, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query session memories: %w", err)
+	}
+	defer rows.Close()
+
+	var out []SessionMemory
+	for rows.Next() {
+		var sm SessionMemory
+		if err := rows.Scan(&sm.SessionID, &sm.URI, &sm.Created, &sm.RecordedAt); err != nil {
+			return nil, fmt.Errorf("scan session memory: %w", err)
+		}
+		out = append(out, sm)
+	}
+	return out, rows.Err()
+}
+
+// repointSessionMemories moves the sessions that wrote node fromID onto node
+// toID, for a merge or dedup. They are recorded as updates of the survivor:
+// none of them created it. A session already recorded on the survivor keeps
+// its own row.
+func repointSessionMemories(tx *Tx, fromID, toID int64) error {
+	for _, q := range []string{
+		`UPDATE OR IGNORE session_memories SET uri = (SELECT uri FROM mem_nodes WHERE id = ?2), created = 0
+			WHERE uri = (SELECT uri FROM mem_nodes WHERE id = ?1)`,
+		`DELETE FROM session_memories WHERE uri = (SELECT uri FROM mem_nodes WHERE id = ?1)`,
+	} {
+		if _, err := tx.Exec(q, fromID, toID); err != nil {
+			return fmt.Errorf("repoint session memories of node %d: %w", fromID, err)
+		}
+	}
+	return nil
+}
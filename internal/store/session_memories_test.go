@@ -0,0 +1,123 @@
+package store
+
+import "testing"
+
+func TestSessionMemories(t *testing.T) {
+	db := testDB(t)
+	a := seedNode(t, db, "mem://user/events/a", "events", "a")
+	b := seedNode(t, db, "mem://user/preferences/b", "preferences", "b")
+
+	for _, r := range []struct {
+		session, uri string
+		created      bool
+	}{
+		{"s1", a.URI, true},
+		{"s1", b.URI, false},
+		{"s1", b.URI, false}, // idempotent
+		{"s2", b.URI, false},
+		{"s2", b.URI, true}, // a creation upgrades the record
+		{"s1", a.URI, false},
+	} {
+		if err := db.RecordSessionMemory(r.session, r.uri, r.created); err != nil {
+			t.Fatalf("RecordSessionMemory: %v", err)
+		}
+	}
+
+	got, err := db.SessionMemories("s1")
+	if err != nil {
+		t.Fatalf("SessionMemories: %v", err)
+	}
+	if len(got) != 2 || got[0].URI != a.URI || !got[0].Created || got[1].URI != b.URI || got[1].Created {
+		t.Errorf("s1 = %+v, want a created then b updated", got)
+	}
+	sessions, _ := db.MemorySessions(b.URI)
+	if len(sessions) != 2 || sessions[0].SessionID != "s1" || sessions[1].SessionID != "s2" || !sessions[1].Created {
+		t.Errorf("sessions of b = %+v, want s1 (updated), s2 (created)", sessions)
+	}
+
+	// Retracted memories drop out of both directions.
+	if _, err := db.RetractNode(a.URI, "wrong", ""); err != nil {
+		t.Fatalf("RetractNode: %v", err)
+	}
+	if got, _ := db.SessionMemories("s1"); len(got) != 1 || got[0].URI != b.URI {
+		t.Errorf("s1 after retraction = %+v, want only b", got)
+	}
+	if got, _ := db.MemorySessions(a.URI); len(got) != 0 {
+		t.Errorf("sessions of retracted a = %+v, want none", got)
+	}
+
+	// Moving a memory carries its provenance.
+	moved, err := db.MoveNode(b.ID, "mem://user/feedback/b", "feedback")
+	if err != nil {
+		t.Fatalf("MoveNode: %v", err)
+	}
+	if got, _ := db.MemorySessions(moved.URI); len(got) != 2 {
+		t.Errorf("sessions of moved b = %+v, want both", got)
+	}
+}
+
+func TestMergeSessionsMovesSessionMemories(t *testing.T) {
+	db := testDB(t)
+	for _, id := range []string{"keep", "merge"} {
+		if _, err := db.InitSession(id, "/proj"); err != nil {
+			t.Fatalf("InitSession: %v", err)
+		}
+	}
+	a := seedNode(t, db, "mem://user/events/a", "events", "a")
+	b := seedNode(t, db, "mem://user/preferences/b", "preferences", "b")
+	db.RecordSessionMemory("keep", b.URI, false)
+	db.RecordSessionMemory("merge", b.URI, true)
+	db.RecordSessionMemory("merge", a.URI, true)
+
+	if _, err := db.MergeSessions("keep", "merge"); err != nil {
+		t.Fatalf("MergeSessions: %v", err)
+	}
+	got, _ := db.SessionMemories("keep")
+	if len(got) != 2 {
+		t.Fatalf("keeper memories = %+v, want a and b", got)
+	}
+	for _, sm := range got {
+		if !sm.Created {
+			t.Errorf("%s created=false, want the merged half's creation kept", sm.URI)
+		}
+	}
+	if got, _ := db.SessionMemories("merge"); len(got) != 0 {
+		t.Errorf("merged session still has memories: %+v", got)
+	}
+}
+
+func TestMergedMemoriesMoveSessionMemories(t *testing.T) {
+	db := testDB(t)
+	keep := seedNode(t, db, "mem://agent/cases/keep", "cases", "keep")
+	dup := seedNode(t, db, "mem://agent/cases/dup", "cases", "dup")
+	twin := seedNode(t, db, "mem://agent/cases/twin", "cases", "twin")
+	db.RecordSessionMemory("s1", keep.URI, true)
+	db.RecordSessionMemory("s1", dup.URI, false)
+	db.RecordSessionMemory("s2", dup.URI, true)
+	db.RecordSessionMemory("s3", twin.URI, true)
+
+	if _, err := db.MergeNodes(keep.ID, dup.ID); err != nil {
+		t.Fatalf("MergeNodes: %v", err)
+	}
+	if err := db.AbsorbDuplicates(map[int64][]int64{keep.ID: {twin.ID}}); err != nil {
+		t.Fatalf("AbsorbDuplicates: %v", err)
+	}
+
+	got, err := db.MemorySessions(keep.URI)
+	if err != nil {
+		t.Fatalf("MemorySessions: %v", err)
+	}
+	created := map[string]bool{}
+	for _, sm := range got {
+		created[sm.SessionID] = sm.Created
+	}
+	// s1 keeps its own creation; the absorbed writers become updaters.
+	if len(got) != 3 || !created["s1"] || created["s2"] || created["s3"] {
+		t.Errorf("sessions of the survivor = %+v, want s1 creator, s2 and s3 updaters", got)
+	}
+	var left int
+	db.QueryRow(`SELECT COUNT(*) FROM session_memories WHERE uri IN (?, ?)`, dup.URI, twin.URI).Scan(&left)
+	if left != 0 {
+		t.Errorf("%d session_memories rows still name absorbed memories", left)
+	}
+}
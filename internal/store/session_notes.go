@@ -0,0 +1,81 @@
+package store
+
+import "fmt"
+
+// SessionNotes returns the live session-category leaves written for
+// sessionID, oldest first. Session notes are ephemeral: they belong to one
+// session's context and are deleted when it ends (DeleteSessionNotes).
+func (db *DB) SessionNotes(sessionID string) ([]MemNode, error) {
+	rows, err := db.Query(`
+		SELECT id, uri, parent_uri, node_type, category, l0_abstract, l1_overview, l2_content,
+			mergeable, merged_from, relevance, last_access, access_count, source_session, created_at, updated_at,
+			tombstoned_at, tombstone_reason, superseded_by, pinned_at, relevance_set_at, origin
+		FROM mem_nodes
+		WHERE category = 'session' AND node_type = 'leaf' AND source_session = ? AND tombstoned_at IS NULL
+		ORDER BY created_at, id
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session notes: %w", err)
+	}
+	defer rows.Close()
+
+	return scanNodes(rows)
+}
+
+// IsForeignSessionNote reports whether n is a session note written by a
+// session other than sessionID. Those are hidden from every reader but the
+// session that wrote them: search, the tree, and exports.
+func (n *MemNode) IsForeignSessionNote(sessionID string) bool {
+	return n.Category == "session" && n.SourceSession != sessionID
+}
+
+// DeleteSessionNotes deletes the session notes written for sessionID, with
+// their vectors and the directories they leave empty. Retracted notes stay,
+// so a retraction keeps blocking the content it covers, and so do pinned
+// ones: an operator pin says keep it. Returns the number deleted.
+func (db *DB) DeleteSessionNotes(sessionID string) (int, error) {
+	return db.deleteSessionNotes(`source_session = ?`, sessionID)
+}
+
+// PruneStaleSessionNotes deletes the session notes a SessionEnd never
+// cleared: those whose session is gone, or not active and last seen before
+// cutoff (Unix ms). A session that stopped after cutoff keeps its notes, so
+// resuming it within the grace period still finds them. Retracted and
+// pinned notes stay, as with DeleteSessionNotes. Returns the number deleted.
+func (db *DB) PruneStaleSessionNotes(cutoff int64) (int, error) {
+	return db.deleteSessionNotes(`NOT EXISTS (
+			SELECT 1 FROM sessions s WHERE s.session_key = mem_nodes.source_session
+				AND (s.status = 'active' OR COALESCE(s.ended_at, s.started_at) >= ?)
+		)`, cutoff)
+}
+
+// deleteSessionNotes deletes the live, unpinned session notes matching where.
+func (db *DB) deleteSessionNotes(where string, args ...any) (int, error) {
+	rows, err := db.Query(`
+		SELECT id FROM mem_nodes
+		WHERE category = 'session' AND node_type = 'leaf'
+			AND tombstoned_at IS NULL AND pinned_at IS NULL AND `+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("list session notes: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := db.DeleteNodes(ids); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
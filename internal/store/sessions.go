@@ -3,6 +3,8 @@ package store
 import (
 	"database/sql"
 	"fmt"
+	"hash/fnv"
+	"strings"
 	"time"
 )
 
@@ -19,38 +21,73 @@ type Session struct {
 	ToolCount    int
 	ExtractedAt  *int64
 	Tone         *string
+	SkipReason   *string
+
+	// TranscriptPath is where the session's JSONL transcript was last seen,
+	// recorded from the session-init and extract calls. Lets later work
+	// (profile rebuild, re-extraction) re-read a session without the hook
+	// resending it.
+	TranscriptPath *string
+
+	// Key addresses this row in every other session call, and is what
+	// observations, injections, session memories, failed extractions and
+	// source_session are stored under. It is SessionID itself unless the ID
+	// was already taken by a session in an unrelated project. See SessionKey.
+	Key string
+}
+
+// sessionColumns is the SELECT list matching Session.scanDest.
+const sessionColumns = `id, session_id, project, started_at, ended_at, status, summary_node, message_count, tool_count, extracted_at, tone, skip_reason, transcript_path, session_key`
+
+// scanDest returns Scan destinations in sessionColumns order.
+func (s *Session) scanDest() []any {
+	return []any{&s.ID, &s.SessionID, &s.Project, &s.StartedAt, &s.EndedAt, &s.Status, &s.SummaryNode, &s.MessageCount, &s.ToolCount, &s.ExtractedAt, &s.Tone, &s.SkipReason, &s.TranscriptPath, &s.Key}
 }
 
 // InitSession creates or resumes a session. If the session_id already exists
-// (in any status), it re-activates and returns it. This handles the case where
-// a Stop hook fires mid-conversation (marking it completed) but the user
-// continues sending messages in the same session.
+// in a related project (in any status), it re-activates and returns it. This
+// handles the case where a Stop hook fires mid-conversation (marking it
+// completed) but the user continues sending messages in the same session.
+//
+// A resume from a different directory (a monorepo subdirectory, say) updates
+// the stored project to the new non-empty one, so project-scoped features
+// follow where the session is now. started_at keeps the original start.
+//
+// The row is unique on (session_id, project): the same ID arriving from an
+// unrelated project — neither directory inside the other — starts a separate
+// session with its own Key rather than reactivating the other project's.
+// Every other session call takes that Key; SessionKey finds it.
 func (db *DB) InitSession(sessionID, project string) (*Session, error) {
 	now := time.Now().UnixMilli()
 
-	// Try to find existing session in any status
-	var s Session
-	err := db.QueryRow(`
-		SELECT id, session_id, project, started_at, ended_at, status, summary_node, message_count, tool_count, extracted_at, tone
-		FROM sessions WHERE session_id = ?
-	`, sessionID).Scan(&s.ID, &s.SessionID, &s.Project, &s.StartedAt, &s.EndedAt, &s.Status, &s.SummaryNode, &s.MessageCount, &s.ToolCount, &s.ExtractedAt, &s.Tone)
-	if err == nil {
+	s, err := db.resumableSession(sessionID, project)
+	if err != nil {
+		return nil, err
+	}
+	if s != nil {
 		// Re-activate if not already active
 		if s.Status != "active" {
 			db.Exec(`UPDATE sessions SET status = 'active' WHERE id = ?`, s.ID)
 			s.Status = "active"
 		}
-		return &s, nil
-	}
-	if err != sql.ErrNoRows {
-		return nil, fmt.Errorf("check existing session: %w", err)
+		if project != "" && project != s.Project {
+			if _, err := db.Exec(`UPDATE sessions SET project = ? WHERE id = ?`, project, s.ID); err != nil {
+				return nil, fmt.Errorf("update session project: %w", err)
+			}
+			s.Project = project
+		}
+		return s, nil
 	}
 
 	// Create new session
+	key, err := db.newSessionKey(sessionID, project)
+	if err != nil {
+		return nil, err
+	}
 	result, err := db.Exec(`
-		INSERT INTO sessions (session_id, project, started_at, status)
-		VALUES (?, ?, ?, 'active')
-	`, sessionID, project, now)
+		INSERT INTO sessions (session_id, project, started_at, status, session_key)
+		VALUES (?, ?, ?, 'active', ?)
+	`, sessionID, project, now, key)
 	if err != nil {
 		return nil, fmt.Errorf("insert session: %w", err)
 	}
@@ -62,16 +99,98 @@ func (db *DB) InitSession(sessionID, project string) (*Session, error) {
 		Project:   project,
 		StartedAt: now,
 		Status:    "active",
+		Key:       key,
 	}, nil
 }
 
-// GetSession returns a session by its session_id.
+// SessionKey returns the Key of the session a hook's sessionID names when it
+// runs in project: the row InitSession resumes there, or — before the first
+// prompt has created it — the key that row will get. With no project it is
+// the ID's active row, else its most recently started one. An ID no session
+// has is returned as is, so a Key passes through unchanged.
+func (db *DB) SessionKey(sessionID, project string) (string, error) {
+	if project != "" {
+		s, err := db.resumableSession(sessionID, project)
+		if err != nil {
+			return "", err
+		}
+		if s != nil {
+			return s.Key, nil
+		}
+		return db.newSessionKey(sessionID, project)
+	}
+	var key string
+	err := db.QueryRow(`
+		SELECT session_key FROM sessions WHERE session_id = ?
+		ORDER BY status = 'active' DESC, started_at DESC, id DESC LIMIT 1
+	`, sessionID).Scan(&key)
+	if err == sql.ErrNoRows {
+		return sessionID, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("session key: %w", err)
+	}
+	return key, nil
+}
+
+// newSessionKey picks the Key for a new session: the ID itself while no row
+// holds it, else the ID suffixed with a hash of project.
+func (db *DB) newSessionKey(sessionID, project string) (string, error) {
+	var taken bool
+	err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM sessions WHERE session_key = ?)`, sessionID).Scan(&taken)
+	if err != nil {
+		return "", fmt.Errorf("session key: %w", err)
+	}
+	if !taken {
+		return sessionID, nil
+	}
+	h := fnv.New32a()
+	h.Write([]byte(project))
+	return fmt.Sprintf("%s~%08x", sessionID, h.Sum32()), nil
+}
+
+// resumableSession finds the row InitSession should resume for sessionID in
+// project: the one in exactly that project, else the newest in a related
+// one. Nil when the ID is new, or only known in unrelated projects.
+func (db *DB) resumableSession(sessionID, project string) (*Session, error) {
+	rows, err := db.Query(`
+		SELECT `+sessionColumns+`
+		FROM sessions WHERE session_id = ?
+		ORDER BY project = ? DESC, started_at DESC, id DESC
+	`, sessionID, project)
+	if err != nil {
+		return nil, fmt.Errorf("check existing session: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(s.scanDest()...); err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		if relatedProjects(s.Project, project) {
+			return &s, rows.Err()
+		}
+	}
+	return nil, rows.Err()
+}
+
+// relatedProjects reports whether two session directories are the same
+// project: equal, one inside the other, or either unknown.
+func relatedProjects(a, b string) bool {
+	if a == "" || b == "" || a == b {
+		return true
+	}
+	a, b = strings.TrimSuffix(a, "/"), strings.TrimSuffix(b, "/")
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+// GetSession returns a session by its Key. See SessionKey and
+// GetProjectSession.
 func (db *DB) GetSession(sessionID string) (*Session, error) {
 	var s Session
 	err := db.QueryRow(`
-		SELECT id, session_id, project, started_at, ended_at, status, summary_node, message_count, tool_count, extracted_at, tone
-		FROM sessions WHERE session_id = ?
-	`, sessionID).Scan(&s.ID, &s.SessionID, &s.Project, &s.StartedAt, &s.EndedAt, &s.Status, &s.SummaryNode, &s.MessageCount, &s.ToolCount, &s.ExtractedAt, &s.Tone)
+		SELECT `+sessionColumns+`
+		FROM sessions WHERE session_key = ?`, sessionID).Scan(s.scanDest()...)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -81,12 +200,51 @@ func (db *DB) GetSession(sessionID string) (*Session, error) {
 	return &s, nil
 }
 
+// GetProjectSession returns the session sessionID names in project — the one
+// InitSession would resume there — or nil.
+func (db *DB) GetProjectSession(sessionID, project string) (*Session, error) {
+	s, err := db.resumableSession(sessionID, project)
+	if err != nil {
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+	return s, nil
+}
+
+// PreviousSession returns the most recently started finished session in
+// project, or a related one (see relatedProjects, as InitSession matches),
+// other than excludeID, or nil when there is none. Sessions still running
+// alongside excludeID are skipped: their tool count is not final.
+func (db *DB) PreviousSession(project, excludeID string) (*Session, error) {
+	rows, err := db.Query(`
+		SELECT `+sessionColumns+`
+		FROM sessions WHERE session_key != ? AND status = 'completed'
+		ORDER BY started_at DESC, id DESC
+	`, excludeID)
+	if err != nil {
+		return nil, fmt.Errorf("get previous session: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(s.scanDest()...); err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		if relatedProjects(s.Project, project) {
+			return &s, rows.Err()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get previous session: %w", err)
+	}
+	return nil, nil
+}
+
 // CompleteSession marks a session as completed (called on Stop hook).
 func (db *DB) CompleteSession(sessionID string) error {
 	now := time.Now().UnixMilli()
 	result, err := db.Exec(`
 		UPDATE sessions SET status = 'completed', ended_at = ?
-		WHERE session_id = ? AND status = 'active'
+		WHERE session_key = ? AND status = 'active'
 	`, now, sessionID)
 	if err != nil {
 		return fmt.Errorf("complete session: %w", err)
@@ -104,7 +262,7 @@ func (db *DB) EndSession(sessionID string) error {
 	now := time.Now().UnixMilli()
 	_, err := db.Exec(`
 		UPDATE sessions SET status = 'completed', ended_at = COALESCE(ended_at, ?)
-		WHERE session_id = ? AND status = 'active'
+		WHERE session_key = ? AND status = 'active'
 	`, now, sessionID)
 	if err != nil {
 		return fmt.Errorf("end session: %w", err)
@@ -115,7 +273,7 @@ func (db *DB) EndSession(sessionID string) error {
 // GetRecentSessions returns the most recent sessions, ordered by started_at DESC.
 func (db *DB) GetRecentSessions(limit int) ([]Session, error) {
 	rows, err := db.Query(`
-		SELECT id, session_id, project, started_at, ended_at, status, summary_node, message_count, tool_count, extracted_at, tone
+		SELECT `+sessionColumns+`
 		FROM sessions ORDER BY started_at DESC LIMIT ?
 	`, limit)
 	if err != nil {
@@ -126,7 +284,7 @@ func (db *DB) GetRecentSessions(limit int) ([]Session, error) {
 	var sessions []Session
 	for rows.Next() {
 		var s Session
-		if err := rows.Scan(&s.ID, &s.SessionID, &s.Project, &s.StartedAt, &s.EndedAt, &s.Status, &s.SummaryNode, &s.MessageCount, &s.ToolCount, &s.ExtractedAt, &s.Tone); err != nil {
+		if err := rows.Scan(s.scanDest()...); err != nil {
 			return nil, fmt.Errorf("scan session: %w", err)
 		}
 		sessions = append(sessions, s)
@@ -137,7 +295,7 @@ func (db *DB) GetRecentSessions(limit int) ([]Session, error) {
 // GetSessionsSince returns all sessions started after the given timestamp, ordered by started_at ASC.
 func (db *DB) GetSessionsSince(sinceMs int64) ([]Session, error) {
 	rows, err := db.Query(`
-		SELECT id, session_id, project, started_at, ended_at, status, summary_node, message_count, tool_count, extracted_at, tone
+		SELECT `+sessionColumns+`
 		FROM sessions WHERE started_at >= ? ORDER BY started_at ASC
 	`, sinceMs)
 	if err != nil {
@@ -148,7 +306,7 @@ func (db *DB) GetSessionsSince(sinceMs int64) ([]Session, error) {
 	var sessions []Session
 	for rows.Next() {
 		var s Session
-		if err := rows.Scan(&s.ID, &s.SessionID, &s.Project, &s.StartedAt, &s.EndedAt, &s.Status, &s.SummaryNode, &s.MessageCount, &s.ToolCount, &s.ExtractedAt, &s.Tone); err != nil {
+		if err := rows.Scan(s.scanDest()...); err != nil {
 			return nil, fmt.Errorf("scan session: %w", err)
 		}
 		sessions = append(sessions, s)
@@ -157,9 +315,10 @@ func (db *DB) GetSessionsSince(sinceMs int64) ([]Session, error) {
 }
 
 // MarkExtracted sets extracted_at for a session, preventing duplicate extraction.
+// Any skip_reason left by an earlier content-gated attempt is cleared.
 func (db *DB) MarkExtracted(sessionID string) error {
 	now := time.Now().UnixMilli()
-	_, err := db.Exec(`UPDATE sessions SET extracted_at = ? WHERE session_id = ?`, now, sessionID)
+	_, err := db.Exec(`UPDATE sessions SET extracted_at = ?, skip_reason = NULL WHERE session_key = ?`, now, sessionID)
 	if err != nil {
 		return fmt.Errorf("mark extracted: %w", err)
 	}
@@ -169,7 +328,7 @@ func (db *DB) MarkExtracted(sessionID string) error {
 // UnmarkExtracted clears extracted_at for a single session so it can be
 // re-extracted by a subsequent run without --force.
 func (db *DB) UnmarkExtracted(sessionID string) error {
-	_, err := db.Exec(`UPDATE sessions SET extracted_at = NULL WHERE session_id = ?`, sessionID)
+	_, err := db.Exec(`UPDATE sessions SET extracted_at = NULL WHERE session_key = ?`, sessionID)
 	if err != nil {
 		return fmt.Errorf("unmark extracted: %w", err)
 	}
@@ -185,7 +344,7 @@ func (db *DB) UnmarkEmptyExtractions() (int64, error) {
 		UPDATE sessions
 		SET extracted_at = NULL
 		WHERE extracted_at IS NOT NULL
-		  AND session_id NOT IN (
+		  AND session_key NOT IN (
 		      SELECT DISTINCT source_session
 		      FROM mem_nodes
 		      WHERE source_session IS NOT NULL
@@ -198,9 +357,60 @@ func (db *DB) UnmarkEmptyExtractions() (int64, error) {
 	return rows, nil
 }
 
+// SetSkipReason records why extraction passed over a session (e.g. "too few
+// user messages"). The session is NOT marked extracted — a later Stop/SessionEnd
+// gets another chance — so the reason reflects only the most recent attempt.
+func (db *DB) SetSkipReason(sessionID, reason string) error {
+	_, err := db.Exec(`UPDATE sessions SET skip_reason = ? WHERE session_key = ?`, reason, sessionID)
+	if err != nil {
+		return fmt.Errorf("set skip reason: %w", err)
+	}
+	return nil
+}
+
+// SetTranscriptPath records where a session's transcript lives so the session
+// can be re-processed later. Called from session init and the extract call —
+// the only places the hook hands the path over. An empty path is ignored
+// rather than clearing a known one.
+func (db *DB) SetTranscriptPath(sessionID, path string) error {
+	if path == "" {
+		return nil
+	}
+	_, err := db.Exec(`UPDATE sessions SET transcript_path = ? WHERE session_key = ?`, path, sessionID)
+	if err != nil {
+		return fmt.Errorf("set transcript path: %w", err)
+	}
+	return nil
+}
+
+// RecentTranscriptSessions returns up to limit completed sessions that have a
+// recorded transcript path, most recent first.
+func (db *DB) RecentTranscriptSessions(limit int) ([]Session, error) {
+	rows, err := db.Query(`
+		SELECT `+sessionColumns+`
+		FROM sessions
+		WHERE status = 'completed' AND transcript_path IS NOT NULL AND transcript_path != ''
+		ORDER BY started_at DESC LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("recent transcript sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(s.scanDest()...); err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
 // SetSessionTone stores the emotional arc tone for a session.
 func (db *DB) SetSessionTone(sessionID, tone string) error {
-	_, err := db.Exec(`UPDATE sessions SET tone = ? WHERE session_id = ?`, tone, sessionID)
+	_, err := db.Exec(`UPDATE sessions SET tone = ? WHERE session_key = ?`, tone, sessionID)
 	if err != nil {
 		return fmt.Errorf("set session tone: %w", err)
 	}
@@ -211,10 +421,215 @@ func (db *DB) SetSessionTone(sessionID, tone string) error {
 func (db *DB) IncrementToolCount(sessionID string) error {
 	_, err := db.Exec(`
 		UPDATE sessions SET tool_count = tool_count + 1
-		WHERE session_id = ? AND status = 'active'
+		WHERE session_key = ? AND status = 'active'
 	`, sessionID)
 	if err != nil {
 		return fmt.Errorf("increment tool count: %w", err)
 	}
 	return nil
 }
+
+// MergeSessions folds session mergeID into keepID, for one conversation that
+// Claude Code split across two session IDs on resume. Observations, memory
+// provenance (source_session and session_memories), context injections and
+// any failed extraction record move to the keeper; its row takes the earlier
+// start, the later end, the summed counts and the later-started half's
+// transcript path, and fields the keeper lacks (tone, summary) are filled
+// from the merged row, which is then deleted. The extraction mark and skip
+// reason are cleared: neither half's run saw the whole conversation, so the
+// keeper is due a fresh one. Both sessions must exist and belong to the same
+// project. Runs in one transaction.
+func (db *DB) MergeSessions(keepID, mergeID string) (*Session, error) {
+	if keepID == mergeID {
+		return nil, mergeValidationErrorf("cannot merge a session into itself")
+	}
+	keep, err := db.GetSession(keepID)
+	if err != nil {
+		return nil, err
+	}
+	merge, err := db.GetSession(mergeID)
+	if err != nil {
+		return nil, err
+	}
+	if keep == nil {
+		return nil, withKind(ErrNotFound, mergeValidationErrorf("session not found: %s", keepID))
+	}
+	if merge == nil {
+		return nil, withKind(ErrNotFound, mergeValidationErrorf("session not found: %s", mergeID))
+	}
+	if keep.Project != merge.Project {
+		return nil, mergeValidationErrorf("cannot merge sessions across projects: %s is %q, %s is %q",
+			keepID, keep.Project, mergeID, merge.Project)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin session merge: %w", err)
+	}
+	defer tx.Rollback()
+
+	steps := []struct {
+		what string
+		sql  string
+	}{
+		{"observations", `UPDATE observations SET session_id = ?1 WHERE session_id = ?2`},
+		{"memory provenance", `UPDATE mem_nodes SET source_session = ?1 WHERE source_session = ?2`},
+		// A memory injected into both halves keeps the keeper's row, but
+		// counts as used if either half used it.
+		{"injection use", `
+			UPDATE context_injections SET used_at = (
+				SELECT m.used_at FROM context_injections m
+				WHERE m.session_id = ?2 AND m.uri = context_injections.uri
+			)
+			WHERE session_id = ?1 AND used_at IS NULL`},
+		// Likewise a memory written by both halves: a creation by either wins.
+		{"session memory creations", `
+			UPDATE session_memories SET created = 1
+			WHERE session_id = ?1 AND uri IN (
+				SELECT uri FROM session_memories WHERE session_id = ?2 AND created = 1
+			)`},
+		{"session memories", `UPDATE OR IGNORE session_memories SET session_id = ?1 WHERE session_id = ?2`},
+		{"duplicate session memories", `DELETE FROM session_memories WHERE session_id = ?2`},
+		{"injections", `UPDATE OR IGNORE context_injections SET session_id = ?1 WHERE session_id = ?2`},
+		{"duplicate injections", `DELETE FROM context_injections WHERE session_id = ?2`},
+		// A failed extraction follows to the keeper so retry-failed still
+		// finds it; when both halves failed, the keeper's record stands.
+		{"failed extraction", `UPDATE OR IGNORE failed_extractions SET session_id = ?1 WHERE session_id = ?2`},
+		{"duplicate failed extraction", `DELETE FROM failed_extractions WHERE session_id = ?2`},
+		{"session", `
+			UPDATE sessions SET
+				started_at      = MIN(sessions.started_at, m.started_at),
+				ended_at        = CASE WHEN sessions.ended_at IS NULL OR m.ended_at IS NULL
+				                       THEN COALESCE(sessions.ended_at, m.ended_at)
+				                       ELSE MAX(sessions.ended_at, m.ended_at) END,
+				status          = CASE WHEN m.status = 'active' THEN 'active' ELSE sessions.status END,
+				message_count   = sessions.message_count + m.message_count,
+				tool_count      = sessions.tool_count + m.tool_count,
+				summary_node    = COALESCE(sessions.summary_node, m.summary_node),
+				extracted_at    = NULL,
+				tone            = COALESCE(sessions.tone, m.tone),
+				skip_reason     = NULL,
+				transcript_path = CASE WHEN m.started_at > sessions.started_at
+				                       THEN COALESCE(m.transcript_path, sessions.transcript_path)
+				                       ELSE COALESCE(sessions.transcript_path, m.transcript_path) END
+			FROM (SELECT * FROM sessions WHERE id = ?4) AS m
+			WHERE sessions.id = ?3`},
+		// The moved failure record retries against the merged row's
+		// transcript, not the half it was recorded for.
+		{"failed extraction transcript", `
+			UPDATE failed_extractions SET transcript_path = (SELECT transcript_path FROM sessions WHERE id = ?3)
+			WHERE session_id = ?1 AND (SELECT transcript_path FROM sessions WHERE id = ?3) IS NOT NULL`},
+		{"merged session", `DELETE FROM sessions WHERE id = ?4`},
+	}
+	for _, st := range steps {
+		if _, err := tx.Exec(st.sql, keepID, mergeID, keep.ID, merge.ID); err != nil {
+			return nil, fmt.Errorf("merge %s: %w", st.what, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit session merge: %w", err)
+	}
+	db.invalidateNodes()
+	return db.GetSession(keepID)
+}
+
+// ForgetReport is what ForgetSession removed.
+type ForgetReport struct {
+	SessionID string   `json:"session_id"`
+	Deleted   []string `json:"deleted"`        // memories the session created, deleted with it
+	Kept      []string `json:"kept,omitempty"` // memories it created that another session also wrote, or that are pinned or retracted
+}
+
+// ForgetSession deletes session sessionID (a Key) and everything recorded
+// under it: observations, context injections, its session_memories rows and
+// any failed extraction record. Memories it wrote lose it as their
+// source_session. With memories set the forgetting cascades: each memory the
+// session created goes too, unless another session also wrote it, it is
+// pinned, or it is retracted (a retraction keeps blocking its content).
+// Refuses an active session, which would keep writing under the forgotten
+// key. Runs in one transaction.
+func (db *DB) ForgetSession(sessionID string, memories bool) (ForgetReport, error) {
+	rep := ForgetReport{SessionID: sessionID, Deleted: []string{}}
+	sess, err := db.GetSession(sessionID)
+	if err != nil {
+		return rep, err
+	}
+	if sess == nil {
+		return rep, withKind(ErrNotFound, fmt.Errorf("session not found: %s", sessionID))
+	}
+	if sess.Status == "active" {
+		return rep, withKind(ErrConflict, fmt.Errorf("session %s is still active", sessionID))
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return rep, fmt.Errorf("begin forget session: %w", err)
+	}
+	defer tx.Rollback()
+
+	if memories {
+		rows, err := tx.Query(`
+			SELECT n.id, n.uri,
+				n.pinned_at IS NOT NULL OR n.tombstoned_at IS NOT NULL OR EXISTS (
+					SELECT 1 FROM session_memories o WHERE o.uri = sm.uri AND o.session_id != sm.session_id
+				)
+			FROM session_memories sm
+			JOIN mem_nodes n ON n.uri = sm.uri AND n.node_type = 'leaf'
+			WHERE sm.session_id = ? AND sm.created = 1
+			ORDER BY sm.recorded_at, sm.uri
+		`, sessionID)
+		if err != nil {
+			return rep, fmt.Errorf("list session memories: %w", err)
+		}
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			var uri string
+			var keep bool
+			if err := rows.Scan(&id, &uri, &keep); err != nil {
+				rows.Close()
+				return rep, fmt.Errorf("scan session memory: %w", err)
+			}
+			if keep {
+				rep.Kept = append(rep.Kept, uri)
+				continue
+			}
+			ids = append(ids, id)
+			rep.Deleted = append(rep.Deleted, uri)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return rep, err
+		}
+		for _, id := range ids {
+			if err := deleteNodeTx(tx, id); err != nil {
+				return rep, err
+			}
+		}
+		if _, err := tx.Exec(deleteOrphanDirsSQL); err != nil {
+			return rep, fmt.Errorf("delete orphan dirs: %w", err)
+		}
+	}
+
+	steps := []struct {
+		what string
+		sql  string
+	}{
+		{"observations", `DELETE FROM observations WHERE session_id = ?`},
+		{"injections", `DELETE FROM context_injections WHERE session_id = ?`},
+		{"session memories", `DELETE FROM session_memories WHERE session_id = ?`},
+		{"failed extraction", `DELETE FROM failed_extractions WHERE session_id = ?`},
+		{"memory provenance", `UPDATE mem_nodes SET source_session = NULL WHERE source_session = ?`},
+		{"session", `DELETE FROM sessions WHERE session_key = ?`},
+	}
+	for _, st := range steps {
+		if _, err := tx.Exec(st.sql, sessionID); err != nil {
+			return rep, fmt.Errorf("forget %s: %w", st.what, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return rep, fmt.Errorf("commit forget session: %w", err)
+	}
+	db.invalidateNodes()
+	return rep, nil
+}
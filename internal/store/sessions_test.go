@@ -1,6 +1,7 @@
 package store
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -51,6 +52,124 @@ func TestInitSessionResume(t *testing.T) {
 	}
 }
 
+func TestInitSessionResumeUpdatesProject(t *testing.T) {
+	db, err := OpenMemory()
+	if err != nil {
+		t.Fatalf("OpenMemory: %v", err)
+	}
+	defer db.Close()
+
+	s1, err := db.InitSession("sess-001", "/code/mono")
+	if err != nil {
+		t.Fatalf("InitSession: %v", err)
+	}
+
+	s2, err := db.InitSession("sess-001", "/code/mono/services/api")
+	if err != nil {
+		t.Fatalf("InitSession resume: %v", err)
+	}
+	if s2.ID != s1.ID {
+		t.Errorf("resumed session ID = %d, want %d", s2.ID, s1.ID)
+	}
+	if s2.Project != "/code/mono/services/api" {
+		t.Errorf("returned project = %q, want the new directory", s2.Project)
+	}
+	if s2.StartedAt != s1.StartedAt {
+		t.Errorf("StartedAt = %d, want the original %d", s2.StartedAt, s1.StartedAt)
+	}
+	if got, _ := db.GetSession("sess-001"); got.Project != "/code/mono/services/api" {
+		t.Errorf("stored project = %q, want the new directory", got.Project)
+	}
+
+	// A resume that doesn't say where it is keeps the last known project.
+	s3, err := db.InitSession("sess-001", "")
+	if err != nil {
+		t.Fatalf("InitSession resume without project: %v", err)
+	}
+	if s3.Project != "/code/mono/services/api" {
+		t.Errorf("project = %q after an empty-project resume, want it kept", s3.Project)
+	}
+}
+
+func TestInitSessionSameIDAcrossProjects(t *testing.T) {
+	db := testDB(t)
+
+	a, err := db.InitSession("sess-001", "/code/alpha")
+	if err != nil {
+		t.Fatalf("InitSession alpha: %v", err)
+	}
+	db.IncrementToolCount("sess-001")
+	db.CompleteSession("sess-001")
+
+	// The same ID in an unrelated project is a different session: alpha's
+	// row is neither reactivated nor moved.
+	b, err := db.InitSession("sess-001", "/code/beta")
+	if err != nil {
+		t.Fatalf("InitSession beta: %v", err)
+	}
+	if b.ID == a.ID {
+		t.Fatal("same ID in another project reused the first project's session")
+	}
+	if b.Project != "/code/beta" || b.ToolCount != 0 {
+		t.Errorf("beta session = %+v, want a fresh session in /code/beta", b)
+	}
+
+	gotA, _ := db.GetProjectSession("sess-001", "/code/alpha")
+	if gotA == nil || gotA.ID != a.ID || gotA.Status != "completed" || gotA.ToolCount != 1 {
+		t.Errorf("alpha session = %+v, want it completed with its tool count kept", gotA)
+	}
+
+	if b.Key == a.Key || a.Key != "sess-001" {
+		t.Fatalf("keys alpha %q, beta %q: want alpha to keep the ID and beta its own", a.Key, b.Key)
+	}
+
+	// Without a project the ID resolves to the active session, beta's.
+	if key, _ := db.SessionKey("sess-001", ""); key != b.Key {
+		t.Errorf("SessionKey without project = %q, want beta's %q", key, b.Key)
+	}
+	if err := db.CompleteSession(b.Key); err != nil {
+		t.Fatalf("CompleteSession: %v", err)
+	}
+	if got, _ := db.GetSession(b.Key); got.Status != "completed" {
+		t.Errorf("beta status = %q after CompleteSession, want completed", got.Status)
+	}
+
+	// Each session's child rows stay its own.
+	db.AddObservation(a.Key, "", "Bash", "{}", "alpha")
+	db.AddObservation(b.Key, "", "Bash", "{}", "beta")
+	if obs, _ := db.GetObservations(b.Key); len(obs) != 1 || obs[0].ToolResponse != "beta" {
+		t.Errorf("beta observations = %+v, want only its own", obs)
+	}
+
+	// Resuming in either project, or a directory inside one, finds its own.
+	again, _ := db.InitSession("sess-001", "/code/alpha/cmd")
+	if again.ID != a.ID {
+		t.Errorf("resume in /code/alpha/cmd got session %d, want alpha's %d", again.ID, a.ID)
+	}
+	if key, _ := db.SessionKey("sess-001", ""); key != a.Key {
+		t.Errorf("SessionKey after resuming alpha = %q, want the active alpha session's %q", key, a.Key)
+	}
+	if key, _ := db.SessionKey("sess-001", "/code/beta/internal"); key != b.Key {
+		t.Errorf("SessionKey in beta subdirectory = %q, want %q", key, b.Key)
+	}
+	if got, _ := db.GetProjectSession("sess-001", "/code/beta"); got.ID != b.ID {
+		t.Errorf("GetProjectSession beta = %d, want %d", got.ID, b.ID)
+	}
+	if got, _ := db.GetProjectSession("sess-001", "/code/gamma"); got != nil {
+		t.Errorf("GetProjectSession gamma = %+v, want nil", got)
+	}
+
+	// A third project knows its key before its first prompt creates the row,
+	// so context injected at SessionStart lands on the right session.
+	gammaKey, _ := db.SessionKey("sess-001", "/code/gamma")
+	if gammaKey == a.Key || gammaKey == b.Key {
+		t.Fatalf("gamma key %q collides", gammaKey)
+	}
+	if gamma, _ := db.InitSession("sess-001", "/code/gamma"); gamma.Key != gammaKey {
+		t.Errorf("gamma session key = %q, want the %q SessionKey promised", gamma.Key, gammaKey)
+	}
+}
+
 func TestInitSessionReactivatesCompleted(t *testing.T) {
 	db, err := OpenMemory()
 	if err != nil {
@@ -162,6 +281,44 @@ func TestEndSession(t *testing.T) {
 	}
 }
 
+func TestPreviousSession(t *testing.T) {
+	db, err := OpenMemory()
+	if err != nil {
+		t.Fatalf("OpenMemory: %v", err)
+	}
+	defer db.Close()
+
+	db.InitSession("done", "proj")
+	db.CompleteSession("done")
+	// A second terminal started later and is still running.
+	db.InitSession("parallel", "proj")
+	db.InitSession("current", "proj")
+	db.Exec(`UPDATE sessions SET started_at = started_at + 1000 WHERE session_id IN ('parallel', 'current')`)
+
+	prev, err := db.PreviousSession("proj", "current")
+	if err != nil {
+		t.Fatalf("PreviousSession: %v", err)
+	}
+	if prev == nil || prev.SessionID != "done" {
+		t.Errorf("previous = %+v, want the finished session, not the concurrent one", prev)
+	}
+
+	if prev, _ := db.PreviousSession("other", "current"); prev != nil {
+		t.Errorf("previous in a project with no history = %+v, want nil", prev)
+	}
+
+	// A session in a subdirectory is the same project, as for InitSession.
+	db.InitSession("sub", "proj/internal")
+	db.CompleteSession("sub")
+	db.Exec(`UPDATE sessions SET started_at = started_at + 500 WHERE session_id = 'sub'`)
+	if prev, _ := db.PreviousSession("proj", "current"); prev == nil || prev.SessionID != "sub" {
+		t.Errorf("previous = %+v, want the session in a subdirectory of the project", prev)
+	}
+	if prev, _ := db.PreviousSession("proj-other", "current"); prev != nil {
+		t.Errorf("previous in a sibling project = %+v, want nil", prev)
+	}
+}
+
 func TestGetRecentSessions(t *testing.T) {
 	db, err := OpenMemory()
 	if err != nil {
@@ -342,3 +499,240 @@ func TestIncrementToolCount(t *testing.T) {
 		t.Errorf("ToolCount = %d, want 3", s.ToolCount)
 	}
 }
+
+func TestSetSkipReason(t *testing.T) {
+	db, err := OpenMemory()
+	if err != nil {
+		t.Fatalf("OpenMemory: %v", err)
+	}
+	defer db.Close()
+
+	s, _ := db.InitSession("sess-001", "proj")
+	if s.SkipReason != nil {
+		t.Fatalf("expected nil skip_reason on new session, got %q", *s.SkipReason)
+	}
+
+	if err := db.SetSkipReason("sess-001", "too few user messages (2 < 3)"); err != nil {
+		t.Fatalf("SetSkipReason: %v", err)
+	}
+	s, _ = db.GetSession("sess-001")
+	if s.SkipReason == nil || *s.SkipReason != "too few user messages (2 < 3)" {
+		t.Fatalf("SkipReason = %v, want recorded reason", s.SkipReason)
+	}
+
+	// A successful extraction clears the stale reason.
+	if err := db.MarkExtracted("sess-001"); err != nil {
+		t.Fatalf("MarkExtracted: %v", err)
+	}
+	s, _ = db.GetSession("sess-001")
+	if s.SkipReason != nil {
+		t.Errorf("expected skip_reason cleared by MarkExtracted, got %q", *s.SkipReason)
+	}
+}
+
+func TestTranscriptPathAndRecentTranscriptSessions(t *testing.T) {
+	db, err := OpenMemory()
+	if err != nil {
+		t.Fatalf("OpenMemory: %v", err)
+	}
+	defer db.Close()
+
+	db.InitSession("sess-001", "proj")
+	db.InitSession("sess-002", "proj")
+	db.InitSession("sess-003", "proj")
+
+	if err := db.SetTranscriptPath("sess-001", "/tmp/one.jsonl"); err != nil {
+		t.Fatalf("SetTranscriptPath: %v", err)
+	}
+	// Empty path must not clear a recorded one.
+	if err := db.SetTranscriptPath("sess-001", ""); err != nil {
+		t.Fatalf("SetTranscriptPath empty: %v", err)
+	}
+	s, _ := db.GetSession("sess-001")
+	if s.TranscriptPath == nil || *s.TranscriptPath != "/tmp/one.jsonl" {
+		t.Fatalf("TranscriptPath = %v, want /tmp/one.jsonl", s.TranscriptPath)
+	}
+
+	db.SetTranscriptPath("sess-002", "/tmp/two.jsonl")
+	db.CompleteSession("sess-001")
+	db.CompleteSession("sess-003") // completed, but no transcript path
+
+	// sess-002 has a path but is still active; sess-003 has no path.
+	got, err := db.RecentTranscriptSessions(10)
+	if err != nil {
+		t.Fatalf("RecentTranscriptSessions: %v", err)
+	}
+	if len(got) != 1 || got[0].SessionID != "sess-001" {
+		t.Fatalf("RecentTranscriptSessions = %+v, want only sess-001", got)
+	}
+}
+
+func TestMergeSessions(t *testing.T) {
+	db := testDB(t)
+	for _, id := range []string{"first", "resumed", "elsewhere"} {
+		project := "proj"
+		if id == "elsewhere" {
+			project = "other"
+		}
+		if _, err := db.InitSession(id, project); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.IncrementToolCount("first")
+	db.IncrementToolCount("resumed")
+	db.IncrementToolCount("resumed")
+	db.SetSessionTone("resumed", "focused")
+	db.CompleteSession("first")
+	if _, err := db.AddObservation("resumed", "", "Bash", "ls", "ok"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateNode(&MemNode{URI: "mem://user/preferences/x", NodeType: "leaf", Category: "preferences", L0Abstract: "x", SourceSession: "resumed"}); err != nil {
+		t.Fatal(err)
+	}
+	injected := []InjectedMemory{{URI: "mem://user/preferences/x", Category: "preferences"}}
+	db.RecordInjections("first", injected)
+	db.RecordInjections("resumed", injected)
+	if _, err := db.MarkInjectionUsed("resumed", "mem://user/preferences/x"); err != nil {
+		t.Fatal(err)
+	}
+	db.RecordFailedExtraction("resumed", "/tmp/resumed-old.jsonl", "llm down")
+	// resumed is the later half, whatever the clock gave InitSession.
+	db.Exec(`UPDATE sessions SET started_at = started_at + 1000 WHERE session_key = 'resumed'`)
+	db.SetTranscriptPath("first", "/tmp/first.jsonl")
+	db.SetTranscriptPath("resumed", "/tmp/resumed.jsonl")
+	db.MarkExtracted("first")
+	db.SetSkipReason("first", "too few messages")
+
+	if _, err := db.MergeSessions("first", "elsewhere"); err == nil {
+		t.Error("merging across projects should be refused")
+	}
+	if _, err := db.MergeSessions("first", "missing"); err == nil {
+		t.Error("merging a missing session should be refused")
+	}
+
+	kept, err := db.MergeSessions("first", "resumed")
+	if err != nil {
+		t.Fatalf("MergeSessions: %v", err)
+	}
+	if kept.ToolCount != 3 || kept.Status != "active" || kept.Tone == nil || *kept.Tone != "focused" {
+		t.Errorf("kept session = %+v", kept)
+	}
+	if kept.ExtractedAt != nil || kept.SkipReason != nil {
+		t.Errorf("kept session should be due extraction again: extracted_at %v, skip_reason %v", kept.ExtractedAt, kept.SkipReason)
+	}
+	if kept.TranscriptPath == nil || *kept.TranscriptPath != "/tmp/resumed.jsonl" {
+		t.Errorf("transcript_path = %v, want the later half's /tmp/resumed.jsonl", kept.TranscriptPath)
+	}
+	if gone, _ := db.GetSession("resumed"); gone != nil {
+		t.Error("merged session row should be deleted")
+	}
+	if obs, _ := db.GetObservations("first"); len(obs) != 1 {
+		t.Errorf("observations on keeper = %d, want 1", len(obs))
+	}
+	if n, _ := db.GetNodeByURI("mem://user/preferences/x"); n.SourceSession != "first" {
+		t.Errorf("source_session = %q, want first", n.SourceSession)
+	}
+	use, _ := db.InjectionUsefulness()
+	if len(use) != 1 || use[0].Injected != 1 || use[0].Used != 1 {
+		t.Errorf("injections after merge = %+v, want one used injection", use)
+	}
+	if failed, _ := db.ListFailedExtractions(); len(failed) != 1 || failed[0].SessionID != "first" ||
+		failed[0].TranscriptPath != "/tmp/resumed.jsonl" {
+		t.Errorf("failed extractions after merge = %+v, want the record moved to first with its transcript", failed)
+	}
+}
+
+func TestFailedExtractions(t *testing.T) {
+	db := testDB(t)
+
+	db.RecordFailedExtraction("s1", "/tmp/a.jsonl", "llm down")
+	db.RecordFailedExtraction("s1", "/tmp/b.jsonl", "transcript missing")
+	db.RecordFailedExtraction("s2", "", "llm down")
+
+	failed, err := db.ListFailedExtractions()
+	if err != nil {
+		t.Fatalf("ListFailedExtractions: %v", err)
+	}
+	if len(failed) != 2 {
+		t.Fatalf("got %d failures, want 2", len(failed))
+	}
+	f := failed[0]
+	if f.SessionID != "s1" || f.Attempts != 2 || f.Error != "transcript missing" || f.TranscriptPath != "/tmp/b.jsonl" {
+		t.Errorf("s1 = %+v, want 2 attempts keeping the latest error and path", f)
+	}
+	if f.FirstFailedAt > f.LastFailedAt {
+		t.Errorf("first failure %d after last %d", f.FirstFailedAt, f.LastFailedAt)
+	}
+
+	if err := db.ClearFailedExtraction("s1"); err != nil {
+		t.Fatalf("ClearFailedExtraction: %v", err)
+	}
+	if failed, _ = db.ListFailedExtractions(); len(failed) != 1 || failed[0].SessionID != "s2" {
+		t.Errorf("after clearing s1: %+v, want only s2", failed)
+	}
+}
+
+func TestForgetSession(t *testing.T) {
+	db := testDB(t)
+	for _, id := range []string{"s1", "s2", "live"} {
+		if _, err := db.InitSession(id, "proj"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.CompleteSession("s1")
+	db.CompleteSession("s2")
+
+	alone := seedNode(t, db, "mem://user/events/alone", "events", "only s1 wrote this")
+	shared := seedNode(t, db, "mem://user/preferences/shared", "preferences", "s2 updated this")
+	pinned := seedNode(t, db, "mem://user/events/pinned", "events", "pinned by hand")
+	other := seedNode(t, db, "mem://user/events/other", "events", "s2 created, s1 updated")
+	db.Exec(`UPDATE mem_nodes SET source_session = 's1' WHERE id = ?`, alone.ID)
+	db.Exec(`UPDATE mem_nodes SET source_session = 's1' WHERE id = ?`, other.ID)
+	db.PinNode(pinned.URI)
+	db.RecordSessionMemory("s1", alone.URI, true)
+	db.RecordSessionMemory("s1", shared.URI, true)
+	db.RecordSessionMemory("s2", shared.URI, false)
+	db.RecordSessionMemory("s1", pinned.URI, true)
+	db.RecordSessionMemory("s2", other.URI, true)
+	db.RecordSessionMemory("s1", other.URI, false)
+	db.AddObservation("s1", "", "Bash", "ls", "ok")
+	db.RecordFailedExtraction("s1", "/tmp/s1.jsonl", "llm down")
+
+	if _, err := db.ForgetSession("live", false); !errors.Is(err, ErrConflict) {
+		t.Errorf("forgetting an active session: err = %v, want ErrConflict", err)
+	}
+	if _, err := db.ForgetSession("missing", false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("forgetting a missing session: err = %v, want ErrNotFound", err)
+	}
+
+	rep, err := db.ForgetSession("s1", true)
+	if err != nil {
+		t.Fatalf("ForgetSession: %v", err)
+	}
+	if len(rep.Deleted) != 1 || rep.Deleted[0] != alone.URI || len(rep.Kept) != 2 {
+		t.Errorf("report = %+v, want %s deleted and the shared and pinned memories kept", rep, alone.URI)
+	}
+	if n, _ := db.GetNodeByURI(alone.URI); n != nil {
+		t.Error("the memory only s1 created survived")
+	}
+	for _, uri := range []string{shared.URI, pinned.URI, other.URI} {
+		if n, _ := db.GetNodeByURI(uri); n == nil {
+			t.Errorf("%s was deleted", uri)
+		}
+	}
+	if n, _ := db.GetNodeByURI(other.URI); n.SourceSession != "" {
+		t.Errorf("source_session = %q, want the forgotten session cleared", n.SourceSession)
+	}
+	if s, _ := db.GetSession("s1"); s != nil {
+		t.Error("the session row survived")
+	}
+	if obs, _ := db.GetObservations("s1"); len(obs) != 0 {
+		t.Errorf("%d observations survived", len(obs))
+	}
+	if failed, _ := db.ListFailedExtractions(); len(failed) != 0 {
+		t.Errorf("failed extractions = %+v, want none", failed)
+	}
+	if sm, _ := db.MemorySessions(shared.URI); len(sm) != 1 || sm[0].SessionID != "s2" {
+		t.Errorf("sessions of the shared memory = %+v, want s2 only", sm)
+	}
+}
@@ -119,7 +119,7 @@ func (db *DB) snapshotBeforeRiskyMigration(m migration) (string, error) {
 	// VACUUM INTO is the SQLite-blessed atomic copy. DO NOT replace this with
 	// a file-level copy (os.Rename / io.Copy / `cp` / `tar` / etc.). Reasons:
 	//
-	//   1. WAL mode is on by default (see configurePragmas), which means the
+	//   1. WAL mode is on by default (see connPragmas), which means the
 	//      main .db file is INCOMPLETE on its own. Recent commits live in
 	//      <path>-wal until a checkpoint moves them into the main file. A
 	//      naïve file copy of <path> alone would silently drop everything in
@@ -2,6 +2,7 @@ package store
 
 import (
 	"database/sql"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -66,6 +67,18 @@ func buildSnapshotDBAtVersion(t *testing.T, path string, target int) {
 	applyMigrationsUpTo(t, sqlDB, target)
 }
 
+// lastRiskyVersion is the newest Risky migration: the one whose snapshot
+// survives a multi-step upgrade under single-snapshot retention.
+func lastRiskyVersion() int {
+	last := 0
+	for _, m := range migrations {
+		if m.Risky {
+			last = m.Version
+		}
+	}
+	return last
+}
+
 // =========================================================================
 // Creation behavior
 // =========================================================================
@@ -95,10 +108,10 @@ func TestSnapshot_CreatedBeforeRiskyMigration(t *testing.T) {
 		t.Fatalf("snapshots dir not created: %v", err)
 	}
 	if len(entries) == 0 {
-		t.Fatal("no snapshot file in snapshots/ after v5→v9 upgrade")
+		t.Fatal("no snapshot file in snapshots/ after v5→head upgrade")
 	}
-	// After v5→v9 only ONE snapshot must remain (the pre-v9 one); recording
-	// pruned the pre-v6 file when v9's snapshot landed.
+	// After v5→head only ONE snapshot must remain (the one before the last
+	// risky migration); recording each pruned the one before it.
 	if len(entries) != 1 {
 		var names []string
 		for _, e := range entries {
@@ -106,8 +119,8 @@ func TestSnapshot_CreatedBeforeRiskyMigration(t *testing.T) {
 		}
 		t.Errorf("expected exactly 1 retained snapshot file, got %d: %v", len(entries), names)
 	}
-	if !strings.HasPrefix(entries[0].Name(), "continuity-pre-v9-") {
-		t.Errorf("retained snapshot should be pre-v9; got %q", entries[0].Name())
+	if want := fmt.Sprintf("continuity-pre-v%d-", lastRiskyVersion()); !strings.HasPrefix(entries[0].Name(), want) {
+		t.Errorf("retained snapshot should start %q; got %q", want, entries[0].Name())
 	}
 }
 
@@ -154,7 +167,7 @@ func TestSnapshot_NotCreatedForAdditiveMigration(t *testing.T) {
 
 	dir := t.TempDir()
 	dbPath := filepath.Join(dir, "test.db")
-	buildSnapshotDBAtVersion(t, dbPath, 9) // current head
+	buildSnapshotDBAtVersion(t, dbPath, headVersion())
 
 	db, err := Open(dbPath)
 	if err != nil {
@@ -270,7 +283,7 @@ func TestSnapshot_FailureBlocksMigration(t *testing.T) {
 
 // TestSnapshot_CapturesWALActiveData pins the WAL/source-locking contract
 // that the snapshot code depends on. In WAL mode (Continuity's default —
-// see configurePragmas), committed data may live in the <path>-wal sidecar
+// see connPragmas), committed data may live in the <path>-wal sidecar
 // until a checkpoint moves it into the main .db file. A file-level copy
 // (os.Rename, io.Copy, `cp`, `tar`) of <path> alone would silently miss
 // that data — the snapshot would look intact but be missing the most
@@ -433,20 +446,17 @@ func TestSnapshot_ContentMatchesPreMigrationState(t *testing.T) {
 		t.Errorf("seeded row mangled: got %q", abstract)
 	}
 
-	// Snapshot's schema must be the PRE-v9 schema. Specifically: the
-	// feedback category was added in v9, so an attempt to insert a
-	// feedback row into the snapshot would fail under the v8-era CHECK
-	// constraint.
+	// Snapshot's schema must be the schema from just before the last risky
+	// migration, v24, which relaxed sessions' UNIQUE(session_id) to
+	// UNIQUE(session_id, project). The same session ID in two projects must
+	// still collide in the snapshot. If it didn't, the snapshot got post-v24
+	// content somehow, meaning the snapshot was taken AFTER instead of before.
 	_, err = snap.Exec(`
-		INSERT INTO mem_nodes (uri, node_type, category, created_at, updated_at)
-		VALUES ('mem://user/feedback/post-snapshot', 'leaf', 'feedback', 1000, 1000)
+		INSERT INTO sessions (session_id, project, started_at) VALUES
+			('dup', '/code/a', 1000), ('dup', '/code/b', 1000)
 	`)
-	// We seeded at v5; the snapshot was taken right before v6, so feedback
-	// (added v9) would fail. If it succeeded, the snapshot got post-v9
-	// content somehow, meaning the snapshot was taken AFTER instead of
-	// before.
 	if err == nil {
-		t.Error("snapshot accepted post-v9 category; was taken AFTER migration instead of BEFORE")
+		t.Error("snapshot accepted a post-v24 session pair; was taken AFTER migration instead of BEFORE")
 	}
 }
 
@@ -455,9 +465,8 @@ func TestSnapshot_ContentMatchesPreMigrationState(t *testing.T) {
 // =========================================================================
 
 // TestSnapshot_OnlyMostRecentRetained pins the "single snapshot" policy:
-// after v5→v9 (two risky migrations: v6 and v9), exactly one snapshot
-// remains. The pre-v6 snapshot is replaced by the pre-v9 one when v9
-// runs.
+// after v5→head (risky migrations v6, v9, v17, and v24), exactly one snapshot
+// remains. Each risky migration's snapshot replaces the one before it.
 func TestSnapshot_OnlyMostRecentRetained(t *testing.T) {
 	t.Setenv(EnvNoMigrationSnapshot, "")
 
@@ -478,8 +487,8 @@ func TestSnapshot_OnlyMostRecentRetained(t *testing.T) {
 	if len(snaps) != 1 {
 		t.Errorf("expected exactly 1 retained snapshot row, got %d: %+v", len(snaps), snaps)
 	}
-	if len(snaps) > 0 && snaps[0].TargetVersion != 9 {
-		t.Errorf("retained snapshot should be pre-v9; got target_version=%d", snaps[0].TargetVersion)
+	if last := lastRiskyVersion(); len(snaps) > 0 && snaps[0].TargetVersion != last {
+		t.Errorf("retained snapshot should be pre-v%d; got target_version=%d", last, snaps[0].TargetVersion)
 	}
 
 	// File system should match: one file in this DB's snapshots/<db>/ dir.
@@ -674,7 +683,8 @@ func TestSnapshot_ListReturnsRecordedFields(t *testing.T) {
 
 	dir := t.TempDir()
 	dbPath := filepath.Join(dir, "test.db")
-	buildSnapshotDBAtVersion(t, dbPath, 8)
+	last := lastRiskyVersion()
+	buildSnapshotDBAtVersion(t, dbPath, last-1)
 
 	db, err := Open(dbPath)
 	if err != nil {
@@ -693,11 +703,11 @@ func TestSnapshot_ListReturnsRecordedFields(t *testing.T) {
 	if s.Path == "" || !strings.Contains(s.Path, "snapshots") {
 		t.Errorf("Path = %q", s.Path)
 	}
-	if s.PreVersion != 8 {
-		t.Errorf("PreVersion = %d, want 8", s.PreVersion)
+	if s.PreVersion != last-1 {
+		t.Errorf("PreVersion = %d, want %d", s.PreVersion, last-1)
 	}
-	if s.TargetVersion != 9 {
-		t.Errorf("TargetVersion = %d, want 9", s.TargetVersion)
+	if s.TargetVersion != last {
+		t.Errorf("TargetVersion = %d, want %d", s.TargetVersion, last)
 	}
 	if s.CreatedAt.IsZero() {
 		t.Errorf("CreatedAt is zero")
@@ -0,0 +1,120 @@
+package store
+
+import "fmt"
+
+// Stats is a point-in-time summary of the store, for `continuity stats`.
+// Memory figures cover live (non-retracted) leaves.
+type Stats struct {
+	SchemaVersion int `json:"schema_version"`
+
+	Leaves     int             `json:"leaves"`
+	Retracted  int             `json:"retracted"`
+	Categories []CategoryShare `json:"categories"`      // sorted desc by count
+	Embedded   int             `json:"embedded"`        // live leaves with a stored vector
+	Corrupt    int             `json:"corrupt_vectors"` // stored vectors failing the length check
+
+	// Stored relevance column across live leaves; zero when there are none.
+	RelevanceAvg float64 `json:"relevance_avg"`
+	RelevanceMin float64 `json:"relevance_min"`
+	RelevanceMax float64 `json:"relevance_max"`
+
+	Sessions          map[string]int `json:"sessions"` // by status
+	SessionsTotal     int            `json:"sessions_total"`
+	SessionsExtracted int            `json:"sessions_extracted"`
+}
+
+// Coverage is the fraction of live leaves that have a vector, 0..1.
+func (s *Stats) Coverage() float64 {
+	if s.Leaves == 0 {
+		return 0
+	}
+	return float64(s.Embedded) / float64(s.Leaves)
+}
+
+// Stats computes the summary with a handful of aggregate queries. Read-only.
+func (db *DB) Stats() (*Stats, error) {
+	st := &Stats{Sessions: map[string]int{}}
+
+	var err error
+	if st.SchemaVersion, err = db.SchemaVersion(); err != nil {
+		return nil, fmt.Errorf("schema version: %w", err)
+	}
+
+	rows, err := db.Query(`
+		SELECT category, COUNT(*) FROM mem_nodes
+		WHERE node_type = 'leaf' AND tombstoned_at IS NULL
+		GROUP BY category ORDER BY COUNT(*) DESC, category
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("count by category: %w", err)
+	}
+	for rows.Next() {
+		var c CategoryShare
+		if err := rows.Scan(&c.Category, &c.Count); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan category count: %w", err)
+		}
+		st.Categories = append(st.Categories, c)
+		st.Leaves += c.Count
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range st.Categories {
+		st.Categories[i].Share = float64(st.Categories[i].Count) / float64(st.Leaves)
+	}
+
+	err = db.QueryRow(`
+		SELECT COUNT(v.node_id),
+			COALESCE(AVG(n.relevance), 0), COALESCE(MIN(n.relevance), 0), COALESCE(MAX(n.relevance), 0)
+		FROM mem_nodes n LEFT JOIN mem_vectors v ON v.node_id = n.id
+		WHERE n.node_type = 'leaf' AND n.tombstoned_at IS NULL
+	`).Scan(&st.Embedded, &st.RelevanceAvg, &st.RelevanceMin, &st.RelevanceMax)
+	if err != nil {
+		return nil, fmt.Errorf("vector coverage: %w", err)
+	}
+
+	corrupt, err := db.CorruptVectors()
+	if err != nil {
+		return nil, err
+	}
+	st.Corrupt = len(corrupt)
+
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM mem_nodes WHERE node_type = 'leaf' AND tombstoned_at IS NOT NULL
+	`).Scan(&st.Retracted)
+	if err != nil {
+		return nil, fmt.Errorf("count retracted: %w", err)
+	}
+
+	rows, err = db.Query(`SELECT status, COUNT(*) FROM sessions GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("count sessions: %w", err)
+	}
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan session count: %w", err)
+		}
+		st.Sessions[status] = n
+		st.SessionsTotal += n
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = db.QueryRow(`SELECT COUNT(*) FROM sessions WHERE extracted_at IS NOT NULL`).Scan(&st.SessionsExtracted)
+	if err != nil {
+		return nil, fmt.Errorf("count extracted sessions: %w", err)
+	}
+	return st, nil
+}
+
+// FileSize is the on-disk size of the database at path, counting its WAL.
+func FileSize(path string) int64 {
+	return fileSize(path) + fileSize(path+"-wal")
+}
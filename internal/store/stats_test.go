@@ -0,0 +1,48 @@
+package store
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	db := testDB(t)
+	for _, n := range []*MemNode{
+		{URI: "mem://user/preferences/a", NodeType: "leaf", Category: "preferences", L0Abstract: "a"},
+		{URI: "mem://user/preferences/b", NodeType: "leaf", Category: "preferences", L0Abstract: "b"},
+		{URI: "mem://agent/patterns/c", NodeType: "leaf", Category: "patterns", L0Abstract: "c"},
+	} {
+		if err := db.CreateNode(n); err != nil {
+			t.Fatalf("create %s: %v", n.URI, err)
+		}
+	}
+	// CreateNode starts everything at 1.0; age one node's relevance.
+	if _, err := db.Exec(`UPDATE mem_nodes SET relevance = 0.3 WHERE uri = 'mem://agent/patterns/c'`); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := db.GetNodeByURI("mem://user/preferences/a")
+	if err := db.SaveVector(a.ID, []float64{1, 0}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	db.InitSession("s1", "/tmp/p")
+	db.InitSession("s2", "/tmp/p")
+	db.CompleteSession("s2")
+	db.MarkExtracted("s2")
+
+	st, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if st.Leaves != 3 || st.Embedded != 1 {
+		t.Errorf("leaves/embedded = %d/%d, want 3/1", st.Leaves, st.Embedded)
+	}
+	if len(st.Categories) != 2 || st.Categories[0].Category != "preferences" || st.Categories[0].Count != 2 {
+		t.Errorf("categories = %+v, want preferences first with 2", st.Categories)
+	}
+	if st.RelevanceMin != 0.3 || st.RelevanceMax != 1.0 {
+		t.Errorf("relevance min/max = %v/%v, want 0.3/1.0", st.RelevanceMin, st.RelevanceMax)
+	}
+	if st.SessionsTotal != 2 || st.Sessions["active"] != 1 || st.Sessions["completed"] != 1 || st.SessionsExtracted != 1 {
+		t.Errorf("sessions = %d %v (%d extracted), want 2, 1 active + 1 completed, 1 extracted", st.SessionsTotal, st.Sessions, st.SessionsExtracted)
+	}
+	if st.SchemaVersion != headVersion() {
+		t.Errorf("schema version = %d, want %d", st.SchemaVersion, headVersion())
+	}
+}
@@ -1,10 +1,15 @@
 package store
 
 import (
+	"crypto/sha256"
 	"database/sql"
 	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
 	"math"
+	"slices"
 	"time"
 )
 
@@ -15,6 +20,20 @@ type VectorRecord struct {
 	Model      string
 	Dimensions int
 	CreatedAt  int64
+	TextHash   string // TextHash of the embedded text; "" when not recorded
+}
+
+// TextHash fingerprints the text a vector was embedded from, so a vector left
+// behind by an edit to its node's L0 can be told apart from a current one.
+func TextHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Stale reports whether v was embedded from text other than text. A vector
+// with no recorded hash predates the check and is taken as current.
+func (v *VectorRecord) Stale(text string) bool {
+	return v.TextHash != "" && v.TextHash != TextHash(text)
 }
 
 // encodeEmbedding converts a []float64 to a binary BLOB (8 bytes per float64).
@@ -26,56 +45,131 @@ func encodeEmbedding(vec []float64) []byte {
 	return buf
 }
 
-// decodeEmbedding converts a binary BLOB back to []float64.
-func decodeEmbedding(buf []byte) []float64 {
+// ErrCorruptVector marks a stored embedding whose BLOB can't be a vector of
+// its recorded dimension — a truncated write or a foreign encoding. Such a
+// vector would score 0 against everything and silently hide its node.
+var ErrCorruptVector = errors.New("corrupt vector")
+
+// decodeEmbedding converts a binary BLOB back to []float64, checking it holds
+// exactly dims float64s.
+func decodeEmbedding(buf []byte, dims int) ([]float64, error) {
+	if len(buf)%8 != 0 || len(buf)/8 != dims {
+		return nil, fmt.Errorf("%w: %d bytes for %d dimensions", ErrCorruptVector, len(buf), dims)
+	}
 	n := len(buf) / 8
 	vec := make([]float64, n)
 	for i := 0; i < n; i++ {
 		vec[i] = math.Float64frombits(binary.LittleEndian.Uint64(buf[i*8:]))
 	}
-	return vec
+	return vec, nil
 }
 
-// SaveVector stores or replaces the embedding for a node.
+// SaveVector stores or replaces the embedding for a node, without a record of
+// the text it came from. Prefer SaveVectorFor.
 func (db *DB) SaveVector(nodeID int64, embedding []float64, model string) error {
+	return db.saveVector(nodeID, embedding, model, "")
+}
+
+// SaveVectorFor stores or replaces the embedding for a node along with the
+// TextHash of text, the content it was embedded from.
+func (db *DB) SaveVectorFor(nodeID int64, embedding []float64, model, text string) error {
+	return db.saveVector(nodeID, embedding, model, TextHash(text))
+}
+
+func (db *DB) saveVector(nodeID int64, embedding []float64, model, textHash string) error {
 	now := time.Now().UnixMilli()
 	blob := encodeEmbedding(embedding)
 
 	_, err := db.Exec(`
-		INSERT INTO mem_vectors (node_id, embedding, model, dimensions, created_at)
-		VALUES (?, ?, ?, ?, ?)
-		ON CONFLICT(node_id) DO UPDATE SET embedding = ?, model = ?, dimensions = ?, created_at = ?
-	`, nodeID, blob, model, len(embedding), now,
-		blob, model, len(embedding), now)
+		INSERT INTO mem_vectors (node_id, embedding, model, dimensions, created_at, text_hash)
+		VALUES (?, ?, ?, ?, ?, NULLIF(?, ''))
+		ON CONFLICT(node_id) DO UPDATE SET embedding = ?, model = ?, dimensions = ?, created_at = ?, text_hash = NULLIF(?, '')
+	`, nodeID, blob, model, len(embedding), now, textHash,
+		blob, model, len(embedding), now, textHash)
 	if err != nil {
 		return fmt.Errorf("save vector: %w", err)
 	}
 	return nil
 }
 
+// lexicalModels are the embedding spaces of the hashed keyword fallback:
+// usable, but worse than any neural model at recall.
+var lexicalModels = []string{"hashtf", "hashtf-stem", "tfidf"}
+
+// ModelRank orders embedding models by quality for SaveVectorIfAbsent: the
+// lexical fallback ranks 0, neural models (e.g. "ollama:nomic-embed-text") 1.
+func ModelRank(model string) int {
+	if slices.Contains(lexicalModels, model) {
+		return 0
+	}
+	return 1
+}
+
+// SaveVectorIfAbsent is SaveVectorFor for backfill paths (dedup embedding
+// nodes that lack a vector) that may be running on a degraded embedder. It
+// is wrong after a content change, where a refusal would keep a vector of
+// the old content. It writes only when the node has no vector, the
+// stored vector is from the same model (a refresh, not a downgrade), or
+// model ranks above the stored one (ModelRank). A better vector is never
+// replaced by a worse one; left stale, it is refreshed by EmbedMissing once
+// its embedder is back. Reports whether it wrote.
+func (db *DB) SaveVectorIfAbsent(nodeID int64, embedding []float64, model, text string) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("save vector: %w", err)
+	}
+	defer tx.Rollback()
+
+	var existing string
+	err = tx.QueryRow(`SELECT model FROM mem_vectors WHERE node_id = ?`, nodeID).Scan(&existing)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return false, fmt.Errorf("save vector: %w", err)
+	case existing != model && ModelRank(model) <= ModelRank(existing):
+		return false, nil
+	}
+
+	now := time.Now().UnixMilli()
+	blob := encodeEmbedding(embedding)
+	textHash := TextHash(text)
+	if _, err := tx.Exec(`
+		INSERT INTO mem_vectors (node_id, embedding, model, dimensions, created_at, text_hash)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(node_id) DO UPDATE SET embedding = ?, model = ?, dimensions = ?, created_at = ?, text_hash = ?
+	`, nodeID, blob, model, len(embedding), now, textHash,
+		blob, model, len(embedding), now, textHash); err != nil {
+		return false, fmt.Errorf("save vector: %w", err)
+	}
+	return true, tx.Commit()
+}
+
 // GetVector returns the embedding for a node, or nil if not found.
 func (db *DB) GetVector(nodeID int64) (*VectorRecord, error) {
 	var v VectorRecord
 	var blob []byte
 
 	err := db.QueryRow(`
-		SELECT node_id, embedding, model, dimensions, created_at
+		SELECT node_id, embedding, model, dimensions, created_at, COALESCE(text_hash, '')
 		FROM mem_vectors WHERE node_id = ?
-	`, nodeID).Scan(&v.NodeID, &blob, &v.Model, &v.Dimensions, &v.CreatedAt)
+	`, nodeID).Scan(&v.NodeID, &blob, &v.Model, &v.Dimensions, &v.CreatedAt, &v.TextHash)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("get vector: %w", err)
 	}
-	v.Embedding = decodeEmbedding(blob)
+	if v.Embedding, err = decodeEmbedding(blob, v.Dimensions); err != nil {
+		return nil, fmt.Errorf("vector for node %d: %w", nodeID, err)
+	}
 	return &v, nil
 }
 
-// AllVectors returns all stored vector records.
+// AllVectors returns all stored vector records. Corrupt records are logged
+// and skipped rather than failing the whole load; CorruptVectors counts them.
 func (db *DB) AllVectors() ([]VectorRecord, error) {
 	rows, err := db.Query(`
-		SELECT node_id, embedding, model, dimensions, created_at
+		SELECT node_id, embedding, model, dimensions, created_at, COALESCE(text_hash, '')
 		FROM mem_vectors
 	`)
 	if err != nil {
@@ -87,15 +181,78 @@ func (db *DB) AllVectors() ([]VectorRecord, error) {
 	for rows.Next() {
 		var v VectorRecord
 		var blob []byte
-		if err := rows.Scan(&v.NodeID, &blob, &v.Model, &v.Dimensions, &v.CreatedAt); err != nil {
+		if err := rows.Scan(&v.NodeID, &blob, &v.Model, &v.Dimensions, &v.CreatedAt, &v.TextHash); err != nil {
 			return nil, fmt.Errorf("scan vector: %w", err)
 		}
-		v.Embedding = decodeEmbedding(blob)
+		emb, err := decodeEmbedding(blob, v.Dimensions)
+		if err != nil {
+			log.Printf("vectors: skipping node %d: %v (run `continuity doctor --repair-vectors`)", v.NodeID, err)
+			continue
+		}
+		v.Embedding = emb
 		records = append(records, v)
 	}
 	return records, rows.Err()
 }
 
+// SearchVectorsByCategory returns the vectors of live (non-retracted) leaf
+// nodes in category, filtering in SQL rather than loading every vector and
+// node the way AllVectors callers must. This is the similarity gate's working
+// set during extraction; retracted nodes are excluded because they must never
+// become a merge target.
+func (db *DB) SearchVectorsByCategory(category string) ([]VectorRecord, error) {
+	rows, err := db.Query(`
+		SELECT v.node_id, v.embedding, v.model, v.dimensions, v.created_at, COALESCE(v.text_hash, '')
+		FROM mem_vectors v
+		JOIN mem_nodes n ON n.id = v.node_id
+		WHERE n.category = ? AND n.node_type = 'leaf' AND n.tombstoned_at IS NULL
+	`, category)
+	if err != nil {
+		return nil, fmt.Errorf("vectors by category: %w", err)
+	}
+	defer rows.Close()
+
+	var records []VectorRecord
+	for rows.Next() {
+		var v VectorRecord
+		var blob []byte
+		if err := rows.Scan(&v.NodeID, &blob, &v.Model, &v.Dimensions, &v.CreatedAt, &v.TextHash); err != nil {
+			return nil, fmt.Errorf("scan vector: %w", err)
+		}
+		emb, err := decodeEmbedding(blob, v.Dimensions)
+		if err != nil {
+			log.Printf("vectors: skipping node %d: %v (run `continuity doctor --repair-vectors`)", v.NodeID, err)
+			continue
+		}
+		v.Embedding = emb
+		records = append(records, v)
+	}
+	return records, rows.Err()
+}
+
+// CorruptVectors returns the node IDs whose stored embedding fails
+// decodeEmbedding's length check. The check runs in SQL, so no BLOB is read.
+func (db *DB) CorruptVectors() ([]int64, error) {
+	rows, err := db.Query(`
+		SELECT node_id FROM mem_vectors
+		WHERE length(embedding) % 8 != 0 OR length(embedding) / 8 != dimensions
+		ORDER BY node_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt vectors: %w", err)
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan corrupt vector: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 // DeleteVector removes the embedding for a node.
 func (db *DB) DeleteVector(nodeID int64) error {
 	_, err := db.Exec("DELETE FROM mem_vectors WHERE node_id = ?", nodeID)
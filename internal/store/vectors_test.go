@@ -1,6 +1,7 @@
 package store
 
 import (
+	"errors"
 	"math"
 	"testing"
 )
@@ -8,7 +9,10 @@ import (
 func TestEncodeDecodeEmbedding(t *testing.T) {
 	original := []float64{1.0, -0.5, 0.333, math.Pi, 0.0}
 	blob := encodeEmbedding(original)
-	decoded := decodeEmbedding(blob)
+	decoded, err := decodeEmbedding(blob, len(original))
+	if err != nil {
+		t.Fatalf("decodeEmbedding: %v", err)
+	}
 
 	if len(decoded) != len(original) {
 		t.Fatalf("length mismatch: %d vs %d", len(decoded), len(original))
@@ -20,6 +24,22 @@ func TestEncodeDecodeEmbedding(t *testing.T) {
 	}
 }
 
+func TestDecodeEmbeddingRejectsBadLength(t *testing.T) {
+	blob := encodeEmbedding([]float64{1, 2, 3})
+	for name, tc := range map[string]struct {
+		blob []byte
+		dims int
+	}{
+		"truncated":    {blob[:20], 3},
+		"wrong dims":   {blob, 4},
+		"float32 blob": {blob[:12], 3},
+	} {
+		if _, err := decodeEmbedding(tc.blob, tc.dims); !errors.Is(err, ErrCorruptVector) {
+			t.Errorf("%s: err = %v, want ErrCorruptVector", name, err)
+		}
+	}
+}
+
 func TestSaveAndGetVector(t *testing.T) {
 	db := testDB(t)
 
@@ -83,6 +103,36 @@ func TestSaveVectorReplace(t *testing.T) {
 	}
 }
 
+func TestSaveVectorIfAbsentNeverDowngrades(t *testing.T) {
+	db := testDB(t)
+	node := &MemNode{URI: "mem://user/profile/coding-style", NodeType: "leaf", Category: "profile"}
+	db.CreateNode(node)
+
+	steps := []struct {
+		vec   []float64
+		model string
+		wrote bool
+	}{
+		{[]float64{1, 0}, "hashtf", true},                         // nothing stored yet
+		{[]float64{0, 1}, "hashtf", true},                         // same model: a refresh
+		{[]float64{0.5, 0.5, 0}, "ollama:nomic-embed-text", true}, // an upgrade
+		{[]float64{1, 0}, "hashtf", false},                        // a downgrade
+		{[]float64{0, 0, 1}, "ollama:mxbai-embed-large", false},   // a different space of equal rank
+	}
+	for i, st := range steps {
+		wrote, err := db.SaveVectorIfAbsent(node.ID, st.vec, st.model, "text")
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if wrote != st.wrote {
+			t.Errorf("step %d (%s): wrote = %v, want %v", i, st.model, wrote, st.wrote)
+		}
+	}
+	if v, _ := db.GetVector(node.ID); v.Model != "ollama:nomic-embed-text" || v.Dimensions != 3 {
+		t.Errorf("stored vector = %s/%d, want the neural one kept", v.Model, v.Dimensions)
+	}
+}
+
 func TestGetVectorNotFound(t *testing.T) {
 	db := testDB(t)
 
@@ -131,3 +181,73 @@ func TestDeleteVector(t *testing.T) {
 		t.Error("expected nil after delete")
 	}
 }
+
+func TestSearchVectorsByCategory(t *testing.T) {
+	db := testDB(t)
+
+	mk := func(uri, category string) *MemNode {
+		t.Helper()
+		n := &MemNode{URI: uri, NodeType: "leaf", Category: category, L0Abstract: uri}
+		if err := db.CreateNode(n); err != nil {
+			t.Fatalf("CreateNode %s: %v", uri, err)
+		}
+		if err := db.SaveVector(n.ID, []float64{1, 0}, "test-model"); err != nil {
+			t.Fatalf("SaveVector %s: %v", uri, err)
+		}
+		return n
+	}
+	live := mk("mem://user/preferences/live", "preferences")
+	mk("mem://user/preferences/retracted", "preferences")
+	mk("mem://user/events/other-category", "events")
+	if _, err := db.RetractNode("mem://user/preferences/retracted", "test", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := db.SearchVectorsByCategory("preferences")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].NodeID != live.ID {
+		t.Errorf("got %+v, want only the live preferences vector (node %d)", got, live.ID)
+	}
+	if len(got) == 1 && len(got[0].Embedding) != 2 {
+		t.Errorf("embedding not decoded: %v", got[0].Embedding)
+	}
+}
+
+func TestCorruptVectorsSkipped(t *testing.T) {
+	db := testDB(t)
+	var ids []int64
+	for _, uri := range []string{"mem://user/events/good", "mem://user/events/bad"} {
+		n := &MemNode{URI: uri, NodeType: "leaf", Category: "events", L0Abstract: uri}
+		if err := db.CreateNode(n); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SaveVector(n.ID, []float64{0.1, 0.2, 0.3}, "test-model"); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, n.ID)
+	}
+	// A truncated write: 5 bytes where 3 float64s belong.
+	if _, err := db.Exec(`UPDATE mem_vectors SET embedding = x'0102030405' WHERE node_id = ?`, ids[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := db.AllVectors()
+	if err != nil {
+		t.Fatalf("AllVectors: %v", err)
+	}
+	if len(all) != 1 || all[0].NodeID != ids[0] {
+		t.Errorf("AllVectors = %d records, want only the intact one", len(all))
+	}
+	if _, err := db.GetVector(ids[1]); !errors.Is(err, ErrCorruptVector) {
+		t.Errorf("GetVector(corrupt) err = %v, want ErrCorruptVector", err)
+	}
+	corrupt, err := db.CorruptVectors()
+	if err != nil || len(corrupt) != 1 || corrupt[0] != ids[1] {
+		t.Errorf("CorruptVectors = %v, %v; want [%d]", corrupt, err, ids[1])
+	}
+	if st, _ := db.Stats(); st == nil || st.Corrupt != 1 {
+		t.Errorf("Stats().Corrupt = %+v, want 1", st)
+	}
+}
@@ -1,7 +1,9 @@
 package transcript
 
 import (
+	"fmt"
 	"strings"
+	"unicode/utf8"
 )
 
 const (
@@ -16,7 +18,7 @@ const (
 // - Mid assistant: up to 200 chars + "..."
 // - Drop tool_use/tool_result blocks (already filtered by extractText)
 // - Strip <system-reminder> tags (done in parsing)
-// - Skip entries < 5 chars or starting with `{` (done in parsing)
+// - Skip short assistant entries and ones starting with `{` (done in parsing)
 func Condense(entries []ParsedEntry) string {
 	if len(entries) == 0 {
 		return ""
@@ -68,3 +70,44 @@ func Condense(entries []ParsedEntry) string {
 
 	return strings.TrimSpace(b.String())
 }
+
+// Truncate caps a condensed transcript at max bytes, keeping its head and
+// tail — the opening request and where the session ended up carry the most
+// signal — and marking what was cut. Cuts fall on paragraph (entry) breaks
+// where one is near. max <= 0 means no cap.
+func Truncate(condensed string, max int) string {
+	if max <= 0 || len(condensed) <= max {
+		return condensed
+	}
+	marker := "\n\n[... %d chars omitted ...]\n\n"
+	budget := max - len(fmt.Sprintf(marker, len(condensed)))
+	if budget <= 0 {
+		return cutAt(condensed, max)
+	}
+	head := cutAt(condensed, budget/2)
+	if i := strings.LastIndex(head, "\n\n"); i > len(head)/2 {
+		head = head[:i]
+	}
+	tailStart := len(condensed) - (budget - len(head))
+	for tailStart < len(condensed) && !utf8.RuneStart(condensed[tailStart]) {
+		tailStart++
+	}
+	tail := condensed[tailStart:]
+	if i := strings.Index(tail, "\n\n"); i >= 0 && i < len(tail)/2 {
+		tail = tail[i+2:]
+	}
+	omitted := len(condensed) - len(head) - len(tail)
+	return head + fmt.Sprintf(marker, omitted) + tail
+}
+
+// cutAt returns the longest prefix of s no longer than n bytes that doesn't
+// split a rune.
+func cutAt(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -2,8 +2,11 @@ package transcript
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
@@ -34,18 +37,56 @@ type ParsedEntry struct {
 	Text string // extracted plain text
 }
 
+// gzipMagic is the two-byte header every gzip stream starts with.
+var gzipMagic = []byte{0x1f, 0x8b}
+
 var systemReminderRe = regexp.MustCompile(`<system-reminder>[\s\S]*?</system-reminder>`)
 
+// ParseOpts tunes which entries parsing keeps.
+type ParseOpts struct {
+	// MinLength drops assistant entries shorter than this many bytes —
+	// "Done.", "OK" and other acknowledgement noise. User entries are kept at
+	// any length: a short correction ("use go 1.22") is often the signal.
+	MinLength int
+
+	// KeepJSON keeps entries whose text starts with '{', which are otherwise
+	// dropped as tool payloads echoed into the conversation.
+	KeepJSON bool
+}
+
+// DefaultParseOpts are the options ParseFile and ParseLines use.
+func DefaultParseOpts() ParseOpts {
+	return ParseOpts{MinLength: 5}
+}
+
 // ParseFile reads a JSONL transcript file and returns parsed entries.
+// Gzipped transcripts (archived .jsonl.gz) are detected by their magic bytes,
+// whatever the file is named, and decompressed transparently.
 func ParseFile(path string) ([]ParsedEntry, error) {
+	return ParseFileOpts(path, DefaultParseOpts())
+}
+
+// ParseFileOpts is ParseFile with explicit skip rules.
+func ParseFileOpts(path string, opts ParseOpts) ([]ParsedEntry, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("open transcript: %w", err)
 	}
 	defer f.Close()
 
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, _ := br.Peek(2); bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("open gzipped transcript: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
 	var entries []ParsedEntry
-	scanner := bufio.NewScanner(f)
+	scanner := bufio.NewScanner(r)
 	scanner.Buffer(make([]byte, 1024*1024), 1024*1024) // 1MB line buffer
 
 	for scanner.Scan() {
@@ -54,7 +95,7 @@ func ParseFile(path string) ([]ParsedEntry, error) {
 			continue
 		}
 
-		entry, err := parseLine(line)
+		entry, err := parseLine(line, opts)
 		if err != nil {
 			continue // skip malformed lines
 		}
@@ -72,6 +113,11 @@ func ParseFile(path string) ([]ParsedEntry, error) {
 
 // ParseLines parses transcript content from a string (for testing).
 func ParseLines(content string) ([]ParsedEntry, error) {
+	return ParseLinesOpts(content, DefaultParseOpts())
+}
+
+// ParseLinesOpts is ParseLines with explicit skip rules.
+func ParseLinesOpts(content string, opts ParseOpts) ([]ParsedEntry, error) {
 	var entries []ParsedEntry
 	for _, line := range strings.Split(content, "\n") {
 		line = strings.TrimSpace(line)
@@ -79,7 +125,7 @@ func ParseLines(content string) ([]ParsedEntry, error) {
 			continue
 		}
 
-		entry, err := parseLine([]byte(line))
+		entry, err := parseLine([]byte(line), opts)
 		if err != nil {
 			continue
 		}
@@ -90,7 +136,7 @@ func ParseLines(content string) ([]ParsedEntry, error) {
 	return entries, nil
 }
 
-func parseLine(line []byte) (*ParsedEntry, error) {
+func parseLine(line []byte, opts ParseOpts) (*ParsedEntry, error) {
 	var entry Entry
 	if err := json.Unmarshal(line, &entry); err != nil {
 		return nil, err
@@ -109,10 +155,13 @@ func parseLine(line []byte) (*ParsedEntry, error) {
 	text = systemReminderRe.ReplaceAllString(text, "")
 	text = strings.TrimSpace(text)
 
-	if len(text) < 5 {
+	if text == "" {
+		return nil, nil
+	}
+	if entry.Type != "user" && len(text) < opts.MinLength {
 		return nil, nil
 	}
-	if strings.HasPrefix(text, "{") {
+	if !opts.KeepJSON && strings.HasPrefix(text, "{") {
 		return nil, nil
 	}
 
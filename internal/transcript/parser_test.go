@@ -1,6 +1,11 @@
 package transcript
 
 import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -31,6 +36,37 @@ func TestParseLines(t *testing.T) {
 	}
 }
 
+func TestParseFileGzip(t *testing.T) {
+	lines := `{"type":"user","message":{"role":"user","content":"Hello, help me with Go code"}}
+{"type":"assistant","message":{"role":"assistant","content":"Sure, I can help with Go."}}
+`
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(lines))
+	zw.Close()
+
+	dir := t.TempDir()
+	// Detection is by content, so a gzip stream without the .gz suffix and
+	// a plain file both parse.
+	for name, data := range map[string][]byte{
+		"archived.jsonl.gz": buf.Bytes(),
+		"misnamed.jsonl":    buf.Bytes(),
+		"plain.jsonl":       []byte(lines),
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		entries, err := ParseFile(path)
+		if err != nil {
+			t.Fatalf("ParseFile(%s): %v", name, err)
+		}
+		if len(entries) != 2 || entries[1].Text != "Sure, I can help with Go." {
+			t.Errorf("ParseFile(%s) = %+v, want 2 entries", name, entries)
+		}
+	}
+}
+
 func TestParseLinesContentArray(t *testing.T) {
 	lines := `{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Here is the code:"},{"type":"tool_use","id":"tu_1","name":"Write"}]}}`
 
@@ -48,8 +84,9 @@ func TestParseLinesContentArray(t *testing.T) {
 }
 
 func TestParseLinesSkipsShort(t *testing.T) {
-	lines := `{"type":"user","message":{"role":"user","content":"ok"}}
-{"type":"user","message":{"role":"user","content":"yes"}}
+	lines := `{"type":"assistant","message":{"role":"assistant","content":"ok"}}
+{"type":"assistant","message":{"role":"assistant","content":"Done"}}
+{"type":"user","message":{"role":"user","content":"1.22"}}
 {"type":"user","message":{"role":"user","content":"This is a real message"}}`
 
 	entries, err := ParseLines(lines)
@@ -57,9 +94,24 @@ func TestParseLinesSkipsShort(t *testing.T) {
 		t.Fatalf("ParseLines: %v", err)
 	}
 
-	// "ok" and "yes" are < 5 chars, should be skipped
-	if len(entries) != 1 {
-		t.Fatalf("expected 1 entry (skipping short), got %d", len(entries))
+	// Short assistant acknowledgements (< 5 chars) are noise; a short user
+	// message can be a correction and is kept.
+	if len(entries) != 2 || entries[0].Text != "1.22" {
+		t.Fatalf("expected the 2 user entries, got %+v", entries)
+	}
+}
+
+func TestParseLinesOpts(t *testing.T) {
+	lines := `{"type":"assistant","message":{"role":"assistant","content":"Fixed."}}
+{"type":"user","message":{"role":"user","content":"{\"json\":\"data\"}"}}`
+
+	entries, _ := ParseLinesOpts(lines, ParseOpts{MinLength: 10})
+	if len(entries) != 0 {
+		t.Errorf("MinLength 10: expected nothing kept, got %+v", entries)
+	}
+	entries, _ = ParseLinesOpts(lines, ParseOpts{KeepJSON: true})
+	if len(entries) != 2 {
+		t.Errorf("KeepJSON: expected both entries, got %+v", entries)
 	}
 }
 
@@ -124,6 +176,42 @@ func TestCountUserMessages(t *testing.T) {
 	}
 }
 
+func TestResolvePath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	project := t.TempDir()
+	for _, p := range []string{
+		filepath.Join(home, "t.jsonl"),
+		filepath.Join(project, "rel", "s.jsonl"),
+		filepath.Join(project, "old.jsonl.gz"),
+	} {
+		os.MkdirAll(filepath.Dir(p), 0o700)
+		if err := os.WriteFile(p, []byte("{}\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct{ in, want string }{
+		{"~/t.jsonl", filepath.Join(home, "t.jsonl")},
+		{filepath.Join(home, "t.jsonl"), filepath.Join(home, "t.jsonl")},
+		{"rel/s.jsonl", filepath.Join(project, "rel", "s.jsonl")},
+		{"t.jsonl", filepath.Join(home, "t.jsonl")},
+		{"old.jsonl", filepath.Join(project, "old.jsonl.gz")},
+	}
+	for _, tt := range tests {
+		got, err := ResolvePath(tt.in, project)
+		if err != nil || got != tt.want {
+			t.Errorf("ResolvePath(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	_, err := ResolvePath("missing.jsonl", project)
+	if err == nil || !strings.Contains(err.Error(), filepath.Join(project, "missing.jsonl")) ||
+		!strings.Contains(err.Error(), filepath.Join(home, "missing.jsonl")) {
+		t.Errorf("missing transcript: err = %v, want the paths tried", err)
+	}
+}
+
 func TestCondense(t *testing.T) {
 	entries := []ParsedEntry{
 		{Type: "user", Text: "Help me write Go code"},
@@ -178,3 +266,35 @@ func TestCondenseEmpty(t *testing.T) {
 		t.Errorf("expected empty string for empty, got %q", result)
 	}
 }
+
+func TestTruncate(t *testing.T) {
+	var paras []string
+	for i := 0; i < 200; i++ {
+		paras = append(paras, fmt.Sprintf("[USER] message number %d with some words", i))
+	}
+	condensed := strings.Join(paras, "\n\n")
+
+	if got := Truncate(condensed, 0); got != condensed {
+		t.Error("max 0 should leave the transcript alone")
+	}
+	if got := Truncate(condensed, len(condensed)); got != condensed {
+		t.Error("a transcript within budget should be unchanged")
+	}
+
+	got := Truncate(condensed, 1000)
+	if len(got) > 1000 {
+		t.Errorf("truncated to %d bytes, want <= 1000", len(got))
+	}
+	if !strings.HasPrefix(got, "[USER] message number 0 ") || !strings.HasSuffix(got, "message number 199 with some words") {
+		t.Errorf("head and tail should survive:\n%s", got)
+	}
+	if !strings.Contains(got, "chars omitted") {
+		t.Errorf("missing omission marker:\n%s", got)
+	}
+	// Cuts land on entry breaks: no partial "[USER]" entries either side.
+	for _, p := range strings.Split(got, "\n\n") {
+		if !strings.HasPrefix(p, "[USER] ") && !strings.HasPrefix(p, "[... ") {
+			t.Errorf("partial entry %q", p)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package transcript
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ResolvePath turns a transcript path as a hook sent it into one that exists.
+// Hooks run under whatever shell or launchd environment Claude Code inherited,
+// so the path may arrive with a leading ~ or relative to the session's working
+// directory rather than ours. Candidates, in order: the path with ~ expanded;
+// if relative, joined onto each of dirs (typically the session's project) and
+// then onto the home directory and the process CWD. Each candidate is also
+// tried with a .gz suffix, for transcripts archived after the hook fired.
+//
+// Best effort: it returns the first candidate that exists, or an error listing
+// every path tried.
+func ResolvePath(path string, dirs ...string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", fmt.Errorf("no transcript path provided")
+	}
+	home, _ := os.UserHomeDir()
+	path = expandHome(path, home)
+
+	var bases []string
+	if filepath.IsAbs(path) {
+		bases = []string{path}
+	} else {
+		for _, d := range dirs {
+			if d = expandHome(strings.TrimSpace(d), home); filepath.IsAbs(d) {
+				bases = append(bases, filepath.Join(d, path))
+			}
+		}
+		if home != "" {
+			bases = append(bases, filepath.Join(home, path))
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			bases = append(bases, abs)
+		}
+	}
+
+	var tried []string
+	seen := map[string]bool{}
+	for _, b := range bases {
+		for _, c := range []string{filepath.Clean(b), filepath.Clean(b) + ".gz"} {
+			if seen[c] {
+				continue
+			}
+			seen[c] = true
+			tried = append(tried, c)
+			if fi, err := os.Stat(c); err == nil && fi.Mode().IsRegular() {
+				return c, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("transcript not found; tried: %s", strings.Join(tried, ", "))
+}
+
+// expandHome replaces a leading ~ or ~/ with home. Other ~user forms are left
+// alone; there's no portable way to resolve them.
+func expandHome(path, home string) string {
+	if home == "" {
+		return path
+	}
+	if path == "~" {
+		return home
+	}
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(home, path[2:])
+	}
+	return path
+}